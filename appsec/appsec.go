@@ -13,11 +13,13 @@ package appsec
 
 import (
 	"context"
+	"errors"
 	"sync"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/grpcsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/httpsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/sharedsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
@@ -48,6 +50,7 @@ func MonitorParsedHTTPBody(ctx context.Context, body interface{}) error {
 // When an error is returned, the caller must immediately abort its execution and the
 // request handler's. The blocking response will be automatically sent by the
 // APM tracer middleware on use according to your blocking configuration.
+// Such errors can be recognized with IsSecurityError().
 // This function always returns nil when appsec is disabled and doesn't block users.
 func SetUser(ctx context.Context, id string, opts ...tracer.UserMonitoringOption) error {
 	s, ok := tracer.SpanFromContext(ctx)
@@ -63,6 +66,17 @@ func SetUser(ctx context.Context, id string, opts ...tracer.UserMonitoringOption
 	return sharedsec.MonitorUser(ctx, id)
 }
 
+// IsSecurityError returns true if the given error, or any error it wraps, was
+// returned by an appsec function such as SetUser() or MonitorParsedHTTPBody()
+// to signal that the request is blocked. The caller must then immediately abort
+// its execution and the request handler's, as the blocking response is sent by
+// the APM tracer middleware.
+func IsSecurityError(err error) bool {
+	var httpErr *httpsec.MonitoringError
+	var grpcErr *grpcsec.MonitoringError
+	return errors.As(err, &httpErr) || errors.As(err, &grpcErr)
+}
+
 // TrackUserLoginSuccessEvent sets a successful user login event, with the given
 // user id and optional metadata, as service entry span tags. It also calls
 // SetUser() to set the currently authenticated user, along with the given
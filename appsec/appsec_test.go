@@ -7,12 +7,16 @@ package appsec_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/appsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 	privateAppsec "gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/grpcsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/httpsec"
 
 	"github.com/stretchr/testify/require"
 )
@@ -167,6 +171,14 @@ func TestSetUser(t *testing.T) {
 	})
 }
 
+func TestIsSecurityError(t *testing.T) {
+	require.True(t, appsec.IsSecurityError(httpsec.NewMonitoringError("Request blocked")))
+	require.True(t, appsec.IsSecurityError(grpcsec.NewMonitoringError("Request blocked", 10)))
+	require.True(t, appsec.IsSecurityError(fmt.Errorf("login: %w", httpsec.NewMonitoringError("Request blocked"))))
+	require.False(t, appsec.IsSecurityError(errors.New("not a security error")))
+	require.False(t, appsec.IsSecurityError(nil))
+}
+
 func ExampleTrackUserLoginSuccessEvent() {
 	// Create an example span and set a user login success appsec event example to it.
 	span, ctx := tracer.StartSpanFromContext(context.Background(), "example")
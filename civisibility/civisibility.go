@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package civisibility provides the building blocks used to report test runs to
+// Datadog CI Visibility. Go tests can be instrumented using the gotesting
+// subpackage.
+package civisibility // import "gopkg.in/DataDog/dd-trace-go.v1/civisibility"
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/osinfo"
+)
+
+// provider describes how to read the details of a pipeline from the environment
+// of a CI provider.
+type provider struct {
+	name string
+	// detect is the environment variable which is set when running on this provider.
+	detect string
+	// commit, branch, repository and workspace are the environment variables
+	// holding the respective values.
+	commit, branch, repository, workspace string
+	// pipelineURL returns the URL of the running pipeline.
+	pipelineURL func() string
+}
+
+var providers = []provider{
+	{
+		name:      "github",
+		detect:    "GITHUB_ACTIONS",
+		commit:    "GITHUB_SHA",
+		branch:    "GITHUB_REF",
+		workspace: "GITHUB_WORKSPACE",
+		pipelineURL: func() string {
+			return fmt.Sprintf("%s/%s/actions/runs/%s",
+				os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"))
+		},
+	},
+	{
+		name:        "gitlab",
+		detect:      "GITLAB_CI",
+		commit:      "CI_COMMIT_SHA",
+		branch:      "CI_COMMIT_REF_NAME",
+		repository:  "CI_REPOSITORY_URL",
+		workspace:   "CI_PROJECT_DIR",
+		pipelineURL: func() string { return os.Getenv("CI_PIPELINE_URL") },
+	},
+	{
+		name:        "circleci",
+		detect:      "CIRCLECI",
+		commit:      "CIRCLE_SHA1",
+		branch:      "CIRCLE_BRANCH",
+		repository:  "CIRCLE_REPOSITORY_URL",
+		workspace:   "CIRCLE_WORKING_DIRECTORY",
+		pipelineURL: func() string { return os.Getenv("CIRCLE_BUILD_URL") },
+	},
+	{
+		name:        "jenkins",
+		detect:      "JENKINS_URL",
+		commit:      "GIT_COMMIT",
+		branch:      "GIT_BRANCH",
+		repository:  "GIT_URL",
+		workspace:   "WORKSPACE",
+		pipelineURL: func() string { return os.Getenv("BUILD_URL") },
+	},
+	{
+		name:        "buildkite",
+		detect:      "BUILDKITE",
+		commit:      "BUILDKITE_COMMIT",
+		branch:      "BUILDKITE_BRANCH",
+		repository:  "BUILDKITE_REPO",
+		workspace:   "BUILDKITE_BUILD_CHECKOUT_PATH",
+		pipelineURL: func() string { return os.Getenv("BUILDKITE_BUILD_URL") },
+	},
+}
+
+// EnvironmentTags returns the tags describing the environment the tests run in:
+// the CI provider and pipeline, the git commit being tested, the operating system
+// and the Go runtime. The DD_GIT_COMMIT_SHA, DD_GIT_BRANCH and DD_GIT_REPOSITORY_URL
+// environment variables take precedence over the values found for the CI provider.
+func EnvironmentTags() map[string]string {
+	tags := map[string]string{
+		"os.platform":     runtime.GOOS,
+		"os.architecture": runtime.GOARCH,
+		"os.version":      osinfo.OSVersion(),
+		"runtime.name":    runtime.Compiler,
+		"runtime.version": runtime.Version(),
+	}
+	set := func(tag, env string) {
+		if env == "" {
+			return
+		}
+		if v := os.Getenv(env); v != "" {
+			tags[tag] = v
+		}
+	}
+	for _, p := range providers {
+		if _, ok := os.LookupEnv(p.detect); !ok {
+			continue
+		}
+		tags[ext.CIProviderName] = p.name
+		tags[ext.CIPipelineURL] = p.pipelineURL()
+		set(internal.TagCommitSha, p.commit)
+		set(ext.GitBranch, p.branch)
+		set(internal.TagRepositoryURL, p.repository)
+		set(ext.CIWorkspacePath, p.workspace)
+		break
+	}
+	set(internal.TagCommitSha, internal.EnvGitCommitSha)
+	set(ext.GitBranch, "DD_GIT_BRANCH")
+	set(internal.TagRepositoryURL, internal.EnvGitRepositoryURL)
+	if b, ok := tags[ext.GitBranch]; ok {
+		tags[ext.GitBranch] = strings.TrimPrefix(strings.TrimPrefix(b, "refs/heads/"), "origin/")
+	}
+	return tags
+}
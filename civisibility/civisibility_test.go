@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package civisibility
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+)
+
+func TestEnvironmentTags(t *testing.T) {
+	// make sure the provider of the environment running this test isn't detected
+	for _, p := range providers {
+		if v, ok := os.LookupEnv(p.detect); ok {
+			os.Unsetenv(p.detect)
+			defer os.Setenv(p.detect, v)
+		}
+	}
+
+	t.Run("github", func(t *testing.T) {
+		assert := assert.New(t)
+		t.Setenv("GITHUB_ACTIONS", "true")
+		t.Setenv("GITHUB_SHA", "abc123")
+		t.Setenv("GITHUB_REF", "refs/heads/main")
+		t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+		t.Setenv("GITHUB_REPOSITORY", "DataDog/dd-trace-go")
+		t.Setenv("GITHUB_RUN_ID", "42")
+		tags := EnvironmentTags()
+		assert.Equal("github", tags[ext.CIProviderName])
+		assert.Equal("https://github.com/DataDog/dd-trace-go/actions/runs/42", tags[ext.CIPipelineURL])
+		assert.Equal("abc123", tags[internal.TagCommitSha])
+		assert.Equal("main", tags[ext.GitBranch])
+		assert.Equal(runtime.Version(), tags["runtime.version"])
+	})
+
+	t.Run("override", func(t *testing.T) {
+		assert := assert.New(t)
+		t.Setenv("GITLAB_CI", "true")
+		t.Setenv("CI_COMMIT_SHA", "abc123")
+		t.Setenv(internal.EnvGitCommitSha, "def456")
+		tags := EnvironmentTags()
+		assert.Equal("gitlab", tags[ext.CIProviderName])
+		assert.Equal("def456", tags[internal.TagCommitSha])
+	})
+
+	t.Run("none", func(t *testing.T) {
+		tags := EnvironmentTags()
+		assert.NotContains(t, tags, ext.CIProviderName)
+		assert.Equal(t, runtime.GOOS, tags["os.platform"])
+	})
+}
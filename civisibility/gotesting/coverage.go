@@ -0,0 +1,206 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package gotesting
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/civisibility/internal/intake"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+// coverageEnabled reports whether code coverage should be reported. It requires
+// the DD_CIVISIBILITY_CODE_COVERAGE_ENABLED environment variable to be true, and
+// the tests to be run with -coverprofile. The testing package gives no access to
+// the coverage counters while tests run, so the coverage is read from the
+// profile written at the end of the run, and covers the whole test module.
+func coverageEnabled() bool {
+	if !internal.BoolEnv("DD_CIVISIBILITY_CODE_COVERAGE_ENABLED", false) {
+		return false
+	}
+	if coverProfile() == "" {
+		log.Warn("civisibility: code coverage is enabled but no coverage profile is written; run the tests with -coverprofile")
+		return false
+	}
+	return true
+}
+
+// coverProfile returns the path of the coverage profile written by the test
+// binary once the tests have run, or "" if there is none.
+func coverProfile() string {
+	f := flag.Lookup("test.coverprofile")
+	if f == nil || f.Value.String() == "" {
+		return ""
+	}
+	path := f.Value.String()
+	if dir := flag.Lookup("test.outputdir"); dir != nil && dir.Value.String() != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(dir.Value.String(), path)
+	}
+	return path
+}
+
+// readCoverProfile reads the coverage profile at path, as written by the test
+// binary, and returns the covered code segments along with the percentage of
+// statements covered.
+func readCoverProfile(path string) (files []intake.FileCoverage, pct float64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	var (
+		total, covered int
+		byFile         = make(map[string]*intake.FileCoverage)
+	)
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if line == 1 && strings.HasPrefix(text, "mode: ") || text == "" {
+			continue
+		}
+		file, seg, stmts, count, err := parseCoverLine(text)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		total += stmts
+		if count == 0 {
+			continue
+		}
+		covered += stmts
+		fc, ok := byFile[file]
+		if !ok {
+			fc = &intake.FileCoverage{Filename: relativeFilename(file)}
+			byFile[file] = fc
+		}
+		fc.Segments = append(fc.Segments, [5]int{seg[0], seg[1], seg[2], seg[3], count})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, 0, err
+	}
+	for _, fc := range byFile {
+		files = append(files, *fc)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Filename < files[j].Filename })
+	if total > 0 {
+		pct = float64(covered) * 100 / float64(total)
+	}
+	return files, pct, nil
+}
+
+// parseCoverLine parses a block of a coverage profile, of the form
+// "file:startLine.startCol,endLine.endCol numStmts count".
+func parseCoverLine(text string) (file string, seg [4]int, stmts, count int, err error) {
+	i := strings.LastIndexByte(text, ':')
+	if i < 0 {
+		return "", seg, 0, 0, fmt.Errorf("malformed coverage block %q", text)
+	}
+	file = text[:i]
+	f := strings.Fields(text[i+1:])
+	if len(f) != 3 {
+		return "", seg, 0, 0, fmt.Errorf("malformed coverage block %q", text)
+	}
+	pos := strings.FieldsFunc(f[0], func(r rune) bool { return r == '.' || r == ',' })
+	if len(pos) != 4 {
+		return "", seg, 0, 0, fmt.Errorf("malformed coverage block %q", text)
+	}
+	for j, p := range pos {
+		if seg[j], err = strconv.Atoi(p); err != nil {
+			return "", seg, 0, 0, fmt.Errorf("malformed coverage block %q", text)
+		}
+	}
+	if stmts, err = strconv.Atoi(f[1]); err != nil {
+		return "", seg, 0, 0, fmt.Errorf("malformed coverage block %q", text)
+	}
+	if count, err = strconv.Atoi(f[2]); err != nil {
+		return "", seg, 0, 0, fmt.Errorf("malformed coverage block %q", text)
+	}
+	return file, seg, stmts, count, nil
+}
+
+// relativeFilename returns the path of file, as found in coverage data, relative
+// to the root of the module being tested when possible. Coverage data names files
+// using their import path, e.g. "example.com/mod/pkg/file.go".
+func relativeFilename(file string) string {
+	if mod := modulePath(); mod != "" {
+		if rel := strings.TrimPrefix(file, mod+"/"); rel != file {
+			return rel
+		}
+	}
+	return file
+}
+
+// uploadCoverage uploads the code covered by the tests of the run, as found in
+// the coverage profile at path, and tags the session and module spans with the
+// percentage of statements covered. It must be called once the profile is
+// written, and before the spans are finished.
+func (r *run) uploadCoverage(path string) {
+	files, pct, err := readCoverProfile(path)
+	if os.IsNotExist(err) {
+		// the tests did not run to completion
+		log.Debug("civisibility: no coverage profile found at %s", path)
+		return
+	}
+	if err != nil {
+		log.Error("civisibility: failed to read the coverage profile: %v", err)
+		return
+	}
+	r.session.SetTag(ext.TestCodeCoverageLinesPercentage, pct)
+	r.module.SetTag(ext.TestCodeCoverageLinesPercentage, pct)
+	if len(files) == 0 {
+		return
+	}
+	cov := intake.Coverage{
+		SessionID: r.session.Context().SpanID(),
+		SpanID:    r.module.Context().SpanID(),
+		Files:     files,
+	}
+	if err := intake.NewClient().SendCoverage([]intake.Coverage{cov}); err != nil {
+		log.Error("civisibility: failed to upload code coverage: %v", err)
+	}
+}
+
+var (
+	modulePathOnce sync.Once
+	modulePathName string
+)
+
+// modulePath returns the path of the module holding the tests, as declared in the
+// closest go.mod file found in the working directory or its parents.
+func modulePath() string {
+	modulePathOnce.Do(func() {
+		dir, err := os.Getwd()
+		if err != nil {
+			return
+		}
+		for {
+			if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+				for _, line := range strings.Split(string(data), "\n") {
+					if f := strings.Fields(line); len(f) == 2 && f[0] == "module" {
+						modulePathName = strings.Trim(f[1], `"`)
+						return
+					}
+				}
+				return
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return
+			}
+			dir = parent
+		}
+	})
+	return modulePathName
+}
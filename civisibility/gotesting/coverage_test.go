@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package gotesting
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/civisibility/internal/intake"
+)
+
+func TestReadCoverProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cover.out")
+	err := os.WriteFile(path, []byte(`mode: atomic
+gopkg.in/DataDog/dd-trace-go.v1/civisibility/gotesting/file.go:1.1,2.2 1 0
+gopkg.in/DataDog/dd-trace-go.v1/civisibility/gotesting/file.go:3.1,4.2 2 2
+gopkg.in/DataDog/dd-trace-go.v1/civisibility/gotesting/file.go:5.1,6.2 1 1
+example.com/other/other.go:1.10,3.2 4 0
+`), 0o644)
+	require.NoError(t, err)
+
+	files, pct, err := readCoverProfile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []intake.FileCoverage{{
+		Filename: "civisibility/gotesting/file.go",
+		Segments: [][5]int{{3, 1, 4, 2, 2}, {5, 1, 6, 2, 1}},
+	}}, files)
+	assert.Equal(t, 37.5, pct)
+
+	_, _, err = readCoverProfile(filepath.Join(t.TempDir(), "missing.out"))
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, os.WriteFile(path, []byte("mode: set\nfile.go:1.1 1 1\n"), 0o644))
+	_, _, err = readCoverProfile(path)
+	assert.Error(t, err)
+}
+
+func TestCoverageEnabled(t *testing.T) {
+	f := flag.Lookup("test.coverprofile")
+	require.NotNil(t, f)
+	old := f.Value.String()
+	defer f.Value.Set(old)
+
+	t.Setenv("DD_CIVISIBILITY_CODE_COVERAGE_ENABLED", "true")
+	f.Value.Set("")
+	assert.False(t, coverageEnabled())
+	f.Value.Set("/tmp/cover.out")
+	assert.True(t, coverageEnabled())
+	assert.Equal(t, "/tmp/cover.out", coverProfile())
+	t.Setenv("DD_CIVISIBILITY_CODE_COVERAGE_ENABLED", "false")
+	assert.False(t, coverageEnabled())
+}
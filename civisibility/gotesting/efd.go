@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package gotesting
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/civisibility/internal/intake"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+// earlyFlakeDetection holds the state of Early Flake Detection for a test run.
+// New tests, which are unknown to Datadog, are run multiple times to detect
+// flakiness, the number of executions depending on the duration of the first one.
+type earlyFlakeDetection struct {
+	// known holds the known tests of the module, by suite and name.
+	known map[string]map[string]bool
+
+	// executions maps test durations to the number of times tests faster than
+	// this duration are run, by increasing duration.
+	executions []slowTestExecutions
+}
+
+type slowTestExecutions struct {
+	below time.Duration
+	count int
+}
+
+// newEarlyFlakeDetection returns the Early Flake Detection state of a run of the
+// given module and tests, or nil if it is disabled. It sets the tags reporting its
+// state on the session of r. Early Flake Detection is aborted when the session is
+// faulty, that is when the share of new tests is above the configured threshold,
+// which usually means the known tests don't match the tests being run.
+func newEarlyFlakeDetection(r *run, s *intake.Settings, module string, tests []testing.InternalTest) *earlyFlakeDetection {
+	if !s.EarlyFlakeDetection.Enabled || !internal.BoolEnv("DD_CIVISIBILITY_EARLY_FLAKE_DETECTION_ENABLED", true) {
+		return nil
+	}
+	known, err := intake.NewClient().GetKnownTests(settingsRequest(r.tags))
+	if err != nil || len(known) == 0 {
+		log.Warn("civisibility: early flake detection is disabled, no known tests: %v", err)
+		return nil
+	}
+	efd := &earlyFlakeDetection{known: make(map[string]map[string]bool)}
+	for suite, names := range known[module] {
+		efd.known[suite] = make(map[string]bool, len(names))
+		for _, name := range names {
+			efd.known[suite][name] = true
+		}
+	}
+	for k, count := range s.EarlyFlakeDetection.SlowTestRetries {
+		d, err := time.ParseDuration(k)
+		if err != nil {
+			log.Debug("civisibility: invalid early flake detection duration %q: %v", k, err)
+			continue
+		}
+		efd.executions = append(efd.executions, slowTestExecutions{below: d, count: count})
+	}
+	sort.Slice(efd.executions, func(i, j int) bool { return efd.executions[i].below < efd.executions[j].below })
+
+	r.session.SetTag(ext.TestEarlyFlakeEnabled, "true")
+	var newTests int
+	for _, t := range tests {
+		if t.F == nil {
+			continue
+		}
+		pc := reflect.ValueOf(t.F).Pointer()
+		file, _ := runtime.FuncForPC(pc).FileLine(pc)
+		if efd.isNew(filepath.Base(file), t.Name) {
+			newTests++
+		}
+	}
+	if threshold := s.EarlyFlakeDetection.FaultySessionThreshold; threshold > 0 && newTests*100 > threshold*len(tests) {
+		log.Warn("civisibility: early flake detection aborted, %d out of %d tests are new", newTests, len(tests))
+		r.session.SetTag(ext.TestEarlyFlakeAbortReason, "faulty")
+		return nil
+	}
+	return efd
+}
+
+// isNew reports whether the test with the given suite and name is new.
+func (e *earlyFlakeDetection) isNew(suite, name string) bool {
+	return !e.known[suite][name]
+}
+
+// executionCount returns the number of times a new test should run, given the
+// duration of its first execution.
+func (e *earlyFlakeDetection) executionCount(d time.Duration) int {
+	for _, x := range e.executions {
+		if d < x.below {
+			return x.count
+		}
+	}
+	return 1
+}
+
+// runNewTest runs the new test f multiple times, reporting each execution as a
+// separate test event. The test passes if any execution passes.
+func (r *run) runNewTest(t *testing.T, s *suite, info testInfo, f func(*testing.T)) {
+	start := time.Now()
+	a := r.startAttempt(s, info, ext.TestIsNew, "true")
+	statuses := []string{a.finish(t, runIsolated(t, f))}
+	if !isParallel(t) {
+		n := r.efd.executionCount(time.Since(start))
+		for i := 1; i < n; i++ {
+			resetStatus(t)
+			a := r.startAttempt(s, info, ext.TestIsNew, "true", ext.TestIsRetry, "true")
+			statuses = append(statuses, a.finish(t, runIsolated(t, f)))
+		}
+	}
+	status := finalStatus(statuses)
+	switch {
+	case status == ext.TestStatusPass:
+		resetStatus(t)
+	case status == ext.TestStatusFail && !t.Failed():
+		t.Fail()
+	}
+	s.record(status)
+}
+
+// finalStatus returns the status of a test given the statuses of its executions:
+// it passes if any execution passed, and is skipped only if all were skipped.
+func finalStatus(statuses []string) string {
+	status := ext.TestStatusSkip
+	for _, s := range statuses {
+		switch s {
+		case ext.TestStatusPass:
+			return ext.TestStatusPass
+		case ext.TestStatusFail:
+			status = ext.TestStatusFail
+		}
+	}
+	return status
+}
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package gotesting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestEarlyFlakeDetection(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	r := newRun("example.com/pkg")
+	r.efd = &earlyFlakeDetection{
+		known:      map[string]map[string]bool{"efd_test.go": {"TestKnown": true}},
+		executions: []slowTestExecutions{{below: 5 * time.Second, count: 3}, {below: time.Minute, count: 2}},
+	}
+	var known, flaky int
+	t.Run("TestKnown", r.instrument("TestKnown", func(t *testing.T) { known++ }))
+	t.Run("TestFlaky", r.instrument("TestFlaky", func(t *testing.T) {
+		flaky++
+		if flaky == 2 {
+			t.FailNow()
+		}
+	}))
+	r.finish(0)
+	assert.Equal(t, 1, known)
+	assert.Equal(t, 3, flaky)
+
+	var statuses []interface{}
+	for _, s := range mt.FinishedSpans() {
+		if s.Tag(ext.SpanType) != ext.SpanTypeTest {
+			continue
+		}
+		switch s.Tag(ext.TestName) {
+		case "TestKnown":
+			assert.Nil(t, s.Tag(ext.TestIsNew))
+		case "TestFlaky":
+			assert.Equal(t, "true", s.Tag(ext.TestIsNew))
+			statuses = append(statuses, s.Tag(ext.TestStatus))
+		}
+	}
+	assert.Equal(t, []interface{}{ext.TestStatusPass, ext.TestStatusFail, ext.TestStatusPass}, statuses)
+}
+
+func TestExecutionCount(t *testing.T) {
+	efd := &earlyFlakeDetection{
+		executions: []slowTestExecutions{{below: 5 * time.Second, count: 10}, {below: 30 * time.Second, count: 3}},
+	}
+	assert.Equal(t, 10, efd.executionCount(time.Millisecond))
+	assert.Equal(t, 3, efd.executionCount(10*time.Second))
+	assert.Equal(t, 1, efd.executionCount(time.Minute))
+}
+
+func TestFinalStatus(t *testing.T) {
+	assert.Equal(t, ext.TestStatusPass, finalStatus([]string{ext.TestStatusFail, ext.TestStatusPass}))
+	assert.Equal(t, ext.TestStatusFail, finalStatus([]string{ext.TestStatusFail, ext.TestStatusSkip}))
+	assert.Equal(t, ext.TestStatusSkip, finalStatus([]string{ext.TestStatusSkip}))
+}
@@ -0,0 +1,342 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package gotesting reports the tests run by the standard testing package to
+// Datadog CI Visibility, without requiring any change to the tests themselves.
+// To enable it, run the tests of a package from its TestMain function using RunM:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(gotesting.RunM(m))
+//	}
+//
+// Each run creates a test session and a test module for the package. Each test
+// file becomes a test suite holding one test event per top-level test, carrying
+// its status and duration.
+package gotesting // import "gopkg.in/DataDog/dd-trace-go.v1/civisibility/gotesting"
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/civisibility"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+// framework is the value of the ext.TestFramework tag.
+const framework = "golang.org/pkg/testing"
+
+// RunM starts the tracer in CI Visibility mode, runs the tests of m and returns
+// the exit code to be passed to os.Exit. The tracer is stopped, flushing all test
+// events, before returning. Additional tracer options may be passed in opts.
+func RunM(m *testing.M, opts ...tracer.StartOption) int {
+	if !flag.Parsed() {
+		// m.Run parses the testing flags, which are needed beforehand to set
+		// up code coverage
+		flag.Parse()
+	}
+	tracer.Start(append([]tracer.StartOption{tracer.WithCIVisibility(true)}, opts...)...)
+	defer tracer.Stop()
+
+	tests := internalTests(m)
+	r := newRun(moduleName(*tests))
+	settings := fetchSettings(r.tags)
+	r.retries = newRetryBudget(settings)
+	r.efd = newEarlyFlakeDetection(r, settings, r.tags[ext.TestModule], *tests)
+	for i, t := range *tests {
+		(*tests)[i].F = r.instrument(t.Name, t.F)
+	}
+	code := m.Run()
+	r.finish(code)
+	return code
+}
+
+// run holds the state of a test run: its session, module and suites.
+type run struct {
+	tags    map[string]string
+	session ddtrace.Span
+	module  ddtrace.Span
+
+	// coverage is the path of the coverage profile of the run, when code
+	// coverage is reported.
+	coverage string
+
+	// retries holds the budget of auto test retries, when enabled.
+	retries *retryBudget
+
+	// efd holds the state of Early Flake Detection, when enabled.
+	efd *earlyFlakeDetection
+
+	mu     sync.Mutex        // guards suites
+	suites map[string]*suite // suites by name
+}
+
+// suite holds the state of a test suite.
+type suite struct {
+	span ddtrace.Span
+
+	mu      sync.Mutex // guards below fields
+	tests   int        // number of finished tests
+	failed  int        // number of failed tests
+	skipped int        // number of skipped tests
+}
+
+// newRun starts the session and module spans of a test run for the given module.
+func newRun(module string) *run {
+	r := &run{
+		tags:   civisibility.EnvironmentTags(),
+		suites: make(map[string]*suite),
+	}
+	r.tags[ext.TestFramework] = framework
+	r.tags[ext.TestFrameworkVersion] = runtime.Version()
+	r.tags[ext.TestCommand] = strings.Join(os.Args, " ")
+	if coverageEnabled() {
+		r.coverage = coverProfile()
+		r.tags[ext.TestCodeCoverageEnabled] = "true"
+	}
+	r.session = r.startSpan("go.test_session", ext.SpanTypeTestSession, r.tags[ext.TestCommand])
+	r.tags[ext.TestSessionID] = spanID(r.session)
+	r.session.SetTag(ext.TestSessionID, r.tags[ext.TestSessionID])
+	r.tags[ext.TestModule] = module
+	r.module = r.startSpan("go.test_module", ext.SpanTypeTestModule, module)
+	r.tags[ext.TestModuleID] = spanID(r.module)
+	r.module.SetTag(ext.TestModuleID, r.tags[ext.TestModuleID])
+	return r
+}
+
+// startSpan starts a root span of the given type, holding the tags of the run
+// and any extra tags.
+func (r *run) startSpan(name, typ, resource string, extra ...string) ddtrace.Span {
+	opts := []ddtrace.StartSpanOption{
+		tracer.SpanType(typ),
+		tracer.ResourceName(resource),
+	}
+	for k, v := range r.tags {
+		opts = append(opts, tracer.Tag(k, v))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		opts = append(opts, tracer.Tag(extra[i], extra[i+1]))
+	}
+	return tracer.StartSpan(name, opts...)
+}
+
+// suite returns the suite with the given name, starting it if needed.
+func (r *run) suite(name string) *suite {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.suites[name]; ok {
+		return s
+	}
+	s := &suite{span: r.startSpan("go.test_suite", ext.SpanTypeTestSuite, name, ext.TestSuite, name)}
+	s.span.SetTag(ext.TestSuiteID, spanID(s.span))
+	r.suites[name] = s
+	return s
+}
+
+// testInfo describes an instrumented test function.
+type testInfo struct {
+	name  string
+	suite string
+	file  string
+	line  int
+}
+
+// instrument returns a test function running f and reporting it as a test event.
+// When Early Flake Detection is enabled, new tests are run multiple times. When
+// auto test retries are enabled, failed tests are retried. Each attempt is reported
+// as a separate test event.
+func (r *run) instrument(name string, f func(*testing.T)) func(*testing.T) {
+	if f == nil {
+		return f
+	}
+	pc := reflect.ValueOf(f).Pointer()
+	file, line := runtime.FuncForPC(pc).FileLine(pc)
+	info := testInfo{name: name, suite: filepath.Base(file), file: file, line: line}
+	return func(t *testing.T) {
+		s := r.suite(info.suite)
+		switch {
+		case r.efd != nil && r.efd.isNew(info.suite, info.name):
+			r.runNewTest(t, s, info, f)
+		case r.retries != nil:
+			r.runWithRetries(t, s, info, f)
+		default:
+			a := r.startAttempt(s, info)
+			defer func() { s.record(a.finish(t, recover())) }()
+			f(t)
+		}
+	}
+}
+
+// runWithRetries runs the test f, retrying it while it fails and the retry budget
+// allows it.
+func (r *run) runWithRetries(t *testing.T, s *suite, info testInfo, f func(*testing.T)) {
+	for retries := 0; ; retries++ {
+		var a *attempt
+		if retries == 0 {
+			a = r.startAttempt(s, info)
+		} else {
+			a = r.startAttempt(s, info, ext.TestIsRetry, "true")
+		}
+		status := a.finish(t, runIsolated(t, f))
+		if status != ext.TestStatusFail || isParallel(t) || !r.retries.allow(retries) {
+			s.record(status)
+			return
+		}
+		resetStatus(t)
+	}
+}
+
+// attempt holds the state of a single run of a test.
+type attempt struct {
+	r    *run
+	s    *suite
+	span ddtrace.Span
+}
+
+// startAttempt starts the span of a new attempt to run the test described by info,
+// holding the given extra tags.
+func (r *run) startAttempt(s *suite, info testInfo, extra ...string) *attempt {
+	tags := []string{
+		ext.TestName, info.name,
+		ext.TestSuite, info.suite,
+		ext.TestSuiteID, spanID(s.span),
+		ext.TestType, "test",
+		ext.TestSourceFile, info.file,
+		ext.TestSourceStartLine, strconv.Itoa(info.line),
+	}
+	tags = append(tags, extra...)
+	a := &attempt{
+		r:    r,
+		s:    s,
+		span: r.startSpan("go.test", ext.SpanTypeTest, info.suite+"."+info.name, tags...),
+	}
+	return a
+}
+
+// finish finishes the attempt of running t and returns its status. If the test
+// panicked with p, the run is finished and the panic resumed.
+func (a *attempt) finish(t *testing.T, p interface{}) string {
+	if p != nil {
+		// the test binary exits right after a panic; report what we can
+		a.span.SetTag(ext.TestStatus, ext.TestStatusFail)
+		a.span.Finish(tracer.WithError(fmt.Errorf("panic: %v", p)))
+		a.s.record(ext.TestStatusFail)
+		a.r.finish(1)
+		tracer.Stop()
+		panic(p)
+	}
+	status := testStatus(t)
+	finishSpan(a.span, status)
+	return status
+}
+
+// runIsolated runs f(t) in a new goroutine, so that calls to t.FailNow or t.SkipNow,
+// which exit the calling goroutine, end the attempt instead of the test. It returns
+// the value f panicked with, if any.
+func runIsolated(t *testing.T, f func(*testing.T)) (p interface{}) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() { p = recover() }()
+		f(t)
+	}()
+	<-done
+	return p
+}
+
+// record records the status of a finished test of the suite.
+func (s *suite) record(status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tests++
+	switch status {
+	case ext.TestStatusFail:
+		s.failed++
+	case ext.TestStatusSkip:
+		s.skipped++
+	}
+}
+
+// finish finishes the suites, module and session of the run, given the exit code
+// returned by (*testing.M).Run.
+func (r *run) finish(code int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.suites {
+		s.mu.Lock()
+		status := ext.TestStatusPass
+		switch {
+		case s.failed > 0:
+			status = ext.TestStatusFail
+		case s.skipped == s.tests:
+			status = ext.TestStatusSkip
+		}
+		s.mu.Unlock()
+		finishSpan(s.span, status)
+	}
+	r.suites = make(map[string]*suite)
+	status := ext.TestStatusPass
+	if code != 0 {
+		status = ext.TestStatusFail
+	}
+	if r.coverage != "" {
+		r.uploadCoverage(r.coverage)
+	}
+	finishSpan(r.module, status)
+	finishSpan(r.session, status)
+}
+
+// finishSpan finishes span with the given test status.
+func finishSpan(span ddtrace.Span, status string) {
+	span.SetTag(ext.TestStatus, status)
+	if status == ext.TestStatusFail {
+		span.SetTag(ext.Error, true)
+	}
+	span.Finish()
+}
+
+// testStatus returns the status of the finished test t.
+func testStatus(t *testing.T) string {
+	switch {
+	case t.Failed():
+		return ext.TestStatusFail
+	case t.Skipped():
+		return ext.TestStatusSkip
+	default:
+		return ext.TestStatusPass
+	}
+}
+
+// spanID returns the ID of span as a tag value.
+func spanID(span ddtrace.Span) string {
+	return strconv.FormatUint(span.Context().SpanID(), 10)
+}
+
+// moduleName returns the name of the module being tested, which is the import
+// path of the package holding the tests.
+func moduleName(tests []testing.InternalTest) string {
+	for _, t := range tests {
+		if t.F == nil {
+			continue
+		}
+		fn := runtime.FuncForPC(reflect.ValueOf(t.F).Pointer()).Name()
+		// fn is of the form "path/to/pkg.TestName"
+		if i := strings.LastIndex(fn, "."); i > 0 {
+			return strings.TrimSuffix(fn[:i], "_test")
+		}
+	}
+	log.Debug("civisibility: unable to find the name of the tested package")
+	return filepath.Base(os.Args[0])
+}
@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package gotesting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestInstrument(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	r := newRun("example.com/pkg")
+	t.Run("TestPass", r.instrument("TestPass", func(t *testing.T) {}))
+	t.Run("TestSkip", r.instrument("TestSkip", func(t *testing.T) { t.Skip("skipped") }))
+	r.finish(0)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 5)
+	byType := make(map[interface{}][]mocktracer.Span)
+	for _, s := range spans {
+		byType[s.Tag(ext.SpanType)] = append(byType[s.Tag(ext.SpanType)], s)
+	}
+	session := byType[ext.SpanTypeTestSession][0]
+	module := byType[ext.SpanTypeTestModule][0]
+	suite := byType[ext.SpanTypeTestSuite][0]
+	tests := byType[ext.SpanTypeTest]
+	assert.Len(t, tests, 2)
+
+	assert.Equal(t, ext.TestStatusPass, session.Tag(ext.TestStatus))
+	assert.Equal(t, framework, session.Tag(ext.TestFramework))
+	assert.Equal(t, "example.com/pkg", module.Tag(ext.TestModule))
+	assert.Equal(t, module.Tag(ext.TestSessionID), session.Tag(ext.TestSessionID))
+	assert.Equal(t, "gotesting_test.go", suite.Tag(ext.TestSuite))
+	assert.Equal(t, ext.TestStatusPass, suite.Tag(ext.TestStatus))
+	for _, test := range tests {
+		assert.Equal(t, suite.Tag(ext.TestSuiteID), test.Tag(ext.TestSuiteID))
+		assert.Equal(t, module.Tag(ext.TestModuleID), test.Tag(ext.TestModuleID))
+		assert.Equal(t, "gotesting_test.go", test.Tag(ext.TestSuite))
+		switch test.Tag(ext.TestName) {
+		case "TestPass":
+			assert.Equal(t, ext.TestStatusPass, test.Tag(ext.TestStatus))
+		case "TestSkip":
+			assert.Equal(t, ext.TestStatusSkip, test.Tag(ext.TestStatus))
+		default:
+			t.Fatalf("unexpected test %v", test.Tag(ext.TestName))
+		}
+	}
+}
+
+func TestFinishStatus(t *testing.T) {
+	for name, tt := range map[string]struct {
+		code     int
+		statuses []string
+		suite    string
+		module   string
+	}{
+		"fail":    {code: 1, statuses: []string{ext.TestStatusPass, ext.TestStatusFail}, suite: ext.TestStatusFail, module: ext.TestStatusFail},
+		"skipped": {code: 0, statuses: []string{ext.TestStatusSkip}, suite: ext.TestStatusSkip, module: ext.TestStatusPass},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			r := newRun("example.com/pkg")
+			s := r.suite("a_test.go")
+			for _, status := range tt.statuses {
+				s.record(status)
+			}
+			r.finish(tt.code)
+			for _, span := range mt.FinishedSpans() {
+				switch span.Tag(ext.SpanType) {
+				case ext.SpanTypeTestSuite:
+					assert.Equal(t, tt.suite, span.Tag(ext.TestStatus))
+				case ext.SpanTypeTestModule, ext.SpanTypeTestSession:
+					assert.Equal(t, tt.module, span.Tag(ext.TestStatus))
+				}
+			}
+		})
+	}
+}
+
+func TestModuleName(t *testing.T) {
+	name := moduleName([]testing.InternalTest{{Name: "TestModuleName", F: TestModuleName}})
+	assert.Equal(t, "gopkg.in/DataDog/dd-trace-go.v1/civisibility/gotesting", name)
+}
@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package gotesting
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// internalTests returns a pointer to the list of tests held by m, allowing them
+// to be wrapped before m runs them. testing.M doesn't expose its tests, so they
+// are read from its unexported "tests" field. If the field can't be found, for
+// example because a new version of Go renamed it, an empty list is returned and
+// the tests run without being instrumented.
+func internalTests(m *testing.M) *[]testing.InternalTest {
+	v := reflect.ValueOf(m).Elem().FieldByName("tests")
+	if !v.IsValid() || v.Type() != reflect.TypeOf([]testing.InternalTest(nil)) {
+		return new([]testing.InternalTest)
+	}
+	return (*[]testing.InternalTest)(unsafe.Pointer(v.UnsafeAddr()))
+}
+
+// isParallel reports whether t called t.Parallel. Parallel tests can't be retried,
+// as t.Parallel can only be called once per test.
+func isParallel(t *testing.T) bool {
+	v := reflect.ValueOf(t).Elem().FieldByName("isParallel")
+	return v.IsValid() && v.Kind() == reflect.Bool && v.Bool()
+}
+
+// resetStatus resets the failed and skipped states of t, so it can be run again.
+func resetStatus(t *testing.T) {
+	c := reflect.ValueOf(t).Elem().FieldByName("common")
+	for _, name := range []string{"failed", "skipped", "finished"} {
+		if f := c.FieldByName(name); f.IsValid() && f.Kind() == reflect.Bool {
+			*(*bool)(unsafe.Pointer(f.UnsafeAddr())) = false
+		}
+	}
+}
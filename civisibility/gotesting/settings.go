@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package gotesting
+
+import (
+	"os"
+	"path"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/civisibility/internal/intake"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+// settingsRequest returns the request describing the test service, given the
+// tags of the run.
+func settingsRequest(tags map[string]string) intake.SettingsRequest {
+	env := os.Getenv("DD_ENV")
+	if env == "" {
+		env = "none"
+	}
+	req := intake.SettingsRequest{
+		Service:       serviceName(tags),
+		Env:           env,
+		RepositoryURL: tags[internal.TagRepositoryURL],
+		Branch:        tags[ext.GitBranch],
+		Sha:           tags[internal.TagCommitSha],
+		Configuration: make(map[string]string),
+	}
+	for _, k := range []string{"os.platform", "os.architecture", "os.version", "runtime.name", "runtime.version"} {
+		req.Configuration[k] = tags[k]
+	}
+	return req
+}
+
+// fetchSettings returns the CI Visibility settings of the test service, as
+// configured in Datadog, given the tags of the run. If they can't be fetched,
+// all features are disabled.
+func fetchSettings(tags map[string]string) *intake.Settings {
+	s, err := intake.NewClient().GetSettings(settingsRequest(tags))
+	if err != nil {
+		log.Warn("civisibility: remotely configured features are disabled: %v", err)
+		return &intake.Settings{}
+	}
+	return s
+}
+
+// serviceName returns the name of the test service: DD_SERVICE if set, or the
+// name of the repository being tested.
+func serviceName(tags map[string]string) string {
+	if v := os.Getenv("DD_SERVICE"); v != "" {
+		return v
+	}
+	if repo := tags[internal.TagRepositoryURL]; repo != "" {
+		return strings.TrimSuffix(path.Base(repo), ".git")
+	}
+	return path.Base(moduleName(nil))
+}
+
+const (
+	// defaultFlakyRetryCount is the default number of times a failed test is retried.
+	defaultFlakyRetryCount = 5
+
+	// defaultTotalFlakyRetryCount is the default number of retries allowed for a
+	// whole test run.
+	defaultTotalFlakyRetryCount = 1000
+)
+
+// retryBudget limits the number of auto test retries.
+type retryBudget struct {
+	perTest   int   // maximum number of retries of a single test
+	remaining int64 // number of retries left for the run, accessed atomically
+}
+
+// newRetryBudget returns the retry budget of a run given its settings, or nil if
+// auto test retries are disabled. Retries can be disabled locally by setting
+// DD_CIVISIBILITY_FLAKY_RETRY_ENABLED to false, and their number configured using
+// DD_CIVISIBILITY_FLAKY_RETRY_COUNT and DD_CIVISIBILITY_TOTAL_FLAKY_RETRY_COUNT.
+func newRetryBudget(s *intake.Settings) *retryBudget {
+	if !s.FlakyTestRetriesEnabled || !internal.BoolEnv("DD_CIVISIBILITY_FLAKY_RETRY_ENABLED", true) {
+		return nil
+	}
+	return &retryBudget{
+		perTest:   internal.IntEnv("DD_CIVISIBILITY_FLAKY_RETRY_COUNT", defaultFlakyRetryCount),
+		remaining: int64(internal.IntEnv("DD_CIVISIBILITY_TOTAL_FLAKY_RETRY_COUNT", defaultTotalFlakyRetryCount)),
+	}
+}
+
+// allow reports whether a test which was already retried the given number of
+// times can be retried again, consuming the budget when it can.
+func (b *retryBudget) allow(retries int) bool {
+	if retries >= b.perTest {
+		return false
+	}
+	return atomic.AddInt64(&b.remaining, -1) >= 0
+}
@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package gotesting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/civisibility/internal/intake"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestRetryBudget(t *testing.T) {
+	assert.Nil(t, newRetryBudget(&intake.Settings{}))
+
+	t.Setenv("DD_CIVISIBILITY_FLAKY_RETRY_COUNT", "2")
+	t.Setenv("DD_CIVISIBILITY_TOTAL_FLAKY_RETRY_COUNT", "3")
+	b := newRetryBudget(&intake.Settings{FlakyTestRetriesEnabled: true})
+	assert.True(t, b.allow(0))
+	assert.True(t, b.allow(1))
+	assert.False(t, b.allow(2))
+	assert.True(t, b.allow(0))
+	assert.False(t, b.allow(0))
+
+	t.Setenv("DD_CIVISIBILITY_FLAKY_RETRY_ENABLED", "false")
+	assert.Nil(t, newRetryBudget(&intake.Settings{FlakyTestRetriesEnabled: true}))
+}
+
+func TestRetries(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	r := newRun("example.com/pkg")
+	r.retries = &retryBudget{perTest: 5, remaining: 10}
+	calls := 0
+	t.Run("TestFlaky", r.instrument("TestFlaky", func(t *testing.T) {
+		calls++
+		if calls < 3 {
+			t.FailNow()
+		}
+	}))
+	r.finish(0)
+	assert.Equal(t, 3, calls)
+
+	var statuses []interface{}
+	var retries int
+	for _, s := range mt.FinishedSpans() {
+		if s.Tag(ext.SpanType) != ext.SpanTypeTest {
+			continue
+		}
+		statuses = append(statuses, s.Tag(ext.TestStatus))
+		if s.Tag(ext.TestIsRetry) == "true" {
+			retries++
+		}
+	}
+	assert.Equal(t, []interface{}{ext.TestStatusFail, ext.TestStatusFail, ext.TestStatusPass}, statuses)
+	assert.Equal(t, 2, retries)
+}
@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package intake implements a client for the CI Visibility intakes and APIs,
+// reached either through the agent's EVP proxy or directly (agentless mode).
+package intake
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/version"
+)
+
+const (
+	// evpProxyPath is the path prefix of the agent's EVP proxy.
+	evpProxyPath = "/evp_proxy/v2"
+
+	// defaultSite is the Datadog site used in agentless mode when DD_SITE is not set.
+	defaultSite = "datadoghq.com"
+
+	// defaultTimeout is the timeout of the requests sent by the client.
+	defaultTimeout = 10 * time.Second
+)
+
+// Client sends requests to the CI Visibility intakes and APIs.
+type Client struct {
+	agentless bool
+	agentURL  string // base URL of the agent, when not in agentless mode
+	site      string // Datadog site, in agentless mode
+	apiKey    string // Datadog API key, in agentless mode
+	http      *http.Client
+}
+
+// NewClient returns a new Client configured using the environment. Requests are
+// sent through the agent's EVP proxy, unless DD_CIVISIBILITY_AGENTLESS_ENABLED is
+// true, in which case they are sent directly to the intakes of DD_SITE, using
+// DD_API_KEY.
+func NewClient() *Client {
+	c := &Client{
+		agentless: internal.BoolEnv("DD_CIVISIBILITY_AGENTLESS_ENABLED", false),
+		site:      os.Getenv("DD_SITE"),
+		apiKey:    os.Getenv("DD_API_KEY"),
+		http:      &http.Client{Timeout: defaultTimeout},
+	}
+	if c.site == "" {
+		c.site = defaultSite
+	}
+	if u := internal.AgentURLFromEnv(); u != nil && u.Scheme != "unix" {
+		c.agentURL = u.String()
+	} else {
+		host, port := "localhost", "8126"
+		if v := os.Getenv("DD_AGENT_HOST"); v != "" {
+			host = v
+		}
+		if v := os.Getenv("DD_TRACE_AGENT_PORT"); v != "" {
+			port = v
+		}
+		c.agentURL = "http://" + net.JoinHostPort(host, port)
+	}
+	return c
+}
+
+// do sends a request with the given body to path on the given intake subdomain,
+// and returns the response body.
+func (c *Client) do(method, subdomain, path, contentType string, body []byte) ([]byte, error) {
+	var url string
+	if c.agentless {
+		url = fmt.Sprintf("https://%s.%s%s", subdomain, c.site, path)
+	} else {
+		url = c.agentURL + evpProxyPath + path
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create http request: %v", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Datadog-Meta-Lang", "go")
+	req.Header.Set("Datadog-Meta-Tracer-Version", version.Tag)
+	if c.agentless {
+		req.Header.Set("dd-api-key", c.apiKey)
+	} else {
+		req.Header.Set("X-Datadog-EVP-Subdomain", subdomain)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("%s: %s", resp.Status, data)
+	}
+	return data, nil
+}
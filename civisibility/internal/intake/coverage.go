@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package intake
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/textproto"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+const (
+	// coverageSubdomain is the subdomain of the code coverage intake.
+	coverageSubdomain = "citestcov-intake"
+
+	// coveragePath is the path of the code coverage intake.
+	coveragePath = "/api/v2/citestcov"
+)
+
+// Coverage holds the code covered by a single test.
+type Coverage struct {
+	// SessionID, SuiteID and SpanID identify the test.
+	SessionID, SuiteID, SpanID uint64
+
+	// Files lists the files covered by the test.
+	Files []FileCoverage
+}
+
+// FileCoverage holds the code covered in a single file.
+type FileCoverage struct {
+	// Filename is the path of the file, relative to the repository root when known.
+	Filename string
+
+	// Segments lists the covered code segments, as
+	// [start line, start column, end line, end column, count].
+	Segments [][5]int
+}
+
+// SendCoverage uploads coverages to the code coverage intake, in the citestcov
+// format.
+func (c *Client) SendCoverage(coverages []Coverage) error {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	f, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Disposition": []string{`form-data; name="coverage1"; filename="filecoverage1.msgpack"`},
+		"Content-Type":        []string{"application/msgpack"},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(encodeCoverage(nil, coverages)); err != nil {
+		return err
+	}
+	f, err = mw.CreatePart(textproto.MIMEHeader{
+		"Content-Disposition": []string{`form-data; name="event"; filename="fileevent.json"`},
+		"Content-Type":        []string{"application/json"},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write([]byte(`{"dummy":true}`)); err != nil {
+		return err
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+	_, err = c.do("POST", coverageSubdomain, coveragePath, mw.FormDataContentType(), buf.Bytes())
+	return err
+}
+
+// encodeCoverage appends the msgpack encoding of coverages to b, as expected by
+// the code coverage intake, and returns the extended buffer.
+func encodeCoverage(b []byte, coverages []Coverage) []byte {
+	b = msgp.AppendMapHeader(b, 2)
+	b = msgp.AppendString(b, "version")
+	b = msgp.AppendInt(b, 2)
+	b = msgp.AppendString(b, "coverages")
+	b = msgp.AppendArrayHeader(b, uint32(len(coverages)))
+	for _, c := range coverages {
+		b = msgp.AppendMapHeader(b, 4)
+		b = msgp.AppendString(b, "test_session_id")
+		b = msgp.AppendUint64(b, c.SessionID)
+		b = msgp.AppendString(b, "test_suite_id")
+		b = msgp.AppendUint64(b, c.SuiteID)
+		b = msgp.AppendString(b, "span_id")
+		b = msgp.AppendUint64(b, c.SpanID)
+		b = msgp.AppendString(b, "files")
+		b = msgp.AppendArrayHeader(b, uint32(len(c.Files)))
+		for _, f := range c.Files {
+			b = msgp.AppendMapHeader(b, 2)
+			b = msgp.AppendString(b, "filename")
+			b = msgp.AppendString(b, f.Filename)
+			b = msgp.AppendString(b, "segments")
+			b = msgp.AppendArrayHeader(b, uint32(len(f.Segments)))
+			for _, s := range f.Segments {
+				b = msgp.AppendArrayHeader(b, uint32(len(s)))
+				for _, v := range s {
+					b = msgp.AppendInt(b, v)
+				}
+			}
+		}
+	}
+	return b
+}
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package intake
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestSendCoverage(t *testing.T) {
+	var (
+		subdomain string
+		files     = map[string][]byte{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, evpProxyPath+coveragePath, r.URL.Path)
+		subdomain = r.Header.Get("X-Datadog-EVP-Subdomain")
+		mr, err := r.MultipartReader()
+		require.NoError(t, err)
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			files[p.FormName()], _ = io.ReadAll(p)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("DD_TRACE_AGENT_URL", srv.URL)
+	t.Setenv("DD_CIVISIBILITY_AGENTLESS_ENABLED", "false")
+
+	err := NewClient().SendCoverage([]Coverage{{
+		SessionID: 1,
+		SuiteID:   2,
+		SpanID:    3,
+		Files: []FileCoverage{{
+			Filename: "pkg/file.go",
+			Segments: [][5]int{{10, 2, 12, 3, 1}},
+		}},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, coverageSubdomain, subdomain)
+	assert.JSONEq(t, `{"dummy":true}`, string(files["event"]))
+
+	v, _, err := msgp.ReadIntfBytes(files["coverage1"])
+	require.NoError(t, err)
+	payload := v.(map[string]interface{})
+	assert.EqualValues(t, 2, payload["version"])
+	coverages := payload["coverages"].([]interface{})
+	require.Len(t, coverages, 1)
+	c := coverages[0].(map[string]interface{})
+	assert.EqualValues(t, 1, c["test_session_id"])
+	assert.EqualValues(t, 2, c["test_suite_id"])
+	assert.EqualValues(t, 3, c["span_id"])
+	f := c["files"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "pkg/file.go", f["filename"])
+	assert.Equal(t, []interface{}{int64(10), int64(2), int64(12), int64(3), int64(1)}, f["segments"].([]interface{})[0])
+}
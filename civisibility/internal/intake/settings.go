@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package intake
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// apiSubdomain is the subdomain of the CI Visibility API.
+	apiSubdomain = "api"
+
+	// settingsPath is the path of the library settings endpoint.
+	settingsPath = "/api/v2/libraries/tests/services/setting"
+
+	// knownTestsPath is the path of the known tests endpoint.
+	knownTestsPath = "/api/v2/ci/libraries/tests"
+)
+
+// SettingsRequest describes the test run for which settings are requested.
+type SettingsRequest struct {
+	Service       string            `json:"service"`
+	Env           string            `json:"env"`
+	RepositoryURL string            `json:"repository_url"`
+	Branch        string            `json:"branch"`
+	Sha           string            `json:"sha"`
+	Configuration map[string]string `json:"configurations"`
+}
+
+// Settings holds the CI Visibility settings of a test service, as configured in Datadog.
+type Settings struct {
+	CodeCoverage            bool `json:"code_coverage"`
+	TestsSkipping           bool `json:"tests_skipping"`
+	FlakyTestRetriesEnabled bool `json:"flaky_test_retries_enabled"`
+
+	EarlyFlakeDetection EarlyFlakeDetectionSettings `json:"early_flake_detection"`
+}
+
+// EarlyFlakeDetectionSettings holds the settings of Early Flake Detection, which
+// runs new tests multiple times to detect flakiness before they are merged.
+type EarlyFlakeDetectionSettings struct {
+	Enabled bool `json:"enabled"`
+
+	// SlowTestRetries maps test durations, such as "5s" or "5m", to the number
+	// of times tests running faster than this duration are run.
+	SlowTestRetries map[string]int `json:"slow_test_retries"`
+
+	// FaultySessionThreshold is the percentage of new tests above which a
+	// session is considered faulty, disabling Early Flake Detection.
+	FaultySessionThreshold int `json:"faulty_session_threshold"`
+}
+
+// GetSettings returns the settings of the test service described by req.
+func (c *Client) GetSettings(req SettingsRequest) (*Settings, error) {
+	var resp Settings
+	if err := c.postAPI(settingsPath, "ci_app_test_service_libraries_settings", req, &resp); err != nil {
+		return nil, fmt.Errorf("fetching settings: %v", err)
+	}
+	return &resp, nil
+}
+
+// KnownTests lists the test names known by Datadog, by module and suite.
+type KnownTests map[string]map[string][]string
+
+// GetKnownTests returns the tests of the test service described by req which
+// have already been run, as known by Datadog.
+func (c *Client) GetKnownTests(req SettingsRequest) (KnownTests, error) {
+	var resp struct {
+		Tests KnownTests `json:"tests"`
+	}
+	if err := c.postAPI(knownTestsPath, "ci_app_libraries_tests_request", req, &resp); err != nil {
+		return nil, fmt.Errorf("fetching known tests: %v", err)
+	}
+	return resp.Tests, nil
+}
+
+// postAPI sends a JSON:API request of the given type and attributes to path on
+// the CI Visibility API, and decodes the attributes of the response into out.
+func (c *Client) postAPI(path, typ string, attributes, out interface{}) error {
+	type requestData struct {
+		ID         string      `json:"id"`
+		Type       string      `json:"type"`
+		Attributes interface{} `json:"attributes"`
+	}
+	body, err := json.Marshal(struct {
+		Data requestData `json:"data"`
+	}{requestData{ID: "1", Type: typ, Attributes: attributes}})
+	if err != nil {
+		return err
+	}
+	data, err := c.do("POST", apiSubdomain, path, "application/json", body)
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Data struct {
+			Attributes json.RawMessage `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+	return json.Unmarshal(resp.Data.Attributes, out)
+}
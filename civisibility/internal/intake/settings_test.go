@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package intake
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSettings(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, evpProxyPath+settingsPath, r.URL.Path)
+		assert.Equal(t, apiSubdomain, r.Header.Get("X-Datadog-EVP-Subdomain"))
+		var req struct {
+			Data struct {
+				Type       string          `json:"type"`
+				Attributes SettingsRequest `json:"attributes"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "ci_app_test_service_libraries_settings", req.Data.Type)
+		assert.Equal(t, "svc", req.Data.Attributes.Service)
+		assert.Equal(t, "linux", req.Data.Attributes.Configuration["os.platform"])
+		w.Write([]byte(`{"data":{"attributes":{"code_coverage":true,"flaky_test_retries_enabled":true,` +
+			`"early_flake_detection":{"enabled":true,"slow_test_retries":{"5s":10},"faulty_session_threshold":30}}}}`))
+	}))
+	defer srv.Close()
+	t.Setenv("DD_TRACE_AGENT_URL", srv.URL)
+	t.Setenv("DD_CIVISIBILITY_AGENTLESS_ENABLED", "false")
+
+	s, err := NewClient().GetSettings(SettingsRequest{
+		Service:       "svc",
+		Configuration: map[string]string{"os.platform": "linux"},
+	})
+	require.NoError(t, err)
+	assert.True(t, s.CodeCoverage)
+	assert.True(t, s.FlakyTestRetriesEnabled)
+	assert.False(t, s.TestsSkipping)
+	assert.True(t, s.EarlyFlakeDetection.Enabled)
+	assert.Equal(t, map[string]int{"5s": 10}, s.EarlyFlakeDetection.SlowTestRetries)
+	assert.Equal(t, 30, s.EarlyFlakeDetection.FaultySessionThreshold)
+}
+
+func TestGetKnownTests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, evpProxyPath+knownTestsPath, r.URL.Path)
+		w.Write([]byte(`{"data":{"attributes":{"tests":{"example.com/pkg":{"a_test.go":["TestA","TestB"]}}}}}`))
+	}))
+	defer srv.Close()
+	t.Setenv("DD_TRACE_AGENT_URL", srv.URL)
+	t.Setenv("DD_CIVISIBILITY_AGENTLESS_ENABLED", "false")
+
+	tests, err := NewClient().GetKnownTests(SettingsRequest{Service: "svc"})
+	require.NoError(t, err)
+	assert.Equal(t, KnownTests{"example.com/pkg": {"a_test.go": {"TestA", "TestB"}}}, tests)
+}
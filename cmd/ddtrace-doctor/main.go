@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+// Command ddtrace-doctor checks the tracer configuration resulting from the
+// environment it runs in, as well as its ability to reach the Datadog Agent,
+// and prints a report in JSON format. It should be run with the same environment
+// variables as the traced application:
+//
+//	go run gopkg.in/DataDog/dd-trace-go.v1/cmd/ddtrace-doctor
+//
+// It exits with a non-zero status if the agent is unreachable or if conflicting
+// environment variables are found.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func main() {
+	timeout := flag.Duration("timeout", 5*time.Second, "maximum time spent contacting the agent")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	report := tracer.SelfCheck(ctx, tracer.WithLogStartup(false))
+	fmt.Println(report)
+	if report.Products["tracing"] && !report.Products["lambda_mode"] && !report.AgentReachable {
+		os.Exit(1)
+	}
+	if len(report.EnvConflicts) > 0 {
+		os.Exit(2)
+	}
+}
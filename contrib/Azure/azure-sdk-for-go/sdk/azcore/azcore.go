@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package azcore provides functions to trace the requests sent by the clients
+// of the Azure SDK for Go (https://github.com/Azure/azure-sdk-for-go), built on
+// the github.com/Azure/azure-sdk-for-go/sdk/azcore package.
+//
+// The policy returned by NewPolicy traces the requests of any Azure client,
+// such as the clients of Blob Storage, Service Bus or Cosmos DB, when it is
+// added to the policies of its pipeline.
+package azcore
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+const componentName = "Azure/azure-sdk-for-go/sdk/azcore"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+const spanName = "azure.request"
+
+// Tags set on the spans.
+const (
+	tagService   = "azure.service"
+	tagOperation = "azure.operation"
+	tagAccount   = "azure.account"
+	tagRequestID = "azure.request_id"
+)
+
+type tracingPolicy struct {
+	cfg *config
+}
+
+// NewPolicy returns a policy tracing the requests of an Azure client. It must
+// be added to the PerCallPolicies of the options of the client, to trace each
+// operation of the client, or to its PerRetryPolicies, to trace each attempt
+// to send a request.
+func NewPolicy(opts ...Option) policy.Policy {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/Azure/azure-sdk-for-go/sdk/azcore: Configuring Policy: %#v", cfg)
+	return &tracingPolicy{cfg: cfg}
+}
+
+// Do implements policy.Policy.
+func (p *tracingPolicy) Do(req *policy.Request) (*http.Response, error) {
+	raw := req.Raw()
+	info := parseRequest(raw)
+	// Copy the URL without its query, which may hold a shared access
+	// signature, and userinfo.
+	url := *raw.URL
+	url.User = nil
+	url.RawQuery = ""
+	opts := []ddtrace.StartSpanOption{
+		tracer.SpanType(ext.SpanTypeHTTP),
+		tracer.ServiceName(p.serviceName(info.service)),
+		tracer.ResourceName(info.service + "." + info.operation),
+		tracer.Tag(tagService, info.service),
+		tracer.Tag(tagOperation, info.operation),
+		tracer.Tag(ext.HTTPMethod, raw.Method),
+		tracer.Tag(ext.HTTPURL, url.String()),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+	}
+	if info.account != "" {
+		opts = append(opts, tracer.Tag(tagAccount, info.account))
+	}
+	if !math.IsNaN(p.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, p.cfg.analyticsRate))
+	}
+	span, ctx := tracer.StartSpanFromContext(raw.Context(), spanName, opts...)
+
+	resp, err := req.Clone(ctx).Next()
+
+	if resp != nil {
+		span.SetTag(ext.HTTPCode, strconv.Itoa(resp.StatusCode))
+		if id := resp.Header.Get("x-ms-request-id"); id != "" {
+			span.SetTag(tagRequestID, id)
+		}
+		if resp.StatusCode >= 500 {
+			span.SetTag(ext.Error, true)
+		}
+	}
+	if err != nil && (p.cfg.errCheck == nil || p.cfg.errCheck(err)) {
+		span.SetTag(ext.Error, err)
+	}
+	span.Finish()
+	return resp, err
+}
+
+func (p *tracingPolicy) serviceName(service string) string {
+	if p.cfg.serviceName != "" {
+		return p.cfg.serviceName
+	}
+	defaultName := "azure." + service
+	return namingschema.NewDefaultServiceName(
+		defaultName,
+		namingschema.WithOverrideV0(defaultName),
+	).GetName()
+}
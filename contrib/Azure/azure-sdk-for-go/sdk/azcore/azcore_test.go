@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package azcore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTransport responds to the requests with the given status code, unless
+// err is set.
+type testTransport struct {
+	status int
+	err    error
+	req    *http.Request
+}
+
+func (t *testTransport) Do(req *http.Request) (*http.Response, error) {
+	t.req = req
+	if t.err != nil {
+		return nil, t.err
+	}
+	return &http.Response{
+		StatusCode: t.status,
+		Header:     http.Header{"X-Ms-Request-Id": []string{"request-1"}},
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func sendRequest(t *testing.T, transport *testTransport, method, url string, opts ...Option) (*http.Response, error) {
+	pl := runtime.NewPipeline("test", "v1.0.0", runtime.PipelineOptions{}, &policy.ClientOptions{
+		PerCallPolicies: []policy.Policy{NewPolicy(opts...)},
+		Retry:           policy.RetryOptions{MaxRetries: -1},
+		Transport:       transport,
+	})
+	req, err := runtime.NewRequest(context.Background(), method, url)
+	require.NoError(t, err)
+	return pl.Do(req)
+}
+
+func TestPolicy(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	transport := &testTransport{status: http.StatusCreated}
+	resp, err := sendRequest(t, transport, http.MethodPut, "https://account.blob.core.windows.net/container/blob?comp=block&sig=secret")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, spanName, s.OperationName())
+	assert.Equal(t, "blob.PutBlock", s.Tag(ext.ResourceName))
+	assert.Equal(t, "azure.blob", s.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanTypeHTTP, s.Tag(ext.SpanType))
+	assert.Equal(t, ext.SpanKindClient, s.Tag(ext.SpanKind))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, "blob", s.Tag(tagService))
+	assert.Equal(t, "PutBlock", s.Tag(tagOperation))
+	assert.Equal(t, "account", s.Tag(tagAccount))
+	assert.Equal(t, "request-1", s.Tag(tagRequestID))
+	assert.Equal(t, http.MethodPut, s.Tag(ext.HTTPMethod))
+	assert.Equal(t, "https://account.blob.core.windows.net/container/blob", s.Tag(ext.HTTPURL))
+	assert.Equal(t, "201", s.Tag(ext.HTTPCode))
+	assert.Nil(t, s.Tag(ext.Error))
+
+	// the span is in the context of the request sent by the transport
+	span, ok := tracer.SpanFromContext(transport.req.Context())
+	require.True(t, ok)
+	assert.Equal(t, s.SpanID(), span.Context().SpanID())
+}
+
+func TestPolicyError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	sendErr := errors.New("connection refused")
+	_, err := sendRequest(t, &testTransport{err: sendErr}, http.MethodGet, "https://account.queue.core.windows.net/queue/messages")
+	require.Error(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.NotNil(t, spans[0].Tag(ext.Error))
+}
+
+func TestPolicyServerError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	resp, err := sendRequest(t, &testTransport{status: http.StatusServiceUnavailable}, http.MethodGet, "https://account.blob.core.windows.net/container/blob")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "503", spans[0].Tag(ext.HTTPCode))
+	assert.Equal(t, true, spans[0].Tag(ext.Error))
+}
+
+func TestServiceName(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	_, err := sendRequest(t, &testTransport{status: http.StatusOK}, http.MethodGet, "https://account.blob.core.windows.net/container/blob", WithServiceName("my-storage"))
+	require.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "my-storage", spans[0].Tag(ext.ServiceName))
+}
+
+func TestParseRequest(t *testing.T) {
+	for _, tt := range []struct {
+		method string
+		url    string
+		header http.Header
+		want   requestInfo
+	}{
+		{http.MethodGet, "https://account.blob.core.windows.net/?comp=list", nil, requestInfo{"blob", "GetList", "account"}},
+		{http.MethodPut, "https://account.blob.core.windows.net/container?restype=container", nil, requestInfo{"blob", "PutContainer", "account"}},
+		{http.MethodGet, "https://account.blob.core.windows.net/container/dir/blob", nil, requestInfo{"blob", "GetBlob", "account"}},
+		{http.MethodPut, "https://account.blob.core.windows.net/container/blob?comp=blocklist", nil, requestInfo{"blob", "PutBlockList", "account"}},
+		{http.MethodPost, "https://account.queue.core.windows.net/queue/messages", nil, requestInfo{"queue", "PostMessages", "account"}},
+		{http.MethodPost, "https://account.table.core.windows.net/Tables", nil, requestInfo{"table", "PostTable", "account"}},
+		{http.MethodGet, "https://account.table.core.windows.net/users(PartitionKey='a',RowKey='b')", nil, requestInfo{"table", "GetEntity", "account"}},
+		{http.MethodGet, "https://account.documents.azure.com/dbs/db/colls/users/docs/1", nil, requestInfo{"cosmosdb", "GetItem", "account"}},
+		{http.MethodPost, "https://account.documents.azure.com/dbs/db/colls/users/docs", http.Header{"X-Ms-Documentdb-Isquery": []string{"true"}}, requestInfo{"cosmosdb", "QueryItem", "account"}},
+		{http.MethodGet, "https://account.documents.azure.com/", nil, requestInfo{"cosmosdb", "GetAccount", "account"}},
+		{http.MethodPut, "https://namespace.servicebus.windows.net/queue", nil, requestInfo{"servicebus", "PutEntity", "namespace"}},
+		{http.MethodGet, "https://vault.vault.azure.net/secrets/password/1", nil, requestInfo{"keyvault", "GetSecret", "vault"}},
+		{http.MethodGet, "https://management.azure.com/subscriptions/1/resourceGroups", nil, requestInfo{"resourcemanager", "Get", ""}},
+		{http.MethodGet, "http://127.0.0.1:10000/devstoreaccount1/container", nil, requestInfo{"127.0.0.1", "Get", ""}},
+	} {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, nil)
+			require.NoError(t, err)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			assert.Equal(t, tt.want, parseRequest(req))
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package azcore_test
+
+import (
+	"context"
+	"log"
+
+	azcoretrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/Azure/azure-sdk-for-go/sdk/azcore"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+func Example() {
+	// The policy is added to the options of the clients of the Azure SDK, e.g.
+	// azblob.ClientOptions{ClientOptions: opts}.
+	opts := policy.ClientOptions{
+		PerCallPolicies: []policy.Policy{azcoretrace.NewPolicy()},
+	}
+	pl := runtime.NewPipeline("example", "v1.0.0", runtime.PipelineOptions{}, &opts)
+	req, err := runtime.NewRequest(context.Background(), "GET", "https://account.blob.core.windows.net/container/blob")
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+}
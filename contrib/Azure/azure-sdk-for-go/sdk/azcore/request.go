@@ -0,0 +1,178 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package azcore
+
+import (
+	"net/http"
+	"strings"
+)
+
+// requestInfo describes the Azure operation performed by a request.
+type requestInfo struct {
+	service   string // e.g. "blob"
+	operation string // e.g. "PutBlock"
+	account   string // name of the storage account, or namespace, if any
+}
+
+// hostServices maps the suffixes of the hosts of the Azure services to the
+// name of the service.
+var hostServices = []struct {
+	suffix  string
+	service string
+}{
+	{".blob.core.windows.net", "blob"},
+	{".queue.core.windows.net", "queue"},
+	{".file.core.windows.net", "file"},
+	{".table.core.windows.net", "table"},
+	{".dfs.core.windows.net", "datalake"},
+	{".table.cosmos.azure.com", "table"},
+	{".documents.azure.com", "cosmosdb"},
+	{".servicebus.windows.net", "servicebus"},
+	{".vault.azure.net", "keyvault"},
+	{".azconfig.io", "appconfig"},
+}
+
+// storageResources are the names of the resources of the storage services,
+// addressed by the number of segments of the path: the service itself, its
+// containers and their items.
+var storageResources = map[string][3]string{
+	"blob":     {"Service", "Container", "Blob"},
+	"queue":    {"Service", "Queue", "Messages"},
+	"file":     {"Service", "Share", "File"},
+	"datalake": {"Service", "Filesystem", "Path"},
+}
+
+// storageComponents maps the values of the "comp" and "restype" query
+// parameters of the storage services to their name in the operations.
+var storageComponents = map[string]string{
+	"acl":         "ACL",
+	"appendblock": "AppendBlock",
+	"batch":       "Batch",
+	"block":       "Block",
+	"blocklist":   "BlockList",
+	"container":   "Container",
+	"copy":        "Copy",
+	"lease":       "Lease",
+	"list":        "List",
+	"metadata":    "Metadata",
+	"page":        "Page",
+	"pagelist":    "PageList",
+	"properties":  "Properties",
+	"query":       "Query",
+	"service":     "Service",
+	"share":       "Share",
+	"snapshot":    "Snapshot",
+	"stats":       "Stats",
+	"tags":        "Tags",
+}
+
+// cosmosResources maps the types of the resources of Cosmos DB, as found in
+// the paths, to their name in the operations.
+var cosmosResources = map[string]string{
+	"dbs":         "Database",
+	"colls":       "Container",
+	"docs":        "Item",
+	"sprocs":      "StoredProcedure",
+	"triggers":    "Trigger",
+	"udfs":        "UserDefinedFunction",
+	"users":       "User",
+	"permissions": "Permission",
+	"offers":      "Offer",
+	"pkranges":    "PartitionKeyRange",
+}
+
+// parseRequest returns the service and operation of an Azure request, found
+// in its host, method and path.
+func parseRequest(req *http.Request) requestInfo {
+	host := req.URL.Hostname()
+	info := requestInfo{service: host}
+	if host == "management.azure.com" {
+		info.service = "resourcemanager"
+	}
+	for _, hs := range hostServices {
+		if strings.HasSuffix(host, hs.suffix) {
+			info.service = hs.service
+			info.account = strings.TrimSuffix(host, hs.suffix)
+			break
+		}
+	}
+	verb := title(req.Method)
+	path := strings.Trim(req.URL.Path, "/")
+	var segments []string
+	if path != "" {
+		segments = strings.Split(path, "/")
+	}
+	switch info.service {
+	case "blob", "queue", "file", "datalake":
+		info.operation = verb + storageOperation(info.service, req, segments)
+	case "table":
+		if len(segments) > 0 && strings.HasPrefix(segments[0], "Tables") {
+			info.operation = verb + "Table"
+		} else {
+			info.operation = verb + "Entity"
+		}
+	case "cosmosdb":
+		// the paths alternate types of resources and their ids, e.g.
+		// /dbs/{db}/colls/{container}/docs/{id}
+		resource := "Account"
+		if n := len(segments); n > 0 {
+			typ := segments[(n-1)&^1]
+			if name, ok := cosmosResources[typ]; ok {
+				resource = name
+			} else {
+				resource = title(typ)
+			}
+		}
+		if req.Method == http.MethodPost && req.Header.Get("x-ms-documentdb-isquery") == "true" {
+			verb = "Query"
+		}
+		info.operation = verb + resource
+	case "servicebus":
+		if len(segments) > 0 && segments[len(segments)-1] == "messages" {
+			info.operation = verb + "Messages"
+		} else {
+			info.operation = verb + "Entity"
+		}
+	case "keyvault":
+		// e.g. /secrets/{name}/{version}
+		if len(segments) > 0 {
+			info.operation = verb + title(strings.TrimSuffix(segments[0], "s"))
+		} else {
+			info.operation = verb
+		}
+	default:
+		info.operation = verb
+	}
+	return info
+}
+
+// storageOperation returns the resource of a request to a storage service,
+// e.g. "Blob" or "BlockList".
+func storageOperation(service string, req *http.Request, segments []string) string {
+	q := req.URL.Query()
+	for _, param := range []string{"comp", "restype"} {
+		if v := q.Get(param); v != "" {
+			if name, ok := storageComponents[v]; ok {
+				return name
+			}
+			return title(v)
+		}
+	}
+	depth := len(segments)
+	if depth > 2 {
+		depth = 2
+	}
+	return storageResources[service][depth]
+}
+
+// title returns s with its first letter in upper case and the others in lower
+// case, e.g. "Get" for "GET".
+func title(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + strings.ToLower(s[1:])
+}
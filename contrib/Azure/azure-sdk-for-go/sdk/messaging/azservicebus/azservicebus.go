@@ -0,0 +1,248 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package azservicebus provides functions to trace the Azure/azure-sdk-for-go/sdk/messaging/azservicebus package (https://github.com/Azure/azure-sdk-for-go/tree/main/sdk/messaging/azservicebus).
+//
+// The sent messages are traced, and their span context is propagated in their
+// application properties, along with their Data Streams pathway when Data
+// Streams Monitoring is enabled. The received messages are traced as well, and
+// checkpointed in the Data Streams map.
+package azservicebus // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+
+import (
+	"context"
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+)
+
+const componentName = "Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// WrapClient wraps an azservicebus.Client so that the messages sent by its
+// senders and received by its receivers are traced.
+func WrapClient(c *azservicebus.Client, opts ...Option) *Client {
+	wrapped := &Client{
+		Client: c,
+		cfg:    newConfig(opts...),
+	}
+	log.Debug("contrib/Azure/azure-sdk-for-go/sdk/messaging/azservicebus: Wrapping Client: %#v", wrapped.cfg)
+	return wrapped
+}
+
+// A Client wraps an azservicebus.Client.
+type Client struct {
+	*azservicebus.Client
+	cfg *config
+}
+
+// NewSender calls azservicebus.Client.NewSender and wraps the returned
+// sender, so that the messages it sends are traced.
+func (c *Client) NewSender(queueOrTopic string, options *azservicebus.NewSenderOptions) (*Sender, error) {
+	s, err := c.Client.NewSender(queueOrTopic, options)
+	if err != nil {
+		return nil, err
+	}
+	return &Sender{Sender: s, cfg: c.cfg, entity: queueOrTopic}, nil
+}
+
+// NewReceiverForQueue calls azservicebus.Client.NewReceiverForQueue and wraps
+// the returned receiver, so that the messages it receives are traced.
+func (c *Client) NewReceiverForQueue(queue string, options *azservicebus.ReceiverOptions) (*Receiver, error) {
+	r, err := c.Client.NewReceiverForQueue(queue, options)
+	if err != nil {
+		return nil, err
+	}
+	return &Receiver{Receiver: r, cfg: c.cfg, entity: queue}, nil
+}
+
+// NewReceiverForSubscription calls azservicebus.Client.NewReceiverForSubscription
+// and wraps the returned receiver, so that the messages it receives are traced.
+func (c *Client) NewReceiverForSubscription(topic, subscription string, options *azservicebus.ReceiverOptions) (*Receiver, error) {
+	r, err := c.Client.NewReceiverForSubscription(topic, subscription, options)
+	if err != nil {
+		return nil, err
+	}
+	return &Receiver{Receiver: r, cfg: c.cfg, entity: topic, subscription: subscription}, nil
+}
+
+// A Sender wraps an azservicebus.Sender.
+type Sender struct {
+	*azservicebus.Sender
+	cfg    *config
+	entity string
+}
+
+// SendMessage calls azservicebus.Sender.SendMessage and traces the request.
+// The span context and the Data Streams pathway are injected into the
+// application properties of m.
+func (s *Sender) SendMessage(ctx context.Context, m *azservicebus.Message, options *azservicebus.SendMessageOptions) error {
+	span, ctx := s.startSendSpan(ctx, m)
+	err := s.Sender.SendMessage(ctx, m, options)
+	span.Finish(tracer.WithError(err))
+	return err
+}
+
+// NewMessageBatch calls azservicebus.Sender.NewMessageBatch and wraps the
+// returned batch, so that the messages added to it are traced as children of
+// the span found in ctx, if any.
+func (s *Sender) NewMessageBatch(ctx context.Context, options *azservicebus.MessageBatchOptions) (*MessageBatch, error) {
+	b, err := s.Sender.NewMessageBatch(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return &MessageBatch{MessageBatch: b, ctx: ctx, s: s}, nil
+}
+
+// SendMessageBatch calls azservicebus.Sender.SendMessageBatch and traces the
+// request.
+func (s *Sender) SendMessageBatch(ctx context.Context, batch *MessageBatch, options *azservicebus.SendMessageBatchOptions) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, s.cfg.producerSpanName, s.spanOptions(
+		tracer.Tag(ext.MessagingBatchMessageCount, batch.NumMessages()),
+	)...)
+	err := s.Sender.SendMessageBatch(ctx, batch.MessageBatch, options)
+	span.Finish(tracer.WithError(err))
+	return err
+}
+
+func (s *Sender) spanOptions(extraOpts ...ddtrace.StartSpanOption) []ddtrace.StartSpanOption {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(s.cfg.producerServiceName),
+		tracer.ResourceName("Send " + s.entity),
+		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindProducer),
+		tracer.Tag(ext.MessagingSystem, ext.MessagingSystemServiceBus),
+		tracer.Tag(ext.ServiceBusEntity, s.entity),
+	}
+	if !math.IsNaN(s.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, s.cfg.analyticsRate))
+	}
+	return append(opts, extraOpts...)
+}
+
+// startSendSpan starts the span of the sending of m, and injects its context
+// and the Data Streams pathway into the application properties of m.
+func (s *Sender) startSendSpan(ctx context.Context, m *azservicebus.Message) (ddtrace.Span, context.Context) {
+	var opts []ddtrace.StartSpanOption
+	if m.MessageID != nil {
+		opts = append(opts, tracer.Tag(ext.ServiceBusMessageID, *m.MessageID))
+	}
+	if m.ApplicationProperties == nil {
+		m.ApplicationProperties = map[string]interface{}{}
+	}
+	carrier := propertiesCarrier(m.ApplicationProperties)
+	span, ctx := tracer.StartSpanFromContext(ctx, s.cfg.producerSpanName, s.spanOptions(opts...)...)
+	if err := tracer.Inject(span.Context(), carrier); err != nil {
+		log.Debug("contrib/Azure/azure-sdk-for-go/sdk/messaging/azservicebus: Failed to inject span context into carrier, %v", err)
+	}
+	if p := datastreams.GetGlobalProcessor(); p != nil {
+		ctx = p.SetCheckpointWithParams(ctx,
+			datastreams.CheckpointParams{PayloadSize: int64(len(m.Body))},
+			"direction:out",
+			"topic:"+s.entity,
+			"type:servicebus",
+		)
+		datastreams.InjectToBase64Carrier(ctx, carrier)
+	}
+	return span, ctx
+}
+
+// A MessageBatch wraps an azservicebus.MessageBatch.
+type MessageBatch struct {
+	*azservicebus.MessageBatch
+	ctx context.Context
+	s   *Sender
+}
+
+// AddMessage calls azservicebus.MessageBatch.AddMessage. The addition of m is
+// traced, and the span context and the Data Streams pathway are injected into
+// the application properties of m, as the messages of the batch are serialized
+// when they are added.
+func (b *MessageBatch) AddMessage(m *azservicebus.Message, options *azservicebus.AddMessageOptions) error {
+	span, _ := b.s.startSendSpan(b.ctx, m)
+	err := b.MessageBatch.AddMessage(m, options)
+	span.Finish(tracer.WithError(err))
+	return err
+}
+
+// A Receiver wraps an azservicebus.Receiver.
+type Receiver struct {
+	*azservicebus.Receiver
+	cfg          *config
+	entity       string
+	subscription string
+}
+
+// ReceiveMessages calls azservicebus.Receiver.ReceiveMessages and traces
+// every received message. The span context and the Data Streams pathway of the
+// receiver are injected into the application properties of each message, so
+// that they can be retrieved using ExtractSpanContext and ContextWithMessage.
+func (r *Receiver) ReceiveMessages(ctx context.Context, maxMessages int, options *azservicebus.ReceiveMessagesOptions) ([]*azservicebus.ReceivedMessage, error) {
+	msgs, err := r.Receiver.ReceiveMessages(ctx, maxMessages, options)
+	for _, m := range msgs {
+		r.traceMessage(m)
+	}
+	return msgs, err
+}
+
+// traceMessage records the reception of m, and re-injects the resulting span
+// context and pathway into its application properties.
+func (r *Receiver) traceMessage(m *azservicebus.ReceivedMessage) {
+	opts := []tracer.StartSpanOption{
+		tracer.ServiceName(r.cfg.consumerServiceName),
+		tracer.ResourceName("Receive " + r.entity),
+		tracer.SpanType(ext.SpanTypeMessageConsumer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindConsumer),
+		tracer.Tag(ext.MessagingSystem, ext.MessagingSystemServiceBus),
+		tracer.Tag(ext.ServiceBusEntity, r.entity),
+		tracer.Tag(ext.ServiceBusMessageID, m.MessageID),
+		tracer.Tag(ext.ServiceBusDeliveryCount, m.DeliveryCount),
+		tracer.Measured(),
+	}
+	if r.subscription != "" {
+		opts = append(opts, tracer.Tag(ext.ServiceBusSubscription, r.subscription))
+	}
+	if !math.IsNaN(r.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, r.cfg.analyticsRate))
+	}
+	if m.ApplicationProperties == nil {
+		m.ApplicationProperties = map[string]interface{}{}
+	}
+	carrier := propertiesCarrier(m.ApplicationProperties)
+	if spanctx, err := tracer.Extract(carrier); err == nil {
+		opts = append(opts, tracer.ChildOf(spanctx))
+	}
+	span := tracer.StartSpan(r.cfg.consumerSpanName, opts...)
+	// reinject the span context so consumers can pick it up
+	if err := tracer.Inject(span.Context(), carrier); err != nil {
+		log.Debug("contrib/Azure/azure-sdk-for-go/sdk/messaging/azservicebus: Failed to inject span context into carrier, %v", err)
+	}
+	span.Finish()
+	if p := datastreams.GetGlobalProcessor(); p != nil {
+		edges := []string{"direction:in"}
+		if r.subscription != "" {
+			edges = append(edges, "group:"+r.subscription)
+		}
+		edges = append(edges, "topic:"+r.entity, "type:servicebus")
+		ctx := datastreams.ExtractFromBase64Carrier(context.Background(), carrier)
+		ctx = p.SetCheckpointWithParams(ctx,
+			datastreams.CheckpointParams{PayloadSize: int64(len(m.Body))},
+			edges...,
+		)
+		datastreams.InjectToBase64Carrier(ctx, carrier)
+	}
+}
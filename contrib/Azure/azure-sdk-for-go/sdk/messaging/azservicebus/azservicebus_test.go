@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package azservicebus
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendReceive(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	cfg := newConfig()
+	s := &Sender{cfg: cfg, entity: "topic"}
+	id := "message-1"
+	m := &azservicebus.Message{Body: []byte("hello"), MessageID: &id}
+	span, _ := s.startSendSpan(context.Background(), m)
+	span.Finish()
+
+	r := &Receiver{cfg: cfg, entity: "topic", subscription: "subscription"}
+	rm := &azservicebus.ReceivedMessage{
+		ApplicationProperties: m.ApplicationProperties,
+		Body:                  m.Body,
+		MessageID:             id,
+		DeliveryCount:         2,
+	}
+	r.traceMessage(rm)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	s0, s1 := spans[0], spans[1]
+	assert.Equal(t, "servicebus.send", s0.OperationName())
+	assert.Equal(t, "Send topic", s0.Tag(ext.ResourceName))
+	assert.Equal(t, "servicebus", s0.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanKindProducer, s0.Tag(ext.SpanKind))
+	assert.Equal(t, ext.MessagingSystemServiceBus, s0.Tag(ext.MessagingSystem))
+	assert.Equal(t, "topic", s0.Tag(ext.ServiceBusEntity))
+	assert.Equal(t, "message-1", s0.Tag(ext.ServiceBusMessageID))
+	assert.Equal(t, componentName, s0.Tag(ext.Component))
+
+	assert.Equal(t, "servicebus.receive", s1.OperationName())
+	assert.Equal(t, "Receive topic", s1.Tag(ext.ResourceName))
+	assert.Equal(t, ext.SpanKindConsumer, s1.Tag(ext.SpanKind))
+	assert.Equal(t, "topic", s1.Tag(ext.ServiceBusEntity))
+	assert.Equal(t, "subscription", s1.Tag(ext.ServiceBusSubscription))
+	assert.Equal(t, "message-1", s1.Tag(ext.ServiceBusMessageID))
+	assert.Equal(t, uint32(2), s1.Tag(ext.ServiceBusDeliveryCount))
+	assert.Equal(t, s0.SpanID(), s1.ParentID())
+	assert.Equal(t, s0.TraceID(), s1.TraceID())
+
+	spanctx, err := ExtractSpanContext(rm)
+	require.NoError(t, err)
+	assert.Equal(t, s1.SpanID(), spanctx.SpanID())
+}
+
+func TestDataStreams(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	p := datastreams.NewProcessor(&statsd.NoOpClient{}, "env", "service", &url.URL{Scheme: "http", Host: "agent-address"}, nil)
+	datastreams.SetGlobalProcessor(p)
+	defer datastreams.SetGlobalProcessor(nil)
+
+	cfg := newConfig()
+	s := &Sender{cfg: cfg, entity: "queue"}
+	m := &azservicebus.Message{Body: []byte("hello")}
+	span, ctx := s.startSendSpan(context.Background(), m)
+	span.Finish()
+	produced, ok := datastreams.PathwayFromContext(ctx)
+	require.True(t, ok)
+	assert.Contains(t, m.ApplicationProperties, datastreams.PropagationKeyBase64)
+
+	r := &Receiver{cfg: cfg, entity: "queue"}
+	rm := &azservicebus.ReceivedMessage{ApplicationProperties: m.ApplicationProperties, Body: m.Body}
+	r.traceMessage(rm)
+	consumed, ok := datastreams.PathwayFromContext(ContextWithMessage(context.Background(), rm))
+	require.True(t, ok)
+	assert.NotEqual(t, produced.GetHash(), consumed.GetHash())
+	assert.Equal(t, produced.PathwayStart(), consumed.PathwayStart())
+}
+
+func TestNoDataStreams(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	s := &Sender{cfg: newConfig(), entity: "queue"}
+	m := &azservicebus.Message{}
+	span, ctx := s.startSendSpan(context.Background(), m)
+	span.Finish()
+	_, ok := datastreams.PathwayFromContext(ctx)
+	assert.False(t, ok)
+	assert.NotContains(t, m.ApplicationProperties, datastreams.PropagationKeyBase64)
+}
+
+func TestReceivedMessageWithoutProperties(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	r := &Receiver{cfg: newConfig(), entity: "queue"}
+	rm := &azservicebus.ReceivedMessage{}
+	r.traceMessage(rm)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, uint64(0), spans[0].ParentID())
+	spanctx, err := ExtractSpanContext(rm)
+	require.NoError(t, err)
+	assert.Equal(t, spans[0].SpanID(), spanctx.SpanID())
+}
@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package azservicebus_test
+
+import (
+	"context"
+	"log"
+
+	servicebustrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+)
+
+func Example() {
+	c, err := azservicebus.NewClientFromConnectionString("Endpoint=sb://namespace.servicebus.windows.net/;SharedAccessKeyName=key;SharedAccessKey=secret", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := servicebustrace.WrapClient(c, servicebustrace.WithServiceName("my-servicebus"))
+	ctx := context.Background()
+
+	sender, err := client.NewSender("queue", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer sender.Close(ctx)
+	if err := sender.SendMessage(ctx, &azservicebus.Message{Body: []byte("hello")}, nil); err != nil {
+		log.Fatal(err)
+	}
+
+	receiver, err := client.NewReceiverForQueue("queue", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer receiver.Close(ctx)
+	msgs, err := receiver.ReceiveMessages(ctx, 10, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, m := range msgs {
+		// the span context of the reception is found in the message, and its
+		// Data Streams pathway is carried by the messages sent using msgCtx.
+		msgCtx := servicebustrace.ContextWithMessage(ctx, m)
+		if spanctx, err := servicebustrace.ExtractSpanContext(m); err == nil {
+			log.Printf("received message of trace %d", spanctx.TraceID())
+		}
+		if err := receiver.CompleteMessage(msgCtx, m, nil); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package azservicebus
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+)
+
+// A propertiesCarrier implements TextMapReader/TextMapWriter for
+// extracting/injecting traces and Data Streams pathways on the application
+// properties of a Service Bus message.
+type propertiesCarrier map[string]interface{}
+
+var _ interface {
+	tracer.TextMapReader
+	tracer.TextMapWriter
+} = (*propertiesCarrier)(nil)
+
+// ForeachKey conforms to the TextMapReader interface.
+func (c propertiesCarrier) ForeachKey(handler func(key, val string) error) error {
+	for k, v := range c {
+		s, ok := v.(string)
+		if !ok {
+			// only string properties are set by the tracer
+			continue
+		}
+		if err := handler(k, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set implements TextMapWriter
+func (c propertiesCarrier) Set(key, val string) {
+	c[key] = val
+}
+
+// ExtractSpanContext retrieves the SpanContext from a received message.
+func ExtractSpanContext(m *azservicebus.ReceivedMessage) (ddtrace.SpanContext, error) {
+	return tracer.Extract(propertiesCarrier(m.ApplicationProperties))
+}
+
+// ContextWithMessage returns a copy of ctx holding the Data Streams pathway
+// found in the application properties of m, if any. Passing the returned
+// context to Sender.SendMessage links the sent messages to the received one in
+// the Data Streams map.
+func ContextWithMessage(ctx context.Context, m *azservicebus.ReceivedMessage) context.Context {
+	return datastreams.ExtractFromBase64Carrier(ctx, propertiesCarrier(m.ApplicationProperties))
+}
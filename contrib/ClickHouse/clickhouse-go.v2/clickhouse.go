@@ -0,0 +1,274 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package clickhouse provides functions to trace the ClickHouse/clickhouse-go/v2 package (https://github.com/ClickHouse/clickhouse-go).
+package clickhouse
+
+import (
+	"context"
+	"math"
+	"net"
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/DataDog/datadog-agent/pkg/obfuscate"
+)
+
+const componentName = "ClickHouse/clickhouse-go.v2"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Names of the spans.
+const (
+	spanNameQuery       = "clickhouse.query"
+	spanNameExec        = "clickhouse.exec"
+	spanNameAsyncInsert = "clickhouse.async_insert"
+	spanNameBatchSend   = "clickhouse.batch.send"
+	spanNamePing        = "clickhouse.ping"
+)
+
+// Tags set on the spans.
+const (
+	tagQueryType       = "clickhouse.query_type"
+	tagAsyncInsert     = "clickhouse.async_insert"
+	tagAsyncInsertWait = "clickhouse.async_insert.wait"
+	tagBatchRows       = "clickhouse.batch.rows"
+	tagBlocks          = "clickhouse.blocks"
+	tagRowsRead        = "clickhouse.rows_read"
+	tagBytesRead       = "clickhouse.bytes_read"
+)
+
+// Open is like clickhouse.Open, but the returned connection is traced.
+func Open(opt *clickhouse.Options, opts ...Option) (driver.Conn, error) {
+	conn, err := clickhouse.Open(opt)
+	if err != nil {
+		return nil, err
+	}
+	return WrapConn(conn, opt, opts...), nil
+}
+
+// WrapConn returns conn, traced. The spans are tagged with the server address
+// and the database found in opt, which can be nil.
+func WrapConn(conn driver.Conn, opt *clickhouse.Options, opts ...Option) driver.Conn {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/ClickHouse/clickhouse-go.v2: Wrapping Conn: %#v", cfg)
+	tc := &tracedConn{Conn: conn, cfg: cfg}
+	if opt != nil {
+		tc.database = opt.Auth.Database
+		if len(opt.Addr) > 0 {
+			tc.host, tc.port, _ = net.SplitHostPort(opt.Addr[0])
+		}
+	}
+	return tc
+}
+
+// tracedConn is a driver.Conn tracing the queries sent to the server.
+type tracedConn struct {
+	driver.Conn
+	cfg      *config
+	database string
+	host     string
+	port     string
+}
+
+// Select implements driver.Conn.
+func (tc *tracedConn) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	span, ctx := tc.startSpan(ctx, spanNameQuery, "Select", query)
+	err := tc.Conn.Select(ctx, dest, query, args...)
+	tc.finishSpan(span, err)
+	return err
+}
+
+// Query implements driver.Conn. The span of the query is finished when the
+// returned rows are closed.
+func (tc *tracedConn) Query(ctx context.Context, query string, args ...interface{}) (driver.Rows, error) {
+	span, ctx := tc.startSpan(ctx, spanNameQuery, "Query", query)
+	rows, err := tc.Conn.Query(ctx, query, args...)
+	if err != nil {
+		tc.finishSpan(span, err)
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, span: span, tc: tc}, nil
+}
+
+// QueryRow implements driver.Conn.
+func (tc *tracedConn) QueryRow(ctx context.Context, query string, args ...interface{}) driver.Row {
+	span, ctx := tc.startSpan(ctx, spanNameQuery, "QueryRow", query)
+	row := tc.Conn.QueryRow(ctx, query, args...)
+	tc.finishSpan(span, row.Err())
+	return row
+}
+
+// Exec implements driver.Conn.
+func (tc *tracedConn) Exec(ctx context.Context, query string, args ...interface{}) error {
+	span, ctx := tc.startSpan(ctx, spanNameExec, "Exec", query)
+	err := tc.Conn.Exec(ctx, query, args...)
+	tc.finishSpan(span, err)
+	return err
+}
+
+// AsyncInsert implements driver.Conn. The span is tagged as an asynchronous
+// insert, and whether it waited for the data to be flushed by the server.
+func (tc *tracedConn) AsyncInsert(ctx context.Context, query string, wait bool) error {
+	span, ctx := tc.startSpan(ctx, spanNameAsyncInsert, "AsyncInsert", query,
+		tracer.Tag(tagAsyncInsert, true),
+		tracer.Tag(tagAsyncInsertWait, wait),
+	)
+	err := tc.Conn.AsyncInsert(ctx, query, wait)
+	tc.finishSpan(span, err)
+	return err
+}
+
+// PrepareBatch implements driver.Conn. The sending of the returned batch is
+// traced.
+func (tc *tracedConn) PrepareBatch(ctx context.Context, query string) (driver.Batch, error) {
+	batch, err := tc.Conn.PrepareBatch(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedBatch{Batch: batch, ctx: ctx, query: query, tc: tc}, nil
+}
+
+// Ping implements driver.Conn.
+func (tc *tracedConn) Ping(ctx context.Context) error {
+	span, ctx := tc.startSpan(ctx, spanNamePing, "Ping", "")
+	err := tc.Conn.Ping(ctx)
+	tc.finishSpan(span, err)
+	return err
+}
+
+func (tc *tracedConn) startSpan(ctx context.Context, spanName, queryType, query string, extraOpts ...ddtrace.StartSpanOption) (ddtrace.Span, context.Context) {
+	resource := queryType
+	if query != "" {
+		resource = query
+		if !tc.cfg.rawQuery {
+			resource = obfuscateQuery(query, queryType)
+		}
+	}
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(tc.cfg.serviceName),
+		tracer.SpanType(ext.SpanTypeSQL),
+		tracer.ResourceName(resource),
+		tracer.Tag(tagQueryType, queryType),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		tracer.Tag(ext.DBSystem, "clickhouse"),
+	}
+	if tc.database != "" {
+		opts = append(opts, tracer.Tag(ext.DBName, tc.database))
+	}
+	if tc.host != "" {
+		opts = append(opts, tracer.Tag(ext.TargetHost, tc.host), tracer.Tag(ext.TargetPort, tc.port))
+	}
+	if !math.IsNaN(tc.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, tc.cfg.analyticsRate))
+	}
+	opts = append(opts, extraOpts...)
+	span, ctx := tracer.StartSpanFromContext(ctx, spanName, opts...)
+	if tc.cfg.profileInfo {
+		ctx = clickhouse.Context(ctx, clickhouse.WithProfileInfo(func(p *clickhouse.ProfileInfo) {
+			// the profile info is sent once per query, at its end.
+			span.SetTag(tagBlocks, p.Blocks)
+			span.SetTag(tagRowsRead, p.Rows)
+			span.SetTag(tagBytesRead, p.Bytes)
+		}))
+	}
+	return span, ctx
+}
+
+func (tc *tracedConn) finishSpan(span ddtrace.Span, err error) {
+	if err != nil && (tc.cfg.errCheck == nil || tc.cfg.errCheck(err)) {
+		span.Finish(tracer.WithError(err))
+		return
+	}
+	span.Finish()
+}
+
+// tracedRows finishes the span of a query when it is closed.
+type tracedRows struct {
+	driver.Rows
+	span      ddtrace.Span
+	tc        *tracedConn
+	closeOnce sync.Once
+}
+
+// Close implements driver.Rows.
+func (r *tracedRows) Close() error {
+	err := r.Rows.Close()
+	r.closeOnce.Do(func() {
+		if err == nil {
+			err = r.Rows.Err()
+		}
+		r.tc.finishSpan(r.span, err)
+	})
+	return err
+}
+
+// tracedBatch traces the sending of a batch.
+type tracedBatch struct {
+	driver.Batch
+	ctx   context.Context
+	query string
+	tc    *tracedConn
+	rows  int
+}
+
+// Append implements driver.Batch.
+func (b *tracedBatch) Append(v ...interface{}) error {
+	err := b.Batch.Append(v...)
+	if err == nil {
+		b.rows++
+	}
+	return err
+}
+
+// AppendStruct implements driver.Batch.
+func (b *tracedBatch) AppendStruct(v interface{}) error {
+	err := b.Batch.AppendStruct(v)
+	if err == nil {
+		b.rows++
+	}
+	return err
+}
+
+// Send implements driver.Batch.
+func (b *tracedBatch) Send() error {
+	span, _ := b.tc.startSpan(b.ctx, spanNameBatchSend, "Batch", b.query, tracer.Tag(tagBatchRows, b.rows))
+	err := b.Batch.Send()
+	b.tc.finishSpan(span, err)
+	return err
+}
+
+var (
+	obfuscatorOnce sync.Once
+	obfuscator     *obfuscate.Obfuscator
+)
+
+// obfuscateQuery returns query with its literals replaced with "?". It
+// returns fallback when the query can't be parsed.
+func obfuscateQuery(query, fallback string) string {
+	obfuscatorOnce.Do(func() {
+		obfuscator = obfuscate.NewObfuscator(obfuscate.Config{})
+	})
+	oq, err := obfuscator.ObfuscateSQLString(query)
+	if err != nil {
+		log.Debug("contrib/ClickHouse/clickhouse-go.v2: failed to obfuscate query: %v", err)
+		return fallback
+	}
+	return oq.Query
+}
@@ -0,0 +1,173 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConn implements the methods of driver.Conn used by the tests.
+type testConn struct {
+	driver.Conn
+	err error
+}
+
+func (c *testConn) Exec(context.Context, string, ...interface{}) error { return c.err }
+
+func (c *testConn) AsyncInsert(context.Context, string, bool) error { return c.err }
+
+func (c *testConn) Query(context.Context, string, ...interface{}) (driver.Rows, error) {
+	return &testRows{}, c.err
+}
+
+func (c *testConn) PrepareBatch(context.Context, string) (driver.Batch, error) {
+	return &testBatch{}, c.err
+}
+
+type testRows struct {
+	driver.Rows
+}
+
+func (r *testRows) Close() error { return nil }
+
+func (r *testRows) Err() error { return nil }
+
+type testBatch struct {
+	driver.Batch
+}
+
+func (b *testBatch) Append(...interface{}) error { return nil }
+
+func (b *testBatch) Send() error { return nil }
+
+var testOptions = &clickhouse.Options{
+	Addr: []string{"127.0.0.1:9000"},
+	Auth: clickhouse.Auth{Database: "default"},
+}
+
+func TestExec(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	conn := WrapConn(&testConn{}, testOptions)
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	require.NoError(t, conn.Exec(ctx, "INSERT INTO users VALUES (1, 'jane')"))
+	parent.Finish()
+
+	spans := mocktracer.FinishedSpansByOperation(mt, spanNameExec)
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "INSERT INTO users VALUES ( ? )", s.Tag(ext.ResourceName))
+	assert.Equal(t, "Exec", s.Tag(tagQueryType))
+	assert.Equal(t, defaultServiceName, s.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanTypeSQL, s.Tag(ext.SpanType))
+	assert.Equal(t, ext.SpanKindClient, s.Tag(ext.SpanKind))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, "clickhouse", s.Tag(ext.DBSystem))
+	assert.Equal(t, "default", s.Tag(ext.DBName))
+	assert.Equal(t, "127.0.0.1", s.Tag(ext.TargetHost))
+	assert.Equal(t, "9000", s.Tag(ext.TargetPort))
+	assert.Equal(t, parent.Context().SpanID(), s.ParentID())
+}
+
+func TestRawQuery(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	conn := WrapConn(&testConn{}, nil, WithRawQuery(true))
+	require.NoError(t, conn.Exec(context.Background(), "SELECT 1"))
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "SELECT 1", spans[0].Tag(ext.ResourceName))
+	assert.Nil(t, spans[0].Tag(ext.TargetHost))
+}
+
+func TestAsyncInsert(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	conn := WrapConn(&testConn{}, testOptions)
+	require.NoError(t, conn.AsyncInsert(context.Background(), "INSERT INTO events VALUES (1)", false))
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, spanNameAsyncInsert, spans[0].OperationName())
+	assert.Equal(t, true, spans[0].Tag(tagAsyncInsert))
+	assert.Equal(t, false, spans[0].Tag(tagAsyncInsertWait))
+}
+
+func TestQuery(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	conn := WrapConn(&testConn{}, testOptions)
+	rows, err := conn.Query(context.Background(), "SELECT name FROM users")
+	require.NoError(t, err)
+	assert.Empty(t, mt.FinishedSpans())
+	require.NoError(t, rows.Close())
+	require.NoError(t, rows.Close())
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, spanNameQuery, spans[0].OperationName())
+	assert.Equal(t, "SELECT name FROM users", spans[0].Tag(ext.ResourceName))
+}
+
+func TestBatch(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	conn := WrapConn(&testConn{}, testOptions)
+	batch, err := conn.PrepareBatch(context.Background(), "INSERT INTO users")
+	require.NoError(t, err)
+	require.NoError(t, batch.Append(1, "jane"))
+	require.NoError(t, batch.Append(2, "john"))
+	require.NoError(t, batch.Send())
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, spanNameBatchSend, spans[0].OperationName())
+	assert.Equal(t, "INSERT INTO users", spans[0].Tag(ext.ResourceName))
+	assert.Equal(t, 2, spans[0].Tag(tagBatchRows))
+}
+
+func TestErrorCheck(t *testing.T) {
+	errIgnored := errors.New("ignored")
+	for name, tc := range map[string]struct {
+		opts  []Option
+		error bool
+	}{
+		"default": {error: true},
+		"errcheck": {
+			opts:  []Option{WithErrorCheck(func(err error) bool { return !errors.Is(err, errIgnored) })},
+			error: false,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			conn := WrapConn(&testConn{err: errIgnored}, nil, tc.opts...)
+			assert.Equal(t, errIgnored, conn.Exec(context.Background(), "SELECT 1"))
+
+			spans := mt.FinishedSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tc.error, spans[0].Tag(ext.Error) != nil)
+		})
+	}
+}
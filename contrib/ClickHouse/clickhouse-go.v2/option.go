@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package clickhouse
+
+import (
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+)
+
+const defaultServiceName = "clickhouse"
+
+type config struct {
+	serviceName   string
+	analyticsRate float64
+	rawQuery      bool
+	profileInfo   bool
+	errCheck      func(err error) bool
+}
+
+// Option represents an option that can be used to customize the tracing of
+// the connections.
+type Option func(*config)
+
+func defaults(cfg *config) {
+	cfg.serviceName = namingschema.NewDefaultServiceName(
+		defaultServiceName,
+		namingschema.WithOverrideV0(defaultServiceName),
+	).GetName()
+	if internal.BoolEnv("DD_TRACE_CLICKHOUSE_ANALYTICS_ENABLED", false) {
+		cfg.analyticsRate = 1.0
+	} else {
+		cfg.analyticsRate = math.NaN()
+	}
+}
+
+// WithServiceName sets the given service name for the connections.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithRawQuery sets whether the resource names of the spans are the raw
+// queries. By default, the queries are obfuscated: their literals are replaced
+// with "?".
+func WithRawQuery(raw bool) Option {
+	return func(cfg *config) {
+		cfg.rawQuery = raw
+	}
+}
+
+// WithProfileInfo enables the tagging of the spans of the queries with the
+// number of blocks, rows and bytes read, as reported by the server. It replaces
+// any profile info callback set on the context of the queries with
+// clickhouse.WithProfileInfo.
+func WithProfileInfo() Option {
+	return func(cfg *config) {
+		cfg.profileInfo = true
+	}
+}
+
+// WithErrorCheck specifies a function fn which determines whether the passed
+// error should be marked as an error.
+func WithErrorCheck(fn func(err error) bool) Option {
+	return func(cfg *config) {
+		cfg.errCheck = fn
+	}
+}
@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package functions_test
+
+import (
+	"context"
+	"net/http"
+
+	functionstrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/GoogleCloudPlatform/functions-framework-go/functions"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+)
+
+func Example() {
+	// Serverless mode is enabled by default in Cloud Functions, it is only
+	// set explicitly here for clarity.
+	tracer.Start(tracer.WithServerlessMode(true))
+
+	// Register the functions in the init function of the package, as
+	// required by the Functions Framework.
+	functionstrace.HTTP("HelloWorld", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("Hello, World!"))
+	})
+	functionstrace.CloudEvent("ProcessOrder", func(ctx context.Context, e event.Event) error {
+		span, _ := tracer.StartSpanFromContext(ctx, "process.order")
+		defer span.Finish()
+		return nil
+	})
+}
@@ -0,0 +1,133 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package functions provides functions to trace Google Cloud Functions
+// registered with the GoogleCloudPlatform/functions-framework-go package (https://github.com/GoogleCloudPlatform/functions-framework-go).
+//
+// The instances running functions may be frozen as soon as a request has been
+// responded to, so the tracer is flushed before the traced functions return.
+// The tracer should be started with tracer.WithServerlessMode, which is enabled
+// by default in Cloud Functions, so that flushes wait for the traces to be sent.
+package functions // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/GoogleCloudPlatform/functions-framework-go/functions"
+
+import (
+	"context"
+	"math"
+	"net/http"
+
+	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/GoogleCloudPlatform/functions-framework-go/functions"
+	"github.com/cloudevents/sdk-go/v2/event"
+)
+
+const componentName = "GoogleCloudPlatform/functions-framework-go/functions"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Tags set on the function spans.
+const (
+	tagFunctionName = "faas.name"
+	tagEventID      = "cloudevents.event_id"
+	tagEventSource  = "cloudevents.event_source"
+	tagEventType    = "cloudevents.event_type"
+	tagEventSubject = "cloudevents.event_subject"
+)
+
+// HTTP registers a traced HTTP function with the given name, using functions.HTTP.
+func HTTP(name string, fn func(http.ResponseWriter, *http.Request), opts ...Option) {
+	functions.HTTP(name, WrapHTTP(name, fn, opts...))
+}
+
+// WrapHTTP returns a traced version of the HTTP function fn with the given name.
+func WrapHTTP(name string, fn func(http.ResponseWriter, *http.Request), opts ...Option) func(http.ResponseWriter, *http.Request) {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/GoogleCloudPlatform/functions-framework-go/functions: Wrapping HTTP function %q: %#v", name, cfg)
+	spanOpts := []ddtrace.StartSpanOption{
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+		tracer.Tag(tagFunctionName, name),
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		spanOpts = append(spanOpts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	h := http.HandlerFunc(fn)
+	return func(w http.ResponseWriter, r *http.Request) {
+		httptrace.TraceAndServe(h, w, r, &httptrace.ServeConfig{
+			Service:  cfg.serviceName,
+			Resource: r.Method + " " + name,
+			SpanOpts: spanOpts,
+		})
+		if cfg.flush {
+			tracer.Flush()
+		}
+	}
+}
+
+// CloudEvent registers a traced CloudEvent function with the given name, using
+// functions.CloudEvent.
+func CloudEvent(name string, fn func(context.Context, event.Event) error, opts ...Option) {
+	functions.CloudEvent(name, WrapCloudEvent(name, fn, opts...))
+}
+
+// WrapCloudEvent returns a traced version of the CloudEvent function fn with
+// the given name. The span of the function is a child of the span context
+// found in the extensions of the event, such as the traceparent extension
+// defined by the Distributed Tracing extension of the CloudEvents specification.
+func WrapCloudEvent(name string, fn func(context.Context, event.Event) error, opts ...Option) func(context.Context, event.Event) error {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/GoogleCloudPlatform/functions-framework-go/functions: Wrapping CloudEvent function %q: %#v", name, cfg)
+	return func(ctx context.Context, e event.Event) error {
+		spanOpts := []ddtrace.StartSpanOption{
+			tracer.ServiceName(cfg.serviceName),
+			tracer.ResourceName(e.Type()),
+			tracer.SpanType(ext.SpanTypeMessageConsumer),
+			tracer.Tag(ext.Component, componentName),
+			tracer.Tag(ext.SpanKind, ext.SpanKindConsumer),
+			tracer.Tag(tagFunctionName, name),
+			tracer.Tag(tagEventID, e.ID()),
+			tracer.Tag(tagEventSource, e.Source()),
+			tracer.Tag(tagEventType, e.Type()),
+			tracer.Measured(),
+		}
+		if subject := e.Subject(); subject != "" {
+			spanOpts = append(spanOpts, tracer.Tag(tagEventSubject, subject))
+		}
+		if !math.IsNaN(cfg.analyticsRate) {
+			spanOpts = append(spanOpts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+		}
+		if spanctx, err := tracer.Extract(extensionsCarrier(e.Extensions())); err == nil {
+			spanOpts = append(spanOpts, tracer.ChildOf(spanctx))
+		}
+		span, ctx := tracer.StartSpanFromContext(ctx, cfg.eventSpanName, spanOpts...)
+		err := fn(ctx, e)
+		if err != nil && (cfg.errCheck == nil || cfg.errCheck(err)) {
+			span.SetTag(ext.Error, err)
+		}
+		span.Finish()
+		if cfg.flush {
+			tracer.Flush()
+		}
+		return err
+	}
+}
+
+// extensionsCarrier returns a carrier holding the string extensions of an event.
+func extensionsCarrier(extensions map[string]interface{}) tracer.TextMapCarrier {
+	carrier := make(tracer.TextMapCarrier, len(extensions))
+	for k, v := range extensions {
+		if s, ok := v.(string); ok {
+			carrier[k] = s
+		}
+	}
+	return carrier
+}
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package functions
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapHTTP(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	fn := WrapHTTP("HelloWorld", func(w http.ResponseWriter, r *http.Request) {
+		_, ok := tracer.SpanFromContext(r.Context())
+		assert.True(t, ok)
+		w.WriteHeader(http.StatusCreated)
+	}, WithServiceName("hello"))
+	fn(httptest.NewRecorder(), httptest.NewRequest("POST", "/", nil))
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "http.request", s.OperationName())
+	assert.Equal(t, "POST HelloWorld", s.Tag(ext.ResourceName))
+	assert.Equal(t, "hello", s.Tag(ext.ServiceName))
+	assert.Equal(t, "201", s.Tag(ext.HTTPCode))
+	assert.Equal(t, "HelloWorld", s.Tag(tagFunctionName))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, ext.SpanKindServer, s.Tag(ext.SpanKind))
+}
+
+func TestWrapCloudEvent(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	parent := tracer.StartSpan("publisher")
+	carrier := tracer.TextMapCarrier{}
+	require.NoError(t, tracer.Inject(parent.Context(), carrier))
+
+	e := event.New()
+	e.SetID("1")
+	e.SetSource("//pubsub.googleapis.com/projects/my-project/topics/orders")
+	e.SetType("google.cloud.pubsub.topic.v1.messagePublished")
+	for k, v := range carrier {
+		e.SetExtension(k, v)
+	}
+	errFailed := errors.New("failed")
+	fn := WrapCloudEvent("ProcessOrder", func(ctx context.Context, _ event.Event) error {
+		_, ok := tracer.SpanFromContext(ctx)
+		assert.True(t, ok)
+		return errFailed
+	})
+	err := fn(context.Background(), e)
+	assert.Equal(t, errFailed, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "gcp.cloudfunctions.event", s.OperationName())
+	assert.Equal(t, "google.cloud.pubsub.topic.v1.messagePublished", s.Tag(ext.ResourceName))
+	assert.Equal(t, "1", s.Tag(tagEventID))
+	assert.Equal(t, "//pubsub.googleapis.com/projects/my-project/topics/orders", s.Tag(tagEventSource))
+	assert.Equal(t, "ProcessOrder", s.Tag(tagFunctionName))
+	assert.Equal(t, parent.Context().TraceID(), s.TraceID())
+	assert.Equal(t, parent.Context().SpanID(), s.ParentID())
+	assert.NotNil(t, s.Tag(ext.Error))
+}
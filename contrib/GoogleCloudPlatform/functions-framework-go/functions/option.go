@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package functions
+
+import (
+	"math"
+	"os"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+)
+
+const defaultServiceName = "cloudfunctions"
+
+type config struct {
+	serviceName   string
+	eventSpanName string
+	analyticsRate float64
+	errCheck      func(err error) bool
+	flush         bool
+}
+
+// Option represents an option that can be passed to HTTP, CloudEvent and their
+// Wrap counterparts.
+type Option func(*config)
+
+func newConfig(opts ...Option) *config {
+	fallback := defaultServiceName
+	if name := os.Getenv("K_SERVICE"); name != "" {
+		// K_SERVICE holds the name of the function, or of the Cloud Run service.
+		fallback = name
+	}
+	cfg := &config{
+		serviceName:   namingschema.NewDefaultServiceName(fallback).GetName(),
+		eventSpanName: "gcp.cloudfunctions.event",
+		flush:         true,
+	}
+	if internal.BoolEnv("DD_TRACE_CLOUDFUNCTIONS_ANALYTICS_ENABLED", false) {
+		cfg.analyticsRate = 1.0
+	} else {
+		cfg.analyticsRate = globalconfig.AnalyticsRate()
+	}
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return cfg
+}
+
+// WithServiceName sets the given service name for the started spans. It
+// defaults to the name of the function.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithErrorCheck specifies a function fn which determines whether the passed
+// error should be marked as an error. The fn is called whenever a CloudEvent
+// function returns an error.
+func WithErrorCheck(fn func(err error) bool) Option {
+	return func(cfg *config) {
+		cfg.errCheck = fn
+	}
+}
+
+// WithFlush specifies whether the tracer is flushed before the function
+// returns. It is enabled by default, as the instance may be frozen as soon as
+// the function has returned.
+func WithFlush(on bool) Option {
+	return func(cfg *config) {
+		cfg.flush = on
+	}
+}
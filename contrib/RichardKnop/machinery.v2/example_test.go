@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package machinery_test
+
+import (
+	"context"
+	"log"
+
+	machinerytrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/RichardKnop/machinery.v2"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/RichardKnop/machinery/v2"
+	"github.com/RichardKnop/machinery/v2/tasks"
+)
+
+func Example() {
+	var server *machinery.Server // created with machinery.NewServer
+
+	s := machinerytrace.WrapServer(server)
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "checkout")
+	defer span.Finish()
+	if _, err := s.SendTaskWithContext(ctx, &tasks.Signature{Name: "send_receipt"}); err != nil {
+		log.Fatal(err)
+	}
+
+	w := machinerytrace.WrapWorker(server.NewWorker("worker", 10))
+	server.RegisterTask("send_receipt", func(ctx context.Context) error {
+		// the processing of the task is part of the trace of the checkout
+		spanctx, _ := machinerytrace.ExtractSpanContext(tasks.SignatureFromContext(ctx))
+		span := tracer.StartSpan("send.receipt", tracer.ChildOf(spanctx))
+		defer span.Finish()
+		return nil
+	})
+	if err := w.Launch(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,249 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package machinery provides functions to trace the RichardKnop/machinery/v2 package (https://github.com/RichardKnop/machinery).
+//
+// The span context is propagated from the senders of tasks to the workers
+// processing them in the headers of the task signatures, whatever the broker.
+package machinery // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/RichardKnop/machinery.v2"
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/RichardKnop/machinery/v2"
+	"github.com/RichardKnop/machinery/v2/backends/result"
+	"github.com/RichardKnop/machinery/v2/tasks"
+)
+
+const componentName = "RichardKnop/machinery.v2"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+const (
+	tagTaskName   = "machinery.task.name"
+	tagTaskUUID   = "machinery.task.uuid"
+	tagGroupUUID  = "machinery.group.uuid"
+	tagTaskCount  = "machinery.task.count"
+	tagRoutingKey = "machinery.routing_key"
+	tagRetryCount = "machinery.task.retry_count"
+	tagRetried    = "machinery.task.retried"
+)
+
+// WrapServer wraps a machinery.Server so that sent tasks are traced.
+func WrapServer(s *machinery.Server, opts ...Option) *Server {
+	wrapped := &Server{
+		Server: s,
+		cfg:    newConfig(opts...),
+	}
+	log.Debug("contrib/RichardKnop/machinery.v2: Wrapping Server: %#v", wrapped.cfg)
+	return wrapped
+}
+
+// A Server wraps a machinery.Server.
+type Server struct {
+	*machinery.Server
+	cfg *config
+}
+
+// SendTask calls machinery.Server.SendTask and traces the request.
+func (s *Server) SendTask(signature *tasks.Signature) (*result.AsyncResult, error) {
+	return s.SendTaskWithContext(context.Background(), signature)
+}
+
+// SendTaskWithContext calls machinery.Server.SendTaskWithContext and traces
+// the request. The span context is injected into the headers of signature.
+func (s *Server) SendTaskWithContext(ctx context.Context, signature *tasks.Signature) (*result.AsyncResult, error) {
+	span, ctx := s.startSendSpan(ctx, signature.Name)
+	injectSpanContext(span.Context(), signature)
+	res, err := s.Server.SendTaskWithContext(ctx, signature)
+	span.SetTag(tagTaskUUID, signature.UUID)
+	span.SetTag(tagRoutingKey, signature.RoutingKey)
+	span.Finish(tracer.WithError(err))
+	return res, err
+}
+
+// SendGroup calls machinery.Server.SendGroup and traces the request.
+func (s *Server) SendGroup(group *tasks.Group, sendConcurrency int) ([]*result.AsyncResult, error) {
+	return s.SendGroupWithContext(context.Background(), group, sendConcurrency)
+}
+
+// SendGroupWithContext calls machinery.Server.SendGroupWithContext and traces
+// the request. The span context is injected into the headers of the
+// signatures of the group.
+func (s *Server) SendGroupWithContext(ctx context.Context, group *tasks.Group, sendConcurrency int) ([]*result.AsyncResult, error) {
+	span, ctx := s.startSendSpan(ctx, "group")
+	for _, signature := range group.Tasks {
+		injectSpanContext(span.Context(), signature)
+	}
+	res, err := s.Server.SendGroupWithContext(ctx, group, sendConcurrency)
+	span.SetTag(tagGroupUUID, group.GroupUUID)
+	span.SetTag(tagTaskCount, len(group.Tasks))
+	span.Finish(tracer.WithError(err))
+	return res, err
+}
+
+// SendChain calls machinery.Server.SendChain and traces the request.
+func (s *Server) SendChain(chain *tasks.Chain) (*result.ChainAsyncResult, error) {
+	return s.SendChainWithContext(context.Background(), chain)
+}
+
+// SendChainWithContext calls machinery.Server.SendChainWithContext and traces
+// the request. The span context is injected into the headers of the
+// signatures of the chain.
+func (s *Server) SendChainWithContext(ctx context.Context, chain *tasks.Chain) (*result.ChainAsyncResult, error) {
+	span, ctx := s.startSendSpan(ctx, "chain")
+	for _, signature := range chain.Tasks {
+		injectSpanContext(span.Context(), signature)
+	}
+	res, err := s.Server.SendChainWithContext(ctx, chain)
+	span.SetTag(tagTaskCount, len(chain.Tasks))
+	span.Finish(tracer.WithError(err))
+	return res, err
+}
+
+func (s *Server) startSendSpan(ctx context.Context, resource string) (ddtrace.Span, context.Context) {
+	opts := []tracer.StartSpanOption{
+		tracer.ServiceName(s.cfg.producerServiceName),
+		tracer.ResourceName(resource),
+		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindProducer),
+	}
+	if !math.IsNaN(s.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, s.cfg.analyticsRate))
+	}
+	return tracer.StartSpanFromContext(ctx, s.cfg.producerSpanName, opts...)
+}
+
+// injectSpanContext injects spanctx into the headers of signature.
+func injectSpanContext(spanctx ddtrace.SpanContext, signature *tasks.Signature) {
+	if signature.Headers == nil {
+		signature.Headers = tasks.Headers{}
+	}
+	if err := tracer.Inject(spanctx, signature.Headers); err != nil {
+		log.Debug("contrib/RichardKnop/machinery.v2: Failed to inject span context into headers, %v", err)
+	}
+}
+
+// ExtractSpanContext retrieves the span context from the headers of
+// signature. Within a task taking a context.Context as first argument, the
+// signature is found using tasks.SignatureFromContext.
+func ExtractSpanContext(signature *tasks.Signature) (ddtrace.SpanContext, error) {
+	return tracer.Extract(signature.Headers)
+}
+
+// WrapWorker wraps a machinery.Worker so that the processing of tasks is
+// traced, using its pre- and post-task handlers. Handlers of the application
+// must be set on the returned Worker, which runs them as well. The span
+// context of the processing is injected into the headers of the signature of
+// the task, so that it can be retrieved using ExtractSpanContext.
+func WrapWorker(w *machinery.Worker, opts ...Option) *Worker {
+	wrapped := &Worker{
+		Worker: w,
+		cfg:    newConfig(opts...),
+	}
+	log.Debug("contrib/RichardKnop/machinery.v2: Wrapping Worker: %#v", wrapped.cfg)
+	w.SetPreTaskHandler(wrapped.preTask)
+	w.SetPostTaskHandler(wrapped.postTask)
+	return wrapped
+}
+
+// A Worker wraps a machinery.Worker.
+type Worker struct {
+	*machinery.Worker
+	cfg *config
+
+	mu              sync.RWMutex
+	preTaskHandler  func(*tasks.Signature)
+	postTaskHandler func(*tasks.Signature)
+	processing      sync.Map // *tasks.Signature -> *processing
+}
+
+// processing is the state of the processing of a task.
+type processing struct {
+	span       ddtrace.Span
+	retryCount int
+}
+
+// SetPreTaskHandler sets a handler called before each task is processed,
+// after its span is started.
+func (w *Worker) SetPreTaskHandler(handler func(*tasks.Signature)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.preTaskHandler = handler
+}
+
+// SetPostTaskHandler sets a handler called after each task is processed,
+// before its span is finished.
+func (w *Worker) SetPostTaskHandler(handler func(*tasks.Signature)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.postTaskHandler = handler
+}
+
+func (w *Worker) preTask(signature *tasks.Signature) {
+	opts := []tracer.StartSpanOption{
+		tracer.ServiceName(w.cfg.consumerServiceName),
+		tracer.ResourceName(signature.Name),
+		tracer.SpanType(ext.SpanTypeMessageConsumer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindConsumer),
+		tracer.Tag(tagTaskName, signature.Name),
+		tracer.Tag(tagTaskUUID, signature.UUID),
+		tracer.Tag(tagRoutingKey, signature.RoutingKey),
+		tracer.Tag(tagRetryCount, signature.RetryCount),
+		tracer.Measured(),
+	}
+	if signature.GroupUUID != "" {
+		opts = append(opts, tracer.Tag(tagGroupUUID, signature.GroupUUID))
+	}
+	if !math.IsNaN(w.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, w.cfg.analyticsRate))
+	}
+	if spanctx, err := ExtractSpanContext(signature); err == nil {
+		opts = append(opts, tracer.ChildOf(spanctx))
+	}
+	span := tracer.StartSpan(w.cfg.consumerSpanName, opts...)
+	// reinject the span context so tasks can pick it up
+	injectSpanContext(span.Context(), signature)
+	w.processing.Store(signature, &processing{span: span, retryCount: signature.RetryCount})
+
+	w.mu.RLock()
+	handler := w.preTaskHandler
+	w.mu.RUnlock()
+	if handler != nil {
+		handler(signature)
+	}
+}
+
+func (w *Worker) postTask(signature *tasks.Signature) {
+	w.mu.RLock()
+	handler := w.postTaskHandler
+	w.mu.RUnlock()
+	if handler != nil {
+		handler(signature)
+	}
+
+	v, ok := w.processing.LoadAndDelete(signature)
+	if !ok {
+		return
+	}
+	p := v.(*processing)
+	if signature.RetryCount < p.retryCount {
+		// machinery decrements the retry count of failed tasks it retries
+		p.span.SetTag(tagRetried, true)
+	}
+	p.span.Finish()
+}
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package machinery
+
+import (
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/RichardKnop/machinery/v2/tasks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessing(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	producer := tracer.StartSpan("send")
+	signature := &tasks.Signature{UUID: "task_1", Name: "add", RoutingKey: "machinery_tasks", RetryCount: 3}
+	injectSpanContext(producer.Context(), signature)
+	producer.Finish()
+
+	var calls []string
+	w := &Worker{cfg: newConfig(WithServiceName("worker"))}
+	w.SetPreTaskHandler(func(s *tasks.Signature) {
+		// the span context of the processing is found in the headers
+		spanctx, err := ExtractSpanContext(s)
+		require.NoError(t, err)
+		assert.NotEqual(t, producer.Context().SpanID(), spanctx.SpanID())
+		calls = append(calls, "pre")
+	})
+	w.SetPostTaskHandler(func(*tasks.Signature) {
+		calls = append(calls, "post")
+	})
+	w.preTask(signature)
+	// machinery retries the task
+	signature.RetryCount--
+	w.postTask(signature)
+	assert.Equal(t, []string{"pre", "post"}, calls)
+
+	spans := mocktracer.FinishedSpansByOperation(mt, "machinery.process")
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "add", s.Tag(ext.ResourceName))
+	assert.Equal(t, "worker", s.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanKindConsumer, s.Tag(ext.SpanKind))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, "task_1", s.Tag(tagTaskUUID))
+	assert.Equal(t, "machinery_tasks", s.Tag(tagRoutingKey))
+	assert.Equal(t, 3, s.Tag(tagRetryCount))
+	assert.Equal(t, true, s.Tag(tagRetried))
+	assert.Equal(t, producer.Context().SpanID(), s.ParentID())
+
+	spanctx, err := ExtractSpanContext(signature)
+	require.NoError(t, err)
+	assert.Equal(t, s.SpanID(), spanctx.SpanID())
+}
+
+func TestProcessingWithoutParent(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	w := &Worker{cfg: newConfig()}
+	signature := &tasks.Signature{Name: "add"}
+	w.preTask(signature)
+	w.postTask(signature)
+	// unknown signatures are ignored
+	w.postTask(&tasks.Signature{})
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Zero(t, spans[0].ParentID())
+	assert.Nil(t, spans[0].Tag(tagRetried))
+}
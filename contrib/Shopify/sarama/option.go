@@ -20,6 +20,7 @@ type config struct {
 	consumerSpanName    string
 	producerSpanName    string
 	analyticsRate       float64
+	groupID             string
 }
 
 func defaults(cfg *config) {
@@ -73,3 +74,12 @@ func WithAnalyticsRate(rate float64) Option {
 		}
 	}
 }
+
+// WithGroupID sets the consumer group ID of the wrapped consumers. When Data
+// Streams Monitoring is enabled, it is used to report the offsets consumed by
+// the group, so that its backlog can be computed.
+func WithGroupID(groupID string) Option {
+	return func(cfg *config) {
+		cfg.groupID = groupID
+	}
+}
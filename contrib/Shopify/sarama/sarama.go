@@ -12,6 +12,7 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
 
@@ -36,7 +37,9 @@ func (pc *partitionConsumer) Messages() <-chan *sarama.ConsumerMessage {
 }
 
 // WrapPartitionConsumer wraps a sarama.PartitionConsumer causing each received
-// message to be traced.
+// message to be traced. When Data Streams Monitoring is enabled and a group ID
+// is set using WithGroupID, the offsets of the received messages and the high
+// watermark of the partition are reported to compute the backlog of the group.
 func WrapPartitionConsumer(pc sarama.PartitionConsumer, opts ...Option) sarama.PartitionConsumer {
 	cfg := new(config)
 	defaults(cfg)
@@ -75,6 +78,11 @@ func WrapPartitionConsumer(pc sarama.PartitionConsumer, opts ...Option) sarama.P
 			next := tracer.StartSpan(cfg.consumerSpanName, opts...)
 			// reinject the span context so consumers can pick it up
 			tracer.Inject(next.Context(), carrier)
+			if p := datastreams.GetGlobalProcessor(); p != nil && cfg.groupID != "" {
+				// as in Kafka, the committed offset is the one of the next message to read
+				p.TrackKafkaCommitOffset(cfg.groupID, msg.Topic, msg.Partition, msg.Offset+1)
+				p.TrackKafkaHighWatermarkOffset(cfg.groupID, msg.Topic, msg.Partition, pc.HighWaterMarkOffset())
+			}
 
 			wrapped.messages <- msg
 
@@ -128,6 +136,9 @@ func (p *syncProducer) SendMessage(msg *sarama.ProducerMessage) (partition int32
 	span := startProducerSpan(p.cfg, p.version, msg)
 	partition, offset, err = p.SyncProducer.SendMessage(msg)
 	finishProducerSpan(span, partition, offset, err)
+	if err == nil {
+		trackProduceOffset(msg.Topic, partition, offset)
+	}
 	return partition, offset, err
 }
 
@@ -142,6 +153,9 @@ func (p *syncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
 	err := p.SyncProducer.SendMessages(msgs)
 	for i, span := range spans {
 		finishProducerSpan(span, msgs[i].Partition, msgs[i].Offset, err)
+		if err == nil {
+			trackProduceOffset(msgs[i].Topic, msgs[i].Partition, msgs[i].Offset)
+		}
 	}
 	return err
 }
@@ -190,8 +204,12 @@ func (p *asyncProducer) Errors() <-chan *sarama.ProducerError {
 // WrapAsyncProducer wraps a sarama.AsyncProducer so that all produced messages
 // are traced. It requires the underlying sarama Config so we can know whether
 // or not successes will be returned. Tracing requires at least sarama.V0_11_0_0
-// version which is the first version that supports headers. Only spans of
-// successfully published messages have partition and offset tags set.
+// version which is the first version that supports headers. When successes
+// are returned, spans are finished when the messages are acknowledged on the
+// Successes or Errors channels, and spans of successfully published messages
+// have partition and offset tags set. Otherwise, spans are finished as soon as
+// the messages are handed to the producer, since there's no way to know when
+// they will be done.
 func WrapAsyncProducer(saramaConfig *sarama.Config, p sarama.AsyncProducer, opts ...Option) sarama.AsyncProducer {
 	cfg := new(config)
 	defaults(cfg)
@@ -212,52 +230,59 @@ func WrapAsyncProducer(saramaConfig *sarama.Config, p sarama.AsyncProducer, opts
 		errors:        make(chan *sarama.ProducerError),
 	}
 	go func() {
-		spans := make(map[uint64]ddtrace.Span)
+		// sarama returns the messages it was given on the Successes and
+		// Errors channels, so the spans are keyed by message rather than by
+		// the span context found in their headers, which may not be injected
+		// for older versions.
+		spans := make(map[*sarama.ProducerMessage]ddtrace.Span)
 		defer close(wrapped.input)
 		defer close(wrapped.successes)
 		defer close(wrapped.errors)
-		for {
+		successes, errors := p.Successes(), p.Errors()
+		// the producer closes both channels when it is shut down, after
+		// returning the messages it was still handling
+		for successes != nil || errors != nil {
 			select {
 			case msg := <-wrapped.input:
 				span := startProducerSpan(cfg, saramaConfig.Version, msg)
 				p.Input() <- msg
 				if saramaConfig.Producer.Return.Successes {
-					spanID := span.Context().SpanID()
-					spans[spanID] = span
+					spans[msg] = span
 				} else {
 					// if returning successes isn't enabled, we just finish the
 					// span right away because there's no way to know when it will
 					// be done
 					span.Finish()
 				}
-			case msg, ok := <-p.Successes():
+			case msg, ok := <-successes:
 				if !ok {
-					// producer was closed, so exit
-					return
+					successes = nil
+					continue
 				}
-				if spanctx, spanFound := getSpanContext(msg); spanFound {
-					spanID := spanctx.SpanID()
-					if span, ok := spans[spanID]; ok {
-						delete(spans, spanID)
-						finishProducerSpan(span, msg.Partition, msg.Offset, nil)
-					}
+				if span, ok := spans[msg]; ok {
+					delete(spans, msg)
+					finishProducerSpan(span, msg.Partition, msg.Offset, nil)
 				}
+				trackProduceOffset(msg.Topic, msg.Partition, msg.Offset)
 				wrapped.successes <- msg
-			case err, ok := <-p.Errors():
+			case err, ok := <-errors:
 				if !ok {
-					// producer was closed
-					return
+					errors = nil
+					continue
 				}
-				if spanctx, spanFound := getSpanContext(err.Msg); spanFound {
-					spanID := spanctx.SpanID()
-					if span, ok := spans[spanID]; ok {
-						delete(spans, spanID)
-						span.Finish(tracer.WithError(err))
-					}
+				if span, ok := spans[err.Msg]; ok {
+					delete(spans, err.Msg)
+					// the offset is only known for published messages
+					span.SetTag(ext.MessagingKafkaPartition, err.Msg.Partition)
+					span.Finish(tracer.WithError(err))
 				}
 				wrapped.errors <- err
 			}
 		}
+		// finish the spans of any message the producer never returned
+		for _, span := range spans {
+			span.Finish()
+		}
 	}()
 	return wrapped
 }
@@ -293,12 +318,10 @@ func finishProducerSpan(span ddtrace.Span, partition int32, offset int64, err er
 	span.Finish(tracer.WithError(err))
 }
 
-func getSpanContext(msg *sarama.ProducerMessage) (ddtrace.SpanContext, bool) {
-	carrier := NewProducerMessageCarrier(msg)
-	spanctx, err := tracer.Extract(carrier)
-	if err != nil {
-		return nil, false
+// trackProduceOffset reports the offset of a produced message to Data Streams
+// Monitoring, if it is enabled.
+func trackProduceOffset(topic string, partition int32, offset int64) {
+	if p := datastreams.GetGlobalProcessor(); p != nil {
+		p.TrackKafkaProduceOffset(topic, partition, offset)
 	}
-
-	return spanctx, true
 }
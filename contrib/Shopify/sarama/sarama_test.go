@@ -6,7 +6,13 @@
 package sarama
 
 import (
+	"compress/gzip"
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
 	"testing"
 	"time"
 
@@ -14,10 +20,14 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
 
+	"github.com/DataDog/datadog-go/v5/statsd"
 	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
 )
 
 func genTestSpans(t *testing.T, serviceOverride string) []mocktracer.Span {
@@ -165,6 +175,81 @@ func TestConsumer(t *testing.T) {
 	}
 }
 
+// startDataStreams sets up a global Data Streams processor sending its
+// payloads to a fake agent, and returns a function flushing it and returning
+// the reported backlogs.
+func startDataStreams(t *testing.T) func() []datastreams.Backlog {
+	var (
+		mu       sync.Mutex
+		backlogs []datastreams.Backlog
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		var payload datastreams.StatsPayload
+		require.NoError(t, msgp.Decode(gr, &payload))
+		mu.Lock()
+		defer mu.Unlock()
+		for _, b := range payload.Stats {
+			backlogs = append(backlogs, b.Backlogs...)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	p := datastreams.NewProcessor(&statsd.NoOpClient{}, "env", "service", u, srv.Client())
+	p.Start()
+	datastreams.SetGlobalProcessor(p)
+	t.Cleanup(func() {
+		datastreams.SetGlobalProcessor(nil)
+		p.Stop()
+	})
+	return func() []datastreams.Backlog {
+		p.Flush()
+		mu.Lock()
+		defer mu.Unlock()
+		return backlogs
+	}
+}
+
+func TestConsumerBacklog(t *testing.T) {
+	flush := startDataStreams(t)
+
+	broker := sarama.NewMockBroker(t, 0)
+	defer broker.Close()
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("test-topic", 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("test-topic", 0, sarama.OffsetOldest, 0).
+			SetOffset("test-topic", 0, sarama.OffsetNewest, 1),
+		"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+			SetMessage("test-topic", 0, 0, sarama.StringEncoder("hello")).
+			SetHighWaterMark("test-topic", 0, 5),
+	})
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.MinVersion
+	consumer, err := sarama.NewConsumer([]string{broker.Addr()}, cfg)
+	require.NoError(t, err)
+	defer consumer.Close()
+	consumer = WrapConsumer(consumer, WithGroupID("test-group"))
+
+	partitionConsumer, err := consumer.ConsumePartition("test-topic", 0, 0)
+	require.NoError(t, err)
+	<-partitionConsumer.Messages()
+	partitionConsumer.Close()
+	// wait for the channel to be closed
+	<-partitionConsumer.Messages()
+
+	backlogs := flush()
+	require.Len(t, backlogs, 2)
+	assert.Equal(t, []string{"consumer_group:test-group", "partition:0", "topic:test-topic", "type:kafka_commit"}, backlogs[0].Tags)
+	assert.Equal(t, int64(1), backlogs[0].Value)
+	assert.Equal(t, []string{"consumer_group:test-group", "partition:0", "topic:test-topic", "type:kafka_high_watermark"}, backlogs[1].Tags)
+	assert.Equal(t, int64(5), backlogs[1].Value)
+}
+
 func TestSyncProducer(t *testing.T) {
 	mt := mocktracer.Start()
 	defer mt.Stop()
@@ -356,6 +441,40 @@ func TestAsyncProducer(t *testing.T) {
 	})
 }
 
+func TestAsyncProducerAcks(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V0_11_0_0
+	cfg.Producer.Return.Successes = true
+	mp := mocks.NewAsyncProducer(t, cfg)
+	produceErr := errors.New("produce failed")
+	mp.ExpectInputAndSucceed()
+	mp.ExpectInputAndFail(produceErr)
+	producer := WrapAsyncProducer(cfg, mp)
+
+	producer.Input() <- &sarama.ProducerMessage{Topic: "my_topic", Partition: 1, Value: sarama.StringEncoder("test 1")}
+	msg := <-producer.Successes()
+	producer.Input() <- &sarama.ProducerMessage{Topic: "my_topic", Partition: 2, Value: sarama.StringEncoder("test 2")}
+	perr := <-producer.Errors()
+	assert.Equal(t, produceErr, perr.Err)
+	require.NoError(t, producer.Close())
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	s0, s1 := spans[0], spans[1]
+	assert.Equal(t, "kafka.produce", s0.OperationName())
+	assert.Equal(t, int32(1), s0.Tag(ext.MessagingKafkaPartition))
+	assert.Equal(t, msg.Offset, s0.Tag("offset"))
+	assert.Nil(t, s0.Tag(ext.Error))
+
+	assert.Equal(t, "kafka.produce", s1.OperationName())
+	assert.Equal(t, int32(2), s1.Tag(ext.MessagingKafkaPartition))
+	assert.Nil(t, s1.Tag("offset"))
+	assert.Equal(t, perr, s1.Tag(ext.Error))
+}
+
 func TestNamingSchema(t *testing.T) {
 	namingschematest.NewKafkaTest(genTestSpans)(t)
 }
@@ -0,0 +1,182 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package anthropic provides functions to trace the anthropics/anthropic-sdk-go package (https://github.com/anthropics/anthropic-sdk-go).
+//
+// A middleware of the Anthropic client traces every request made to the API.
+// The spans of messages are tagged with the requested and responding models,
+// the token usage and the stop reason, and the spans of streamed messages
+// with the time to the first token, following the same conventions as the
+// other LLM integrations so that they can be processed by LLM Observability.
+// The prompts and responses can also be captured, with redaction, with
+// WithContentCapture.
+//
+// The spans of streamed messages finish when the stream is closed or read to
+// the end.
+package anthropic // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/anthropics/anthropic-sdk-go"
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/llmobs"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+const componentName = "anthropics/anthropic-sdk-go"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+const modelProvider = "anthropic"
+
+// Tags set on the request spans.
+const (
+	tagEndpoint      = "anthropic.request.endpoint"
+	tagMethod        = "anthropic.request.method"
+	tagRequestModel  = "anthropic.request.model"
+	tagStream        = "anthropic.request.stream"
+	tagResponseModel = "anthropic.response.model"
+	tagStopReason    = "anthropic.response.stop_reason"
+)
+
+const resourceMessages = "messages"
+
+// RequestOption returns an option.RequestOption tracing the requests of an
+// Anthropic client, to be passed to anthropic.NewClient.
+func RequestOption(opts ...Option) option.RequestOption {
+	return option.WithMiddleware(Middleware(opts...))
+}
+
+// Middleware returns an option.Middleware tracing the requests of an
+// Anthropic client. The spans are children of the span found in the context
+// of the requests, if any.
+func Middleware(opts ...Option) option.Middleware {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/anthropics/anthropic-sdk-go: Configuring Middleware: %#v", cfg)
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		endpoint := req.URL.Path
+		if i := strings.Index(endpoint, "/v1/"); i >= 0 {
+			endpoint = endpoint[i+len("/v1"):]
+		}
+		// Only the creation of messages is a call to a model.
+		isMessages := req.Method == http.MethodPost && strings.HasSuffix(endpoint, "/messages")
+		resource := req.Method + " " + endpoint
+		if isMessages {
+			resource = resourceMessages
+		}
+		spanOpts := []ddtrace.StartSpanOption{
+			tracer.ServiceName(cfg.serviceName),
+			tracer.ResourceName(resource),
+			tracer.SpanType(ext.SpanTypeHTTP),
+			tracer.Tag(tagEndpoint, endpoint),
+			tracer.Tag(tagMethod, req.Method),
+			tracer.Tag(ext.Component, componentName),
+			tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		}
+		if !math.IsNaN(cfg.analyticsRate) {
+			spanOpts = append(spanOpts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+		}
+		var body requestBody
+		if isMessages {
+			body = readRequestBody(req)
+			spanOpts = append(spanOpts,
+				tracer.Tag(llmobs.TagSpanKind, llmobs.SpanKindLLM),
+				tracer.Tag(llmobs.TagModelProvider, modelProvider),
+				tracer.Tag(tagStream, body.Stream),
+			)
+			if body.Model != "" {
+				spanOpts = append(spanOpts,
+					tracer.Tag(tagRequestModel, body.Model),
+					tracer.Tag(llmobs.TagModelName, body.Model),
+				)
+			}
+		}
+		span, ctx := tracer.StartSpanFromContext(req.Context(), cfg.spanName, spanOpts...)
+		if isMessages {
+			cfg.capture.TagMessages(span, llmobs.TagInputMessages, body.messages())
+		}
+		start := time.Now()
+
+		resp, err := next(req.WithContext(ctx))
+		if err != nil {
+			span.Finish(tracer.WithError(err))
+			return resp, err
+		}
+		span.SetTag(ext.HTTPCode, strconv.Itoa(resp.StatusCode))
+		if resp.StatusCode >= 400 {
+			span.Finish(tracer.WithError(fmt.Errorf("%d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))))
+			return resp, nil
+		}
+		if !isMessages {
+			span.Finish()
+			return resp, nil
+		}
+		if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+			resp.Body = newStream(resp.Body, span, cfg, start)
+			return resp, nil
+		}
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(b))
+		if err != nil {
+			span.Finish(tracer.WithError(err))
+			return resp, nil
+		}
+		var rb responseBody
+		if err := rb.unmarshal(b); err == nil {
+			rb.tag(span, cfg)
+		}
+		span.Finish()
+		return resp, nil
+	}
+}
+
+// readRequestBody returns the body of req, which is restored to be sent.
+func readRequestBody(req *http.Request) requestBody {
+	var body requestBody
+	if req.Body == nil || req.Body == http.NoBody {
+		return body
+	}
+	var b []byte
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return body
+		}
+		b, err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return body
+		}
+	} else {
+		var err error
+		b, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(b))
+		if err != nil {
+			return body
+		}
+	}
+	body.unmarshal(b)
+	return body
+}
@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/llmobs"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testModel = "claude-sonnet-4-20250514"
+
+func newServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		json.Unmarshal(b, &body)
+		if r.URL.Path != "/v1/messages" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"type":"error","error":{"type":"not_found_error","message":"not found"}}`)
+			return
+		}
+		if !body.Stream {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":%q,"content":[{"type":"text","text":"Hello there"}],"stop_reason":"end_turn","usage":{"input_tokens":12,"output_tokens":2}}`, testModel)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, ev := range []struct{ name, data string }{
+			{"message_start", fmt.Sprintf(`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":%q,"content":[],"usage":{"input_tokens":12,"output_tokens":1}}}`, testModel)},
+			{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" there"}}`},
+			{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+			{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":2}}`},
+			{"message_stop", `{"type":"message_stop"}`},
+		} {
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.name, ev.data)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newClient(srv *httptest.Server, opts ...Option) anthropic.Client {
+	return anthropic.NewClient(
+		option.WithBaseURL(srv.URL+"/"),
+		option.WithAPIKey("sk-ant-test"),
+		option.WithMaxRetries(0),
+		RequestOption(opts...),
+	)
+}
+
+func messageParams() anthropic.MessageNewParams {
+	return anthropic.MessageNewParams{
+		Model:     anthropic.Model(testModel),
+		MaxTokens: 1024,
+		System:    []anthropic.TextBlockParam{{Text: "Be brief."}},
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropic.NewTextBlock("Hi, I am jane@example.com")),
+		},
+	}
+}
+
+func TestMessage(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	client := newClient(newServer(t), WithContentCapture(true))
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "root")
+	resp, err := client.Messages.New(ctx, messageParams())
+	require.NoError(t, err)
+	assert.Equal(t, "Hello there", resp.Content[0].Text)
+	root.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	span := spans[0]
+	assert.Equal(t, "anthropic.request", span.OperationName())
+	assert.Equal(t, root.Context().SpanID(), span.ParentID())
+	assert.Equal(t, "anthropic", span.Tag(ext.ServiceName))
+	assert.Equal(t, "messages", span.Tag(ext.ResourceName))
+	assert.Equal(t, "200", span.Tag(ext.HTTPCode))
+	assert.Equal(t, testModel, span.Tag(tagRequestModel))
+	assert.Equal(t, testModel, span.Tag(llmobs.TagModelName))
+	assert.Equal(t, "anthropic", span.Tag(llmobs.TagModelProvider))
+	assert.Equal(t, llmobs.SpanKindLLM, span.Tag(llmobs.TagSpanKind))
+	assert.Equal(t, false, span.Tag(tagStream))
+	assert.Equal(t, "end_turn", span.Tag(tagStopReason))
+	assert.Equal(t, "end_turn", span.Tag(llmobs.TagStopReason))
+	assert.Equal(t, int64(12), span.Tag(llmobs.TagInputTokens))
+	assert.Equal(t, int64(2), span.Tag(llmobs.TagOutputTokens))
+	assert.Equal(t, int64(14), span.Tag(llmobs.TagTotalTokens))
+	assert.Equal(t, `[{"role":"system","content":"Be brief."},{"role":"user","content":"Hi, I am <redacted>"}]`, span.Tag(llmobs.TagInputMessages))
+	assert.Equal(t, `[{"role":"assistant","content":"Hello there"}]`, span.Tag(llmobs.TagOutputMessages))
+	assert.Equal(t, componentName, span.Tag(ext.Component))
+	assert.Equal(t, ext.SpanKindClient, span.Tag(ext.SpanKind))
+	assert.Nil(t, span.Tag(ext.Error))
+}
+
+func TestMessageStreaming(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	client := newClient(newServer(t))
+
+	stream := client.Messages.NewStreaming(context.Background(), messageParams())
+	var content string
+	for stream.Next() {
+		event := stream.Current()
+		if event.Type == "content_block_delta" {
+			content += event.Delta.Text
+		}
+	}
+	require.NoError(t, stream.Err())
+	require.NoError(t, stream.Close())
+	assert.Equal(t, "Hello there", content)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, true, span.Tag(tagStream))
+	assert.Equal(t, testModel, span.Tag(tagResponseModel))
+	assert.Equal(t, "end_turn", span.Tag(llmobs.TagStopReason))
+	assert.Equal(t, int64(12), span.Tag(llmobs.TagInputTokens))
+	assert.Equal(t, int64(2), span.Tag(llmobs.TagOutputTokens))
+	assert.IsType(t, float64(0), span.Tag(llmobs.TagTimeToFirstToken))
+	// Contents aren't captured by default.
+	assert.Nil(t, span.Tag(llmobs.TagInputMessages))
+	assert.Nil(t, span.Tag(llmobs.TagOutputMessages))
+	assert.Nil(t, span.Tag(ext.Error))
+}
+
+func TestError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	client := newClient(newServer(t))
+
+	_, err := client.Models.Get(context.Background(), "unknown", anthropic.ModelGetParams{})
+	require.Error(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /models/unknown", span.Tag(ext.ResourceName))
+	assert.Equal(t, "404", span.Tag(ext.HTTPCode))
+	assert.NotNil(t, span.Tag(ext.Error))
+	assert.Nil(t, span.Tag(llmobs.TagSpanKind))
+}
@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package anthropic_test
+
+import (
+	"context"
+	"log"
+
+	anthropictrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/anthropics/anthropic-sdk-go"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func Example() {
+	tracer.Start()
+	defer tracer.Stop()
+
+	// Every request made by the client is traced. The API key is read from
+	// the ANTHROPIC_API_KEY environment variable.
+	client := anthropic.NewClient(anthropictrace.RequestOption(
+		anthropictrace.WithServiceName("my-llm-app"),
+	))
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "answer")
+	defer span.Finish()
+
+	resp, err := client.Messages.New(ctx, anthropic.MessageNewParams{
+		Model:     anthropic.ModelClaudeSonnet4_20250514,
+		MaxTokens: 1024,
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropic.NewTextBlock("What is distributed tracing?")),
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println(resp.Content[0].Text)
+}
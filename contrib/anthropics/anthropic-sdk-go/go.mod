@@ -0,0 +1,11 @@
+module gopkg.in/DataDog/dd-trace-go.v1/contrib/anthropics/anthropic-sdk-go
+
+go 1.25.1
+
+require (
+	github.com/anthropics/anthropic-sdk-go v1.5.0
+	github.com/stretchr/testify v1.8.4
+	gopkg.in/DataDog/dd-trace-go.v1 v1.54.0
+)
+
+replace gopkg.in/DataDog/dd-trace-go.v1 => ../../..
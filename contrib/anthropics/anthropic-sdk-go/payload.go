@@ -0,0 +1,219 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/llmobs"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// requestBody holds the fields of the requests which are relevant to tracing.
+type requestBody struct {
+	Model    string          `json:"model"`
+	Stream   bool            `json:"stream"`
+	System   json.RawMessage `json:"system"`
+	Messages []message       `json:"messages"`
+}
+
+type message struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+func (b *requestBody) unmarshal(data []byte) error {
+	return json.Unmarshal(data, b)
+}
+
+// messages returns the prompt of the request, starting with its system
+// prompt, if any.
+func (b *requestBody) messages() []llmobs.Message {
+	msgs := make([]llmobs.Message, 0, len(b.Messages)+1)
+	if system := contentText(b.System); system != "" {
+		msgs = append(msgs, llmobs.Message{Role: "system", Content: system})
+	}
+	for _, m := range b.Messages {
+		msgs = append(msgs, llmobs.Message{Role: m.Role, Content: contentText(m.Content)})
+	}
+	return msgs
+}
+
+type contentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// contentText returns the text of the content raw, which is either a string
+// or a list of content blocks, of which only the text blocks are kept.
+func contentText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var blocks []contentBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return ""
+	}
+	return blocksText(blocks)
+}
+
+func blocksText(blocks []contentBlock) string {
+	texts := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Type == "text" {
+			texts = append(texts, b.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// responseBody holds the fields of the responses which are relevant to
+// tracing.
+type responseBody struct {
+	Model      string         `json:"model"`
+	Role       string         `json:"role"`
+	Content    []contentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      *usage         `json:"usage"`
+}
+
+type usage struct {
+	InputTokens  int64 `json:"input_tokens"`
+	OutputTokens int64 `json:"output_tokens"`
+}
+
+func (b *responseBody) unmarshal(data []byte) error {
+	return json.Unmarshal(data, b)
+}
+
+// tag tags span with the model, usage and stop reason of the response, and
+// with its output if enabled.
+func (b *responseBody) tag(span ddtrace.Span, cfg *config) {
+	tagModel(span, b.Model)
+	tagStopReason(span, b.StopReason)
+	if b.Usage != nil {
+		llmobs.TagUsage(span, b.Usage.InputTokens, b.Usage.OutputTokens, -1)
+	}
+	cfg.capture.TagMessages(span, llmobs.TagOutputMessages, []llmobs.Message{
+		{Role: b.Role, Content: blocksText(b.Content)},
+	})
+}
+
+func tagModel(span ddtrace.Span, model string) {
+	if model != "" {
+		span.SetTag(tagResponseModel, model)
+		span.SetTag(llmobs.TagModelName, model)
+	}
+}
+
+func tagStopReason(span ddtrace.Span, reason string) {
+	if reason != "" {
+		span.SetTag(tagStopReason, reason)
+		span.SetTag(llmobs.TagStopReason, reason)
+	}
+}
+
+// streamEvent holds the fields of the events of streamed responses which are
+// relevant to tracing.
+type streamEvent struct {
+	Type    string        `json:"type"`
+	Message *responseBody `json:"message"`
+	Delta   struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *usage `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// stream tags the span of a streamed response with the events it receives,
+// and finishes it when the stream ends.
+type stream struct {
+	span       ddtrace.Span
+	cfg        *config
+	text       llmobs.StreamText
+	model      string
+	role       string
+	usage      usage
+	stopReason string
+	err        error
+	// started reports whether the message_start event, holding the input
+	// tokens, was received.
+	started bool
+}
+
+func newStream(body io.ReadCloser, span ddtrace.Span, cfg *config, start time.Time) *llmobs.EventStream {
+	s := &stream{
+		span: span,
+		cfg:  cfg,
+		text: llmobs.StreamText{Span: span, Start: start, Capture: cfg.capture.Enabled},
+	}
+	return &llmobs.EventStream{ReadCloser: body, OnData: s.event, OnEnd: s.end}
+}
+
+func (s *stream) event(data []byte) {
+	var ev streamEvent
+	if err := json.Unmarshal(data, &ev); err != nil {
+		return
+	}
+	switch ev.Type {
+	case "message_start":
+		if ev.Message != nil {
+			s.started = true
+			s.model = ev.Message.Model
+			s.role = ev.Message.Role
+			if ev.Message.Usage != nil {
+				s.usage = *ev.Message.Usage
+			}
+		}
+	case "content_block_delta":
+		if ev.Delta.Type == "text_delta" {
+			s.text.Write(ev.Delta.Text)
+		}
+	case "message_delta":
+		if ev.Delta.StopReason != "" {
+			s.stopReason = ev.Delta.StopReason
+		}
+		// The output tokens of message_delta events are cumulative.
+		if ev.Usage != nil {
+			s.usage.OutputTokens = ev.Usage.OutputTokens
+		}
+	case "error":
+		if ev.Error != nil {
+			s.err = errors.New(ev.Error.Type + ": " + ev.Error.Message)
+		}
+	}
+}
+
+func (s *stream) end(err error) {
+	tagModel(s.span, s.model)
+	tagStopReason(s.span, s.stopReason)
+	if s.started {
+		llmobs.TagUsage(s.span, s.usage.InputTokens, s.usage.OutputTokens, -1)
+	}
+	if out := s.text.String(); out != "" {
+		s.cfg.capture.TagMessages(s.span, llmobs.TagOutputMessages, []llmobs.Message{
+			{Role: s.role, Content: out},
+		})
+	}
+	if err == nil {
+		err = s.err
+	}
+	s.span.Finish(tracer.WithError(err))
+}
@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package gocql_test
+
+import (
+	"context"
+
+	gocqltrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/apache/cassandra-gocql-driver.v2"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/apache/cassandra-gocql-driver/v2"
+)
+
+func Example() {
+	// Create an instrumented cluster. The queries and batches run by the
+	// sessions it creates are traced.
+	cluster := gocqltrace.NewCluster([]string{"127.0.0.1"}, gocqltrace.WithServiceName("cassandra"))
+	session, _ := cluster.CreateSession()
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "parent.request")
+	defer span.Finish()
+
+	// The context of the query is used as parent of its span.
+	session.Query("SELECT name FROM trace.person WHERE id = ?", 1).WithContext(ctx).Exec()
+}
+
+func ExampleInstrument() {
+	cluster := gocql.NewCluster("127.0.0.1")
+	// Observers already set on the cluster keep being called after the
+	// tracing one.
+	cluster.QueryObserver = myQueryObserver{}
+	gocqltrace.Instrument(cluster)
+
+	session, _ := cluster.CreateSession()
+	session.Query("SELECT now() FROM system.local").Exec()
+}
+
+type myQueryObserver struct{}
+
+func (myQueryObserver) ObserveQuery(context.Context, gocql.ObservedQuery) {}
@@ -0,0 +1,173 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package gocql provides functions to trace the apache/cassandra-gocql-driver/v2 package (https://github.com/apache/cassandra-gocql-driver).
+//
+// Unlike the gocql/gocql integration, the queries and batches are not wrapped.
+// Instead, an Observer is registered as the QueryObserver and BatchObserver of
+// the cluster and creates a span for every attempt of a query or a batch, using
+// the context the query or batch was executed with as parent. Observers which
+// were already set on the cluster keep being called.
+package gocql // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/apache/cassandra-gocql-driver.v2"
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/apache/cassandra-gocql-driver/v2"
+)
+
+const componentName = "apache/cassandra-gocql-driver.v2"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Observer traces the queries and batches it observes. It implements both
+// gocql.QueryObserver and gocql.BatchObserver.
+type Observer struct {
+	cfg *config
+	// contactPoints holds the hosts of the cluster the observer was
+	// registered on, if any.
+	contactPoints string
+}
+
+var (
+	_ gocql.QueryObserver = (*Observer)(nil)
+	_ gocql.BatchObserver = (*Observer)(nil)
+)
+
+// NewObserver returns a new Observer. It can be set as the QueryObserver and
+// BatchObserver of a gocql.ClusterConfig, or of individual queries and batches.
+func NewObserver(opts ...Option) *Observer {
+	cfg := defaultConfig()
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/apache/cassandra-gocql-driver.v2: Configuring Observer: %#v", cfg)
+	return &Observer{cfg: cfg}
+}
+
+// Instrument registers a new Observer as the QueryObserver and BatchObserver
+// of the given cluster. The observers already set on the cluster are chained
+// after it, unless WithQueryObserver or WithBatchObserver are given.
+func Instrument(cluster *gocql.ClusterConfig, opts ...Option) *Observer {
+	cfg := defaultConfig()
+	cfg.nextQueryObserver = cluster.QueryObserver
+	cfg.nextBatchObserver = cluster.BatchObserver
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/apache/cassandra-gocql-driver.v2: Instrumenting ClusterConfig: %#v", cfg)
+	o := &Observer{cfg: cfg}
+	if len(cluster.Hosts) > 0 {
+		o.contactPoints = strings.Join(cluster.Hosts, ",")
+	}
+	cluster.QueryObserver = o
+	cluster.BatchObserver = o
+	return o
+}
+
+// NewCluster calls gocql.NewCluster and instruments the returned cluster.
+func NewCluster(hosts []string, opts ...Option) *gocql.ClusterConfig {
+	cluster := gocql.NewCluster(hosts...)
+	Instrument(cluster, opts...)
+	return cluster
+}
+
+// ObserveQuery implements gocql.QueryObserver.
+func (o *Observer) ObserveQuery(ctx context.Context, q gocql.ObservedQuery) {
+	resource := o.cfg.resourceName
+	if resource == "" {
+		resource = q.Statement
+	}
+	opts := o.startSpanOptions(resource, q.Keyspace, q.Host, q.Attempt)
+	if q.Query != nil {
+		opts = append(opts, tracer.Tag(ext.CassandraConsistencyLevel, q.Query.GetConsistency().String()))
+	}
+	opts = append(opts,
+		tracer.StartTime(q.Start),
+		tracer.Tag(ext.CassandraRowCount, strconv.Itoa(q.Rows)),
+	)
+	span, _ := tracer.StartSpanFromContext(ctx, o.cfg.querySpanName, opts...)
+	o.finishSpan(span, q.End, q.Err)
+
+	if o.cfg.nextQueryObserver != nil {
+		o.cfg.nextQueryObserver.ObserveQuery(ctx, q)
+	}
+}
+
+// ObserveBatch implements gocql.BatchObserver.
+func (o *Observer) ObserveBatch(ctx context.Context, b gocql.ObservedBatch) {
+	opts := o.startSpanOptions(o.cfg.resourceName, b.Keyspace, b.Host, b.Attempt)
+	if b.Batch != nil {
+		opts = append(opts, tracer.Tag(ext.CassandraConsistencyLevel, b.Batch.GetConsistency().String()))
+	}
+	opts = append(opts,
+		tracer.StartTime(b.Start),
+		tracer.Tag(ext.CassandraBatchSize, len(b.Statements)),
+	)
+	span, _ := tracer.StartSpanFromContext(ctx, o.cfg.batchSpanName, opts...)
+	o.finishSpan(span, b.End, b.Err)
+
+	if o.cfg.nextBatchObserver != nil {
+		o.cfg.nextBatchObserver.ObserveBatch(ctx, b)
+	}
+}
+
+func (o *Observer) startSpanOptions(resource, keyspace string, host *gocql.HostInfo, attempt int) []ddtrace.StartSpanOption {
+	opts := []ddtrace.StartSpanOption{
+		tracer.SpanType(ext.SpanTypeCassandra),
+		tracer.ServiceName(o.cfg.serviceName),
+		tracer.Tag(ext.CassandraKeyspace, keyspace),
+		tracer.Tag(ext.CassandraAttempt, attempt),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		tracer.Tag(ext.DBSystem, ext.DBSystemCassandra),
+	}
+	if resource != "" {
+		opts = append(opts, tracer.ResourceName(resource))
+	}
+	if !math.IsNaN(o.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, o.cfg.analyticsRate))
+	}
+	if o.contactPoints != "" {
+		opts = append(opts, tracer.Tag(ext.CassandraContactPoints, o.contactPoints))
+	}
+	if host != nil {
+		opts = append(opts,
+			tracer.Tag(ext.TargetHost, host.ConnectAddress().String()),
+			tracer.Tag(ext.TargetPort, strconv.Itoa(host.Port())),
+			tracer.Tag(ext.CassandraCluster, host.DataCenter()),
+		)
+	}
+	for k, v := range o.cfg.customTags {
+		opts = append(opts, tracer.Tag(k, v))
+	}
+	return opts
+}
+
+func (o *Observer) finishSpan(span ddtrace.Span, end time.Time, err error) {
+	if err != nil && o.cfg.shouldIgnoreError(err) {
+		err = nil
+	}
+	opts := []ddtrace.FinishOption{
+		tracer.FinishTime(end),
+		tracer.WithError(err),
+	}
+	if o.cfg.noDebugStack {
+		opts = append(opts, tracer.NoDebugStack())
+	}
+	span.Finish(opts...)
+}
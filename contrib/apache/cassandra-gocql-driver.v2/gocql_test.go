@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package gocql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/apache/cassandra-gocql-driver/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingObserver struct {
+	queries []gocql.ObservedQuery
+	batches []gocql.ObservedBatch
+}
+
+func (r *recordingObserver) ObserveQuery(_ context.Context, q gocql.ObservedQuery) {
+	r.queries = append(r.queries, q)
+}
+
+func (r *recordingObserver) ObserveBatch(_ context.Context, b gocql.ObservedBatch) {
+	r.batches = append(r.batches, b)
+}
+
+func TestObserveQuery(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	start := time.Now().Add(-time.Second)
+	end := start.Add(10 * time.Millisecond)
+	o := NewObserver(WithServiceName("cassandra-svc"), WithCustomTag("custom", "tag"))
+	o.ObserveQuery(ctx, gocql.ObservedQuery{
+		Keyspace:  "trace",
+		Statement: "SELECT * FROM trace.person",
+		Start:     start,
+		End:       end,
+		Rows:      3,
+		Attempt:   1,
+	})
+	root.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	s := spans[0]
+	assert.Equal(t, "cassandra.query", s.OperationName())
+	assert.Equal(t, "SELECT * FROM trace.person", s.Tag(ext.ResourceName))
+	assert.Equal(t, "cassandra-svc", s.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanTypeCassandra, s.Tag(ext.SpanType))
+	assert.Equal(t, "trace", s.Tag(ext.CassandraKeyspace))
+	assert.Equal(t, "3", s.Tag(ext.CassandraRowCount))
+	assert.Equal(t, 1, s.Tag(ext.CassandraAttempt))
+	assert.Equal(t, "tag", s.Tag("custom"))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, ext.SpanKindClient, s.Tag(ext.SpanKind))
+	assert.Equal(t, ext.DBSystemCassandra, s.Tag(ext.DBSystem))
+	assert.Equal(t, root.Context().SpanID(), s.ParentID())
+	assert.Equal(t, start, s.StartTime())
+	assert.Equal(t, end, s.FinishTime())
+}
+
+func TestObserveBatch(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	o := NewObserver(WithResourceName("INSERT persons"))
+	o.ObserveBatch(context.Background(), gocql.ObservedBatch{
+		Keyspace:   "trace",
+		Statements: []string{"INSERT INTO trace.person ...", "INSERT INTO trace.person ..."},
+		Start:      time.Now(),
+		End:        time.Now(),
+		Err:        errors.New("timeout"),
+	})
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "cassandra.batch", s.OperationName())
+	assert.Equal(t, "INSERT persons", s.Tag(ext.ResourceName))
+	assert.Equal(t, 2, s.Tag(ext.CassandraBatchSize))
+	assert.NotNil(t, s.Tag(ext.Error))
+}
+
+func TestErrorCheck(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	errNotFound := errors.New("not found")
+	o := NewObserver(WithErrorCheck(func(err error) bool { return err != errNotFound }))
+	o.ObserveQuery(context.Background(), gocql.ObservedQuery{Statement: "SELECT 1", Err: errNotFound})
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Nil(t, spans[0].Tag(ext.Error))
+}
+
+func TestInstrumentChainsObservers(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	rec := &recordingObserver{}
+	cluster := gocql.NewCluster("127.0.0.1", "127.0.0.2")
+	cluster.QueryObserver = rec
+	cluster.BatchObserver = rec
+	o := Instrument(cluster)
+	assert.Equal(t, o, cluster.QueryObserver)
+	assert.Equal(t, o, cluster.BatchObserver)
+
+	cluster.QueryObserver.ObserveQuery(context.Background(), gocql.ObservedQuery{Statement: "SELECT 1"})
+	cluster.BatchObserver.ObserveBatch(context.Background(), gocql.ObservedBatch{})
+
+	assert.Len(t, rec.queries, 1)
+	assert.Len(t, rec.batches, 1)
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "127.0.0.1,127.0.0.2", spans[0].Tag(ext.CassandraContactPoints))
+}
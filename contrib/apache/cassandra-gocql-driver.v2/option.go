@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package gocql
+
+import (
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+
+	"github.com/apache/cassandra-gocql-driver/v2"
+)
+
+const defaultServiceName = "gocql.query"
+
+type config struct {
+	serviceName, resourceName    string
+	querySpanName, batchSpanName string
+	noDebugStack                 bool
+	analyticsRate                float64
+	errCheck                     func(err error) bool
+	customTags                   map[string]interface{}
+	nextQueryObserver            gocql.QueryObserver
+	nextBatchObserver            gocql.BatchObserver
+}
+
+// Option represents an option that can be passed to NewObserver or Instrument.
+type Option func(*config)
+
+func defaultConfig() *config {
+	cfg := &config{}
+	cfg.serviceName = namingschema.NewDefaultServiceName(
+		defaultServiceName,
+		namingschema.WithOverrideV0(defaultServiceName),
+	).GetName()
+	cfg.querySpanName = namingschema.NewCassandraOutboundOp().GetName()
+	cfg.batchSpanName = namingschema.NewCassandraOutboundOp(
+		namingschema.WithOverrideV0("cassandra.batch"),
+	).GetName()
+	if internal.BoolEnv("DD_TRACE_GOCQL_ANALYTICS_ENABLED", false) {
+		cfg.analyticsRate = 1.0
+	} else {
+		cfg.analyticsRate = math.NaN()
+	}
+	cfg.errCheck = func(error) bool { return true }
+	return cfg
+}
+
+// WithServiceName sets the given service name for the traced queries and batches.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithResourceName sets a custom resource name to be used with the traced
+// queries and batches. By default, the statement of the query is used.
+func WithResourceName(name string) Option {
+	return func(cfg *config) {
+		cfg.resourceName = name
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// NoDebugStack prevents stack traces from being attached to spans finishing
+// with an error. This is useful in situations where errors are frequent and
+// performance is critical.
+func NoDebugStack() Option {
+	return func(cfg *config) {
+		cfg.noDebugStack = true
+	}
+}
+
+func (c *config) shouldIgnoreError(err error) bool {
+	return c != nil && c.errCheck != nil && !c.errCheck(err)
+}
+
+// WithErrorCheck specifies a function fn which determines whether the passed
+// error should be marked as an error. The fn is called whenever a CQL request
+// finishes with an error.
+func WithErrorCheck(fn func(err error) bool) Option {
+	return func(cfg *config) {
+		cfg.errCheck = fn
+	}
+}
+
+// WithCustomTag will attach the value to the span tagged by the key.
+func WithCustomTag(key string, value interface{}) Option {
+	return func(cfg *config) {
+		if cfg.customTags == nil {
+			cfg.customTags = make(map[string]interface{})
+		}
+		cfg.customTags[key] = value
+	}
+}
+
+// WithQueryObserver sets an observer which is called with every query after
+// its span has been created. This allows using a custom gocql.QueryObserver
+// along with the tracing one.
+func WithQueryObserver(o gocql.QueryObserver) Option {
+	return func(cfg *config) {
+		cfg.nextQueryObserver = o
+	}
+}
+
+// WithBatchObserver sets an observer which is called with every batch after
+// its span has been created. This allows using a custom gocql.BatchObserver
+// along with the tracing one.
+func WithBatchObserver(o gocql.BatchObserver) Option {
+	return func(cfg *config) {
+		cfg.nextBatchObserver = o
+	}
+}
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package pulsar_test
+
+import (
+	"context"
+	"log"
+
+	pulsartrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/apache/pulsar-client-go"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+)
+
+func Example() {
+	client, err := pulsar.NewClient(pulsar.ClientOptions{URL: "pulsar://localhost:6650"})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	producer, err := client.CreateProducer(pulsar.ProducerOptions{
+		Topic:        "orders",
+		Interceptors: pulsar.ProducerInterceptors{pulsartrace.NewProducerInterceptor()},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer producer.Close()
+	consumer, err := client.Subscribe(pulsar.ConsumerOptions{
+		Topic:            "orders",
+		SubscriptionName: "billing",
+		Interceptors:     pulsar.ConsumerInterceptors{pulsartrace.NewConsumerInterceptor()},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer consumer.Close()
+
+	ctx := context.Background()
+	msg := &pulsar.ProducerMessage{Payload: []byte("hello")}
+	// the span of the sending of the message is a child of the span of ctx.
+	pulsartrace.InjectContext(ctx, msg)
+	if _, err := producer.Send(ctx, msg); err != nil {
+		log.Fatal(err)
+	}
+
+	m, err := consumer.Receive(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if spanctx, err := pulsartrace.ExtractSpanContext(m); err == nil {
+		log.Printf("consumed message of trace %d", spanctx.TraceID())
+	}
+	consumer.Ack(m)
+}
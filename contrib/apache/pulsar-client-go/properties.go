@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package pulsar
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+)
+
+// A propertiesCarrier implements TextMapReader/TextMapWriter for
+// extracting/injecting traces and Data Streams pathways on the properties of a
+// Pulsar message.
+type propertiesCarrier map[string]string
+
+var _ interface {
+	tracer.TextMapReader
+	tracer.TextMapWriter
+} = (*propertiesCarrier)(nil)
+
+// ForeachKey conforms to the TextMapReader interface.
+func (c propertiesCarrier) ForeachKey(handler func(key, val string) error) error {
+	for k, v := range c {
+		if err := handler(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set implements TextMapWriter
+func (c propertiesCarrier) Set(key, val string) {
+	c[key] = val
+}
+
+// InjectContext injects the span context and the Data Streams pathway found
+// in ctx, if any, into the properties of msg, so that the span of its sending
+// is a child of the span of ctx, and its pathway follows the one of ctx.
+func InjectContext(ctx context.Context, msg *pulsar.ProducerMessage) {
+	if msg.Properties == nil {
+		msg.Properties = map[string]string{}
+	}
+	carrier := propertiesCarrier(msg.Properties)
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		if err := tracer.Inject(span.Context(), carrier); err != nil {
+			log.Debug("contrib/apache/pulsar-client-go: Failed to inject span context into carrier, %v", err)
+		}
+	}
+	datastreams.InjectToBase64Carrier(ctx, carrier)
+}
+
+// ExtractSpanContext retrieves the SpanContext from a consumed message.
+func ExtractSpanContext(msg pulsar.Message) (ddtrace.SpanContext, error) {
+	return tracer.Extract(propertiesCarrier(msg.Properties()))
+}
+
+// ContextWithMessage returns a copy of ctx holding the Data Streams pathway
+// found in the properties of msg, if any. Passing the returned context to
+// InjectContext links the messages sent next to the consumed one in the Data
+// Streams map.
+func ContextWithMessage(ctx context.Context, msg pulsar.Message) context.Context {
+	return datastreams.ExtractFromBase64Carrier(ctx, propertiesCarrier(msg.Properties()))
+}
@@ -0,0 +1,170 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package pulsar provides functions to trace the apache/pulsar-client-go package (https://github.com/apache/pulsar-client-go).
+//
+// The interceptors returned by NewProducerInterceptor and
+// NewConsumerInterceptor trace the messages sent by the producers and received
+// by the consumers they are added to. The span context is propagated in the
+// properties of the messages, along with their Data Streams pathway when Data
+// Streams Monitoring is enabled.
+package pulsar // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/apache/pulsar-client-go"
+
+import (
+	"context"
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+)
+
+const componentName = "apache/pulsar-client-go"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+type producerInterceptor struct {
+	cfg *config
+}
+
+// NewProducerInterceptor returns an interceptor tracing the messages sent by
+// the producers it is added to, using pulsar.ProducerOptions.Interceptors.
+// The span of the sending of a message is a child of the span context found in
+// its properties, if any, which can be set using InjectContext.
+func NewProducerInterceptor(opts ...Option) pulsar.ProducerInterceptor {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/apache/pulsar-client-go: Configuring ProducerInterceptor: %#v", cfg)
+	return &producerInterceptor{cfg: cfg}
+}
+
+// BeforeSend implements pulsar.ProducerInterceptor. The span context and the
+// Data Streams pathway are injected into the properties of message.
+func (i *producerInterceptor) BeforeSend(producer pulsar.Producer, message *pulsar.ProducerMessage) {
+	topic := producer.Topic()
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(i.cfg.producerServiceName),
+		tracer.ResourceName("Produce Topic " + topic),
+		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindProducer),
+		tracer.Tag(ext.MessagingSystem, ext.MessagingSystemPulsar),
+		tracer.Tag(ext.PulsarTopic, topic),
+		tracer.Tag(ext.PulsarProducerName, producer.Name()),
+	}
+	if !math.IsNaN(i.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, i.cfg.analyticsRate))
+	}
+	if message.Properties == nil {
+		message.Properties = map[string]string{}
+	}
+	carrier := propertiesCarrier(message.Properties)
+	if spanctx, err := tracer.Extract(carrier); err == nil {
+		opts = append(opts, tracer.ChildOf(spanctx))
+	}
+	span := tracer.StartSpan(i.cfg.producerSpanName, opts...)
+	if err := tracer.Inject(span.Context(), carrier); err != nil {
+		log.Debug("contrib/apache/pulsar-client-go: Failed to inject span context into carrier, %v", err)
+	}
+	// The interceptors aren't notified of the messages which fail to be
+	// sent, so the span is finished right away rather than when the message
+	// is acknowledged.
+	span.Finish()
+	if p := datastreams.GetGlobalProcessor(); p != nil {
+		ctx := datastreams.ExtractFromBase64Carrier(context.Background(), carrier)
+		ctx = p.SetCheckpointWithParams(ctx,
+			datastreams.CheckpointParams{PayloadSize: int64(len(message.Payload))},
+			"direction:out",
+			"topic:"+topic,
+			"type:pulsar",
+		)
+		datastreams.InjectToBase64Carrier(ctx, carrier)
+	}
+}
+
+// OnSendAcknowledgement implements pulsar.ProducerInterceptor.
+func (i *producerInterceptor) OnSendAcknowledgement(pulsar.Producer, *pulsar.ProducerMessage, pulsar.MessageID) {
+}
+
+type consumerInterceptor struct {
+	cfg *config
+}
+
+// NewConsumerInterceptor returns an interceptor tracing the messages received
+// by the consumers it is added to, using pulsar.ConsumerOptions.Interceptors.
+// The span context and the Data Streams pathway of the consumption are
+// injected into the properties of each message, so that they can be retrieved
+// using ExtractSpanContext and ContextWithMessage.
+func NewConsumerInterceptor(opts ...Option) pulsar.ConsumerInterceptor {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/apache/pulsar-client-go: Configuring ConsumerInterceptor: %#v", cfg)
+	return &consumerInterceptor{cfg: cfg}
+}
+
+// BeforeConsume implements pulsar.ConsumerInterceptor.
+func (i *consumerInterceptor) BeforeConsume(message pulsar.ConsumerMessage) {
+	topic := message.Topic()
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(i.cfg.consumerServiceName),
+		tracer.ResourceName("Consume Topic " + topic),
+		tracer.SpanType(ext.SpanTypeMessageConsumer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindConsumer),
+		tracer.Tag(ext.MessagingSystem, ext.MessagingSystemPulsar),
+		tracer.Tag(ext.PulsarTopic, topic),
+		tracer.Tag(ext.PulsarProducerName, message.ProducerName()),
+		tracer.Tag(ext.PulsarRedeliveryCount, message.RedeliveryCount()),
+		tracer.Measured(),
+	}
+	var subscription string
+	if message.Consumer != nil {
+		subscription = message.Consumer.Subscription()
+		opts = append(opts, tracer.Tag(ext.PulsarSubscription, subscription))
+	}
+	if !math.IsNaN(i.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, i.cfg.analyticsRate))
+	}
+	// The properties of the messages are only allocated when they have some.
+	props := message.Properties()
+	carrier := propertiesCarrier(props)
+	if spanctx, err := tracer.Extract(carrier); err == nil {
+		opts = append(opts, tracer.ChildOf(spanctx))
+	}
+	span := tracer.StartSpan(i.cfg.consumerSpanName, opts...)
+	if props != nil {
+		// reinject the span context so consumers can pick it up
+		if err := tracer.Inject(span.Context(), carrier); err != nil {
+			log.Debug("contrib/apache/pulsar-client-go: Failed to inject span context into carrier, %v", err)
+		}
+	}
+	span.Finish()
+	if p := datastreams.GetGlobalProcessor(); p != nil {
+		edges := []string{"direction:in"}
+		if subscription != "" {
+			edges = append(edges, "group:"+subscription)
+		}
+		edges = append(edges, "topic:"+topic, "type:pulsar")
+		ctx := datastreams.ExtractFromBase64Carrier(context.Background(), carrier)
+		ctx = p.SetCheckpointWithParams(ctx,
+			datastreams.CheckpointParams{PayloadSize: int64(len(message.Payload()))},
+			edges...,
+		)
+		if props != nil {
+			datastreams.InjectToBase64Carrier(ctx, carrier)
+		}
+	}
+}
+
+// OnAcknowledge implements pulsar.ConsumerInterceptor.
+func (i *consumerInterceptor) OnAcknowledge(pulsar.Consumer, pulsar.MessageID) {}
+
+// OnNegativeAcksSend implements pulsar.ConsumerInterceptor.
+func (i *consumerInterceptor) OnNegativeAcksSend(pulsar.Consumer, []pulsar.MessageID) {}
@@ -0,0 +1,140 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package pulsar
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testProducer struct {
+	pulsar.Producer
+}
+
+func (p *testProducer) Topic() string { return "persistent://public/default/orders" }
+
+func (p *testProducer) Name() string { return "producer-1" }
+
+type testConsumer struct {
+	pulsar.Consumer
+}
+
+func (c *testConsumer) Subscription() string { return "billing" }
+
+type testMessage struct {
+	pulsar.Message
+	props   map[string]string
+	payload []byte
+}
+
+func (m *testMessage) Topic() string                 { return "persistent://public/default/orders" }
+func (m *testMessage) Properties() map[string]string { return m.props }
+func (m *testMessage) Payload() []byte               { return m.payload }
+func (m *testMessage) ProducerName() string          { return "producer-1" }
+func (m *testMessage) RedeliveryCount() uint32       { return 1 }
+
+// sendAndConsume runs the interceptors on a message sent with ctx, and returns
+// the consumed message.
+func sendAndConsume(ctx context.Context, payload []byte) (*pulsar.ProducerMessage, *testMessage) {
+	msg := &pulsar.ProducerMessage{Payload: payload}
+	InjectContext(ctx, msg)
+	pi := NewProducerInterceptor()
+	pi.BeforeSend(&testProducer{}, msg)
+
+	m := &testMessage{props: msg.Properties, payload: msg.Payload}
+	ci := NewConsumerInterceptor()
+	ci.BeforeConsume(pulsar.ConsumerMessage{Consumer: &testConsumer{}, Message: m})
+	return msg, m
+}
+
+func TestProduceConsume(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	_, m := sendAndConsume(ctx, []byte("hello"))
+	parent.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 3)
+	s0, s1 := spans[0], spans[1]
+	assert.Equal(t, "pulsar.produce", s0.OperationName())
+	assert.Equal(t, "Produce Topic persistent://public/default/orders", s0.Tag(ext.ResourceName))
+	assert.Equal(t, "pulsar", s0.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanKindProducer, s0.Tag(ext.SpanKind))
+	assert.Equal(t, ext.MessagingSystemPulsar, s0.Tag(ext.MessagingSystem))
+	assert.Equal(t, "persistent://public/default/orders", s0.Tag(ext.PulsarTopic))
+	assert.Equal(t, "producer-1", s0.Tag(ext.PulsarProducerName))
+	assert.Equal(t, componentName, s0.Tag(ext.Component))
+	assert.Equal(t, parent.Context().SpanID(), s0.ParentID())
+
+	assert.Equal(t, "pulsar.consume", s1.OperationName())
+	assert.Equal(t, "Consume Topic persistent://public/default/orders", s1.Tag(ext.ResourceName))
+	assert.Equal(t, ext.SpanKindConsumer, s1.Tag(ext.SpanKind))
+	assert.Equal(t, "billing", s1.Tag(ext.PulsarSubscription))
+	assert.Equal(t, uint32(1), s1.Tag(ext.PulsarRedeliveryCount))
+	assert.Equal(t, s0.SpanID(), s1.ParentID())
+	assert.Equal(t, s0.TraceID(), s1.TraceID())
+
+	spanctx, err := ExtractSpanContext(m)
+	require.NoError(t, err)
+	assert.Equal(t, s1.SpanID(), spanctx.SpanID())
+}
+
+func TestConsumeWithoutProperties(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ci := NewConsumerInterceptor()
+	ci.BeforeConsume(pulsar.ConsumerMessage{Consumer: &testConsumer{}, Message: &testMessage{}})
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, uint64(0), spans[0].ParentID())
+}
+
+func TestDataStreams(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	p := datastreams.NewProcessor(&statsd.NoOpClient{}, "env", "service", &url.URL{Scheme: "http", Host: "agent-address"}, nil)
+	datastreams.SetGlobalProcessor(p)
+	defer datastreams.SetGlobalProcessor(nil)
+
+	msg := &pulsar.ProducerMessage{Payload: []byte("hello")}
+	NewProducerInterceptor().BeforeSend(&testProducer{}, msg)
+	produced, ok := datastreams.PathwayFromContext(datastreams.ExtractFromBase64Carrier(context.Background(), propertiesCarrier(msg.Properties)))
+	require.True(t, ok)
+
+	props := make(map[string]string, len(msg.Properties))
+	for k, v := range msg.Properties {
+		props[k] = v
+	}
+	m := &testMessage{props: props, payload: msg.Payload}
+	NewConsumerInterceptor().BeforeConsume(pulsar.ConsumerMessage{Consumer: &testConsumer{}, Message: m})
+	consumed, ok := datastreams.PathwayFromContext(ContextWithMessage(context.Background(), m))
+	require.True(t, ok)
+	assert.NotEqual(t, produced.GetHash(), consumed.GetHash())
+	assert.Equal(t, produced.PathwayStart(), consumed.PathwayStart())
+}
+
+func TestNoDataStreams(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	msg, _ := sendAndConsume(context.Background(), nil)
+	assert.NotContains(t, msg.Properties, datastreams.PropagationKeyBase64)
+}
@@ -0,0 +1,170 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package lambda
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// datadogAttribute is the name of the message attribute, or of the field of
+// the EventBridge event details, holding the propagated span context.
+const datadogAttribute = "_datadog"
+
+// Event sources reported in the function_trigger.event_source tag.
+const (
+	eventSourceSQS         = "sqs"
+	eventSourceSNS         = "sns"
+	eventSourceEventBridge = "eventbridge"
+	eventSourceAPIGateway  = "api-gateway"
+)
+
+// event holds the fields of the supported event payloads which are relevant
+// to tracing. Only one group of fields is set, depending on the trigger.
+type event struct {
+	// SQS and SNS events.
+	Records []record `json:"Records"`
+
+	// EventBridge events.
+	DetailType string                     `json:"detail-type"`
+	Source     string                     `json:"source"`
+	Detail     map[string]json.RawMessage `json:"detail"`
+
+	// API Gateway REST and HTTP API events.
+	Headers        map[string]string `json:"headers"`
+	HTTPMethod     string            `json:"httpMethod"`
+	Resource       string            `json:"resource"`
+	RouteKey       string            `json:"routeKey"`
+	RequestContext *struct {
+		APIID string `json:"apiId"`
+		HTTP  *struct {
+			Method string `json:"method"`
+		} `json:"http"`
+	} `json:"requestContext"`
+}
+
+type record struct {
+	EventSource       string                       `json:"eventSource"`
+	EventSourceARN    string                       `json:"eventSourceARN"`
+	Body              string                       `json:"body"`
+	MessageAttributes map[string]sqsAttributeValue `json:"messageAttributes"`
+
+	// SNS records use different casing.
+	SNSEventSource string           `json:"EventSource"`
+	SNS            *snsNotification `json:"Sns"`
+}
+
+type sqsAttributeValue struct {
+	DataType    string `json:"dataType"`
+	StringValue string `json:"stringValue"`
+	BinaryValue []byte `json:"binaryValue"`
+}
+
+type snsNotification struct {
+	TopicArn          string                       `json:"TopicArn"`
+	MessageAttributes map[string]snsAttributeValue `json:"MessageAttributes"`
+}
+
+type snsAttributeValue struct {
+	Type  string `json:"Type"`
+	Value string `json:"Value"`
+}
+
+// trigger describes the event which triggered an invocation.
+type trigger struct {
+	source    string
+	sourceARN string
+	method    string
+	route     string
+	carrier   tracer.TextMapCarrier
+}
+
+// parseTrigger returns the trigger of the invocation with the given payload.
+// It returns nil if the payload isn't one of the supported events.
+func parseTrigger(payload []byte) *trigger {
+	var ev event
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		return nil
+	}
+	switch {
+	case len(ev.Records) > 0:
+		// Records are batched, only the first one is used as parent.
+		r := ev.Records[0]
+		if r.EventSource == "aws:sqs" {
+			t := &trigger{source: eventSourceSQS, sourceARN: r.EventSourceARN}
+			if v, ok := r.MessageAttributes[datadogAttribute]; ok {
+				if v.DataType == "Binary" {
+					t.carrier = decodeCarrier(v.BinaryValue)
+				} else {
+					t.carrier = decodeCarrier([]byte(v.StringValue))
+				}
+			} else {
+				// The message may have been delivered to the queue by
+				// an SNS subscription without raw message delivery.
+				var n snsNotification
+				if err := json.Unmarshal([]byte(r.Body), &n); err == nil {
+					t.carrier = snsCarrier(&n)
+				}
+			}
+			return t
+		}
+		if r.SNSEventSource == "aws:sns" && r.SNS != nil {
+			return &trigger{
+				source:    eventSourceSNS,
+				sourceARN: r.SNS.TopicArn,
+				carrier:   snsCarrier(r.SNS),
+			}
+		}
+	case ev.DetailType != "" && ev.Source != "":
+		t := &trigger{source: eventSourceEventBridge}
+		if v, ok := ev.Detail[datadogAttribute]; ok {
+			t.carrier = decodeCarrier(v)
+		}
+		return t
+	case ev.RequestContext != nil && ev.RequestContext.APIID != "":
+		t := &trigger{
+			source:  eventSourceAPIGateway,
+			carrier: tracer.TextMapCarrier(ev.Headers),
+			method:  ev.HTTPMethod,
+			route:   ev.Resource,
+		}
+		if ev.RequestContext.HTTP != nil {
+			// HTTP API payload format version 2.0.
+			t.method = ev.RequestContext.HTTP.Method
+			t.route = ev.RouteKey
+		}
+		return t
+	}
+	return nil
+}
+
+// snsCarrier returns the carrier found in the message attributes of n, if any.
+func snsCarrier(n *snsNotification) tracer.TextMapCarrier {
+	v, ok := n.MessageAttributes[datadogAttribute]
+	if !ok {
+		return nil
+	}
+	if v.Type == "Binary" {
+		b, err := base64.StdEncoding.DecodeString(v.Value)
+		if err != nil {
+			return nil
+		}
+		return decodeCarrier(b)
+	}
+	return decodeCarrier([]byte(v.Value))
+}
+
+// decodeCarrier decodes the JSON object b into a carrier. It returns nil if b
+// isn't a valid JSON object.
+func decodeCarrier(b []byte) tracer.TextMapCarrier {
+	var carrier tracer.TextMapCarrier
+	if err := json.Unmarshal(b, &carrier); err != nil {
+		return nil
+	}
+	return carrier
+}
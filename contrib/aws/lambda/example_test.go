@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package lambda_test
+
+import (
+	"context"
+
+	lambdatrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/aws/lambda"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func handleMessages(ctx context.Context, ev events.SQSEvent) error {
+	for range ev.Records {
+		// Spans started from ctx are children of the invocation span.
+		span, _ := tracer.StartSpanFromContext(ctx, "process.message")
+		span.Finish()
+	}
+	return nil
+}
+
+func Example() {
+	// The tracer is started once per execution environment, and flushed at
+	// the end of every invocation.
+	tracer.Start()
+	defer tracer.Stop()
+
+	lambdatrace.Start(handleMessages, lambdatrace.WithServiceName("orders-consumer"))
+}
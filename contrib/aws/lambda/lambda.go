@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package lambda provides functions to trace the invocations of AWS Lambda
+// functions built with the aws/aws-lambda-go package (https://github.com/aws/aws-lambda-go).
+//
+// Every invocation is traced by a span which is a child of the span context
+// propagated in the triggering event, if any. The span context is extracted
+// from the _datadog message attribute of SQS and SNS events, from the _datadog
+// field of the details of EventBridge events and from the headers of API
+// Gateway events. The tracer is flushed at the end of every invocation.
+package lambda // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/aws/lambda"
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+const componentName = "aws/lambda"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Tags set on the invocation spans.
+const (
+	tagFunctionARN      = "function_arn"
+	tagFunctionVersion  = "function_version"
+	tagFunctionName     = "functionname"
+	tagRequestID        = "request_id"
+	tagColdStart        = "cold_start"
+	tagTriggerSource    = "function_trigger.event_source"
+	tagTriggerSourceARN = "function_trigger.event_source_arn"
+)
+
+const spanTypeServerless = "serverless"
+
+// coldStart is true until the first invocation of the execution environment
+// is started.
+var coldStart int32 = 1
+
+type handler struct {
+	lambda.Handler
+	cfg *config
+}
+
+// WrapHandler wraps the given lambda.Handler so that its invocations are
+// traced. The returned handler is meant to be passed to lambda.Start.
+func WrapHandler(h lambda.Handler, opts ...Option) lambda.Handler {
+	cfg := &config{}
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/aws/lambda: Wrapping Handler: %#v", cfg)
+	return &handler{Handler: h, cfg: cfg}
+}
+
+// WrapFunction wraps the given handler function, which must satisfy the
+// requirements of lambda.Start, so that its invocations are traced.
+func WrapFunction(handlerFunc interface{}, opts ...Option) lambda.Handler {
+	return WrapHandler(lambda.NewHandler(handlerFunc), opts...)
+}
+
+// Start wraps the given handler function and starts it with lambda.Start.
+func Start(handlerFunc interface{}, opts ...Option) {
+	lambda.Start(WrapFunction(handlerFunc, opts...))
+}
+
+// Invoke implements lambda.Handler.
+func (h *handler) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	opts := []ddtrace.StartSpanOption{
+		tracer.SpanType(spanTypeServerless),
+		tracer.ServiceName(h.cfg.serviceName),
+		tracer.Tag(tagColdStart, atomic.SwapInt32(&coldStart, 0) == 1),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+		tracer.Measured(),
+	}
+	if !math.IsNaN(h.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, h.cfg.analyticsRate))
+	}
+	resource := lambdacontext.FunctionName
+	if resource != "" {
+		opts = append(opts,
+			tracer.ResourceName(resource),
+			tracer.Tag(tagFunctionName, resource),
+		)
+	}
+	if lambdacontext.FunctionVersion != "" {
+		opts = append(opts, tracer.Tag(tagFunctionVersion, lambdacontext.FunctionVersion))
+	}
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		opts = append(opts,
+			tracer.Tag(tagRequestID, lc.AwsRequestID),
+			tracer.Tag(tagFunctionARN, lc.InvokedFunctionArn),
+		)
+	}
+	if t := parseTrigger(payload); t != nil {
+		opts = append(opts, tracer.Tag(tagTriggerSource, t.source))
+		if t.sourceARN != "" {
+			opts = append(opts, tracer.Tag(tagTriggerSourceARN, t.sourceARN))
+		}
+		if t.method != "" {
+			opts = append(opts, tracer.Tag(ext.HTTPMethod, t.method))
+		}
+		if t.route != "" {
+			opts = append(opts, tracer.Tag(ext.HTTPRoute, t.route))
+		}
+		if h.cfg.extract && t.carrier != nil {
+			if spanctx, err := tracer.Extract(t.carrier); err == nil {
+				opts = append(opts, tracer.ChildOf(spanctx))
+			}
+		}
+	}
+	span, ctx := tracer.StartSpanFromContext(ctx, h.cfg.spanName, opts...)
+	out, err := h.Handler.Invoke(ctx, payload)
+	if err != nil && (h.cfg.errCheck == nil || h.cfg.errCheck(err)) {
+		span.SetTag(ext.Error, err)
+	}
+	span.Finish()
+	if h.cfg.flush {
+		tracer.Flush()
+	}
+	return out, err
+}
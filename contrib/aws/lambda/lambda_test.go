@@ -0,0 +1,169 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package lambda
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// datadogCarrier returns the JSON encoded carrier holding the context of span.
+func datadogCarrier(t *testing.T, span tracer.Span) string {
+	carrier := tracer.TextMapCarrier{}
+	require.NoError(t, tracer.Inject(span.Context(), carrier))
+	b, err := json.Marshal(carrier)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestInvoke(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	var called bool
+	h := WrapFunction(func(ctx context.Context, _ json.RawMessage) (string, error) {
+		called = true
+		_, ok := tracer.SpanFromContext(ctx)
+		assert.True(t, ok)
+		return "ok", nil
+	}, WithServiceName("my-function"))
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{
+		AwsRequestID:       "request-1",
+		InvokedFunctionArn: "arn:aws:lambda:us-east-1:123456789012:function:my-function",
+	})
+	out, err := h.Invoke(ctx, []byte(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, `"ok"`, string(out))
+	assert.True(t, called)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "aws.lambda", s.OperationName())
+	assert.Equal(t, "my-function", s.Tag(ext.ServiceName))
+	assert.Equal(t, spanTypeServerless, s.Tag(ext.SpanType))
+	assert.Equal(t, "request-1", s.Tag(tagRequestID))
+	assert.Equal(t, "arn:aws:lambda:us-east-1:123456789012:function:my-function", s.Tag(tagFunctionARN))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, ext.SpanKindServer, s.Tag(ext.SpanKind))
+	assert.Nil(t, s.Tag(tagTriggerSource))
+}
+
+func TestInvokeError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	errFailed := errors.New("failed")
+	h := WrapHandler(lambda.NewHandler(func() error { return errFailed }))
+	_, err := h.Invoke(context.Background(), []byte(`{}`))
+	require.Error(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.NotNil(t, spans[0].Tag(ext.Error))
+}
+
+func TestInvokeTriggers(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	parent := tracer.StartSpan("producer")
+	carrier := datadogCarrier(t, parent)
+	quoted, _ := json.Marshal(carrier)
+	encoded := base64.StdEncoding.EncodeToString([]byte(carrier))
+	snsNotification := fmt.Sprintf(`{"TopicArn":"arn:aws:sns:us-east-1:123456789012:orders","MessageAttributes":{"_datadog":{"Type":"Binary","Value":%q}}}`, encoded)
+
+	for name, tc := range map[string]struct {
+		payload   string
+		source    string
+		sourceARN string
+		method    string
+	}{
+		"sqs": {
+			payload:   fmt.Sprintf(`{"Records":[{"eventSource":"aws:sqs","eventSourceARN":"arn:aws:sqs:us-east-1:123456789012:orders","messageAttributes":{"_datadog":{"dataType":"String","stringValue":%s}}}]}`, quoted),
+			source:    eventSourceSQS,
+			sourceARN: "arn:aws:sqs:us-east-1:123456789012:orders",
+		},
+		"sqs-binary": {
+			payload:   fmt.Sprintf(`{"Records":[{"eventSource":"aws:sqs","eventSourceARN":"arn:aws:sqs:us-east-1:123456789012:orders","messageAttributes":{"_datadog":{"dataType":"Binary","binaryValue":%q}}}]}`, encoded),
+			source:    eventSourceSQS,
+			sourceARN: "arn:aws:sqs:us-east-1:123456789012:orders",
+		},
+		"sns-to-sqs": {
+			payload:   fmt.Sprintf(`{"Records":[{"eventSource":"aws:sqs","eventSourceARN":"arn:aws:sqs:us-east-1:123456789012:orders","body":%q}]}`, snsNotification),
+			source:    eventSourceSQS,
+			sourceARN: "arn:aws:sqs:us-east-1:123456789012:orders",
+		},
+		"sns": {
+			payload:   fmt.Sprintf(`{"Records":[{"EventSource":"aws:sns","Sns":%s}]}`, snsNotification),
+			source:    eventSourceSNS,
+			sourceARN: "arn:aws:sns:us-east-1:123456789012:orders",
+		},
+		"eventbridge": {
+			payload: fmt.Sprintf(`{"detail-type":"OrderCreated","source":"orders","detail":{"_datadog":%s}}`, carrier),
+			source:  eventSourceEventBridge,
+		},
+		"api-gateway": {
+			payload: fmt.Sprintf(`{"httpMethod":"POST","resource":"/orders","headers":%s,"requestContext":{"apiId":"abc"}}`, carrier),
+			source:  eventSourceAPIGateway,
+			method:  "POST",
+		},
+		"api-gateway-v2": {
+			payload: fmt.Sprintf(`{"routeKey":"POST /orders","headers":%s,"requestContext":{"apiId":"abc","http":{"method":"POST"}}}`, carrier),
+			source:  eventSourceAPIGateway,
+			method:  "POST",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt.Reset()
+			h := WrapFunction(func() {})
+			_, err := h.Invoke(context.Background(), []byte(tc.payload))
+			require.NoError(t, err)
+
+			spans := mt.FinishedSpans()
+			require.Len(t, spans, 1)
+			s := spans[0]
+			assert.Equal(t, parent.Context().TraceID(), s.TraceID())
+			assert.Equal(t, parent.Context().SpanID(), s.ParentID())
+			assert.Equal(t, tc.source, s.Tag(tagTriggerSource))
+			if tc.sourceARN != "" {
+				assert.Equal(t, tc.sourceARN, s.Tag(tagTriggerSourceARN))
+			}
+			if tc.method != "" {
+				assert.Equal(t, tc.method, s.Tag(ext.HTTPMethod))
+			}
+		})
+	}
+}
+
+func TestDistributedTracingDisabled(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	parent := tracer.StartSpan("producer")
+	payload := fmt.Sprintf(`{"detail-type":"OrderCreated","source":"orders","detail":{"_datadog":%s}}`, datadogCarrier(t, parent))
+	h := WrapFunction(func() {}, WithDistributedTracing(false))
+	_, err := h.Invoke(context.Background(), []byte(payload))
+	require.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.NotEqual(t, parent.Context().TraceID(), spans[0].TraceID())
+	assert.Equal(t, eventSourceEventBridge, spans[0].Tag(tagTriggerSource))
+}
@@ -0,0 +1,102 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package lambda
+
+import (
+	"math"
+	"os"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+)
+
+const defaultServiceName = "aws.lambda"
+
+type config struct {
+	serviceName   string
+	spanName      string
+	analyticsRate float64
+	errCheck      func(err error) bool
+	flush         bool
+	extract       bool
+}
+
+// Option represents an option that can be passed to WrapHandler.
+type Option func(*config)
+
+func defaults(cfg *config) {
+	fallback := defaultServiceName
+	if name := os.Getenv("AWS_LAMBDA_FUNCTION_NAME"); name != "" {
+		fallback = name
+	}
+	cfg.serviceName = namingschema.NewDefaultServiceName(fallback).GetName()
+	cfg.spanName = "aws.lambda"
+	if internal.BoolEnv("DD_TRACE_AWS_LAMBDA_ANALYTICS_ENABLED", false) {
+		cfg.analyticsRate = 1.0
+	} else {
+		cfg.analyticsRate = math.NaN()
+	}
+	cfg.flush = true
+	cfg.extract = true
+}
+
+// WithServiceName sets the given service name for the invocation spans.
+// It defaults to the name of the function.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithErrorCheck specifies a function fn which determines whether the passed
+// error should be marked as an error. The fn is called whenever an invocation
+// finishes with an error.
+func WithErrorCheck(fn func(err error) bool) Option {
+	return func(cfg *config) {
+		cfg.errCheck = fn
+	}
+}
+
+// WithFlush specifies whether the tracer is flushed at the end of every
+// invocation. It is enabled by default, as the execution environment may be
+// frozen as soon as the handler returns.
+func WithFlush(on bool) Option {
+	return func(cfg *config) {
+		cfg.flush = on
+	}
+}
+
+// WithDistributedTracing specifies whether the span context found in the
+// event payloads is used as parent of the invocation spans. It is enabled by
+// default.
+func WithDistributedTracing(on bool) Option {
+	return func(cfg *config) {
+		cfg.extract = on
+	}
+}
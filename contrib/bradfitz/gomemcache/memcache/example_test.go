@@ -26,3 +26,11 @@ func Example() {
 	mc.WithContext(ctx).Set(&memcache.Item{Key: "my key", Value: []byte("my value")})
 
 }
+
+func ExampleWithServerSelector() {
+	ss := new(memcache.ServerList)
+	ss.SetServers("10.0.0.1:11211", "10.0.0.2:11211")
+	// the spans are tagged with the address of the server picked for the key
+	mc := memcachetrace.WrapClient(memcache.NewFromSelector(ss), memcachetrace.WithServerSelector(ss))
+	mc.Get("my key")
+}
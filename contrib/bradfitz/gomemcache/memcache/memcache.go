@@ -14,6 +14,7 @@ package memcache // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/bradfitz/gom
 import (
 	"context"
 	"math"
+	"net"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
@@ -70,8 +71,10 @@ func (c *Client) WithContext(ctx context.Context) *Client {
 	}
 }
 
-// startSpan starts a span from the context set with WithContext.
-func (c *Client) startSpan(resourceName string) ddtrace.Span {
+// startSpan starts a span from the context set with WithContext. command is
+// the command of the memcached protocol sent by the client, and key the key
+// used to pick the server, if any.
+func (c *Client) startSpan(resourceName, command, key string) ddtrace.Span {
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeMemcached),
 		tracer.ServiceName(c.cfg.serviceName),
@@ -79,6 +82,13 @@ func (c *Client) startSpan(resourceName string) ddtrace.Span {
 		tracer.Tag(ext.Component, componentName),
 		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
 		tracer.Tag(ext.DBSystem, ext.DBSystemMemcached),
+		tracer.Tag("memcached.command", command),
+		tracer.Tag("memcached.pool.max_idle_conns", c.maxIdleConns()),
+	}
+	if c.cfg.selector != nil && key != "" {
+		if addr, err := c.cfg.selector.PickServer(key); err == nil {
+			opts = append(opts, serverAddrTags(addr)...)
+		}
 	}
 	if !math.IsNaN(c.cfg.analyticsRate) {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
@@ -87,11 +97,45 @@ func (c *Client) startSpan(resourceName string) ddtrace.Span {
 	return span
 }
 
+// maxIdleConns returns the maximum number of idle connections kept by the
+// client per server.
+func (c *Client) maxIdleConns() int {
+	if c.Client.MaxIdleConns > 0 {
+		return c.Client.MaxIdleConns
+	}
+	return memcache.DefaultMaxIdleConns
+}
+
+// serverAddrTags returns the tags of the server at addr.
+func serverAddrTags(addr net.Addr) []ddtrace.StartSpanOption {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		// unix socket
+		return []ddtrace.StartSpanOption{tracer.Tag(ext.TargetHost, addr.String())}
+	}
+	return []ddtrace.StartSpanOption{
+		tracer.Tag(ext.TargetHost, host),
+		tracer.Tag(ext.TargetPort, port),
+	}
+}
+
+// setHitsTags tags span with the outcome of the lookup of a single key.
+func setHitsTags(span ddtrace.Span, hit bool, err error) {
+	switch {
+	case hit:
+		span.SetTag("memcached.hits", 1)
+		span.SetTag("memcached.misses", 0)
+	case err == memcache.ErrCacheMiss:
+		span.SetTag("memcached.hits", 0)
+		span.SetTag("memcached.misses", 1)
+	}
+}
+
 // wrapped methods:
 
 // Add invokes and traces Client.Add.
 func (c *Client) Add(item *memcache.Item) error {
-	span := c.startSpan("Add")
+	span := c.startSpan("Add", "add", item.Key)
 	err := c.Client.Add(item)
 	span.Finish(tracer.WithError(err))
 	return err
@@ -99,7 +143,7 @@ func (c *Client) Add(item *memcache.Item) error {
 
 // Append invokes and traces Client.Append.
 func (c *Client) Append(item *memcache.Item) error {
-	span := c.startSpan("Append")
+	span := c.startSpan("Append", "append", item.Key)
 	err := c.Client.Append(item)
 	span.Finish(tracer.WithError(err))
 	return err
@@ -107,7 +151,7 @@ func (c *Client) Append(item *memcache.Item) error {
 
 // CompareAndSwap invokes and traces Client.CompareAndSwap.
 func (c *Client) CompareAndSwap(item *memcache.Item) error {
-	span := c.startSpan("CompareAndSwap")
+	span := c.startSpan("CompareAndSwap", "cas", item.Key)
 	err := c.Client.CompareAndSwap(item)
 	span.Finish(tracer.WithError(err))
 	return err
@@ -115,7 +159,7 @@ func (c *Client) CompareAndSwap(item *memcache.Item) error {
 
 // Decrement invokes and traces Client.Decrement.
 func (c *Client) Decrement(key string, delta uint64) (newValue uint64, err error) {
-	span := c.startSpan("Decrement")
+	span := c.startSpan("Decrement", "decr", key)
 	newValue, err = c.Client.Decrement(key, delta)
 	span.Finish(tracer.WithError(err))
 	return newValue, err
@@ -123,7 +167,7 @@ func (c *Client) Decrement(key string, delta uint64) (newValue uint64, err error
 
 // Delete invokes and traces Client.Delete.
 func (c *Client) Delete(key string) error {
-	span := c.startSpan("Delete")
+	span := c.startSpan("Delete", "delete", key)
 	err := c.Client.Delete(key)
 	span.Finish(tracer.WithError(err))
 	return err
@@ -131,7 +175,7 @@ func (c *Client) Delete(key string) error {
 
 // DeleteAll invokes and traces Client.DeleteAll.
 func (c *Client) DeleteAll() error {
-	span := c.startSpan("DeleteAll")
+	span := c.startSpan("DeleteAll", "flush_all", "")
 	err := c.Client.DeleteAll()
 	span.Finish(tracer.WithError(err))
 	return err
@@ -139,7 +183,7 @@ func (c *Client) DeleteAll() error {
 
 // FlushAll invokes and traces Client.FlushAll.
 func (c *Client) FlushAll() error {
-	span := c.startSpan("FlushAll")
+	span := c.startSpan("FlushAll", "flush_all", "")
 	err := c.Client.FlushAll()
 	span.Finish(tracer.WithError(err))
 	return err
@@ -147,23 +191,28 @@ func (c *Client) FlushAll() error {
 
 // Get invokes and traces Client.Get.
 func (c *Client) Get(key string) (item *memcache.Item, err error) {
-	span := c.startSpan("Get")
+	span := c.startSpan("Get", "gets", key)
 	item, err = c.Client.Get(key)
+	setHitsTags(span, item != nil, err)
 	span.Finish(tracer.WithError(err))
 	return item, err
 }
 
 // GetMulti invokes and traces Client.GetMulti.
 func (c *Client) GetMulti(keys []string) (map[string]*memcache.Item, error) {
-	span := c.startSpan("GetMulti")
+	span := c.startSpan("GetMulti", "gets", "")
 	items, err := c.Client.GetMulti(keys)
+	if err == nil {
+		span.SetTag("memcached.hits", len(items))
+		span.SetTag("memcached.misses", len(keys)-len(items))
+	}
 	span.Finish(tracer.WithError(err))
 	return items, err
 }
 
 // Increment invokes and traces Client.Increment.
 func (c *Client) Increment(key string, delta uint64) (newValue uint64, err error) {
-	span := c.startSpan("Increment")
+	span := c.startSpan("Increment", "incr", key)
 	newValue, err = c.Client.Increment(key, delta)
 	span.Finish(tracer.WithError(err))
 	return newValue, err
@@ -171,7 +220,7 @@ func (c *Client) Increment(key string, delta uint64) (newValue uint64, err error
 
 // Prepend invokes and traces Client.Prepend.
 func (c *Client) Prepend(item *memcache.Item) error {
-	span := c.startSpan("Prepend")
+	span := c.startSpan("Prepend", "prepend", item.Key)
 	err := c.Client.Prepend(item)
 	span.Finish(tracer.WithError(err))
 	return err
@@ -179,7 +228,7 @@ func (c *Client) Prepend(item *memcache.Item) error {
 
 // Replace invokes and traces Client.Replace.
 func (c *Client) Replace(item *memcache.Item) error {
-	span := c.startSpan("Replace")
+	span := c.startSpan("Replace", "replace", item.Key)
 	err := c.Client.Replace(item)
 	span.Finish(tracer.WithError(err))
 	return err
@@ -187,7 +236,7 @@ func (c *Client) Replace(item *memcache.Item) error {
 
 // Set invokes and traces Client.Set.
 func (c *Client) Set(item *memcache.Item) error {
-	span := c.startSpan("Set")
+	span := c.startSpan("Set", "set", item.Key)
 	err := c.Client.Set(item)
 	span.Finish(tracer.WithError(err))
 	return err
@@ -195,7 +244,7 @@ func (c *Client) Set(item *memcache.Item) error {
 
 // Touch invokes and traces Client.Touch.
 func (c *Client) Touch(key string, seconds int32) error {
-	span := c.startSpan("Touch")
+	span := c.startSpan("Touch", "touch", key)
 	err := c.Client.Touch(key, seconds)
 	span.Finish(tracer.WithError(err))
 	return err
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -102,6 +103,63 @@ func testMemcache(t *testing.T, addr string) {
 	})
 }
 
+func TestHitsAndMisses(t *testing.T) {
+	li := makeFakeServer(t)
+	defer li.Close()
+	addr := li.Addr().(*net.TCPAddr)
+
+	ss := new(memcache.ServerList)
+	require.NoError(t, ss.SetServers(addr.String()))
+	client := WrapClient(memcache.NewFromSelector(ss), WithServerSelector(ss))
+	client.Timeout = 2 * time.Second
+
+	t.Run("hit", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		_, err := client.Get("hit")
+		assert.NoError(t, err)
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "gets", spans[0].Tag("memcached.command"))
+		assert.Equal(t, 1, spans[0].Tag("memcached.hits"))
+		assert.Equal(t, 0, spans[0].Tag("memcached.misses"))
+		assert.Equal(t, memcache.DefaultMaxIdleConns, spans[0].Tag("memcached.pool.max_idle_conns"))
+		assert.Equal(t, addr.IP.String(), spans[0].Tag(ext.TargetHost))
+		assert.Equal(t, strconv.Itoa(addr.Port), spans[0].Tag(ext.TargetPort))
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		_, err := client.Get("miss")
+		assert.Equal(t, memcache.ErrCacheMiss, err)
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, 0, spans[0].Tag("memcached.hits"))
+		assert.Equal(t, 1, spans[0].Tag("memcached.misses"))
+	})
+
+	t.Run("multi", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		items, err := client.GetMulti([]string{"hit", "miss", "other"})
+		assert.NoError(t, err)
+		assert.Len(t, items, 1)
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "GetMulti", spans[0].Tag(ext.ResourceName))
+		assert.Equal(t, 1, spans[0].Tag("memcached.hits"))
+		assert.Equal(t, 2, spans[0].Tag("memcached.misses"))
+		assert.Nil(t, spans[0].Tag(ext.TargetHost))
+	})
+}
+
 func TestFakeServer(t *testing.T) {
 	li := makeFakeServer(t)
 	defer li.Close()
@@ -248,6 +306,14 @@ func makeFakeServer(t *testing.T) net.Listener {
 							return
 						}
 						fmt.Fprintf(c, "STORED\r\n")
+					case "gets":
+						// only the "hit" key is found
+						for _, key := range args[1:] {
+							if key == "hit" {
+								fmt.Fprintf(c, "VALUE hit 0 5 1\r\nvalue\r\n")
+							}
+						}
+						fmt.Fprintf(c, "END\r\n")
 					default:
 						fmt.Fprintf(c, "SERVER ERROR unknown command: %v \r\n", args[0])
 						return
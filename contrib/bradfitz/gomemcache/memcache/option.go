@@ -10,6 +10,8 @@ import (
 
 	"gopkg.in/DataDog/dd-trace-go.v1/internal"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+
+	"github.com/bradfitz/gomemcache/memcache"
 )
 
 const (
@@ -20,6 +22,7 @@ type clientConfig struct {
 	serviceName   string
 	operationName string
 	analyticsRate float64
+	selector      memcache.ServerSelector
 }
 
 // ClientOption represents an option that can be passed to Dial.
@@ -69,3 +72,12 @@ func WithAnalyticsRate(rate float64) ClientOption {
 		}
 	}
 }
+
+// WithServerSelector sets the ServerSelector the client was created with, e.g.
+// by memcache.NewFromSelector. It is used to tag the spans with the address of
+// the server picked for the key of the command.
+func WithServerSelector(ss memcache.ServerSelector) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.selector = ss
+	}
+}
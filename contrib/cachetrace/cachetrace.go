@@ -0,0 +1,150 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package cachetrace provides functions to trace the lookups of in-process
+// caches, such as dgraph-io/ristretto, maypok86/otter or allegro/bigcache.
+//
+// The Get method of the cache is wrapped in a function which takes a context
+// and traces every lookup with a span tagged with its outcome, hit or miss, and
+// a hash of the looked up key:
+//
+//	cache, _ := ristretto.NewCache(&ristretto.Config[string, *User]{...})
+//	get := cachetrace.Wrap(cache.Get, cachetrace.WithCacheName("users"))
+//	user, ok := get(ctx, id)
+//
+// Caches which report misses through an error, like bigcache, are wrapped with
+// WrapError instead:
+//
+//	get := cachetrace.WrapError(cache.Get, func(err error) bool {
+//		return errors.Is(err, bigcache.ErrEntryNotFound)
+//	})
+//
+// To avoid the overhead of a span per lookup, WithParentTags tags the span
+// found in the context, such as the span of the request being served, instead.
+package cachetrace // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/cachetrace"
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+)
+
+const componentName = "cachetrace"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Tags set on the lookup spans.
+const (
+	tagCacheName = "cache.name"
+	tagHit       = "cache.hit"
+	tagKeyHash   = "cache.key_hash"
+)
+
+// Getter looks up the value stored in a cache for key. It reports whether the
+// key was found, like the Get methods of ristretto and otter.
+type Getter[K comparable, V any] func(key K) (V, bool)
+
+// ErrorGetter looks up the value stored in a cache for key. It returns an
+// error if the key wasn't found, like the Get method of bigcache.
+type ErrorGetter[K comparable, V any] func(key K) (V, error)
+
+// Wrap returns a traced version of get. Every lookup is traced by a span which
+// is a child of the span found in the given context, if any.
+func Wrap[K comparable, V any](get Getter[K, V], opts ...Option) func(ctx context.Context, key K) (V, bool) {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/cachetrace: Wrapping Getter: %#v", cfg)
+	return func(ctx context.Context, key K) (V, bool) {
+		l := cfg.start(ctx, key)
+		v, ok := get(key)
+		l.finish(ok, nil)
+		return v, ok
+	}
+}
+
+// WrapError returns a traced version of get. The function isMiss reports
+// whether an error returned by get means that the key wasn't found. Other
+// errors are set on the spans.
+func WrapError[K comparable, V any](get ErrorGetter[K, V], isMiss func(err error) bool, opts ...Option) func(ctx context.Context, key K) (V, error) {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/cachetrace: Wrapping ErrorGetter: %#v", cfg)
+	return func(ctx context.Context, key K) (V, error) {
+		l := cfg.start(ctx, key)
+		v, err := get(key)
+		switch {
+		case err == nil:
+			l.finish(true, nil)
+		case isMiss != nil && isMiss(err):
+			l.finish(false, nil)
+		default:
+			l.finish(false, err)
+		}
+		return v, err
+	}
+}
+
+// lookup is a traced cache lookup.
+type lookup struct {
+	cfg  *config
+	span ddtrace.Span
+	// own reports whether span was started for the lookup, rather than
+	// being the span found in the context.
+	own bool
+}
+
+func (cfg *config) start(ctx context.Context, key interface{}) lookup {
+	if cfg.parentTags {
+		span, _ := tracer.SpanFromContext(ctx)
+		if cfg.keyHash {
+			span.SetTag(cfg.parentTag(tagKeyHash), hashKey(key))
+		}
+		return lookup{cfg: cfg, span: span}
+	}
+	opts := []ddtrace.StartSpanOption{
+		tracer.ResourceName(cfg.resourceName()),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindInternal),
+	}
+	if cfg.serviceName != "" {
+		opts = append(opts, tracer.ServiceName(cfg.serviceName))
+	}
+	if cfg.cacheName != "" {
+		opts = append(opts, tracer.Tag(tagCacheName, cfg.cacheName))
+	}
+	if cfg.keyHash {
+		opts = append(opts, tracer.Tag(tagKeyHash, hashKey(key)))
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	span, _ := tracer.StartSpanFromContext(ctx, cfg.spanName, opts...)
+	return lookup{cfg: cfg, span: span, own: true}
+}
+
+func (l lookup) finish(hit bool, err error) {
+	if !l.own {
+		l.span.SetTag(l.cfg.parentTag(tagHit), hit)
+		return
+	}
+	l.span.SetTag(tagHit, hit)
+	l.span.Finish(tracer.WithError(err))
+}
+
+// hashKey returns the hexadecimal FNV-1a hash of the string representation of
+// key, so that keys can be correlated without being sent to Datadog.
+func hashKey(key interface{}) string {
+	h := fnv.New64a()
+	fmt.Fprint(h, key)
+	return strconv.FormatUint(h.Sum64(), 16)
+}
@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package cachetrace
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errNotFound = errors.New("entry not found")
+
+func mapGetter(m map[string]int) Getter[string, int] {
+	return func(key string) (int, bool) {
+		v, ok := m[key]
+		return v, ok
+	}
+}
+
+func TestWrap(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	get := Wrap(mapGetter(map[string]int{"a": 1}), WithCacheName("numbers"))
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "root")
+	v, ok := get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	_, ok = get(ctx, "b")
+	assert.False(t, ok)
+	root.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 3)
+	for i, hit := range []bool{true, false} {
+		s := spans[i]
+		assert.Equal(t, defaultSpanName, s.OperationName())
+		assert.Equal(t, "numbers", s.Tag(ext.ResourceName))
+		assert.Equal(t, "numbers", s.Tag(tagCacheName))
+		assert.Equal(t, hit, s.Tag(tagHit))
+		assert.Equal(t, componentName, s.Tag(ext.Component))
+		assert.Equal(t, ext.SpanKindInternal, s.Tag(ext.SpanKind))
+		assert.Equal(t, root.Context().SpanID(), s.ParentID())
+	}
+	assert.Equal(t, hashKey("a"), spans[0].Tag(tagKeyHash))
+	assert.Equal(t, hashKey("b"), spans[1].Tag(tagKeyHash))
+	assert.NotEqual(t, spans[0].Tag(tagKeyHash), spans[1].Tag(tagKeyHash))
+}
+
+func TestWrapError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	errBroken := errors.New("broken")
+	get := WrapError(func(key string) ([]byte, error) {
+		switch key {
+		case "a":
+			return []byte("1"), nil
+		case "b":
+			return nil, errNotFound
+		}
+		return nil, errBroken
+	}, func(err error) bool {
+		return errors.Is(err, errNotFound)
+	}, WithKeyHash(false))
+
+	for _, key := range []string{"a", "b", "c"} {
+		get(context.Background(), key)
+	}
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, true, spans[0].Tag(tagHit))
+	assert.Nil(t, spans[0].Tag(ext.Error))
+	assert.Equal(t, false, spans[1].Tag(tagHit))
+	assert.Nil(t, spans[1].Tag(ext.Error))
+	assert.Equal(t, false, spans[2].Tag(tagHit))
+	assert.Equal(t, errBroken, spans[2].Tag(ext.Error))
+	for _, s := range spans {
+		assert.Nil(t, s.Tag(tagKeyHash))
+		assert.Equal(t, defaultResourceName, s.Tag(ext.ResourceName))
+	}
+}
+
+func TestWithParentTags(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	get := Wrap(mapGetter(map[string]int{"a": 1}), WithParentTags(), WithCacheName("numbers"))
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "root")
+	get(ctx, "a")
+	root.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, true, spans[0].Tag("cache.hit.numbers"))
+	assert.Equal(t, hashKey("a"), spans[0].Tag("cache.key_hash.numbers"))
+}
@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package cachetrace_test
+
+import (
+	"context"
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/cachetrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func Example() {
+	// Any function with the signature of the Get method of the cache can be
+	// wrapped, e.g. the Get method of a ristretto.Cache or an otter.Cache.
+	var cache sync.Map
+	cache.Store("user:1", "gopher")
+
+	get := cachetrace.Wrap(func(key string) (interface{}, bool) {
+		return cache.Load(key)
+	}, cachetrace.WithCacheName("users"))
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "http.request")
+	defer span.Finish()
+
+	// The lookup is traced by a child of span.
+	if user, ok := get(ctx, "user:1"); ok {
+		_ = user
+	}
+}
@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package cachetrace
+
+import (
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+)
+
+const (
+	defaultSpanName     = "cache.get"
+	defaultResourceName = "get"
+)
+
+type config struct {
+	serviceName   string
+	spanName      string
+	cacheName     string
+	analyticsRate float64
+	keyHash       bool
+	parentTags    bool
+}
+
+// Option represents an option that can be passed to Wrap or WrapError.
+type Option func(*config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		spanName:      defaultSpanName,
+		analyticsRate: math.NaN(),
+		keyHash:       true,
+	}
+	if internal.BoolEnv("DD_TRACE_CACHETRACE_ANALYTICS_ENABLED", false) {
+		cfg.analyticsRate = 1.0
+	}
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return cfg
+}
+
+func (cfg *config) resourceName() string {
+	if cfg.cacheName != "" {
+		return cfg.cacheName
+	}
+	return defaultResourceName
+}
+
+// parentTag returns the given tag qualified by the name of the cache, if any,
+// so that the lookups in several caches can be told apart when tagging the
+// span found in the context.
+func (cfg *config) parentTag(tag string) string {
+	if cfg.cacheName != "" {
+		return tag + "." + cfg.cacheName
+	}
+	return tag
+}
+
+// WithServiceName sets the given service name for the lookup spans. By
+// default, the spans inherit the service of their parent.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithSpanName sets the operation name of the lookup spans. It defaults to
+// "cache.get".
+func WithSpanName(name string) Option {
+	return func(cfg *config) {
+		cfg.spanName = name
+	}
+}
+
+// WithCacheName sets the name of the cache, which is used as the resource of
+// the lookup spans and set in the cache.name tag.
+func WithCacheName(name string) Option {
+	return func(cfg *config) {
+		cfg.cacheName = name
+	}
+}
+
+// WithKeyHash enables or disables tagging the spans with a hash of the looked
+// up key. It is enabled by default.
+func WithKeyHash(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.keyHash = enabled
+	}
+}
+
+// WithParentTags tags the span found in the context of every lookup with its
+// outcome instead of starting a new span. When a cache name is set, the tags
+// are suffixed with it, e.g. cache.hit.<name>.
+func WithParentTags() Option {
+	return func(cfg *config) {
+		cfg.parentTags = true
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
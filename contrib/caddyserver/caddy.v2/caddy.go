@@ -0,0 +1,255 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package caddy provides a Caddy HTTP handler module tracing the requests
+// served by Caddy (https://github.com/caddyserver/caddy).
+//
+// The module is registered as http.handlers.datadog, and as the datadog
+// directive of the Caddyfile, by importing this package in a Caddy build, e.g.
+// with xcaddy:
+//
+//	xcaddy build --with gopkg.in/DataDog/dd-trace-go.v1/contrib/caddyserver/caddy.v2
+//
+// Requests are traced by server spans which are propagated to the upstream
+// services proxied to by the handlers that follow, such as reverse_proxy, in
+// the headers of the requests. The tracer is started when the module is first
+// provisioned and stopped once no configuration uses it anymore. It is
+// configured with the DD_* environment variables.
+package caddy // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/caddyserver/caddy.v2"
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/httptrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/httpsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/normalizer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/caddyserver/caddy/v2/caddyconfig/httpcaddyfile"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"go.uber.org/zap"
+)
+
+const (
+	componentName      = "caddyserver/caddy.v2"
+	defaultServiceName = "caddy"
+)
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+	caddy.RegisterModule(Handler{})
+	httpcaddyfile.RegisterHandlerDirective("datadog", parseCaddyfile)
+	httpcaddyfile.RegisterDirectiveOrder("datadog", httpcaddyfile.Before, "tracing")
+}
+
+// Handler is a Caddy HTTP handler tracing the requests it serves.
+type Handler struct {
+	// Service is the service name of the request spans. It defaults to
+	// the service name of the tracer, or caddy.
+	Service string `json:"service,omitempty"`
+
+	// Resource is the resource name of the request spans, which may hold
+	// placeholders. It defaults to the method of the request, as paths may
+	// have a high cardinality.
+	Resource string `json:"resource,omitempty"`
+
+	// HeaderTags are the request headers to tag the request spans with, as
+	// header or header:tag, like DD_TRACE_HEADER_TAGS which they default to.
+	HeaderTags []string `json:"header_tags,omitempty"`
+
+	// Tags are additional tags of the request spans, whose values may hold
+	// placeholders. They are set once the request was served.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	serviceName   string
+	analyticsRate float64
+	headerTags    *internal.LockMap
+}
+
+// CaddyModule returns the Caddy module information.
+func (Handler) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID:  "http.handlers.datadog",
+		New: func() caddy.Module { return new(Handler) },
+	}
+}
+
+// Provision implements caddy.Provisioner and starts the tracer, unless it is
+// already started by another configuration.
+func (h *Handler) Provision(caddy.Context) error {
+	h.setup()
+	startTracer()
+	return nil
+}
+
+// setup configures the handler from its fields.
+func (h *Handler) setup() {
+	h.serviceName = h.Service
+	if h.serviceName == "" {
+		h.serviceName = namingschema.NewDefaultServiceName(defaultServiceName).GetName()
+	}
+	if len(h.HeaderTags) > 0 {
+		h.headerTags = internal.NewLockMap(normalizer.HeaderTagSlice(h.HeaderTags))
+	} else {
+		h.headerTags = globalconfig.HeaderTagMap()
+	}
+	if internal.BoolEnv("DD_TRACE_CADDY_ANALYTICS_ENABLED", false) {
+		h.analyticsRate = 1.0
+	} else {
+		h.analyticsRate = globalconfig.AnalyticsRate()
+	}
+	log.Debug("contrib/caddyserver/caddy.v2: Configuring Handler: %#v", h)
+}
+
+// Cleanup implements caddy.CleanerUpper and stops the tracer, unless it is
+// still used by another configuration.
+func (h *Handler) Cleanup() error {
+	stopTracer()
+	return nil
+}
+
+// ServeHTTP implements caddyhttp.MiddlewareHandler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhttp.Handler) error {
+	repl, _ := r.Context().Value(caddy.ReplacerCtxKey).(*caddy.Replacer)
+	resource := r.Method
+	if h.Resource != "" && repl != nil {
+		resource = repl.ReplaceAll(h.Resource, "")
+	}
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(h.serviceName),
+		tracer.ResourceName(resource),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+		httptrace.HeaderTagsFromRequest(r, h.headerTags),
+	}
+	if !math.IsNaN(h.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, h.analyticsRate))
+	}
+	span, ctx := httptrace.StartRequestSpan(r, opts...)
+
+	// Propagate the span to the upstream services proxied to.
+	if err := tracer.Inject(span.Context(), tracer.HTTPHeadersCarrier(r.Header)); err != nil {
+		log.Debug("contrib/caddyserver/caddy.v2: failed to inject http headers: %v", err)
+	}
+	traceID := strconv.FormatUint(span.Context().TraceID(), 10)
+	spanID := strconv.FormatUint(span.Context().SpanID(), 10)
+	// The ids are available as the {http.vars.trace_id} and
+	// {http.vars.span_id} placeholders, and in the access logs.
+	caddyhttp.SetVar(ctx, "trace_id", traceID)
+	caddyhttp.SetVar(ctx, "span_id", spanID)
+	if extra, ok := ctx.Value(caddyhttp.ExtraLogFieldsCtxKey).(*caddyhttp.ExtraLogFields); ok {
+		extra.Add(zap.String("dd.trace_id", traceID))
+		extra.Add(zap.String("dd.span_id", spanID))
+	}
+
+	rw := &responseWriter{ResponseWriterWrapper: &caddyhttp.ResponseWriterWrapper{ResponseWriter: w}}
+	var err error
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = next.ServeHTTP(w, r)
+	})
+	if appsec.Enabled() {
+		handler = httpsec.WrapHandler(handler, span, nil)
+	}
+	handler.ServeHTTP(rw, r.WithContext(ctx))
+
+	for k, v := range h.Tags {
+		if repl != nil {
+			v = repl.ReplaceAll(v, "")
+		}
+		span.SetTag(k, v)
+	}
+	status := rw.status
+	var finishOpts []ddtrace.FinishOption
+	if err != nil {
+		// Caddy responds to the error once it is returned, with its status
+		// code if it has one.
+		status = http.StatusInternalServerError
+		var handlerErr caddyhttp.HandlerError
+		if errors.As(err, &handlerErr) && handlerErr.StatusCode != 0 {
+			status = handlerErr.StatusCode
+		}
+		if status >= 500 {
+			finishOpts = append(finishOpts, tracer.WithError(err))
+		}
+	}
+	httptrace.FinishRequestSpan(span, status, finishOpts...)
+	return err
+}
+
+// responseWriter records the status code of the response to a request.
+type responseWriter struct {
+	*caddyhttp.ResponseWriterWrapper
+	status int
+}
+
+// Status returns the status code of the response, or 0 if it was not written
+// yet. It is used by AppSec.
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *responseWriter) WriteHeader(status int) {
+	// Informational responses precede the final one.
+	if w.status == 0 && status >= 200 {
+		w.status = status
+	}
+	w.ResponseWriterWrapper.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriterWrapper.Write(b)
+}
+
+// tracerUsers counts the provisioned handlers using the tracer, which is
+// started by the first of them and stopped by the last one.
+var tracerUsers struct {
+	sync.Mutex
+	n int
+}
+
+func startTracer() {
+	tracerUsers.Lock()
+	defer tracerUsers.Unlock()
+	if tracerUsers.n == 0 {
+		tracer.Start()
+	}
+	tracerUsers.n++
+}
+
+func stopTracer() {
+	tracerUsers.Lock()
+	defer tracerUsers.Unlock()
+	tracerUsers.n--
+	if tracerUsers.n == 0 {
+		tracer.Stop()
+	}
+}
+
+// Interface guards
+var (
+	_ caddy.Provisioner           = (*Handler)(nil)
+	_ caddy.CleanerUpper          = (*Handler)(nil)
+	_ caddyhttp.MiddlewareHandler = (*Handler)(nil)
+	_ caddyfile.Unmarshaler       = (*Handler)(nil)
+)
@@ -0,0 +1,228 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package caddy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve serves r with h and next, as a Caddy server does.
+func serve(t *testing.T, h *Handler, r *http.Request, next caddyhttp.HandlerFunc) (*httptest.ResponseRecorder, map[string]any, error) {
+	t.Helper()
+	h.setup()
+	vars := make(map[string]any)
+	ctx := context.WithValue(r.Context(), caddy.ReplacerCtxKey, caddyhttp.NewTestReplacer(r))
+	ctx = context.WithValue(ctx, caddyhttp.VarsCtxKey, vars)
+	w := httptest.NewRecorder()
+	err := h.ServeHTTP(w, r.WithContext(ctx), next)
+	return w, vars, err
+}
+
+func TestHandler(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	var upstream http.Header
+	h := &Handler{
+		Service:    "edge",
+		HeaderTags: []string{"X-Request-Id:request.id"},
+	}
+	r := httptest.NewRequest("GET", "http://example.com/users/1", nil)
+	r.Header.Set("X-Request-Id", "abc")
+	w, vars, err := serve(t, h, r, func(w http.ResponseWriter, r *http.Request) error {
+		upstream = r.Header.Clone()
+		_, err := w.Write([]byte("ok"))
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "http.request", s.OperationName())
+	assert.Equal(t, "GET", s.Tag(ext.ResourceName))
+	assert.Equal(t, "edge", s.Tag(ext.ServiceName))
+	assert.Equal(t, "http://example.com/users/1", s.Tag(ext.HTTPURL))
+	assert.Equal(t, "200", s.Tag(ext.HTTPCode))
+	assert.Equal(t, "abc", s.Tag("request.id"))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, ext.SpanKindServer, s.Tag(ext.SpanKind))
+	assert.Nil(t, s.Tag(ext.Error))
+
+	// The span is propagated to the next handlers, such as reverse_proxy.
+	spanctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(upstream))
+	require.NoError(t, err)
+	assert.Equal(t, s.SpanID(), spanctx.SpanID())
+	assert.Equal(t, s.TraceID(), spanctx.TraceID())
+	assert.Equal(t, strconv.FormatUint(s.TraceID(), 10), vars["trace_id"])
+	assert.Equal(t, strconv.FormatUint(s.SpanID(), 10), vars["span_id"])
+}
+
+func TestHandlerParent(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	parent := tracer.StartSpan("parent")
+	r := httptest.NewRequest("GET", "/", nil)
+	require.NoError(t, tracer.Inject(parent.Context(), tracer.HTTPHeadersCarrier(r.Header)))
+	_, _, err := serve(t, &Handler{}, r, func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, parent.Context().SpanID(), spans[0].ParentID())
+	assert.Equal(t, parent.Context().TraceID(), spans[0].TraceID())
+}
+
+func TestHandlerPlaceholders(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	h := &Handler{
+		Resource: "{http.request.method} {http.request.host}",
+		Tags:     map[string]string{"request.path": "{http.request.uri.path}"},
+	}
+	r := httptest.NewRequest("POST", "http://example.com/users", nil)
+	_, _, err := serve(t, h, r, func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusCreated)
+		return nil
+	})
+	require.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "POST example.com", spans[0].Tag(ext.ResourceName))
+	assert.Equal(t, "/users", spans[0].Tag("request.path"))
+	assert.Equal(t, "201", spans[0].Tag(ext.HTTPCode))
+}
+
+func TestHandlerErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		err     error
+		status  string
+		spanErr bool
+	}{
+		{
+			name:    "server",
+			err:     caddyhttp.Error(http.StatusBadGateway, errors.New("upstream down")),
+			status:  "502",
+			spanErr: true,
+		},
+		{
+			name:   "client",
+			err:    caddyhttp.Error(http.StatusNotFound, errors.New("not found")),
+			status: "404",
+		},
+		{
+			name:    "no-status",
+			err:     errors.New("boom"),
+			status:  "500",
+			spanErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			r := httptest.NewRequest("GET", "/", nil)
+			_, _, err := serve(t, &Handler{}, r, func(w http.ResponseWriter, r *http.Request) error {
+				return tt.err
+			})
+			require.Equal(t, tt.err, err)
+
+			spans := mt.FinishedSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.status, spans[0].Tag(ext.HTTPCode))
+			if tt.spanErr {
+				assert.Equal(t, tt.err, spans[0].Tag(ext.Error))
+			} else {
+				assert.Nil(t, spans[0].Tag(ext.Error))
+			}
+		})
+	}
+}
+
+func TestResponseWriterInformational(t *testing.T) {
+	w := &responseWriter{ResponseWriterWrapper: &caddyhttp.ResponseWriterWrapper{ResponseWriter: httptest.NewRecorder()}}
+	w.WriteHeader(http.StatusEarlyHints)
+	assert.Equal(t, 0, w.Status())
+	w.WriteHeader(http.StatusAccepted)
+	assert.Equal(t, http.StatusAccepted, w.Status())
+}
+
+func TestUnmarshalCaddyfile(t *testing.T) {
+	t.Run("full", func(t *testing.T) {
+		var h Handler
+		err := h.UnmarshalCaddyfile(caddyfile.NewTestDispenser(`
+datadog {
+	service edge
+	resource "{http.request.method} {http.request.host}"
+	header_tags X-Request-Id:request.id User-Agent
+	tags {
+		team platform
+	}
+}`))
+		require.NoError(t, err)
+		assert.Equal(t, "edge", h.Service)
+		assert.Equal(t, "{http.request.method} {http.request.host}", h.Resource)
+		assert.Equal(t, []string{"X-Request-Id:request.id", "User-Agent"}, h.HeaderTags)
+		assert.Equal(t, map[string]string{"team": "platform"}, h.Tags)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		var h Handler
+		require.NoError(t, h.UnmarshalCaddyfile(caddyfile.NewTestDispenser(`datadog`)))
+		assert.Equal(t, Handler{}, h)
+	})
+
+	for name, input := range map[string]string{
+		"argument":   `datadog edge`,
+		"unknown":    "datadog {\n\tunknown value\n}",
+		"no-service": "datadog {\n\tservice\n}",
+		"no-headers": "datadog {\n\theader_tags\n}",
+		"bad-tag":    "datadog {\n\ttags {\n\t\tteam\n\t}\n}",
+	} {
+		t.Run(name, func(t *testing.T) {
+			var h Handler
+			assert.Error(t, h.UnmarshalCaddyfile(caddyfile.NewTestDispenser(input)))
+		})
+	}
+}
+
+func TestAnalyticsSettings(t *testing.T) {
+	t.Setenv("DD_TRACE_CADDY_ANALYTICS_ENABLED", "true")
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	r := httptest.NewRequest("GET", "/", nil)
+	_, _, err := serve(t, &Handler{}, r, func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, 1.0, spans[0].Tag(ext.EventSampleRate))
+}
@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package caddy
+
+import (
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/caddyserver/caddy/v2/caddyconfig/httpcaddyfile"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+// UnmarshalCaddyfile sets up the handler from Caddyfile tokens. Syntax:
+//
+//	datadog {
+//		[service <name>]
+//		[resource <resource>]
+//		[header_tags <header[:tag]>...]
+//		[tags {
+//			<tag> <value>
+//		}]
+//	}
+func (h *Handler) UnmarshalCaddyfile(d *caddyfile.Dispenser) error {
+	d.Next() // consume directive name
+	if d.NextArg() {
+		return d.ArgErr()
+	}
+	for d.NextBlock(0) {
+		switch d.Val() {
+		case "service":
+			if !d.AllArgs(&h.Service) {
+				return d.ArgErr()
+			}
+		case "resource":
+			if !d.AllArgs(&h.Resource) {
+				return d.ArgErr()
+			}
+		case "header_tags":
+			args := d.RemainingArgs()
+			if len(args) == 0 {
+				return d.ArgErr()
+			}
+			h.HeaderTags = append(h.HeaderTags, args...)
+		case "tags":
+			if d.NextArg() {
+				return d.ArgErr()
+			}
+			if h.Tags == nil {
+				h.Tags = make(map[string]string)
+			}
+			for d.NextBlock(1) {
+				key := d.Val()
+				var value string
+				if !d.AllArgs(&value) {
+					return d.ArgErr()
+				}
+				h.Tags[key] = value
+			}
+		default:
+			return d.Errf("unrecognized subdirective %s", d.Val())
+		}
+	}
+	return nil
+}
+
+func parseCaddyfile(h httpcaddyfile.Helper) (caddyhttp.MiddlewareHandler, error) {
+	var m Handler
+	err := m.UnmarshalCaddyfile(h.Dispenser)
+	return &m, err
+}
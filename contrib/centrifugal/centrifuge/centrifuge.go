@@ -0,0 +1,181 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package centrifuge provides functions to trace the centrifugal/centrifuge package (https://github.com/centrifugal/centrifuge).
+//
+// The event handlers registered on a centrifuge.Node and its clients are
+// wrapped so that every connection attempt, subscription, publication and RPC
+// is traced. The spans of the events of a client are children of the span
+// found in the context of the client, if any, and finish when the handler
+// replies to the event through its callback:
+//
+//	node.OnConnecting(centrifugetrace.WrapConnecting(onConnecting))
+//	node.OnConnect(func(client *centrifuge.Client) {
+//		client.OnSubscribe(centrifugetrace.WrapSubscribe(client, onSubscribe))
+//		client.OnPublish(centrifugetrace.WrapPublish(client, onPublish))
+//		client.OnRPC(centrifugetrace.WrapRPC(client, onRPC))
+//	})
+package centrifuge // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/centrifugal/centrifuge"
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/centrifugal/centrifuge"
+)
+
+const componentName = "centrifugal/centrifuge"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Tags set on the event spans.
+const (
+	tagChannel    = "centrifuge.channel"
+	tagClientID   = "centrifuge.client_id"
+	tagUserID     = "centrifuge.user_id"
+	tagTransport  = "centrifuge.transport"
+	tagRPCMethod  = "centrifuge.rpc_method"
+	tagDataSize   = "centrifuge.data_size"
+	tagErrorCode  = "centrifuge.error_code"
+	tagDisconnect = "centrifuge.disconnect_code"
+)
+
+// Names of the event spans.
+const (
+	spanConnect   = "centrifuge.connect"
+	spanSubscribe = "centrifuge.subscribe"
+	spanPublish   = "centrifuge.publish"
+	spanRPC       = "centrifuge.rpc"
+)
+
+const resourceConnect = "connect"
+
+// WrapConnecting returns a traced version of the given connecting handler, to
+// be registered with the OnConnecting method of a centrifuge.Node.
+func WrapConnecting(h centrifuge.ConnectingHandler, opts ...Option) centrifuge.ConnectingHandler {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/centrifugal/centrifuge: Wrapping ConnectingHandler: %#v", cfg)
+	return func(ctx context.Context, e centrifuge.ConnectEvent) (centrifuge.ConnectReply, error) {
+		spanOpts := cfg.startSpanOptions(resourceConnect,
+			tracer.Tag(tagClientID, e.ClientID),
+		)
+		if e.Transport != nil {
+			spanOpts = append(spanOpts, tracer.Tag(tagTransport, e.Transport.Name()))
+		}
+		span, ctx := tracer.StartSpanFromContext(ctx, spanConnect, spanOpts...)
+		reply, err := h(ctx, e)
+		if reply.Credentials != nil {
+			span.SetTag(tagUserID, reply.Credentials.UserID)
+		}
+		cfg.finishSpan(span, err)
+		return reply, err
+	}
+}
+
+// WrapSubscribe returns a traced version of the given subscribe handler, to be
+// registered with the OnSubscribe method of client.
+func WrapSubscribe(client *centrifuge.Client, h centrifuge.SubscribeHandler, opts ...Option) centrifuge.SubscribeHandler {
+	cfg := newConfig(opts...)
+	return func(e centrifuge.SubscribeEvent, cb centrifuge.SubscribeCallback) {
+		span := cfg.startClientSpan(client, spanSubscribe, e.Channel,
+			tracer.Tag(tagChannel, e.Channel),
+			tracer.Tag(tagDataSize, len(e.Data)),
+		)
+		h(e, func(reply centrifuge.SubscribeReply, err error) {
+			cfg.finishSpan(span, err)
+			cb(reply, err)
+		})
+	}
+}
+
+// WrapPublish returns a traced version of the given publish handler, to be
+// registered with the OnPublish method of client.
+func WrapPublish(client *centrifuge.Client, h centrifuge.PublishHandler, opts ...Option) centrifuge.PublishHandler {
+	cfg := newConfig(opts...)
+	return func(e centrifuge.PublishEvent, cb centrifuge.PublishCallback) {
+		span := cfg.startClientSpan(client, spanPublish, e.Channel,
+			tracer.Tag(tagChannel, e.Channel),
+			tracer.Tag(tagDataSize, len(e.Data)),
+		)
+		h(e, func(reply centrifuge.PublishReply, err error) {
+			cfg.finishSpan(span, err)
+			cb(reply, err)
+		})
+	}
+}
+
+// WrapRPC returns a traced version of the given RPC handler, to be registered
+// with the OnRPC method of client. Every RPC is traced by a span named after
+// its method.
+func WrapRPC(client *centrifuge.Client, h centrifuge.RPCHandler, opts ...Option) centrifuge.RPCHandler {
+	cfg := newConfig(opts...)
+	return func(e centrifuge.RPCEvent, cb centrifuge.RPCCallback) {
+		span := cfg.startClientSpan(client, spanRPC, e.Method,
+			tracer.Tag(tagRPCMethod, e.Method),
+			tracer.Tag(tagDataSize, len(e.Data)),
+		)
+		h(e, func(reply centrifuge.RPCReply, err error) {
+			cfg.finishSpan(span, err)
+			cb(reply, err)
+		})
+	}
+}
+
+func (cfg *config) startSpanOptions(resource string, opts ...ddtrace.StartSpanOption) []ddtrace.StartSpanOption {
+	opts = append(opts,
+		tracer.ServiceName(cfg.serviceName),
+		tracer.ResourceName(resource),
+		tracer.SpanType(ext.SpanTypeWeb),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+		tracer.Measured(),
+	)
+	if !math.IsNaN(cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	return opts
+}
+
+// startClientSpan starts the span of an event of client, as a child of the
+// span found in the context of client, if any.
+func (cfg *config) startClientSpan(client *centrifuge.Client, name, resource string, opts ...ddtrace.StartSpanOption) ddtrace.Span {
+	opts = cfg.startSpanOptions(resource, opts...)
+	opts = append(opts,
+		tracer.Tag(tagClientID, client.ID()),
+		tracer.Tag(tagUserID, client.UserID()),
+		tracer.Tag(tagTransport, client.Transport().Name()),
+	)
+	span, _ := tracer.StartSpanFromContext(client.Context(), name, opts...)
+	return span
+}
+
+func (cfg *config) finishSpan(span ddtrace.Span, err error) {
+	if err == nil {
+		span.Finish()
+		return
+	}
+	var cerr *centrifuge.Error
+	if errors.As(err, &cerr) {
+		span.SetTag(tagErrorCode, cerr.Code)
+	}
+	var disconnect centrifuge.Disconnect
+	if errors.As(err, &disconnect) {
+		span.SetTag(tagDisconnect, disconnect.Code)
+	}
+	if cfg.errCheck != nil && !cfg.errCheck(err) {
+		span.Finish()
+		return
+	}
+	span.Finish(tracer.WithError(err))
+}
@@ -0,0 +1,95 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package centrifuge
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/centrifugal/centrifuge"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapConnecting(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	h := WrapConnecting(func(ctx context.Context, _ centrifuge.ConnectEvent) (centrifuge.ConnectReply, error) {
+		_, ok := tracer.SpanFromContext(ctx)
+		assert.True(t, ok)
+		return centrifuge.ConnectReply{Credentials: &centrifuge.Credentials{UserID: "42"}}, nil
+	}, WithServiceName("realtime"))
+	_, err := h(context.Background(), centrifuge.ConnectEvent{ClientID: "client-1"})
+	require.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, spanConnect, s.OperationName())
+	assert.Equal(t, resourceConnect, s.Tag(ext.ResourceName))
+	assert.Equal(t, "realtime", s.Tag(ext.ServiceName))
+	assert.Equal(t, "client-1", s.Tag(tagClientID))
+	assert.Equal(t, "42", s.Tag(tagUserID))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, ext.SpanKindServer, s.Tag(ext.SpanKind))
+	assert.Nil(t, s.Tag(ext.Error))
+}
+
+func TestFinishSpanErrors(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	for name, tc := range map[string]struct {
+		err   error
+		check func(error) bool
+		tag   string
+		code  uint32
+		isErr bool
+	}{
+		"error": {
+			err:   centrifuge.ErrorPermissionDenied,
+			tag:   tagErrorCode,
+			code:  centrifuge.ErrorPermissionDenied.Code,
+			isErr: true,
+		},
+		"disconnect": {
+			err:   centrifuge.DisconnectInvalidToken,
+			tag:   tagDisconnect,
+			code:  centrifuge.DisconnectInvalidToken.Code,
+			isErr: true,
+		},
+		"ignored": {
+			err:   centrifuge.ErrorPermissionDenied,
+			check: func(err error) bool { return !errors.Is(err, centrifuge.ErrorPermissionDenied) },
+			tag:   tagErrorCode,
+			code:  centrifuge.ErrorPermissionDenied.Code,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt.Reset()
+			h := WrapConnecting(func(context.Context, centrifuge.ConnectEvent) (centrifuge.ConnectReply, error) {
+				return centrifuge.ConnectReply{}, tc.err
+			}, WithErrorCheck(tc.check))
+			_, err := h(context.Background(), centrifuge.ConnectEvent{})
+			require.Error(t, err)
+
+			spans := mt.FinishedSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tc.code, spans[0].Tag(tc.tag))
+			if tc.isErr {
+				assert.NotNil(t, spans[0].Tag(ext.Error))
+			} else {
+				assert.Nil(t, spans[0].Tag(ext.Error))
+			}
+		})
+	}
+}
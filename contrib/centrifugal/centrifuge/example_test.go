@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package centrifuge_test
+
+import (
+	"context"
+
+	centrifugetrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/centrifugal/centrifuge"
+
+	"github.com/centrifugal/centrifuge"
+)
+
+func Example() {
+	node, err := centrifuge.New(centrifuge.Config{})
+	if err != nil {
+		panic(err)
+	}
+	node.OnConnecting(centrifugetrace.WrapConnecting(func(ctx context.Context, _ centrifuge.ConnectEvent) (centrifuge.ConnectReply, error) {
+		return centrifuge.ConnectReply{Credentials: &centrifuge.Credentials{UserID: "42"}}, nil
+	}))
+	node.OnConnect(func(client *centrifuge.Client) {
+		client.OnSubscribe(centrifugetrace.WrapSubscribe(client, func(_ centrifuge.SubscribeEvent, cb centrifuge.SubscribeCallback) {
+			cb(centrifuge.SubscribeReply{}, nil)
+		}))
+		client.OnPublish(centrifugetrace.WrapPublish(client, func(_ centrifuge.PublishEvent, cb centrifuge.PublishCallback) {
+			cb(centrifuge.PublishReply{}, nil)
+		}))
+		client.OnRPC(centrifugetrace.WrapRPC(client, func(e centrifuge.RPCEvent, cb centrifuge.RPCCallback) {
+			cb(centrifuge.RPCReply{Data: e.Data}, nil)
+		}))
+	})
+	if err := node.Run(); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,234 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package bigquery provides functions to trace the cloud.google.com/go/bigquery package (https://pkg.go.dev/cloud.google.com/go/bigquery).
+//
+// WrapClient returns a Client embedding a bigquery.Client, whose query jobs
+// are traced from their submission until their results are read.
+package bigquery
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"cloud.google.com/go/bigquery"
+	"google.golang.org/api/iterator"
+)
+
+const componentName = "cloud.google.com/go/bigquery"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Names of the spans.
+const (
+	spanNameRun  = "bigquery.job.run"
+	spanNameWait = "bigquery.job.wait"
+	spanNameRead = "bigquery.job.read"
+)
+
+// Tags set on the spans.
+const (
+	tagProject        = "bigquery.project"
+	tagJobID          = "bigquery.job.id"
+	tagJobLocation    = "bigquery.job.location"
+	tagStatementType  = "bigquery.statement_type"
+	tagBytesProcessed = "bigquery.bytes_processed"
+	tagBytesBilled    = "bigquery.bytes_billed"
+	tagSlotMillis     = "bigquery.slot_ms"
+	tagCacheHit       = "bigquery.cache_hit"
+	tagRows           = "bigquery.rows"
+)
+
+// WrapClient returns c, traced.
+func WrapClient(c *bigquery.Client, opts ...Option) *Client {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/cloud.google.com/go/bigquery: Wrapping Client: %#v", cfg)
+	return &Client{Client: c, cfg: cfg}
+}
+
+// A Client is a bigquery.Client whose query jobs are traced.
+type Client struct {
+	*bigquery.Client
+	cfg *config
+}
+
+// Query is like bigquery.Client.Query, but the jobs run by the returned query
+// are traced.
+func (c *Client) Query(q string) *Query {
+	return &Query{Query: c.Client.Query(q), c: c}
+}
+
+// JobFromID is like bigquery.Client.JobFromID, but the returned job is traced.
+func (c *Client) JobFromID(ctx context.Context, id string) (*Job, error) {
+	j, err := c.Client.JobFromID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &Job{Job: j, c: c}, nil
+}
+
+func (c *Client) startSpan(ctx context.Context, spanName, resource string, extraOpts ...ddtrace.StartSpanOption) (ddtrace.Span, context.Context) {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(c.cfg.serviceName),
+		tracer.SpanType(ext.SpanTypeSQL),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		tracer.Tag(ext.DBSystem, "bigquery"),
+		tracer.Tag(tagProject, c.Client.Project()),
+	}
+	if resource != "" {
+		opts = append(opts, tracer.ResourceName(resource))
+	}
+	if !math.IsNaN(c.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
+	}
+	opts = append(opts, extraOpts...)
+	return tracer.StartSpanFromContext(ctx, spanName, opts...)
+}
+
+func (c *Client) finishSpan(span ddtrace.Span, err error) {
+	if err != nil && (c.cfg.errCheck == nil || c.cfg.errCheck(err)) {
+		span.Finish(tracer.WithError(err))
+		return
+	}
+	span.Finish()
+}
+
+// A Query is a bigquery.Query whose jobs are traced.
+type Query struct {
+	*bigquery.Query
+	c *Client
+}
+
+// Run invokes and traces bigquery.Query.Run, which submits the query job.
+func (q *Query) Run(ctx context.Context) (*Job, error) {
+	span, ctx := q.c.startSpan(ctx, spanNameRun, q.Q)
+	j, err := q.Query.Run(ctx)
+	if err != nil {
+		q.c.finishSpan(span, err)
+		return nil, err
+	}
+	setJobTags(span, j)
+	q.c.finishSpan(span, nil)
+	return &Job{Job: j, c: q.c, sql: q.Q}, nil
+}
+
+// Read runs the query and reads its results, tracing both the submission of
+// the job and the iteration over its results.
+func (q *Query) Read(ctx context.Context) (*RowIterator, error) {
+	j, err := q.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return j.Read(ctx)
+}
+
+// A Job is a bigquery.Job whose wait and reads are traced.
+type Job struct {
+	*bigquery.Job
+	c   *Client
+	sql string // query of the job, if known
+}
+
+// Wait invokes and traces bigquery.Job.Wait. The span is tagged with the
+// statistics of the job, such as the number of bytes it processed and the
+// slot-milliseconds it consumed.
+func (j *Job) Wait(ctx context.Context) (*bigquery.JobStatus, error) {
+	span, ctx := j.c.startSpan(ctx, spanNameWait, j.sql)
+	setJobTags(span, j.Job)
+	status, err := j.Job.Wait(ctx)
+	if err == nil {
+		setStatusTags(span, status)
+		// Wait returns no error when the job fails.
+		err = status.Err()
+	}
+	j.c.finishSpan(span, err)
+	return status, err
+}
+
+// Read invokes and traces bigquery.Job.Read. The span is finished when the
+// returned iterator is done, and tagged with the number of rows read.
+func (j *Job) Read(ctx context.Context) (*RowIterator, error) {
+	span, ctx := j.c.startSpan(ctx, spanNameRead, j.sql)
+	setJobTags(span, j.Job)
+	it, err := j.Job.Read(ctx)
+	if err != nil {
+		j.c.finishSpan(span, err)
+		return nil, err
+	}
+	if status := j.LastStatus(); status != nil {
+		setStatusTags(span, status)
+	}
+	return &RowIterator{RowIterator: it, c: j.c, span: span}, nil
+}
+
+func setJobTags(span ddtrace.Span, j *bigquery.Job) {
+	span.SetTag(tagJobID, j.ID())
+	if loc := j.Location(); loc != "" {
+		span.SetTag(tagJobLocation, loc)
+	}
+}
+
+func setStatusTags(span ddtrace.Span, status *bigquery.JobStatus) {
+	stats := status.Statistics
+	if stats == nil {
+		return
+	}
+	span.SetTag(tagBytesProcessed, stats.TotalBytesProcessed)
+	qs, ok := stats.Details.(*bigquery.QueryStatistics)
+	if !ok {
+		return
+	}
+	span.SetTag(tagBytesBilled, qs.TotalBytesBilled)
+	span.SetTag(tagSlotMillis, qs.SlotMillis)
+	span.SetTag(tagCacheHit, qs.CacheHit)
+	if qs.StatementType != "" {
+		span.SetTag(tagStatementType, qs.StatementType)
+	}
+}
+
+// A RowIterator is a bigquery.RowIterator finishing the span of the read which
+// returned it, once it is done.
+type RowIterator struct {
+	*bigquery.RowIterator
+	c    *Client
+	span ddtrace.Span
+	rows int
+	once sync.Once
+}
+
+// Next invokes bigquery.RowIterator.Next.
+func (it *RowIterator) Next(dst interface{}) error {
+	err := it.RowIterator.Next(dst)
+	switch err {
+	case nil:
+		it.rows++
+	case iterator.Done:
+		it.finish(nil)
+	default:
+		it.finish(err)
+	}
+	return err
+}
+
+func (it *RowIterator) finish(err error) {
+	it.once.Do(func() {
+		it.span.SetTag(tagRows, it.rows)
+		it.c.finishSpan(it.span, err)
+	})
+}
@@ -0,0 +1,147 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package bigquery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+const (
+	testJobReference = `{"projectId":"test-project","jobId":"job-1","location":"US"}`
+	testQuery        = "SELECT name FROM dataset.users"
+)
+
+// newTestServer returns a server faking the BigQuery API for a query job
+// which has completed.
+func newTestServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(r.URL.Path, "/queries/job-1"):
+			w.Write([]byte(`{"jobReference":` + testJobReference + `,"jobComplete":true}`))
+		case strings.HasSuffix(r.URL.Path, "/jobs") && r.Method == http.MethodPost:
+			w.Write([]byte(`{"jobReference":` + testJobReference + `,
+				"configuration":{"query":{"query":"` + testQuery + `"}},
+				"status":{"state":"RUNNING"}}`))
+		case strings.HasSuffix(r.URL.Path, "/jobs/job-1"):
+			w.Write([]byte(`{"jobReference":` + testJobReference + `,
+				"configuration":{"query":{"query":"` + testQuery + `"}},
+				"status":{"state":"DONE"},
+				"statistics":{"totalBytesProcessed":"1024","query":{
+					"totalBytesProcessed":"1024","totalBytesBilled":"10485760",
+					"totalSlotMs":"42","cacheHit":false,"statementType":"SELECT"}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestClient(t *testing.T, opts ...Option) *Client {
+	srv := newTestServer(t)
+	c, err := bigquery.NewClient(context.Background(), "test-project",
+		option.WithEndpoint(srv.URL),
+		option.WithoutAuthentication(),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return WrapClient(c, opts...)
+}
+
+func assertCommonTags(t *testing.T, s mocktracer.Span) {
+	assert.Equal(t, defaultServiceName, s.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanTypeSQL, s.Tag(ext.SpanType))
+	assert.Equal(t, ext.SpanKindClient, s.Tag(ext.SpanKind))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, "bigquery", s.Tag(ext.DBSystem))
+	assert.Equal(t, "test-project", s.Tag(tagProject))
+	assert.Equal(t, testQuery, s.Tag(ext.ResourceName))
+	assert.Equal(t, "job-1", s.Tag(tagJobID))
+	assert.Equal(t, "US", s.Tag(tagJobLocation))
+}
+
+func TestRunAndWait(t *testing.T) {
+	c := newTestClient(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	q := c.Query(testQuery)
+	q.JobID = "job-1"
+	q.Location = "US"
+	j, err := q.Run(context.Background())
+	require.NoError(t, err)
+	status, err := j.Wait(context.Background())
+	require.NoError(t, err)
+	assert.True(t, status.Done())
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	run, wait := spans[0], spans[1]
+	assert.Equal(t, spanNameRun, run.OperationName())
+	assertCommonTags(t, run)
+
+	assert.Equal(t, spanNameWait, wait.OperationName())
+	assertCommonTags(t, wait)
+	assert.Equal(t, int64(1024), wait.Tag(tagBytesProcessed))
+	assert.Equal(t, int64(10485760), wait.Tag(tagBytesBilled))
+	assert.Equal(t, int64(42), wait.Tag(tagSlotMillis))
+	assert.Equal(t, false, wait.Tag(tagCacheHit))
+	assert.Equal(t, "SELECT", wait.Tag(tagStatementType))
+	assert.Nil(t, wait.Tag(ext.Error))
+}
+
+func TestServiceName(t *testing.T) {
+	c := newTestClient(t, WithServiceName("my-bigquery"))
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	q := c.Query(testQuery)
+	q.JobID = "job-1"
+	_, err := q.Run(context.Background())
+	require.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "my-bigquery", spans[0].Tag(ext.ServiceName))
+}
+
+func TestSetStatusTags(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span := mt.StartSpan("test")
+	setStatusTags(span, &bigquery.JobStatus{
+		Statistics: &bigquery.JobStatistics{
+			TotalBytesProcessed: 2048,
+			Details: &bigquery.QueryStatistics{
+				TotalBytesBilled: 10485760,
+				SlotMillis:       7,
+				CacheHit:         true,
+			},
+		},
+	})
+	span.Finish()
+
+	s := mt.FinishedSpans()[0]
+	assert.Equal(t, int64(2048), s.Tag(tagBytesProcessed))
+	assert.Equal(t, int64(10485760), s.Tag(tagBytesBilled))
+	assert.Equal(t, int64(7), s.Tag(tagSlotMillis))
+	assert.Equal(t, true, s.Tag(tagCacheHit))
+	assert.Nil(t, s.Tag(tagStatementType))
+}
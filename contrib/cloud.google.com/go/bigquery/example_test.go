@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package bigquery_test
+
+import (
+	"context"
+	"log"
+
+	bigquerytrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/cloud.google.com/go/bigquery"
+
+	"cloud.google.com/go/bigquery"
+	"google.golang.org/api/iterator"
+)
+
+func Example() {
+	ctx := context.Background()
+	c, err := bigquery.NewClient(ctx, "my-project")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+	client := bigquerytrace.WrapClient(c, bigquerytrace.WithServiceName("my-bigquery"))
+
+	// The submission of the job and the read of its results are traced, the
+	// latter until the iterator is done.
+	it, err := client.Query("SELECT name FROM dataset.users").Read(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for {
+		var row []bigquery.Value
+		err := it.Next(&row)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
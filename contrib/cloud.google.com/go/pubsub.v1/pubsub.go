@@ -13,6 +13,7 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
 
@@ -28,7 +29,8 @@ func init() {
 // Publish publishes a message on the specified topic and returns a PublishResult.
 // This function is functionally equivalent to t.Publish(ctx, msg), but it also starts a publish
 // span and it ensures that the tracing metadata is propagated as attributes attached to
-// the published message.
+// the published message. When Data Streams Monitoring is enabled, a checkpoint is
+// set and the resulting pathway is propagated as an attribute as well.
 // It is required to call (*PublishResult).Get(ctx) on the value returned by Publish to complete
 // the span.
 func Publish(ctx context.Context, t *pubsub.Topic, msg *pubsub.Message, opts ...Option) *PublishResult {
@@ -62,6 +64,13 @@ func Publish(ctx context.Context, t *pubsub.Topic, msg *pubsub.Message, opts ...
 	if err := tracer.Inject(span.Context(), tracer.TextMapCarrier(msg.Attributes)); err != nil {
 		log.Debug("contrib/cloud.google.com/go/pubsub.v1/: failed injecting tracing attributes: %v", err)
 	}
+	if p := datastreams.GetGlobalProcessor(); p != nil {
+		ctx = p.SetCheckpointWithParams(ctx,
+			datastreams.CheckpointParams{PayloadSize: int64(len(msg.Data))},
+			"direction:out", "topic:"+t.ID(), "type:google-pubsub",
+		)
+		datastreams.InjectToBase64Carrier(ctx, tracer.TextMapCarrier(msg.Attributes))
+	}
 	span.SetTag("num_attributes", len(msg.Attributes))
 	return &PublishResult{
 		PublishResult: t.Publish(ctx, msg),
@@ -89,7 +98,9 @@ func (r *PublishResult) Get(ctx context.Context) (string, error) {
 
 // WrapReceiveHandler returns a receive handler that wraps the supplied handler,
 // extracts any tracing metadata attached to the received message, and starts a
-// receive span.
+// receive span. When Data Streams Monitoring is enabled, it also sets a checkpoint
+// on the pathway propagated with the message, and passes the resulting pathway
+// to the handler through its context.
 func WrapReceiveHandler(s *pubsub.Subscription, f func(context.Context, *pubsub.Message), opts ...Option) func(context.Context, *pubsub.Message) {
 	cfg := defaultConfig()
 	for _, opt := range opts {
@@ -122,6 +133,13 @@ func WrapReceiveHandler(s *pubsub.Subscription, f func(context.Context, *pubsub.
 			span.SetTag("delivery_attempt", *msg.DeliveryAttempt)
 		}
 		defer span.Finish()
+		if p := datastreams.GetGlobalProcessor(); p != nil {
+			ctx = datastreams.ExtractFromBase64Carrier(ctx, tracer.TextMapCarrier(msg.Attributes))
+			ctx = p.SetCheckpointWithParams(ctx,
+				datastreams.CheckpointParams{PayloadSize: int64(len(msg.Data))},
+				"direction:in", "topic:"+s.ID(), "type:google-pubsub",
+			)
+		}
 		f(ctx, msg)
 	}
 }
@@ -7,6 +7,7 @@ package pubsub
 
 import (
 	"context"
+	"net/url"
 	"testing"
 	"time"
 
@@ -14,9 +15,11 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
 
 	"cloud.google.com/go/pubsub"
 	"cloud.google.com/go/pubsub/pstest"
+	"github.com/DataDog/datadog-go/v5/statsd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/api/option"
@@ -238,6 +241,35 @@ func TestPropagationNoPublisherSpan(t *testing.T) {
 	}, spans[0].Tags())
 }
 
+func TestDataStreams(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel, _, topic, sub := setup(t)
+	p := datastreams.NewProcessor(&statsd.NoOpClient{}, "env", "service", &url.URL{Scheme: "http", Host: "agent-address"}, nil)
+	datastreams.SetGlobalProcessor(p)
+	defer datastreams.SetGlobalProcessor(nil)
+
+	msg := &pubsub.Message{Data: []byte("hello")}
+	_, err := Publish(ctx, topic, msg).Get(ctx)
+	assert.NoError(err)
+	assert.Contains(msg.Attributes, datastreams.PropagationKeyBase64)
+	produced, err := datastreams.DecodeBase64(msg.Attributes[datastreams.PropagationKeyBase64])
+	require.NoError(t, err)
+
+	var (
+		consumed datastreams.Pathway
+		ok       bool
+	)
+	err = sub.Receive(ctx, WrapReceiveHandler(sub, func(ctx context.Context, msg *pubsub.Message) {
+		consumed, ok = datastreams.PathwayFromContext(ctx)
+		msg.Ack()
+		cancel()
+	}))
+	assert.NoError(err)
+	assert.True(ok, "no pathway")
+	assert.NotEqual(produced.GetHash(), consumed.GetHash())
+	assert.Equal(produced.PathwayStart(), consumed.PathwayStart())
+}
+
 func TestNamingSchema(t *testing.T) {
 	genSpans := namingschematest.GenSpansFn(func(t *testing.T, serviceOverride string) []mocktracer.Span {
 		var opts []Option
@@ -0,0 +1,36 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package spanner_test
+
+import (
+	"context"
+	"log"
+
+	spannertrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/cloud.google.com/go/spanner"
+
+	"cloud.google.com/go/spanner"
+)
+
+func Example() {
+	ctx := context.Background()
+	database := "projects/my-project/instances/my-instance/databases/my-db"
+	c, err := spanner.NewClient(ctx, database)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+	client := spannertrace.WrapClient(c, database, spannertrace.WithServiceName("my-spanner"))
+
+	// the spans of the operations are children of the span of the transaction,
+	// tagged with the attempt to commit it they are part of.
+	_, err = client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spannertrace.ReadWriteTransaction) error {
+		_, err := txn.Update(ctx, spanner.Statement{SQL: "UPDATE users SET name = 'jane' WHERE id = 1"})
+		return err
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,349 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package spanner provides functions to trace the cloud.google.com/go/spanner package (https://pkg.go.dev/cloud.google.com/go/spanner).
+//
+// WrapClient returns a Client embedding a spanner.Client, whose transactions,
+// queries, reads and mutations are traced.
+package spanner
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"cloud.google.com/go/spanner"
+	"google.golang.org/api/iterator"
+)
+
+const componentName = "cloud.google.com/go/spanner"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Names of the spans.
+const (
+	spanNameTransaction = "spanner.transaction"
+	spanNameQuery       = "spanner.query"
+	spanNameRead        = "spanner.read"
+	spanNameUpdate      = "spanner.update"
+	spanNameApply       = "spanner.apply"
+)
+
+// Tags set on the spans.
+const (
+	tagProject         = "spanner.project"
+	tagTable           = "spanner.table"
+	tagTransactionType = "spanner.transaction.type"
+	tagAttempt         = "spanner.transaction.attempt"
+	tagAttempts        = "spanner.transaction.attempts"
+	tagMutations       = "spanner.mutations"
+	tagStatements      = "spanner.statements"
+	tagRows            = "spanner.rows"
+	tagRowsAffected    = "spanner.rows_affected"
+)
+
+// Types of the transactions.
+const (
+	transactionSingleUse = "single_use"
+	transactionReadOnly  = "read_only"
+	transactionReadWrite = "read_write"
+)
+
+// WrapClient returns c, traced. database is the name of the database the
+// client was created for, in the form
+// "projects/PROJECT_ID/instances/INSTANCE_ID/databases/DATABASE_ID".
+func WrapClient(c *spanner.Client, database string, opts ...Option) *Client {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/cloud.google.com/go/spanner: Wrapping Client: %#v", cfg)
+	tc := &Client{Client: c, cfg: cfg}
+	tc.project, tc.instance, tc.database = parseDatabase(database)
+	return tc
+}
+
+// parseDatabase returns the project, instance and database ids of the
+// database name.
+func parseDatabase(name string) (project, instance, database string) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 6 || parts[0] != "projects" || parts[2] != "instances" || parts[4] != "databases" {
+		return "", "", name
+	}
+	return parts[1], parts[3], parts[5]
+}
+
+// A Client is a spanner.Client whose operations are traced.
+type Client struct {
+	*spanner.Client
+	cfg      *config
+	project  string
+	instance string
+	database string
+}
+
+// Single is like spanner.Client.Single, but the operations of the returned
+// transaction are traced.
+func (c *Client) Single() *ReadOnlyTransaction {
+	return &ReadOnlyTransaction{ReadOnlyTransaction: c.Client.Single(), c: c, single: true}
+}
+
+// ReadOnlyTransaction is like spanner.Client.ReadOnlyTransaction, but the
+// returned transaction is traced, from its first operation until it is closed.
+func (c *Client) ReadOnlyTransaction() *ReadOnlyTransaction {
+	return &ReadOnlyTransaction{ReadOnlyTransaction: c.Client.ReadOnlyTransaction(), c: c}
+}
+
+// ReadWriteTransaction is like spanner.Client.ReadWriteTransaction, but the
+// transaction is traced, along with the operations of the transaction passed
+// to f. The span of the transaction is tagged with the number of attempts to
+// commit it, and the spans of the operations with the attempt they are part
+// of, as f is called again when the transaction is aborted.
+func (c *Client) ReadWriteTransaction(ctx context.Context, f func(context.Context, *ReadWriteTransaction) error) (commitTimestamp time.Time, err error) {
+	span, ctx := c.startSpan(ctx, spanNameTransaction, "ReadWriteTransaction",
+		tracer.Tag(tagTransactionType, transactionReadWrite),
+	)
+	attempts := 0
+	commitTimestamp, err = c.Client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		attempts++
+		return f(ctx, &ReadWriteTransaction{ReadWriteTransaction: txn, c: c, attempt: attempts})
+	})
+	span.SetTag(tagAttempts, attempts)
+	c.finishSpan(span, err)
+	return commitTimestamp, err
+}
+
+// Apply invokes and traces spanner.Client.Apply.
+func (c *Client) Apply(ctx context.Context, ms []*spanner.Mutation, opts ...spanner.ApplyOption) (commitTimestamp time.Time, err error) {
+	span, ctx := c.startSpan(ctx, spanNameApply, "Apply", tracer.Tag(tagMutations, len(ms)))
+	commitTimestamp, err = c.Client.Apply(ctx, ms, opts...)
+	c.finishSpan(span, err)
+	return commitTimestamp, err
+}
+
+func (c *Client) startSpan(ctx context.Context, spanName, resource string, extraOpts ...ddtrace.StartSpanOption) (ddtrace.Span, context.Context) {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(c.cfg.serviceName),
+		tracer.SpanType(ext.SpanTypeSQL),
+		tracer.ResourceName(resource),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		tracer.Tag(ext.DBSystem, "spanner"),
+		tracer.Tag(ext.DBName, c.database),
+	}
+	if c.instance != "" {
+		opts = append(opts, tracer.Tag(ext.DBInstance, c.instance), tracer.Tag(tagProject, c.project))
+	}
+	if !math.IsNaN(c.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
+	}
+	opts = append(opts, extraOpts...)
+	return tracer.StartSpanFromContext(ctx, spanName, opts...)
+}
+
+func (c *Client) finishSpan(span ddtrace.Span, err error) {
+	if err != nil && (c.cfg.errCheck == nil || c.cfg.errCheck(err)) {
+		span.Finish(tracer.WithError(err))
+		return
+	}
+	span.Finish()
+}
+
+// A ReadOnlyTransaction is a spanner.ReadOnlyTransaction whose operations are
+// traced.
+type ReadOnlyTransaction struct {
+	*spanner.ReadOnlyTransaction
+	c      *Client
+	single bool
+
+	mu   sync.Mutex
+	span ddtrace.Span // span of the transaction, started by its first operation
+}
+
+// WithTimestampBound invokes spanner.ReadOnlyTransaction.WithTimestampBound.
+func (t *ReadOnlyTransaction) WithTimestampBound(tb spanner.TimestampBound) *ReadOnlyTransaction {
+	t.ReadOnlyTransaction.WithTimestampBound(tb)
+	return t
+}
+
+// Query invokes and traces spanner.ReadOnlyTransaction.Query. The span is
+// finished when the returned iterator is done or stopped.
+func (t *ReadOnlyTransaction) Query(ctx context.Context, stmt spanner.Statement) *RowIterator {
+	span, ctx := t.startSpan(ctx, spanNameQuery, stmt.SQL)
+	return newRowIterator(t.c, span, t.ReadOnlyTransaction.Query(ctx, stmt))
+}
+
+// Read invokes and traces spanner.ReadOnlyTransaction.Read. The span is
+// finished when the returned iterator is done or stopped.
+func (t *ReadOnlyTransaction) Read(ctx context.Context, table string, keys spanner.KeySet, columns []string) *RowIterator {
+	span, ctx := t.startSpan(ctx, spanNameRead, table, tracer.Tag(tagTable, table))
+	return newRowIterator(t.c, span, t.ReadOnlyTransaction.Read(ctx, table, keys, columns))
+}
+
+// ReadRow invokes and traces spanner.ReadOnlyTransaction.ReadRow.
+func (t *ReadOnlyTransaction) ReadRow(ctx context.Context, table string, key spanner.Key, columns []string) (*spanner.Row, error) {
+	span, ctx := t.startSpan(ctx, spanNameRead, table, tracer.Tag(tagTable, table))
+	row, err := t.ReadOnlyTransaction.ReadRow(ctx, table, key, columns)
+	t.c.finishSpan(span, err)
+	return row, err
+}
+
+// Close invokes spanner.ReadOnlyTransaction.Close and finishes the span of
+// the transaction.
+func (t *ReadOnlyTransaction) Close() {
+	t.ReadOnlyTransaction.Close()
+	t.mu.Lock()
+	span := t.span
+	t.span = nil
+	t.mu.Unlock()
+	if span != nil {
+		span.Finish()
+	}
+}
+
+// startSpan starts the span of an operation of the transaction. Unless the
+// transaction is single-use, it is a child of the span of the transaction,
+// which is started by its first operation.
+func (t *ReadOnlyTransaction) startSpan(ctx context.Context, spanName, resource string, extraOpts ...ddtrace.StartSpanOption) (ddtrace.Span, context.Context) {
+	if t.single {
+		extraOpts = append(extraOpts, tracer.Tag(tagTransactionType, transactionSingleUse))
+		return t.c.startSpan(ctx, spanName, resource, extraOpts...)
+	}
+	t.mu.Lock()
+	if t.span == nil {
+		t.span, _ = t.c.startSpan(ctx, spanNameTransaction, "ReadOnlyTransaction",
+			tracer.Tag(tagTransactionType, transactionReadOnly),
+		)
+	}
+	txnSpan := t.span
+	t.mu.Unlock()
+	extraOpts = append(extraOpts, tracer.Tag(tagTransactionType, transactionReadOnly))
+	return t.c.startSpan(tracer.ContextWithSpan(ctx, txnSpan), spanName, resource, extraOpts...)
+}
+
+// A ReadWriteTransaction is a spanner.ReadWriteTransaction whose operations
+// are traced.
+type ReadWriteTransaction struct {
+	*spanner.ReadWriteTransaction
+	c       *Client
+	attempt int
+}
+
+// Query invokes and traces spanner.ReadWriteTransaction.Query. The span is
+// finished when the returned iterator is done or stopped.
+func (t *ReadWriteTransaction) Query(ctx context.Context, stmt spanner.Statement) *RowIterator {
+	span, ctx := t.startSpan(ctx, spanNameQuery, stmt.SQL)
+	return newRowIterator(t.c, span, t.ReadWriteTransaction.Query(ctx, stmt))
+}
+
+// Read invokes and traces spanner.ReadWriteTransaction.Read. The span is
+// finished when the returned iterator is done or stopped.
+func (t *ReadWriteTransaction) Read(ctx context.Context, table string, keys spanner.KeySet, columns []string) *RowIterator {
+	span, ctx := t.startSpan(ctx, spanNameRead, table, tracer.Tag(tagTable, table))
+	return newRowIterator(t.c, span, t.ReadWriteTransaction.Read(ctx, table, keys, columns))
+}
+
+// ReadRow invokes and traces spanner.ReadWriteTransaction.ReadRow.
+func (t *ReadWriteTransaction) ReadRow(ctx context.Context, table string, key spanner.Key, columns []string) (*spanner.Row, error) {
+	span, ctx := t.startSpan(ctx, spanNameRead, table, tracer.Tag(tagTable, table))
+	row, err := t.ReadWriteTransaction.ReadRow(ctx, table, key, columns)
+	t.c.finishSpan(span, err)
+	return row, err
+}
+
+// Update invokes and traces spanner.ReadWriteTransaction.Update.
+func (t *ReadWriteTransaction) Update(ctx context.Context, stmt spanner.Statement) (rowCount int64, err error) {
+	span, ctx := t.startSpan(ctx, spanNameUpdate, stmt.SQL)
+	rowCount, err = t.ReadWriteTransaction.Update(ctx, stmt)
+	if err == nil {
+		span.SetTag(tagRowsAffected, rowCount)
+	}
+	t.c.finishSpan(span, err)
+	return rowCount, err
+}
+
+// BatchUpdate invokes and traces spanner.ReadWriteTransaction.BatchUpdate.
+func (t *ReadWriteTransaction) BatchUpdate(ctx context.Context, stmts []spanner.Statement) ([]int64, error) {
+	span, ctx := t.startSpan(ctx, spanNameUpdate, "BatchUpdate", tracer.Tag(tagStatements, len(stmts)))
+	counts, err := t.ReadWriteTransaction.BatchUpdate(ctx, stmts)
+	var affected int64
+	for _, n := range counts {
+		affected += n
+	}
+	span.SetTag(tagRowsAffected, affected)
+	t.c.finishSpan(span, err)
+	return counts, err
+}
+
+func (t *ReadWriteTransaction) startSpan(ctx context.Context, spanName, resource string, extraOpts ...ddtrace.StartSpanOption) (ddtrace.Span, context.Context) {
+	extraOpts = append(extraOpts,
+		tracer.Tag(tagTransactionType, transactionReadWrite),
+		tracer.Tag(tagAttempt, t.attempt),
+	)
+	return t.c.startSpan(ctx, spanName, resource, extraOpts...)
+}
+
+// A RowIterator is a spanner.RowIterator finishing the span of the query or
+// read which returned it, once it is done or stopped. The span is tagged with
+// the number of rows read.
+type RowIterator struct {
+	*spanner.RowIterator
+	c    *Client
+	span ddtrace.Span
+	rows int
+	once sync.Once
+}
+
+func newRowIterator(c *Client, span ddtrace.Span, iter *spanner.RowIterator) *RowIterator {
+	return &RowIterator{RowIterator: iter, c: c, span: span}
+}
+
+// Next invokes spanner.RowIterator.Next.
+func (r *RowIterator) Next() (*spanner.Row, error) {
+	row, err := r.RowIterator.Next()
+	switch err {
+	case nil:
+		r.rows++
+	case iterator.Done:
+		r.finish(nil)
+	default:
+		r.finish(err)
+	}
+	return row, err
+}
+
+// Do invokes spanner.RowIterator.Do.
+func (r *RowIterator) Do(f func(row *spanner.Row) error) error {
+	err := r.RowIterator.Do(func(row *spanner.Row) error {
+		r.rows++
+		return f(row)
+	})
+	r.finish(err)
+	return err
+}
+
+// Stop invokes spanner.RowIterator.Stop.
+func (r *RowIterator) Stop() {
+	r.RowIterator.Stop()
+	r.finish(nil)
+}
+
+func (r *RowIterator) finish(err error) {
+	r.once.Do(func() {
+		r.span.SetTag(tagRows, r.rows)
+		r.c.finishSpan(r.span, err)
+	})
+}
@@ -0,0 +1,198 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package spanner
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/spannertest"
+	"cloud.google.com/go/spanner/spansql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const testDatabase = "projects/test-project/instances/test-instance/databases/test-db"
+
+func newTestClient(t *testing.T, opts ...Option) *Client {
+	srv, err := spannertest.NewServer("localhost:0")
+	require.NoError(t, err)
+	t.Cleanup(srv.Close)
+	ddl, err := spansql.ParseDDL("", "CREATE TABLE users (id INT64 NOT NULL, name STRING(MAX)) PRIMARY KEY (id)")
+	require.NoError(t, err)
+	require.NoError(t, srv.UpdateDDL(ddl))
+
+	c, err := spanner.NewClient(context.Background(), testDatabase,
+		option.WithEndpoint(srv.Addr),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+	return WrapClient(c, testDatabase, opts...)
+}
+
+func insertUser(t *testing.T, c *Client) {
+	_, err := c.Apply(context.Background(), []*spanner.Mutation{
+		spanner.Insert("users", []string{"id", "name"}, []interface{}{1, "jane"}),
+	})
+	require.NoError(t, err)
+}
+
+func assertCommonTags(t *testing.T, s mocktracer.Span) {
+	assert.Equal(t, defaultServiceName, s.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanTypeSQL, s.Tag(ext.SpanType))
+	assert.Equal(t, ext.SpanKindClient, s.Tag(ext.SpanKind))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, "spanner", s.Tag(ext.DBSystem))
+	assert.Equal(t, "test-db", s.Tag(ext.DBName))
+	assert.Equal(t, "test-instance", s.Tag(ext.DBInstance))
+	assert.Equal(t, "test-project", s.Tag(tagProject))
+}
+
+func TestApply(t *testing.T) {
+	c := newTestClient(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	insertUser(t, c)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, spanNameApply, s.OperationName())
+	assert.Equal(t, "Apply", s.Tag(ext.ResourceName))
+	assert.Equal(t, 1, s.Tag(tagMutations))
+	assertCommonTags(t, s)
+}
+
+func TestSingleQuery(t *testing.T) {
+	c := newTestClient(t)
+	insertUser(t, c)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	iter := c.Single().Query(context.Background(), spanner.Statement{SQL: "SELECT id, name FROM users"})
+	rows := 0
+	for {
+		_, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		require.NoError(t, err)
+		rows++
+	}
+	iter.Stop()
+	assert.Equal(t, 1, rows)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, spanNameQuery, s.OperationName())
+	assert.Equal(t, "SELECT id, name FROM users", s.Tag(ext.ResourceName))
+	assert.Equal(t, transactionSingleUse, s.Tag(tagTransactionType))
+	assert.Equal(t, 1, s.Tag(tagRows))
+	assertCommonTags(t, s)
+}
+
+func TestReadOnlyTransaction(t *testing.T) {
+	c := newTestClient(t)
+	insertUser(t, c)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	txn := c.ReadOnlyTransaction()
+	row, err := txn.ReadRow(context.Background(), "users", spanner.Key{1}, []string{"name"})
+	require.NoError(t, err)
+	var name string
+	require.NoError(t, row.Column(0, &name))
+	assert.Equal(t, "jane", name)
+	err = txn.Read(context.Background(), "users", spanner.AllKeys(), []string{"id"}).Do(func(*spanner.Row) error {
+		return nil
+	})
+	require.NoError(t, err)
+	txn.Close()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 3)
+	txnSpan := spans[2]
+	assert.Equal(t, spanNameTransaction, txnSpan.OperationName())
+	assert.Equal(t, "ReadOnlyTransaction", txnSpan.Tag(ext.ResourceName))
+	assert.Equal(t, transactionReadOnly, txnSpan.Tag(tagTransactionType))
+	for _, s := range spans[:2] {
+		assert.Equal(t, spanNameRead, s.OperationName())
+		assert.Equal(t, "users", s.Tag(ext.ResourceName))
+		assert.Equal(t, "users", s.Tag(tagTable))
+		assert.Equal(t, txnSpan.SpanID(), s.ParentID())
+		assertCommonTags(t, s)
+	}
+	assert.Equal(t, 1, spans[1].Tag(tagRows))
+}
+
+func TestReadWriteTransaction(t *testing.T) {
+	c := newTestClient(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	_, err := c.ReadWriteTransaction(context.Background(), func(ctx context.Context, txn *ReadWriteTransaction) error {
+		iter := txn.Read(ctx, "users", spanner.AllKeys(), []string{"id"})
+		defer iter.Stop()
+		if _, err := iter.Next(); err != iterator.Done {
+			return err
+		}
+		return txn.BufferWrite([]*spanner.Mutation{
+			spanner.Insert("users", []string{"id", "name"}, []interface{}{1, "jane"}),
+		})
+	})
+	require.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	read, txnSpan := spans[0], spans[1]
+	assert.Equal(t, spanNameTransaction, txnSpan.OperationName())
+	assert.Equal(t, "ReadWriteTransaction", txnSpan.Tag(ext.ResourceName))
+	assert.Equal(t, transactionReadWrite, txnSpan.Tag(tagTransactionType))
+	assert.Equal(t, 1, txnSpan.Tag(tagAttempts))
+	assertCommonTags(t, txnSpan)
+
+	assert.Equal(t, spanNameRead, read.OperationName())
+	assert.Equal(t, transactionReadWrite, read.Tag(tagTransactionType))
+	assert.Equal(t, 1, read.Tag(tagAttempt))
+	assert.Equal(t, 0, read.Tag(tagRows))
+	assert.Equal(t, txnSpan.SpanID(), read.ParentID())
+}
+
+func TestParseDatabase(t *testing.T) {
+	project, instance, database := parseDatabase(testDatabase)
+	assert.Equal(t, "test-project", project)
+	assert.Equal(t, "test-instance", instance)
+	assert.Equal(t, "test-db", database)
+
+	project, instance, database = parseDatabase("test-db")
+	assert.Equal(t, "", project)
+	assert.Equal(t, "", instance)
+	assert.Equal(t, "test-db", database)
+}
+
+func TestServiceName(t *testing.T) {
+	c := newTestClient(t, WithServiceName("my-spanner"))
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	insertUser(t, c)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "my-spanner", spans[0].Tag(ext.ServiceName))
+}
@@ -0,0 +1,37 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package storage_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+
+	storagetrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/cloud.google.com/go/storage"
+
+	"cloud.google.com/go/storage"
+)
+
+func Example() {
+	ctx := context.Background()
+	c, err := storage.NewClient(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+	client := storagetrace.WrapClient(c, storagetrace.WithServiceName("my-gcs"))
+
+	// The read is traced until the reader is closed.
+	r, err := client.Bucket("my-bucket").Object("hello.txt").NewReader(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := io.Copy(os.Stdout, r); err != nil {
+		log.Fatal(err)
+	}
+}
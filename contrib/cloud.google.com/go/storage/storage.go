@@ -0,0 +1,286 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package storage provides functions to trace the cloud.google.com/go/storage package (https://pkg.go.dev/cloud.google.com/go/storage).
+//
+// WrapClient returns a Client embedding a storage.Client, whose reads, writes
+// and attributes of objects are traced.
+package storage
+
+import (
+	"context"
+	"io"
+	"math"
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"cloud.google.com/go/storage"
+)
+
+const componentName = "cloud.google.com/go/storage"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+const spanName = "gcs.command"
+
+// Tags set on the spans.
+const (
+	tagOperation    = "gcs.operation"
+	tagBucket       = "gcs.bucket.name"
+	tagObject       = "gcs.object.name"
+	tagBytesRead    = "gcs.bytes_read"
+	tagBytesWritten = "gcs.bytes_written"
+	tagRangeOffset  = "gcs.range.offset"
+	tagRangeLength  = "gcs.range.length"
+)
+
+// WrapClient returns c, traced.
+func WrapClient(c *storage.Client, opts ...Option) *Client {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/cloud.google.com/go/storage: Wrapping Client: %#v", cfg)
+	return &Client{Client: c, cfg: cfg}
+}
+
+// A Client is a storage.Client whose operations on buckets and objects are
+// traced.
+type Client struct {
+	*storage.Client
+	cfg *config
+}
+
+// Bucket is like storage.Client.Bucket, but the operations of the returned
+// handle are traced.
+func (c *Client) Bucket(name string) *BucketHandle {
+	return &BucketHandle{BucketHandle: c.Client.Bucket(name), c: c, name: name}
+}
+
+func (c *Client) startSpan(ctx context.Context, operation, bucket, object string) (ddtrace.Span, context.Context) {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(c.cfg.serviceName),
+		tracer.SpanType(ext.SpanTypeHTTP),
+		tracer.ResourceName("GCS." + operation),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		tracer.Tag(tagOperation, operation),
+		tracer.Tag(tagBucket, bucket),
+	}
+	if object != "" {
+		opts = append(opts, tracer.Tag(tagObject, object))
+	}
+	if !math.IsNaN(c.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
+	}
+	return tracer.StartSpanFromContext(ctx, spanName, opts...)
+}
+
+func (c *Client) finishSpan(span ddtrace.Span, err error) {
+	if err != nil && (c.cfg.errCheck == nil || c.cfg.errCheck(err)) {
+		span.Finish(tracer.WithError(err))
+		return
+	}
+	span.Finish()
+}
+
+// A BucketHandle is a storage.BucketHandle whose operations are traced.
+type BucketHandle struct {
+	*storage.BucketHandle
+	c    *Client
+	name string
+}
+
+// Object is like storage.BucketHandle.Object, but the operations of the
+// returned handle are traced.
+func (b *BucketHandle) Object(name string) *ObjectHandle {
+	return b.c.wrapObject(b.BucketHandle.Object(name))
+}
+
+// If is like storage.BucketHandle.If, returning a traced handle.
+func (b *BucketHandle) If(conds storage.BucketConditions) *BucketHandle {
+	return &BucketHandle{BucketHandle: b.BucketHandle.If(conds), c: b.c, name: b.name}
+}
+
+// UserProject is like storage.BucketHandle.UserProject, returning a traced
+// handle.
+func (b *BucketHandle) UserProject(projectID string) *BucketHandle {
+	return &BucketHandle{BucketHandle: b.BucketHandle.UserProject(projectID), c: b.c, name: b.name}
+}
+
+// Attrs invokes and traces storage.BucketHandle.Attrs.
+func (b *BucketHandle) Attrs(ctx context.Context) (*storage.BucketAttrs, error) {
+	span, ctx := b.c.startSpan(ctx, "GetBucketAttrs", b.name, "")
+	attrs, err := b.BucketHandle.Attrs(ctx)
+	b.c.finishSpan(span, err)
+	return attrs, err
+}
+
+// An ObjectHandle is a storage.ObjectHandle whose operations are traced.
+type ObjectHandle struct {
+	*storage.ObjectHandle
+	c *Client
+}
+
+func (c *Client) wrapObject(o *storage.ObjectHandle) *ObjectHandle {
+	return &ObjectHandle{ObjectHandle: o, c: c}
+}
+
+// Generation is like storage.ObjectHandle.Generation, returning a traced
+// handle.
+func (o *ObjectHandle) Generation(gen int64) *ObjectHandle {
+	return o.c.wrapObject(o.ObjectHandle.Generation(gen))
+}
+
+// If is like storage.ObjectHandle.If, returning a traced handle.
+func (o *ObjectHandle) If(conds storage.Conditions) *ObjectHandle {
+	return o.c.wrapObject(o.ObjectHandle.If(conds))
+}
+
+// Key is like storage.ObjectHandle.Key, returning a traced handle.
+func (o *ObjectHandle) Key(encryptionKey []byte) *ObjectHandle {
+	return o.c.wrapObject(o.ObjectHandle.Key(encryptionKey))
+}
+
+// ReadCompressed is like storage.ObjectHandle.ReadCompressed, returning a
+// traced handle.
+func (o *ObjectHandle) ReadCompressed(compressed bool) *ObjectHandle {
+	return o.c.wrapObject(o.ObjectHandle.ReadCompressed(compressed))
+}
+
+// Retryer is like storage.ObjectHandle.Retryer, returning a traced handle.
+func (o *ObjectHandle) Retryer(opts ...storage.RetryOption) *ObjectHandle {
+	return o.c.wrapObject(o.ObjectHandle.Retryer(opts...))
+}
+
+// Attrs invokes and traces storage.ObjectHandle.Attrs.
+func (o *ObjectHandle) Attrs(ctx context.Context) (*storage.ObjectAttrs, error) {
+	span, ctx := o.startSpan(ctx, "GetObjectAttrs")
+	attrs, err := o.ObjectHandle.Attrs(ctx)
+	o.c.finishSpan(span, err)
+	return attrs, err
+}
+
+// Update invokes and traces storage.ObjectHandle.Update.
+func (o *ObjectHandle) Update(ctx context.Context, uattrs storage.ObjectAttrsToUpdate) (*storage.ObjectAttrs, error) {
+	span, ctx := o.startSpan(ctx, "UpdateObject")
+	attrs, err := o.ObjectHandle.Update(ctx, uattrs)
+	o.c.finishSpan(span, err)
+	return attrs, err
+}
+
+// Delete invokes and traces storage.ObjectHandle.Delete.
+func (o *ObjectHandle) Delete(ctx context.Context) error {
+	span, ctx := o.startSpan(ctx, "DeleteObject")
+	err := o.ObjectHandle.Delete(ctx)
+	o.c.finishSpan(span, err)
+	return err
+}
+
+// NewReader is like storage.ObjectHandle.NewReader, but the read is traced
+// until the returned reader is closed.
+func (o *ObjectHandle) NewReader(ctx context.Context) (*Reader, error) {
+	return o.NewRangeReader(ctx, 0, -1)
+}
+
+// NewRangeReader is like storage.ObjectHandle.NewRangeReader, but the read is
+// traced until the returned reader is closed. The span is tagged with the
+// number of bytes read.
+func (o *ObjectHandle) NewRangeReader(ctx context.Context, offset, length int64) (*Reader, error) {
+	span, ctx := o.startSpan(ctx, "ReadObject")
+	if offset != 0 || length >= 0 {
+		span.SetTag(tagRangeOffset, offset)
+		span.SetTag(tagRangeLength, length)
+	}
+	r, err := o.ObjectHandle.NewRangeReader(ctx, offset, length)
+	if err != nil {
+		o.c.finishSpan(span, err)
+		return nil, err
+	}
+	return &Reader{Reader: r, c: o.c, span: span}, nil
+}
+
+// NewWriter is like storage.ObjectHandle.NewWriter, but the write is traced
+// until the returned writer is closed. The span is tagged with the number of
+// bytes written.
+func (o *ObjectHandle) NewWriter(ctx context.Context) *Writer {
+	span, ctx := o.startSpan(ctx, "WriteObject")
+	return &Writer{Writer: o.ObjectHandle.NewWriter(ctx), c: o.c, span: span}
+}
+
+func (o *ObjectHandle) startSpan(ctx context.Context, operation string) (ddtrace.Span, context.Context) {
+	return o.c.startSpan(ctx, operation, o.BucketName(), o.ObjectName())
+}
+
+// A Reader is a storage.Reader counting the bytes read, which finishes the
+// span of the read when it is closed.
+type Reader struct {
+	*storage.Reader
+	c    *Client
+	span ddtrace.Span
+	n    int64
+	err  error // first error returned by Read, other than io.EOF
+	once sync.Once
+}
+
+// Read invokes storage.Reader.Read.
+func (r *Reader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// Close invokes storage.Reader.Close and finishes the span of the read.
+func (r *Reader) Close() error {
+	err := r.Reader.Close()
+	r.once.Do(func() {
+		r.span.SetTag(tagBytesRead, r.n)
+		spanErr := r.err
+		if spanErr == nil {
+			spanErr = err
+		}
+		r.c.finishSpan(r.span, spanErr)
+	})
+	return err
+}
+
+// A Writer is a storage.Writer counting the bytes written, which finishes
+// the span of the write when it is closed.
+type Writer struct {
+	*storage.Writer
+	c    *Client
+	span ddtrace.Span
+	n    int64
+	once sync.Once
+}
+
+// Write invokes storage.Writer.Write.
+func (w *Writer) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// Close invokes storage.Writer.Close, which completes the write, and finishes
+// its span.
+func (w *Writer) Close() error {
+	err := w.Writer.Close()
+	w.once.Do(func() {
+		w.span.SetTag(tagBytesWritten, w.n)
+		w.c.finishSpan(w.span, err)
+	})
+	return err
+}
@@ -0,0 +1,160 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+
+	"cloud.google.com/go/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+const testObjectJSON = `{"bucket":"my-bucket","name":"hello.txt","size":"5","contentType":"text/plain"}`
+
+// newTestClient returns a client of a server faking the API of Cloud Storage
+// for the object "hello.txt" of the bucket "my-bucket".
+func newTestClient(t *testing.T, opts ...Option) *Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/my-bucket/hello.txt":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("hello"))
+		case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/upload/storage/v1/b/my-bucket/o"):
+			io.Copy(io.Discard, r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(testObjectJSON))
+		case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/b/my-bucket/o/hello.txt":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(testObjectJSON))
+		case r.Method == http.MethodDelete && r.URL.Path == "/storage/v1/b/my-bucket/o/hello.txt":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"message":"Not Found"}}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := storage.NewClient(context.Background(),
+		option.WithEndpoint(srv.URL+"/storage/v1/"),
+		option.WithoutAuthentication(),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return WrapClient(c, opts...)
+}
+
+func assertCommonTags(t *testing.T, s mocktracer.Span, operation string) {
+	assert.Equal(t, spanName, s.OperationName())
+	assert.Equal(t, "GCS."+operation, s.Tag(ext.ResourceName))
+	assert.Equal(t, operation, s.Tag(tagOperation))
+	assert.Equal(t, defaultServiceName, s.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanTypeHTTP, s.Tag(ext.SpanType))
+	assert.Equal(t, ext.SpanKindClient, s.Tag(ext.SpanKind))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, "my-bucket", s.Tag(tagBucket))
+}
+
+func TestRead(t *testing.T) {
+	c := newTestClient(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	r, err := c.Bucket("my-bucket").Object("hello.txt").NewReader(context.Background())
+	require.NoError(t, err)
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+	require.NoError(t, r.Close())
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assertCommonTags(t, s, "ReadObject")
+	assert.Equal(t, "hello.txt", s.Tag(tagObject))
+	assert.Equal(t, int64(5), s.Tag(tagBytesRead))
+	assert.Nil(t, s.Tag(tagRangeOffset))
+	assert.Nil(t, s.Tag(ext.Error))
+}
+
+func TestWrite(t *testing.T) {
+	c := newTestClient(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	w := c.Bucket("my-bucket").Object("hello.txt").NewWriter(context.Background())
+	w.ContentType = "text/plain"
+	_, err := w.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assertCommonTags(t, s, "WriteObject")
+	assert.Equal(t, "hello.txt", s.Tag(tagObject))
+	assert.Equal(t, int64(5), s.Tag(tagBytesWritten))
+	assert.Nil(t, s.Tag(ext.Error))
+}
+
+func TestAttrsAndDelete(t *testing.T) {
+	c := newTestClient(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	o := c.Bucket("my-bucket").Object("hello.txt")
+	attrs, err := o.Attrs(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), attrs.Size)
+	require.NoError(t, o.Delete(context.Background()))
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	assertCommonTags(t, spans[0], "GetObjectAttrs")
+	assertCommonTags(t, spans[1], "DeleteObject")
+	for _, s := range spans {
+		assert.Equal(t, "hello.txt", s.Tag(tagObject))
+	}
+}
+
+func TestError(t *testing.T) {
+	c := newTestClient(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	_, err := c.Bucket("my-bucket").Object("missing.txt").Attrs(context.Background())
+	assert.Equal(t, storage.ErrObjectNotExist, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, err, spans[0].Tag(ext.Error))
+}
+
+func TestErrorCheck(t *testing.T) {
+	c := newTestClient(t, WithErrorCheck(func(err error) bool {
+		return err != storage.ErrObjectNotExist
+	}))
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	_, err := c.Bucket("my-bucket").Object("missing.txt").Attrs(context.Background())
+	assert.Equal(t, storage.ErrObjectNotExist, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Nil(t, spans[0].Tag(ext.Error))
+}
@@ -13,6 +13,7 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
 
@@ -21,6 +22,10 @@ import (
 
 const componentName = "confluentinc/confluent-kafka-go/kafka.v2"
 
+// tagPartitionCount holds the number of partitions committed, assigned or
+// revoked.
+const tagPartitionCount = "messaging.kafka.partition_count"
+
 func init() {
 	telemetry.LoadIntegration(componentName)
 }
@@ -80,6 +85,8 @@ func (c *Consumer) traceEventsChannel(in chan kafka.Event) chan kafka.Event {
 			if msg, ok := evt.(*kafka.Message); ok {
 				next = c.startSpan(msg)
 			}
+			c.trackOffsets(evt)
+			c.traceRebalanceEvent(evt)
 
 			out <- evt
 
@@ -162,6 +169,8 @@ func (c *Consumer) Poll(timeoutMS int) (event kafka.Event) {
 	if msg, ok := evt.(*kafka.Message); ok {
 		c.prev = c.startSpan(msg)
 	}
+	c.trackOffsets(evt)
+	c.traceRebalanceEvent(evt)
 	return evt
 }
 
@@ -176,9 +185,190 @@ func (c *Consumer) ReadMessage(timeout time.Duration) (*kafka.Message, error) {
 		return nil, err
 	}
 	c.prev = c.startSpan(msg)
+	c.trackOffsets(msg)
 	return msg, nil
 }
 
+// Commit calls the underlying Consumer.Commit and traces the request. When
+// Data Streams Monitoring is enabled, the committed offsets are reported to
+// compute the backlog of the consumer group.
+func (c *Consumer) Commit() ([]kafka.TopicPartition, error) {
+	span := c.startCommitSpan()
+	tps, err := c.Consumer.Commit()
+	finishCommitSpan(span, tps, err)
+	c.trackCommitOffsets(tps, err)
+	return tps, err
+}
+
+// CommitMessage calls the underlying Consumer.CommitMessage and traces the
+// request. When Data Streams Monitoring is enabled, the committed offsets are
+// reported to compute the backlog of the consumer group.
+func (c *Consumer) CommitMessage(msg *kafka.Message) ([]kafka.TopicPartition, error) {
+	span := c.startCommitSpan()
+	tps, err := c.Consumer.CommitMessage(msg)
+	finishCommitSpan(span, tps, err)
+	c.trackCommitOffsets(tps, err)
+	return tps, err
+}
+
+// CommitOffsets calls the underlying Consumer.CommitOffsets and traces the
+// request. When Data Streams Monitoring is enabled, the committed offsets are
+// reported to compute the backlog of the consumer group.
+func (c *Consumer) CommitOffsets(offsets []kafka.TopicPartition) ([]kafka.TopicPartition, error) {
+	span := c.startCommitSpan()
+	tps, err := c.Consumer.CommitOffsets(offsets)
+	finishCommitSpan(span, tps, err)
+	c.trackCommitOffsets(tps, err)
+	return tps, err
+}
+
+func (c *Consumer) startCommitSpan() ddtrace.Span {
+	opts := []tracer.StartSpanOption{
+		tracer.ServiceName(c.cfg.consumerServiceName),
+		tracer.ResourceName("Commit"),
+		tracer.SpanType(ext.SpanTypeMessageConsumer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		tracer.Tag(ext.MessagingSystem, "kafka"),
+	}
+	if c.cfg.bootstrapServers != "" {
+		opts = append(opts, tracer.Tag(ext.KafkaBootstrapServers, c.cfg.bootstrapServers))
+	}
+	if c.cfg.groupID != "" {
+		opts = append(opts, tracer.Tag(ext.MessagingKafkaConsumerGroup, c.cfg.groupID))
+	}
+	span, _ := tracer.StartSpanFromContext(c.cfg.ctx, c.cfg.commitSpanName, opts...)
+	return span
+}
+
+// finishCommitSpan finishes the span of a commit of offsets, which failed if
+// err is not nil or if the commit of any partition failed.
+func finishCommitSpan(span ddtrace.Span, offsets []kafka.TopicPartition, err error) {
+	if kerr, ok := err.(kafka.Error); ok && kerr.Code() == kafka.ErrNoOffset {
+		// there was nothing to commit
+		err = nil
+	}
+	span.SetTag(tagPartitionCount, len(offsets))
+	for _, tp := range offsets {
+		if err != nil {
+			break
+		}
+		err = tp.Error
+	}
+	span.Finish(tracer.WithError(err))
+}
+
+// Subscribe calls the underlying Consumer.Subscribe. The calls to rebalanceCb,
+// if any, are traced.
+func (c *Consumer) Subscribe(topic string, rebalanceCb kafka.RebalanceCb) error {
+	return c.Consumer.Subscribe(topic, c.traceRebalanceCb(rebalanceCb))
+}
+
+// SubscribeTopics calls the underlying Consumer.SubscribeTopics. The calls to
+// rebalanceCb, if any, are traced.
+func (c *Consumer) SubscribeTopics(topics []string, rebalanceCb kafka.RebalanceCb) error {
+	return c.Consumer.SubscribeTopics(topics, c.traceRebalanceCb(rebalanceCb))
+}
+
+func (c *Consumer) traceRebalanceCb(cb kafka.RebalanceCb) kafka.RebalanceCb {
+	if cb == nil {
+		// leave the rebalances to the client, or to the application through
+		// the events, which are traced when polled
+		return nil
+	}
+	return func(consumer *kafka.Consumer, evt kafka.Event) error {
+		span := c.startRebalanceSpan(evt)
+		err := cb(consumer, evt)
+		if span != nil {
+			span.Finish(tracer.WithError(err))
+		}
+		return err
+	}
+}
+
+// traceRebalanceEvent records the rebalance event evt, if it is one, as
+// delivered to the application when go.application.rebalance.enable is set.
+func (c *Consumer) traceRebalanceEvent(evt kafka.Event) {
+	if span := c.startRebalanceSpan(evt); span != nil {
+		span.Finish()
+	}
+}
+
+// startRebalanceSpan starts the span of the assignment or revocation of
+// partitions described by evt. It returns nil if evt is not a rebalance
+// event.
+func (c *Consumer) startRebalanceSpan(evt kafka.Event) ddtrace.Span {
+	var (
+		resource   string
+		partitions []kafka.TopicPartition
+	)
+	switch e := evt.(type) {
+	case kafka.AssignedPartitions:
+		resource, partitions = "Assign Partitions", e.Partitions
+	case kafka.RevokedPartitions:
+		resource, partitions = "Revoke Partitions", e.Partitions
+	default:
+		return nil
+	}
+	opts := []tracer.StartSpanOption{
+		tracer.ServiceName(c.cfg.consumerServiceName),
+		tracer.ResourceName(resource),
+		tracer.SpanType(ext.SpanTypeMessageConsumer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindConsumer),
+		tracer.Tag(ext.MessagingSystem, "kafka"),
+		tracer.Tag(tagPartitionCount, len(partitions)),
+	}
+	if c.cfg.bootstrapServers != "" {
+		opts = append(opts, tracer.Tag(ext.KafkaBootstrapServers, c.cfg.bootstrapServers))
+	}
+	if c.cfg.groupID != "" {
+		opts = append(opts, tracer.Tag(ext.MessagingKafkaConsumerGroup, c.cfg.groupID))
+	}
+	span, _ := tracer.StartSpanFromContext(c.cfg.ctx, c.cfg.rebalanceSpanName, opts...)
+	return span
+}
+
+// trackOffsets reports the offsets found in evt to Data Streams Monitoring, if
+// it is enabled and the consumer has a group: the high watermark of the
+// partition of consumed messages, and the offsets committed automatically.
+func (c *Consumer) trackOffsets(evt kafka.Event) {
+	p := datastreams.GetGlobalProcessor()
+	if p == nil || c.cfg.groupID == "" {
+		return
+	}
+	switch e := evt.(type) {
+	case *kafka.Message:
+		tp := e.TopicPartition
+		if tp.Topic == nil {
+			return
+		}
+		// the watermarks are cached by the client, so this doesn't query the broker
+		if _, high, err := c.Consumer.GetWatermarkOffsets(*tp.Topic, tp.Partition); err == nil {
+			p.TrackKafkaHighWatermarkOffset(c.cfg.groupID, *tp.Topic, tp.Partition, high)
+		}
+	case kafka.OffsetsCommitted:
+		c.trackCommitOffsets(e.Offsets, e.Error)
+	}
+}
+
+// trackCommitOffsets reports the committed offsets to Data Streams
+// Monitoring, if it is enabled, the commit succeeded and the consumer has a
+// group.
+func (c *Consumer) trackCommitOffsets(offsets []kafka.TopicPartition, err error) {
+	p := datastreams.GetGlobalProcessor()
+	if err != nil || p == nil || c.cfg.groupID == "" {
+		return
+	}
+	for _, tp := range offsets {
+		if tp.Error != nil || tp.Topic == nil || tp.Offset < 0 {
+			// skip failed commits and logical offsets
+			continue
+		}
+		p.TrackKafkaCommitOffset(c.cfg.groupID, *tp.Topic, tp.Partition, int64(tp.Offset))
+	}
+}
+
 // A Producer wraps a kafka.Producer.
 type Producer struct {
 	*kafka.Producer
@@ -261,6 +451,9 @@ func (p *Producer) Produce(msg *kafka.Message, deliveryChan chan kafka.Event) er
 			if msg, ok := evt.(*kafka.Message); ok {
 				// delivery errors are returned via TopicPartition.Error
 				err = msg.TopicPartition.Error
+				if err == nil {
+					trackProduceOffset(msg.TopicPartition)
+				}
 			}
 			span.Finish(tracer.WithError(err))
 			oldDeliveryChan <- evt
@@ -275,6 +468,14 @@ func (p *Producer) Produce(msg *kafka.Message, deliveryChan chan kafka.Event) er
 	return err
 }
 
+// trackProduceOffset reports the offset of a delivered message to Data
+// Streams Monitoring, if it is enabled.
+func trackProduceOffset(tp kafka.TopicPartition) {
+	if p := datastreams.GetGlobalProcessor(); p != nil && tp.Topic != nil {
+		p.TrackKafkaProduceOffset(*tp.Topic, tp.Partition, int64(tp.Offset))
+	}
+}
+
 // ProduceChannel returns a channel which can receive kafka Messages and will
 // send them to the underlying producer channel.
 func (p *Producer) ProduceChannel() chan *kafka.Message {
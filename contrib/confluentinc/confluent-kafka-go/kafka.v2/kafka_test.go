@@ -151,6 +151,84 @@ func TestConsumerChannel(t *testing.T) {
 	}
 }
 
+func TestCommitSpan(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := &Consumer{cfg: newConfig(WithConfig(&kafka.ConfigMap{
+		"group.id":          testGroupID,
+		"bootstrap.servers": "127.0.0.1:9092",
+	}))}
+	offsets := []kafka.TopicPartition{
+		{Topic: &testTopic, Partition: 0, Offset: 1},
+		{Topic: &testTopic, Partition: 1, Offset: 2},
+	}
+	finishCommitSpan(c.startCommitSpan(), offsets, nil)
+	finishCommitSpan(c.startCommitSpan(), nil, kafka.NewError(kafka.ErrNoOffset, "no offset stored", false))
+	offsets[1].Error = kafka.NewError(kafka.ErrUnknownMemberID, "unknown member", false)
+	finishCommitSpan(c.startCommitSpan(), offsets, nil)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 3)
+	for _, s := range spans {
+		assert.Equal(t, "kafka.commit", s.OperationName())
+		assert.Equal(t, "Commit", s.Tag(ext.ResourceName))
+		assert.Equal(t, testGroupID, s.Tag(ext.MessagingKafkaConsumerGroup))
+		assert.Equal(t, "127.0.0.1", s.Tag(ext.KafkaBootstrapServers))
+		assert.Equal(t, ext.SpanKindClient, s.Tag(ext.SpanKind))
+		assert.Equal(t, "confluentinc/confluent-kafka-go/kafka.v2", s.Tag(ext.Component))
+	}
+	assert.Equal(t, 2, spans[0].Tag(tagPartitionCount))
+	assert.Nil(t, spans[0].Tag(ext.Error))
+	// a commit with nothing to commit did not fail
+	assert.Nil(t, spans[1].Tag(ext.Error))
+	assert.Equal(t, offsets[1].Error, spans[2].Tag(ext.Error))
+}
+
+func TestRebalance(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c, err := NewConsumer(&kafka.ConfigMap{
+		"go.events.channel.enable":        true,
+		"go.application.rebalance.enable": true,
+		"group.id":                        testGroupID,
+		"socket.timeout.ms":               10,
+		"session.timeout.ms":              10,
+	})
+	require.NoError(t, err)
+
+	partitions := []kafka.TopicPartition{{Topic: &testTopic, Partition: 0}}
+	go func() {
+		c.Consumer.Events() <- kafka.AssignedPartitions{Partitions: partitions}
+	}()
+	_, ok := (<-c.Events()).(kafka.AssignedPartitions)
+	assert.True(t, ok)
+
+	cbErr := errors.New("rebalance failed")
+	cb := c.traceRebalanceCb(func(*kafka.Consumer, kafka.Event) error {
+		return cbErr
+	})
+	assert.Equal(t, cbErr, cb(c.Consumer, kafka.RevokedPartitions{Partitions: partitions}))
+	assert.Nil(t, c.traceRebalanceCb(nil))
+
+	c.Close()
+	<-c.Events()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	for _, s := range spans {
+		assert.Equal(t, "kafka.rebalance", s.OperationName())
+		assert.Equal(t, 1, s.Tag(tagPartitionCount))
+		assert.Equal(t, testGroupID, s.Tag(ext.MessagingKafkaConsumerGroup))
+		assert.Equal(t, ext.SpanKindConsumer, s.Tag(ext.SpanKind))
+	}
+	assert.Equal(t, "Assign Partitions", spans[0].Tag(ext.ResourceName))
+	assert.Nil(t, spans[0].Tag(ext.Error))
+	assert.Equal(t, "Revoke Partitions", spans[1].Tag(ext.ResourceName))
+	assert.Equal(t, cbErr, spans[1].Tag(ext.Error))
+}
+
 /*
 to run the integration test locally:
 
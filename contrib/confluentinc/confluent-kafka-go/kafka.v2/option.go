@@ -25,8 +25,11 @@ type config struct {
 	producerServiceName string
 	consumerSpanName    string
 	producerSpanName    string
+	commitSpanName      string
+	rebalanceSpanName   string
 	analyticsRate       float64
 	bootstrapServers    string
+	groupID             string
 	tagFns              map[string]func(msg *kafka.Message) interface{}
 }
 
@@ -50,6 +53,8 @@ func newConfig(opts ...Option) *config {
 	).GetName()
 	cfg.consumerSpanName = namingschema.NewKafkaInboundOp().GetName()
 	cfg.producerSpanName = namingschema.NewKafkaOutboundOp().GetName()
+	cfg.commitSpanName = "kafka.commit"
+	cfg.rebalanceSpanName = "kafka.rebalance"
 
 	for _, opt := range opts {
 		opt(cfg)
@@ -111,6 +116,9 @@ func WithCustomTag(tag string, tagFn func(msg *kafka.Message) interface{}) Optio
 // WithConfig extracts the config information for the client to be tagged
 func WithConfig(cg *kafka.ConfigMap) Option {
 	return func(cfg *config) {
+		if groupID, err := cg.Get("group.id", ""); err == nil {
+			cfg.groupID = groupID.(string)
+		}
 		if bs, err := cg.Get("bootstrap.servers", ""); err == nil && bs != "" {
 			for _, addr := range strings.Split(bs.(string), ",") {
 				host, _, err := net.SplitHostPort(addr)
@@ -11,6 +11,7 @@ import (
 
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
 
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -44,3 +45,12 @@ func TestAnalyticsSettings(t *testing.T) {
 		assert.Equal(t, 0.2, cfg.analyticsRate)
 	})
 }
+
+func TestWithConfig(t *testing.T) {
+	cfg := newConfig(WithConfig(&kafka.ConfigMap{
+		"bootstrap.servers": "127.0.0.1:9092",
+		"group.id":          "workers",
+	}))
+	assert.Equal(t, "127.0.0.1", cfg.bootstrapServers)
+	assert.Equal(t, "workers", cfg.groupID)
+}
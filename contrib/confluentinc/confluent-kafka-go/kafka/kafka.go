@@ -13,6 +13,7 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
 
@@ -81,6 +82,7 @@ func (c *Consumer) traceEventsChannel(in chan kafka.Event) chan kafka.Event {
 			if msg, ok := evt.(*kafka.Message); ok {
 				next = c.startSpan(msg)
 			}
+			c.trackOffsets(evt)
 
 			out <- evt
 
@@ -165,6 +167,7 @@ func (c *Consumer) Poll(timeoutMS int) (event kafka.Event) {
 	if msg, ok := evt.(*kafka.Message); ok {
 		c.prev = c.startSpan(msg)
 	}
+	c.trackOffsets(evt)
 	return evt
 }
 
@@ -179,9 +182,77 @@ func (c *Consumer) ReadMessage(timeout time.Duration) (*kafka.Message, error) {
 		return nil, err
 	}
 	c.prev = c.startSpan(msg)
+	c.trackOffsets(msg)
 	return msg, nil
 }
 
+// Commit calls the underlying Consumer.Commit. When Data Streams Monitoring is
+// enabled, the committed offsets are reported to compute the backlog of the
+// consumer group.
+func (c *Consumer) Commit() ([]kafka.TopicPartition, error) {
+	tps, err := c.Consumer.Commit()
+	c.trackCommitOffsets(tps, err)
+	return tps, err
+}
+
+// CommitMessage calls the underlying Consumer.CommitMessage. When Data Streams
+// Monitoring is enabled, the committed offsets are reported to compute the
+// backlog of the consumer group.
+func (c *Consumer) CommitMessage(msg *kafka.Message) ([]kafka.TopicPartition, error) {
+	tps, err := c.Consumer.CommitMessage(msg)
+	c.trackCommitOffsets(tps, err)
+	return tps, err
+}
+
+// CommitOffsets calls the underlying Consumer.CommitOffsets. When Data Streams
+// Monitoring is enabled, the committed offsets are reported to compute the
+// backlog of the consumer group.
+func (c *Consumer) CommitOffsets(offsets []kafka.TopicPartition) ([]kafka.TopicPartition, error) {
+	tps, err := c.Consumer.CommitOffsets(offsets)
+	c.trackCommitOffsets(tps, err)
+	return tps, err
+}
+
+// trackOffsets reports the offsets found in evt to Data Streams Monitoring, if
+// it is enabled and the consumer has a group: the high watermark of the
+// partition of consumed messages, and the offsets committed automatically.
+func (c *Consumer) trackOffsets(evt kafka.Event) {
+	p := datastreams.GetGlobalProcessor()
+	if p == nil || c.cfg.groupID == "" {
+		return
+	}
+	switch e := evt.(type) {
+	case *kafka.Message:
+		tp := e.TopicPartition
+		if tp.Topic == nil {
+			return
+		}
+		// the watermarks are cached by the client, so this doesn't query the broker
+		if _, high, err := c.Consumer.GetWatermarkOffsets(*tp.Topic, tp.Partition); err == nil {
+			p.TrackKafkaHighWatermarkOffset(c.cfg.groupID, *tp.Topic, tp.Partition, high)
+		}
+	case kafka.OffsetsCommitted:
+		c.trackCommitOffsets(e.Offsets, e.Error)
+	}
+}
+
+// trackCommitOffsets reports the committed offsets to Data Streams
+// Monitoring, if it is enabled, the commit succeeded and the consumer has a
+// group.
+func (c *Consumer) trackCommitOffsets(offsets []kafka.TopicPartition, err error) {
+	p := datastreams.GetGlobalProcessor()
+	if err != nil || p == nil || c.cfg.groupID == "" {
+		return
+	}
+	for _, tp := range offsets {
+		if tp.Error != nil || tp.Topic == nil || tp.Offset < 0 {
+			// skip failed commits and logical offsets
+			continue
+		}
+		p.TrackKafkaCommitOffset(c.cfg.groupID, *tp.Topic, tp.Partition, int64(tp.Offset))
+	}
+}
+
 // A Producer wraps a kafka.Producer.
 type Producer struct {
 	*kafka.Producer
@@ -268,6 +339,9 @@ func (p *Producer) Produce(msg *kafka.Message, deliveryChan chan kafka.Event) er
 			if msg, ok := evt.(*kafka.Message); ok {
 				// delivery errors are returned via TopicPartition.Error
 				err = msg.TopicPartition.Error
+				if err == nil {
+					trackProduceOffset(msg.TopicPartition)
+				}
 			}
 			span.Finish(tracer.WithError(err))
 			oldDeliveryChan <- evt
@@ -283,6 +357,14 @@ func (p *Producer) Produce(msg *kafka.Message, deliveryChan chan kafka.Event) er
 	return err
 }
 
+// trackProduceOffset reports the offset of a delivered message to Data
+// Streams Monitoring, if it is enabled.
+func trackProduceOffset(tp kafka.TopicPartition) {
+	if p := datastreams.GetGlobalProcessor(); p != nil && tp.Topic != nil {
+		p.TrackKafkaProduceOffset(*tp.Topic, tp.Partition, int64(tp.Offset))
+	}
+}
+
 // ProduceChannel returns a channel which can receive kafka Messages and will
 // send them to the underlying producer channel.
 func (p *Producer) ProduceChannel() chan *kafka.Message {
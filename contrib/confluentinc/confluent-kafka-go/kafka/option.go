@@ -27,6 +27,7 @@ type config struct {
 	producerSpanName    string
 	analyticsRate       float64
 	bootstrapServers    string
+	groupID             string
 	tagFns              map[string]func(msg *kafka.Message) interface{}
 }
 
@@ -111,6 +112,9 @@ func WithCustomTag(tag string, tagFn func(msg *kafka.Message) interface{}) Optio
 // WithConfig extracts the config information for the client to be tagged
 func WithConfig(cg *kafka.ConfigMap) Option {
 	return func(cfg *config) {
+		if groupID, err := cg.Get("group.id", ""); err == nil {
+			cfg.groupID = groupID.(string)
+		}
 		if bs, err := cg.Get("bootstrap.servers", ""); err == nil && bs != "" {
 			for _, addr := range strings.Split(bs.(string), ",") {
 				host, _, err := net.SplitHostPort(addr)
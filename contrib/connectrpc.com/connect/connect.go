@@ -0,0 +1,250 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package connect provides functions to trace the connectrpc.com/connect package (https://github.com/connectrpc/connect-go).
+//
+// The same interceptor traces both clients and handlers, whether calls are
+// unary or streaming, and propagates the span context in the request headers
+// with all the protocols supported by Connect: Connect, gRPC and gRPC-Web.
+package connect // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/connectrpc.com/connect"
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"connectrpc.com/connect"
+)
+
+const componentName = "connectrpc.com/connect"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+const (
+	tagProcedure  = "connect.procedure"
+	tagProtocol   = "connect.protocol"
+	tagStreamType = "connect.stream_type"
+)
+
+// NewInterceptor returns a connect.Interceptor tracing the calls of clients
+// and handlers, to be added to them with connect.WithInterceptors.
+func NewInterceptor(opts ...Option) connect.Interceptor {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/connectrpc.com/connect: Configuring Interceptor: %#v", cfg)
+	return &interceptor{cfg: cfg}
+}
+
+type interceptor struct {
+	cfg *config
+}
+
+// WrapUnary implements connect.Interceptor.
+func (i *interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		spec := req.Spec()
+		if i.untraced(spec) {
+			return next(ctx, req)
+		}
+		span, ctx := i.startSpan(ctx, spec, req.Header())
+		tagPeer(span, spec, req.Peer())
+		if spec.IsClient {
+			injectSpanContext(span, req.Header())
+		}
+		res, err := next(ctx, req)
+		finishSpan(span, err)
+		return res, err
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor. The span of the call is
+// finished when its response is closed.
+func (i *interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		if i.untraced(spec) {
+			return next(ctx, spec)
+		}
+		span, ctx := i.startSpan(ctx, spec, nil)
+		conn := next(ctx, spec)
+		tagPeer(span, spec, conn.Peer())
+		// the headers are sent with the first message
+		injectSpanContext(span, conn.RequestHeader())
+		return &streamingClientConn{StreamingClientConn: conn, span: span}
+	}
+}
+
+// WrapStreamingHandler implements connect.Interceptor.
+func (i *interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		spec := conn.Spec()
+		if i.untraced(spec) {
+			return next(ctx, conn)
+		}
+		span, ctx := i.startSpan(ctx, spec, conn.RequestHeader())
+		tagPeer(span, spec, conn.Peer())
+		err := next(ctx, conn)
+		finishSpan(span, err)
+		return err
+	}
+}
+
+func (i *interceptor) untraced(spec connect.Spec) bool {
+	_, ok := i.cfg.untracedProcedures[spec.Procedure]
+	return ok
+}
+
+// startSpan starts the span of a call. The span of handlers is a child of the
+// span context found in header, if any.
+func (i *interceptor) startSpan(ctx context.Context, spec connect.Spec, header http.Header) (ddtrace.Span, context.Context) {
+	service, method := splitProcedure(spec.Procedure)
+	opts := []tracer.StartSpanOption{
+		tracer.ResourceName(spec.Procedure),
+		tracer.SpanType(ext.AppTypeRPC),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.RPCSystem, ext.RPCSystemConnect),
+		tracer.Tag(ext.RPCService, service),
+		tracer.Tag(ext.RPCMethod, method),
+		tracer.Tag(tagProcedure, spec.Procedure),
+		tracer.Tag(tagStreamType, streamType(spec.StreamType)),
+	}
+	if !math.IsNaN(i.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, i.cfg.analyticsRate))
+	}
+	if spec.IsClient {
+		opts = append(opts,
+			tracer.ServiceName(i.cfg.clientServiceName),
+			tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		)
+		return tracer.StartSpanFromContext(ctx, i.cfg.clientSpanName, opts...)
+	}
+	opts = append(opts,
+		tracer.ServiceName(i.cfg.serverServiceName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+		tracer.Measured(),
+	)
+	if spanctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(header)); err == nil {
+		opts = append(opts, tracer.ChildOf(spanctx))
+	}
+	return tracer.StartSpanFromContext(ctx, i.cfg.serverSpanName, opts...)
+}
+
+func injectSpanContext(span ddtrace.Span, header http.Header) {
+	if err := tracer.Inject(span.Context(), tracer.HTTPHeadersCarrier(header)); err != nil {
+		log.Debug("contrib/connectrpc.com/connect: Failed to inject span context into headers, %v", err)
+	}
+}
+
+// tagPeer sets the protocol of the call and the address of the peer on span.
+func tagPeer(span ddtrace.Span, spec connect.Spec, peer connect.Peer) {
+	if peer.Protocol != "" {
+		span.SetTag(tagProtocol, peer.Protocol)
+	}
+	host, port, err := net.SplitHostPort(peer.Addr)
+	if err != nil {
+		// the address of servers may have no port
+		host, port = peer.Addr, ""
+	}
+	if spec.IsClient {
+		if host != "" {
+			span.SetTag(ext.NetworkDestinationName, host)
+		}
+		if port != "" {
+			span.SetTag(ext.NetworkDestinationPort, port)
+		}
+		return
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.To4() != nil {
+			span.SetTag(ext.PeerHostIPV4, ip.String())
+		} else {
+			span.SetTag(ext.PeerHostIPV6, ip.String())
+		}
+	}
+	if port != "" {
+		span.SetTag(ext.PeerPort, port)
+	}
+}
+
+// finishSpan finishes span with err, disregarding the end of streams and
+// canceled calls.
+func finishSpan(span ddtrace.Span, err error) {
+	if err == nil || errors.Is(err, io.EOF) {
+		span.Finish()
+		return
+	}
+	code := connect.CodeOf(err)
+	span.SetTag(ext.ConnectRPCErrorCode, code.String())
+	if code == connect.CodeCanceled {
+		span.Finish()
+		return
+	}
+	span.Finish(tracer.WithError(err))
+}
+
+// streamingClientConn finishes the span of a streaming call when its
+// response is closed, with the error it was received with, if any.
+type streamingClientConn struct {
+	connect.StreamingClientConn
+	span ddtrace.Span
+
+	mu       sync.Mutex
+	err      error
+	finished bool
+}
+
+// Receive implements connect.StreamingClientConn.
+func (c *streamingClientConn) Receive(msg any) error {
+	err := c.StreamingClientConn.Receive(msg)
+	if err != nil && !errors.Is(err, io.EOF) {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// CloseResponse implements connect.StreamingClientConn.
+func (c *streamingClientConn) CloseResponse() error {
+	err := c.StreamingClientConn.CloseResponse()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.finished {
+		c.finished = true
+		finishSpan(c.span, c.err)
+	}
+	return err
+}
+
+// splitProcedure splits a procedure such as "/acme.foo.v1.FooService/Bar"
+// into its service and method names.
+func splitProcedure(procedure string) (service, method string) {
+	service, method, _ = strings.Cut(strings.TrimPrefix(procedure, "/"), "/")
+	return service, method
+}
+
+func streamType(t connect.StreamType) string {
+	switch t {
+	case connect.StreamTypeClient:
+		return "client_streaming"
+	case connect.StreamTypeServer:
+		return "server_streaming"
+	case connect.StreamTypeBidi:
+		return "bidi_streaming"
+	default:
+		return "unary"
+	}
+}
@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package connect
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	pingProcedure   = "/test.v1.TestService/Ping"
+	streamProcedure = "/test.v1.TestService/Stream"
+	failProcedure   = "/test.v1.TestService/Fail"
+)
+
+func newServer(t *testing.T, opts ...Option) *httptest.Server {
+	interceptors := connect.WithInterceptors(NewInterceptor(opts...))
+	mux := http.NewServeMux()
+	mux.Handle(pingProcedure, connect.NewUnaryHandler(pingProcedure,
+		func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+			return connect.NewResponse(wrapperspb.String("pong")), nil
+		}, interceptors))
+	mux.Handle(streamProcedure, connect.NewServerStreamHandler(streamProcedure,
+		func(ctx context.Context, req *connect.Request[wrapperspb.StringValue], stream *connect.ServerStream[wrapperspb.StringValue]) error {
+			for i := 0; i < 2; i++ {
+				if err := stream.Send(wrapperspb.String("pong")); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, interceptors))
+	mux.Handle(failProcedure, connect.NewUnaryHandler(failProcedure,
+		func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("no such ping"))
+		}, interceptors))
+	srv := httptest.NewUnstartedServer(mux)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestUnary(t *testing.T) {
+	for name, opt := range map[string]connect.ClientOption{
+		"connect": connect.WithProtoJSON(),
+		"grpc":    connect.WithGRPC(),
+		"grpcweb": connect.WithGRPCWeb(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			srv := newServer(t)
+			client := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+pingProcedure,
+				opt, connect.WithInterceptors(NewInterceptor()))
+			root, ctx := tracer.StartSpanFromContext(context.Background(), "root")
+			_, err := client.CallUnary(ctx, connect.NewRequest(wrapperspb.String("ping")))
+			require.NoError(t, err)
+			root.Finish()
+
+			spans := mt.FinishedSpans()
+			require.Len(t, spans, 3)
+			server, clientSpan := spans[0], spans[1]
+			assert.Equal(t, "connect.server", server.OperationName())
+			assert.Equal(t, "connect.client", clientSpan.OperationName())
+			assert.Equal(t, root.Context().SpanID(), clientSpan.ParentID())
+			assert.Equal(t, clientSpan.SpanID(), server.ParentID())
+			for _, s := range []mocktracer.Span{server, clientSpan} {
+				assert.Equal(t, pingProcedure, s.Tag(ext.ResourceName))
+				assert.Equal(t, ext.RPCSystemConnect, s.Tag(ext.RPCSystem))
+				assert.Equal(t, "test.v1.TestService", s.Tag(ext.RPCService))
+				assert.Equal(t, "Ping", s.Tag(ext.RPCMethod))
+				assert.Equal(t, "unary", s.Tag(tagStreamType))
+				assert.Equal(t, name, s.Tag(tagProtocol))
+				assert.Equal(t, componentName, s.Tag(ext.Component))
+				assert.Nil(t, s.Tag(ext.Error))
+			}
+			assert.Equal(t, ext.SpanKindServer, server.Tag(ext.SpanKind))
+			assert.Equal(t, "connect.server", server.Tag(ext.ServiceName))
+			assert.Equal(t, "127.0.0.1", server.Tag(ext.PeerHostIPV4))
+			assert.Equal(t, ext.SpanKindClient, clientSpan.Tag(ext.SpanKind))
+			assert.Equal(t, "connect.client", clientSpan.Tag(ext.ServiceName))
+			assert.Equal(t, "127.0.0.1", clientSpan.Tag(ext.NetworkDestinationName))
+		})
+	}
+}
+
+func TestServerStream(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	srv := newServer(t, WithServiceName("pinger"))
+	client := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+streamProcedure,
+		connect.WithInterceptors(NewInterceptor(WithServiceName("pinger"))))
+	stream, err := client.CallServerStream(context.Background(), connect.NewRequest(wrapperspb.String("ping")))
+	require.NoError(t, err)
+	var n int
+	for stream.Receive() {
+		n++
+	}
+	require.NoError(t, stream.Err())
+	assert.Equal(t, 2, n)
+	// the span of the client is finished when the stream is closed
+	require.Len(t, mt.FinishedSpans(), 1)
+	require.NoError(t, stream.Close())
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	server, clientSpan := spans[0], spans[1]
+	assert.Equal(t, clientSpan.SpanID(), server.ParentID())
+	for _, s := range spans {
+		assert.Equal(t, streamProcedure, s.Tag(ext.ResourceName))
+		assert.Equal(t, "server_streaming", s.Tag(tagStreamType))
+		assert.Equal(t, "pinger", s.Tag(ext.ServiceName))
+		assert.Nil(t, s.Tag(ext.Error))
+	}
+}
+
+func TestError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	srv := newServer(t)
+	client := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+failProcedure,
+		connect.WithInterceptors(NewInterceptor()))
+	_, err := client.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("ping")))
+	require.Error(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	for _, s := range spans {
+		assert.Equal(t, "not_found", s.Tag(ext.ConnectRPCErrorCode))
+		assert.NotNil(t, s.Tag(ext.Error))
+	}
+}
+
+func TestUntracedProcedures(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	srv := newServer(t, WithUntracedProcedures(pingProcedure))
+	client := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+pingProcedure,
+		connect.WithInterceptors(NewInterceptor(WithUntracedProcedures(pingProcedure))))
+	_, err := client.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("ping")))
+	require.NoError(t, err)
+	assert.Empty(t, mt.FinishedSpans())
+}
+
+func TestSplitProcedure(t *testing.T) {
+	service, method := splitProcedure("/acme.foo.v1.FooService/Bar")
+	assert.Equal(t, "acme.foo.v1.FooService", service)
+	assert.Equal(t, "Bar", method)
+}
@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package connect_test
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	connecttrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/connectrpc.com/connect"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func Example() {
+	const procedure = "/greet.v1.GreetService/Greet"
+	interceptors := connect.WithInterceptors(connecttrace.NewInterceptor())
+
+	// handlers, including the ones generated by connect-go, take the
+	// interceptor as option.
+	mux := http.NewServeMux()
+	mux.Handle(procedure, connect.NewUnaryHandler(procedure,
+		func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+			return connect.NewResponse(wrapperspb.String("Hello, " + req.Msg.Value)), nil
+		}, interceptors))
+	go http.ListenAndServe(":8080", mux)
+
+	// and so do clients.
+	client := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](http.DefaultClient,
+		"http://localhost:8080"+procedure, interceptors)
+	if _, err := client.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("Jane"))); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package connect
+
+import (
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+)
+
+const (
+	defaultClientServiceName = "connect.client"
+	defaultServerServiceName = "connect.server"
+)
+
+type config struct {
+	clientServiceName  string
+	serverServiceName  string
+	clientSpanName     string
+	serverSpanName     string
+	analyticsRate      float64
+	untracedProcedures map[string]struct{}
+}
+
+// An Option customizes the config.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		analyticsRate: math.NaN(),
+	}
+	if internal.BoolEnv("DD_TRACE_CONNECT_ANALYTICS_ENABLED", false) {
+		cfg.analyticsRate = 1.0
+	}
+	cfg.clientServiceName = namingschema.NewDefaultServiceName(
+		defaultClientServiceName,
+		namingschema.WithOverrideV0(defaultClientServiceName),
+	).GetName()
+	cfg.serverServiceName = namingschema.NewDefaultServiceName(defaultServerServiceName).GetName()
+	cfg.clientSpanName = namingschema.NewClientOutboundOp(
+		"connect",
+		namingschema.WithOverrideV0("connect.client"),
+	).GetName()
+	cfg.serverSpanName = namingschema.NewServerInboundOp(
+		"connect",
+		namingschema.WithOverrideV0("connect.server"),
+	).GetName()
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithServiceName sets the config service name to serviceName.
+func WithServiceName(serviceName string) Option {
+	return func(cfg *config) {
+		cfg.clientServiceName = serviceName
+		cfg.serverServiceName = serviceName
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithUntracedProcedures specifies procedures, such as "/acme.foo.v1.FooService/Bar",
+// whose calls are not traced.
+func WithUntracedProcedures(procedures ...string) Option {
+	ups := make(map[string]struct{}, len(procedures))
+	for _, p := range procedures {
+		ups[p] = struct{}{}
+	}
+	return func(cfg *config) {
+		cfg.untracedProcedures = ups
+	}
+}
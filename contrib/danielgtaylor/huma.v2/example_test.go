@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package huma_test
+
+import (
+	"context"
+	"net/http"
+
+	humatrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/danielgtaylor/huma.v2"
+	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humago"
+)
+
+type GreetingInput struct {
+	Name string `path:"name" maxLength:"30"`
+}
+
+type GreetingOutput struct {
+	Body struct {
+		Message string `json:"message"`
+	}
+}
+
+func Example() {
+	tracer.Start()
+	defer tracer.Stop()
+
+	// Set the validation errors of traced requests on their span.
+	huma.NewErrorWithContext = humatrace.WrapNewErrorWithContext(huma.NewErrorWithContext)
+
+	mux := httptrace.NewServeMux()
+	api := humago.New(mux, huma.DefaultConfig("Greetings", "1.0.0"))
+	api.UseMiddleware(humatrace.Middleware(humatrace.WithServiceName("greetings")))
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-greeting",
+		Method:      http.MethodGet,
+		Path:        "/greeting/{name}",
+	}, func(ctx context.Context, in *GreetingInput) (*GreetingOutput, error) {
+		out := new(GreetingOutput)
+		out.Body.Message = "Hello, " + in.Name
+		return out, nil
+	})
+
+	http.ListenAndServe(":8888", mux)
+}
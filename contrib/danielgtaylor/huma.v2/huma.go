@@ -0,0 +1,192 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package huma provides tracing functions for the danielgtaylor/huma package (https://github.com/danielgtaylor/huma).
+//
+// Request spans are named after the operationId of the OpenAPI operation
+// serving the request, and tagged with the operation and the schema of its
+// request body. The errors Huma responds with, such as validation errors, are
+// set on the request spans once huma.NewErrorWithContext is wrapped with
+// WrapNewErrorWithContext.
+//
+// Huma is router agnostic: to protect an API with AppSec, trace the router it
+// is served by with the matching integration, such as contrib/net/http.
+package huma // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/danielgtaylor/huma.v2"
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+const componentName = "danielgtaylor/huma.v2"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// requestErrorKey is the context key of the requestError of a traced request.
+type requestErrorKey struct{}
+
+// requestError holds the error Huma responded to a traced request with.
+type requestError struct {
+	err error
+}
+
+// Middleware returns a Huma middleware tracing the requests served by an API,
+// to be registered with huma.API.UseMiddleware.
+func Middleware(opts ...Option) func(ctx huma.Context, next func(huma.Context)) {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/danielgtaylor/huma.v2: Configuring Middleware: %#v", cfg)
+	return func(ctx huma.Context, next func(huma.Context)) {
+		op := ctx.Operation()
+		spanOpts := []ddtrace.StartSpanOption{
+			tracer.SpanType(ext.SpanTypeWeb),
+			tracer.ServiceName(cfg.serviceName),
+			tracer.ResourceName(resourceName(op)),
+			tracer.Tag(ext.HTTPMethod, ctx.Method()),
+			tracer.Tag(ext.HTTPURL, requestURL(ctx)),
+			tracer.Tag(ext.HTTPRoute, op.Path),
+			tracer.Tag(ext.Component, componentName),
+			tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+			tracer.Measured(),
+		}
+		if ua := ctx.Header("User-Agent"); ua != "" {
+			spanOpts = append(spanOpts, tracer.Tag(ext.HTTPUserAgent, ua))
+		}
+		if op.OperationID != "" {
+			spanOpts = append(spanOpts, tracer.Tag(ext.OpenAPIOperationID, op.OperationID))
+		}
+		if len(op.Tags) > 0 {
+			spanOpts = append(spanOpts, tracer.Tag(ext.OpenAPIOperationTags, strings.Join(op.Tags, ",")))
+		}
+		if schema := requestSchema(op); schema != "" {
+			spanOpts = append(spanOpts, tracer.Tag(ext.OpenAPIRequestSchema, schema))
+		}
+		if !math.IsNaN(cfg.analyticsRate) {
+			spanOpts = append(spanOpts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+		}
+		h := http.Header{}
+		ctx.EachHeader(func(name, value string) {
+			h.Add(name, value)
+		})
+		if spanctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(h)); err == nil {
+			spanOpts = append(spanOpts, tracer.ChildOf(spanctx))
+		}
+		spanOpts = append(spanOpts, cfg.spanOpts...)
+		// A span found in the request context, such as one started by the
+		// integration of the router, takes precedence as the parent.
+		span, spanctx := tracer.StartSpanFromContext(ctx.Context(), cfg.spanName, spanOpts...)
+		defer span.Finish()
+
+		reqErr := new(requestError)
+		next(huma.WithContext(ctx, context.WithValue(spanctx, requestErrorKey{}, reqErr)))
+
+		status := ctx.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetTag(ext.HTTPCode, strconv.Itoa(status))
+		if reqErr.err != nil {
+			span.SetTag(ext.Error, reqErr.err)
+		} else if cfg.isStatusError(status) {
+			span.SetTag(ext.Error, fmt.Errorf("%d: %s", status, http.StatusText(status)))
+		}
+	}
+}
+
+// WrapNewErrorWithContext wraps fn, which Huma calls to create the errors it
+// responds with, such as validation errors. The client and server errors fn
+// creates for requests traced by Middleware are set on their span. It is meant
+// to replace huma.NewErrorWithContext:
+//
+//	huma.NewErrorWithContext = humatrace.WrapNewErrorWithContext(huma.NewErrorWithContext)
+func WrapNewErrorWithContext(fn func(ctx huma.Context, status int, msg string, errs ...error) huma.StatusError) func(ctx huma.Context, status int, msg string, errs ...error) huma.StatusError {
+	return func(ctx huma.Context, status int, msg string, errs ...error) huma.StatusError {
+		err := fn(ctx, status, msg, errs...)
+		if ctx == nil || err == nil || err.GetStatus() < 400 {
+			return err
+		}
+		if reqErr, ok := ctx.Context().Value(requestErrorKey{}).(*requestError); ok {
+			reqErr.err = newStatusError(err.GetStatus(), msg, errs)
+		}
+		return err
+	}
+}
+
+// newStatusError returns an error describing an error response, including the
+// details of the errors which caused it.
+func newStatusError(status int, msg string, errs []error) error {
+	if msg == "" {
+		msg = http.StatusText(status)
+	}
+	details := make([]string, 0, len(errs))
+	for _, err := range errs {
+		if err != nil {
+			details = append(details, err.Error())
+		}
+	}
+	if len(details) == 0 {
+		return fmt.Errorf("%d: %s", status, msg)
+	}
+	return fmt.Errorf("%d: %s: %s", status, msg, strings.Join(details, "; "))
+}
+
+// requestURL returns the URL of the request of ctx, without its query.
+func requestURL(ctx huma.Context) string {
+	u := ctx.URL()
+	path := u.EscapedPath()
+	if ctx.Host() == "" {
+		return path
+	}
+	scheme := "http"
+	if ctx.TLS() != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + ctx.Host() + path
+}
+
+// resourceName returns the resource name of the requests served by op: its
+// operationId, or else its method and path.
+func resourceName(op *huma.Operation) string {
+	if op.OperationID != "" {
+		return op.OperationID
+	}
+	return op.Method + " " + op.Path
+}
+
+// requestSchema returns the reference of the schema of the request body of op,
+// if any. The JSON schema is preferred when several media types are accepted.
+func requestSchema(op *huma.Operation) string {
+	if op.RequestBody == nil || len(op.RequestBody.Content) == 0 {
+		return ""
+	}
+	if mt := op.RequestBody.Content["application/json"]; mt != nil && mt.Schema != nil && mt.Schema.Ref != "" {
+		return mt.Schema.Ref
+	}
+	types := make([]string, 0, len(op.RequestBody.Content))
+	for t := range op.RequestBody.Content {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		if mt := op.RequestBody.Content[t]; mt != nil && mt.Schema != nil && mt.Schema.Ref != "" {
+			return mt.Schema.Ref
+		}
+	}
+	return ""
+}
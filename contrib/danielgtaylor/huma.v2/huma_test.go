@@ -0,0 +1,267 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package huma
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type greetingInput struct {
+	Name string `path:"name" maxLength:"10"`
+}
+
+type greetingOutput struct {
+	Body struct {
+		Message string `json:"message"`
+	}
+}
+
+type user struct {
+	Name string `json:"name" minLength:"1"`
+}
+
+type createUserInput struct {
+	Body user
+}
+
+func newTestAPI(t *testing.T, opts ...Option) humatest.TestAPI {
+	_, api := humatest.New(t)
+	api.UseMiddleware(Middleware(opts...))
+	huma.Register(api, huma.Operation{
+		OperationID: "get-greeting",
+		Method:      http.MethodGet,
+		Path:        "/greeting/{name}",
+		Tags:        []string{"greetings", "public"},
+	}, func(ctx context.Context, in *greetingInput) (*greetingOutput, error) {
+		if in.Name == "boom" {
+			return nil, huma.Error500InternalServerError("boom")
+		}
+		out := new(greetingOutput)
+		out.Body.Message = "Hello, " + in.Name
+		return out, nil
+	})
+	huma.Register(api, huma.Operation{
+		Method: http.MethodPost,
+		Path:   "/users",
+	}, func(ctx context.Context, in *createUserInput) (*struct{}, error) {
+		return nil, nil
+	})
+	return api
+}
+
+func TestMiddleware(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	api := newTestAPI(t, WithServiceName("api"))
+	resp := api.Get("/greeting/world", "User-Agent: test")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "http.request", s.OperationName())
+	assert.Equal(t, "get-greeting", s.Tag(ext.ResourceName))
+	assert.Equal(t, "api", s.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanTypeWeb, s.Tag(ext.SpanType))
+	assert.Equal(t, "GET", s.Tag(ext.HTTPMethod))
+	assert.Equal(t, "/greeting/{name}", s.Tag(ext.HTTPRoute))
+	assert.Equal(t, "/greeting/world", s.Tag(ext.HTTPURL))
+	assert.Equal(t, "test", s.Tag(ext.HTTPUserAgent))
+	assert.Equal(t, "200", s.Tag(ext.HTTPCode))
+	assert.Equal(t, "get-greeting", s.Tag(ext.OpenAPIOperationID))
+	assert.Equal(t, "greetings,public", s.Tag(ext.OpenAPIOperationTags))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, ext.SpanKindServer, s.Tag(ext.SpanKind))
+	assert.Nil(t, s.Tag(ext.Error))
+}
+
+func TestMiddlewareRequestSchema(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	api := newTestAPI(t)
+	resp := api.Post("/users", user{Name: "alice"})
+	require.Equal(t, http.StatusNoContent, resp.Code)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	// Operations without an operationId are named after their method and path.
+	assert.Equal(t, "POST /users", s.Tag(ext.ResourceName))
+	assert.Nil(t, s.Tag(ext.OpenAPIOperationID))
+	assert.Equal(t, "#/components/schemas/User", s.Tag(ext.OpenAPIRequestSchema))
+	assert.Equal(t, "204", s.Tag(ext.HTTPCode))
+}
+
+func TestMiddlewareServerError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	api := newTestAPI(t)
+	resp := api.Get("/greeting/boom")
+	require.Equal(t, http.StatusInternalServerError, resp.Code)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "500", spans[0].Tag(ext.HTTPCode))
+	assert.EqualError(t, spans[0].Tag(ext.Error).(error), "500: Internal Server Error")
+}
+
+func TestMiddlewareStatusCheck(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	api := newTestAPI(t, WithStatusCheck(func(statusCode int) bool {
+		return statusCode >= 400
+	}))
+	resp := api.Get("/greeting/elevencharsx")
+	require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.EqualError(t, spans[0].Tag(ext.Error).(error), "422: Unprocessable Entity")
+}
+
+func TestWrapNewErrorWithContext(t *testing.T) {
+	newErr := huma.NewErrorWithContext
+	defer func() { huma.NewErrorWithContext = newErr }()
+	huma.NewErrorWithContext = WrapNewErrorWithContext(huma.NewErrorWithContext)
+
+	t.Run("validation", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		api := newTestAPI(t)
+		resp := api.Get("/greeting/elevencharsx")
+		require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "422", spans[0].Tag(ext.HTTPCode))
+		assert.EqualError(t, spans[0].Tag(ext.Error).(error),
+			"422: validation failed: expected length <= 10 (path.name: elevencharsx)")
+	})
+
+	t.Run("body", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		api := newTestAPI(t)
+		resp := api.Post("/users", map[string]any{"name": ""})
+		require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.EqualError(t, spans[0].Tag(ext.Error).(error),
+			"422: validation failed: expected length >= 1 (body.name: )")
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		api := newTestAPI(t)
+		resp := api.Get("/greeting/world")
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Nil(t, spans[0].Tag(ext.Error))
+	})
+
+	t.Run("no-context", func(t *testing.T) {
+		err := huma.NewErrorWithContext(nil, http.StatusBadRequest, "bad", errors.New("detail"))
+		assert.Equal(t, http.StatusBadRequest, err.GetStatus())
+	})
+}
+
+func TestMiddlewareParent(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	api := newTestAPI(t)
+
+	t.Run("context", func(t *testing.T) {
+		mt.Reset()
+		parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+		api.GetCtx(ctx, "/greeting/world")
+		parent.Finish()
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 2)
+		assert.Equal(t, parent.Context().SpanID(), spans[0].ParentID())
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		mt.Reset()
+		parent := tracer.StartSpan("parent")
+		h := http.Header{}
+		require.NoError(t, tracer.Inject(parent.Context(), tracer.HTTPHeadersCarrier(h)))
+		var args []any
+		for k := range h {
+			args = append(args, k+": "+h.Get(k))
+		}
+		api.Get("/greeting/world", args...)
+		parent.Finish()
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 2)
+		assert.Equal(t, parent.Context().SpanID(), spans[0].ParentID())
+		assert.Equal(t, parent.Context().TraceID(), spans[0].TraceID())
+	})
+}
+
+func TestAnalyticsSettings(t *testing.T) {
+	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
+		api := newTestAPI(t, opts...)
+		api.Get("/greeting/world")
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, rate, spans[0].Tag(ext.EventSampleRate))
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, nil)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 1.0, WithAnalytics(true))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, nil, WithAnalytics(false))
+	})
+
+	t.Run("override", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 0.23, WithAnalyticsRate(0.23))
+	})
+}
@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package sql
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/database/sql/internal"
+	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/httpsec"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppSecRASP(t *testing.T) {
+	t.Setenv("DD_APPSEC_RULES", "../../../internal/appsec/testdata/rasp.json")
+	appsec.Start()
+	defer appsec.Stop()
+	if !appsec.Enabled() {
+		t.Skip("AppSec needs to be enabled for this test")
+	}
+
+	d := &internal.MockDriver{}
+	Register("test", d)
+	defer unregister("test")
+	db, err := Open("test", "dn")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// The /user endpoint builds its query out of the name query parameter, without escaping it.
+	mux := httptrace.NewServeMux()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		rows, err := db.QueryContext(r.Context(), "SELECT * FROM users WHERE name = '"+r.URL.Query().Get("name")+"'")
+		var blocked *httpsec.MonitoringError
+		if errors.As(err, &blocked) {
+			return
+		}
+		require.NoError(t, err)
+		rows.Close()
+		w.Write([]byte("Hello World!\n"))
+	})
+
+	for _, tc := range []struct {
+		name   string
+		user   string
+		status int
+	}{
+		{name: "block/tautology", user: "' OR '1'='1", status: 403},
+		{name: "block/stacked", user: "'; DROP TABLE users --", status: 403},
+		{name: "no-block", user: "O'Brien", status: 200},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+			d.Executed = nil
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/user?name="+url.QueryEscape(tc.user), nil)
+			mux.ServeHTTP(rec, req)
+			require.Equal(t, tc.status, rec.Code)
+
+			spans := mt.FinishedSpans()
+			server := spans[len(spans)-1]
+			if tc.status == 403 {
+				// The query never reached the database.
+				require.Empty(t, d.Executed)
+				require.Contains(t, server.Tag("_dd.appsec.json"), "rasp-942-100")
+			} else {
+				require.Len(t, d.Executed, 1)
+				require.Nil(t, server.Tag("_dd.appsec.json"))
+			}
+		})
+	}
+}
@@ -14,6 +14,8 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/sqlsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 )
 
@@ -45,6 +47,7 @@ const (
 
 const (
 	keyDBMTraceInjected = "_dd.dbm_trace_injected"
+	keyRowsAffected     = "db.result.rows_affected"
 )
 
 // TracedConn holds a traced connection with tracing parameters.
@@ -99,6 +102,9 @@ func (tc *TracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (tx dr
 // The provided context is used for the preparation of the statement, not for the
 // execution of the statement.
 func (tc *TracedConn) PrepareContext(ctx context.Context, query string) (stmt driver.Stmt, err error) {
+	if err := tc.protect(ctx, query); err != nil {
+		return nil, err
+	}
 	start := time.Now()
 	mode := tc.cfg.dbmPropagationMode
 	if mode == tracer.DBMPropagationModeFull {
@@ -114,7 +120,7 @@ func (tc *TracedConn) PrepareContext(ctx context.Context, query string) (stmt dr
 		if err != nil {
 			return nil, err
 		}
-		return &tracedStmt{Stmt: stmt, traceParams: tc.traceParams, ctx: ctx, query: query}, nil
+		return &tracedStmt{Stmt: stmt, traceParams: tc.traceParams, ctx: ctx, query: query, conn: tc.Conn}, nil
 	}
 	ctx, end := startTraceTask(ctx, QueryTypePrepare)
 	defer end()
@@ -123,19 +129,22 @@ func (tc *TracedConn) PrepareContext(ctx context.Context, query string) (stmt dr
 	if err != nil {
 		return nil, err
 	}
-	return &tracedStmt{Stmt: stmt, traceParams: tc.traceParams, ctx: ctx, query: query}, nil
+	return &tracedStmt{Stmt: stmt, traceParams: tc.traceParams, ctx: ctx, query: query, conn: tc.Conn}, nil
 }
 
 // ExecContext executes a query without returning any rows.
 // The args are for any placeholder parameters in the query.
 func (tc *TracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (r driver.Result, err error) {
+	if err := tc.protect(ctx, query); err != nil {
+		return nil, err
+	}
 	start := time.Now()
 	if execContext, ok := tc.Conn.(driver.ExecerContext); ok {
 		cquery, spanID := tc.injectComments(ctx, query, tc.cfg.dbmPropagationMode)
 		ctx, end := startTraceTask(ctx, QueryTypeExec)
 		defer end()
 		r, err := execContext.ExecContext(ctx, cquery, args)
-		tc.tryTrace(ctx, QueryTypeExec, query, start, err, append(withDBMTraceInjectedTag(tc.cfg.dbmPropagationMode), tracer.WithSpanID(spanID))...)
+		tc.tryTrace(ctx, QueryTypeExec, query, start, err, append(withRowsAffectedTag(r, err, withDBMTraceInjectedTag(tc.cfg.dbmPropagationMode)), tracer.WithSpanID(spanID))...)
 		return r, err
 	}
 	if execer, ok := tc.Conn.(driver.Execer); ok {
@@ -152,7 +161,7 @@ func (tc *TracedConn) ExecContext(ctx context.Context, query string, args []driv
 		ctx, end := startTraceTask(ctx, QueryTypeExec)
 		defer end()
 		r, err = execer.Exec(cquery, dargs)
-		tc.tryTrace(ctx, QueryTypeExec, query, start, err, append(withDBMTraceInjectedTag(tc.cfg.dbmPropagationMode), tracer.WithSpanID(spanID))...)
+		tc.tryTrace(ctx, QueryTypeExec, query, start, err, append(withRowsAffectedTag(r, err, withDBMTraceInjectedTag(tc.cfg.dbmPropagationMode)), tracer.WithSpanID(spanID))...)
 		return r, err
 	}
 	return nil, driver.ErrSkip
@@ -173,6 +182,9 @@ func (tc *TracedConn) Ping(ctx context.Context) (err error) {
 // QueryContext executes a query that returns rows, typically a SELECT.
 // The args are for any placeholder parameters in the query.
 func (tc *TracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (rows driver.Rows, err error) {
+	if err := tc.protect(ctx, query); err != nil {
+		return nil, err
+	}
 	start := time.Now()
 	if queryerContext, ok := tc.Conn.(driver.QueryerContext); ok {
 		cquery, spanID := tc.injectComments(ctx, query, tc.cfg.dbmPropagationMode)
@@ -267,6 +279,29 @@ func withDBMTraceInjectedTag(mode tracer.DBMPropagationMode) []tracer.StartSpanO
 	return nil
 }
 
+// withRowsAffectedTag appends to opts the tag holding the number of rows
+// affected by an execution, when the driver reports it.
+func withRowsAffectedTag(r driver.Result, err error, opts []tracer.StartSpanOption) []tracer.StartSpanOption {
+	if err != nil || r == nil {
+		return opts
+	}
+	n, err := r.RowsAffected()
+	if err != nil {
+		return opts
+	}
+	return append(opts, tracer.Tag(keyRowsAffected, n))
+}
+
+// protect runs the AppSec RASP (Runtime Application Self-Protection) checks on the query about to be sent to the
+// driver. It returns an error when the query must be blocked, in which case it must not be executed.
+func (tp *traceParams) protect(ctx context.Context, query string) error {
+	if !appsec.Enabled() {
+		return nil
+	}
+	dbSystem, _ := normalizeDBSystem(tp.driverName)
+	return sqlsec.ProtectSQLOperation(ctx, query, dbSystem)
+}
+
 // tryTrace will create a span using the given arguments, but will act as a no-op when err is driver.ErrSkip.
 func (tp *traceParams) tryTrace(ctx context.Context, qtype QueryType, query string, startTime time.Time, err error, spanOpts ...ddtrace.StartSpanOption) {
 	if err == driver.ErrSkip {
@@ -305,6 +340,9 @@ func (tp *traceParams) tryTrace(ctx context.Context, qtype QueryType, query stri
 	resource := string(qtype)
 	if query != "" {
 		resource = query
+		if !tp.cfg.rawQuery {
+			resource = obfuscateQuery(query, string(qtype))
+		}
 	}
 	span.SetTag("sql.query_type", string(qtype))
 	span.SetTag(ext.ResourceName, resource)
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"testing"
 
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/database/sql/internal"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
 
@@ -381,3 +382,64 @@ func TestWithCustomTag(t *testing.T) {
 		})
 	}
 }
+
+func TestWithRawQuery(t *testing.T) {
+	query := "SELECT name FROM users WHERE id = 42 AND email = 'jane@example.com'"
+	for name, tc := range map[string]struct {
+		opts     []Option
+		resource string
+	}{
+		"default":    {resource: query},
+		"raw":        {opts: []Option{WithRawQuery(true)}, resource: query},
+		"obfuscated": {opts: []Option{WithRawQuery(false)}, resource: "SELECT name FROM users WHERE id = ? AND email = ?"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			Register("rawquery", &internal.MockDriver{})
+			defer unregister("rawquery")
+			db, err := Open("rawquery", "", tc.opts...)
+			require.NoError(t, err)
+			defer db.Close()
+
+			_, err = db.QueryContext(context.Background(), query)
+			require.NoError(t, err)
+
+			spans := spansOfType(mt.FinishedSpans(), string(QueryTypeQuery))
+			require.Len(t, spans, 1)
+			assert.Equal(t, tc.resource, spans[0].Tag(ext.ResourceName))
+		})
+	}
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("DD_TRACE_SQL_CLIENT_OBFUSCATION_ENABLED", "true")
+		cfg := new(config)
+		defaults(cfg, "rawquery", nil)
+		assert.False(t, cfg.rawQuery)
+	})
+}
+
+func TestRowsAffected(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	Register("rowsaffected", &internal.MockDriver{})
+	defer unregister("rowsaffected")
+	db, err := Open("rowsaffected", "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.ExecContext(context.Background(), "DELETE FROM users")
+	require.NoError(t, err)
+	stmt, err := db.Prepare("DELETE FROM users")
+	require.NoError(t, err)
+	_, err = stmt.Exec()
+	require.NoError(t, err)
+
+	spans := spansOfType(mt.FinishedSpans(), string(QueryTypeExec))
+	require.Len(t, spans, 2)
+	for _, s := range spans {
+		assert.Equal(t, int64(0), s.Tag(keyRowsAffected))
+	}
+}
@@ -0,0 +1,127 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package sql
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+)
+
+// Names of the connection pool metrics reported when WithDBStats is used.
+const (
+	metricPoolMaxOpen           = "db.pool.max_open"
+	metricPoolOpen              = "db.pool.open"
+	metricPoolInUse             = "db.pool.in_use"
+	metricPoolIdle              = "db.pool.idle"
+	metricPoolWaitCount         = "db.pool.wait_count"
+	metricPoolWaitDuration      = "db.pool.wait_duration"
+	metricPoolClosedMaxIdle     = "db.pool.closed.max_idle"
+	metricPoolClosedMaxIdleTime = "db.pool.closed.max_idle_time"
+	metricPoolClosedMaxLifetime = "db.pool.closed.max_lifetime"
+)
+
+// dbStatsInterval is the interval at which the connection pool metrics are
+// reported.
+var dbStatsInterval = 10 * time.Second
+
+// statsdClient is the subset of the statsd client methods used to report the
+// connection pool metrics.
+type statsdClient interface {
+	Gauge(name string, value float64, tags []string, rate float64) error
+	Close() error
+}
+
+// newStatsdClient returns a client sending metrics to the DogStatsD server at
+// addr. It is replaced in tests.
+var newStatsdClient = func(addr string) (statsdClient, error) {
+	return statsd.New(addr)
+}
+
+// dbStatsPoller periodically reports the statistics of the connection pool of
+// a database as metrics.
+type dbStatsPoller struct {
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// startDBStatsPoller starts reporting the connection pool statistics of db,
+// with the given tags, until the returned poller is stopped.
+func startDBStatsPoller(db *sql.DB, tags []string) *dbStatsPoller {
+	p := &dbStatsPoller{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go p.run(db, tags)
+	return p
+}
+
+func (p *dbStatsPoller) run(db *sql.DB, tags []string) {
+	defer close(p.done)
+	tick := time.NewTicker(dbStatsInterval)
+	defer tick.Stop()
+	var client statsdClient
+	defer func() {
+		if client != nil {
+			client.Close()
+		}
+	}()
+	for {
+		select {
+		case <-tick.C:
+			if client == nil {
+				// the address is only known once the tracer is started,
+				// which may happen after the database is opened.
+				addr := globalconfig.DogstatsdAddr()
+				if addr == "" {
+					continue
+				}
+				c, err := newStatsdClient(addr)
+				if err != nil {
+					log.Warn("contrib/database/sql: failed to create statsd client: %v", err)
+					continue
+				}
+				client = c
+			}
+			reportDBStats(client, db.Stats(), tags)
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// Stop stops reporting the metrics, and waits until the poller has returned.
+func (p *dbStatsPoller) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+	<-p.done
+}
+
+// reportDBStats sends the connection pool statistics as gauges.
+func reportDBStats(client statsdClient, stats sql.DBStats, tags []string) {
+	for name, value := range map[string]float64{
+		metricPoolMaxOpen:           float64(stats.MaxOpenConnections),
+		metricPoolOpen:              float64(stats.OpenConnections),
+		metricPoolInUse:             float64(stats.InUse),
+		metricPoolIdle:              float64(stats.Idle),
+		metricPoolWaitCount:         float64(stats.WaitCount),
+		metricPoolWaitDuration:      float64(stats.WaitDuration.Milliseconds()),
+		metricPoolClosedMaxIdle:     float64(stats.MaxIdleClosed),
+		metricPoolClosedMaxIdleTime: float64(stats.MaxIdleTimeClosed),
+		metricPoolClosedMaxLifetime: float64(stats.MaxLifetimeClosed),
+	} {
+		if err := client.Gauge(name, value, tags, 1); err != nil {
+			log.Debug("contrib/database/sql: failed to report %s: %v", name, err)
+		}
+	}
+}
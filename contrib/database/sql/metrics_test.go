@@ -0,0 +1,115 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package sql
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/database/sql/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type gauge struct {
+	value float64
+	tags  []string
+}
+
+type testStatsdClient struct {
+	mu     sync.Mutex
+	gauges map[string]gauge
+	closed bool
+}
+
+func (c *testStatsdClient) Gauge(name string, value float64, tags []string, _ float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gauges == nil {
+		c.gauges = make(map[string]gauge)
+	}
+	c.gauges[name] = gauge{value: value, tags: tags}
+	return nil
+}
+
+func (c *testStatsdClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *testStatsdClient) gauge(name string) (gauge, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	g, ok := c.gauges[name]
+	return g, ok
+}
+
+func TestReportDBStats(t *testing.T) {
+	var c testStatsdClient
+	reportDBStats(&c, sql.DBStats{
+		MaxOpenConnections: 10,
+		OpenConnections:    4,
+		InUse:              3,
+		Idle:               1,
+		WaitCount:          7,
+		WaitDuration:       2 * time.Second,
+		MaxLifetimeClosed:  2,
+	}, []string{"db.system:postgresql"})
+
+	for name, want := range map[string]float64{
+		metricPoolMaxOpen:           10,
+		metricPoolOpen:              4,
+		metricPoolInUse:             3,
+		metricPoolIdle:              1,
+		metricPoolWaitCount:         7,
+		metricPoolWaitDuration:      2000,
+		metricPoolClosedMaxIdle:     0,
+		metricPoolClosedMaxIdleTime: 0,
+		metricPoolClosedMaxLifetime: 2,
+	} {
+		g, ok := c.gauge(name)
+		require.True(t, ok, name)
+		assert.Equal(t, want, g.value, name)
+		assert.Equal(t, []string{"db.system:postgresql"}, g.tags)
+	}
+}
+
+func TestWithDBStats(t *testing.T) {
+	defer func(interval time.Duration) { dbStatsInterval = interval }(dbStatsInterval)
+	dbStatsInterval = time.Millisecond
+	defer func(fn func(string) (statsdClient, error)) { newStatsdClient = fn }(newStatsdClient)
+	c := &testStatsdClient{}
+	newStatsdClient = func(addr string) (statsdClient, error) {
+		assert.Equal(t, "localhost:8125", addr)
+		return c, nil
+	}
+	globalconfig.SetDogstatsdAddr("localhost:8125")
+	defer globalconfig.SetDogstatsdAddr("")
+
+	Register("dbstats", &internal.MockDriver{})
+	defer unregister("dbstats")
+	db, err := Open("dbstats", "", WithDBStats(), WithServiceName("my-db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(5)
+
+	assert.Eventually(t, func() bool {
+		g, ok := c.gauge(metricPoolMaxOpen)
+		return ok && g.value == 5
+	}, time.Second, time.Millisecond)
+	g, _ := c.gauge(metricPoolMaxOpen)
+	assert.Equal(t, []string{"db.system:other_sql", "service:my-db"}, g.tags)
+
+	require.NoError(t, db.Close())
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	assert.True(t, c.closed)
+}
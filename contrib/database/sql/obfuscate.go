@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package sql
+
+import (
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+
+	"github.com/DataDog/datadog-agent/pkg/obfuscate"
+)
+
+var (
+	obfuscatorOnce sync.Once
+	obfuscator     *obfuscate.Obfuscator
+)
+
+// obfuscateQuery returns query with its literals replaced with "?", as done by
+// the agent. It returns fallback when the query can't be parsed, so that
+// unparsable queries never leak in the resource names.
+func obfuscateQuery(query, fallback string) string {
+	obfuscatorOnce.Do(func() {
+		obfuscator = obfuscate.NewObfuscator(obfuscate.Config{})
+	})
+	oq, err := obfuscator.ObfuscateSQLString(query)
+	if err != nil {
+		log.Debug("contrib/database/sql: failed to obfuscate query: %v", err)
+		return fallback
+	}
+	return oq.Query
+}
@@ -25,6 +25,9 @@ type config struct {
 	errCheck           func(err error) bool
 	tags               map[string]interface{}
 	dbmPropagationMode tracer.DBMPropagationMode
+	dbmTracePrepared   bool
+	dbStats            bool
+	rawQuery           bool
 }
 
 // Option represents an option that can be passed to Register, Open or OpenDB.
@@ -47,6 +50,8 @@ func defaults(cfg *config, driverName string, rc *registerConfig) {
 		mode = os.Getenv("DD_TRACE_SQL_COMMENT_INJECTION_MODE")
 	}
 	cfg.dbmPropagationMode = tracer.DBMPropagationMode(mode)
+	cfg.dbmTracePrepared = internal.BoolEnv("DD_DBM_TRACE_PREPARED_STATEMENTS", false)
+	cfg.rawQuery = !internal.BoolEnv("DD_TRACE_SQL_CLIENT_OBFUSCATION_ENABLED", false)
 	cfg.serviceName = getServiceName(driverName, rc)
 	cfg.spanName = getSpanName(driverName)
 	if rc != nil {
@@ -60,6 +65,13 @@ func defaults(cfg *config, driverName string, rc *registerConfig) {
 		cfg.errCheck = rc.errCheck
 		cfg.ignoreQueryTypes = rc.ignoreQueryTypes
 		cfg.childSpansOnly = rc.childSpansOnly
+		cfg.dbStats = rc.dbStats
+		if !cfg.dbmTracePrepared {
+			cfg.dbmTracePrepared = rc.dbmTracePrepared
+		}
+		if cfg.rawQuery {
+			cfg.rawQuery = rc.rawQuery
+		}
 	}
 }
 
@@ -188,3 +200,51 @@ func WithDBMPropagation(mode tracer.DBMPropagationMode) Option {
 		cfg.dbmPropagationMode = mode
 	}
 }
+
+// WithDBMTracePreparedStatements enables the propagation of the trace context
+// of the executions of prepared statements, when the DBM propagation mode is
+// full. The text of a prepared statement is fixed when it is prepared, so it
+// only holds the service information. With this option, the session parameter
+// application_name is set to the traceparent of each execution right before
+// it runs, allowing Database Monitoring to link it to its span. It is only
+// supported with PostgreSQL, and costs a round trip per execution. It can also
+// be enabled by setting DD_DBM_TRACE_PREPARED_STATEMENTS to true.
+func WithDBMTracePreparedStatements(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.dbmTracePrepared = enabled
+	}
+}
+
+// WithDBStats enables the periodic reporting of the statistics of the
+// connection pool of the database, as returned by sql.DB.Stats, to the DogStatsD
+// server used by the tracer. The following gauges are reported every 10 seconds,
+// tagged with the db.system and the service, until the database is closed:
+//
+//	db.pool.max_open                 maximum number of open connections
+//	db.pool.open                     number of established connections
+//	db.pool.in_use                   number of connections in use
+//	db.pool.idle                     number of idle connections
+//	db.pool.wait_count               total number of connections waited for
+//	db.pool.wait_duration            total time blocked waiting for a connection, in milliseconds
+//	db.pool.closed.max_idle          total number of connections closed due to SetMaxIdleConns
+//	db.pool.closed.max_idle_time     total number of connections closed due to SetConnMaxIdleTime
+//	db.pool.closed.max_lifetime      total number of connections closed due to SetConnMaxLifetime
+//
+// An increasing wait count and wait duration are a sign of pool exhaustion.
+func WithDBStats() Option {
+	return func(cfg *config) {
+		cfg.dbStats = true
+	}
+}
+
+// WithRawQuery sets whether the resource names of the spans are the raw
+// queries, as they were sent to the database, which is the default. When
+// set to false, the queries are obfuscated before being set as resource
+// names: their literals are replaced with "?", so that sensitive values
+// never leave the application. Client-side obfuscation can also be enabled
+// by setting DD_TRACE_SQL_CLIENT_OBFUSCATION_ENABLED to true.
+func WithRawQuery(raw bool) Option {
+	return func(cfg *config) {
+		cfg.rawQuery = raw
+	}
+}
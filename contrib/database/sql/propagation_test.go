@@ -8,7 +8,9 @@ package sql
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"regexp"
+	"strings"
 	"testing"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/contrib/database/sql/internal"
@@ -297,3 +299,67 @@ func spansOfType(spans []mocktracer.Span, spanType string) (filtered []mocktrace
 	}
 	return filtered
 }
+
+func TestDBMTracePreparedStatements(t *testing.T) {
+	testCases := []struct {
+		name     string
+		driver   string
+		opts     []RegisterOption
+		injected bool
+	}{
+		{
+			name:     "postgres",
+			driver:   "postgres",
+			opts:     []RegisterOption{WithDBMPropagation(tracer.DBMPropagationModeFull), WithDBMTracePreparedStatements(true)},
+			injected: true,
+		},
+		{
+			name:   "disabled",
+			driver: "postgres",
+			opts:   []RegisterOption{WithDBMPropagation(tracer.DBMPropagationModeFull)},
+		},
+		{
+			name:   "service-mode",
+			driver: "postgres",
+			opts:   []RegisterOption{WithDBMPropagation(tracer.DBMPropagationModeService), WithDBMTracePreparedStatements(true)},
+		},
+		{
+			name:   "not-postgres",
+			driver: "mysql",
+			opts:   []RegisterOption{WithDBMPropagation(tracer.DBMPropagationModeFull), WithDBMTracePreparedStatements(true)},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := mocktracer.Start()
+			defer tr.Stop()
+
+			d := &internal.MockDriver{}
+			Register(tc.driver, d, tc.opts...)
+			defer unregister(tc.driver)
+
+			db, err := Open(tc.driver, "dn")
+			require.NoError(t, err)
+			s, ctx := tracer.StartSpanFromContext(context.Background(), "test.call")
+			stmt, err := db.PrepareContext(ctx, "SELECT 1")
+			require.NoError(t, err)
+			_, err = stmt.ExecContext(ctx)
+			require.NoError(t, err)
+			s.Finish()
+
+			spans := spansOfType(tr.FinishedSpans(), string(QueryTypeExec))
+			require.Len(t, spans, 1)
+			if !tc.injected {
+				require.Len(t, d.Executed, 1)
+				assert.True(t, strings.HasSuffix(d.Executed[0], "SELECT 1"))
+				assert.Nil(t, spans[0].Tag(keyDBMTraceInjected))
+				return
+			}
+			require.Len(t, d.Executed, 2)
+			// the propagated span ID is the one of the execution span
+			assert.Regexp(t, fmt.Sprintf("^SET application_name = '_DD_00-[\\da-f]{32}-%016x-0[01]'$", spans[0].SpanID()), d.Executed[0])
+			assert.True(t, strings.HasSuffix(d.Executed[1], "SELECT 1"))
+			assert.Equal(t, true, spans[0].Tag(keyDBMTraceInjected))
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"io"
 	"reflect"
 	"sync"
 	"time"
@@ -139,6 +140,7 @@ type tracedConnector struct {
 	connector  driver.Connector
 	driverName string
 	cfg        *config
+	dbStats    *dbStatsPoller // nil unless WithDBStats is used
 }
 
 func (t *tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
@@ -166,6 +168,19 @@ func (t *tracedConnector) Driver() driver.Driver {
 	return t.connector.Driver()
 }
 
+// Close implements io.Closer. It is called by sql.DB.Close, and stops the
+// reporting of the connection pool metrics before closing the wrapped
+// connector, if it implements io.Closer.
+func (t *tracedConnector) Close() error {
+	if t.dbStats != nil {
+		t.dbStats.Stop()
+	}
+	if c, ok := t.connector.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
 // from Go stdlib implementation of sql.Open
 type dsnConnector struct {
 	dsn    string
@@ -202,7 +217,15 @@ func OpenDB(c driver.Connector, opts ...Option) *sql.DB {
 		driverName: driverName,
 		cfg:        cfg,
 	}
-	return sql.OpenDB(tc)
+	db := sql.OpenDB(tc)
+	if cfg.dbStats {
+		dbSystem, _ := normalizeDBSystem(driverName)
+		tc.dbStats = startDBStatsPoller(db, []string{
+			"db.system:" + dbSystem,
+			"service:" + cfg.serviceName,
+		})
+	}
+	return db
 }
 
 // Open returns connection to a DB using the traced version of the given driver. The driver may
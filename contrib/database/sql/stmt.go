@@ -10,6 +10,11 @@ import (
 	"database/sql/driver"
 	"errors"
 	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 )
 
 var _ driver.Stmt = (*tracedStmt)(nil)
@@ -20,6 +25,7 @@ type tracedStmt struct {
 	*traceParams
 	ctx   context.Context
 	query string
+	conn  driver.Conn // the connection the statement was prepared on
 }
 
 // Close sends a span before closing a statement
@@ -38,8 +44,9 @@ func (s *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue)
 	if stmtExecContext, ok := s.Stmt.(driver.StmtExecContext); ok {
 		ctx, end := startTraceTask(s.ctx, QueryTypeExec)
 		defer end()
+		opts := s.injectTraceContext(ctx)
 		res, err := stmtExecContext.ExecContext(ctx, args)
-		s.tryTrace(ctx, QueryTypeExec, s.query, start, err)
+		s.tryTrace(ctx, QueryTypeExec, s.query, start, err, withRowsAffectedTag(res, err, opts)...)
 		return res, err
 	}
 	dargs, err := namedValueToValue(args)
@@ -53,8 +60,9 @@ func (s *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue)
 	}
 	ctx, end := startTraceTask(s.ctx, QueryTypeExec)
 	defer end()
+	opts := s.injectTraceContext(ctx)
 	res, err = s.Exec(dargs)
-	s.tryTrace(ctx, QueryTypeExec, s.query, start, err)
+	s.tryTrace(ctx, QueryTypeExec, s.query, start, err, withRowsAffectedTag(res, err, opts)...)
 	return res, err
 }
 
@@ -64,8 +72,9 @@ func (s *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue)
 	if stmtQueryContext, ok := s.Stmt.(driver.StmtQueryContext); ok {
 		ctx, end := startTraceTask(s.ctx, QueryTypeQuery)
 		defer end()
+		opts := s.injectTraceContext(ctx)
 		rows, err := stmtQueryContext.QueryContext(ctx, args)
-		s.tryTrace(ctx, QueryTypeQuery, s.query, start, err)
+		s.tryTrace(ctx, QueryTypeQuery, s.query, start, err, opts...)
 		return rows, err
 	}
 	dargs, err := namedValueToValue(args)
@@ -79,11 +88,42 @@ func (s *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue)
 	}
 	ctx, end := startTraceTask(s.ctx, QueryTypeQuery)
 	defer end()
+	opts := s.injectTraceContext(ctx)
 	rows, err = s.Query(dargs)
-	s.tryTrace(ctx, QueryTypeQuery, s.query, start, err)
+	s.tryTrace(ctx, QueryTypeQuery, s.query, start, err, opts...)
 	return rows, err
 }
 
+// injectTraceContext propagates the trace context of the execution of the
+// statement to the database, when WithDBMTracePreparedStatements is enabled,
+// by setting it as the application_name of the PostgreSQL session. It returns
+// the options of the span of the execution, whose ID is the one propagated.
+func (s *tracedStmt) injectTraceContext(ctx context.Context) []ddtrace.StartSpanOption {
+	if !s.cfg.dbmTracePrepared || s.cfg.dbmPropagationMode != tracer.DBMPropagationModeFull {
+		return nil
+	}
+	if dbSystem, _ := normalizeDBSystem(s.driverName); dbSystem != ext.DBSystemPostgreSQL {
+		return nil
+	}
+	execer, ok := s.conn.(driver.ExecerContext)
+	if !ok {
+		return nil
+	}
+	var spanCtx ddtrace.SpanContext
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		spanCtx = span.Context()
+	}
+	carrier := tracer.SQLCommentCarrier{Mode: tracer.DBMPropagationModeFull, DBServiceName: s.cfg.serviceName}
+	if err := carrier.Inject(spanCtx); err != nil || carrier.TraceParent == "" {
+		return nil
+	}
+	if _, err := execer.ExecContext(ctx, "SET application_name = '_DD_"+carrier.TraceParent+"'", nil); err != nil {
+		log.Debug("contrib/database/sql: failed to set the trace context of a prepared statement: %v", err)
+		return nil
+	}
+	return append(withDBMTraceInjectedTag(tracer.DBMPropagationModeFull), tracer.WithSpanID(carrier.SpanID))
+}
+
 // copied from stdlib database/sql package: src/database/sql/ctxutil.go
 func namedValueToValue(named []driver.NamedValue) ([]driver.Value, error) {
 	dargs := make([]driver.Value, len(named))
@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package client provides functions to trace the Docker Engine API client of
+// the docker/docker package (https://pkg.go.dev/github.com/docker/docker/client).
+//
+// The transport of the HTTP client used by the Docker client is wrapped, so
+// that every call to the Docker Engine API is traced by a span tagged with the
+// operation, such as container.start or image.pull, the identifier of the
+// container or image it applies to, and the version of the API.
+package client // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/docker/docker/client"
+
+import (
+	"net/http"
+
+	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+)
+
+const componentName = "docker/docker/client"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+const defaultServiceName = "docker"
+
+// WrapRoundTripper wraps a RoundTripper used to call the Docker Engine API so
+// that all the calls are traced. The given options override the defaults of
+// the integration, such as the docker service name.
+func WrapRoundTripper(rt http.RoundTripper, opts ...httptrace.RoundTripperOption) http.RoundTripper {
+	serviceName := namingschema.NewDefaultServiceName(
+		defaultServiceName,
+		namingschema.WithOverrideV0(defaultServiceName),
+	).GetName()
+	spanName := namingschema.NewClientOutboundOp("docker").GetName()
+	opts = append([]httptrace.RoundTripperOption{
+		httptrace.RTWithServiceName(serviceName),
+		httptrace.RTWithSpanNamer(func(*http.Request) string { return spanName }),
+		httptrace.RTWithResourceNamer(func(req *http.Request) string {
+			if r, ok := parseAPIRequest(req); ok {
+				return r.operation
+			}
+			return req.Method + " " + req.URL.Path
+		}),
+	}, opts...)
+	opts = append(opts, httptrace.WithBefore(func(req *http.Request, span ddtrace.Span) {
+		span.SetTag(ext.Component, componentName)
+		span.SetTag(ext.SpanKind, ext.SpanKindClient)
+		r, ok := parseAPIRequest(req)
+		if !ok {
+			return
+		}
+		span.SetTag(tagOperation, r.operation)
+		if r.version != "" {
+			span.SetTag(tagAPIVersion, r.version)
+		}
+		if r.idTag != "" && r.id != "" {
+			span.SetTag(r.idTag, r.id)
+		}
+		if r.containerName != "" {
+			span.SetTag(tagContainerName, r.containerName)
+		}
+	}))
+	log.Debug("contrib/docker/docker/client: Wrapping RoundTripper.")
+	return httptrace.WrapRoundTripper(rt, opts...)
+}
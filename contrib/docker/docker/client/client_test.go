@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer s.Close()
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	cli, err := client.NewClientWithOpts(
+		client.WithHost("tcp://"+s.Listener.Addr().String()),
+		client.WithHTTPClient(&http.Client{Transport: WrapRoundTripper(http.DefaultTransport)}),
+		client.WithVersion("1.43"),
+	)
+	require.NoError(t, err)
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "root")
+	require.NoError(t, cli.ContainerStop(ctx, "3f4e", container.StopOptions{}))
+	root.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	span := spans[0]
+	assert.Equal(t, "docker.request", span.OperationName())
+	assert.Equal(t, "container.stop", span.Tag(ext.ResourceName))
+	assert.Equal(t, defaultServiceName, span.Tag(ext.ServiceName))
+	assert.Equal(t, "container.stop", span.Tag(tagOperation))
+	assert.Equal(t, "3f4e", span.Tag(tagContainerID))
+	assert.Equal(t, "1.43", span.Tag(tagAPIVersion))
+	assert.Equal(t, "204", span.Tag(ext.HTTPCode))
+	assert.Equal(t, componentName, span.Tag(ext.Component))
+	assert.Equal(t, ext.SpanKindClient, span.Tag(ext.SpanKind))
+	assert.Equal(t, root.Context().SpanID(), span.ParentID())
+}
+
+func TestServiceNameOverride(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+	defer s.Close()
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := &http.Client{Transport: WrapRoundTripper(http.DefaultTransport, httptrace.RTWithServiceName("ci-docker"))}
+	resp, err := c.Get(s.URL + "/_ping")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "ci-docker", spans[0].Tag(ext.ServiceName))
+	assert.Equal(t, "system.ping", spans[0].Tag(ext.ResourceName))
+}
@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package client_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	dockertrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/docker/docker/client"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+func Example() {
+	// The transport of the HTTP client can't be configured by the Docker
+	// client once wrapped, so it dials the Docker socket itself.
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", "/var/run/docker.sock")
+		},
+	}
+	cli, err := client.NewClientWithOpts(
+		client.WithHTTPClient(&http.Client{Transport: dockertrace.WrapRoundTripper(transport)}),
+		client.WithAPIVersionNegotiation(),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer cli.Close()
+
+	// Traced by a span with the image.pull resource.
+	rc, err := cli.ImagePull(context.Background(), "docker.io/library/alpine:3.19", types.ImagePullOptions{})
+	if err != nil {
+		panic(err)
+	}
+	rc.Close()
+}
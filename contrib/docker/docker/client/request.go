@@ -0,0 +1,135 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package client
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Tags describing the calls made to the Docker Engine API.
+const (
+	tagOperation     = "docker.operation"
+	tagAPIVersion    = "docker.api_version"
+	tagContainerID   = "docker.container_id"
+	tagContainerName = "docker.container_name"
+	tagImage         = "docker.image"
+	tagExecID        = "docker.exec_id"
+)
+
+// imageActions holds the actions on an image, which are the last element of
+// the path since image names may contain slashes.
+var imageActions = map[string]bool{
+	"json":    true,
+	"history": true,
+	"push":    true,
+	"tag":     true,
+	"get":     true,
+}
+
+// apiRequest describes a call to the Docker Engine API.
+// See https://docs.docker.com/engine/api/latest/.
+type apiRequest struct {
+	// operation is the object the call applies to followed by the action,
+	// e.g. container.start.
+	operation string
+	version   string
+	// idTag is the tag holding id, the identifier of the object.
+	idTag         string
+	id            string
+	containerName string
+}
+
+// parseAPIRequest parses the path of req. It returns false if req is not a
+// call to the Docker Engine API.
+func parseAPIRequest(req *http.Request) (apiRequest, bool) {
+	var r apiRequest
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts[0]) > 1 && parts[0][0] == 'v' && strings.Contains(parts[0], ".") {
+		// /v{version}/...
+		r.version, parts = parts[0][1:], parts[1:]
+	}
+	if len(parts) == 0 || parts[0] == "" {
+		return r, false
+	}
+	kind := parts[0]
+	object := strings.TrimSuffix(kind, "s")
+	switch kind {
+	case "containers":
+		r.idTag = tagContainerID
+	case "images":
+		r.idTag = tagImage
+	case "exec":
+		r.idTag = tagExecID
+	case "build":
+		r.operation = "image.build"
+		return r, true
+	}
+	if len(parts) == 1 {
+		// System calls, such as /_ping, /version, /info or /events.
+		r.operation = "system." + strings.TrimPrefix(kind, "_")
+		return r, true
+	}
+	rest := parts[1:]
+	switch {
+	case len(rest) == 1 && rest[0] == "json":
+		r.operation = object + ".list"
+	case len(rest) == 1 && rest[0] == "create":
+		r.operation = object + ".create"
+		if kind == "containers" {
+			r.containerName = req.URL.Query().Get("name")
+		}
+		if kind == "images" {
+			// Images are created by pulling or importing them.
+			q := req.URL.Query()
+			if image := q.Get("fromImage"); image != "" {
+				r.operation = "image.pull"
+				if tag := q.Get("tag"); tag != "" {
+					image += ":" + tag
+				}
+				r.id = image
+			} else {
+				r.operation = "image.import"
+			}
+		}
+	case len(rest) == 1 && (rest[0] == "prune" || rest[0] == "search" || rest[0] == "load"):
+		r.operation = object + "." + rest[0]
+	case kind == "images":
+		action := rest[len(rest)-1]
+		if len(rest) > 1 && imageActions[action] {
+			rest = rest[:len(rest)-1]
+		} else {
+			action = ""
+		}
+		r.id = strings.Join(rest, "/")
+		r.operation = object + "." + methodAction(req.Method, action)
+	default:
+		r.id = rest[0]
+		action := ""
+		if len(rest) > 1 {
+			action = rest[1]
+		}
+		r.operation = object + "." + methodAction(req.Method, action)
+	}
+	return r, true
+}
+
+// methodAction returns the name of the action made with the given method on
+// an object, where action is the last element of the path, if any.
+func methodAction(method, action string) string {
+	switch {
+	case action == "json":
+		return "inspect"
+	case action != "":
+		return action
+	case method == http.MethodDelete:
+		return "remove"
+	case method == http.MethodGet:
+		return "inspect"
+	default:
+		return strings.ToLower(method)
+	}
+}
@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAPIRequest(t *testing.T) {
+	for _, tc := range []struct {
+		method, url string
+		want        apiRequest
+	}{
+		{"GET", "/_ping", apiRequest{operation: "system.ping"}},
+		{"GET", "/v1.43/info", apiRequest{operation: "system.info", version: "1.43"}},
+		{"GET", "/v1.43/containers/json?all=1", apiRequest{operation: "container.list", version: "1.43", idTag: tagContainerID}},
+		{"POST", "/v1.43/containers/create?name=web", apiRequest{operation: "container.create", version: "1.43", idTag: tagContainerID, containerName: "web"}},
+		{"POST", "/v1.43/containers/3f4e/start", apiRequest{operation: "container.start", version: "1.43", idTag: tagContainerID, id: "3f4e"}},
+		{"GET", "/v1.43/containers/3f4e/logs?follow=1", apiRequest{operation: "container.logs", version: "1.43", idTag: tagContainerID, id: "3f4e"}},
+		{"GET", "/v1.43/containers/3f4e/json", apiRequest{operation: "container.inspect", version: "1.43", idTag: tagContainerID, id: "3f4e"}},
+		{"DELETE", "/v1.43/containers/3f4e?force=1", apiRequest{operation: "container.remove", version: "1.43", idTag: tagContainerID, id: "3f4e"}},
+		{"POST", "/v1.43/images/create?fromImage=library/nginx&tag=1.25", apiRequest{operation: "image.pull", version: "1.43", idTag: tagImage, id: "library/nginx:1.25"}},
+		{"POST", "/v1.43/images/create?fromSrc=-", apiRequest{operation: "image.import", version: "1.43", idTag: tagImage}},
+		{"GET", "/v1.43/images/json", apiRequest{operation: "image.list", version: "1.43", idTag: tagImage}},
+		{"GET", "/v1.43/images/library/nginx:1.25/json", apiRequest{operation: "image.inspect", version: "1.43", idTag: tagImage, id: "library/nginx:1.25"}},
+		{"POST", "/v1.43/images/registry.example.com/app/push?tag=v1", apiRequest{operation: "image.push", version: "1.43", idTag: tagImage, id: "registry.example.com/app"}},
+		{"DELETE", "/v1.43/images/nginx", apiRequest{operation: "image.remove", version: "1.43", idTag: tagImage, id: "nginx"}},
+		{"POST", "/v1.43/build?t=app", apiRequest{operation: "image.build", version: "1.43"}},
+		{"POST", "/v1.43/exec/9a1b/start", apiRequest{operation: "exec.start", version: "1.43", idTag: tagExecID, id: "9a1b"}},
+		{"POST", "/v1.43/networks/net1/connect", apiRequest{operation: "network.connect", version: "1.43", id: "net1"}},
+		{"POST", "/v1.43/volumes/create", apiRequest{operation: "volume.create", version: "1.43"}},
+	} {
+		r, ok := parseAPIRequest(httptest.NewRequest(tc.method, tc.url, nil))
+		assert.True(t, ok, tc.url)
+		assert.Equal(t, tc.want, r, tc.url)
+	}
+
+	_, ok := parseAPIRequest(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.False(t, ok)
+}
@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package mqtt_test
+
+import (
+	"context"
+	"log"
+
+	mqtttrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/eclipse/paho.mqtt.golang"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+func Example() {
+	opts := mqtt.NewClientOptions().AddBroker("tcp://localhost:1883")
+	client := mqtttrace.WrapClient(mqtt.NewClient(opts), mqtttrace.WithServiceName("my-mqtt"))
+	if tok := client.Connect(); tok.Wait() && tok.Error() != nil {
+		log.Fatal(tok.Error())
+	}
+	defer client.Disconnect(250)
+
+	client.Subscribe("devices/+/telemetry", 1, func(_ mqtt.Client, m mqtt.Message) {
+		// the spans started from ctx are children of the span of the handling
+		// of the message.
+		ctx := mqtttrace.ContextWithMessage(context.Background(), m)
+		span, _ := tracer.StartSpanFromContext(ctx, "process.telemetry")
+		defer span.Finish()
+	}).Wait()
+
+	tok := client.Publish("devices/1/telemetry", 1, false, []byte(`{"temperature":21}`))
+	if tok.Wait() && tok.Error() != nil {
+		log.Fatal(tok.Error())
+	}
+}
@@ -0,0 +1,162 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package mqtt provides functions to trace the eclipse/paho.mqtt.golang package (https://github.com/eclipse/paho.mqtt.golang).
+//
+// The published messages are traced until they are acknowledged by the broker,
+// and the handling of the messages delivered to the callbacks of the
+// subscriptions is traced as well. MQTT 3.1.1, implemented by the package,
+// has no message properties, so the span context can't be propagated from the
+// publishers to the subscribers.
+package mqtt // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/eclipse/paho.mqtt.golang"
+
+import (
+	"context"
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const componentName = "eclipse/paho.mqtt.golang"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// WrapClient wraps an mqtt.Client so that the published messages and the
+// messages delivered to the callbacks of the subscriptions are traced.
+func WrapClient(mc mqtt.Client, opts ...Option) *Client {
+	c := &Client{
+		Client: mc,
+		cfg:    newConfig(opts...),
+	}
+	log.Debug("contrib/eclipse/paho.mqtt.golang: Wrapping Client: %#v", c.cfg)
+	return c
+}
+
+// A Client wraps an mqtt.Client.
+type Client struct {
+	mqtt.Client
+	cfg *config
+}
+
+var _ mqtt.Client = (*Client)(nil)
+
+// Publish calls mqtt.Client.Publish and traces it until the returned token
+// completes.
+func (c *Client) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	return c.PublishWithContext(context.Background(), topic, qos, retained, payload)
+}
+
+// PublishWithContext is like Publish, but the span is a child of the span
+// found in ctx, if any.
+func (c *Client) PublishWithContext(ctx context.Context, topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(c.cfg.producerServiceName),
+		tracer.ResourceName("Publish " + topic),
+		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindProducer),
+		tracer.Tag(ext.MessagingSystem, ext.MessagingSystemMQTT),
+		tracer.Tag(ext.MQTTTopic, topic),
+		tracer.Tag(ext.MQTTQoS, qos),
+		tracer.Tag(ext.MQTTRetained, retained),
+	}
+	if !math.IsNaN(c.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
+	}
+	span, _ := tracer.StartSpanFromContext(ctx, c.cfg.producerSpanName, opts...)
+	tok := c.Client.Publish(topic, qos, retained, payload)
+	go func() {
+		// the token completes once the message is sent, for QoS 0, or
+		// acknowledged by the broker.
+		<-tok.Done()
+		span.Finish(tracer.WithError(tok.Error()))
+	}()
+	return tok
+}
+
+// Subscribe calls mqtt.Client.Subscribe, tracing the handling of each message
+// delivered to callback.
+func (c *Client) Subscribe(topic string, qos byte, callback mqtt.MessageHandler) mqtt.Token {
+	return c.Client.Subscribe(topic, qos, traceHandler(c.cfg, topic, callback))
+}
+
+// SubscribeMultiple calls mqtt.Client.SubscribeMultiple, tracing the handling
+// of each message delivered to callback.
+func (c *Client) SubscribeMultiple(filters map[string]byte, callback mqtt.MessageHandler) mqtt.Token {
+	return c.Client.SubscribeMultiple(filters, traceHandler(c.cfg, "", callback))
+}
+
+// AddRoute calls mqtt.Client.AddRoute, tracing the handling of each message
+// delivered to callback.
+func (c *Client) AddRoute(topic string, callback mqtt.MessageHandler) {
+	c.Client.AddRoute(topic, traceHandler(c.cfg, topic, callback))
+}
+
+// WrapMessageHandler returns a handler tracing the handling of the messages by
+// h, to be used as the default publish handler of the options of a client.
+func WrapMessageHandler(h mqtt.MessageHandler, opts ...Option) mqtt.MessageHandler {
+	return traceHandler(newConfig(opts...), "", h)
+}
+
+// traceHandler returns a handler tracing the handling of the messages by cb.
+// filter is the topic filter of the subscription, which may hold wildcards,
+// used in the resource names so that they don't depend on the topics of the
+// messages. The messages passed to cb carry the span of their handling, which
+// can be retrieved using ContextWithMessage.
+func traceHandler(cfg *config, filter string, cb mqtt.MessageHandler) mqtt.MessageHandler {
+	if cb == nil {
+		return nil
+	}
+	return func(client mqtt.Client, m mqtt.Message) {
+		resource := filter
+		if resource == "" {
+			resource = m.Topic()
+		}
+		opts := []ddtrace.StartSpanOption{
+			tracer.ServiceName(cfg.consumerServiceName),
+			tracer.ResourceName("Consume " + resource),
+			tracer.SpanType(ext.SpanTypeMessageConsumer),
+			tracer.Tag(ext.Component, componentName),
+			tracer.Tag(ext.SpanKind, ext.SpanKindConsumer),
+			tracer.Tag(ext.MessagingSystem, ext.MessagingSystemMQTT),
+			tracer.Tag(ext.MQTTTopic, m.Topic()),
+			tracer.Tag(ext.MQTTQoS, m.Qos()),
+			tracer.Tag(ext.MQTTRetained, m.Retained()),
+			tracer.Tag(ext.MQTTMessageID, m.MessageID()),
+			tracer.Tag(ext.MQTTDuplicate, m.Duplicate()),
+			tracer.Measured(),
+		}
+		if !math.IsNaN(cfg.analyticsRate) {
+			opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+		}
+		span := tracer.StartSpan(cfg.consumerSpanName, opts...)
+		defer span.Finish()
+		cb(client, &message{Message: m, span: span})
+	}
+}
+
+// A message is a received mqtt.Message carrying the span of its handling.
+type message struct {
+	mqtt.Message
+	span ddtrace.Span
+}
+
+// ContextWithMessage returns a copy of ctx holding the span of the handling of
+// m, when m was passed to a traced handler, so that the spans started from the
+// returned context are its children.
+func ContextWithMessage(ctx context.Context, m mqtt.Message) context.Context {
+	if tm, ok := m.(*message); ok {
+		return tracer.ContextWithSpan(ctx, tm.span)
+	}
+	return ctx
+}
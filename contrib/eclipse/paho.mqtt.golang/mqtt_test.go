@@ -0,0 +1,162 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testToken is a completed token.
+type testToken struct {
+	err error
+}
+
+func (t *testToken) Wait() bool                     { return true }
+func (t *testToken) WaitTimeout(time.Duration) bool { return true }
+func (t *testToken) Error() error                   { return t.err }
+
+func (t *testToken) Done() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+// testClient delivers the published messages to the handlers of the
+// subscriptions.
+type testClient struct {
+	mqtt.Client
+	err      error
+	handlers map[string]mqtt.MessageHandler
+}
+
+func newTestClient() *testClient {
+	return &testClient{handlers: map[string]mqtt.MessageHandler{}}
+}
+
+func (c *testClient) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	if h, ok := c.handlers[topic]; ok && c.err == nil {
+		h(c, &testMessage{topic: topic, qos: qos, retained: retained, id: 1})
+	}
+	return &testToken{err: c.err}
+}
+
+func (c *testClient) Subscribe(topic string, qos byte, callback mqtt.MessageHandler) mqtt.Token {
+	c.handlers[topic] = callback
+	return &testToken{}
+}
+
+type testMessage struct {
+	mqtt.Message
+	topic    string
+	qos      byte
+	retained bool
+	id       uint16
+}
+
+func (m *testMessage) Topic() string     { return m.topic }
+func (m *testMessage) Qos() byte         { return m.qos }
+func (m *testMessage) Retained() bool    { return m.retained }
+func (m *testMessage) MessageID() uint16 { return m.id }
+func (m *testMessage) Duplicate() bool   { return false }
+
+// waitSpans waits for n spans to be finished, as the spans of the published
+// messages are finished asynchronously.
+func waitSpans(t *testing.T, mt mocktracer.Tracer, n int) []mocktracer.Span {
+	require.Eventually(t, func() bool { return len(mt.FinishedSpans()) >= n }, time.Second, time.Millisecond)
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, n)
+	return spans
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := WrapClient(newTestClient())
+	var child mocktracer.Span
+	c.Subscribe("devices/1/telemetry", 1, func(_ mqtt.Client, m mqtt.Message) {
+		span, _ := tracer.StartSpanFromContext(ContextWithMessage(context.Background(), m), "child")
+		child = span.(mocktracer.Span)
+		span.Finish()
+	}).Wait()
+
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	tok := c.PublishWithContext(ctx, "devices/1/telemetry", 1, true, []byte("hello"))
+	require.True(t, tok.Wait())
+	require.NoError(t, tok.Error())
+	parent.Finish()
+
+	spans := waitSpans(t, mt, 4)
+	var publish, consume mocktracer.Span
+	for _, s := range spans {
+		switch s.OperationName() {
+		case "mqtt.publish":
+			publish = s
+		case "mqtt.receive":
+			consume = s
+		}
+	}
+	require.NotNil(t, publish)
+	require.NotNil(t, consume)
+
+	assert.Equal(t, "Publish devices/1/telemetry", publish.Tag(ext.ResourceName))
+	assert.Equal(t, "mqtt", publish.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanKindProducer, publish.Tag(ext.SpanKind))
+	assert.Equal(t, ext.MessagingSystemMQTT, publish.Tag(ext.MessagingSystem))
+	assert.Equal(t, "devices/1/telemetry", publish.Tag(ext.MQTTTopic))
+	assert.Equal(t, byte(1), publish.Tag(ext.MQTTQoS))
+	assert.Equal(t, true, publish.Tag(ext.MQTTRetained))
+	assert.Equal(t, componentName, publish.Tag(ext.Component))
+	assert.Equal(t, parent.Context().SpanID(), publish.ParentID())
+
+	assert.Equal(t, "Consume devices/1/telemetry", consume.Tag(ext.ResourceName))
+	assert.Equal(t, ext.SpanKindConsumer, consume.Tag(ext.SpanKind))
+	assert.Equal(t, "devices/1/telemetry", consume.Tag(ext.MQTTTopic))
+	assert.Equal(t, uint16(1), consume.Tag(ext.MQTTMessageID))
+	assert.Equal(t, false, consume.Tag(ext.MQTTDuplicate))
+	assert.Equal(t, consume.SpanID(), child.ParentID())
+}
+
+func TestPublishError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	mc := newTestClient()
+	mc.err = errors.New("not connected")
+	c := WrapClient(mc)
+	tok := c.Publish("topic", 0, false, "hello")
+	require.Error(t, tok.Error())
+
+	spans := waitSpans(t, mt, 1)
+	assert.Equal(t, mc.err, spans[0].Tag(ext.Error))
+}
+
+func TestWrapMessageHandler(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	called := false
+	h := WrapMessageHandler(func(mqtt.Client, mqtt.Message) { called = true }, WithServiceName("my-mqtt"))
+	h(nil, &testMessage{topic: "topic"})
+	assert.True(t, called)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "Consume topic", spans[0].Tag(ext.ResourceName))
+	assert.Equal(t, "my-mqtt", spans[0].Tag(ext.ServiceName))
+	assert.Nil(t, WrapMessageHandler(nil))
+}
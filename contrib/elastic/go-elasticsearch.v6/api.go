@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package elastic
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/obfuscate"
+)
+
+// endpointAPIs maps the endpoints of the documents APIs to the name of the
+// API, as named by the Elasticsearch clients.
+var endpointAPIs = map[string]string{
+	"_create":          "create",
+	"_update":          "update",
+	"_source":          "get_source",
+	"_search":          "search",
+	"_msearch":         "msearch",
+	"_bulk":            "bulk",
+	"_count":           "count",
+	"_mget":            "mget",
+	"_delete_by_query": "delete_by_query",
+	"_update_by_query": "update_by_query",
+	"_explain":         "explain",
+	"_termvectors":     "termvectors",
+	"_mtermvectors":    "mtermvectors",
+	"_field_caps":      "field_caps",
+	"_refresh":         "indices.refresh",
+}
+
+// namespaceEndpoints are the endpoints whose API is named after the next
+// segment of the path, e.g. "/_cluster/health" is "cluster.health".
+var namespaceEndpoints = map[string]bool{
+	"_cat":     true,
+	"_cluster": true,
+	"_nodes":   true,
+}
+
+// tookAPIs are the APIs whose response holds the time spent by Elasticsearch
+// to process the request.
+var tookAPIs = map[string]bool{
+	"search":          true,
+	"msearch":         true,
+	"bulk":            true,
+	"delete_by_query": true,
+	"update_by_query": true,
+}
+
+// apiInfo returns the name of the API called by a request with the given
+// method and URL path, e.g. "search" or "index", along with the index it
+// targets, if any.
+func apiInfo(path, method string) (api, index string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) == 1 && segments[0] == "" {
+		if method == http.MethodHead {
+			return "ping", ""
+		}
+		return "info", ""
+	}
+	if !strings.HasPrefix(segments[0], "_") {
+		index = segments[0]
+		segments = segments[1:]
+	}
+	if len(segments) == 0 {
+		// index management
+		switch method {
+		case http.MethodPut:
+			return "indices.create", index
+		case http.MethodDelete:
+			return "indices.delete", index
+		case http.MethodHead:
+			return "indices.exists", index
+		}
+		return "indices.get", index
+	}
+	endpoint := segments[0]
+	if !strings.HasPrefix(endpoint, "_") {
+		// the mapping type of the documents, before Elasticsearch 7
+		endpoint = "_doc"
+		if len(segments) > 1 && strings.HasPrefix(segments[1], "_") {
+			endpoint = segments[1]
+		}
+	}
+	if endpoint == "_doc" {
+		switch method {
+		case http.MethodGet:
+			return "get", index
+		case http.MethodHead:
+			return "exists", index
+		case http.MethodDelete:
+			return "delete", index
+		}
+		return "index", index
+	}
+	if api, ok := endpointAPIs[endpoint]; ok {
+		return api, index
+	}
+	api = strings.TrimPrefix(endpoint, "_")
+	if namespaceEndpoints[endpoint] && len(segments) > 1 && !strings.HasPrefix(segments[1], "_") {
+		api += "." + segments[1]
+	}
+	if index != "" {
+		api = "indices." + api
+	}
+	return api, index
+}
+
+var tookRegexp = regexp.MustCompile(`"took"\s*:\s*([0-9]+)`)
+
+// responseTook returns the time spent by Elasticsearch to process a request,
+// in milliseconds, found in the beginning of the body of its response.
+func responseTook(snip string) (int, bool) {
+	m := tookRegexp.FindStringSubmatch(snip)
+	if m == nil {
+		return 0, false
+	}
+	took, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, false
+	}
+	return took, true
+}
+
+var (
+	obfuscatorOnce sync.Once
+	obfuscator     *obfuscate.Obfuscator
+)
+
+// obfuscateBody returns body with the values of its JSON documents replaced
+// with "?", as done by the agent.
+func obfuscateBody(body string) string {
+	obfuscatorOnce.Do(func() {
+		obfuscator = obfuscate.NewObfuscator(obfuscate.Config{
+			ES: obfuscate.JSONConfig{Enabled: true},
+		})
+	})
+	return obfuscator.ObfuscateElasticSearchString(body)
+}
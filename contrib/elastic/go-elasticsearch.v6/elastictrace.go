@@ -4,6 +4,10 @@
 // Copyright 2016 Datadog, Inc.
 
 // Package elastic provides functions to trace the github.com/elastic/go-elasticsearch packages.
+//
+// The round tripper returned by NewRoundTripper is set as the transport of the
+// clients. Spans are tagged with the name of the API called, e.g. "search" or
+// "bulk", and with the index targeted by the request.
 package elastic // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/elastic/go-elasticsearch
 
 import (
@@ -44,6 +48,11 @@ func NewRoundTripper(opts ...ClientOption) http.RoundTripper {
 // value obtained from an HTTP request or response body.
 var bodyCutoff = 5 * 1024
 
+// tookCutoff specifies the number of bytes of a response body looked up for
+// the time spent by Elasticsearch to process the request, which is among the
+// first fields of the responses.
+var tookCutoff = 128
+
 // roundTripper is an implementation of http.RoundTripper that captures Elasticsearch spans.
 type roundTripper struct {
 	config clientConfig
@@ -56,7 +65,11 @@ var _ http.RoundTripper = &roundTripper{}
 func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	url := req.URL.Path
 	method := req.Method
+	api, index := apiInfo(url, method)
 	resource := t.config.resourceNamer(url, method)
+	if t.config.apiResourceNames {
+		resource = api
+	}
 	opts := []ddtrace.StartSpanOption{
 		tracer.ServiceName(t.config.serviceName),
 		tracer.SpanType(ext.SpanTypeElasticSearch),
@@ -68,6 +81,10 @@ func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
 		tracer.Tag(ext.DBSystem, ext.DBSystemElasticsearch),
 		tracer.Tag(ext.NetworkDestinationName, req.URL.Hostname()),
+		tracer.Tag("elasticsearch.api", api),
+	}
+	if index != "" {
+		opts = append(opts, tracer.Tag("elasticsearch.index", index))
 	}
 	if !math.IsNaN(t.config.analyticsRate) {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, t.config.analyticsRate))
@@ -78,6 +95,9 @@ func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	contentEncoding := req.Header.Get("Content-Encoding")
 	snip, rc, err := peek(req.Body, contentEncoding, int(req.ContentLength), bodyCutoff)
 	if err == nil {
+		if t.config.obfuscateBody {
+			snip = obfuscateBody(snip)
+		}
 		span.SetTag("elasticsearch.body", snip)
 	}
 	req.Body = rc
@@ -94,6 +114,12 @@ func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 		}
 		span.SetTag(ext.Error, errors.New(snip))
 		res.Body = rc
+	} else if tookAPIs[api] {
+		snip, rc, err := peek(res.Body, res.Header.Get("Content-Encoding"), int(res.ContentLength), tookCutoff)
+		if took, ok := responseTook(snip); err == nil && ok {
+			span.SetTag("elasticsearch.took", took)
+		}
+		res.Body = rc
 	}
 	if res != nil {
 		span.SetTag(ext.HTTPCode, strconv.Itoa(res.StatusCode))
@@ -63,6 +63,54 @@ func TestQuantize(t *testing.T) {
 	}
 }
 
+func TestAPIInfo(t *testing.T) {
+	for _, tc := range []struct {
+		url, method string
+		api, index  string
+	}{
+		{url: "/", method: "GET", api: "info"},
+		{url: "/", method: "HEAD", api: "ping"},
+		{url: "/twitter", method: "PUT", api: "indices.create", index: "twitter"},
+		{url: "/twitter/_doc/1", method: "PUT", api: "index", index: "twitter"},
+		{url: "/twitter/_doc/1", method: "GET", api: "get", index: "twitter"},
+		{url: "/twitter/_doc/1", method: "DELETE", api: "delete", index: "twitter"},
+		{url: "/twitter/tweets/123", method: "GET", api: "get", index: "twitter"},
+		{url: "/twitter/tweets", method: "POST", api: "index", index: "twitter"},
+		{url: "/logs_2016_05/event/_search", method: "GET", api: "search", index: "logs_2016_05"},
+		{url: "/twitter/_search", method: "POST", api: "search", index: "twitter"},
+		{url: "/_search", method: "POST", api: "search"},
+		{url: "/_bulk", method: "POST", api: "bulk"},
+		{url: "/twitter/_update/1", method: "POST", api: "update", index: "twitter"},
+		{url: "/twitter/_mapping", method: "GET", api: "indices.mapping", index: "twitter"},
+		{url: "/_cluster/health", method: "GET", api: "cluster.health"},
+		{url: "/_cat/indices", method: "GET", api: "cat.indices"},
+	} {
+		api, index := apiInfo(tc.url, tc.method)
+		assert.Equal(t, tc.api, api, tc.method+" "+tc.url)
+		assert.Equal(t, tc.index, index, tc.method+" "+tc.url)
+	}
+}
+
+func TestResponseTook(t *testing.T) {
+	took, ok := responseTook(`{"took":12,"timed_out":false,"_shards":{"total":1`)
+	assert.True(t, ok)
+	assert.Equal(t, 12, took)
+
+	took, ok = responseTook(`{"took" : 3, "errors" : false`)
+	assert.True(t, ok)
+	assert.Equal(t, 3, took)
+
+	_, ok = responseTook(`{"_index":"twitter"`)
+	assert.False(t, ok)
+}
+
+func TestObfuscateBody(t *testing.T) {
+	assert.Equal(t,
+		`{"query":{"match":{"user":"?"}}}`,
+		obfuscateBody(`{"query":{"match":{"user":"kimchy"}}}`),
+	)
+}
+
 func TestPeek(t *testing.T) {
 	assert := assert.New(t)
 
@@ -29,6 +29,8 @@ func checkGETTraceV8(assert *assert.Assertions, mt mocktracer.Tracer) {
 	assert.Equal("/twitter/_doc/1", span.Tag("elasticsearch.url"))
 	assert.Equal("GET", span.Tag("elasticsearch.method"))
 	assert.Equal("127.0.0.1", span.Tag(ext.NetworkDestinationName))
+	assert.Equal("get", span.Tag("elasticsearch.api"))
+	assert.Equal("twitter", span.Tag("elasticsearch.index"))
 }
 
 func checkErrTraceV8(assert *assert.Assertions, mt mocktracer.Tracer) {
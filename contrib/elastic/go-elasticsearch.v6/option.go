@@ -16,11 +16,13 @@ import (
 const defaultServiceName = "elastic.client"
 
 type clientConfig struct {
-	serviceName   string
-	operationName string
-	transport     http.RoundTripper
-	analyticsRate float64
-	resourceNamer func(url, method string) string
+	serviceName      string
+	operationName    string
+	transport        http.RoundTripper
+	analyticsRate    float64
+	resourceNamer    func(url, method string) string
+	apiResourceNames bool
+	obfuscateBody    bool
 }
 
 // ClientOption represents an option that can be used when creating a client.
@@ -34,6 +36,7 @@ func defaults(cfg *clientConfig) {
 	cfg.operationName = namingschema.NewElasticsearchOutboundOp().GetName()
 	cfg.transport = http.DefaultTransport
 	cfg.resourceNamer = quantize
+	cfg.obfuscateBody = internal.BoolEnv("DD_TRACE_ELASTIC_CLIENT_OBFUSCATION_ENABLED", false)
 	if internal.BoolEnv("DD_TRACE_ELASTIC_ANALYTICS_ENABLED", false) {
 		cfg.analyticsRate = 1.0
 	} else {
@@ -87,3 +90,24 @@ func WithResourceNamer(namer func(url, method string) string) ClientOption {
 		cfg.resourceNamer = namer
 	}
 }
+
+// WithAPIResourceNames sets whether the resource names of the spans are the
+// names of the APIs called, e.g. "search", "index" or "bulk", instead of the
+// quantized method and URL of the requests. The index of the request is
+// tagged as elasticsearch.index either way.
+func WithAPIResourceNames(on bool) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.apiResourceNames = on
+	}
+}
+
+// WithBodyObfuscation sets whether the request bodies tagged as
+// elasticsearch.body are obfuscated by the client: the values of the queries
+// and documents are replaced with "?", so that sensitive values never leave
+// the application. It can also be enabled by setting
+// DD_TRACE_ELASTIC_CLIENT_OBFUSCATION_ENABLED to true.
+func WithBodyObfuscation(on bool) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.obfuscateBody = on
+	}
+}
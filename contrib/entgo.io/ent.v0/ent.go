@@ -0,0 +1,166 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package ent provides functions to trace the entgo.io/ent package (https://entgo.io).
+//
+// The statements sent to the database are traced by wrapping the driver of the
+// client with NewDriver. The spans of the operations performed by the generated
+// code are tagged with the entity and the edges they involve when the Hook and
+// the Interceptor of this package are registered on the client.
+package ent
+
+import (
+	"context"
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"entgo.io/ent/dialect"
+)
+
+const componentName = "entgo.io/ent.v0"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Names of the spans created by the driver.
+const (
+	spanNameExec     = "ent.exec"
+	spanNameQuery    = "ent.query"
+	spanNameTx       = "ent.tx"
+	spanNameCommit   = "ent.commit"
+	spanNameRollback = "ent.rollback"
+)
+
+var (
+	_ dialect.Driver = (*Driver)(nil)
+	_ dialect.Tx     = (*Tx)(nil)
+)
+
+// Driver is a dialect.Driver tracing the statements sent to the database.
+type Driver struct {
+	dialect.Driver
+	cfg *config
+}
+
+// NewDriver returns drv, traced. It is meant to be passed to the client
+// generated by ent, e.g.:
+//
+//	drv, err := sql.Open(dialect.Postgres, dsn)
+//	client := ent.NewClient(ent.Driver(enttrace.NewDriver(drv)))
+func NewDriver(drv dialect.Driver, opts ...Option) *Driver {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/entgo.io/ent.v0: Wrapping Driver: %#v", cfg)
+	return &Driver{Driver: drv, cfg: cfg}
+}
+
+// Exec implements dialect.Driver.
+func (d *Driver) Exec(ctx context.Context, query string, args, v interface{}) error {
+	return trace(ctx, d.cfg, d.Dialect(), spanNameExec, query, func() error {
+		return d.Driver.Exec(ctx, query, args, v)
+	})
+}
+
+// Query implements dialect.Driver.
+func (d *Driver) Query(ctx context.Context, query string, args, v interface{}) error {
+	return trace(ctx, d.cfg, d.Dialect(), spanNameQuery, query, func() error {
+		return d.Driver.Query(ctx, query, args, v)
+	})
+}
+
+// Tx implements dialect.Driver. The statements run in the returned
+// transaction, as well as its commit or rollback, are traced.
+func (d *Driver) Tx(ctx context.Context) (dialect.Tx, error) {
+	var tx dialect.Tx
+	err := trace(ctx, d.cfg, d.Dialect(), spanNameTx, "BEGIN", func() (err error) {
+		tx, err = d.Driver.Tx(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, ctx: ctx, dialect: d.Dialect(), cfg: d.cfg}, nil
+}
+
+// Tx is a dialect.Tx tracing the statements run in a transaction.
+type Tx struct {
+	dialect.Tx
+	// ctx is the context the transaction was started with, used as the
+	// parent of its commit or rollback.
+	ctx     context.Context
+	dialect string
+	cfg     *config
+}
+
+// Exec implements dialect.Tx.
+func (tx *Tx) Exec(ctx context.Context, query string, args, v interface{}) error {
+	return trace(ctx, tx.cfg, tx.dialect, spanNameExec, query, func() error {
+		return tx.Tx.Exec(ctx, query, args, v)
+	})
+}
+
+// Query implements dialect.Tx.
+func (tx *Tx) Query(ctx context.Context, query string, args, v interface{}) error {
+	return trace(ctx, tx.cfg, tx.dialect, spanNameQuery, query, func() error {
+		return tx.Tx.Query(ctx, query, args, v)
+	})
+}
+
+// Commit implements dialect.Tx.
+func (tx *Tx) Commit() error {
+	return trace(tx.ctx, tx.cfg, tx.dialect, spanNameCommit, "COMMIT", tx.Tx.Commit)
+}
+
+// Rollback implements dialect.Tx.
+func (tx *Tx) Rollback() error {
+	return trace(tx.ctx, tx.cfg, tx.dialect, spanNameRollback, "ROLLBACK", tx.Tx.Rollback)
+}
+
+// trace runs fn in a span, tagged with the entity found in ctx, if any.
+func trace(ctx context.Context, cfg *config, dialectName, spanName, resource string, fn func() error) error {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(cfg.serviceName),
+		tracer.SpanType(ext.SpanTypeSQL),
+		tracer.ResourceName(resource),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		tracer.Tag(ext.DBSystem, dbSystem(dialectName)),
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	if op, ok := operationFromContext(ctx); ok {
+		opts = append(opts, op.tags()...)
+	}
+	span, _ := tracer.StartSpanFromContext(ctx, spanName, opts...)
+	err := fn()
+	if err != nil && (cfg.errCheck == nil || cfg.errCheck(err)) {
+		span.Finish(tracer.WithError(err))
+	} else {
+		span.Finish()
+	}
+	return err
+}
+
+// dbSystem returns the db.system of the given ent dialect.
+func dbSystem(dialectName string) string {
+	switch dialectName {
+	case dialect.MySQL:
+		return ext.DBSystemMySQL
+	case dialect.Postgres:
+		return ext.DBSystemPostgreSQL
+	default:
+		return ext.DBSystemOtherSQL
+	}
+}
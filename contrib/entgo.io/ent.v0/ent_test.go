@@ -0,0 +1,155 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package ent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"entgo.io/ent/dialect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDriver is a dialect.Driver recording the statements it runs.
+type testDriver struct {
+	err        error
+	statements []string
+}
+
+func (d *testDriver) Exec(_ context.Context, query string, _, _ interface{}) error {
+	d.statements = append(d.statements, query)
+	return d.err
+}
+
+func (d *testDriver) Query(_ context.Context, query string, _, _ interface{}) error {
+	d.statements = append(d.statements, query)
+	return d.err
+}
+
+func (d *testDriver) Tx(context.Context) (dialect.Tx, error) {
+	return &testTx{testDriver: d}, nil
+}
+
+func (d *testDriver) Close() error { return nil }
+
+func (d *testDriver) Dialect() string { return dialect.Postgres }
+
+type testTx struct {
+	*testDriver
+}
+
+func (tx *testTx) Commit() error {
+	tx.statements = append(tx.statements, "COMMIT")
+	return nil
+}
+
+func (tx *testTx) Rollback() error {
+	tx.statements = append(tx.statements, "ROLLBACK")
+	return nil
+}
+
+func TestDriver(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	drv := NewDriver(&testDriver{}, WithServiceName("my-db"))
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	require.NoError(t, drv.Query(ctx, "SELECT id FROM users", []interface{}{}, nil))
+	require.NoError(t, drv.Exec(ctx, "DELETE FROM users", []interface{}{}, nil))
+	parent.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 3)
+	for i, want := range []struct{ name, resource string }{
+		{spanNameQuery, "SELECT id FROM users"},
+		{spanNameExec, "DELETE FROM users"},
+	} {
+		s := spans[i]
+		assert.Equal(t, want.name, s.OperationName())
+		assert.Equal(t, want.resource, s.Tag(ext.ResourceName))
+		assert.Equal(t, "my-db", s.Tag(ext.ServiceName))
+		assert.Equal(t, ext.SpanTypeSQL, s.Tag(ext.SpanType))
+		assert.Equal(t, ext.DBSystemPostgreSQL, s.Tag(ext.DBSystem))
+		assert.Equal(t, ext.SpanKindClient, s.Tag(ext.SpanKind))
+		assert.Equal(t, componentName, s.Tag(ext.Component))
+		assert.Equal(t, parent.Context().SpanID(), s.ParentID())
+		assert.Nil(t, s.Tag(tagEntity))
+	}
+}
+
+func TestTx(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	drv := NewDriver(&testDriver{})
+	ctx := context.Background()
+	tx, err := drv.Tx(ctx)
+	require.NoError(t, err)
+	require.NoError(t, tx.Exec(ctx, "INSERT INTO users (name) VALUES ($1)", []interface{}{"jane"}, nil))
+	require.NoError(t, tx.Commit())
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, spanNameTx, spans[0].OperationName())
+	assert.Equal(t, spanNameExec, spans[1].OperationName())
+	assert.Equal(t, spanNameCommit, spans[2].OperationName())
+	assert.Equal(t, "COMMIT", spans[2].Tag(ext.ResourceName))
+}
+
+func TestErrorCheck(t *testing.T) {
+	errNoRows := errors.New("no rows")
+	for name, tc := range map[string]struct {
+		opts  []Option
+		error bool
+	}{
+		"default": {error: true},
+		"errcheck": {
+			opts:  []Option{WithErrorCheck(func(err error) bool { return !errors.Is(err, errNoRows) })},
+			error: false,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			drv := NewDriver(&testDriver{err: errNoRows}, tc.opts...)
+			err := drv.Query(context.Background(), "SELECT 1", []interface{}{}, nil)
+			assert.Equal(t, errNoRows, err)
+
+			spans := mt.FinishedSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tc.error, spans[0].Tag(ext.Error) != nil)
+		})
+	}
+}
+
+func TestOperationTags(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	drv := NewDriver(&testDriver{})
+	ctx := contextWithOperation(context.Background(), &operation{
+		entity:     "User",
+		name:       "UpdateOne",
+		edgesAdded: []string{"pets", "groups"},
+	})
+	require.NoError(t, drv.Exec(ctx, "UPDATE users SET name = $1", []interface{}{"jane"}, nil))
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "User", s.Tag(tagEntity))
+	assert.Equal(t, "UpdateOne", s.Tag(tagOperation))
+	assert.Equal(t, "pets,groups", s.Tag(tagEdgesAdded))
+	assert.Nil(t, s.Tag(tagEdgesRemoved))
+	assert.Nil(t, s.Tag(tagEdgesCleared))
+}
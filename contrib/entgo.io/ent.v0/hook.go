@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package ent
+
+import (
+	"context"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"entgo.io/ent"
+)
+
+// Tags set on the spans of the statements run by the generated code, when the
+// Hook and the Interceptor are registered.
+const (
+	tagEntity       = "ent.entity"
+	tagOperation    = "ent.operation"
+	tagEdgesAdded   = "ent.edges.added"
+	tagEdgesRemoved = "ent.edges.removed"
+	tagEdgesCleared = "ent.edges.cleared"
+)
+
+type operationKey struct{}
+
+// operation describes the operation of the generated code that runs the
+// statements.
+type operation struct {
+	entity       string
+	name         string
+	edgesAdded   []string
+	edgesRemoved []string
+	edgesCleared []string
+}
+
+func (op *operation) tags() []ddtrace.StartSpanOption {
+	opts := []ddtrace.StartSpanOption{
+		tracer.Tag(tagEntity, op.entity),
+		tracer.Tag(tagOperation, op.name),
+	}
+	for tag, edges := range map[string][]string{
+		tagEdgesAdded:   op.edgesAdded,
+		tagEdgesRemoved: op.edgesRemoved,
+		tagEdgesCleared: op.edgesCleared,
+	} {
+		if len(edges) > 0 {
+			opts = append(opts, tracer.Tag(tag, strings.Join(edges, ",")))
+		}
+	}
+	return opts
+}
+
+func contextWithOperation(ctx context.Context, op *operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+func operationFromContext(ctx context.Context) (*operation, bool) {
+	op, ok := ctx.Value(operationKey{}).(*operation)
+	return op, ok
+}
+
+// Hook returns a hook tagging the spans of the statements run by mutations
+// with the mutated entity, the operation and the edges changed. It is meant to
+// be registered on the generated client:
+//
+//	client.Use(enttrace.Hook())
+func Hook() ent.Hook {
+	return func(next ent.Mutator) ent.Mutator {
+		return ent.MutateFunc(func(ctx context.Context, m ent.Mutation) (ent.Value, error) {
+			ctx = contextWithOperation(ctx, &operation{
+				entity:       m.Type(),
+				name:         mutationOpName(m.Op()),
+				edgesAdded:   m.AddedEdges(),
+				edgesRemoved: m.RemovedEdges(),
+				edgesCleared: m.ClearedEdges(),
+			})
+			return next.Mutate(ctx, m)
+		})
+	}
+}
+
+// Interceptor returns an interceptor tagging the spans of the statements run
+// by queries, including the traversals of edges and their eager loading, with
+// the queried entity. It is meant to be registered on
+// the generated client:
+//
+//	client.Intercept(enttrace.Interceptor())
+func Interceptor() ent.Interceptor {
+	return ent.InterceptFunc(func(next ent.Querier) ent.Querier {
+		return ent.QuerierFunc(func(ctx context.Context, q ent.Query) (ent.Value, error) {
+			if qc := ent.QueryFromContext(ctx); qc != nil {
+				ctx = contextWithOperation(ctx, &operation{entity: qc.Type, name: "Query"})
+			}
+			return next.Query(ctx, q)
+		})
+	})
+}
+
+// mutationOpName returns the name of a mutation operation.
+func mutationOpName(op ent.Op) string {
+	switch {
+	case op.Is(ent.OpCreate):
+		return "Create"
+	case op.Is(ent.OpUpdateOne):
+		return "UpdateOne"
+	case op.Is(ent.OpUpdate):
+		return "Update"
+	case op.Is(ent.OpDeleteOne):
+		return "DeleteOne"
+	case op.Is(ent.OpDelete):
+		return "Delete"
+	default:
+		return op.String()
+	}
+}
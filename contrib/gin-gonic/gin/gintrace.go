@@ -7,10 +7,12 @@
 package gin // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/gin-gonic/gin"
 
 import (
+	"context"
 	"fmt"
 	"math"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/httptrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
@@ -28,6 +30,12 @@ func init() {
 
 // Middleware returns middleware that will trace incoming requests. If service is empty then the
 // default service name will be used.
+//
+// The middleware may be used by router groups with distinct options, to
+// separate e.g. internal admin routes from the public API. When the request
+// is already traced by the middleware of the engine or of a parent group, no
+// other span is started: the span of the request is configured with the
+// options of the group instead, and with its service name if it is not empty.
 func Middleware(service string, opts ...Option) gin.HandlerFunc {
 	cfg := newConfig(service)
 	for _, opt := range opts {
@@ -43,6 +51,23 @@ func Middleware(service string, opts ...Option) gin.HandlerFunc {
 		if cfg.ignoreRequest(c) {
 			return
 		}
+		if span, ok := c.Request.Context().Value(spanKey{}).(tracer.Span); ok {
+			if service != "" {
+				span.SetTag(ext.ServiceName, cfg.serviceName)
+			}
+			span.SetTag(ext.ResourceName, cfg.resourceNamer(c))
+			if !math.IsNaN(cfg.analyticsRate) {
+				span.SetTag(ext.EventSampleRate, cfg.analyticsRate)
+			}
+			sc := ddtrace.StartSpanConfig{Tags: make(map[string]interface{})}
+			httptrace.HeaderTagsFromRequest(c.Request, cfg.headerTags)(&sc)
+			for k, v := range sc.Tags {
+				span.SetTag(k, v)
+			}
+			c.Next()
+			cfg.setCustomTags(span, c)
+			return
+		}
 		opts := append(spanOpts, tracer.ResourceName(cfg.resourceNamer(c)))
 		if !math.IsNaN(cfg.analyticsRate) {
 			opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
@@ -55,7 +80,7 @@ func Middleware(service string, opts ...Option) gin.HandlerFunc {
 		}()
 
 		// pass the span through the request context
-		c.Request = c.Request.WithContext(ctx)
+		c.Request = c.Request.WithContext(context.WithValue(ctx, spanKey{}, span))
 
 		// Use AppSec if enabled by user
 		if appsec.Enabled() {
@@ -68,6 +93,20 @@ func Middleware(service string, opts ...Option) gin.HandlerFunc {
 		if len(c.Errors) > 0 {
 			span.SetTag("gin.errors", c.Errors.String())
 		}
+		cfg.setCustomTags(span, c)
+	}
+}
+
+// spanKey is the request context key of the span started by the middleware.
+type spanKey struct{}
+
+// setCustomTags sets the tags returned by the WithCustomTags function on span.
+func (cfg *config) setCustomTags(span tracer.Span, c *gin.Context) {
+	if cfg.customTags == nil {
+		return
+	}
+	for k, v := range cfg.customTags(c) {
+		span.SetTag(k, v)
 	}
 }
 
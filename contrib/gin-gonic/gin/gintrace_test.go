@@ -667,3 +667,81 @@ func TestNamingSchema(t *testing.T) {
 	})
 	namingschematest.NewHTTPServerTest(genSpans, "gin.router")(t)
 }
+
+func TestGroups(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	router := gin.New()
+	router.Use(Middleware("api"))
+	admin := router.Group("/admin")
+	admin.Use(Middleware("admin",
+		WithResourceNamer(func(c *gin.Context) string { return "admin " + c.FullPath() }),
+		WithHeaderTags([]string{"X-Admin-Id:admin.id"}),
+	))
+	admin.GET("/users", func(c *gin.Context) {
+		span, ok := tracer.SpanFromContext(c.Request.Context())
+		assert.True(ok)
+		assert.Equal("admin", span.(mocktracer.Span).Tag(ext.ServiceName))
+		c.Status(200)
+	})
+	router.GET("/users", func(c *gin.Context) {
+		c.Status(200)
+	})
+
+	r := httptest.NewRequest("GET", "/admin/users", nil)
+	r.Header.Set("X-Admin-Id", "42")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 2)
+	assert.Equal("admin", spans[0].Tag(ext.ServiceName))
+	assert.Equal("admin /admin/users", spans[0].Tag(ext.ResourceName))
+	assert.Equal("/admin/users", spans[0].Tag(ext.HTTPRoute))
+	assert.Equal("42", spans[0].Tag("admin.id"))
+	assert.Equal("200", spans[0].Tag(ext.HTTPCode))
+	assert.Equal("api", spans[1].Tag(ext.ServiceName))
+	assert.Equal("GET /users", spans[1].Tag(ext.ResourceName))
+	assert.Nil(spans[1].Tag("admin.id"))
+
+	t.Run("no-service", func(t *testing.T) {
+		mt.Reset()
+		router := gin.New()
+		router.Use(Middleware("api"))
+		group := router.Group("/v1")
+		group.Use(Middleware(""))
+		group.GET("/ping", func(c *gin.Context) {
+			c.Status(200)
+		})
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/ping", nil))
+
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 1)
+		assert.Equal("api", spans[0].Tag(ext.ServiceName))
+	})
+}
+
+func TestCustomTags(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	router := gin.New()
+	router.Use(Middleware("api", WithCustomTags(func(c *gin.Context) map[string]any {
+		return map[string]any{"api.version": "v1", "user.id": c.GetString("user")}
+	})))
+	router.GET("/ping", func(c *gin.Context) {
+		c.Set("user", "jane")
+		c.Status(200)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ping", nil))
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	assert.Equal("v1", spans[0].Tag("api.version"))
+	assert.Equal("jane", spans[0].Tag("user.id"))
+}
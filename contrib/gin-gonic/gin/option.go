@@ -25,6 +25,7 @@ type config struct {
 	serviceName   string
 	ignoreRequest func(c *gin.Context) bool
 	headerTags    *internal.LockMap
+	customTags    func(c *gin.Context) map[string]any
 }
 
 func newConfig(serviceName string) *config {
@@ -97,6 +98,15 @@ func WithIgnoreRequest(f func(c *gin.Context) bool) Option {
 	}
 }
 
+// WithCustomTags specifies a function returning additional tags to set on
+// the span of the request. It is called once the request was served, so that
+// the tags may depend on the values set in the context by the handlers.
+func WithCustomTags(fn func(c *gin.Context) map[string]any) Option {
+	return func(cfg *config) {
+		cfg.customTags = fn
+	}
+}
+
 func defaultResourceNamer(c *gin.Context) string {
 	// getName is a hacky way to check whether *gin.Context implements the FullPath()
 	// method introduced in v1.4.0, falling back to the previous implementation otherwise.
@@ -54,9 +54,9 @@ func Middleware(opts ...Option) func(next http.Handler) http.Handler {
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 			defer func() {
 				status := ww.Status()
-				var opts []tracer.FinishOption
+				opts := cfg.finishOpts
 				if cfg.isStatusError(status) {
-					opts = []tracer.FinishOption{tracer.WithError(fmt.Errorf("%d: %s", status, http.StatusText(status)))}
+					opts = append(opts[:len(opts):len(opts)], tracer.WithError(fmt.Errorf("%d: %s", status, http.StatusText(status))))
 				}
 				httptrace.FinishRequestSpan(span, status, opts...)
 			}()
@@ -209,6 +209,28 @@ func TestError(t *testing.T) {
 	})
 }
 
+func TestNoDebugStack(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	router := chi.NewRouter()
+	router.Use(Middleware(NoDebugStack()))
+	router.Get("/err", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "500!", http.StatusInternalServerError)
+	})
+	r := httptest.NewRequest("GET", "/err", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.Equal(http.StatusInternalServerError, w.Code)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	s := spans[0]
+	assert.EqualError(s.Tag(ext.Error).(error), "500: Internal Server Error")
+	assert.Equal("<debug stack disabled>", s.Tag(ext.ErrorStack))
+}
+
 func TestGetSpanNotInstrumented(t *testing.T) {
 	assert := assert.New(t)
 	router := chi.NewRouter()
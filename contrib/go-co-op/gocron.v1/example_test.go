@@ -0,0 +1,29 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package gocron_test
+
+import (
+	"context"
+	"time"
+
+	gocrontrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/go-co-op/gocron.v1"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/go-co-op/gocron"
+)
+
+func Example() {
+	s := gocron.NewScheduler(time.UTC)
+	s.Cron("0 3 * * *").DoWithJobDetails(gocrontrace.WrapJob("cleanup", func(ctx context.Context) error {
+		// the work of the job is part of the trace of its run
+		span, ctx := tracer.StartSpanFromContext(ctx, "cleanup.expired")
+		defer span.Finish()
+		_ = ctx
+		return nil
+	}))
+	s.StartAsync()
+	defer s.Stop()
+}
@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package gocron provides functions to trace the go-co-op/gocron package (https://github.com/go-co-op/gocron).
+//
+// Every run of a wrapped job is traced by a root span, tagged with the name
+// and tags of the job, the time the run was scheduled at and how late it
+// started.
+package gocron // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/go-co-op/gocron.v1"
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/go-co-op/gocron"
+)
+
+const componentName = "go-co-op/gocron.v1"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+const (
+	tagJobName       = "gocron.job.name"
+	tagJobTags       = "gocron.job.tags"
+	tagScheduledTime = "gocron.job.scheduled_time"
+	tagStartSkew     = "gocron.job.start_skew_ms"
+)
+
+// WrapJob returns a job function tracing the runs of fn, for use with
+// gocron.Scheduler.DoWithJobDetails:
+//
+//	s.Every(time.Minute).DoWithJobDetails(gocron.WrapJob("cleanup", cleanup))
+//
+// The span of each run is named after name, has no parent and is available
+// from the context passed to fn. An error returned by fn is set on the span.
+func WrapJob(name string, fn func(ctx context.Context) error, opts ...Option) func(gocron.Job) error {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/go-co-op/gocron.v1: Wrapping Job %q: %#v", name, cfg)
+	return func(job gocron.Job) (err error) {
+		start := time.Now()
+		spanOpts := []tracer.StartSpanOption{
+			tracer.ServiceName(cfg.serviceName),
+			tracer.ResourceName(name),
+			tracer.StartTime(start),
+			tracer.Tag(ext.Component, componentName),
+			tracer.Tag(tagJobName, name),
+			tracer.Measured(),
+		}
+		if tags := job.Tags(); len(tags) > 0 {
+			spanOpts = append(spanOpts, tracer.Tag(tagJobTags, strings.Join(tags, ",")))
+		}
+		// the scheduler sets the last run of the job to the time this run was
+		// scheduled at, before starting it.
+		if scheduled := job.LastRun(); !scheduled.IsZero() {
+			spanOpts = append(spanOpts,
+				tracer.Tag(tagScheduledTime, scheduled.UTC().Format(time.RFC3339Nano)),
+				tracer.Tag(tagStartSkew, float64(start.Sub(scheduled))/float64(time.Millisecond)),
+			)
+		}
+		if !math.IsNaN(cfg.analyticsRate) {
+			spanOpts = append(spanOpts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+		}
+		span, ctx := tracer.StartSpanFromContext(context.Background(), cfg.spanName, spanOpts...)
+		defer func() {
+			finishSpan(span, err, recover())
+		}()
+		return fn(ctx)
+	}
+}
+
+// finishSpan finishes span with err, or with the panic r of the job, which is
+// then propagated.
+func finishSpan(span ddtrace.Span, err error, r interface{}) {
+	if r != nil {
+		span.Finish(tracer.WithError(fmt.Errorf("%v", r)))
+		panic(r)
+	}
+	span.Finish(tracer.WithError(err))
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package gocron
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/go-co-op/gocron"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapJob(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	jobErr := errors.New("job failed")
+	runs := make(chan struct{}, 2)
+	s := gocron.NewScheduler(time.UTC)
+	_, err := s.Every(50*time.Millisecond).Tag("reports", "daily").DoWithJobDetails(WrapJob("report", func(ctx context.Context) error {
+		_, ok := tracer.SpanFromContext(ctx)
+		assert.True(t, ok)
+		select {
+		case runs <- struct{}{}:
+		default:
+		}
+		return jobErr
+	}, WithServiceName("jobs")))
+	require.NoError(t, err)
+	s.StartAsync()
+	<-runs
+	<-runs
+	s.Stop()
+
+	spans := mt.FinishedSpans()
+	require.GreaterOrEqual(t, len(spans), 2)
+	for _, s := range spans {
+		assert.Equal(t, "gocron.job", s.OperationName())
+		assert.Equal(t, "report", s.Tag(ext.ResourceName))
+		assert.Equal(t, "report", s.Tag(tagJobName))
+		assert.Equal(t, "reports,daily", s.Tag(tagJobTags))
+		assert.Equal(t, "jobs", s.Tag(ext.ServiceName))
+		assert.Equal(t, componentName, s.Tag(ext.Component))
+		assert.Equal(t, jobErr, s.Tag(ext.Error))
+		assert.Zero(t, s.ParentID())
+	}
+	// the second run was scheduled
+	scheduled, err := time.Parse(time.RFC3339Nano, spans[1].Tag(tagScheduledTime).(string))
+	require.NoError(t, err)
+	assert.WithinDuration(t, spans[1].StartTime(), scheduled, 50*time.Millisecond)
+	assert.IsType(t, float64(0), spans[1].Tag(tagStartSkew))
+}
+
+func TestFinishSpanPanic(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	assert.Panics(t, func() {
+		finishSpan(tracer.StartSpan("gocron.job"), nil, "job failed")
+	})
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.EqualError(t, spans[0].Tag(ext.Error).(error), "job failed")
+}
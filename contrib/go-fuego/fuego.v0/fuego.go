@@ -0,0 +1,177 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package fuego provides tracing functions for the go-fuego/fuego package (https://github.com/go-fuego/fuego).
+//
+// Fuego middlewares are net/http middlewares served once a route matched the
+// request, so the middleware of this package only needs the OpenAPI
+// description generated by a Fuego server to name request spans after the
+// operationId of their route, and tag them with the operation and the schema
+// of its request body:
+//
+//	s := fuego.NewServer()
+//	fuego.Use(s, fuegotrace.Middleware(s.OpenAPI.Description))
+package fuego // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/go-fuego/fuego.v0"
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const componentName = "go-fuego/fuego.v0"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Middleware returns a middleware, to be registered with fuego.Use, tracing
+// the requests served by a Fuego server. The OpenAPI operations of the
+// requests are looked up in the description returned by spec, such as the
+// Description method of the OpenAPI field of the server. It is called while
+// requests are served, as routes may be registered after the middleware.
+//
+// Client errors responded to requests failing validation mark their span as
+// an error, see WithValidationErrorCheck.
+func Middleware(spec func() *openapi3.T, opts ...Option) func(http.Handler) http.Handler {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/go-fuego/fuego.v0: Configuring Middleware: %#v", cfg)
+	ops := operationCache{spec: spec}
+	return func(next http.Handler) http.Handler {
+		// Validation errors are only known from the status of the response,
+		// once next returned, while the request span is still unfinished.
+		h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			sw, ok := w.(interface{ Status() int })
+			if !ok {
+				return
+			}
+			status := sw.Status()
+			if status >= 400 && status < 500 && cfg.isValidationError(status) {
+				if span, ok := tracer.SpanFromContext(r.Context()); ok {
+					span.SetTag(ext.Error, fmt.Errorf("%d: %s", status, http.StatusText(status)))
+				}
+			}
+		})
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.ignoreRequest(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			route := patternRoute(r.Pattern)
+			spanOpts := []ddtrace.StartSpanOption{
+				tracer.Tag(ext.Component, componentName),
+				tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+			}
+			var resource string
+			if op := ops.lookup(r.Method, route); op != nil {
+				resource = op.OperationID
+				spanOpts = append(spanOpts, operationTags(op)...)
+			}
+			if resource == "" && route != "" {
+				resource = r.Method + " " + route
+			}
+			httptrace.TraceAndServe(h, w, r, &httptrace.ServeConfig{
+				Service:  cfg.serviceName,
+				Resource: resource,
+				Route:    route,
+				SpanOpts: append(spanOpts, cfg.spanOpts...),
+			})
+		})
+	}
+}
+
+// operationCache looks up the OpenAPI operations of routes, caching those
+// found.
+type operationCache struct {
+	spec func() *openapi3.T
+	ops  sync.Map // "METHOD route" -> *openapi3.Operation
+}
+
+// lookup returns the operation serving the given method on route, if any.
+func (c *operationCache) lookup(method, route string) *openapi3.Operation {
+	if route == "" {
+		return nil
+	}
+	key := method + " " + route
+	if op, ok := c.ops.Load(key); ok {
+		return op.(*openapi3.Operation)
+	}
+	doc := c.spec()
+	if doc == nil || doc.Paths == nil {
+		return nil
+	}
+	item := doc.Paths.Find(route)
+	if item == nil {
+		return nil
+	}
+	op := item.GetOperation(method)
+	if op == nil {
+		return nil
+	}
+	c.ops.Store(key, op)
+	return op
+}
+
+// operationTags returns the span options tagging a span with op.
+func operationTags(op *openapi3.Operation) []ddtrace.StartSpanOption {
+	var opts []ddtrace.StartSpanOption
+	if op.OperationID != "" {
+		opts = append(opts, tracer.Tag(ext.OpenAPIOperationID, op.OperationID))
+	}
+	if len(op.Tags) > 0 {
+		opts = append(opts, tracer.Tag(ext.OpenAPIOperationTags, strings.Join(op.Tags, ",")))
+	}
+	if schema := requestSchema(op); schema != "" {
+		opts = append(opts, tracer.Tag(ext.OpenAPIRequestSchema, schema))
+	}
+	return opts
+}
+
+// requestSchema returns the reference of the schema of the request body of op,
+// if any. The JSON schema is preferred when several media types are accepted.
+func requestSchema(op *openapi3.Operation) string {
+	if op.RequestBody == nil || op.RequestBody.Value == nil || len(op.RequestBody.Value.Content) == 0 {
+		return ""
+	}
+	content := op.RequestBody.Value.Content
+	if mt := content["application/json"]; mt != nil && mt.Schema != nil && mt.Schema.Ref != "" {
+		return mt.Schema.Ref
+	}
+	types := make([]string, 0, len(content))
+	for t := range content {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		if mt := content[t]; mt != nil && mt.Schema != nil && mt.Schema.Ref != "" {
+			return mt.Schema.Ref
+		}
+	}
+	return ""
+}
+
+// patternRoute returns the path of the given http.ServeMux pattern, which
+// may be prefixed with a method and a host.
+func patternRoute(pattern string) string {
+	if i := strings.IndexAny(pattern, " \t"); i >= 0 {
+		pattern = strings.TrimLeft(pattern[i:], " \t")
+	}
+	if i := strings.Index(pattern, "/"); i > 0 {
+		pattern = pattern[i:]
+	}
+	return pattern
+}
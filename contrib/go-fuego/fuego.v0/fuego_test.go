@@ -0,0 +1,263 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package fuego
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSpec() *openapi3.T {
+	users := &openapi3.PathItem{
+		Post: &openapi3.Operation{
+			OperationID: "POST_/users",
+			Tags:        []string{"users"},
+			RequestBody: &openapi3.RequestBodyRef{
+				Value: openapi3.NewRequestBody().WithContent(openapi3.Content{
+					"application/json": &openapi3.MediaType{
+						Schema: openapi3.NewSchemaRef("#/components/schemas/User", nil),
+					},
+				}),
+			},
+		},
+	}
+	user := &openapi3.PathItem{
+		Get: &openapi3.Operation{
+			OperationID: "GET_/users/:id",
+			Tags:        []string{"users", "public"},
+		},
+	}
+	return &openapi3.T{
+		OpenAPI: "3.1.0",
+		Paths: openapi3.NewPaths(
+			openapi3.WithPath("/users", users),
+			openapi3.WithPath("/users/{id}", user),
+		),
+	}
+}
+
+// newTestMux returns a mux applying mw to each of its routes, as Fuego does.
+func newTestMux(mw func(http.Handler) http.Handler) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "boom" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"id":"` + r.PathValue("id") + `"}`))
+	})))
+	mux.Handle("POST /users", mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength == 0 {
+			// Fuego responds to requests failing validation with 400 Bad Request.
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})))
+	mux.Handle("GET /health", mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})))
+	return mux
+}
+
+func TestMiddleware(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	mux := newTestMux(Middleware(testSpec, WithServiceName("api")))
+	r := httptest.NewRequest("GET", "/users/123", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "http.request", s.OperationName())
+	assert.Equal(t, "GET_/users/:id", s.Tag(ext.ResourceName))
+	assert.Equal(t, "api", s.Tag(ext.ServiceName))
+	assert.Equal(t, "/users/{id}", s.Tag(ext.HTTPRoute))
+	assert.Equal(t, "200", s.Tag(ext.HTTPCode))
+	assert.Equal(t, "GET_/users/:id", s.Tag(ext.OpenAPIOperationID))
+	assert.Equal(t, "users,public", s.Tag(ext.OpenAPIOperationTags))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, ext.SpanKindServer, s.Tag(ext.SpanKind))
+	assert.Nil(t, s.Tag(ext.Error))
+}
+
+func TestMiddlewareRequestSchema(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	mux := newTestMux(Middleware(testSpec))
+	r := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"alice"}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "POST_/users", spans[0].Tag(ext.ResourceName))
+	assert.Equal(t, "#/components/schemas/User", spans[0].Tag(ext.OpenAPIRequestSchema))
+	assert.Equal(t, "users", spans[0].Tag(ext.OpenAPIOperationTags))
+}
+
+func TestMiddlewareUndocumentedRoute(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	mux := newTestMux(Middleware(testSpec))
+	r := httptest.NewRequest("GET", "/health", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /health", spans[0].Tag(ext.ResourceName))
+	assert.Equal(t, "/health", spans[0].Tag(ext.HTTPRoute))
+	assert.Nil(t, spans[0].Tag(ext.OpenAPIOperationID))
+}
+
+func TestMiddlewareErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		req    *http.Request
+		opts   []Option
+		status int
+		err    string
+	}{
+		{
+			name:   "server",
+			req:    httptest.NewRequest("GET", "/users/boom", nil),
+			status: http.StatusInternalServerError,
+			err:    "500: Internal Server Error",
+		},
+		{
+			name:   "validation",
+			req:    httptest.NewRequest("POST", "/users", nil),
+			status: http.StatusBadRequest,
+			err:    "400: Bad Request",
+		},
+		{
+			name:   "validation-check",
+			req:    httptest.NewRequest("POST", "/users", nil),
+			opts:   []Option{WithValidationErrorCheck(func(int) bool { return false })},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "not-found",
+			req:    httptest.NewRequest("GET", "/users", nil),
+			status: http.StatusMethodNotAllowed,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			mux := newTestMux(Middleware(testSpec, tt.opts...))
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, tt.req)
+			require.Equal(t, tt.status, w.Code)
+
+			spans := mt.FinishedSpans()
+			if tt.status == http.StatusMethodNotAllowed {
+				// Requests matching no route are not served by route middlewares.
+				assert.Empty(t, spans)
+				return
+			}
+			require.Len(t, spans, 1)
+			if tt.err == "" {
+				assert.Nil(t, spans[0].Tag(ext.Error))
+			} else {
+				assert.EqualError(t, spans[0].Tag(ext.Error).(error), tt.err)
+			}
+		})
+	}
+}
+
+func TestMiddlewareIgnoreRequest(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	mux := newTestMux(Middleware(testSpec, WithIgnoreRequest(func(r *http.Request) bool {
+		return r.URL.Path == "/health"
+	})))
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET_/users/:id", spans[0].Tag(ext.ResourceName))
+}
+
+func TestOperationCache(t *testing.T) {
+	var calls int
+	spec := func() *openapi3.T {
+		calls++
+		return testSpec()
+	}
+	ops := operationCache{spec: spec}
+	for i := 0; i < 3; i++ {
+		op := ops.lookup("GET", "/users/{id}")
+		require.NotNil(t, op)
+		assert.Equal(t, "GET_/users/:id", op.OperationID)
+	}
+	assert.Equal(t, 1, calls)
+	// Operations not found yet are looked up again, as they may be registered
+	// later on.
+	assert.Nil(t, ops.lookup("DELETE", "/users/{id}"))
+	assert.Nil(t, ops.lookup("DELETE", "/users/{id}"))
+	assert.Equal(t, 3, calls)
+	assert.Nil(t, ops.lookup("GET", ""))
+}
+
+func TestAnalyticsSettings(t *testing.T) {
+	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
+		mux := newTestMux(Middleware(testSpec, opts...))
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, rate, spans[0].Tag(ext.EventSampleRate))
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, nil)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 1.0, WithAnalytics(true))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, nil, WithAnalytics(false))
+	})
+
+	t.Run("override", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 0.23, WithAnalyticsRate(0.23))
+	})
+}
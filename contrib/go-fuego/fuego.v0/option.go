@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package fuego
+
+import (
+	"math"
+	"net/http"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+)
+
+const defaultServiceName = "fuego"
+
+type config struct {
+	serviceName       string
+	analyticsRate     float64
+	isValidationError func(statusCode int) bool
+	ignoreRequest     func(*http.Request) bool
+	spanOpts          []ddtrace.StartSpanOption
+}
+
+// Option represents an option that can be passed to Middleware.
+type Option func(*config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		serviceName:       namingschema.NewDefaultServiceName(defaultServiceName).GetName(),
+		isValidationError: isValidationError,
+		ignoreRequest:     func(*http.Request) bool { return false },
+	}
+	if internal.BoolEnv("DD_TRACE_FUEGO_ANALYTICS_ENABLED", false) {
+		cfg.analyticsRate = 1.0
+	} else {
+		cfg.analyticsRate = globalconfig.AnalyticsRate()
+	}
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		cfg.spanOpts = append(cfg.spanOpts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	return cfg
+}
+
+// WithServiceName sets the given service name for the started spans.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithSpanOptions applies the given set of options to the started spans.
+func WithSpanOptions(opts ...ddtrace.StartSpanOption) Option {
+	return func(cfg *config) {
+		cfg.spanOpts = append(cfg.spanOpts, opts...)
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithValidationErrorCheck specifies a function telling whether a client
+// error status code is the response to a request failing validation, which
+// marks the span as an error. By default, 400 and 422 status codes are, as
+// Fuego responds to requests failing validation with 400 Bad Request. Server
+// errors always mark the span as an error.
+func WithValidationErrorCheck(fn func(statusCode int) bool) Option {
+	return func(cfg *config) {
+		cfg.isValidationError = fn
+	}
+}
+
+// WithIgnoreRequest specifies a function telling whether a request should not
+// be traced.
+func WithIgnoreRequest(fn func(*http.Request) bool) Option {
+	return func(cfg *config) {
+		cfg.ignoreRequest = fn
+	}
+}
+
+func isValidationError(statusCode int) bool {
+	return statusCode == http.StatusBadRequest || statusCode == http.StatusUnprocessableEntity
+}
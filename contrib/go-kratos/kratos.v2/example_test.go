@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package kratos_test
+
+import (
+	"context"
+
+	kratostrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/go-kratos/kratos.v2"
+
+	"github.com/go-kratos/kratos/v2"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/go-kratos/kratos/v2/transport/http"
+)
+
+func Example() {
+	// Trace the operations served over both transports. The spans are
+	// named after the application, unless DD_SERVICE is set.
+	httpSrv := http.NewServer(http.Address(":8000"), http.Middleware(kratostrace.Server()))
+	grpcSrv := grpc.NewServer(grpc.Address(":9000"), grpc.Middleware(kratostrace.Server()))
+
+	app := kratos.New(kratos.Name("helloworld"), kratos.Server(httpSrv, grpcSrv))
+	app.Run()
+}
+
+func ExampleClient() {
+	// Trace the operations called by a client.
+	conn, err := grpc.DialInsecure(context.Background(),
+		grpc.WithEndpoint("127.0.0.1:9000"),
+		grpc.WithMiddleware(kratostrace.Client()),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer conn.Close()
+}
@@ -0,0 +1,204 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package kratos provides functions to trace the go-kratos/kratos/v2 package (https://github.com/go-kratos/kratos).
+//
+// The middlewares returned by Server and Client trace the operations served
+// and called by Kratos applications, over both the HTTP and gRPC transports,
+// and propagate the span context in the request headers.
+package kratos // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/go-kratos/kratos.v2"
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	kratosapp "github.com/go-kratos/kratos/v2"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+const componentName = "go-kratos/kratos.v2"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+const (
+	tagOperation   = "kratos.operation"
+	tagTransport   = "kratos.transport"
+	tagErrorReason = "kratos.error.reason"
+	tagErrorCode   = "kratos.error.code"
+)
+
+// Server returns a middleware tracing the operations served by a Kratos
+// server, to be added with the http.Middleware or grpc.Middleware server
+// options. The spans are children of the span context found in the request
+// headers, if any.
+func Server(opts ...Option) middleware.Middleware {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/go-kratos/kratos.v2: Configuring Server middleware: %#v", cfg)
+	return func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok || cfg.ignore(tr) {
+				return next(ctx, req)
+			}
+			spanOpts := startSpanOptions(cfg, tr)
+			spanOpts = append(spanOpts,
+				tracer.ServiceName(serverServiceName(ctx, cfg)),
+				tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+				tracer.Measured(),
+			)
+			if spanctx, err := tracer.Extract(headerCarrier{tr.RequestHeader()}); err == nil {
+				spanOpts = append(spanOpts, tracer.ChildOf(spanctx))
+			}
+			span, ctx := tracer.StartSpanFromContext(ctx, cfg.serverSpanName, spanOpts...)
+			reply, err := next(ctx, req)
+			finishSpan(cfg, span, err)
+			return reply, err
+		}
+	}
+}
+
+// Client returns a middleware tracing the operations called by a Kratos
+// client, to be added with the http.WithMiddleware or grpc.WithMiddleware
+// client options. The span context is injected in the request headers.
+func Client(opts ...Option) middleware.Middleware {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/go-kratos/kratos.v2: Configuring Client middleware: %#v", cfg)
+	return func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok || cfg.ignore(tr) {
+				return next(ctx, req)
+			}
+			spanOpts := startSpanOptions(cfg, tr)
+			spanOpts = append(spanOpts, tracer.Tag(ext.SpanKind, ext.SpanKindClient))
+			if cfg.serviceName != "" {
+				spanOpts = append(spanOpts, tracer.ServiceName(cfg.serviceName))
+			} else if svc := globalconfig.ServiceName(); svc != "" {
+				spanOpts = append(spanOpts, tracer.ServiceName(svc))
+			} else {
+				spanOpts = append(spanOpts, tracer.ServiceName(defaultClientServiceName))
+			}
+			if peer := discoveredService(tr.Endpoint()); peer != "" {
+				spanOpts = append(spanOpts, tracer.Tag(ext.PeerService, peer))
+			}
+			span, ctx := tracer.StartSpanFromContext(ctx, cfg.clientSpanName, spanOpts...)
+			if err := tracer.Inject(span.Context(), headerCarrier{tr.RequestHeader()}); err != nil {
+				log.Debug("contrib/go-kratos/kratos.v2: Failed to inject span context into headers, %v", err)
+			}
+			reply, err := next(ctx, req)
+			finishSpan(cfg, span, err)
+			return reply, err
+		}
+	}
+}
+
+func (cfg *config) ignore(tr transport.Transporter) bool {
+	_, ok := cfg.ignored[tr.Operation()]
+	return ok
+}
+
+func startSpanOptions(cfg *config, tr transport.Transporter) []ddtrace.StartSpanOption {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ResourceName(tr.Operation()),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(tagOperation, tr.Operation()),
+		tracer.Tag(tagTransport, tr.Kind().String()),
+	}
+	if tr.Kind() == transport.KindGRPC {
+		opts = append(opts,
+			tracer.SpanType(ext.AppTypeRPC),
+			tracer.Tag(ext.RPCSystem, ext.RPCSystemGRPC),
+			tracer.Tag(ext.GRPCFullMethod, tr.Operation()),
+		)
+	} else {
+		opts = append(opts, tracer.SpanType(ext.SpanTypeWeb))
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	return opts
+}
+
+// serverServiceName returns the service name of the spans of servers: the
+// one configured, or else the one set with DD_SERVICE, or else the name the
+// application is registered with in the service registry.
+func serverServiceName(ctx context.Context, cfg *config) string {
+	if cfg.serviceName != "" {
+		return cfg.serviceName
+	}
+	if svc := globalconfig.ServiceName(); svc != "" {
+		return svc
+	}
+	if app, ok := kratosapp.FromContext(ctx); ok && app.Name() != "" {
+		return app.Name()
+	}
+	return defaultServerServiceName
+}
+
+// discoveredService returns the name of the service resolved by the service
+// registry for endpoints such as "discovery:///helloworld", or an empty string.
+func discoveredService(endpoint string) string {
+	const prefix = "discovery://"
+	if !strings.HasPrefix(endpoint, prefix) {
+		return ""
+	}
+	name := strings.TrimPrefix(endpoint, prefix)
+	// the authority of the endpoint is optional
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+func finishSpan(cfg *config, span ddtrace.Span, err error) {
+	if err == nil {
+		span.Finish()
+		return
+	}
+	if e := errors.FromError(err); e != nil {
+		span.SetTag(tagErrorCode, e.Code)
+		if e.Reason != "" {
+			span.SetTag(tagErrorReason, e.Reason)
+		}
+	}
+	if cfg.errCheck != nil && !cfg.errCheck(err) {
+		span.Finish()
+		return
+	}
+	span.Finish(tracer.WithError(err))
+}
+
+// headerCarrier adapts a transport.Header to tracer.TextMapReader and
+// tracer.TextMapWriter.
+type headerCarrier struct {
+	header transport.Header
+}
+
+// ForeachKey implements tracer.TextMapReader.
+func (c headerCarrier) ForeachKey(handler func(key, val string) error) error {
+	for _, k := range c.header.Keys() {
+		if err := handler(k, c.header.Get(k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set implements tracer.TextMapWriter.
+func (c headerCarrier) Set(key, val string) {
+	c.header.Set(key, val)
+}
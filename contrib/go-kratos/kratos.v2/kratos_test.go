@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package kratos
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	kratosapp "github.com/go-kratos/kratos/v2"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testHeader map[string]string
+
+func (h testHeader) Get(key string) string      { return h[key] }
+func (h testHeader) Set(key, value string)      { h[key] = value }
+func (h testHeader) Add(key, value string)      { h[key] = value }
+func (h testHeader) Values(key string) []string { return []string{h[key]} }
+func (h testHeader) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type testTransport struct {
+	kind      transport.Kind
+	endpoint  string
+	operation string
+	header    testHeader
+}
+
+func (t *testTransport) Kind() transport.Kind            { return t.kind }
+func (t *testTransport) Endpoint() string                { return t.endpoint }
+func (t *testTransport) Operation() string               { return t.operation }
+func (t *testTransport) RequestHeader() transport.Header { return t.header }
+func (t *testTransport) ReplyHeader() transport.Header   { return testHeader{} }
+
+const operation = "/helloworld.v1.Greeter/SayHello"
+
+func TestClientServer(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	header := testHeader{}
+	server := Server()(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		_, ok := tracer.SpanFromContext(ctx)
+		assert.True(t, ok)
+		return "hello", nil
+	})
+	client := Client()(func(ctx context.Context, req interface{}) (interface{}, error) {
+		// the request is served by the server, with the headers sent by the client
+		ctx = transport.NewServerContext(kratosapp.NewContext(context.Background(), kratosapp.New(kratosapp.Name("helloworld"))),
+			&testTransport{kind: transport.KindGRPC, operation: operation, header: header})
+		return server(ctx, req)
+	})
+	ctx := transport.NewClientContext(context.Background(),
+		&testTransport{kind: transport.KindGRPC, endpoint: "discovery:///helloworld", operation: operation, header: header})
+	reply, err := client(ctx, "world")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", reply)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	srv, cli := spans[0], spans[1]
+
+	assert.Equal(t, "kratos.server", srv.OperationName())
+	assert.Equal(t, operation, srv.Tag(ext.ResourceName))
+	assert.Equal(t, "helloworld", srv.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanKindServer, srv.Tag(ext.SpanKind))
+	assert.Equal(t, "grpc", srv.Tag(tagTransport))
+	assert.Equal(t, componentName, srv.Tag(ext.Component))
+	assert.Equal(t, cli.SpanID(), srv.ParentID())
+
+	assert.Equal(t, "kratos.client", cli.OperationName())
+	assert.Equal(t, operation, cli.Tag(ext.ResourceName))
+	assert.Equal(t, defaultClientServiceName, cli.Tag(ext.ServiceName))
+	assert.Equal(t, "helloworld", cli.Tag(ext.PeerService))
+	assert.Equal(t, ext.SpanKindClient, cli.Tag(ext.SpanKind))
+}
+
+func TestServerError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	server := Server(WithServiceName("greeter"))(func(context.Context, interface{}) (interface{}, error) {
+		return nil, errors.NotFound("USER_NOT_FOUND", "no such user")
+	})
+	ctx := transport.NewServerContext(context.Background(),
+		&testTransport{kind: transport.KindHTTP, operation: operation, header: testHeader{}})
+	_, err := server(ctx, nil)
+	require.Error(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "greeter", s.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanTypeWeb, s.Tag(ext.SpanType))
+	assert.Equal(t, "USER_NOT_FOUND", s.Tag(tagErrorReason))
+	assert.Equal(t, int32(404), s.Tag(tagErrorCode))
+	assert.NotNil(t, s.Tag(ext.Error))
+}
+
+func TestIgnoredOperations(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	server := Server(WithIgnoredOperations(operation))(func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	ctx := transport.NewServerContext(context.Background(),
+		&testTransport{kind: transport.KindGRPC, operation: operation, header: testHeader{}})
+	_, err := server(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, mt.FinishedSpans())
+}
+
+func TestDiscoveredService(t *testing.T) {
+	for endpoint, want := range map[string]string{
+		"discovery:///helloworld":      "helloworld",
+		"discovery://etcd/helloworld":  "helloworld",
+		"127.0.0.1:9000":               "",
+		"http://helloworld.local:8000": "",
+	} {
+		assert.Equal(t, want, discoveredService(endpoint), endpoint)
+	}
+}
@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package kratos
+
+import (
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+)
+
+const (
+	defaultClientServiceName = "kratos.client"
+	defaultServerServiceName = "kratos.server"
+)
+
+type config struct {
+	// serviceName is the service name set with WithServiceName, if any.
+	serviceName    string
+	clientSpanName string
+	serverSpanName string
+	analyticsRate  float64
+	errCheck       func(err error) bool
+	ignored        map[string]struct{}
+}
+
+// An Option customizes the config.
+type Option func(cfg *config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		analyticsRate: math.NaN(),
+		ignored:       make(map[string]struct{}),
+	}
+	if internal.BoolEnv("DD_TRACE_KRATOS_ANALYTICS_ENABLED", false) {
+		cfg.analyticsRate = 1.0
+	}
+	cfg.clientSpanName = namingschema.NewClientOutboundOp(
+		"kratos",
+		namingschema.WithOverrideV0("kratos.client"),
+	).GetName()
+	cfg.serverSpanName = namingschema.NewServerInboundOp(
+		"kratos",
+		namingschema.WithOverrideV0("kratos.server"),
+	).GetName()
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithServiceName sets the service name of the started spans. By default, the
+// service name is the one set with DD_SERVICE, or else the name of the Kratos
+// application registered in the service registry for servers.
+func WithServiceName(serviceName string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = serviceName
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithErrorCheck specifies a function fn which determines whether the passed
+// error should be marked as an error. The fn is called whenever an operation
+// finishes with an error.
+func WithErrorCheck(fn func(err error) bool) Option {
+	return func(cfg *config) {
+		cfg.errCheck = fn
+	}
+}
+
+// WithIgnoredOperations specifies operations, such as
+// "/helloworld.v1.Greeter/SayHello", which are not traced.
+func WithIgnoredOperations(operations ...string) Option {
+	return func(cfg *config) {
+		for _, op := range operations {
+			cfg.ignored[op] = struct{}{}
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package mongo
+
+import (
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo/description"
+)
+
+// heartbeatCommands are the commands used by the drivers to monitor the
+// servers of the cluster.
+var heartbeatCommands = map[string]bool{
+	"hello":    true,
+	"isMaster": true,
+	"ismaster": true,
+}
+
+// commandCollection returns the collection targeted by a command, if any.
+func commandCollection(name string, cmd bson.Raw) string {
+	if name == "getMore" {
+		if v, ok := cmd.Lookup("collection").StringValueOK(); ok {
+			return v
+		}
+		return ""
+	}
+	// the value of the command name is the collection for the CRUD commands
+	v, err := cmd.LookupErr(name)
+	if err != nil {
+		return ""
+	}
+	s, _ := v.StringValueOK()
+	return s
+}
+
+// commandFilter returns the filter of a command, if any.
+func commandFilter(name string, cmd bson.Raw) (bson.Raw, bool) {
+	switch name {
+	case "find":
+		return documentValue(cmd.Lookup("filter"))
+	case "count", "distinct", "findAndModify":
+		return documentValue(cmd.Lookup("query"))
+	case "delete", "update":
+		// the filter of the first statement of the bulk write
+		stmt, ok := firstDocument(cmd.Lookup(name + "s"))
+		if !ok {
+			return nil, false
+		}
+		return documentValue(stmt.Lookup("q"))
+	case "aggregate":
+		// the filter of the leading $match stage
+		stage, ok := firstDocument(cmd.Lookup("pipeline"))
+		if !ok {
+			return nil, false
+		}
+		return documentValue(stage.Lookup("$match"))
+	}
+	return nil, false
+}
+
+// filterShape returns the shape of filter: its fields and operators, with
+// all of the values replaced by "?", e.g. {"age":{"$gt":"?"}}.
+func filterShape(filter bson.Raw) string {
+	var b strings.Builder
+	writeDocumentShape(&b, filter)
+	return b.String()
+}
+
+func writeDocumentShape(b *strings.Builder, doc bson.Raw) {
+	elems, err := doc.Elements()
+	if err != nil {
+		b.WriteString(`"?"`)
+		return
+	}
+	b.WriteByte('{')
+	for i, elem := range elems {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Quote(elem.Key()))
+		b.WriteByte(':')
+		writeValueShape(b, elem.Value())
+	}
+	b.WriteByte('}')
+}
+
+func writeValueShape(b *strings.Builder, v bson.RawValue) {
+	switch v.Type {
+	case bsontype.EmbeddedDocument:
+		writeDocumentShape(b, v.Document())
+	case bsontype.Array:
+		// arrays of documents, such as the operands of $and and $or, are
+		// part of the shape, the arrays of values are not.
+		vals, err := v.Array().Values()
+		if err != nil || len(vals) == 0 || vals[0].Type != bsontype.EmbeddedDocument {
+			b.WriteString(`"?"`)
+			return
+		}
+		b.WriteByte('[')
+		for i, val := range vals {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeValueShape(b, val)
+		}
+		b.WriteByte(']')
+	default:
+		b.WriteString(`"?"`)
+	}
+}
+
+// replyDocumentsReturned returns the number of documents returned by the
+// reply of a command reading a cursor.
+func replyDocumentsReturned(name string, reply bson.Raw) (int, bool) {
+	var batch string
+	switch name {
+	case "find", "aggregate":
+		batch = "firstBatch"
+	case "getMore":
+		batch = "nextBatch"
+	default:
+		return 0, false
+	}
+	v := reply.Lookup("cursor", batch)
+	if v.Type != bsontype.Array {
+		return 0, false
+	}
+	vals, err := v.Array().Values()
+	if err != nil {
+		return 0, false
+	}
+	return len(vals), true
+}
+
+// replyDocumentsModified returns the number of documents inserted, updated or
+// deleted by a command, found in its reply.
+func replyDocumentsModified(name string, reply bson.Raw) (int64, bool) {
+	switch name {
+	case "insert", "delete":
+		return intValue(reply.Lookup("n"))
+	case "update":
+		return intValue(reply.Lookup("nModified"))
+	case "findAndModify":
+		return intValue(reply.Lookup("lastErrorObject", "n"))
+	}
+	return 0, false
+}
+
+// topologyName returns the name of the topology tagged on the spans.
+func topologyName(kind description.TopologyKind) string {
+	switch kind {
+	case description.Single:
+		return "single"
+	case description.ReplicaSet, description.ReplicaSetNoPrimary, description.ReplicaSetWithPrimary:
+		return "replica_set"
+	case description.Sharded:
+		return "sharded"
+	case description.LoadBalanced:
+		return "load_balanced"
+	}
+	return ""
+}
+
+func documentValue(v bson.RawValue) (bson.Raw, bool) {
+	if v.Type != bsontype.EmbeddedDocument {
+		return nil, false
+	}
+	return v.Document(), true
+}
+
+// firstDocument returns the first document of the array v.
+func firstDocument(v bson.RawValue) (bson.Raw, bool) {
+	if v.Type != bsontype.Array {
+		return nil, false
+	}
+	vals, err := v.Array().Values()
+	if err != nil || len(vals) == 0 {
+		return nil, false
+	}
+	return documentValue(vals[0])
+}
+
+func intValue(v bson.RawValue) (int64, bool) {
+	switch v.Type {
+	case bsontype.Int32:
+		return int64(v.Int32()), true
+	case bsontype.Int64:
+		return v.Int64(), true
+	case bsontype.Double:
+		return int64(v.Double()), true
+	}
+	return 0, false
+}
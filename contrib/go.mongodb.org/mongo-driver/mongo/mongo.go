@@ -15,6 +15,7 @@ import (
 	"math"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
@@ -39,11 +40,15 @@ type spanKey struct {
 
 type monitor struct {
 	sync.Mutex
-	spans map[spanKey]ddtrace.Span
-	cfg   *config
+	spans    map[spanKey]ddtrace.Span
+	cfg      *config
+	topology atomic.Value // string, name of the topology of the cluster
 }
 
 func (m *monitor) Started(ctx context.Context, evt *event.CommandStartedEvent) {
+	if m.cfg.skipHeartbeats && heartbeatCommands[evt.CommandName] {
+		return
+	}
 	hostname, port := peerInfo(evt)
 	b, _ := bson.MarshalExtJSON(evt.Command, false, false)
 	opts := []ddtrace.StartSpanOption{
@@ -59,6 +64,16 @@ func (m *monitor) Started(ctx context.Context, evt *event.CommandStartedEvent) {
 		tracer.Tag(ext.Component, componentName),
 		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
 		tracer.Tag(ext.DBSystem, ext.DBSystemMongoDB),
+		tracer.Tag("db.operation", evt.CommandName),
+	}
+	if coll := commandCollection(evt.CommandName, evt.Command); coll != "" {
+		opts = append(opts, tracer.Tag(ext.MongoDBCollection, coll))
+	}
+	if filter, ok := commandFilter(evt.CommandName, evt.Command); ok {
+		opts = append(opts, tracer.Tag("db.mongodb.filter", filterShape(filter)))
+	}
+	if topology, _ := m.topology.Load().(string); topology != "" {
+		opts = append(opts, tracer.Tag("db.mongodb.topology", topology))
 	}
 	if !math.IsNaN(m.cfg.analyticsRate) {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, m.cfg.analyticsRate))
@@ -74,14 +89,14 @@ func (m *monitor) Started(ctx context.Context, evt *event.CommandStartedEvent) {
 }
 
 func (m *monitor) Succeeded(_ context.Context, evt *event.CommandSucceededEvent) {
-	m.Finished(&evt.CommandFinishedEvent, nil)
+	m.Finished(&evt.CommandFinishedEvent, nil, evt.Reply)
 }
 
 func (m *monitor) Failed(_ context.Context, evt *event.CommandFailedEvent) {
-	m.Finished(&evt.CommandFinishedEvent, fmt.Errorf("%s", evt.Failure))
+	m.Finished(&evt.CommandFinishedEvent, fmt.Errorf("%s", evt.Failure), nil)
 }
 
-func (m *monitor) Finished(evt *event.CommandFinishedEvent, err error) {
+func (m *monitor) Finished(evt *event.CommandFinishedEvent, err error, reply bson.Raw) {
 	key := spanKey{
 		ConnectionID: evt.ConnectionID,
 		RequestID:    evt.RequestID,
@@ -95,21 +110,64 @@ func (m *monitor) Finished(evt *event.CommandFinishedEvent, err error) {
 	if !ok {
 		return
 	}
+	if n, ok := replyDocumentsReturned(evt.CommandName, reply); ok {
+		span.SetTag("db.mongodb.documents_returned", n)
+	}
+	if n, ok := replyDocumentsModified(evt.CommandName, reply); ok {
+		span.SetTag("db.mongodb.documents_modified", n)
+	}
 	span.Finish(tracer.WithError(err))
 }
 
+// topologyChanged records the topology of the cluster the spans are tagged
+// with.
+func (m *monitor) topologyChanged(evt *event.TopologyDescriptionChangedEvent) {
+	m.topology.Store(topologyName(evt.NewDescription.Kind))
+}
+
 // NewMonitor creates a new mongodb event CommandMonitor.
 func NewMonitor(opts ...Option) *event.CommandMonitor {
+	return newMonitor(opts...).commandMonitor()
+}
+
+// NewMonitors creates a new mongodb event CommandMonitor, like NewMonitor, along
+// with a ServerMonitor tagging its spans with the topology of the cluster, e.g.
+// "replica_set" or "sharded". Both must be set on the client. The ServerMonitor
+// wraps a copy of next, which may be nil, and forwards it all the events; next
+// itself is left unchanged:
+//
+//	cm, sm := mongotrace.NewMonitors(next)
+//	opts := options.Client().SetMonitor(cm).SetServerMonitor(sm)
+func NewMonitors(next *event.ServerMonitor, opts ...Option) (*event.CommandMonitor, *event.ServerMonitor) {
+	m := newMonitor(opts...)
+	sm := new(event.ServerMonitor)
+	if next != nil {
+		*sm = *next
+	}
+	forward := sm.TopologyDescriptionChanged
+	sm.TopologyDescriptionChanged = func(evt *event.TopologyDescriptionChangedEvent) {
+		m.topologyChanged(evt)
+		if forward != nil {
+			forward(evt)
+		}
+	}
+	return m.commandMonitor(), sm
+}
+
+func newMonitor(opts ...Option) *monitor {
 	cfg := new(config)
 	defaults(cfg)
 	for _, opt := range opts {
 		opt(cfg)
 	}
 	log.Debug("contrib/go.mongodb.org/mongo-driver/mongo: Creating Monitor: %#v", cfg)
-	m := &monitor{
+	return &monitor{
 		spans: make(map[spanKey]ddtrace.Span),
 		cfg:   cfg,
 	}
+}
+
+func (m *monitor) commandMonitor() *event.CommandMonitor {
 	return &event.CommandMonitor{
 		Started:   m.Started,
 		Succeeded: m.Succeeded,
@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -21,6 +22,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -81,6 +83,94 @@ func Test(t *testing.T) {
 	assert.Equal(t, "go.mongodb.org/mongo-driver/mongo", s.Tag(ext.Component))
 	assert.Equal(t, ext.SpanKindClient, s.Tag(ext.SpanKind))
 	assert.Equal(t, "mongodb", s.Tag(ext.DBSystem))
+	assert.Equal(t, "insert", s.Tag("db.operation"))
+	assert.Equal(t, "test-collection", s.Tag(ext.MongoDBCollection))
+	assert.EqualValues(t, 1, s.Tag("db.mongodb.documents_modified"))
+}
+
+func TestCRUDTags(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	var topologies int32
+	next := &event.ServerMonitor{
+		TopologyDescriptionChanged: func(*event.TopologyDescriptionChangedEvent) {
+			atomic.AddInt32(&topologies, 1)
+		},
+	}
+	cm, sm := NewMonitors(next, WithSkipHeartbeats(true))
+	opts := options.Client().
+		SetMonitor(cm).
+		SetServerMonitor(sm).
+		ApplyURI("mongodb://localhost:27017/?connect=direct")
+	client, err := mongo.Connect(ctx, opts)
+	require.NoError(t, err)
+	defer client.Disconnect(ctx)
+
+	coll := client.Database("test-database").Collection("test-crud")
+	_, err = coll.InsertMany(ctx, []interface{}{
+		bson.D{{Key: "name", Value: "a"}, {Key: "age", Value: 20}},
+		bson.D{{Key: "name", Value: "b"}, {Key: "age", Value: 30}},
+	})
+	require.NoError(t, err)
+	cur, err := coll.Find(ctx, bson.D{{Key: "age", Value: bson.D{{Key: "$gt", Value: 10}}}})
+	require.NoError(t, err)
+	require.NoError(t, cur.All(ctx, new([]bson.D)))
+	_, err = coll.DeleteMany(ctx, bson.D{{Key: "name", Value: bson.D{{Key: "$in", Value: bson.A{"a", "b"}}}}})
+	require.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 3)
+	for _, s := range spans {
+		assert.Equal(t, "test-crud", s.Tag(ext.MongoDBCollection))
+		assert.Equal(t, "single", s.Tag("db.mongodb.topology"))
+	}
+	assert.EqualValues(t, 2, spans[0].Tag("db.mongodb.documents_modified"))
+	assert.Equal(t, "find", spans[1].Tag("db.operation"))
+	assert.Equal(t, `{"age":{"$gt":"?"}}`, spans[1].Tag("db.mongodb.filter"))
+	assert.Equal(t, 2, spans[1].Tag("db.mongodb.documents_returned"))
+	assert.Equal(t, `{"name":{"$in":"?"}}`, spans[2].Tag("db.mongodb.filter"))
+	assert.EqualValues(t, 2, spans[2].Tag("db.mongodb.documents_modified"))
+	// the events are forwarded to next, which is left unchanged
+	assert.NotZero(t, atomic.LoadInt32(&topologies))
+	assert.NotSame(t, next, sm)
+}
+
+func TestNewMonitors(t *testing.T) {
+	next := &event.ServerMonitor{}
+	_, sm := NewMonitors(next)
+	assert.Nil(t, next.TopologyDescriptionChanged)
+	assert.NotNil(t, sm.TopologyDescriptionChanged)
+
+	// next is optional
+	_, sm = NewMonitors(nil)
+	assert.NotPanics(t, func() {
+		sm.TopologyDescriptionChanged(&event.TopologyDescriptionChangedEvent{})
+	})
+}
+
+func TestFilterShape(t *testing.T) {
+	for _, tt := range []struct {
+		filter bson.D
+		want   string
+	}{
+		{filter: bson.D{}, want: `{}`},
+		{filter: bson.D{{Key: "name", Value: "a"}}, want: `{"name":"?"}`},
+		{
+			filter: bson.D{{Key: "$or", Value: bson.A{
+				bson.D{{Key: "age", Value: bson.D{{Key: "$lt", Value: 10}}}},
+				bson.D{{Key: "tags", Value: bson.A{"a", "b"}}},
+			}}},
+			want: `{"$or":[{"age":{"$lt":"?"}},{"tags":"?"}]}`,
+		},
+	} {
+		raw, err := bson.Marshal(tt.filter)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, filterShape(raw))
+	}
 }
 
 func TestAnalyticsSettings(t *testing.T) {
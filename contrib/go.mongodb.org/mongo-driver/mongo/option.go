@@ -15,9 +15,10 @@ import (
 const defaultServiceName = "mongo"
 
 type config struct {
-	serviceName   string
-	spanName      string
-	analyticsRate float64
+	serviceName    string
+	spanName       string
+	analyticsRate  float64
+	skipHeartbeats bool
 }
 
 // Option represents an option that can be passed to Dial.
@@ -68,3 +69,11 @@ func WithAnalyticsRate(rate float64) Option {
 		}
 	}
 }
+
+// WithSkipHeartbeats reports whether to skip tracing the commands used to
+// monitor the servers, namely hello and isMaster.
+func WithSkipHeartbeats(skip bool) Option {
+	return func(cfg *config) {
+		cfg.skipHeartbeats = skip
+	}
+}
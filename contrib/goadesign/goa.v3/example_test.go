@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package goa_test
+
+import (
+	"context"
+	"net/http"
+
+	goatrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/goadesign/goa.v3"
+
+	goahttp "goa.design/goa/v3/http"
+	goa "goa.design/goa/v3/pkg"
+)
+
+// endpoints stands for the Endpoints type generated by Goa for a service.
+type endpoints struct {
+	Add goa.Endpoint
+}
+
+func (e *endpoints) Use(m func(goa.Endpoint) goa.Endpoint) {
+	e.Add = m(e.Add)
+}
+
+func Example() {
+	e := &endpoints{Add: func(context.Context, interface{}) (interface{}, error) { return 3, nil }}
+	// Name the request spans after the service methods.
+	e.Use(goatrace.EndpointMiddleware())
+
+	mux := goahttp.NewMuxer()
+	// Pass the error formatter to the generated server constructor, such
+	// as calcsvr.New(e, mux, goahttp.RequestDecoder, goahttp.ResponseEncoder,
+	// nil, formatter), so that validation errors are tagged.
+	formatter := goatrace.ErrorFormatter(goahttp.NewErrorResponse)
+	_ = formatter
+
+	// Trace the requests served by the server.
+	http.ListenAndServe(":8080", goatrace.Middleware(goatrace.WithServiceName("calc"))(mux))
+}
@@ -0,0 +1,11 @@
+module gopkg.in/DataDog/dd-trace-go.v1/contrib/goadesign/goa.v3
+
+go 1.25.1
+
+require (
+	github.com/stretchr/testify v1.9.0
+	goa.design/goa/v3 v3.16.1
+	gopkg.in/DataDog/dd-trace-go.v1 v1.54.0
+)
+
+replace gopkg.in/DataDog/dd-trace-go.v1 => ../../..
@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package goa provides tracing functions for the goadesign/goa v3 package (https://github.com/goadesign/goa).
+//
+// The requests served by the HTTP servers generated by Goa are traced by
+// Middleware. The spans are named after the service and method of the design
+// serving them by EndpointMiddleware, or by ErrorFormatter when the request
+// fails before reaching the endpoint, such as when its payload fails
+// validation:
+//
+//	endpoints := calc.NewEndpoints(svc)
+//	endpoints.Use(goatrace.EndpointMiddleware())
+//	server := calcsvr.New(endpoints, mux, dec, enc, eh, goatrace.ErrorFormatter(goahttp.NewErrorResponse))
+//	handler := goatrace.Middleware()(mux)
+package goa // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/goadesign/goa.v3"
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	goahttp "goa.design/goa/v3/http"
+	goa "goa.design/goa/v3/pkg"
+)
+
+const componentName = "goadesign/goa.v3"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Tags set on the request spans.
+const (
+	tagService    = "goa.service"
+	tagMethod     = "goa.method"
+	tagErrorName  = "goa.error.name"
+	tagErrorField = "goa.error.field"
+)
+
+// Middleware returns a net/http middleware tracing the requests served by a
+// Goa HTTP server. Use EndpointMiddleware and ErrorFormatter to name the spans
+// after the service methods serving the requests.
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/goadesign/goa.v3: Configuring Middleware: %#v", cfg)
+	spanOpts := append([]ddtrace.StartSpanOption{
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+	}, cfg.spanOpts...)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.ignoreRequest(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			httptrace.TraceAndServe(next, w, r, &httptrace.ServeConfig{
+				Service:  cfg.serviceName,
+				SpanOpts: spanOpts,
+			})
+		})
+	}
+}
+
+// EndpointMiddleware returns a Goa endpoint middleware, to be registered with
+// the Use method of the generated Endpoints, naming the request spans after
+// the service and method of the endpoints, as found in the design.
+func EndpointMiddleware(opts ...Option) func(goa.Endpoint) goa.Endpoint {
+	cfg := newConfig(opts...)
+	return func(e goa.Endpoint) goa.Endpoint {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			span, ok := tracer.SpanFromContext(ctx)
+			if !ok {
+				return e(ctx, req)
+			}
+			tagMethodSpan(span, ctx)
+			res, err := e(ctx, req)
+			if err != nil {
+				tagServiceError(cfg, span, err)
+			}
+			return res, err
+		}
+	}
+}
+
+// ErrorFormatter returns a Goa HTTP error formatter, to be passed to the
+// generated server constructor, which tags the request spans with the errors
+// formatted by fn, such as goahttp.NewErrorResponse. The errors returned by the
+// payload validation mark the span as an error, see WithValidationErrorCheck.
+func ErrorFormatter(fn func(context.Context, error) goahttp.Statuser, opts ...Option) func(context.Context, error) goahttp.Statuser {
+	cfg := newConfig(opts...)
+	return func(ctx context.Context, err error) goahttp.Statuser {
+		if span, ok := tracer.SpanFromContext(ctx); ok {
+			// The request may have failed before reaching the endpoint.
+			tagMethodSpan(span, ctx)
+			tagServiceError(cfg, span, err)
+		}
+		return fn(ctx, err)
+	}
+}
+
+// tagMethodSpan names span after the service method found in ctx.
+func tagMethodSpan(span ddtrace.Span, ctx context.Context) {
+	service, _ := ctx.Value(goa.ServiceKey).(string)
+	method, _ := ctx.Value(goa.MethodKey).(string)
+	if service == "" || method == "" {
+		return
+	}
+	span.SetTag(ext.ResourceName, service+"."+method)
+	span.SetTag(tagService, service)
+	span.SetTag(tagMethod, method)
+}
+
+// tagServiceError tags span with err when it is a goa.ServiceError.
+func tagServiceError(cfg *config, span ddtrace.Span, err error) {
+	var serr *goa.ServiceError
+	if !errors.As(err, &serr) {
+		return
+	}
+	span.SetTag(tagErrorName, serr.Name)
+	if serr.Field != nil {
+		span.SetTag(tagErrorField, *serr.Field)
+	}
+	if cfg.isValidationError(serr.Name) {
+		span.SetTag(ext.Error, err)
+	}
+}
@@ -0,0 +1,115 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package goa
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	goahttp "goa.design/goa/v3/http"
+	goa "goa.design/goa/v3/pkg"
+)
+
+// methodHandler mimics the HTTP handlers generated by Goa, which set the
+// service and method in the context before decoding the payload and calling
+// the endpoint.
+func methodHandler(endpoint goa.Endpoint, decodeErr error) http.Handler {
+	formatter := ErrorFormatter(goahttp.NewErrorResponse)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), goa.MethodKey, "add")
+		ctx = context.WithValue(ctx, goa.ServiceKey, "calc")
+		if decodeErr != nil {
+			w.WriteHeader(formatter(ctx, decodeErr).StatusCode())
+			return
+		}
+		if _, err := endpoint(ctx, nil); err != nil {
+			w.WriteHeader(formatter(ctx, err).StatusCode())
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	endpoint := EndpointMiddleware()(func(context.Context, interface{}) (interface{}, error) {
+		return 3, nil
+	})
+	h := Middleware(WithServiceName("calc-svc"))(methodHandler(endpoint, nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/add/1/2", nil))
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "http.request", s.OperationName())
+	assert.Equal(t, "calc.add", s.Tag(ext.ResourceName))
+	assert.Equal(t, "calc-svc", s.Tag(ext.ServiceName))
+	assert.Equal(t, "calc", s.Tag(tagService))
+	assert.Equal(t, "add", s.Tag(tagMethod))
+	assert.Equal(t, "200", s.Tag(ext.HTTPCode))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, ext.SpanKindServer, s.Tag(ext.SpanKind))
+	assert.Nil(t, s.Tag(ext.Error))
+}
+
+func TestValidationError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	endpoint := EndpointMiddleware()(func(context.Context, interface{}) (interface{}, error) {
+		t.Fatal("endpoint called with an invalid payload")
+		return nil, nil
+	})
+	h := Middleware()(methodHandler(endpoint, goa.MissingFieldError("a", "body")))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/add", nil))
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "calc.add", s.Tag(ext.ResourceName))
+	assert.Equal(t, "missing_field", s.Tag(tagErrorName))
+	assert.NotNil(t, s.Tag(tagErrorField))
+	assert.Equal(t, "400", s.Tag(ext.HTTPCode))
+	assert.NotNil(t, s.Tag(ext.Error))
+}
+
+func TestEndpointError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	endpoint := EndpointMiddleware()(func(context.Context, interface{}) (interface{}, error) {
+		return nil, goa.PermanentError("not_found", "no such value")
+	})
+	h := Middleware(WithValidationErrorCheck(func(string) bool { return false }))(methodHandler(endpoint, nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/add/1/2", nil))
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "not_found", s.Tag(tagErrorName))
+	assert.Nil(t, s.Tag(ext.Error))
+}
+
+func TestIgnoreRequest(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	h := Middleware(WithIgnoreRequest(func(r *http.Request) bool {
+		return r.URL.Path == "/healthz"
+	}))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+
+	assert.Empty(t, mt.FinishedSpans())
+}
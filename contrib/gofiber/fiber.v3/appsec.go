@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package fiber
+
+import (
+	"net/http"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/httpsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+// withAppSec passes the execution down the line while monitoring the request
+// with AppSec, by serving it through httpsec.WrapHandler as a net/http
+// request. The next handlers aren't called if AppSec blocks the request.
+func withAppSec(c fiber.Ctx, span tracer.Span) error {
+	var r http.Request
+	if err := fasthttpadaptor.ConvertRequest(c.RequestCtx(), &r, true); err != nil {
+		log.Debug("gofiber/fiber.v3: failed to convert request for appsec: %v", err)
+		return c.Next()
+	}
+	params := make(map[string]string)
+	for _, n := range c.Route().Params {
+		params[n] = c.Params(n)
+	}
+	w := &responseWriter{c: c, header: make(http.Header)}
+	var err error
+	handler := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		c.SetContext(r.Context())
+		err = c.Next()
+		// If the error is a monitoring one, it means appsec actions will take care of writing the response
+		// and handling the error. Don't call the fiber error handler in this case
+		if _, ok := err.(*httpsec.MonitoringError); ok {
+			err = nil
+		}
+		// Let appsec monitor the response headers set by the handlers.
+		c.Response().Header.VisitAll(func(k, v []byte) {
+			w.header.Add(string(k), string(v))
+		})
+	})
+	httpsec.WrapHandler(handler, span, params).ServeHTTP(w, r.WithContext(c.Context()))
+	return err
+}
+
+// responseWriter is an http.ResponseWriter writing to the response of a fiber
+// request, such as the one of a request blocked by appsec.
+type responseWriter struct {
+	c           fiber.Ctx
+	header      http.Header
+	wroteHeader bool
+}
+
+// Header implements http.ResponseWriter.
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *responseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	for k, vs := range w.header {
+		w.c.Response().Header.Del(k)
+		for _, v := range vs {
+			w.c.Response().Header.Add(k, v)
+		}
+	}
+	w.c.Status(status)
+}
+
+// Write implements http.ResponseWriter.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.c.Write(b)
+}
+
+// Status returns the status code of the response, for httpsec.WrapHandler.
+func (w *responseWriter) Status() int {
+	return w.c.Response().StatusCode()
+}
@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package fiber_test
+
+import (
+	fibertrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/gofiber/fiber.v3"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func Example() {
+	// Start the tracer
+	tracer.Start()
+	defer tracer.Stop()
+
+	// Create a fiber v3 Router
+	router := fiber.New()
+
+	// Use the tracer middleware with the default service name "fiber",
+	// ignoring health checks.
+	router.Use(fibertrace.Middleware(fibertrace.WithIgnorePaths("/health")))
+
+	// Set up some endpoints.
+	router.Get("/health", func(c fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	router.Get("/user/:id", func(c fiber.Ctx) error {
+		// The span of the request is available from c.Context().
+		return c.SendString(c.Params("id"))
+	})
+
+	// And start gathering request traces
+	router.Listen(":8080")
+}
@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package fiber provides tracing functions for tracing the fiber package (https://github.com/gofiber/fiber).
+package fiber // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/gofiber/fiber.v3"
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+const componentName = "gofiber/fiber.v3"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Middleware returns middleware that will trace incoming requests.
+//
+// Errors returned by the next handlers are passed to the error handler of the
+// application by the middleware, so that the span has the status code of the
+// response it writes.
+func Middleware(opts ...Option) fiber.Handler {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("gofiber/fiber.v3: Middleware: %#v", cfg)
+	return func(c fiber.Ctx) error {
+		if cfg.ignoreRequest(c) {
+			return c.Next()
+		}
+		opts := []ddtrace.StartSpanOption{
+			tracer.SpanType(ext.SpanTypeWeb),
+			tracer.ServiceName(cfg.serviceName),
+			tracer.Tag(ext.HTTPMethod, c.Method()),
+			tracer.Tag(ext.HTTPURL, string(c.Request().URI().PathOriginal())),
+			tracer.Measured(),
+		}
+		if !math.IsNaN(cfg.analyticsRate) {
+			opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+		}
+		// Create a http.Header object so that a parent trace can be extracted. Fiber uses a non-standard header carrier
+		h := http.Header{}
+		for k, vs := range c.GetReqHeaders() {
+			for _, v := range vs {
+				h.Add(k, v)
+			}
+		}
+		if spanctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(h)); err == nil {
+			opts = append(opts, tracer.ChildOf(spanctx))
+		}
+		opts = append(opts, cfg.spanOpts...)
+		opts = append(opts, tracer.Tag(ext.Component, componentName))
+		opts = append(opts, tracer.Tag(ext.SpanKind, ext.SpanKindServer))
+		span, ctx := tracer.StartSpanFromContext(c.Context(), cfg.spanName, opts...)
+
+		defer span.Finish()
+
+		// pass the span through the request context
+		c.SetContext(ctx)
+
+		// pass the execution down the line
+		var err error
+		if appsec.Enabled() {
+			err = withAppSec(c, span)
+		} else {
+			err = c.Next()
+		}
+		spanErr := err
+		if err != nil {
+			// Let the application write the error response now, so that its
+			// status code is known.
+			err = c.App().ErrorHandler(c, err)
+		}
+
+		// The route is the one of the handler, once the request went through it.
+		span.SetTag(ext.ResourceName, cfg.resourceNamer(c))
+		span.SetTag(ext.HTTPRoute, c.Route().Path)
+
+		status := c.Response().StatusCode()
+		// on the off chance we don't yet have a status after the rest of the things have run
+		if status == 0 {
+			// 0 - means we do not have a status code at this point
+			// in case the response was returned by a middleware without one
+			status = http.StatusOK
+		}
+		span.SetTag(ext.HTTPCode, strconv.Itoa(status))
+
+		if err != nil {
+			// the error handler failed
+			span.SetTag(ext.Error, err)
+		} else if cfg.isStatusError(status) {
+			if spanErr == nil {
+				// mark 5xx server error
+				spanErr = fmt.Errorf("%d: %s", status, http.StatusText(status))
+			}
+			span.SetTag(ext.Error, spanErr)
+		}
+		return err
+	}
+}
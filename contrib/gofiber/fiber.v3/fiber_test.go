@@ -0,0 +1,293 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package fiber
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/namingschematest"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace200(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	router := fiber.New()
+	router.Use(Middleware(WithServiceName("foobar")))
+	router.Get("/user/:id", func(c fiber.Ctx) error {
+		return c.SendString(c.Params("id"))
+	})
+
+	resp, err := router.Test(httptest.NewRequest("GET", "/user/123", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(200, resp.StatusCode)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal("http.request", span.OperationName())
+	assert.Equal(ext.SpanTypeWeb, span.Tag(ext.SpanType))
+	assert.Equal("foobar", span.Tag(ext.ServiceName))
+	assert.Equal("GET /user/:id", span.Tag(ext.ResourceName))
+	assert.Equal("/user/:id", span.Tag(ext.HTTPRoute))
+	assert.Equal("200", span.Tag(ext.HTTPCode))
+	assert.Equal("GET", span.Tag(ext.HTTPMethod))
+	assert.Equal("/user/123", span.Tag(ext.HTTPURL))
+	assert.Equal(ext.SpanKindServer, span.Tag(ext.SpanKind))
+	assert.Equal("gofiber/fiber.v3", span.Tag(ext.Component))
+	assert.Nil(span.Tag(ext.Error))
+}
+
+func TestStatusError(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	router := fiber.New()
+	router.Use(Middleware(WithServiceName("foobar")))
+	code := 500
+	wantErr := fmt.Sprintf("%d: %s", code, http.StatusText(code))
+	router.Get("/err", func(c fiber.Ctx) error {
+		return c.Status(code).SendString(fmt.Sprintf("%d!", code))
+	})
+
+	resp, err := router.Test(httptest.NewRequest("GET", "/err", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(500, resp.StatusCode)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal("500", spans[0].Tag(ext.HTTPCode))
+	assert.Equal(wantErr, spans[0].Tag(ext.Error).(error).Error())
+}
+
+func TestErrorHandler(t *testing.T) {
+	handlerErr := errors.New("oops")
+	newRouter := func(opts ...Option) *fiber.App {
+		router := fiber.New()
+		router.Use(Middleware(opts...))
+		router.Get("/error", func(c fiber.Ctx) error {
+			return handlerErr
+		})
+		router.Get("/teapot", func(c fiber.Ctx) error {
+			return fiber.ErrTeapot
+		})
+		return router
+	}
+
+	t.Run("error", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		resp, err := newRouter().Test(httptest.NewRequest("GET", "/error", nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, 500, resp.StatusCode)
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "500", spans[0].Tag(ext.HTTPCode))
+		assert.Equal(t, handlerErr, spans[0].Tag(ext.Error))
+		assert.Equal(t, "GET /error", spans[0].Tag(ext.ResourceName))
+	})
+
+	t.Run("client-error", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		resp, err := newRouter().Test(httptest.NewRequest("GET", "/teapot", nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, fiber.StatusTeapot, resp.StatusCode)
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "418", spans[0].Tag(ext.HTTPCode))
+		assert.Nil(t, spans[0].Tag(ext.Error))
+	})
+
+	t.Run("status-check", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		router := newRouter(WithStatusCheck(func(statusCode int) bool {
+			return statusCode >= 400
+		}))
+		resp, err := router.Test(httptest.NewRequest("GET", "/teapot", nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, fiber.ErrTeapot, spans[0].Tag(ext.Error))
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		resp, err := newRouter().Test(httptest.NewRequest("GET", "/unknown", nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, 404, resp.StatusCode)
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "404", spans[0].Tag(ext.HTTPCode))
+		assert.Nil(t, spans[0].Tag(ext.Error))
+	})
+}
+
+func TestContext(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	router := fiber.New()
+	router.Use(Middleware())
+	router.Get("/", func(c fiber.Ctx) error {
+		span, _ := tracer.StartSpanFromContext(c.Context(), "child")
+		defer span.Finish()
+		return c.SendString("test")
+	})
+
+	parent := tracer.StartSpan("parent")
+	r := httptest.NewRequest("GET", "/", nil)
+	require.NoError(t, tracer.Inject(parent.Context(), tracer.HTTPHeadersCarrier(r.Header)))
+	resp, err := router.Test(r)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, spans[1].SpanID(), spans[0].ParentID())
+	assert.Equal(t, parent.Context().SpanID(), spans[1].ParentID())
+	assert.Equal(t, parent.Context().TraceID(), spans[0].TraceID())
+}
+
+func TestIgnore(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"paths": WithIgnorePaths("/health"),
+		"request": WithIgnoreRequest(func(c fiber.Ctx) bool {
+			return c.Path() == "/health"
+		}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			router := fiber.New()
+			router.Use(Middleware(opt))
+			router.Get("/health", func(c fiber.Ctx) error {
+				return c.SendString("ok")
+			})
+			router.Get("/user", func(c fiber.Ctx) error {
+				return c.SendString("user")
+			})
+
+			for _, path := range []string{"/health", "/user"} {
+				resp, err := router.Test(httptest.NewRequest("GET", path, nil))
+				require.NoError(t, err)
+				resp.Body.Close()
+			}
+
+			spans := mt.FinishedSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "GET /user", spans[0].Tag(ext.ResourceName))
+		})
+	}
+}
+
+func TestAnalyticsSettings(t *testing.T) {
+	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
+		router := fiber.New()
+		router.Use(Middleware(opts...))
+		router.Get("/user/:id", func(c fiber.Ctx) error {
+			return c.SendString(c.Params("id"))
+		})
+
+		resp, err := router.Test(httptest.NewRequest("GET", "/user/123", nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, rate, spans[0].Tag(ext.EventSampleRate))
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, nil)
+	})
+
+	t.Run("global", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		rate := globalconfig.AnalyticsRate()
+		defer globalconfig.SetAnalyticsRate(rate)
+		globalconfig.SetAnalyticsRate(0.4)
+
+		assertRate(t, mt, 0.4)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 1.0, WithAnalytics(true))
+	})
+
+	t.Run("override", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		rate := globalconfig.AnalyticsRate()
+		defer globalconfig.SetAnalyticsRate(rate)
+		globalconfig.SetAnalyticsRate(0.4)
+
+		assertRate(t, mt, 0.23, WithAnalyticsRate(0.23))
+	})
+}
+
+func TestNamingSchema(t *testing.T) {
+	genSpans := namingschematest.GenSpansFn(func(t *testing.T, serviceOverride string) []mocktracer.Span {
+		var opts []Option
+		if serviceOverride != "" {
+			opts = append(opts, WithServiceName(serviceOverride))
+		}
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		mux := fiber.New()
+		mux.Use(Middleware(opts...))
+		mux.Get("/200", func(c fiber.Ctx) error {
+			return c.SendString("ok")
+		})
+		resp, err := mux.Test(httptest.NewRequest("GET", "/200", nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		return mt.FinishedSpans()
+	})
+	namingschematest.NewHTTPServerTest(genSpans, "fiber")(t)
+}
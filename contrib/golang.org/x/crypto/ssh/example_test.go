@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package ssh_test
+
+import (
+	"context"
+	"log"
+
+	sshtrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/golang.org/x/crypto/ssh"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func Example() {
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "deploy")
+	defer span.Finish()
+
+	client, err := sshtrace.DialContext(ctx, "tcp", "host.example.com:22", &ssh.ClientConfig{
+		User:            "deploy",
+		Auth:            []ssh.AuthMethod{ssh.Password("secret")},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	session, err := client.NewSessionContext(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer session.Close()
+
+	// The command is traced by an ssh.exec span with the systemctl resource.
+	if err := session.Run("sudo systemctl restart app"); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package ssh
+
+import (
+	"math"
+	"path"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+)
+
+const (
+	defaultServiceName     = "ssh"
+	defaultDialSpanName    = "ssh.dial"
+	defaultSessionSpanName = "ssh.session"
+	defaultExecSpanName    = "ssh.exec"
+	unknownCategory        = "unknown"
+)
+
+type config struct {
+	serviceName     string
+	dialSpanName    string
+	sessionSpanName string
+	execSpanName    string
+	analyticsRate   float64
+	categorize      func(cmd string) string
+}
+
+// Option represents an option that can be passed to Dial, DialContext or
+// WrapClient.
+type Option func(*config)
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		serviceName: namingschema.NewDefaultServiceName(
+			defaultServiceName,
+			namingschema.WithOverrideV0(defaultServiceName),
+		).GetName(),
+		dialSpanName:    defaultDialSpanName,
+		sessionSpanName: defaultSessionSpanName,
+		execSpanName:    defaultExecSpanName,
+		analyticsRate:   math.NaN(),
+		categorize:      commandCategory,
+	}
+	if internal.BoolEnv("DD_TRACE_SSH_ANALYTICS_ENABLED", false) {
+		cfg.analyticsRate = 1.0
+	}
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return cfg
+}
+
+// commandCategory returns the name of the program executed by cmd, skipping
+// the variable assignments and the sudo and env prefixes, e.g. systemctl for
+// "FOO=bar sudo /usr/bin/systemctl restart nginx".
+func commandCategory(cmd string) string {
+	for _, f := range strings.Fields(cmd) {
+		if strings.Contains(f, "=") || strings.HasPrefix(f, "-") {
+			continue
+		}
+		if f == "sudo" || f == "env" {
+			continue
+		}
+		return path.Base(f)
+	}
+	return unknownCategory
+}
+
+// WithServiceName sets the given service name for the SSH spans.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithCommandCategorizer sets the function returning the category of a
+// command, which is used as the resource of its span. It replaces the default
+// categorization by executed program. The command itself is never collected.
+func WithCommandCategorizer(fn func(cmd string) string) Option {
+	return func(cfg *config) {
+		cfg.categorize = fn
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
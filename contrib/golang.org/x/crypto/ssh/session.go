@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Session is a traced ssh.Session. The execution of commands and shells is
+// traced, the other methods are those of the embedded ssh.Session.
+type Session struct {
+	*ssh.Session
+	client *Client
+	ctx    context.Context
+	span   ddtrace.Span
+
+	mu       sync.Mutex
+	execSpan ddtrace.Span
+	closed   bool
+}
+
+// Context returns the context holding the span of the session.
+func (s *Session) Context() context.Context {
+	return s.ctx
+}
+
+// Start starts the given command in the session, like ssh.Session.Start, and
+// starts its span.
+func (s *Session) Start(cmd string) error {
+	return s.start(s.client.cfg.categorize(cmd), func() error { return s.Session.Start(cmd) })
+}
+
+// Shell starts a login shell in the session, like ssh.Session.Shell, and
+// starts its span.
+func (s *Session) Shell() error {
+	return s.start("shell", s.Session.Shell)
+}
+
+func (s *Session) start(category string, start func() error) error {
+	opts := s.client.cfg.startSpanOptions(s.client.host, s.client.port, category)
+	opts = append(opts, tracer.Tag(tagCommandCategory, category))
+	span, _ := tracer.StartSpanFromContext(s.ctx, s.client.cfg.execSpanName, opts...)
+	if err := start(); err != nil {
+		span.Finish(tracer.WithError(err))
+		return err
+	}
+	s.mu.Lock()
+	s.execSpan = span
+	s.mu.Unlock()
+	return nil
+}
+
+// Wait waits for the command or shell started in the session to exit, like
+// ssh.Session.Wait, and finishes its span.
+func (s *Session) Wait() error {
+	err := s.Session.Wait()
+	s.mu.Lock()
+	span := s.execSpan
+	s.execSpan = nil
+	s.mu.Unlock()
+	if span != nil {
+		finishExecSpan(span, err)
+	}
+	return err
+}
+
+// Run runs the given command in the session, like ssh.Session.Run.
+func (s *Session) Run(cmd string) error {
+	if err := s.Start(cmd); err != nil {
+		return err
+	}
+	return s.Wait()
+}
+
+// Output runs the given command in the session and returns its standard
+// output, like ssh.Session.Output.
+func (s *Session) Output(cmd string) ([]byte, error) {
+	if s.Stdout != nil {
+		return nil, errors.New("ssh: Stdout already set")
+	}
+	var b bytes.Buffer
+	s.Stdout = &b
+	err := s.Run(cmd)
+	return b.Bytes(), err
+}
+
+// CombinedOutput runs the given command in the session and returns its
+// combined standard output and standard error, like
+// ssh.Session.CombinedOutput.
+func (s *Session) CombinedOutput(cmd string) ([]byte, error) {
+	if s.Stdout != nil {
+		return nil, errors.New("ssh: Stdout already set")
+	}
+	if s.Stderr != nil {
+		return nil, errors.New("ssh: Stderr already set")
+	}
+	var b singleWriter
+	s.Stdout = &b
+	s.Stderr = &b
+	err := s.Run(cmd)
+	return b.b.Bytes(), err
+}
+
+// Close closes the session, like ssh.Session.Close, and finishes its span.
+func (s *Session) Close() error {
+	err := s.Session.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		s.span.Finish()
+	}
+	return err
+}
+
+// singleWriter serializes the writes of the standard output and standard
+// error of a command.
+type singleWriter struct {
+	b  bytes.Buffer
+	mu sync.Mutex
+}
+
+func (w *singleWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.b.Write(p)
+}
@@ -0,0 +1,150 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package ssh provides functions to trace the SSH clients of the golang.org/x/crypto/ssh package (https://pkg.go.dev/golang.org/x/crypto/ssh).
+//
+// Connecting to a server is traced by an ssh.dial span. A session is traced by
+// an ssh.session span which finishes when the session is closed, and every
+// command executed in the session by a child ssh.exec span. The commands are
+// not collected: the resource of their spans is the category of the command,
+// which defaults to the name of the executed program.
+package ssh // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/golang.org/x/crypto/ssh"
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const componentName = "golang.org/x/crypto/ssh"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Tags set on the SSH spans.
+const (
+	tagUser            = "ssh.user"
+	tagCommandCategory = "ssh.command_category"
+	tagExitStatus      = "ssh.exit_status"
+	tagExitSignal      = "ssh.exit_signal"
+)
+
+// Client is a traced ssh.Client. Its sessions are traced, the other methods
+// are those of the embedded ssh.Client.
+type Client struct {
+	*ssh.Client
+	cfg  *config
+	host string
+	port string
+}
+
+// Dial connects to the SSH server at addr and returns a traced client, like
+// ssh.Dial.
+func Dial(network, addr string, config *ssh.ClientConfig, opts ...Option) (*Client, error) {
+	return DialContext(context.Background(), network, addr, config, opts...)
+}
+
+// DialContext is like Dial, but the span of the connection is a child of the
+// span found in ctx, if any, and ctx bounds the establishment of the TCP
+// connection.
+func DialContext(ctx context.Context, network, addr string, config *ssh.ClientConfig, opts ...Option) (*Client, error) {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/golang.org/x/crypto/ssh: Dialing: %#v", cfg)
+	host, port, _ := net.SplitHostPort(addr)
+	spanOpts := cfg.startSpanOptions(host, port, "dial")
+	spanOpts = append(spanOpts, tracer.Tag(tagUser, config.User))
+	span, ctx := tracer.StartSpanFromContext(ctx, cfg.dialSpanName, spanOpts...)
+	c, err := dial(ctx, network, addr, config)
+	span.Finish(tracer.WithError(err))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{Client: c, cfg: cfg, host: host, port: port}, nil
+}
+
+func dial(ctx context.Context, network, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	d := net.Dialer{Timeout: config.Timeout}
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// WrapClient wraps the given client so that its sessions are traced.
+func WrapClient(c *ssh.Client, opts ...Option) *Client {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/golang.org/x/crypto/ssh: Wrapping Client: %#v", cfg)
+	host, port, _ := net.SplitHostPort(c.RemoteAddr().String())
+	return &Client{Client: c, cfg: cfg, host: host, port: port}
+}
+
+// NewSession opens a new traced session, like ssh.Client.NewSession.
+func (c *Client) NewSession() (*Session, error) {
+	return c.NewSessionContext(context.Background())
+}
+
+// NewSessionContext is like NewSession, but the span of the session is a
+// child of the span found in ctx, if any.
+func (c *Client) NewSessionContext(ctx context.Context) (*Session, error) {
+	spanOpts := c.cfg.startSpanOptions(c.host, c.port, "session")
+	spanOpts = append(spanOpts, tracer.Tag(tagUser, c.User()))
+	span, ctx := tracer.StartSpanFromContext(ctx, c.cfg.sessionSpanName, spanOpts...)
+	s, err := c.Client.NewSession()
+	if err != nil {
+		span.Finish(tracer.WithError(err))
+		return nil, err
+	}
+	return &Session{Session: s, client: c, ctx: ctx, span: span}, nil
+}
+
+func (cfg *config) startSpanOptions(host, port, resource string) []ddtrace.StartSpanOption {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(cfg.serviceName),
+		tracer.ResourceName(resource),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+	}
+	if host != "" {
+		opts = append(opts, tracer.Tag(ext.TargetHost, host))
+	}
+	if port != "" {
+		opts = append(opts, tracer.Tag(ext.TargetPort, port))
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	return opts
+}
+
+// finishExecSpan finishes the span of a command which returned err, tagging
+// it with the exit status of the command.
+func finishExecSpan(span ddtrace.Span, err error) {
+	var ee *ssh.ExitError
+	if errors.As(err, &ee) {
+		span.SetTag(tagExitStatus, ee.ExitStatus())
+		if ee.Signal() != "" {
+			span.SetTag(tagExitSignal, ee.Signal())
+		}
+	} else if err == nil {
+		span.SetTag(tagExitStatus, 0)
+	}
+	span.Finish(tracer.WithError(err))
+}
@@ -0,0 +1,141 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package ssh
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCommandCategory(t *testing.T) {
+	for cmd, category := range map[string]string{
+		"uptime":                              "uptime",
+		"/usr/bin/systemctl restart nginx":    "systemctl",
+		"FOO=bar sudo -u root apt-get update": "apt-get",
+		"env A=1 B=2 ./deploy.sh":             "deploy.sh",
+		"":                                    unknownCategory,
+	} {
+		assert.Equal(t, category, commandCategory(cmd), cmd)
+	}
+}
+
+// startServer starts an SSH server accepting any client, which replies to
+// every command with an exit status of 0, or 1 for "false".
+func startServer(t *testing.T) string {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for nc := range chans {
+					ch, reqs, err := nc.Accept()
+					if err != nil {
+						continue
+					}
+					go func() {
+						for req := range reqs {
+							req.Reply(req.Type == "exec", nil)
+							if req.Type != "exec" {
+								continue
+							}
+							var payload struct{ Command string }
+							ssh.Unmarshal(req.Payload, &payload)
+							status := make([]byte, 4)
+							if payload.Command == "false" {
+								binary.BigEndian.PutUint32(status, 1)
+							}
+							ch.SendRequest("exit-status", false, status)
+							ch.Close()
+						}
+					}()
+				}
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestSession(t *testing.T) {
+	addr := startServer(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "root")
+	c, err := DialContext(ctx, "tcp", addr, &ssh.ClientConfig{
+		User:            "gopher",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}, WithServiceName("deployer"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := c.NewSessionContext(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.Run("sudo systemctl restart nginx"))
+	s.Close()
+
+	s, err = c.NewSessionContext(ctx)
+	require.NoError(t, err)
+	assert.Error(t, s.Run("false"))
+	s.Close()
+	root.Finish()
+
+	host, port, _ := net.SplitHostPort(addr)
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 6)
+
+	dial := spans[0]
+	assert.Equal(t, defaultDialSpanName, dial.OperationName())
+	assert.Equal(t, "deployer", dial.Tag(ext.ServiceName))
+	assert.Equal(t, host, dial.Tag(ext.TargetHost))
+	assert.Equal(t, port, dial.Tag(ext.TargetPort))
+	assert.Equal(t, "gopher", dial.Tag(tagUser))
+	assert.Equal(t, componentName, dial.Tag(ext.Component))
+	assert.Equal(t, root.Context().SpanID(), dial.ParentID())
+
+	exec, session := spans[1], spans[2]
+	assert.Equal(t, defaultSessionSpanName, session.OperationName())
+	assert.Equal(t, root.Context().SpanID(), session.ParentID())
+	assert.Equal(t, defaultExecSpanName, exec.OperationName())
+	assert.Equal(t, "systemctl", exec.Tag(ext.ResourceName))
+	assert.Equal(t, "systemctl", exec.Tag(tagCommandCategory))
+	assert.Equal(t, 0, exec.Tag(tagExitStatus))
+	assert.Equal(t, session.SpanID(), exec.ParentID())
+	assert.Nil(t, exec.Tag(ext.Error))
+
+	exec = spans[3]
+	assert.Equal(t, "false", exec.Tag(ext.ResourceName))
+	assert.Equal(t, 1, exec.Tag(tagExitStatus))
+	assert.NotNil(t, exec.Tag(ext.Error))
+}
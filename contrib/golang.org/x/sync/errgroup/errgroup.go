@@ -0,0 +1,166 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package errgroup provides a traced version of the golang.org/x/sync/errgroup package (https://pkg.go.dev/golang.org/x/sync/errgroup).
+//
+// A Group created with WithContext is traced by a span which is a child of the
+// span found in the given context, and which finishes when Wait returns. Every
+// function started with Go or GoContext is traced by a child of the span of the
+// group. The member whose error canceled the context of the group is tagged on
+// both its span and the span of the group.
+package errgroup // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/golang.org/x/sync/errgroup"
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const componentName = "golang.org/x/sync/errgroup"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Tags set on the group and member spans.
+const (
+	tagMember     = "errgroup.member"
+	tagMembers    = "errgroup.members"
+	tagCanceledBy = "errgroup.canceled_by"
+	tagCanceler   = "errgroup.canceled_group"
+)
+
+// Group is a traced errgroup.Group.
+type Group struct {
+	group   *errgroup.Group
+	ctx     context.Context
+	span    ddtrace.Span
+	cfg     *config
+	members int32
+
+	cancelOnce sync.Once
+	canceledBy string
+	finishOnce sync.Once
+}
+
+// WithContext returns a new Group and an associated context derived from ctx,
+// like errgroup.WithContext. The returned context holds the span of the group,
+// so that the spans started from it are children of the group.
+func WithContext(ctx context.Context, opts ...Option) (*Group, context.Context) {
+	cfg := defaults()
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/golang.org/x/sync/errgroup: Creating Group: %#v", cfg)
+	spanOpts := cfg.startSpanOptions()
+	if cfg.name != "" {
+		spanOpts = append(spanOpts, tracer.ResourceName(cfg.name))
+	}
+	span, ctx := tracer.StartSpanFromContext(ctx, cfg.groupSpanName, spanOpts...)
+	group, ctx := errgroup.WithContext(ctx)
+	return &Group{group: group, ctx: ctx, span: span, cfg: cfg}, ctx
+}
+
+// Go calls the given function in a new goroutine, like errgroup.Group.Go. The
+// call is traced by a child span of the group, named after the function unless
+// WithName is given.
+func (g *Group) Go(f func() error, opts ...Option) {
+	g.GoContext(func(context.Context) error { return f() }, append([]Option{withFuncName(f)}, opts...)...)
+}
+
+// GoContext calls the given function in a new goroutine, like Go. The function
+// is passed the context of the group holding the span of the call.
+func (g *Group) GoContext(f func(ctx context.Context) error, opts ...Option) {
+	g.group.Go(g.member(f, opts))
+}
+
+// TryGo calls the given function in a new goroutine only if the number of
+// active goroutines in the group is below the configured limit, like
+// errgroup.Group.TryGo. It reports whether the goroutine was started.
+func (g *Group) TryGo(f func() error, opts ...Option) bool {
+	return g.group.TryGo(g.member(func(context.Context) error { return f() }, append([]Option{withFuncName(f)}, opts...)))
+}
+
+// SetLimit limits the number of active goroutines in the group, like
+// errgroup.Group.SetLimit.
+func (g *Group) SetLimit(n int) {
+	g.group.SetLimit(n)
+}
+
+// Wait blocks until all the function calls of the group have returned, then
+// returns the first non-nil error, if any, like errgroup.Group.Wait. The span
+// of the group is finished when Wait is first called.
+func (g *Group) Wait() error {
+	err := g.group.Wait()
+	g.finishOnce.Do(func() {
+		g.span.SetTag(tagMembers, atomic.LoadInt32(&g.members))
+		if g.canceledBy != "" {
+			g.span.SetTag(tagCanceledBy, g.canceledBy)
+		}
+		g.span.Finish(tracer.WithError(err))
+	})
+	return err
+}
+
+// member returns a function calling f in the span of a member of the group.
+func (g *Group) member(f func(ctx context.Context) error, opts []Option) func() error {
+	cfg := *g.cfg
+	cfg.name = ""
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+	return func() error {
+		atomic.AddInt32(&g.members, 1)
+		spanOpts := cfg.startSpanOptions()
+		if cfg.name != "" {
+			spanOpts = append(spanOpts,
+				tracer.ResourceName(cfg.name),
+				tracer.Tag(tagMember, cfg.name),
+			)
+		}
+		span, ctx := tracer.StartSpanFromContext(g.ctx, cfg.memberSpanName, spanOpts...)
+		err := f(ctx)
+		if err != nil {
+			// errgroup cancels the context of the group with the first
+			// error returned by a member.
+			g.cancelOnce.Do(func() {
+				g.canceledBy = cfg.name
+				span.SetTag(tagCanceler, true)
+			})
+		}
+		span.Finish(tracer.WithError(err))
+		return err
+	}
+}
+
+func (cfg *config) startSpanOptions() []ddtrace.StartSpanOption {
+	opts := []ddtrace.StartSpanOption{
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindInternal),
+	}
+	if cfg.serviceName != "" {
+		opts = append(opts, tracer.ServiceName(cfg.serviceName))
+	}
+	return opts
+}
+
+// withFuncName names a member after the function f.
+func withFuncName(f interface{}) Option {
+	return func(cfg *config) {
+		if fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer()); fn != nil {
+			cfg.name = fn.Name()
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package errgroup
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fetch() error { return nil }
+
+func TestGroup(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "root")
+	g, ctx := WithContext(ctx, WithName("fan-out"))
+	g.Go(fetch)
+	g.GoContext(func(ctx context.Context) error {
+		_, ok := tracer.SpanFromContext(ctx)
+		assert.True(t, ok)
+		return nil
+	}, WithName("named"))
+	require.NoError(t, g.Wait())
+	assert.NoError(t, ctx.Err())
+	root.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 4)
+	group := spans[2]
+	assert.Equal(t, defaultGroupSpanName, group.OperationName())
+	assert.Equal(t, "fan-out", group.Tag(ext.ResourceName))
+	assert.Equal(t, int32(2), group.Tag(tagMembers))
+	assert.Equal(t, root.Context().SpanID(), group.ParentID())
+	assert.Nil(t, group.Tag(tagCanceledBy))
+
+	names := map[string]bool{}
+	for _, s := range spans[:2] {
+		assert.Equal(t, defaultMemberSpanName, s.OperationName())
+		assert.Equal(t, group.SpanID(), s.ParentID())
+		assert.Equal(t, componentName, s.Tag(ext.Component))
+		names[s.Tag(tagMember).(string)] = true
+	}
+	assert.True(t, names["named"])
+	assert.True(t, names["gopkg.in/DataDog/dd-trace-go.v1/contrib/golang.org/x/sync/errgroup.fetch"])
+}
+
+func TestGroupCancel(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	errFailed := errors.New("failed")
+	g, ctx := WithContext(context.Background())
+	g.Go(func() error { return errFailed }, WithName("failing"))
+	g.Go(func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithName("waiting"))
+	assert.Equal(t, errFailed, g.Wait())
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 3)
+	for _, s := range spans {
+		switch s.Tag(ext.ResourceName) {
+		case "failing":
+			assert.Equal(t, true, s.Tag(tagCanceler))
+			assert.Equal(t, errFailed, s.Tag(ext.Error))
+		case "waiting":
+			assert.Nil(t, s.Tag(tagCanceler))
+		default:
+			assert.Equal(t, defaultGroupSpanName, s.OperationName())
+			assert.Equal(t, "failing", s.Tag(tagCanceledBy))
+			assert.Equal(t, errFailed, s.Tag(ext.Error))
+		}
+	}
+}
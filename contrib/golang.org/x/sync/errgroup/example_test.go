@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package errgroup_test
+
+import (
+	"context"
+	"net/http"
+
+	errgrouptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/golang.org/x/sync/errgroup"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func Example() {
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "web.request")
+	defer span.Finish()
+
+	g, ctx := errgrouptrace.WithContext(ctx, errgrouptrace.WithName("fetch-all"))
+	for _, url := range []string{"http://example.com/a", "http://example.com/b"} {
+		url := url
+		g.GoContext(func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return err
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+			return resp.Body.Close()
+		}, errgrouptrace.WithName(url))
+	}
+	if err := g.Wait(); err != nil {
+		span.SetTag("error", err)
+	}
+}
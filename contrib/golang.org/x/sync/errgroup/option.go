@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package errgroup
+
+const (
+	defaultGroupSpanName  = "errgroup.group"
+	defaultMemberSpanName = "errgroup.go"
+)
+
+type config struct {
+	serviceName    string
+	groupSpanName  string
+	memberSpanName string
+	name           string
+}
+
+// Option represents an option that can be passed to WithContext, or to the Go,
+// GoContext and TryGo methods of a Group.
+type Option func(*config)
+
+func defaults() *config {
+	return &config{
+		groupSpanName:  defaultGroupSpanName,
+		memberSpanName: defaultMemberSpanName,
+	}
+}
+
+// WithServiceName sets the given service name for the spans of the group and
+// its members. By default, the spans inherit the service of their parent.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithName sets the resource name of the span of the group when passed to
+// WithContext, or of the span of a member when passed to Go, GoContext or
+// TryGo. Members are named after their function by default.
+func WithName(name string) Option {
+	return func(cfg *config) {
+		cfg.name = name
+	}
+}
+
+// WithGroupSpanName sets the operation name of the span of the group. It
+// defaults to "errgroup.group".
+func WithGroupSpanName(name string) Option {
+	return func(cfg *config) {
+		cfg.groupSpanName = name
+	}
+}
+
+// WithMemberSpanName sets the operation name of the spans of the members of
+// the group. It defaults to "errgroup.go".
+func WithMemberSpanName(name string) Option {
+	return func(cfg *config) {
+		cfg.memberSpanName = name
+	}
+}
@@ -52,12 +52,13 @@ func appsecUnaryHandlerMiddleware(span ddtrace.Span, handler grpc.UnaryHandler)
 		if err != nil {
 			return nil, err
 		}
-		defer grpcsec.StartReceiveOperation(grpcsec.ReceiveOperationArgs{}, op).Finish(grpcsec.ReceiveOperationRes{Message: req})
-		rv, err := handler(ctx, req)
-		if e, ok := err.(*grpcsec.MonitoringError); ok {
-			err = status.Error(codes.Code(e.GRPCStatus()), e.Error())
+		// The request message is monitored before calling the handler so that it can be blocked.
+		recvOp := grpcsec.StartReceiveOperation(grpcsec.ReceiveOperationArgs{}, op)
+		if err := recvOp.Finish(grpcsec.ReceiveOperationRes{Message: req}); err != nil {
+			return nil, monitoringErrorStatus(err)
 		}
-		return rv, err
+		rv, err := handler(ctx, req)
+		return rv, monitoringErrorStatus(err)
 	}
 }
 
@@ -98,13 +99,20 @@ func appsecStreamHandlerMiddleware(span ddtrace.Span, handler grpc.StreamHandler
 		}
 
 		err = handler(srv, stream)
-		if e, ok := err.(*grpcsec.MonitoringError); ok {
-			err = status.Error(codes.Code(e.GRPCStatus()), e.Error())
-		}
-		return err
+		return monitoringErrorStatus(err)
 	}
 }
 
+// monitoringErrorStatus converts the AppSec monitoring errors into gRPC status
+// errors carrying the status code of the blocking action. Other errors are
+// returned unchanged.
+func monitoringErrorStatus(err error) error {
+	if e, ok := err.(*grpcsec.MonitoringError); ok {
+		return status.Error(codes.Code(e.GRPCStatus()), e.Error())
+	}
+	return err
+}
+
 type appsecServerStream struct {
 	grpc.ServerStream
 	handlerOperation *grpcsec.HandlerOperation
@@ -112,13 +120,16 @@ type appsecServerStream struct {
 }
 
 // RecvMsg implements grpc.ServerStream interface method to monitor its
-// execution with AppSec.
+// execution with AppSec. Every received message is passed to the WAF, and an
+// error with the status code of the blocking action is returned when the
+// message must be blocked, so that the handler aborts the stream.
 func (ss appsecServerStream) RecvMsg(m interface{}) error {
 	op := grpcsec.StartReceiveOperation(grpcsec.ReceiveOperationArgs{}, ss.handlerOperation)
-	defer func() {
-		op.Finish(grpcsec.ReceiveOperationRes{Message: m})
-	}()
-	return ss.ServerStream.RecvMsg(m)
+	err := ss.ServerStream.RecvMsg(m)
+	if e := op.Finish(grpcsec.ReceiveOperationRes{Message: m}); e != nil && err == nil {
+		err = monitoringErrorStatus(e)
+	}
+	return err
 }
 
 func (ss appsecServerStream) Context() context.Context {
@@ -186,6 +186,52 @@ func TestBlocking(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("unary-message-block", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		// Send a PHP injection attack in the payload, which is a blocking rule
+		ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-client-ip", "1.2.3.5"))
+		reply, err := client.Ping(ctx, &FixtureRequest{Name: "$globals"})
+
+		require.Nil(t, reply)
+		require.Equal(t, codes.Aborted, status.Code(err))
+
+		finished := mt.FinishedSpans()
+		require.Len(t, finished, 1)
+		event, _ := finished[0].Tag("_dd.appsec.json").(string)
+		require.True(t, strings.Contains(event, "crs-933-130-block"))
+		require.Equal(t, true, finished[0].Tag("appsec.blocked"))
+	})
+
+	t.Run("stream-message-block", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-client-ip", "1.2.3.5"))
+		stream, err := client.StreamPing(ctx)
+		require.NoError(t, err)
+
+		// The first message is legit and goes through
+		err = stream.Send(&FixtureRequest{Name: "hello"})
+		require.NoError(t, err)
+		reply, err := stream.Recv()
+		require.Equal(t, codes.OK, status.Code(err))
+		require.Equal(t, "passed", reply.Message)
+
+		// The second message is a PHP injection attack which aborts the stream
+		err = stream.Send(&FixtureRequest{Name: "$globals"})
+		require.NoError(t, err)
+		reply, err = stream.Recv()
+		require.Nil(t, reply)
+		require.Equal(t, codes.Aborted, status.Code(err))
+
+		finished := mt.FinishedSpans()
+		require.Len(t, finished, 1)
+		event, _ := finished[0].Tag("_dd.appsec.json").(string)
+		require.True(t, strings.Contains(event, "crs-933-130-block"))
+		require.Equal(t, true, finished[0].Tag("appsec.blocked"))
+	})
 }
 
 // Test that user blocking works by using custom rules/rules data
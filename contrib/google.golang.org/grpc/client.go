@@ -26,6 +26,7 @@ type clientStream struct {
 	ctx    context.Context
 	cfg    *config
 	method string
+	stats  *streamStats
 }
 
 func (cs *clientStream) Context() context.Context {
@@ -33,6 +34,10 @@ func (cs *clientStream) Context() context.Context {
 }
 
 func (cs *clientStream) RecvMsg(m interface{}) (err error) {
+	var (
+		ordinal int64
+		size    int
+	)
 	if _, ok := cs.cfg.untracedMethods[cs.method]; cs.cfg.traceStreamMessages && !ok {
 		span, _ := startSpanFromContext(
 			cs.Context(),
@@ -45,13 +50,24 @@ func (cs *clientStream) RecvMsg(m interface{}) (err error) {
 		if p, ok := peer.FromContext(cs.Context()); ok {
 			setSpanTargetFromPeer(span, *p)
 		}
-		defer func() { finishWithError(span, err, cs.cfg) }()
+		defer func() {
+			tagMessage(span, ordinal, size)
+			finishWithError(span, err, cs.cfg)
+		}()
 	}
 	err = cs.ClientStream.RecvMsg(m)
+	if err == nil && cs.stats != nil {
+		size = messageSize(m)
+		ordinal = cs.stats.receive(size)
+	}
 	return err
 }
 
 func (cs *clientStream) SendMsg(m interface{}) (err error) {
+	var (
+		ordinal int64
+		size    int
+	)
 	if _, ok := cs.cfg.untracedMethods[cs.method]; cs.cfg.traceStreamMessages && !ok {
 		span, _ := startSpanFromContext(
 			cs.Context(),
@@ -64,9 +80,16 @@ func (cs *clientStream) SendMsg(m interface{}) (err error) {
 		if p, ok := peer.FromContext(cs.Context()); ok {
 			setSpanTargetFromPeer(span, *p)
 		}
-		defer func() { finishWithError(span, err, cs.cfg) }()
+		defer func() {
+			tagMessage(span, ordinal, size)
+			finishWithError(span, err, cs.cfg)
+		}()
 	}
 	err = cs.ClientStream.SendMsg(m)
+	if err == nil && cs.stats != nil {
+		size = messageSize(m)
+		ordinal = cs.stats.send(size)
+	}
 	return err
 }
 
@@ -92,6 +115,7 @@ func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
 			}
 		}
 		var stream grpc.ClientStream
+		stats := newStreamStats(cfg)
 		if _, ok := cfg.untracedMethods[method]; cfg.traceStreamCalls && !ok {
 			var (
 				span tracer.Span
@@ -116,6 +140,7 @@ func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
 
 			go func() {
 				<-stream.Context().Done()
+				stats.tag(span)
 				finishWithError(span, stream.Context().Err(), cfg)
 			}()
 		} else {
@@ -137,6 +162,7 @@ func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
 			cfg:          cfg,
 			method:       method,
 			ctx:          ctx,
+			stats:        stats,
 		}, nil
 	}
 }
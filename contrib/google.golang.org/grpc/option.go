@@ -31,6 +31,7 @@ type config struct {
 	nonErrorCodes       map[codes.Code]bool
 	traceStreamCalls    bool
 	traceStreamMessages bool
+	streamMessageStats  bool
 	noDebugStack        bool
 	ignoredMethods      map[string]struct{}
 	untracedMethods     map[string]struct{}
@@ -110,6 +111,18 @@ func WithStreamMessages(enabled bool) Option {
 	}
 }
 
+// WithStreamMessageStats enables or disables the counting of the messages sent
+// and received by streaming calls. When enabled, the spans of streaming calls
+// are tagged with the number of messages and bytes sent and received, and the
+// spans of stream messages with their size and position in the stream. The
+// stats handlers count the bytes sent over the wire, and the interceptors the
+// encoded size of the messages.
+func WithStreamMessageStats(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.streamMessageStats = enabled
+	}
+}
+
 // NoDebugStack disables debug stacks for traces with errors. This is useful in situations
 // where errors are frequent and the overhead of calling debug.Stack may affect performance.
 func NoDebugStack() Option {
@@ -24,6 +24,7 @@ type serverStream struct {
 	cfg    *config
 	method string
 	ctx    context.Context
+	stats  *streamStats
 }
 
 // Context returns the ServerStream Context.
@@ -39,6 +40,10 @@ func (ss *serverStream) Context() context.Context {
 }
 
 func (ss *serverStream) RecvMsg(m interface{}) (err error) {
+	var (
+		ordinal int64
+		size    int
+	)
 	_, im := ss.cfg.ignoredMethods[ss.method]
 	_, um := ss.cfg.untracedMethods[ss.method]
 	if ss.cfg.traceStreamMessages && !im && !um {
@@ -53,14 +58,23 @@ func (ss *serverStream) RecvMsg(m interface{}) (err error) {
 		defer func() {
 			withMetadataTags(ss.ctx, ss.cfg, span)
 			withRequestTags(ss.cfg, m, span)
+			tagMessage(span, ordinal, size)
 			finishWithError(span, err, ss.cfg)
 		}()
 	}
 	err = ss.ServerStream.RecvMsg(m)
+	if err == nil && ss.stats != nil {
+		size = messageSize(m)
+		ordinal = ss.stats.receive(size)
+	}
 	return err
 }
 
 func (ss *serverStream) SendMsg(m interface{}) (err error) {
+	var (
+		ordinal int64
+		size    int
+	)
 	_, im := ss.cfg.ignoredMethods[ss.method]
 	_, um := ss.cfg.untracedMethods[ss.method]
 	if ss.cfg.traceStreamMessages && !im && !um {
@@ -72,9 +86,16 @@ func (ss *serverStream) SendMsg(m interface{}) (err error) {
 			ss.cfg.startSpanOptions(tracer.Measured())...,
 		)
 		span.SetTag(ext.Component, componentName)
-		defer func() { finishWithError(span, err, ss.cfg) }()
+		defer func() {
+			tagMessage(span, ordinal, size)
+			finishWithError(span, err, ss.cfg)
+		}()
 	}
 	err = ss.ServerStream.SendMsg(m)
+	if err == nil && ss.stats != nil {
+		size = messageSize(m)
+		ordinal = ss.stats.send(size)
+	}
 	return err
 }
 
@@ -88,6 +109,7 @@ func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
 	log.Debug("contrib/google.golang.org/grpc: Configuring StreamServerInterceptor: %#v", cfg)
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
 		ctx := ss.Context()
+		stats := newStreamStats(cfg)
 		// if we've enabled call tracing, create a span
 		_, im := cfg.ignoredMethods[info.FullMethod]
 		_, um := cfg.untracedMethods[info.FullMethod]
@@ -110,7 +132,10 @@ func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
 			case info.IsClientStream:
 				span.SetTag(tagMethodKind, methodKindClientStream)
 			}
-			defer func() { finishWithError(span, err, cfg) }()
+			defer func() {
+				stats.tag(span)
+				finishWithError(span, err, cfg)
+			}()
 			if appsec.Enabled() {
 				handler = appsecStreamHandlerMiddleware(span, handler)
 			}
@@ -123,6 +148,7 @@ func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
 			cfg:          cfg,
 			method:       info.FullMethod,
 			ctx:          ctx,
+			stats:        stats,
 		})
 	}
 }
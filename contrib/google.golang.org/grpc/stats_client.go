@@ -10,18 +10,25 @@ import (
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 
 	context "golang.org/x/net/context"
 	"google.golang.org/grpc/stats"
 )
 
 // NewClientStatsHandler returns a gRPC client stats.Handler to trace RPC calls.
+// It is an alternative to UnaryClientInterceptor and StreamClientInterceptor
+// which traces both unary and streaming calls, and composes with the
+// interceptors of other libraries. The messages of streams are not traced by
+// spans of their own, but with WithStreamMessageStats the spans of streaming
+// calls are tagged with the number of messages and bytes sent and received.
 func NewClientStatsHandler(opts ...Option) stats.Handler {
 	cfg := new(config)
 	clientDefaults(cfg)
 	for _, fn := range opts {
 		fn(cfg)
 	}
+	log.Debug("contrib/google.golang.org/grpc: Configuring ClientStatsHandler: %#v", cfg)
 	return &clientStatsHandler{
 		cfg: cfg,
 	}
@@ -31,35 +38,47 @@ type clientStatsHandler struct{ cfg *config }
 
 // TagRPC starts a new span for the initiated RPC request.
 func (h *clientStatsHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo) context.Context {
-	spanOpts := append([]tracer.StartSpanOption{tracer.Tag(ext.SpanKind, ext.SpanKindClient)}, h.cfg.spanOpts...)
-	_, ctx = startSpanFromContext(
+	if _, ok := h.cfg.untracedMethods[rti.FullMethodName]; ok {
+		return ctx
+	}
+	span, ctx := startSpanFromContext(
 		ctx,
 		rti.FullMethodName,
 		h.cfg.spanName,
 		h.cfg.serviceName,
-		spanOpts...,
+		h.cfg.startSpanOptions(
+			tracer.Tag(ext.Component, componentName),
+			tracer.Tag(ext.SpanKind, ext.SpanKindClient))...,
 	)
-	ctx = injectSpanIntoContext(ctx)
-	return ctx
+	ctx = contextWithRPCStats(ctx, &rpcStats{span: span, streamStats: newStreamStats(h.cfg)})
+	return injectSpanIntoContext(ctx)
 }
 
-// HandleRPC processes the RPC ending event by finishing the span from the context.
+// HandleRPC processes the RPC events, finishing the span of the RPC when it
+// ends.
 func (h *clientStatsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
-	span, ok := tracer.SpanFromContext(ctx)
+	s, ok := rpcStatsFromContext(ctx)
 	if !ok {
 		return
 	}
 	switch rs := rs.(type) {
+	case *stats.Begin:
+		s.begin(rs)
 	case *stats.OutHeader:
 		host, port, err := net.SplitHostPort(rs.RemoteAddr.String())
 		if err == nil {
 			if host != "" {
-				span.SetTag(ext.TargetHost, host)
+				s.span.SetTag(ext.TargetHost, host)
 			}
-			span.SetTag(ext.TargetPort, port)
+			s.span.SetTag(ext.TargetPort, port)
 		}
+	case *stats.InPayload:
+		s.streamStats.receive(rs.Length)
+	case *stats.OutPayload:
+		s.streamStats.send(rs.Length)
 	case *stats.End:
-		finishWithError(span, rs.Error, h.cfg)
+		s.end()
+		finishWithError(s.span, rs.Error, h.cfg)
 	}
 }
 
@@ -58,6 +58,28 @@ func TestClientStatsHandler(t *testing.T) {
 	assert.Equal("grpc", tags[ext.RPCSystem])
 	assert.Equal("/grpc.Fixture/Ping", tags[ext.GRPCFullMethod])
 	assert.Equal(ext.SpanKindClient, tags[ext.SpanKind])
+	assert.Equal(componentName, tags[ext.Component])
+	assert.Equal(methodKindUnary, tags[tagMethodKind])
+}
+
+func TestClientStatsHandlerUntracedMethod(t *testing.T) {
+	statsHandler := NewClientStatsHandler(WithUntracedMethods("/grpc.Fixture/Ping"))
+	server, err := newClientStatsHandlerTestServer(statsHandler)
+	if err != nil {
+		t.Fatalf("failed to start test server: %s", err)
+	}
+	defer server.Close()
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	rootSpan, ctx := tracer.StartSpanFromContext(context.Background(), "a")
+	_, err = server.client.Ping(ctx, &FixtureRequest{Name: "name"})
+	assert.NoError(t, err)
+	// the span of the caller is not finished by the handler
+	assert.Empty(t, mt.FinishedSpans())
+	rootSpan.Finish()
+	assert.Len(t, mt.FinishedSpans(), 1)
 }
 
 func newClientStatsHandlerTestServer(statsHandler stats.Handler) (*rig, error) {
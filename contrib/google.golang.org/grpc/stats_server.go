@@ -6,20 +6,28 @@
 package grpc
 
 import (
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 
 	context "golang.org/x/net/context"
 	"google.golang.org/grpc/stats"
 )
 
 // NewServerStatsHandler returns a gRPC server stats.Handler to trace RPC calls.
+// It is an alternative to UnaryServerInterceptor and StreamServerInterceptor
+// which traces both unary and streaming calls, and composes with the
+// interceptors of other libraries. The messages of streams are not traced by
+// spans of their own, but with WithStreamMessageStats the spans of streaming
+// calls are tagged with the number of messages and bytes sent and received.
 func NewServerStatsHandler(opts ...Option) stats.Handler {
 	cfg := new(config)
 	serverDefaults(cfg)
 	for _, fn := range opts {
 		fn(cfg)
 	}
+	log.Debug("contrib/google.golang.org/grpc: Configuring ServerStatsHandler: %#v", cfg)
 	return &serverStatsHandler{
 		cfg: cfg,
 	}
@@ -31,29 +39,44 @@ type serverStatsHandler struct {
 
 // TagRPC starts a new span for the initiated RPC request.
 func (h *serverStatsHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo) context.Context {
-	spanOpts := append([]tracer.StartSpanOption{
-		tracer.Measured(),
-		tracer.Tag(ext.SpanKind, ext.SpanKindServer)},
-		h.cfg.spanOpts...,
-	)
-	_, ctx = startSpanFromContext(
+	_, im := h.cfg.ignoredMethods[rti.FullMethodName]
+	_, um := h.cfg.untracedMethods[rti.FullMethodName]
+	if im || um {
+		return ctx
+	}
+	span, ctx := startSpanFromContext(
 		ctx,
 		rti.FullMethodName,
 		h.cfg.spanName,
 		h.cfg.serviceName,
-		spanOpts...,
+		h.cfg.startSpanOptions(tracer.Measured(),
+			tracer.Tag(ext.Component, componentName),
+			tracer.Tag(ext.SpanKind, ext.SpanKindServer))...,
 	)
-	return ctx
+	withMetadataTags(ctx, h.cfg, span)
+	return contextWithRPCStats(ctx, &rpcStats{span: span, streamStats: newStreamStats(h.cfg)})
 }
 
-// HandleRPC processes the RPC ending event by finishing the span from the context.
+// HandleRPC processes the RPC events, finishing the span of the RPC when it
+// ends.
 func (h *serverStatsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
-	span, ok := tracer.SpanFromContext(ctx)
+	s, ok := rpcStatsFromContext(ctx)
 	if !ok {
 		return
 	}
-	if v, ok := rs.(*stats.End); ok {
-		finishWithError(span, v.Error, h.cfg)
+	switch rs := rs.(type) {
+	case *stats.Begin:
+		s.begin(rs)
+	case *stats.InPayload:
+		if s.unary {
+			withRequestTags(h.cfg, rs.Payload, s.span)
+		}
+		s.streamStats.receive(rs.Length)
+	case *stats.OutPayload:
+		s.streamStats.send(rs.Length)
+	case *stats.End:
+		s.end()
+		finishWithError(s.span, rs.Error, h.cfg)
 	}
 }
 
@@ -64,3 +87,45 @@ func (h *serverStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo)
 
 // HandleConn implements stats.Handler.
 func (h *serverStatsHandler) HandleConn(_ context.Context, _ stats.ConnStats) {}
+
+// rpcStats holds the state of an RPC traced by a stats handler.
+type rpcStats struct {
+	span        ddtrace.Span
+	unary       bool
+	streamStats *streamStats
+}
+
+type rpcStatsKey struct{}
+
+func contextWithRPCStats(ctx context.Context, s *rpcStats) context.Context {
+	return context.WithValue(ctx, rpcStatsKey{}, s)
+}
+
+// rpcStatsFromContext returns the state of the RPC of ctx, if it is traced.
+// Unlike the span of ctx, it is never the one of a parent of the RPC.
+func rpcStatsFromContext(ctx context.Context) (*rpcStats, bool) {
+	s, ok := ctx.Value(rpcStatsKey{}).(*rpcStats)
+	return s, ok
+}
+
+// begin tags the span of the RPC with the kind of method called.
+func (s *rpcStats) begin(b *stats.Begin) {
+	switch {
+	case b.IsClientStream && b.IsServerStream:
+		s.span.SetTag(tagMethodKind, methodKindBidiStream)
+	case b.IsServerStream:
+		s.span.SetTag(tagMethodKind, methodKindServerStream)
+	case b.IsClientStream:
+		s.span.SetTag(tagMethodKind, methodKindClientStream)
+	default:
+		s.unary = true
+		s.span.SetTag(tagMethodKind, methodKindUnary)
+	}
+}
+
+// end tags the span of a streaming RPC with the counts of its messages.
+func (s *rpcStats) end() {
+	if !s.unary {
+		s.streamStats.tag(s.span)
+	}
+}
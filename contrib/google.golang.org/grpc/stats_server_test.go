@@ -23,7 +23,7 @@ func TestServerStatsHandler(t *testing.T) {
 	assert := assert.New(t)
 
 	serviceName := "grpc-service"
-	statsHandler := NewServerStatsHandler(WithServiceName(serviceName), WithSpanOptions(tracer.Tag("foo", "bar")))
+	statsHandler := NewServerStatsHandler(WithServiceName(serviceName), WithSpanOptions(tracer.Tag("foo", "bar")), WithRequestTags())
 	server, err := newServerStatsHandlerTestServer(statsHandler)
 	if err != nil {
 		t.Fatalf("failed to start test server: %s", err)
@@ -55,6 +55,48 @@ func TestServerStatsHandler(t *testing.T) {
 	assert.Equal("grpc", tags[ext.RPCSystem])
 	assert.Equal("/grpc.Fixture/Ping", tags[ext.GRPCFullMethod])
 	assert.Equal(ext.SpanKindServer, tags[ext.SpanKind])
+	assert.Equal(componentName, tags[ext.Component])
+	assert.Equal(methodKindUnary, tags[tagMethodKind])
+	assert.Equal(`{"name":"name"}`, tags[tagRequest])
+}
+
+func TestServerStatsHandlerStream(t *testing.T) {
+	server, err := newServerStatsHandlerTestServer(NewServerStatsHandler())
+	if err != nil {
+		t.Fatalf("failed to start test server: %s", err)
+	}
+	defer server.Close()
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	stream, err := server.client.StreamPing(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, stream.Send(&FixtureRequest{Name: "break"}))
+	_, err = stream.Recv()
+	assert.NoError(t, err)
+	assert.NoError(t, stream.CloseSend())
+	// to flush the spans
+	_, _ = stream.Recv()
+
+	waitForSpans(mt, 1)
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, methodKindBidiStream, spans[0].Tag(tagMethodKind))
+	assert.Nil(t, spans[0].Tag(tagRequest))
+}
+
+func TestServerStatsHandlerIgnoredMethod(t *testing.T) {
+	server, err := newServerStatsHandlerTestServer(NewServerStatsHandler(WithUntracedMethods("/grpc.Fixture/Ping")))
+	if err != nil {
+		t.Fatalf("failed to start test server: %s", err)
+	}
+	defer server.Close()
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	_, err = server.client.Ping(context.Background(), &FixtureRequest{Name: "name"})
+	assert.NoError(t, err)
+	assert.Empty(t, mt.FinishedSpans())
 }
 
 func newServerStatsHandlerTestServer(statsHandler stats.Handler) (*rig, error) {
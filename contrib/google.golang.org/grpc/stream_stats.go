@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package grpc
+
+import (
+	"sync/atomic"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// streamStats counts the messages sent and received by a stream. A nil
+// *streamStats counts nothing, for streams without message stats.
+type streamStats struct {
+	sent, received           int64
+	bytesSent, bytesReceived int64
+}
+
+func newStreamStats(cfg *config) *streamStats {
+	if !cfg.streamMessageStats {
+		return nil
+	}
+	return new(streamStats)
+}
+
+// send counts a sent message of the given size, returning its ordinal.
+func (s *streamStats) send(size int) int64 {
+	if s == nil {
+		return 0
+	}
+	atomic.AddInt64(&s.bytesSent, int64(size))
+	return atomic.AddInt64(&s.sent, 1)
+}
+
+// receive counts a received message of the given size, returning its
+// ordinal.
+func (s *streamStats) receive(size int) int64 {
+	if s == nil {
+		return 0
+	}
+	atomic.AddInt64(&s.bytesReceived, int64(size))
+	return atomic.AddInt64(&s.received, 1)
+}
+
+// tag sets the counts of messages on the span of the stream.
+func (s *streamStats) tag(span ddtrace.Span) {
+	if s == nil {
+		return
+	}
+	span.SetTag(tagMessagesSent, atomic.LoadInt64(&s.sent))
+	span.SetTag(tagMessagesReceived, atomic.LoadInt64(&s.received))
+	span.SetTag(tagBytesSent, atomic.LoadInt64(&s.bytesSent))
+	span.SetTag(tagBytesReceived, atomic.LoadInt64(&s.bytesReceived))
+}
+
+// messageSize returns the encoded size of m, or 0 if it is not a protocol
+// buffers message.
+func messageSize(m interface{}) int {
+	if p, ok := m.(proto.Message); ok {
+		return proto.Size(p)
+	}
+	return 0
+}
+
+// tagMessage sets the ordinal and size of a stream message on its span. An
+// ordinal of 0 means the message was not counted.
+func tagMessage(span ddtrace.Span, ordinal int64, size int) {
+	if ordinal == 0 {
+		return
+	}
+	span.SetTag(tagMessageOrdinal, ordinal)
+	span.SetTag(tagMessageSize, size)
+}
@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package grpc
+
+import (
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	context "golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// streamPings sends and receives two pings on a stream, then closes it.
+func streamPings(t *testing.T, client FixtureClient) {
+	stream, err := client.StreamPing(context.Background())
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, stream.Send(&FixtureRequest{Name: "pass"}))
+		_, err := stream.Recv()
+		require.NoError(t, err)
+	}
+	require.NoError(t, stream.CloseSend())
+	// to flush the spans
+	stream.Recv()
+}
+
+func TestStreamMessageStats(t *testing.T) {
+	reqSize := int64(proto.Size(&FixtureRequest{Name: "pass"}))
+	replySize := int64(proto.Size(&FixtureReply{Message: "passed"}))
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	rig, err := newRig(true, WithStreamMessageStats(true))
+	require.NoError(t, err)
+	defer rig.Close()
+	streamPings(t, rig.client)
+
+	// 2 calls, 2 sent and 3 received messages on each side, the last one
+	// being the end of the stream.
+	waitForSpans(mt, 12)
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 12)
+
+	var ordinals []interface{}
+	for _, s := range spans {
+		switch s.OperationName() {
+		case "grpc.client":
+			assert.Equal(t, int64(2), s.Tag(tagMessagesSent))
+			assert.Equal(t, int64(2), s.Tag(tagMessagesReceived))
+			assert.Equal(t, 2*reqSize, s.Tag(tagBytesSent))
+			assert.Equal(t, 2*replySize, s.Tag(tagBytesReceived))
+		case "grpc.server":
+			assert.Equal(t, int64(2), s.Tag(tagMessagesSent))
+			assert.Equal(t, int64(2), s.Tag(tagMessagesReceived))
+			assert.Equal(t, 2*replySize, s.Tag(tagBytesSent))
+			assert.Equal(t, 2*reqSize, s.Tag(tagBytesReceived))
+		case "grpc.message":
+			if o := s.Tag(tagMessageOrdinal); o != nil {
+				ordinals = append(ordinals, o)
+				assert.Contains(t, []int{int(reqSize), int(replySize)}, s.Tag(tagMessageSize))
+			}
+		}
+	}
+	// the ends of the streams are not counted
+	assert.ElementsMatch(t, []interface{}{
+		int64(1), int64(2), int64(1), int64(2), // client
+		int64(1), int64(2), int64(1), int64(2), // server
+	}, ordinals)
+}
+
+func TestStreamMessageStatsDisabled(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	rig, err := newRig(true)
+	require.NoError(t, err)
+	defer rig.Close()
+	streamPings(t, rig.client)
+
+	waitForSpans(mt, 12)
+	for _, s := range mt.FinishedSpans() {
+		assert.Nil(t, s.Tag(tagMessagesSent))
+		assert.Nil(t, s.Tag(tagMessageOrdinal))
+	}
+}
+
+func TestStatsHandlerStreamMessageStats(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	rig, err := newRigWithInterceptors(
+		[]grpc.ServerOption{grpc.StatsHandler(NewServerStatsHandler(WithStreamMessageStats(true)))},
+		[]grpc.DialOption{grpc.WithInsecure(), grpc.WithStatsHandler(NewClientStatsHandler(WithStreamMessageStats(true)))},
+	)
+	require.NoError(t, err)
+	defer rig.Close()
+	streamPings(t, rig.client)
+
+	waitForSpans(mt, 2)
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	for _, s := range spans {
+		assert.Equal(t, int64(2), s.Tag(tagMessagesSent), s.OperationName())
+		assert.Equal(t, int64(2), s.Tag(tagMessagesReceived), s.OperationName())
+		assert.NotZero(t, s.Tag(tagBytesSent))
+		assert.NotZero(t, s.Tag(tagBytesReceived))
+	}
+}
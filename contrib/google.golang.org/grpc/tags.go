@@ -12,6 +12,13 @@ const (
 	tagCode           = "grpc.code"
 	tagMetadataPrefix = "grpc.metadata."
 	tagRequest        = "grpc.request"
+
+	tagMessagesSent     = "grpc.stream.messages_sent"
+	tagMessagesReceived = "grpc.stream.messages_received"
+	tagBytesSent        = "grpc.stream.bytes_sent"
+	tagBytesReceived    = "grpc.stream.bytes_received"
+	tagMessageOrdinal   = "grpc.message.ordinal"
+	tagMessageSize      = "grpc.message.size"
 )
 
 const (
@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package genai_test
+
+import (
+	"context"
+	"log"
+	"os"
+
+	genaitrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/google/generative-ai-go/genai"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+)
+
+func Example() {
+	tracer.Start()
+	defer tracer.Stop()
+
+	ctx := context.Background()
+	opts := append(genaitrace.ClientOptions(genaitrace.WithServiceName("my-llm-app")),
+		option.WithAPIKey(os.Getenv("GEMINI_API_KEY")),
+	)
+	client, err := genai.NewClient(ctx, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	span, ctx := tracer.StartSpanFromContext(ctx, "answer")
+	defer span.Finish()
+
+	model := client.GenerativeModel("gemini-1.5-flash")
+	resp, err := model.GenerateContent(ctx, genai.Text("What is distributed tracing?"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println(resp.Candidates[0].Content.Parts[0])
+}
@@ -0,0 +1,228 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package genai provides functions to trace the google/generative-ai-go package (https://github.com/google/generative-ai-go).
+//
+// The Gemini client calls the API over gRPC. The interceptors of this package,
+// added to the client with ClientOptions, trace the generation of contents and
+// embeddings. The spans of generations are tagged with the requested model,
+// the token usage and the finish reason, and the spans of streamed generations
+// with the time to the first token, following the same conventions as the
+// other LLM integrations so that they can be processed by LLM Observability.
+// The prompts and responses can also be captured, with redaction, with
+// WithContentCapture.
+//
+// The spans of streamed generations finish when the stream is read to the
+// end or fails.
+package genai // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/google/generative-ai-go/genai"
+
+import (
+	"context"
+	"io"
+	"math"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/llmobs"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+const componentName = "google/generative-ai-go/genai"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+const modelProvider = "google"
+
+// Tags set on the spans.
+const (
+	tagMethod       = "gemini.request.method"
+	tagRequestModel = "gemini.request.model"
+	tagStream       = "gemini.request.stream"
+	tagFinishReason = "gemini.response.finish_reason"
+)
+
+// methodKinds maps the traced methods of the GenerativeService to the kind of
+// their spans.
+var methodKinds = map[string]string{
+	"GenerateContent":       llmobs.SpanKindLLM,
+	"StreamGenerateContent": llmobs.SpanKindLLM,
+	"EmbedContent":          llmobs.SpanKindEmbedding,
+	"BatchEmbedContents":    llmobs.SpanKindEmbedding,
+}
+
+// ClientOptions returns the options adding the interceptors of this package
+// to a Gemini client, to be passed to genai.NewClient.
+func ClientOptions(opts ...Option) []option.ClientOption {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/google/generative-ai-go/genai: Configuring ClientOptions: %#v", cfg)
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(unaryClientInterceptor(cfg))),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(streamClientInterceptor(cfg))),
+	}
+}
+
+// UnaryClientInterceptor returns a grpc.UnaryClientInterceptor tracing the
+// unary calls to the GenerativeService. Other calls aren't traced.
+func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/google/generative-ai-go/genai: Configuring UnaryClientInterceptor: %#v", cfg)
+	return unaryClientInterceptor(cfg)
+}
+
+// StreamClientInterceptor returns a grpc.StreamClientInterceptor tracing the
+// streaming calls to the GenerativeService. Other calls aren't traced.
+func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/google/generative-ai-go/genai: Configuring StreamClientInterceptor: %#v", cfg)
+	return streamClientInterceptor(cfg)
+}
+
+func newConfig(opts ...Option) *config {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return cfg
+}
+
+// parseMethod returns the name of the given full gRPC method and the kind of
+// its spans. It returns false if the method isn't traced.
+func parseMethod(fullMethod string) (name, kind string, ok bool) {
+	if !strings.Contains(fullMethod, ".GenerativeService/") {
+		return "", "", false
+	}
+	name = path.Base(fullMethod)
+	kind, ok = methodKinds[name]
+	return name, kind, ok
+}
+
+func startSpan(ctx context.Context, cfg *config, method, kind string, stream bool) (ddtrace.Span, context.Context) {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(cfg.serviceName),
+		tracer.ResourceName(method),
+		tracer.Tag(tagMethod, method),
+		tracer.Tag(tagStream, stream),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		tracer.Tag(llmobs.TagSpanKind, kind),
+		tracer.Tag(llmobs.TagModelProvider, modelProvider),
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	return tracer.StartSpanFromContext(ctx, cfg.spanName, opts...)
+}
+
+func unaryClientInterceptor(cfg *config) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		name, kind, ok := parseMethod(method)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		span, ctx := startSpan(ctx, cfg, name, kind, false)
+		tagRequest(span, cfg, req)
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil {
+			tagResponse(span, cfg, reply)
+		}
+		span.Finish(tracer.WithError(err))
+		return err
+	}
+}
+
+func streamClientInterceptor(cfg *config) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		name, kind, ok := parseMethod(method)
+		if !ok {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+		span, ctx := startSpan(ctx, cfg, name, kind, true)
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			span.Finish(tracer.WithError(err))
+			return cs, err
+		}
+		return &clientStream{
+			ClientStream: cs,
+			span:         span,
+			cfg:          cfg,
+			text:         llmobs.StreamText{Span: span, Start: time.Now(), Capture: cfg.capture.Enabled},
+		}, nil
+	}
+}
+
+// clientStream tags the span of a streamed generation with the request it
+// sends and the responses it receives, and finishes it when the stream ends.
+type clientStream struct {
+	grpc.ClientStream
+	span ddtrace.Span
+	cfg  *config
+	text llmobs.StreamText
+	// last holds the last received response, whose usage is cumulative.
+	last *response
+	once sync.Once
+}
+
+func (cs *clientStream) SendMsg(m interface{}) error {
+	tagRequest(cs.span, cs.cfg, m)
+	err := cs.ClientStream.SendMsg(m)
+	if err != nil && err != io.EOF {
+		cs.finish(err)
+	}
+	return err
+}
+
+func (cs *clientStream) RecvMsg(m interface{}) error {
+	err := cs.ClientStream.RecvMsg(m)
+	if err == io.EOF {
+		cs.finish(nil)
+		return err
+	}
+	if err != nil {
+		cs.finish(err)
+		return err
+	}
+	if resp, ok := parseResponse(m); ok {
+		cs.text.Write(resp.text)
+		if cs.last != nil {
+			if resp.finishReason == "" {
+				resp.finishReason = cs.last.finishReason
+			}
+			if resp.role == "" {
+				resp.role = cs.last.role
+			}
+		}
+		cs.last = &resp
+	}
+	return nil
+}
+
+func (cs *clientStream) finish(err error) {
+	cs.once.Do(func() {
+		if cs.last != nil {
+			// The output is the text accumulated from all the responses,
+			// rather than the text of the last one.
+			cs.last.tag(cs.span, llmobs.Capture{})
+			if out := cs.text.String(); out != "" {
+				cs.cfg.capture.TagMessages(cs.span, llmobs.TagOutputMessages, []llmobs.Message{
+					{Role: cs.last.role, Content: out},
+				})
+			}
+		}
+		cs.span.Finish(tracer.WithError(err))
+	})
+}
@@ -0,0 +1,197 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package genai
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/llmobs"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	pb "cloud.google.com/go/ai/generativelanguage/apiv1beta/generativelanguagepb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+type server struct {
+	pb.UnimplementedGenerativeServiceServer
+}
+
+func textContent(role, text string) *pb.Content {
+	return &pb.Content{Role: role, Parts: []*pb.Part{{Data: &pb.Part_Text{Text: text}}}}
+}
+
+func (server) GenerateContent(_ context.Context, req *pb.GenerateContentRequest) (*pb.GenerateContentResponse, error) {
+	if req.GetModel() == "models/unknown" {
+		return nil, status.Error(codes.NotFound, "model not found")
+	}
+	return &pb.GenerateContentResponse{
+		Candidates: []*pb.Candidate{{
+			Content:      textContent("model", "Hello there"),
+			FinishReason: pb.Candidate_STOP,
+		}},
+		UsageMetadata: &pb.GenerateContentResponse_UsageMetadata{
+			PromptTokenCount:     6,
+			CandidatesTokenCount: 2,
+			TotalTokenCount:      8,
+		},
+	}, nil
+}
+
+func (server) StreamGenerateContent(_ *pb.GenerateContentRequest, stream pb.GenerativeService_StreamGenerateContentServer) error {
+	for _, resp := range []*pb.GenerateContentResponse{
+		{Candidates: []*pb.Candidate{{Content: textContent("model", "Hello")}}},
+		{
+			Candidates: []*pb.Candidate{{Content: textContent("", " there"), FinishReason: pb.Candidate_STOP}},
+			UsageMetadata: &pb.GenerateContentResponse_UsageMetadata{
+				PromptTokenCount:     6,
+				CandidatesTokenCount: 2,
+				TotalTokenCount:      8,
+			},
+		},
+	} {
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (server) EmbedContent(context.Context, *pb.EmbedContentRequest) (*pb.EmbedContentResponse, error) {
+	return &pb.EmbedContentResponse{Embedding: &pb.ContentEmbedding{Values: []float32{0.1, 0.2}}}, nil
+}
+
+func newClient(t *testing.T, opts ...Option) pb.GenerativeServiceClient {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	pb.RegisterGenerativeServiceServer(srv, server{})
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial(ln.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(opts...)),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(opts...)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewGenerativeServiceClient(conn)
+}
+
+func generateRequest(model string) *pb.GenerateContentRequest {
+	return &pb.GenerateContentRequest{
+		Model:             model,
+		SystemInstruction: textContent("", "Be brief."),
+		Contents:          []*pb.Content{textContent("user", "Hi, I am jane@example.com")},
+	}
+}
+
+func TestGenerateContent(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	client := newClient(t, WithContentCapture(true))
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "root")
+	_, err := client.GenerateContent(ctx, generateRequest("models/gemini-1.5-flash"))
+	require.NoError(t, err)
+	root.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	span := spans[0]
+	assert.Equal(t, "gemini.request", span.OperationName())
+	assert.Equal(t, root.Context().SpanID(), span.ParentID())
+	assert.Equal(t, "gemini", span.Tag(ext.ServiceName))
+	assert.Equal(t, "GenerateContent", span.Tag(ext.ResourceName))
+	assert.Equal(t, "gemini-1.5-flash", span.Tag(tagRequestModel))
+	assert.Equal(t, "gemini-1.5-flash", span.Tag(llmobs.TagModelName))
+	assert.Equal(t, "google", span.Tag(llmobs.TagModelProvider))
+	assert.Equal(t, llmobs.SpanKindLLM, span.Tag(llmobs.TagSpanKind))
+	assert.Equal(t, false, span.Tag(tagStream))
+	assert.Equal(t, "STOP", span.Tag(tagFinishReason))
+	assert.Equal(t, "STOP", span.Tag(llmobs.TagStopReason))
+	assert.Equal(t, int64(6), span.Tag(llmobs.TagInputTokens))
+	assert.Equal(t, int64(2), span.Tag(llmobs.TagOutputTokens))
+	assert.Equal(t, int64(8), span.Tag(llmobs.TagTotalTokens))
+	assert.Equal(t, `[{"role":"system","content":"Be brief."},{"role":"user","content":"Hi, I am <redacted>"}]`, span.Tag(llmobs.TagInputMessages))
+	assert.Equal(t, `[{"role":"model","content":"Hello there"}]`, span.Tag(llmobs.TagOutputMessages))
+	assert.Equal(t, componentName, span.Tag(ext.Component))
+	assert.Equal(t, ext.SpanKindClient, span.Tag(ext.SpanKind))
+	assert.Nil(t, span.Tag(ext.Error))
+}
+
+func TestGenerateContentError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	client := newClient(t)
+
+	_, err := client.GenerateContent(context.Background(), generateRequest("models/unknown"))
+	require.Error(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.NotNil(t, spans[0].Tag(ext.Error))
+	assert.Nil(t, spans[0].Tag(llmobs.TagInputTokens))
+}
+
+func TestStreamGenerateContent(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	client := newClient(t, WithContentCapture(true))
+
+	stream, err := client.StreamGenerateContent(context.Background(), generateRequest("models/gemini-1.5-flash"))
+	require.NoError(t, err)
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "StreamGenerateContent", span.Tag(ext.ResourceName))
+	assert.Equal(t, true, span.Tag(tagStream))
+	assert.Equal(t, "gemini-1.5-flash", span.Tag(llmobs.TagModelName))
+	assert.Equal(t, "STOP", span.Tag(llmobs.TagStopReason))
+	assert.Equal(t, int64(8), span.Tag(llmobs.TagTotalTokens))
+	assert.IsType(t, float64(0), span.Tag(llmobs.TagTimeToFirstToken))
+	assert.Equal(t, `[{"role":"model","content":"Hello there"}]`, span.Tag(llmobs.TagOutputMessages))
+	assert.Nil(t, span.Tag(ext.Error))
+}
+
+func TestEmbedContent(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	client := newClient(t)
+
+	_, err := client.EmbedContent(context.Background(), &pb.EmbedContentRequest{
+		Model:   "models/text-embedding-004",
+		Content: textContent("", "Hello"),
+	})
+	require.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "EmbedContent", span.Tag(ext.ResourceName))
+	assert.Equal(t, llmobs.SpanKindEmbedding, span.Tag(llmobs.TagSpanKind))
+	assert.Equal(t, "text-embedding-004", span.Tag(llmobs.TagModelName))
+	// Contents aren't captured by default.
+	assert.Nil(t, span.Tag(llmobs.TagInputDocuments))
+}
@@ -0,0 +1,14 @@
+module gopkg.in/DataDog/dd-trace-go.v1/contrib/google/generative-ai-go/genai
+
+go 1.25.1
+
+require (
+	cloud.google.com/go/ai v0.8.0
+	github.com/google/generative-ai-go v0.19.0
+	github.com/stretchr/testify v1.8.4
+	google.golang.org/api v0.186.0
+	google.golang.org/grpc v1.64.0
+	gopkg.in/DataDog/dd-trace-go.v1 v1.54.0
+)
+
+replace gopkg.in/DataDog/dd-trace-go.v1 => ../../../..
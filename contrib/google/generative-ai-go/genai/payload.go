@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package genai
+
+import (
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/llmobs"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+
+	pb "cloud.google.com/go/ai/generativelanguage/apiv1beta/generativelanguagepb"
+)
+
+// modelName returns the name of the given model resource, such as
+// gemini-1.5-flash for models/gemini-1.5-flash.
+func modelName(model string) string {
+	return strings.TrimPrefix(model, "models/")
+}
+
+func tagModel(span ddtrace.Span, model string) {
+	if model == "" {
+		return
+	}
+	model = modelName(model)
+	span.SetTag(tagRequestModel, model)
+	span.SetTag(llmobs.TagModelName, model)
+}
+
+// contentText returns the text parts of c.
+func contentText(c *pb.Content) string {
+	parts := c.GetParts()
+	texts := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if t := p.GetText(); t != "" {
+			texts = append(texts, t)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// tagRequest tags span with the model and input of the request m.
+func tagRequest(span ddtrace.Span, cfg *config, m interface{}) {
+	switch req := m.(type) {
+	case *pb.GenerateContentRequest:
+		tagModel(span, req.GetModel())
+		msgs := make([]llmobs.Message, 0, len(req.GetContents())+1)
+		if system := contentText(req.GetSystemInstruction()); system != "" {
+			msgs = append(msgs, llmobs.Message{Role: "system", Content: system})
+		}
+		for _, c := range req.GetContents() {
+			msgs = append(msgs, llmobs.Message{Role: c.GetRole(), Content: contentText(c)})
+		}
+		cfg.capture.TagMessages(span, llmobs.TagInputMessages, msgs)
+	case *pb.EmbedContentRequest:
+		tagModel(span, req.GetModel())
+		cfg.capture.TagDocuments(span, llmobs.TagInputDocuments, []string{contentText(req.GetContent())})
+	case *pb.BatchEmbedContentsRequest:
+		tagModel(span, req.GetModel())
+		texts := make([]string, len(req.GetRequests()))
+		for i, r := range req.GetRequests() {
+			texts[i] = contentText(r.GetContent())
+		}
+		cfg.capture.TagDocuments(span, llmobs.TagInputDocuments, texts)
+	}
+}
+
+// response holds the fields of a generation response which are relevant to
+// tracing.
+type response struct {
+	usage        *pb.GenerateContentResponse_UsageMetadata
+	finishReason string
+	role         string
+	text         string
+}
+
+// parseResponse returns the response held by m. It returns false if m isn't
+// a generation response.
+func parseResponse(m interface{}) (response, bool) {
+	resp, ok := m.(*pb.GenerateContentResponse)
+	if !ok {
+		return response{}, false
+	}
+	r := response{usage: resp.GetUsageMetadata()}
+	// Only the first candidate is tagged, as more are rarely requested.
+	if candidates := resp.GetCandidates(); len(candidates) > 0 {
+		c := candidates[0]
+		if c.GetFinishReason() != pb.Candidate_FINISH_REASON_UNSPECIFIED {
+			r.finishReason = c.GetFinishReason().String()
+		}
+		r.role = c.GetContent().GetRole()
+		r.text = contentText(c.GetContent())
+	}
+	return r, true
+}
+
+// tag tags span with the usage and finish reason of r, and with its output if
+// enabled.
+func (r *response) tag(span ddtrace.Span, capture llmobs.Capture) {
+	if r.usage != nil {
+		llmobs.TagUsage(span,
+			int64(r.usage.GetPromptTokenCount()),
+			int64(r.usage.GetCandidatesTokenCount()),
+			int64(r.usage.GetTotalTokenCount()),
+		)
+	}
+	if r.finishReason != "" {
+		span.SetTag(tagFinishReason, r.finishReason)
+		span.SetTag(llmobs.TagStopReason, r.finishReason)
+	}
+	if r.text != "" {
+		capture.TagMessages(span, llmobs.TagOutputMessages, []llmobs.Message{
+			{Role: r.role, Content: r.text},
+		})
+	}
+}
+
+// tagResponse tags span with the reply m of a unary call.
+func tagResponse(span ddtrace.Span, cfg *config, m interface{}) {
+	if r, ok := parseResponse(m); ok {
+		r.tag(span, cfg.capture)
+	}
+}
@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package websocket_test
+
+import (
+	"net/http"
+
+	websockettrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/gorilla/websocket.v1"
+	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/gorilla/websocket"
+)
+
+func Example() {
+	tracer.Start()
+	defer tracer.Stop()
+
+	var upgrader websocket.Upgrader
+	mux := httptrace.NewServeMux()
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websockettrace.Upgrade(&upgrader, w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, p, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(messageType, p); err != nil {
+				return
+			}
+		}
+	})
+	http.ListenAndServe(":8080", mux)
+}
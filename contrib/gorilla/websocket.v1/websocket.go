@@ -0,0 +1,263 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package websocket provides functions to trace the gorilla/websocket package (https://github.com/gorilla/websocket).
+//
+// Every traced connection is covered by a span from its handshake until it is
+// closed, tagged with the number of messages and bytes sent and received. The
+// messages read and written with ReadMessage, WriteMessage, ReadJSON and
+// WriteJSON are additionally traced by their own spans, unless disabled with
+// WithMessageSpans. Messages read or written with NextReader or NextWriter
+// are not traced.
+package websocket // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/gorilla/websocket.v1"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/gorilla/websocket"
+)
+
+const componentName = "gorilla/websocket.v1"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+const (
+	tagSubprotocol      = "websocket.subprotocol"
+	tagCloseCode        = "websocket.close_code"
+	tagMessageType      = "websocket.message.type"
+	tagMessageSize      = "websocket.message.size"
+	tagMessagesSent     = "websocket.messages_sent"
+	tagMessagesReceived = "websocket.messages_received"
+	tagBytesSent        = "websocket.bytes_sent"
+	tagBytesReceived    = "websocket.bytes_received"
+)
+
+// Conn is a traced websocket.Conn. Its span is finished when it is closed.
+type Conn struct {
+	*websocket.Conn
+	cfg  *config
+	span ddtrace.Span
+	once sync.Once
+
+	messagesSent, messagesReceived int64
+	bytesSent, bytesReceived       int64
+}
+
+// Upgrade upgrades the HTTP server connection to the WebSocket protocol using
+// u, and returns the traced connection. The connection span is a child of the
+// span found in the request context, such as one started by
+// contrib/net/http, or else of the span context propagated by the handshake
+// request headers.
+func Upgrade(u *websocket.Upgrader, w http.ResponseWriter, r *http.Request, responseHeader http.Header, opts ...Option) (*Conn, error) {
+	conn, err := u.Upgrade(w, r, responseHeader)
+	if err != nil {
+		return nil, err
+	}
+	var parent ddtrace.SpanContext
+	if span, ok := tracer.SpanFromContext(r.Context()); ok {
+		parent = span.Context()
+	} else if spanctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(r.Header)); err == nil {
+		parent = spanctx
+	}
+	return wrapConn(conn, parent, ext.SpanKindServer, opts...), nil
+}
+
+// DialContext creates a new client connection using d, propagating the span
+// found in ctx, if any, through the handshake request headers, and returns the
+// traced connection as a child of that span.
+func DialContext(ctx context.Context, d *websocket.Dialer, urlStr string, requestHeader http.Header, opts ...Option) (*Conn, *http.Response, error) {
+	var parent ddtrace.SpanContext
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		parent = span.Context()
+		requestHeader = requestHeader.Clone()
+		if requestHeader == nil {
+			requestHeader = make(http.Header)
+		}
+		if err := tracer.Inject(parent, tracer.HTTPHeadersCarrier(requestHeader)); err != nil {
+			log.Debug("contrib/gorilla/websocket.v1: failed to inject span context: %v", err)
+		}
+	}
+	conn, res, err := d.DialContext(ctx, urlStr, requestHeader)
+	if err != nil {
+		return nil, res, err
+	}
+	return wrapConn(conn, parent, ext.SpanKindClient, opts...), res, nil
+}
+
+// WrapConn returns a traced connection for conn, which is a child of parent
+// if it is not nil.
+func WrapConn(conn *websocket.Conn, parent ddtrace.SpanContext, opts ...Option) *Conn {
+	return wrapConn(conn, parent, "", opts...)
+}
+
+func wrapConn(conn *websocket.Conn, parent ddtrace.SpanContext, spanKind string, opts ...Option) *Conn {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/gorilla/websocket.v1: Wrapping Conn: %#v", cfg)
+	spanOpts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(cfg.serviceName),
+		tracer.Tag(ext.Component, componentName),
+	}
+	if parent != nil {
+		spanOpts = append(spanOpts, tracer.ChildOf(parent))
+	}
+	if spanKind != "" {
+		spanOpts = append(spanOpts, tracer.Tag(ext.SpanKind, spanKind))
+	}
+	if p := conn.Subprotocol(); p != "" {
+		spanOpts = append(spanOpts, tracer.Tag(tagSubprotocol, p))
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		spanOpts = append(spanOpts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	return &Conn{
+		Conn: conn,
+		cfg:  cfg,
+		span: tracer.StartSpan("websocket.connection", spanOpts...),
+	}
+}
+
+// Context returns a context holding the span of the connection, for tracing
+// work done on behalf of it.
+func (c *Conn) Context() context.Context {
+	return tracer.ContextWithSpan(context.Background(), c.span)
+}
+
+// ReadMessage calls websocket.Conn.ReadMessage and traces the message read.
+func (c *Conn) ReadMessage() (messageType int, p []byte, err error) {
+	messageType, p, err = c.Conn.ReadMessage()
+	c.received(messageType, p, err)
+	return messageType, p, err
+}
+
+// WriteMessage calls websocket.Conn.WriteMessage and traces the message
+// written.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	start := time.Now()
+	err := c.Conn.WriteMessage(messageType, data)
+	c.sent(start, messageType, data, err)
+	return err
+}
+
+// ReadJSON reads the next JSON-encoded message from the connection and stores
+// it in the value pointed to by v, tracing the message read.
+func (c *Conn) ReadJSON(v interface{}) error {
+	_, p, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.NewDecoder(bytes.NewReader(p)).Decode(v)
+}
+
+// WriteJSON writes the JSON encoding of v as a message, tracing the message
+// written.
+func (c *Conn) WriteJSON(v interface{}) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		return err
+	}
+	return c.WriteMessage(websocket.TextMessage, buf.Bytes())
+}
+
+// Close closes the connection and finishes its span.
+func (c *Conn) Close() error {
+	err := c.Conn.Close()
+	c.finish()
+	return err
+}
+
+// received accounts for a message read from the connection.
+func (c *Conn) received(messageType int, p []byte, err error) {
+	if err != nil {
+		var closeErr *websocket.CloseError
+		if errors.As(err, &closeErr) {
+			c.span.SetTag(tagCloseCode, closeErr.Code)
+		}
+		return
+	}
+	atomic.AddInt64(&c.bytesReceived, int64(len(p)))
+	atomic.AddInt64(&c.messagesReceived, 1)
+	if !c.cfg.messageSpans {
+		return
+	}
+	// Reads block until the peer sends a message, so the span marks when the
+	// message was received rather than covering the wait for it.
+	span := c.startMessageSpan("websocket.receive", ext.SpanKindConsumer, time.Now(), messageType, len(p))
+	span.Finish()
+}
+
+// sent accounts for a message written to the connection starting at start.
+func (c *Conn) sent(start time.Time, messageType int, data []byte, err error) {
+	if err == nil {
+		atomic.AddInt64(&c.bytesSent, int64(len(data)))
+		atomic.AddInt64(&c.messagesSent, 1)
+	}
+	if !c.cfg.messageSpans {
+		return
+	}
+	span := c.startMessageSpan("websocket.send", ext.SpanKindProducer, start, messageType, len(data))
+	span.Finish(tracer.WithError(err))
+}
+
+func (c *Conn) startMessageSpan(name, spanKind string, start time.Time, messageType, size int) ddtrace.Span {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ChildOf(c.span.Context()),
+		tracer.ServiceName(c.cfg.serviceName),
+		tracer.StartTime(start),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, spanKind),
+		tracer.Tag(tagMessageType, messageTypeName(messageType)),
+		tracer.Tag(tagMessageSize, size),
+	}
+	if !math.IsNaN(c.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
+	}
+	return tracer.StartSpan(name, opts...)
+}
+
+// finish tags the connection span with its message counters and finishes it.
+func (c *Conn) finish() {
+	c.once.Do(func() {
+		c.span.SetTag(tagMessagesSent, atomic.LoadInt64(&c.messagesSent))
+		c.span.SetTag(tagMessagesReceived, atomic.LoadInt64(&c.messagesReceived))
+		c.span.SetTag(tagBytesSent, atomic.LoadInt64(&c.bytesSent))
+		c.span.SetTag(tagBytesReceived, atomic.LoadInt64(&c.bytesReceived))
+		c.span.Finish()
+	})
+}
+
+// messageTypeName returns the name of a websocket message type.
+func messageTypeName(messageType int) string {
+	switch messageType {
+	case websocket.TextMessage:
+		return "text"
+	case websocket.BinaryMessage:
+		return "binary"
+	case websocket.CloseMessage:
+		return "close"
+	case websocket.PingMessage:
+		return "ping"
+	case websocket.PongMessage:
+		return "pong"
+	default:
+		return strconv.Itoa(messageType)
+	}
+}
@@ -0,0 +1,173 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoServer starts a server echoing messages over traced connections until
+// the client closes them. Once a connection is closed, a value is sent to the
+// returned channel.
+func echoServer(t *testing.T, opts ...Option) (*httptest.Server, <-chan struct{}) {
+	var u websocket.Upgrader
+	done := make(chan struct{}, 1)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(&u, w, r, nil, opts...)
+		require.NoError(t, err)
+		defer func() {
+			conn.Close()
+			done <- struct{}{}
+		}()
+		for {
+			messageType, p, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(messageType, p); err != nil {
+				return
+			}
+		}
+	})
+	return httptest.NewServer(httptrace.WrapHandler(h, "web", "GET /ws")), done
+}
+
+func wsURL(srv *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func TestConn(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	srv, done := echoServer(t)
+	defer srv.Close()
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "root")
+	conn, _, err := DialContext(ctx, websocket.DefaultDialer, wsURL(srv), nil, WithServiceName("client"))
+	require.NoError(t, err)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	_, p, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(p))
+
+	require.NoError(t, conn.WriteJSON(map[string]int{"a": 1}))
+	var v map[string]int
+	require.NoError(t, conn.ReadJSON(&v))
+	assert.Equal(t, map[string]int{"a": 1}, v)
+
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	require.NoError(t, conn.Close())
+	root.Finish()
+	<-done
+
+	spans := mt.FinishedSpans()
+	byName := make(map[string][]mocktracer.Span)
+	for _, s := range spans {
+		byName[s.OperationName()] = append(byName[s.OperationName()], s)
+	}
+	require.Len(t, byName["http.request"], 1)
+	require.Len(t, byName["websocket.connection"], 2)
+	request := byName["http.request"][0]
+	assert.Equal(t, root.Context().TraceID(), request.TraceID())
+	assert.Equal(t, root.Context().SpanID(), request.ParentID())
+
+	var client, server mocktracer.Span
+	for _, s := range byName["websocket.connection"] {
+		if s.Tag(ext.SpanKind) == ext.SpanKindClient {
+			client = s
+		} else {
+			server = s
+		}
+	}
+	require.NotNil(t, client)
+	require.NotNil(t, server)
+	assert.Equal(t, root.Context().SpanID(), client.ParentID())
+	assert.Equal(t, "client", client.Tag(ext.ServiceName))
+	assert.Equal(t, componentName, client.Tag(ext.Component))
+	assert.EqualValues(t, 3, client.Tag(tagMessagesSent))
+	assert.EqualValues(t, 2, client.Tag(tagMessagesReceived))
+	assert.EqualValues(t, 5+8, client.Tag(tagBytesReceived))
+
+	assert.Equal(t, request.SpanID(), server.ParentID())
+	assert.Equal(t, ext.SpanKindServer, server.Tag(ext.SpanKind))
+	assert.Equal(t, "websocket", server.Tag(ext.ServiceName))
+	assert.EqualValues(t, 2, server.Tag(tagMessagesSent))
+	assert.EqualValues(t, 2, server.Tag(tagMessagesReceived))
+	assert.EqualValues(t, websocket.CloseNormalClosure, server.Tag(tagCloseCode))
+
+	// The client sent hello, a JSON message and a close message, which the
+	// server received the first two of and echoed.
+	sends, receives := byName["websocket.send"], byName["websocket.receive"]
+	assert.Len(t, sends, 5)
+	assert.Len(t, receives, 4)
+	for _, s := range append(sends, receives...) {
+		assert.Equal(t, componentName, s.Tag(ext.Component))
+		assert.Contains(t, []interface{}{"text", "close"}, s.Tag(tagMessageType))
+		if s.ParentID() != client.SpanID() {
+			assert.Equal(t, server.SpanID(), s.ParentID())
+		}
+	}
+}
+
+func TestWithMessageSpans(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	srv, done := echoServer(t, WithMessageSpans(false))
+	defer srv.Close()
+
+	conn, _, err := DialContext(context.Background(), websocket.DefaultDialer, wsURL(srv), nil, WithMessageSpans(false))
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte{1, 2, 3}))
+	_, _, err = conn.ReadMessage()
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+	<-done
+
+	for _, s := range mt.FinishedSpans() {
+		assert.NotContains(t, []string{"websocket.send", "websocket.receive"}, s.OperationName())
+		if s.OperationName() == "websocket.connection" {
+			assert.EqualValues(t, 1, s.Tag(tagMessagesSent))
+			assert.EqualValues(t, 3, s.Tag(tagBytesSent))
+		}
+	}
+}
+
+func TestWrapConnAnalytics(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	srv, _ := echoServer(t)
+	defer srv.Close()
+
+	c, _, err := websocket.DefaultDialer.Dial(wsURL(srv), nil)
+	require.NoError(t, err)
+	conn := WrapConn(c, nil, WithAnalyticsRate(0.4))
+	require.NoError(t, conn.Close())
+	conn.Close() // the span is only finished once
+
+	var found bool
+	for _, s := range mt.FinishedSpans() {
+		if s.OperationName() == "websocket.connection" && s.ParentID() == 0 {
+			found = true
+			assert.Equal(t, 0.4, s.Tag(ext.EventSampleRate))
+			assert.Nil(t, s.Tag(ext.SpanKind))
+		}
+	}
+	assert.True(t, found)
+}
@@ -8,8 +8,8 @@ package gorm
 
 import (
 	"context"
+	"errors"
 	"math"
-	"time"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
@@ -29,9 +29,15 @@ func init() {
 type key string
 
 const (
-	gormSpanStartTimeKey = key("dd-trace-go:span")
+	// gormParentContextKey holds the context of a statement before the span
+	// of its operation was started, to restore it once the span finishes.
+	gormParentContextKey = key("dd-trace-go:parent")
 )
 
+// tagRowsAffected is the tag holding the number of rows affected or returned
+// by an operation.
+const tagRowsAffected = "db.result.rows_affected"
+
 // Open opens a new (traced) database connection. The used driver must be formerly registered
 // using (gopkg.in/DataDog/dd-trace-go.v1/contrib/database/sql).Register.
 func Open(dialector gorm.Dialector, cfg *gorm.Config, opts ...Option) (*gorm.DB, error) {
@@ -48,102 +54,123 @@ func withCallbacks(db *gorm.DB, opts ...Option) (*gorm.DB, error) {
 	for _, fn := range opts {
 		fn(cfg)
 	}
+	if cfg.ignoreRecordNotFound {
+		errCheck := cfg.errCheck
+		cfg.errCheck = func(err error) bool {
+			return !errors.Is(err, gorm.ErrRecordNotFound) && errCheck(err)
+		}
+	}
 	log.Debug("Registering Callbacks: %#v", cfg)
 
-	afterFunc := func(operationName string) func(*gorm.DB) {
+	beforeFunc := func(operationName string) func(*gorm.DB) {
 		return func(db *gorm.DB) {
-			after(db, operationName, cfg)
+			before(db, operationName, cfg)
 		}
 	}
+	afterFunc := func(db *gorm.DB) {
+		after(db, cfg)
+	}
 
 	cb := db.Callback()
-	err := cb.Create().Before("gorm:create").Register("dd-trace-go:before_create", before)
+	err := cb.Create().Before("gorm:create").Register("dd-trace-go:before_create", beforeFunc("gorm.create"))
 	if err != nil {
 		return db, err
 	}
-	err = cb.Create().After("gorm:create").Register("dd-trace-go:after_create", afterFunc("gorm.create"))
+	err = cb.Create().After("gorm:create").Register("dd-trace-go:after_create", afterFunc)
 	if err != nil {
 		return db, err
 	}
-	err = cb.Update().Before("gorm:update").Register("dd-trace-go:before_update", before)
+	err = cb.Update().Before("gorm:update").Register("dd-trace-go:before_update", beforeFunc("gorm.update"))
 	if err != nil {
 		return db, err
 	}
-	err = cb.Update().After("gorm:update").Register("dd-trace-go:after_update", afterFunc("gorm.update"))
+	err = cb.Update().After("gorm:update").Register("dd-trace-go:after_update", afterFunc)
 	if err != nil {
 		return db, err
 	}
-	err = cb.Delete().Before("gorm:delete").Register("dd-trace-go:before_delete", before)
+	err = cb.Delete().Before("gorm:delete").Register("dd-trace-go:before_delete", beforeFunc("gorm.delete"))
 	if err != nil {
 		return db, err
 	}
-	err = cb.Delete().After("gorm:delete").Register("dd-trace-go:after_delete", afterFunc("gorm.delete"))
+	err = cb.Delete().After("gorm:delete").Register("dd-trace-go:after_delete", afterFunc)
 	if err != nil {
 		return db, err
 	}
-	err = cb.Query().Before("gorm:query").Register("dd-trace-go:before_query", before)
+	err = cb.Query().Before("gorm:query").Register("dd-trace-go:before_query", beforeFunc("gorm.query"))
 	if err != nil {
 		return db, err
 	}
-	err = cb.Query().After("gorm:query").Register("dd-trace-go:after_query", afterFunc("gorm.query"))
+	err = cb.Query().After("gorm:query").Register("dd-trace-go:after_query", afterFunc)
 	if err != nil {
 		return db, err
 	}
-	err = cb.Row().Before("gorm:query").Register("dd-trace-go:before_row_query", before)
+	err = cb.Row().Before("gorm:row").Register("dd-trace-go:before_row_query", beforeFunc("gorm.row_query"))
 	if err != nil {
 		return db, err
 	}
-	err = cb.Row().After("gorm:query").Register("dd-trace-go:after_row_query", afterFunc("gorm.row_query"))
+	err = cb.Row().After("gorm:row").Register("dd-trace-go:after_row_query", afterFunc)
 	if err != nil {
 		return db, err
 	}
-	err = cb.Raw().Before("gorm:query").Register("dd-trace-go:before_raw_query", before)
+	err = cb.Raw().Before("gorm:raw").Register("dd-trace-go:before_raw_query", beforeFunc("gorm.raw_query"))
 	if err != nil {
 		return db, err
 	}
-	err = cb.Raw().After("gorm:query").Register("dd-trace-go:after_raw_query", afterFunc("gorm.raw_query"))
+	err = cb.Raw().After("gorm:raw").Register("dd-trace-go:after_raw_query", afterFunc)
 	if err != nil {
 		return db, err
 	}
 	return db, nil
 }
 
-func before(scope *gorm.DB) {
-	if scope.Statement != nil && scope.Statement.Context != nil {
-		scope.Statement.Context = context.WithValue(scope.Statement.Context, gormSpanStartTimeKey, time.Now())
-	}
-}
-
-func after(db *gorm.DB, operationName string, cfg *config) {
+// before starts the span of the operation about to be executed, and sets it in
+// the context of the statement, so that the spans of the queries it runs, such
+// as those of a traced database/sql driver, are its children.
+func before(db *gorm.DB, operationName string, cfg *config) {
 	if db.Statement == nil || db.Statement.Context == nil {
 		return
 	}
-
 	ctx := db.Statement.Context
-	t, ok := ctx.Value(gormSpanStartTimeKey).(time.Time)
-	if !ok {
-		return
-	}
-
 	opts := []ddtrace.StartSpanOption{
-		tracer.StartTime(t),
 		tracer.ServiceName(cfg.serviceName),
 		tracer.SpanType(ext.SpanTypeSQL),
-		tracer.ResourceName(db.Statement.SQL.String()),
 		tracer.Tag(ext.Component, componentName),
 	}
 	if !math.IsNaN(cfg.analyticsRate) {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
 	}
+	_, spanCtx := tracer.StartSpanFromContext(ctx, operationName, opts...)
+	db.Statement.Context = context.WithValue(spanCtx, gormParentContextKey, ctx)
+}
+
+// after finishes the span of the operation which was executed, using its SQL
+// statement as resource, and restores the context of the statement.
+func after(db *gorm.DB, cfg *config) {
+	if db.Statement == nil || db.Statement.Context == nil {
+		return
+	}
+	ctx := db.Statement.Context
+	parent, ok := ctx.Value(gormParentContextKey).(context.Context)
+	if !ok {
+		return
+	}
+	db.Statement.Context = parent
+	span, ok := tracer.SpanFromContext(ctx)
+	if !ok {
+		return
+	}
+	if sql := db.Statement.SQL.String(); sql != "" {
+		span.SetTag(ext.ResourceName, sql)
+	}
 	for key, tagFn := range cfg.tagFns {
 		if tagFn != nil {
-			opts = append(opts, tracer.Tag(key, tagFn(db)))
+			span.SetTag(key, tagFn(db))
 		}
 	}
-
-	span, _ := tracer.StartSpanFromContext(ctx, operationName, opts...)
 	var dbErr error
-	if cfg.errCheck(db.Error) {
+	if db.Error == nil {
+		span.SetTag(tagRowsAffected, db.RowsAffected)
+	} else if cfg.errCheck(db.Error) {
 		dbErr = db.Error
 	}
 	span.Finish(tracer.WithError(dbErr))
@@ -197,6 +197,7 @@ func TestCallbacks(t *testing.T) {
 
 		span := spans[len(spans)-2]
 		a.Equal("gorm.create", span.OperationName())
+		a.Equal(int64(1), span.Tag(tagRowsAffected))
 		a.Equal(ext.SpanTypeSQL, span.Tag(ext.SpanType))
 		a.Equal(queryText, span.Tag(ext.ResourceName))
 		a.Equal("gorm.io/gorm.v1", span.Tag(ext.Component))
@@ -304,8 +305,9 @@ func TestCallbacks(t *testing.T) {
 
 		span := spans[len(spans)-2]
 		a.Equal("gorm.raw_query", span.OperationName())
-		a.Equal(ext.SpanTypeSQL, span.Tag(ext.SpanType))
 		a.Equal(queryText, span.Tag(ext.ResourceName))
+		a.True(span.FinishTime().Sub(span.StartTime()) > 0)
+		a.Equal(ext.SpanTypeSQL, span.Tag(ext.SpanType))
 	})
 }
 
@@ -456,6 +458,19 @@ func TestError(t *testing.T) {
 		}
 		assertErrCheck(t, mt, false, WithErrorCheck(errFn))
 	})
+
+	t.Run("ignore-record-not-found", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		assertErrCheck(t, mt, false, WithIgnoreRecordNotFound())
+	})
+
+	t.Run("ignore-record-not-found-errcheck", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		errFn := func(err error) bool { return true }
+		assertErrCheck(t, mt, false, WithIgnoreRecordNotFound(), WithErrorCheck(errFn))
+	})
 }
 
 func TestCustomTags(t *testing.T) {
@@ -496,3 +511,53 @@ func TestCustomTags(t *testing.T) {
 
 	assert.Equal("bar", s.Tag("foo"))
 }
+
+func TestChildSpans(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	sqltrace.Register("pgx", &stdlib.Driver{}, sqltrace.WithChildSpansOnly())
+	sqlDb, err := sqltrace.Open("pgx", pgConnString, sqltrace.WithChildSpansOnly())
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := Open(postgres.New(postgres.Config{Conn: sqlDb}), &gorm.Config{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = db.AutoMigrate(&Product{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	mt.Reset()
+
+	parentSpan, ctx := tracer.StartSpanFromContext(context.Background(), "http.request")
+	db = db.WithContext(ctx)
+	var product Product
+	db.First(&product, "code = ?", "L1212")
+	db.Exec("select 1")
+	parentSpan.Finish()
+
+	// the queries of each operation are traced by children of its span
+	spans := mt.FinishedSpans()
+	byName := make(map[string]mocktracer.Span)
+	for _, s := range spans {
+		byName[s.OperationName()] = s
+	}
+	query, raw := byName["gorm.query"], byName["gorm.raw_query"]
+	if !assert.NotNil(query) || !assert.NotNil(raw) {
+		return
+	}
+	assert.Equal(parentSpan.Context().SpanID(), query.ParentID())
+	assert.Equal(parentSpan.Context().SpanID(), raw.ParentID())
+	var children int
+	for _, s := range spans {
+		if s.OperationName() != "pgx.query" {
+			continue
+		}
+		children++
+		assert.Contains([]uint64{query.SpanID(), raw.SpanID()}, s.ParentID())
+	}
+	assert.GreaterOrEqual(children, 2)
+}
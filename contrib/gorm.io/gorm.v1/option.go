@@ -19,6 +19,8 @@ type config struct {
 	dsn           string
 	errCheck      func(err error) bool
 	tagFns        map[string]func(db *gorm.DB) interface{}
+
+	ignoreRecordNotFound bool
 }
 
 // Option represents an option that can be passed to Register, Open or OpenDB.
@@ -76,6 +78,16 @@ func WithErrorCheck(fn func(err error) bool) Option {
 	}
 }
 
+// WithIgnoreRecordNotFound causes gorm.ErrRecordNotFound, returned when no
+// record matches the conditions of First, Last or Take, not to be marked as
+// an error on the spans. It applies on top of the function passed to
+// WithErrorCheck, regardless of the order of the options.
+func WithIgnoreRecordNotFound() Option {
+	return func(cfg *config) {
+		cfg.ignoreRecordNotFound = true
+	}
+}
+
 // WithCustomTag will cause the given tagFn to be evaluated after executing
 // a query and attach the result to the span tagged by the key.
 func WithCustomTag(tag string, tagFn func(db *gorm.DB) interface{}) Option {
@@ -0,0 +1,21 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package runtime_test
+
+import (
+	"net/http"
+
+	gatewaytrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/grpc-ecosystem/grpc-gateway.v2/runtime"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+func Example() {
+	mux := runtime.NewServeMux(gatewaytrace.ServeMuxOptions(gatewaytrace.WithServiceName("gateway"))...)
+	// register the handlers generated by protoc-gen-grpc-gateway, such as:
+	// pb.RegisterItemsHandlerFromEndpoint(ctx, mux, "localhost:9090", dialOpts)
+	http.ListenAndServe(":8080", mux)
+}
@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package runtime provides functions to trace the runtime package of grpc-ecosystem/grpc-gateway/v2 (https://github.com/grpc-ecosystem/grpc-gateway).
+//
+// The spans of the requests of a traced runtime.ServeMux are named after the
+// gRPC method they are mapped to, and are the parents of the gRPC calls made
+// to serve them.
+package runtime // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/grpc-ecosystem/grpc-gateway.v2/runtime"
+
+import (
+	"context"
+	"math"
+	"net/http"
+
+	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+const componentName = "grpc-ecosystem/grpc-gateway.v2/runtime"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// spanKey is the key of the span of the request in its context.
+type spanKey struct{}
+
+// ServeMuxOptions returns the options tracing the requests of a
+// runtime.ServeMux, to be passed to runtime.NewServeMux:
+//
+//	mux := runtime.NewServeMux(gatewaytrace.ServeMuxOptions()...)
+//
+// A span is started by a middleware for every request matching a route. Once
+// the gRPC method the request is mapped to is known, it is used as resource
+// name, and the path pattern of the route as http.route. The span context is
+// propagated in the metadata of the gRPC call, so that it is the parent of the
+// call even if the gRPC client is not traced.
+func ServeMuxOptions(opts ...Option) []runtime.ServeMuxOption {
+	cfg := newConfig(opts...)
+	spanOpts := append([]ddtrace.StartSpanOption{
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+	}, cfg.spanOpts...)
+	if !math.IsNaN(cfg.analyticsRate) {
+		spanOpts = append(spanOpts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	cfg.spanOpts = spanOpts
+	log.Debug("contrib/grpc-ecosystem/grpc-gateway.v2/runtime: Configuring ServeMux: %#v", cfg)
+	return []runtime.ServeMuxOption{
+		runtime.WithMiddlewares(middleware(cfg)),
+		runtime.WithMetadata(annotateSpan),
+	}
+}
+
+func middleware(cfg *config) runtime.Middleware {
+	return func(next runtime.HandlerFunc) runtime.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+			h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				span, _ := tracer.SpanFromContext(r.Context())
+				ctx := context.WithValue(r.Context(), spanKey{}, span)
+				next(w, r.WithContext(ctx), pathParams)
+			})
+			httptrace.TraceAndServe(h, w, r, &httptrace.ServeConfig{
+				Service:     cfg.serviceName,
+				Resource:    r.Method,
+				SpanOpts:    cfg.spanOpts,
+				RouteParams: pathParams,
+			})
+		}
+	}
+}
+
+// annotateSpan is a runtime metadata annotator, which is called once the
+// gRPC method of the request is known. It names the span of the request
+// after the method, and returns the metadata propagating its span context.
+func annotateSpan(ctx context.Context, _ *http.Request) metadata.MD {
+	span, ok := ctx.Value(spanKey{}).(ddtrace.Span)
+	if !ok {
+		return nil
+	}
+	if method, ok := runtime.RPCMethod(ctx); ok {
+		span.SetTag(ext.ResourceName, method)
+		span.SetTag(ext.GRPCFullMethod, method)
+	}
+	if pattern, ok := runtime.HTTPPathPattern(ctx); ok {
+		span.SetTag(ext.HTTPRoute, pattern)
+	}
+	carrier := tracer.TextMapCarrier{}
+	if err := tracer.Inject(span.Context(), carrier); err != nil {
+		log.Debug("contrib/grpc-ecosystem/grpc-gateway.v2/runtime: Failed to inject span context into metadata, %v", err)
+		return nil
+	}
+	return metadata.New(carrier)
+}
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package runtime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServeMux(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	mux := runtime.NewServeMux(ServeMuxOptions(WithServiceName("gateway"))...)
+	var outgoing metadata.MD
+	err := mux.HandlePath(http.MethodGet, "/v1/items/{id}", func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		// this is what the handlers generated by protoc-gen-grpc-gateway do
+		// before calling the gRPC method
+		ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/test.v1.Items/GetItem", runtime.WithHTTPPathPattern("/v1/items/{id}"))
+		require.NoError(t, err)
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+		w.WriteHeader(http.StatusOK)
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/items/42", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "http.request", s.OperationName())
+	assert.Equal(t, "/test.v1.Items/GetItem", s.Tag(ext.ResourceName))
+	assert.Equal(t, "/test.v1.Items/GetItem", s.Tag(ext.GRPCFullMethod))
+	assert.Equal(t, "/v1/items/{id}", s.Tag(ext.HTTPRoute))
+	assert.Equal(t, "gateway", s.Tag(ext.ServiceName))
+	assert.Equal(t, "200", s.Tag(ext.HTTPCode))
+	assert.Equal(t, ext.SpanKindServer, s.Tag(ext.SpanKind))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+
+	// the span context is propagated to the gRPC call
+	carrier := tracer.TextMapCarrier{}
+	for k, v := range outgoing {
+		carrier[k] = v[0]
+	}
+	spanctx, err := tracer.Extract(carrier)
+	require.NoError(t, err)
+	assert.Equal(t, s.SpanID(), spanctx.SpanID())
+}
+
+func TestUnannotatedRequest(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	mux := runtime.NewServeMux(ServeMuxOptions()...)
+	err := mux.HandlePath(http.MethodPost, "/healthz", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	require.NoError(t, err)
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/healthz", nil))
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, http.MethodPost, spans[0].Tag(ext.ResourceName))
+	assert.NotNil(t, spans[0].Tag(ext.Error))
+	assert.Nil(t, annotateSpan(context.Background(), nil))
+}
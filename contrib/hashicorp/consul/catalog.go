@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package consul
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+// A Catalog is used to trace requests to Consul's catalog.
+type Catalog struct {
+	*consul.Catalog
+	config *clientConfig
+	ctx    context.Context
+}
+
+// Catalog returns the Catalog for the Client.
+func (c *Client) Catalog() *Catalog {
+	return &Catalog{c.Client.Catalog(), c.config, c.ctx}
+}
+
+func (c *Catalog) startSpan(r request) ddtrace.Span {
+	return c.config.startSpan(c.ctx, r)
+}
+
+// Register is used to register an entity in the catalog.
+func (c *Catalog) Register(reg *consul.CatalogRegistration, q *consul.WriteOptions) (*consul.WriteMeta, error) {
+	span := c.startSpan(writeRequest("CATALOG.REGISTER", tagNode, reg.Node, q))
+	meta, err := c.Catalog.Register(reg, q)
+	finishSpan(span, err)
+	return meta, err
+}
+
+// Deregister is used to deregister an entity from the catalog.
+func (c *Catalog) Deregister(dereg *consul.CatalogDeregistration, q *consul.WriteOptions) (*consul.WriteMeta, error) {
+	span := c.startSpan(writeRequest("CATALOG.DEREGISTER", tagNode, dereg.Node, q))
+	meta, err := c.Catalog.Deregister(dereg, q)
+	finishSpan(span, err)
+	return meta, err
+}
+
+// Datacenters is used to query for all the known datacenters.
+func (c *Catalog) Datacenters() ([]string, error) {
+	span := c.startSpan(request{resource: "CATALOG.DATACENTERS"})
+	dcs, err := c.Catalog.Datacenters()
+	finishSpan(span, err)
+	return dcs, err
+}
+
+// Nodes is used to query all the known nodes.
+func (c *Catalog) Nodes(q *consul.QueryOptions) ([]*consul.Node, *consul.QueryMeta, error) {
+	span := c.startSpan(queryRequest("CATALOG.NODES", "", "", q))
+	nodes, meta, err := c.Catalog.Nodes(q)
+	finishSpan(span, err)
+	return nodes, meta, err
+}
+
+// Services is used to query for all known services.
+func (c *Catalog) Services(q *consul.QueryOptions) (map[string][]string, *consul.QueryMeta, error) {
+	span := c.startSpan(queryRequest("CATALOG.SERVICES", "", "", q))
+	services, meta, err := c.Catalog.Services(q)
+	finishSpan(span, err)
+	return services, meta, err
+}
+
+// Service is used to query catalog entries for a given service.
+func (c *Catalog) Service(service, tag string, q *consul.QueryOptions) ([]*consul.CatalogService, *consul.QueryMeta, error) {
+	span := c.startSpan(queryRequest("CATALOG.SERVICE", tagService, service, q))
+	services, meta, err := c.Catalog.Service(service, tag, q)
+	finishSpan(span, err)
+	return services, meta, err
+}
+
+// Node is used to query for service information about a single node.
+func (c *Catalog) Node(node string, q *consul.QueryOptions) (*consul.CatalogNode, *consul.QueryMeta, error) {
+	span := c.startSpan(queryRequest("CATALOG.NODE", tagNode, node, q))
+	info, meta, err := c.Catalog.Node(node, q)
+	finishSpan(span, err)
+	return info, meta, err
+}
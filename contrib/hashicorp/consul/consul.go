@@ -7,7 +7,6 @@ package consul
 
 import (
 	"context"
-	"math"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
@@ -71,60 +70,42 @@ func (c *Client) KV() *KV {
 	return &KV{c.Client.KV(), c.config, c.ctx}
 }
 
-func (k *KV) startSpan(resourceName string, key string) ddtrace.Span {
-	opts := []ddtrace.StartSpanOption{
-		tracer.ResourceName(resourceName),
-		tracer.ServiceName(k.config.serviceName),
-		tracer.SpanType(ext.SpanTypeConsul),
-		tracer.Tag("consul.key", key),
-		tracer.Tag(ext.Component, componentName),
-		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
-		tracer.Tag(ext.DBSystem, ext.DBSystemConsulKV),
-	}
-
-	if k.config.hostname != "" {
-		opts = append(opts, tracer.Tag(ext.NetworkDestinationName, k.config.hostname))
-	}
-
-	if !math.IsNaN(k.config.analyticsRate) {
-		opts = append(opts, tracer.Tag(ext.EventSampleRate, k.config.analyticsRate))
-	}
-	span, _ := tracer.StartSpanFromContext(k.ctx, k.config.spanName, opts...)
-	return span
+func (k *KV) startSpan(r request) ddtrace.Span {
+	return k.config.startSpan(k.ctx, r, tracer.Tag(ext.DBSystem, ext.DBSystemConsulKV))
 }
 
 // Put is used to write a new value. Only the
 // Key, Flags and Value is respected.
 func (k *KV) Put(p *consul.KVPair, q *consul.WriteOptions) (*consul.WriteMeta, error) {
-	span := k.startSpan("PUT", p.Key)
+	span := k.startSpan(writeRequest("PUT", tagKey, p.Key, q))
 	meta, err := k.KV.Put(p, q)
-	defer span.Finish(tracer.WithError(err))
+	finishSpan(span, err)
 	return meta, err
 }
 
 // Get is used to lookup a single key. The returned pointer
 // to the KVPair will be nil if the key does not exist.
 func (k *KV) Get(key string, q *consul.QueryOptions) (*consul.KVPair, *consul.QueryMeta, error) {
-	span := k.startSpan("GET", key)
+	span := k.startSpan(queryRequest("GET", tagKey, key, q))
 	pair, meta, err := k.KV.Get(key, q)
-	defer span.Finish(tracer.WithError(err))
+	finishSpan(span, err)
 	return pair, meta, err
 }
 
 // List is used to lookup all keys under a prefix.
 func (k *KV) List(prefix string, q *consul.QueryOptions) ([]*consul.KVPair, *consul.QueryMeta, error) {
-	span := k.startSpan("LIST", prefix)
+	span := k.startSpan(queryRequest("LIST", tagKey, prefix, q))
 	pairs, meta, err := k.KV.List(prefix, q)
-	defer span.Finish(tracer.WithError(err))
+	finishSpan(span, err)
 	return pairs, meta, err
 }
 
 // Keys is used to list all the keys under a prefix. Optionally,
 // a separator can be used to limit the responses.
 func (k *KV) Keys(prefix, separator string, q *consul.QueryOptions) ([]string, *consul.QueryMeta, error) {
-	span := k.startSpan("KEYS", prefix)
+	span := k.startSpan(queryRequest("KEYS", tagKey, prefix, q))
 	entries, meta, err := k.KV.Keys(prefix, separator, q)
-	defer span.Finish(tracer.WithError(err))
+	finishSpan(span, err)
 	return entries, meta, err
 }
 
@@ -132,9 +113,9 @@ func (k *KV) Keys(prefix, separator string, q *consul.QueryOptions) ([]string, *
 // ModifyIndex, Flags and Value are respected. Returns true
 // on success or false on failures.
 func (k *KV) CAS(p *consul.KVPair, q *consul.WriteOptions) (bool, *consul.WriteMeta, error) {
-	span := k.startSpan("CAS", p.Key)
+	span := k.startSpan(writeRequest("CAS", tagKey, p.Key, q))
 	r, meta, err := k.KV.CAS(p, q)
-	defer span.Finish(tracer.WithError(err))
+	finishSpan(span, err)
 	return r, meta, err
 }
 
@@ -142,9 +123,9 @@ func (k *KV) CAS(p *consul.KVPair, q *consul.WriteOptions) (bool, *consul.WriteM
 // Flags, Value and Session are respected. Returns true
 // on success or false on failures.
 func (k *KV) Acquire(p *consul.KVPair, q *consul.WriteOptions) (bool, *consul.WriteMeta, error) {
-	span := k.startSpan("ACQUIRE", p.Key)
+	span := k.startSpan(writeRequest("ACQUIRE", tagKey, p.Key, q))
 	r, meta, err := k.KV.Acquire(p, q)
-	defer span.Finish(tracer.WithError(err))
+	finishSpan(span, err)
 	return r, meta, err
 }
 
@@ -152,33 +133,33 @@ func (k *KV) Acquire(p *consul.KVPair, q *consul.WriteOptions) (bool, *consul.Wr
 // Flags, Value and Session are respected. Returns true
 // on success or false on failures.
 func (k *KV) Release(p *consul.KVPair, q *consul.WriteOptions) (bool, *consul.WriteMeta, error) {
-	span := k.startSpan("RELEASE", p.Key)
+	span := k.startSpan(writeRequest("RELEASE", tagKey, p.Key, q))
 	r, meta, err := k.KV.Release(p, q)
-	defer span.Finish(tracer.WithError(err))
+	finishSpan(span, err)
 	return r, meta, err
 }
 
 // Delete is used to delete a single key.
 func (k *KV) Delete(key string, w *consul.WriteOptions) (*consul.WriteMeta, error) {
-	span := k.startSpan("DELETE", key)
+	span := k.startSpan(writeRequest("DELETE", tagKey, key, w))
 	meta, err := k.KV.Delete(key, w)
-	defer span.Finish(tracer.WithError(err))
+	finishSpan(span, err)
 	return meta, err
 }
 
 // DeleteCAS is used for a Delete Check-And-Set operation. The Key
 // and ModifyIndex are respected. Returns true on success or false on failures.
 func (k *KV) DeleteCAS(p *consul.KVPair, q *consul.WriteOptions) (bool, *consul.WriteMeta, error) {
-	span := k.startSpan("DELETECAS", p.Key)
+	span := k.startSpan(writeRequest("DELETECAS", tagKey, p.Key, q))
 	r, meta, err := k.KV.DeleteCAS(p, q)
-	defer span.Finish(tracer.WithError(err))
+	finishSpan(span, err)
 	return r, meta, err
 }
 
 // DeleteTree is used to delete all keys under a prefix.
 func (k *KV) DeleteTree(prefix string, w *consul.WriteOptions) (*consul.WriteMeta, error) {
-	span := k.startSpan("DELETETREE", prefix)
+	span := k.startSpan(writeRequest("DELETETREE", tagKey, prefix, w))
 	meta, err := k.KV.DeleteTree(prefix, w)
-	defer span.Finish(tracer.WithError(err))
+	finishSpan(span, err)
 	return meta, err
 }
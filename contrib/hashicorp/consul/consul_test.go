@@ -107,6 +107,102 @@ func TestKV(t *testing.T) {
 		})
 	}
 }
+func TestCatalogHealthSession(t *testing.T) {
+	for name, tc := range map[string]struct {
+		call     func(c *Client)
+		resource string
+		tag      string
+		value    string
+	}{
+		"CatalogServices": {
+			call:     func(c *Client) { c.Catalog().Services(&consul.QueryOptions{AllowStale: true}) },
+			resource: "CATALOG.SERVICES",
+		},
+		"CatalogService": {
+			call:     func(c *Client) { c.Catalog().Service("consul", "", nil) },
+			resource: "CATALOG.SERVICE",
+			tag:      tagService,
+			value:    "consul",
+		},
+		"HealthService": {
+			call:     func(c *Client) { c.Health().Service("consul", "", true, nil) },
+			resource: "HEALTH.SERVICE",
+			tag:      tagService,
+			value:    "consul",
+		},
+		"HealthState": {
+			call:     func(c *Client) { c.Health().State(consul.HealthPassing, nil) },
+			resource: "HEALTH.STATE",
+			tag:      tagCheckState,
+			value:    consul.HealthPassing,
+		},
+		"SessionList": {
+			call:     func(c *Client) { c.Session().List(nil) },
+			resource: "SESSION.LIST",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+			config := consul.DefaultConfig()
+			config.Datacenter = "dc1"
+			client, err := NewClient(config)
+			require.NoError(t, err)
+
+			tc.call(client)
+
+			spans := mt.FinishedSpans()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			assert.Equal(t, "consul.command", span.OperationName())
+			assert.Equal(t, tc.resource, span.Tag(ext.ResourceName))
+			assert.Equal(t, ext.SpanTypeConsul, span.Tag(ext.SpanType))
+			assert.Equal(t, "dc1", span.Tag(tagDatacenter))
+			assert.NotNil(t, span.Tag(tagConsistency))
+			assert.Nil(t, span.Tag(ext.DBSystem))
+			if tc.tag != "" {
+				assert.Equal(t, tc.value, span.Tag(tc.tag))
+			}
+		})
+	}
+}
+
+func TestConsistencyMode(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	client, err := NewClient(consul.DefaultConfig())
+	require.NoError(t, err)
+	kv := client.KV()
+
+	kv.Get("test.key", nil)
+	kv.Get("test.key", &consul.QueryOptions{AllowStale: true, Datacenter: "dc1"})
+	kv.Get("test.key", &consul.QueryOptions{RequireConsistent: true})
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, consistencyDefault, spans[0].Tag(tagConsistency))
+	assert.Equal(t, consistencyStale, spans[1].Tag(tagConsistency))
+	assert.Equal(t, "dc1", spans[1].Tag(tagDatacenter))
+	assert.Equal(t, consistencyConsistent, spans[2].Tag(tagConsistency))
+}
+
+func TestIgnoreRequest(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	client, err := NewClient(consul.DefaultConfig(), WithIgnoreRequest(func(resource, name string) bool {
+		return resource == "HEALTH.SERVICE" && name == "consul"
+	}))
+	require.NoError(t, err)
+
+	_, _, err = client.Health().Service("consul", "", true, nil)
+	require.NoError(t, err)
+	client.Health().Checks("consul", nil)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "HEALTH.CHECKS", spans[0].Tag(ext.ResourceName))
+}
+
 func TestNamingSchema(t *testing.T) {
 	genSpans := func(t *testing.T, serviceOverride string) []mocktracer.Span {
 		var opts []ClientOption
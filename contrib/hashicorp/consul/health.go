@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package consul
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+// A Health is used to trace requests to Consul's health checks.
+type Health struct {
+	*consul.Health
+	config *clientConfig
+	ctx    context.Context
+}
+
+// Health returns the Health for the Client.
+func (c *Client) Health() *Health {
+	return &Health{c.Client.Health(), c.config, c.ctx}
+}
+
+func (h *Health) startSpan(r request) ddtrace.Span {
+	return h.config.startSpan(h.ctx, r)
+}
+
+// Node is used to query for checks belonging to a given node.
+func (h *Health) Node(node string, q *consul.QueryOptions) (consul.HealthChecks, *consul.QueryMeta, error) {
+	span := h.startSpan(queryRequest("HEALTH.NODE", tagNode, node, q))
+	checks, meta, err := h.Health.Node(node, q)
+	finishSpan(span, err)
+	return checks, meta, err
+}
+
+// Checks is used to return the checks associated with a service.
+func (h *Health) Checks(service string, q *consul.QueryOptions) (consul.HealthChecks, *consul.QueryMeta, error) {
+	span := h.startSpan(queryRequest("HEALTH.CHECKS", tagService, service, q))
+	checks, meta, err := h.Health.Checks(service, q)
+	finishSpan(span, err)
+	return checks, meta, err
+}
+
+// Service is used to query health information along with service info for a
+// given service. It can optionally do server-side filtering on a tag or
+// nodes with passing health checks only.
+func (h *Health) Service(service, tag string, passingOnly bool, q *consul.QueryOptions) ([]*consul.ServiceEntry, *consul.QueryMeta, error) {
+	span := h.startSpan(queryRequest("HEALTH.SERVICE", tagService, service, q))
+	entries, meta, err := h.Health.Service(service, tag, passingOnly, q)
+	finishSpan(span, err)
+	return entries, meta, err
+}
+
+// State is used to retrieve all the checks in a given state.
+func (h *Health) State(state string, q *consul.QueryOptions) (consul.HealthChecks, *consul.QueryMeta, error) {
+	span := h.startSpan(queryRequest("HEALTH.STATE", tagCheckState, state, q))
+	checks, meta, err := h.Health.State(state, q)
+	finishSpan(span, err)
+	return checks, meta, err
+}
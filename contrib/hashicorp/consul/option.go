@@ -24,6 +24,8 @@ type clientConfig struct {
 	spanName      string
 	analyticsRate float64
 	hostname      string
+	datacenter    string
+	ignoreRequest func(resource, name string) bool
 }
 
 // ClientOption represents an option that can be used to create or wrap a client.
@@ -76,11 +78,23 @@ func WithAnalyticsRate(rate float64) ClientOption {
 	}
 }
 
-// WithConfig extracts the config information for the client to be tagged
+// WithConfig extracts the config information for the client to be tagged, such
+// as the address and datacenter of the agent.
 func WithConfig(config *consul.Config) ClientOption {
 	return func(cfg *clientConfig) {
 		if host, _, err := net.SplitHostPort(config.Address); err == nil {
 			cfg.hostname = host
 		}
+		cfg.datacenter = config.Datacenter
+	}
+}
+
+// WithIgnoreRequest holds the function to use for determining if a request
+// should not be traced, such as the periodic health polls of an agent. The
+// function is given the resource name of the request, e.g. HEALTH.SERVICE or
+// GET, and the key, service, node or session the request is made on, if any.
+func WithIgnoreRequest(f func(resource, name string) bool) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.ignoreRequest = f
 	}
 }
@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package consul
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+// A Session is used to trace requests to Consul's sessions.
+type Session struct {
+	*consul.Session
+	config *clientConfig
+	ctx    context.Context
+}
+
+// Session returns the Session for the Client.
+func (c *Client) Session() *Session {
+	return &Session{c.Client.Session(), c.config, c.ctx}
+}
+
+func (s *Session) startSpan(r request) ddtrace.Span {
+	return s.config.startSpan(s.ctx, r)
+}
+
+// Create makes a new session. Providing a session entry can customize the
+// session. It can also be nil to use defaults.
+func (s *Session) Create(se *consul.SessionEntry, q *consul.WriteOptions) (string, *consul.WriteMeta, error) {
+	span := s.startSpan(writeRequest("SESSION.CREATE", "", "", q))
+	id, meta, err := s.Session.Create(se, q)
+	if span != nil && err == nil {
+		span.SetTag(tagSession, id)
+	}
+	finishSpan(span, err)
+	return id, meta, err
+}
+
+// CreateNoChecks is like Create but is used specifically to create a session
+// with no associated health checks.
+func (s *Session) CreateNoChecks(se *consul.SessionEntry, q *consul.WriteOptions) (string, *consul.WriteMeta, error) {
+	span := s.startSpan(writeRequest("SESSION.CREATE", "", "", q))
+	id, meta, err := s.Session.CreateNoChecks(se, q)
+	if span != nil && err == nil {
+		span.SetTag(tagSession, id)
+	}
+	finishSpan(span, err)
+	return id, meta, err
+}
+
+// Destroy invalidates a given session.
+func (s *Session) Destroy(id string, q *consul.WriteOptions) (*consul.WriteMeta, error) {
+	span := s.startSpan(writeRequest("SESSION.DESTROY", tagSession, id, q))
+	meta, err := s.Session.Destroy(id, q)
+	finishSpan(span, err)
+	return meta, err
+}
+
+// Renew renews the TTL on a given session.
+func (s *Session) Renew(id string, q *consul.WriteOptions) (*consul.SessionEntry, *consul.WriteMeta, error) {
+	span := s.startSpan(writeRequest("SESSION.RENEW", tagSession, id, q))
+	entry, meta, err := s.Session.Renew(id, q)
+	finishSpan(span, err)
+	return entry, meta, err
+}
+
+// Info looks up a single session.
+func (s *Session) Info(id string, q *consul.QueryOptions) (*consul.SessionEntry, *consul.QueryMeta, error) {
+	span := s.startSpan(queryRequest("SESSION.INFO", tagSession, id, q))
+	entry, meta, err := s.Session.Info(id, q)
+	finishSpan(span, err)
+	return entry, meta, err
+}
+
+// Node gets sessions for a node.
+func (s *Session) Node(node string, q *consul.QueryOptions) ([]*consul.SessionEntry, *consul.QueryMeta, error) {
+	span := s.startSpan(queryRequest("SESSION.NODE", tagNode, node, q))
+	entries, meta, err := s.Session.Node(node, q)
+	finishSpan(span, err)
+	return entries, meta, err
+}
+
+// List gets all active sessions.
+func (s *Session) List(q *consul.QueryOptions) ([]*consul.SessionEntry, *consul.QueryMeta, error) {
+	span := s.startSpan(queryRequest("SESSION.LIST", "", "", q))
+	entries, meta, err := s.Session.List(q)
+	finishSpan(span, err)
+	return entries, meta, err
+}
@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package consul
+
+import (
+	"context"
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+// Tags set on the spans of the Consul requests.
+const (
+	tagKey         = "consul.key"
+	tagService     = "consul.service"
+	tagNode        = "consul.node"
+	tagCheckState  = "consul.check_state"
+	tagSession     = "consul.session"
+	tagDatacenter  = "consul.datacenter"
+	tagConsistency = "consul.consistency_mode"
+)
+
+// Consistency modes of the read requests.
+// See https://developer.hashicorp.com/consul/api-docs/features/consistency.
+const (
+	consistencyDefault    = "default"
+	consistencyStale      = "stale"
+	consistencyConsistent = "consistent"
+)
+
+// request describes a request made to Consul.
+type request struct {
+	resource string
+	// tag is the tag holding name, the key, service, node or session the
+	// request is made on, if any.
+	tag         string
+	name        string
+	datacenter  string
+	consistency string
+}
+
+// queryRequest returns the description of a read request made with q.
+func queryRequest(resource, tag, name string, q *consul.QueryOptions) request {
+	r := request{resource: resource, tag: tag, name: name, consistency: consistencyDefault}
+	if q != nil {
+		r.datacenter = q.Datacenter
+		switch {
+		case q.RequireConsistent:
+			r.consistency = consistencyConsistent
+		case q.AllowStale:
+			r.consistency = consistencyStale
+		}
+	}
+	return r
+}
+
+// writeRequest returns the description of a write request made with w.
+func writeRequest(resource, tag, name string, w *consul.WriteOptions) request {
+	r := request{resource: resource, tag: tag, name: name}
+	if w != nil {
+		r.datacenter = w.Datacenter
+	}
+	return r
+}
+
+// startSpan starts the span of the request r, as a child of the span found in
+// ctx, if any. It returns nil if the request is ignored.
+func (cfg *clientConfig) startSpan(ctx context.Context, r request, extra ...ddtrace.StartSpanOption) ddtrace.Span {
+	if cfg.ignoreRequest != nil && cfg.ignoreRequest(r.resource, r.name) {
+		return nil
+	}
+	opts := []ddtrace.StartSpanOption{
+		tracer.ResourceName(r.resource),
+		tracer.ServiceName(cfg.serviceName),
+		tracer.SpanType(ext.SpanTypeConsul),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+	}
+	if r.tag != "" {
+		opts = append(opts, tracer.Tag(r.tag, r.name))
+	}
+	if dc := r.datacenter; dc != "" || cfg.datacenter != "" {
+		if dc == "" {
+			dc = cfg.datacenter
+		}
+		opts = append(opts, tracer.Tag(tagDatacenter, dc))
+	}
+	if r.consistency != "" {
+		opts = append(opts, tracer.Tag(tagConsistency, r.consistency))
+	}
+	if cfg.hostname != "" {
+		opts = append(opts, tracer.Tag(ext.NetworkDestinationName, cfg.hostname))
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	opts = append(opts, extra...)
+	span, _ := tracer.StartSpanFromContext(ctx, cfg.spanName, opts...)
+	return span
+}
+
+// finishSpan finishes span, if the request wasn't ignored.
+func finishSpan(span ddtrace.Span, err error) {
+	if span != nil {
+		span.Finish(tracer.WithError(err))
+	}
+}
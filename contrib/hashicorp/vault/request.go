@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Operations reported in the vault.operation tag.
+const (
+	operationRead   = "read"
+	operationList   = "list"
+	operationWrite  = "write"
+	operationPatch  = "patch"
+	operationDelete = "delete"
+	operationLogin  = "login"
+	operationRenew  = "renew"
+)
+
+// maxRenewBodySize is the maximum size of the body of a renewal response
+// read to find the renewed TTL.
+const maxRenewBodySize = 64 * 1024
+
+// request describes a request to the Vault HTTP API, from its path.
+type request struct {
+	// mount is the mount the request is made on. The client doesn't know the
+	// mounts of the server, so it is the first segment of the path, or the
+	// first two for the auth methods, e.g. "secret" or "auth/approle".
+	mount string
+	// operation is the operation performed, e.g. "read" or "login".
+	operation string
+	// authMethod is the auth method used by a login or a renewal, if any.
+	authMethod string
+}
+
+func parseRequest(r *http.Request) request {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/"), "/")
+	segs := strings.Split(path, "/")
+	var req request
+	switch {
+	case segs[0] == "auth" && len(segs) > 1:
+		req.mount = "auth/" + segs[1]
+		req.authMethod = segs[1]
+		switch {
+		case segs[len(segs)-1] == "login" || (len(segs) > 2 && segs[2] == "login"):
+			req.operation = operationLogin
+		case segs[1] == "token" && len(segs) > 2 && strings.HasPrefix(segs[2], "renew"):
+			req.operation = operationRenew
+		}
+	case segs[0] == "sys":
+		req.mount = "sys"
+		if path == "sys/renew" || strings.HasPrefix(path, "sys/renew/") || strings.HasPrefix(path, "sys/leases/renew") {
+			req.operation = operationRenew
+		}
+	default:
+		req.mount = segs[0]
+	}
+	if req.operation == "" {
+		req.operation = methodOperation(r)
+	}
+	return req
+}
+
+// methodOperation returns the operation performed by a request on a path,
+// from its method.
+func methodOperation(r *http.Request) string {
+	switch r.Method {
+	case http.MethodGet:
+		if r.URL.Query().Get("list") == "true" {
+			return operationList
+		}
+		return operationRead
+	case "LIST":
+		return operationList
+	case http.MethodPatch:
+		return operationPatch
+	case http.MethodDelete:
+		return operationDelete
+	default:
+		return operationWrite
+	}
+}
+
+// ttlResponse holds the TTLs found in the body of the responses to the login
+// and renewal requests.
+type ttlResponse struct {
+	LeaseDuration int `json:"lease_duration"`
+	Auth          *struct {
+		LeaseDuration int `json:"lease_duration"`
+	} `json:"auth"`
+}
+
+// readTTL returns the TTL, in seconds, granted by the login or renewal whose
+// response is res, and whether it is the TTL of a token, or else of a lease.
+// The body of res is read, and replaced so that it can be read again.
+func readTTL(res *http.Response) (ttl int, token bool, ok bool) {
+	if res.Body == nil || res.StatusCode != http.StatusOK {
+		return 0, false, false
+	}
+	buf, err := io.ReadAll(io.LimitReader(res.Body, maxRenewBodySize))
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), res.Body), res.Body}
+	if err != nil || len(buf) == maxRenewBodySize {
+		return 0, false, false
+	}
+	var body ttlResponse
+	if err := json.Unmarshal(buf, &body); err != nil {
+		return 0, false, false
+	}
+	if body.Auth != nil {
+		return body.Auth.LeaseDuration, true, true
+	}
+	return body.LeaseDuration, false, true
+}
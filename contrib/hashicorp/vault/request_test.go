@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package vault
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRequest(t *testing.T) {
+	for _, tc := range []struct {
+		method string
+		url    string
+		want   request
+	}{
+		{"GET", "/v1/secret/data/foo", request{mount: "secret", operation: operationRead}},
+		{"GET", "/v1/secret/metadata/?list=true", request{mount: "secret", operation: operationList}},
+		{"LIST", "/v1/secret/metadata/", request{mount: "secret", operation: operationList}},
+		{"PUT", "/v1/secret/data/foo", request{mount: "secret", operation: operationWrite}},
+		{"PATCH", "/v1/secret/data/foo", request{mount: "secret", operation: operationPatch}},
+		{"DELETE", "/v1/secret/data/foo", request{mount: "secret", operation: operationDelete}},
+		{"POST", "/v1/auth/approle/login", request{mount: "auth/approle", operation: operationLogin, authMethod: "approle"}},
+		{"POST", "/v1/auth/userpass/login/jane", request{mount: "auth/userpass", operation: operationLogin, authMethod: "userpass"}},
+		{"POST", "/v1/auth/token/renew-self", request{mount: "auth/token", operation: operationRenew, authMethod: "token"}},
+		{"PUT", "/v1/sys/leases/renew", request{mount: "sys", operation: operationRenew}},
+		{"POST", "/v1/sys/mounts/secret", request{mount: "sys", operation: operationWrite}},
+	} {
+		t.Run(tc.method+" "+tc.url, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, tc.url, nil)
+			assert.Equal(t, tc.want, parseRequest(r))
+		})
+	}
+}
+
+func TestTTL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/approle/login", "/v1/auth/token/renew-self":
+			fmt.Fprintln(w, `{"auth":{"client_token":"token","lease_duration":3600}}`)
+		case "/v1/sys/leases/renew":
+			fmt.Fprintln(w, `{"lease_id":"database/creds/app/1","lease_duration":600}`)
+		default:
+			fmt.Fprintln(w, `{"data":{"key":"value"},"lease_duration":60}`)
+		}
+	}))
+	defer ts.Close()
+
+	for _, tc := range []struct {
+		path     string
+		tokenTTL interface{}
+		leaseTTL interface{}
+	}{
+		{path: "/v1/auth/approle/login", tokenTTL: 3600},
+		{path: "/v1/auth/token/renew-self", tokenTTL: 3600},
+		{path: "/v1/sys/leases/renew", leaseTTL: 600},
+		{path: "/v1/secret/data/foo"},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			c := WrapHTTPClient(&http.Client{})
+			res, err := c.Post(ts.URL+tc.path, "application/json", strings.NewReader("{}"))
+			require.NoError(t, err)
+			// the body can still be read by the client
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Contains(t, string(body), "lease_duration")
+			require.NoError(t, res.Body.Close())
+
+			spans := mt.FinishedSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tc.tokenTTL, spans[0].Tag(tagTokenTTL))
+			assert.Equal(t, tc.leaseTTL, spans[0].Tag(tagLeaseTTL))
+		})
+	}
+}
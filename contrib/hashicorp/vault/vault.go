@@ -14,6 +14,10 @@
 // use the WrapHTTPClient function to wrap the client with the tracer code.
 // Your http.Client will continue to work as before, but will also capture
 // traces.
+//
+// The spans are tagged with the mount the requests are made on, the operation
+// performed, the auth method used by logins, and the TTL granted by logins and
+// renewals of tokens and leases.
 package vault
 
 import (
@@ -32,6 +36,15 @@ import (
 
 const componentName = "hashicorp/vault"
 
+// Tags set on the spans, describing the requests.
+const (
+	tagMountPath  = "vault.mount_path"
+	tagOperation  = "vault.operation"
+	tagAuthMethod = "vault.auth.method"
+	tagTokenTTL   = "vault.token.ttl"
+	tagLeaseTTL   = "vault.lease.ttl"
+)
+
 func init() {
 	telemetry.LoadIntegration(componentName)
 }
@@ -76,6 +89,12 @@ func WrapHTTPClient(c *http.Client, opts ...Option) *http.Client {
 			if ns := r.Header.Get(consts.NamespaceHeaderName); ns != "" {
 				s.SetTag("vault.namespace", ns)
 			}
+			req := parseRequest(r)
+			s.SetTag(tagMountPath, req.mount)
+			s.SetTag(tagOperation, req.operation)
+			if req.authMethod != "" {
+				s.SetTag(tagAuthMethod, req.authMethod)
+			}
 		}),
 		httptrace.WithAfter(func(res *http.Response, s ddtrace.Span) {
 			if res == nil {
@@ -87,6 +106,19 @@ func WrapHTTPClient(c *http.Client, opts ...Option) *http.Client {
 				s.SetTag(ext.Error, true)
 				s.SetTag(ext.ErrorMsg, fmt.Sprintf("%d: %s", res.StatusCode, http.StatusText(res.StatusCode)))
 			}
+			if res.Request == nil {
+				return
+			}
+			if op := parseRequest(res.Request).operation; op != operationLogin && op != operationRenew {
+				return
+			}
+			if ttl, token, ok := readTTL(res); ok {
+				if token {
+					s.SetTag(tagTokenTTL, ttl)
+				} else {
+					s.SetTag(tagLeaseTTL, ttl)
+				}
+			}
 		}),
 	)
 	return c
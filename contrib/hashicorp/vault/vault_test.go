@@ -139,6 +139,8 @@ func testMountReadWrite(c *api.Client, t *testing.T) {
 		assert.Equal("/v1/sys/mounts/ns1/ns2/secret", span.Tag(ext.HTTPURL))
 		assert.Equal(http.MethodPost, span.Tag(ext.HTTPMethod))
 		assert.Equal(http.MethodPost+" /v1/sys/mounts/ns1/ns2/secret", span.Tag(ext.ResourceName))
+		assert.Equal("sys", span.Tag(tagMountPath))
+		assert.Equal(operationWrite, span.Tag(tagOperation))
 		assert.Equal(ext.SpanTypeHTTP, span.Tag(ext.SpanType))
 		assert.Equal(200, span.Tag(ext.HTTPCode))
 		assert.Nil(span.Tag(ext.Error))
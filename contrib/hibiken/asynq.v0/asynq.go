@@ -0,0 +1,146 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package asynq provides functions to trace the hibiken/asynq package (https://github.com/hibiken/asynq).
+//
+// As asynq tasks have no headers, the span context is propagated from the
+// producers to the handlers of tasks in their payloads: tasks created with
+// NewTask whose payload is a JSON object hold it under the "_dd_trace" key.
+package asynq // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/hibiken/asynq.v0"
+
+import (
+	"context"
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/hibiken/asynq"
+)
+
+const componentName = "hibiken/asynq.v0"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+const (
+	tagTaskType   = "asynq.task.type"
+	tagTaskID     = "asynq.task.id"
+	tagQueue      = "asynq.queue"
+	tagRetryCount = "asynq.retry_count"
+	tagMaxRetry   = "asynq.max_retry"
+)
+
+// NewTask calls asynq.NewTask. If payload is a JSON object, the span context
+// of the span found in ctx, if any, is added to it, so that the handling of
+// the task is part of the same trace.
+func NewTask(ctx context.Context, typename string, payload []byte, opts ...asynq.Option) *asynq.Task {
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		payload = injectMetadata(payload, span.Context())
+	}
+	return asynq.NewTask(typename, payload, opts...)
+}
+
+// WrapClient wraps an asynq.Client so that enqueued tasks are traced.
+func WrapClient(c *asynq.Client, opts ...Option) *Client {
+	wrapped := &Client{
+		Client: c,
+		cfg:    newConfig(opts...),
+	}
+	log.Debug("contrib/hibiken/asynq.v0: Wrapping Client: %#v", wrapped.cfg)
+	return wrapped
+}
+
+// A Client wraps an asynq.Client.
+type Client struct {
+	*asynq.Client
+	cfg *config
+}
+
+// Enqueue calls asynq.Client.Enqueue and traces the request.
+func (c *Client) Enqueue(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	return c.EnqueueContext(context.Background(), task, opts...)
+}
+
+// EnqueueContext calls asynq.Client.EnqueueContext and traces the request.
+func (c *Client) EnqueueContext(ctx context.Context, task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	spanOpts := []tracer.StartSpanOption{
+		tracer.ServiceName(c.cfg.producerServiceName),
+		tracer.ResourceName(task.Type()),
+		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindProducer),
+		tracer.Tag(tagTaskType, task.Type()),
+	}
+	if !math.IsNaN(c.cfg.analyticsRate) {
+		spanOpts = append(spanOpts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
+	}
+	span, ctx := tracer.StartSpanFromContext(ctx, c.cfg.producerSpanName, spanOpts...)
+	info, err := c.Client.EnqueueContext(ctx, task, opts...)
+	if err == nil {
+		span.SetTag(tagTaskID, info.ID)
+		span.SetTag(tagQueue, info.Queue)
+		span.SetTag(tagMaxRetry, info.MaxRetry)
+	}
+	span.Finish(tracer.WithError(err))
+	return info, err
+}
+
+// Middleware returns a middleware tracing the handling of tasks, for use with
+// asynq.ServeMux.Use.
+func Middleware(opts ...Option) asynq.MiddlewareFunc {
+	return func(h asynq.Handler) asynq.Handler {
+		return WrapHandler(h, opts...)
+	}
+}
+
+// WrapHandler wraps an asynq.Handler so that the handling of tasks is traced.
+// The span is a child of the span context found in the payload of the task,
+// if any, and is available from the context passed to h.
+func WrapHandler(h asynq.Handler, opts ...Option) asynq.Handler {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/hibiken/asynq.v0: Wrapping Handler: %#v", cfg)
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		span, ctx := startProcessSpan(ctx, cfg, t)
+		err := h.ProcessTask(ctx, t)
+		span.Finish(tracer.WithError(err))
+		return err
+	})
+}
+
+func startProcessSpan(ctx context.Context, cfg *config, t *asynq.Task) (ddtrace.Span, context.Context) {
+	opts := []tracer.StartSpanOption{
+		tracer.ServiceName(cfg.consumerServiceName),
+		tracer.ResourceName(t.Type()),
+		tracer.SpanType(ext.SpanTypeMessageConsumer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindConsumer),
+		tracer.Tag(tagTaskType, t.Type()),
+		tracer.Measured(),
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	if id, ok := asynq.GetTaskID(ctx); ok {
+		opts = append(opts, tracer.Tag(tagTaskID, id))
+	}
+	if queue, ok := asynq.GetQueueName(ctx); ok {
+		opts = append(opts, tracer.Tag(tagQueue, queue))
+	}
+	if n, ok := asynq.GetRetryCount(ctx); ok {
+		opts = append(opts, tracer.Tag(tagRetryCount, n))
+	}
+	if n, ok := asynq.GetMaxRetry(ctx); ok {
+		opts = append(opts, tracer.Tag(tagMaxRetry, n))
+	}
+	if spanctx, err := ExtractSpanContext(t); err == nil {
+		opts = append(opts, tracer.ChildOf(spanctx))
+	}
+	return tracer.StartSpanFromContext(ctx, cfg.consumerSpanName, opts...)
+}
@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package asynq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropagation(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	task := NewTask(ctx, "email:send", []byte(`{"to":"user@example.com"}`))
+	parent.Finish()
+
+	var payload struct {
+		To string `json:"to"`
+	}
+	require.NoError(t, json.Unmarshal(task.Payload(), &payload))
+	assert.Equal(t, "user@example.com", payload.To)
+
+	handlerErr := errors.New("handler failed")
+	h := WrapHandler(asynq.HandlerFunc(func(ctx context.Context, _ *asynq.Task) error {
+		_, ok := tracer.SpanFromContext(ctx)
+		assert.True(t, ok)
+		return handlerErr
+	}), WithServiceName("worker"))
+	assert.Equal(t, handlerErr, h.ProcessTask(context.Background(), task))
+
+	spans := mocktracer.FinishedSpansByOperation(mt, "asynq.process")
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "email:send", s.Tag(ext.ResourceName))
+	assert.Equal(t, "email:send", s.Tag(tagTaskType))
+	assert.Equal(t, "worker", s.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanKindConsumer, s.Tag(ext.SpanKind))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, handlerErr, s.Tag(ext.Error))
+	assert.Equal(t, parent.Context().SpanID(), s.ParentID())
+	assert.Equal(t, parent.Context().TraceID(), s.TraceID())
+}
+
+func TestNoPropagation(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	defer span.Finish()
+	for _, payload := range []string{"", "raw", "[1,2]", "null"} {
+		task := NewTask(ctx, "task", []byte(payload))
+		assert.Equal(t, payload, string(task.Payload()))
+		_, err := ExtractSpanContext(task)
+		assert.Equal(t, tracer.ErrSpanContextNotFound, err)
+	}
+	task := NewTask(context.Background(), "task", []byte(`{}`))
+	assert.Equal(t, `{}`, string(task.Payload()))
+}
+
+func TestMiddleware(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	mux := asynq.NewServeMux()
+	mux.Use(Middleware())
+	mux.HandleFunc("task", func(context.Context, *asynq.Task) error { return nil })
+	require.NoError(t, mux.ProcessTask(context.Background(), asynq.NewTask("task", nil)))
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "asynq.process", spans[0].OperationName())
+	assert.Nil(t, spans[0].Tag(ext.Error))
+	assert.Zero(t, spans[0].ParentID())
+}
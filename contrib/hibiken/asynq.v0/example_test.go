@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package asynq_test
+
+import (
+	"context"
+	"log"
+
+	asynqtrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/hibiken/asynq.v0"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/hibiken/asynq"
+)
+
+func ExampleClient() {
+	c := asynqtrace.WrapClient(asynq.NewClient(asynq.RedisClientOpt{Addr: "localhost:6379"}))
+	defer c.Close()
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "signup")
+	defer span.Finish()
+
+	// the handling of the task is part of the trace of the signup
+	task := asynqtrace.NewTask(ctx, "email:welcome", []byte(`{"user_id":42}`))
+	if _, err := c.EnqueueContext(ctx, task); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func ExampleMiddleware() {
+	srv := asynq.NewServer(asynq.RedisClientOpt{Addr: "localhost:6379"}, asynq.Config{})
+	mux := asynq.NewServeMux()
+	mux.Use(asynqtrace.Middleware(asynqtrace.WithServiceName("email-worker")))
+	mux.HandleFunc("email:welcome", func(ctx context.Context, t *asynq.Task) error {
+		// the span of the handling of the task is found in ctx
+		return nil
+	})
+	if err := srv.Run(mux); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package asynq
+
+import (
+	"encoding/json"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+
+	"github.com/hibiken/asynq"
+)
+
+// metadataKey is the key under which the span context is added to the JSON
+// payloads of tasks, since asynq tasks have no headers. Consumers decoding
+// payloads into structs ignore it.
+const metadataKey = "_dd_trace"
+
+// injectMetadata returns payload with the span context spanctx added under
+// metadataKey, if payload is a JSON object. Otherwise, payload is returned
+// unchanged.
+func injectMetadata(payload []byte, spanctx ddtrace.SpanContext) []byte {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &obj); err != nil || obj == nil {
+		return payload
+	}
+	carrier := tracer.TextMapCarrier{}
+	if err := tracer.Inject(spanctx, carrier); err != nil {
+		log.Debug("contrib/hibiken/asynq.v0: Failed to inject span context into carrier, %v", err)
+		return payload
+	}
+	md, err := json.Marshal(carrier)
+	if err != nil {
+		return payload
+	}
+	obj[metadataKey] = md
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return payload
+	}
+	return out
+}
+
+// ExtractSpanContext retrieves the span context from the payload of t, as
+// added by NewTask.
+func ExtractSpanContext(t *asynq.Task) (ddtrace.SpanContext, error) {
+	var obj struct {
+		Metadata tracer.TextMapCarrier `json:"_dd_trace"`
+	}
+	if err := json.Unmarshal(t.Payload(), &obj); err != nil || obj.Metadata == nil {
+		return nil, tracer.ErrSpanContextNotFound
+	}
+	return tracer.Extract(obj.Metadata)
+}
@@ -0,0 +1,167 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package llmobs holds the span tags and helpers shared by the integrations
+// of LLM clients and frameworks, so that their spans can be processed by LLM
+// Observability.
+package llmobs
+
+import (
+	"encoding/json"
+	"regexp"
+	"unicode/utf8"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// Tags read by LLM Observability.
+const (
+	TagSpanKind         = "_ml_obs.meta.span.kind"
+	TagModelName        = "_ml_obs.meta.model_name"
+	TagModelProvider    = "_ml_obs.meta.model_provider"
+	TagInputMessages    = "_ml_obs.meta.input.messages"
+	TagOutputMessages   = "_ml_obs.meta.output.messages"
+	TagInputValue       = "_ml_obs.meta.input.value"
+	TagOutputValue      = "_ml_obs.meta.output.value"
+	TagInputDocuments   = "_ml_obs.meta.input.documents"
+	TagOutputDocuments  = "_ml_obs.meta.output.documents"
+	TagStopReason       = "_ml_obs.meta.metadata.stop_reason"
+	TagInputTokens      = "_ml_obs.metrics.input_tokens"
+	TagOutputTokens     = "_ml_obs.metrics.output_tokens"
+	TagTotalTokens      = "_ml_obs.metrics.total_tokens"
+	TagTimeToFirstToken = "_ml_obs.metrics.time_to_first_token"
+)
+
+// Kinds of LLM Observability spans, set in the TagSpanKind tag.
+const (
+	SpanKindLLM       = "llm"
+	SpanKindEmbedding = "embedding"
+	SpanKindWorkflow  = "workflow"
+	SpanKindAgent     = "agent"
+	SpanKindTool      = "tool"
+	SpanKindRetrieval = "retrieval"
+	SpanKindTask      = "task"
+)
+
+// MaxContentLength is the maximum length in bytes of a captured content.
+// Longer contents are truncated.
+const MaxContentLength = 4096
+
+const truncatedSuffix = "..."
+
+// Message is a captured message of a conversation with a model.
+type Message struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content"`
+}
+
+// Document is a captured document, such as the input of an embedding.
+type Document struct {
+	Text string `json:"text"`
+}
+
+// Capture configures the capture of the prompts and responses of models,
+// which is disabled by default since they may hold sensitive data.
+type Capture struct {
+	// Enabled reports whether contents are captured.
+	Enabled bool
+	// Redact, if set, returns the value a content is captured as.
+	Redact func(content string) string
+}
+
+// TagMessages tags span with the given messages, if enabled.
+func (c Capture) TagMessages(span ddtrace.Span, tag string, msgs []Message) {
+	if !c.Enabled || len(msgs) == 0 {
+		return
+	}
+	out := make([]Message, len(msgs))
+	for i, m := range msgs {
+		out[i] = Message{Role: m.Role, Content: c.content(m.Content)}
+	}
+	if b, err := json.Marshal(out); err == nil {
+		span.SetTag(tag, string(b))
+	}
+}
+
+// TagDocuments tags span with the given documents, if enabled.
+func (c Capture) TagDocuments(span ddtrace.Span, tag string, texts []string) {
+	if !c.Enabled || len(texts) == 0 {
+		return
+	}
+	docs := make([]Document, len(texts))
+	for i, t := range texts {
+		docs[i] = Document{Text: c.content(t)}
+	}
+	if b, err := json.Marshal(docs); err == nil {
+		span.SetTag(tag, string(b))
+	}
+}
+
+// TagValue tags span with the given value, if enabled.
+func (c Capture) TagValue(span ddtrace.Span, tag, value string) {
+	if !c.Enabled || value == "" {
+		return
+	}
+	span.SetTag(tag, c.content(value))
+}
+
+func (c Capture) content(s string) string {
+	if c.Redact != nil {
+		s = c.Redact(s)
+	}
+	return Truncate(s)
+}
+
+// Truncate returns s truncated to MaxContentLength bytes, without splitting
+// multi-byte characters.
+func Truncate(s string) string {
+	if len(s) <= MaxContentLength {
+		return s
+	}
+	n := MaxContentLength - len(truncatedSuffix)
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + truncatedSuffix
+}
+
+// TagUsage tags span with the given token counts. Negative counts are
+// unknown and not tagged.
+func TagUsage(span ddtrace.Span, input, output, total int64) {
+	if input >= 0 {
+		span.SetTag(TagInputTokens, input)
+	}
+	if output >= 0 {
+		span.SetTag(TagOutputTokens, output)
+	}
+	if total < 0 && input >= 0 && output >= 0 {
+		total = input + output
+	}
+	if total >= 0 {
+		span.SetTag(TagTotalTokens, total)
+	}
+}
+
+var redactPatterns = []*regexp.Regexp{
+	// Email addresses.
+	regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`),
+	// Payment card numbers.
+	regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+	// API keys and bearer tokens.
+	regexp.MustCompile(`\b(?:sk|pk|rk)-[A-Za-z0-9_-]{16,}\b`),
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]+=*`),
+}
+
+// Redacted replaces the redacted parts of the captured contents.
+const Redacted = "<redacted>"
+
+// DefaultRedact replaces the email addresses, payment card numbers and API
+// keys found in content.
+func DefaultRedact(content string) string {
+	for _, re := range redactPatterns {
+		content = re.ReplaceAllString(content, Redacted)
+	}
+	return content
+}
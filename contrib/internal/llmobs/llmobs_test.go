@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package llmobs
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapture(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span := mt.StartSpan("llm")
+	Capture{}.TagMessages(span, TagInputMessages, []Message{{Role: "user", Content: "hi"}})
+	Capture{Enabled: true, Redact: DefaultRedact}.TagMessages(span, TagOutputMessages, []Message{
+		{Role: "assistant", Content: "write to gopher@example.com"},
+	})
+	Capture{Enabled: true}.TagDocuments(span, TagInputDocuments, []string{"a", "b"})
+	span.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Nil(t, spans[0].Tag(TagInputMessages))
+	assert.Equal(t, `[{"role":"assistant","content":"write to <redacted>"}]`, spans[0].Tag(TagOutputMessages))
+	assert.Equal(t, `[{"text":"a"},{"text":"b"}]`, spans[0].Tag(TagInputDocuments))
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", Truncate("short"))
+	s := Truncate(strings.Repeat("é", MaxContentLength))
+	assert.LessOrEqual(t, len(s), MaxContentLength)
+	assert.True(t, strings.HasSuffix(s, truncatedSuffix))
+	assert.True(t, strings.HasPrefix(s, "é"))
+}
+
+func TestDefaultRedact(t *testing.T) {
+	for in, out := range map[string]string{
+		"contact gopher@example.com now":       "contact <redacted> now",
+		"card 4111 1111 1111 1111 expires":     "card <redacted> expires",
+		"key sk-abcdefghijklmnopqrstuvwxyz":    "key <redacted>",
+		"Authorization: Bearer abc.def-ghi":    "Authorization: <redacted>",
+		"nothing to see in 2024, call at 9:30": "nothing to see in 2024, call at 9:30",
+	} {
+		assert.Equal(t, out, DefaultRedact(in), in)
+	}
+}
+
+func TestTagUsage(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span := mt.StartSpan("llm")
+	TagUsage(span, 10, 5, -1)
+	span.Finish()
+
+	s := mt.FinishedSpans()[0]
+	assert.Equal(t, int64(10), s.Tag(TagInputTokens))
+	assert.Equal(t, int64(5), s.Tag(TagOutputTokens))
+	assert.Equal(t, int64(15), s.Tag(TagTotalTokens))
+}
@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package llmobs
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// EventStream wraps the body of a response made of server-sent events. It
+// calls OnData with the data of every event, and OnEnd once, when the body is
+// read to the end, fails to be read or is closed.
+type EventStream struct {
+	io.ReadCloser
+	OnData func(data []byte)
+	OnEnd  func(err error)
+
+	// line holds the incomplete line of the last read.
+	line []byte
+	once sync.Once
+}
+
+// Read implements io.Reader.
+func (s *EventStream) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	if n > 0 {
+		s.consume(p[:n])
+	}
+	if err == io.EOF {
+		s.end(nil)
+	} else if err != nil {
+		s.end(err)
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (s *EventStream) Close() error {
+	err := s.ReadCloser.Close()
+	s.end(nil)
+	return err
+}
+
+func (s *EventStream) consume(p []byte) {
+	s.line = append(s.line, p...)
+	for {
+		i := bytes.IndexByte(s.line, '\n')
+		if i < 0 {
+			return
+		}
+		line := bytes.TrimRight(s.line[:i], "\r")
+		s.line = s.line[i+1:]
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		data := bytes.TrimSpace(line[len("data:"):])
+		if len(data) == 0 || bytes.Equal(data, []byte("[DONE]")) {
+			continue
+		}
+		if s.OnData != nil {
+			s.OnData(data)
+		}
+	}
+}
+
+func (s *EventStream) end(err error) {
+	s.once.Do(func() {
+		if s.OnEnd != nil {
+			s.OnEnd(err)
+		}
+	})
+}
+
+// StreamText accumulates the text of a streamed response and tags Span with
+// the time to its first token.
+type StreamText struct {
+	Span  ddtrace.Span
+	Start time.Time
+	// Capture reports whether the text is kept, to be captured once the
+	// stream ends.
+	Capture bool
+
+	text       strings.Builder
+	firstToken bool
+}
+
+// Write appends the given text, received from the stream.
+func (t *StreamText) Write(text string) {
+	if text == "" {
+		return
+	}
+	if !t.firstToken {
+		t.firstToken = true
+		t.Span.SetTag(TagTimeToFirstToken, time.Since(t.Start).Seconds())
+	}
+	if t.Capture && t.text.Len() <= MaxContentLength {
+		t.text.WriteString(text)
+	}
+}
+
+// String returns the accumulated text.
+func (t *StreamText) String() string {
+	return t.text.String()
+}
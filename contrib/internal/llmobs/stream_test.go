@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package llmobs
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventStream(t *testing.T) {
+	body := "event: a\ndata: {\"n\":1}\r\n\r\n: comment\ndata: {\"n\":2}\n\ndata: [DONE]\n\n"
+	var (
+		data []string
+		ends int
+	)
+	s := &EventStream{
+		// Events are split across reads.
+		ReadCloser: io.NopCloser(iotest.OneByteReader(strings.NewReader(body))),
+		OnData:     func(b []byte) { data = append(data, string(b)) },
+		OnEnd:      func(err error) { assert.NoError(t, err); ends++ },
+	}
+	b, err := io.ReadAll(s)
+	assert.NoError(t, err)
+	assert.Equal(t, body, string(b))
+	assert.NoError(t, s.Close())
+	assert.Equal(t, []string{`{"n":1}`, `{"n":2}`}, data)
+	assert.Equal(t, 1, ends)
+}
+
+func TestStreamText(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span := mt.StartSpan("llm")
+	text := StreamText{Span: span, Start: time.Now(), Capture: true}
+	text.Write("")
+	text.Write("Hello")
+	text.Write(" there")
+	span.Finish()
+
+	assert.Equal(t, "Hello there", text.String())
+	assert.IsType(t, float64(0), mt.FinishedSpans()[0].Tag(TagTimeToFirstToken))
+}
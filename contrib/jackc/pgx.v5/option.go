@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package pgx
+
+import (
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+)
+
+const defaultServiceName = "postgres.db"
+
+type config struct {
+	serviceName   string
+	analyticsRate float64
+	traceQuery    bool
+	traceBatch    bool
+	traceCopyFrom bool
+	tracePrepare  bool
+	traceConnect  bool
+}
+
+// Option represents an option that can be used to customize the tracing of
+// the connections.
+type Option func(*config)
+
+func defaults(cfg *config) {
+	cfg.serviceName = namingschema.NewDefaultServiceName(
+		defaultServiceName,
+		namingschema.WithOverrideV0(defaultServiceName),
+	).GetName()
+	if internal.BoolEnv("DD_TRACE_PGX_ANALYTICS_ENABLED", false) {
+		cfg.analyticsRate = 1.0
+	} else {
+		cfg.analyticsRate = math.NaN()
+	}
+	cfg.traceQuery = true
+	cfg.traceBatch = true
+	cfg.traceCopyFrom = true
+	cfg.tracePrepare = true
+	cfg.traceConnect = true
+}
+
+// WithServiceName sets the given service name for the connections.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithTraceQuery enables or disables the tracing of the queries, such as
+// Exec, Query and QueryRow. It is enabled by default.
+func WithTraceQuery(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.traceQuery = enabled
+	}
+}
+
+// WithTraceBatch enables or disables the tracing of SendBatch, which creates
+// a span for the batch and one per query of the batch. It is enabled by
+// default.
+func WithTraceBatch(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.traceBatch = enabled
+	}
+}
+
+// WithTraceCopyFrom enables or disables the tracing of CopyFrom. It is
+// enabled by default.
+func WithTraceCopyFrom(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.traceCopyFrom = enabled
+	}
+}
+
+// WithTracePrepare enables or disables the tracing of Prepare. It is enabled
+// by default.
+func WithTracePrepare(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.tracePrepare = enabled
+	}
+}
+
+// WithTraceConnect enables or disables the tracing of the establishment of
+// the connections, including the ones opened by a pool when a connection is
+// acquired. It is enabled by default.
+func WithTraceConnect(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.traceConnect = enabled
+	}
+}
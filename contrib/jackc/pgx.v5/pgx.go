@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package pgx provides functions to trace the jackc/pgx/v5 package (https://github.com/jackc/pgx),
+// using its native tracer hooks. Queries, batches, copies, prepared statements
+// and connections are traced, without going through database/sql.
+package pgx // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/jackc/pgx.v5"
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const componentName = "jackc/pgx.v5"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Connect is like pgx.Connect, but the returned connection is traced.
+func Connect(ctx context.Context, connString string, opts ...Option) (*pgx.Conn, error) {
+	connConfig, err := pgx.ParseConfig(connString)
+	if err != nil {
+		return nil, err
+	}
+	return ConnectConfig(ctx, connConfig, opts...)
+}
+
+// ConnectConfig is like pgx.ConnectConfig, but the returned connection is
+// traced. Any tracer already set on connConfig is replaced.
+func ConnectConfig(ctx context.Context, connConfig *pgx.ConnConfig, opts ...Option) (*pgx.Conn, error) {
+	connConfig.Tracer = newPgxTracer(opts...)
+	log.Debug("contrib/jackc/pgx.v5: Connecting to %s", connConfig.Host)
+	return pgx.ConnectConfig(ctx, connConfig)
+}
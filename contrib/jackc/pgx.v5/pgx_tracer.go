@@ -0,0 +1,224 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package pgx
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// queryType is the type of the traced operation, set as the "sql.query_type"
+// tag, like in the database/sql integration.
+type queryType string
+
+const (
+	queryTypeQuery      queryType = "Query"
+	queryTypeBatch      queryType = "Batch"
+	queryTypeBatchQuery queryType = "BatchQuery"
+	queryTypeCopyFrom   queryType = "CopyFrom"
+	queryTypePrepare    queryType = "Prepare"
+	queryTypeConnect    queryType = "Connect"
+)
+
+// Span names of the traced operations.
+const (
+	spanNameQuery      = "pgx.query"
+	spanNameBatch      = "pgx.batch"
+	spanNameBatchQuery = "pgx.batch.query"
+	spanNameCopyFrom   = "pgx.copy_from"
+	spanNamePrepare    = "pgx.prepare"
+	spanNameConnect    = "pgx.connect"
+)
+
+// pgxTracer implements the tracer interfaces of pgx, and traces the operations
+// of the connections it is set on.
+type pgxTracer struct {
+	cfg *config
+}
+
+var (
+	_ pgx.QueryTracer    = (*pgxTracer)(nil)
+	_ pgx.BatchTracer    = (*pgxTracer)(nil)
+	_ pgx.CopyFromTracer = (*pgxTracer)(nil)
+	_ pgx.PrepareTracer  = (*pgxTracer)(nil)
+	_ pgx.ConnectTracer  = (*pgxTracer)(nil)
+)
+
+func newPgxTracer(opts ...Option) *pgxTracer {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return &pgxTracer{cfg: cfg}
+}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (t *pgxTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if !t.cfg.traceQuery {
+		return ctx
+	}
+	opts := t.spanOptions(conn.Config(), queryTypeQuery, data.SQL)
+	_, ctx = tracer.StartSpanFromContext(ctx, spanNameQuery, opts...)
+	return ctx
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (t *pgxTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	if !t.cfg.traceQuery {
+		return
+	}
+	finishSpan(ctx, data.CommandTag, data.Err)
+}
+
+// TraceBatchStart implements pgx.BatchTracer.
+func (t *pgxTracer) TraceBatchStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	if !t.cfg.traceBatch {
+		return ctx
+	}
+	opts := t.spanOptions(conn.Config(), queryTypeBatch, "")
+	if data.Batch != nil {
+		opts = append(opts, tracer.Tag("db.batch.num_queries", data.Batch.Len()))
+	}
+	_, ctx = tracer.StartSpanFromContext(ctx, spanNameBatch, opts...)
+	return ctx
+}
+
+// TraceBatchQuery implements pgx.BatchTracer. The queries of a batch are only
+// known once they have run, so their spans are created when they end.
+func (t *pgxTracer) TraceBatchQuery(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchQueryData) {
+	if !t.cfg.traceBatch {
+		return
+	}
+	opts := t.spanOptions(conn.Config(), queryTypeBatchQuery, data.SQL)
+	span, _ := tracer.StartSpanFromContext(ctx, spanNameBatchQuery, opts...)
+	setCommandTag(span, data.CommandTag)
+	span.Finish(tracer.WithError(data.Err))
+}
+
+// TraceBatchEnd implements pgx.BatchTracer.
+func (t *pgxTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	if !t.cfg.traceBatch {
+		return
+	}
+	finishSpan(ctx, pgconn.CommandTag{}, data.Err)
+}
+
+// TraceCopyFromStart implements pgx.CopyFromTracer.
+func (t *pgxTracer) TraceCopyFromStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	if !t.cfg.traceCopyFrom {
+		return ctx
+	}
+	table := data.TableName.Sanitize()
+	opts := t.spanOptions(conn.Config(), queryTypeCopyFrom, "COPY FROM "+table)
+	opts = append(opts, tracer.Tag("db.copy_from.table", table))
+	_, ctx = tracer.StartSpanFromContext(ctx, spanNameCopyFrom, opts...)
+	return ctx
+}
+
+// TraceCopyFromEnd implements pgx.CopyFromTracer.
+func (t *pgxTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	if !t.cfg.traceCopyFrom {
+		return
+	}
+	finishSpan(ctx, data.CommandTag, data.Err)
+}
+
+// TracePrepareStart implements pgx.PrepareTracer.
+func (t *pgxTracer) TracePrepareStart(ctx context.Context, conn *pgx.Conn, data pgx.TracePrepareStartData) context.Context {
+	if !t.cfg.tracePrepare {
+		return ctx
+	}
+	opts := t.spanOptions(conn.Config(), queryTypePrepare, data.SQL)
+	if data.Name != "" {
+		opts = append(opts, tracer.Tag("db.prepared_statement.name", data.Name))
+	}
+	_, ctx = tracer.StartSpanFromContext(ctx, spanNamePrepare, opts...)
+	return ctx
+}
+
+// TracePrepareEnd implements pgx.PrepareTracer.
+func (t *pgxTracer) TracePrepareEnd(ctx context.Context, _ *pgx.Conn, data pgx.TracePrepareEndData) {
+	if !t.cfg.tracePrepare {
+		return
+	}
+	finishSpan(ctx, pgconn.CommandTag{}, data.Err)
+}
+
+// TraceConnectStart implements pgx.ConnectTracer.
+func (t *pgxTracer) TraceConnectStart(ctx context.Context, data pgx.TraceConnectStartData) context.Context {
+	if !t.cfg.traceConnect {
+		return ctx
+	}
+	opts := t.spanOptions(data.ConnConfig, queryTypeConnect, "")
+	_, ctx = tracer.StartSpanFromContext(ctx, spanNameConnect, opts...)
+	return ctx
+}
+
+// TraceConnectEnd implements pgx.ConnectTracer.
+func (t *pgxTracer) TraceConnectEnd(ctx context.Context, data pgx.TraceConnectEndData) {
+	if !t.cfg.traceConnect {
+		return
+	}
+	finishSpan(ctx, pgconn.CommandTag{}, data.Err)
+}
+
+// spanOptions returns the options of the span of an operation of type qtype
+// on a connection configured with connConfig. The resource of the span is
+// resource, or qtype if it is empty.
+func (t *pgxTracer) spanOptions(connConfig *pgx.ConnConfig, qtype queryType, resource string) []ddtrace.StartSpanOption {
+	if resource == "" {
+		resource = string(qtype)
+	}
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(t.cfg.serviceName),
+		tracer.SpanType(ext.SpanTypeSQL),
+		tracer.ResourceName(resource),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		tracer.Tag(ext.DBSystem, ext.DBSystemPostgreSQL),
+		tracer.Tag("sql.query_type", string(qtype)),
+	}
+	if !math.IsNaN(t.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, t.cfg.analyticsRate))
+	}
+	if connConfig != nil {
+		opts = append(opts,
+			tracer.Tag(ext.TargetHost, connConfig.Host),
+			tracer.Tag(ext.TargetPort, strconv.Itoa(int(connConfig.Port))),
+			tracer.Tag(ext.DBName, connConfig.Database),
+			tracer.Tag(ext.DBUser, connConfig.User),
+		)
+	}
+	return opts
+}
+
+// finishSpan finishes the span found in ctx, if any.
+func finishSpan(ctx context.Context, tag pgconn.CommandTag, err error) {
+	span, ok := tracer.SpanFromContext(ctx)
+	if !ok {
+		return
+	}
+	setCommandTag(span, tag)
+	span.Finish(tracer.WithError(err))
+}
+
+// setCommandTag sets the number of rows affected by the operation, as reported
+// by its command tag, on span.
+func setCommandTag(span ddtrace.Span, tag pgconn.CommandTag) {
+	if tag.String() == "" {
+		return
+	}
+	span.SetTag("db.result.rows_affected", tag.RowsAffected())
+}
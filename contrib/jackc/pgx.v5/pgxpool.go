@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package pgx
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NewPool is like pgxpool.New, but the connections of the returned pool are
+// traced, including their establishment when they are acquired.
+func NewPool(ctx context.Context, connString string, opts ...Option) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, err
+	}
+	return NewPoolWithConfig(ctx, config, opts...)
+}
+
+// NewPoolWithConfig is like pgxpool.NewWithConfig, but the connections of the
+// returned pool are traced. Any tracer already set on config.ConnConfig is
+// replaced.
+func NewPoolWithConfig(ctx context.Context, config *pgxpool.Config, opts ...Option) (*pgxpool.Pool, error) {
+	config.ConnConfig.Tracer = newPgxTracer(opts...)
+	log.Debug("contrib/jackc/pgx.v5: Creating pool for %s", config.ConnConfig.Host)
+	return pgxpool.NewWithConfig(ctx, config)
+}
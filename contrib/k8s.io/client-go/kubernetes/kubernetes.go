@@ -47,7 +47,26 @@ func WrapRoundTripper(rt http.RoundTripper) http.RoundTripper {
 
 func wrapRoundTripperWithOptions(rt http.RoundTripper, opts ...httptrace.RoundTripperOption) http.RoundTripper {
 	opts = append(opts, httptrace.WithBefore(func(req *http.Request, span ddtrace.Span) {
-		span.SetTag(ext.ResourceName, RequestToResource(req.Method, req.URL.Path))
+		if r, ok := parseAPIRequest(req); ok {
+			span.SetTag(ext.ResourceName, r.resourceName())
+			span.SetTag(tagVerb, r.verb)
+			if r.group != "" {
+				span.SetTag(tagAPIGroup, r.group)
+			}
+			span.SetTag(tagAPIVersion, r.version)
+			span.SetTag(tagResource, r.resource)
+			if r.subresource != "" {
+				span.SetTag(tagSubresource, r.subresource)
+			}
+			if r.namespace != "" {
+				span.SetTag(tagNamespace, r.namespace)
+			}
+			if r.name != "" {
+				span.SetTag(tagName, r.name)
+			}
+		} else {
+			span.SetTag(ext.ResourceName, RequestToResource(req.Method, req.URL.Path))
+		}
 		span.SetTag(ext.Component, componentName)
 		span.SetTag(ext.SpanKind, ext.SpanKindClient)
 		traceID := span.Context().TraceID()
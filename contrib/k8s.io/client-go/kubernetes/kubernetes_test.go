@@ -81,7 +81,10 @@ func TestKubernetes(t *testing.T) {
 	{
 		span := spans[0]
 		assert.Equal(t, "http.request", span.OperationName())
-		assert.Equal(t, "GET namespaces", span.Tag(ext.ResourceName))
+		assert.Equal(t, "list v1/namespaces", span.Tag(ext.ResourceName))
+		assert.Equal(t, "list", span.Tag(tagVerb))
+		assert.Equal(t, "v1", span.Tag(tagAPIVersion))
+		assert.Equal(t, "namespaces", span.Tag(tagResource))
 		assert.Equal(t, "200", span.Tag(ext.HTTPCode))
 		assert.Equal(t, "GET", span.Tag(ext.HTTPMethod))
 		assert.Equal(t, s.URL+"/api/v1/namespaces", span.Tag(ext.HTTPURL))
@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package kubernetes
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Tags describing the requests made to the Kubernetes API.
+const (
+	tagVerb        = "kubernetes.verb"
+	tagAPIGroup    = "kubernetes.api_group"
+	tagAPIVersion  = "kubernetes.api_version"
+	tagResource    = "kubernetes.resource"
+	tagSubresource = "kubernetes.subresource"
+	tagNamespace   = "kubernetes.namespace"
+	tagName        = "kubernetes.name"
+)
+
+// apiRequest describes a request made on a resource of the Kubernetes API,
+// the way the API server does.
+// See https://kubernetes.io/docs/reference/using-api/api-concepts/#resource-uris.
+type apiRequest struct {
+	verb        string
+	group       string
+	version     string
+	resource    string
+	subresource string
+	namespace   string
+	name        string
+}
+
+// parseAPIRequest parses the path of req. It returns false if req is not made
+// on a resource, such as discovery or health requests.
+func parseAPIRequest(req *http.Request) (apiRequest, bool) {
+	var (
+		r     apiRequest
+		parts []string
+	)
+	switch path := strings.Trim(req.URL.Path, "/"); {
+	case strings.HasPrefix(path, "api/"):
+		// api/{version}/...
+		parts = strings.Split(strings.TrimPrefix(path, "api/"), "/")
+		r.version, parts = parts[0], parts[1:]
+	case strings.HasPrefix(path, "apis/"):
+		// apis/{group}/{version}/...
+		parts = strings.Split(strings.TrimPrefix(path, "apis/"), "/")
+		if len(parts) < 2 {
+			return r, false
+		}
+		r.group, r.version, parts = parts[0], parts[1], parts[2:]
+	default:
+		return r, false
+	}
+	watch := false
+	if len(parts) > 0 && parts[0] == "watch" {
+		// deprecated watch/ prefix
+		watch, parts = true, parts[1:]
+	}
+	if len(parts) > 2 && parts[0] == "namespaces" && parts[2] != "status" && parts[2] != "finalize" {
+		// namespaces/{namespace}/{resource}, but not the subresources of
+		// the namespaces themselves.
+		r.namespace, parts = parts[1], parts[2:]
+	}
+	if len(parts) == 0 || parts[0] == "" {
+		return r, false
+	}
+	r.resource = parts[0]
+	if len(parts) > 1 {
+		r.name = parts[1]
+	}
+	if len(parts) > 2 {
+		r.subresource = parts[2]
+	}
+	if r.resource == "namespaces" {
+		r.namespace = r.name
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		switch {
+		case watch || req.URL.Query().Get("watch") == "true" || req.URL.Query().Get("watch") == "1":
+			r.verb = "watch"
+		case r.name == "":
+			r.verb = "list"
+		default:
+			r.verb = "get"
+		}
+	case http.MethodPost:
+		r.verb = "create"
+	case http.MethodPut:
+		r.verb = "update"
+	case http.MethodPatch:
+		r.verb = "patch"
+	case http.MethodDelete:
+		if r.name == "" {
+			r.verb = "deletecollection"
+		} else {
+			r.verb = "delete"
+		}
+	default:
+		r.verb = strings.ToLower(req.Method)
+	}
+	return r, true
+}
+
+// resourceName returns the resource name of the span of the request, in the
+// form "{verb} {group}/{version}/{resource}[/{subresource}]", e.g.
+// "list apps/v1/deployments" or "get v1/pods/log". The namespace and the name
+// of the resource are set as tags instead, to keep the number of resource
+// names low.
+func (r *apiRequest) resourceName() string {
+	var out strings.Builder
+	out.WriteString(r.verb)
+	out.WriteByte(' ')
+	if r.group != "" {
+		out.WriteString(r.group)
+		out.WriteByte('/')
+	}
+	out.WriteString(r.version)
+	out.WriteByte('/')
+	out.WriteString(r.resource)
+	if r.subresource != "" {
+		out.WriteByte('/')
+		out.WriteString(r.subresource)
+	}
+	return out.String()
+}
+
+// IsLeaderElectionRequest reports whether req is a request made on a Lease, as
+// done by the leader election of client-go and the heartbeats of the nodes.
+// These requests are frequent and rarely of interest, and can be excluded from
+// the traces with the RTWithIgnoreRequest option of the net/http integration:
+//
+//	cfg.WrapTransport = kubernetes.WrapRoundTripperFunc(httptrace.RTWithIgnoreRequest(kubernetes.IsLeaderElectionRequest))
+func IsLeaderElectionRequest(req *http.Request) bool {
+	r, ok := parseAPIRequest(req)
+	return ok && r.group == "coordination.k8s.io" && r.resource == "leases"
+}
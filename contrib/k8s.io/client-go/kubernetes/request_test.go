@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package kubernetes
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAPIRequest(t *testing.T) {
+	for _, tc := range []struct {
+		method   string
+		url      string
+		want     apiRequest
+		resource string
+	}{
+		{
+			method:   "GET",
+			url:      "/api/v1/namespaces",
+			want:     apiRequest{verb: "list", version: "v1", resource: "namespaces"},
+			resource: "list v1/namespaces",
+		},
+		{
+			method:   "GET",
+			url:      "/api/v1/namespaces/default",
+			want:     apiRequest{verb: "get", version: "v1", resource: "namespaces", name: "default", namespace: "default"},
+			resource: "get v1/namespaces",
+		},
+		{
+			method:   "PUT",
+			url:      "/api/v1/namespaces/default/finalize",
+			want:     apiRequest{verb: "update", version: "v1", resource: "namespaces", subresource: "finalize", name: "default", namespace: "default"},
+			resource: "update v1/namespaces/finalize",
+		},
+		{
+			method:   "GET",
+			url:      "/api/v1/namespaces/default/pods/web-0/log",
+			want:     apiRequest{verb: "get", version: "v1", resource: "pods", subresource: "log", namespace: "default", name: "web-0"},
+			resource: "get v1/pods/log",
+		},
+		{
+			method:   "GET",
+			url:      "/apis/apps/v1/namespaces/default/deployments?watch=true",
+			want:     apiRequest{verb: "watch", group: "apps", version: "v1", resource: "deployments", namespace: "default"},
+			resource: "watch apps/v1/deployments",
+		},
+		{
+			method:   "GET",
+			url:      "/api/v1/watch/pods",
+			want:     apiRequest{verb: "watch", version: "v1", resource: "pods"},
+			resource: "watch v1/pods",
+		},
+		{
+			method:   "POST",
+			url:      "/apis/batch/v1/namespaces/jobs/jobs",
+			want:     apiRequest{verb: "create", group: "batch", version: "v1", resource: "jobs", namespace: "jobs"},
+			resource: "create batch/v1/jobs",
+		},
+		{
+			method:   "PATCH",
+			url:      "/api/v1/nodes/node-1/status",
+			want:     apiRequest{verb: "patch", version: "v1", resource: "nodes", subresource: "status", name: "node-1"},
+			resource: "patch v1/nodes/status",
+		},
+		{
+			method:   "DELETE",
+			url:      "/api/v1/namespaces/default/pods",
+			want:     apiRequest{verb: "deletecollection", version: "v1", resource: "pods", namespace: "default"},
+			resource: "deletecollection v1/pods",
+		},
+		{
+			method:   "DELETE",
+			url:      "/api/v1/namespaces/default/pods/web-0",
+			want:     apiRequest{verb: "delete", version: "v1", resource: "pods", namespace: "default", name: "web-0"},
+			resource: "delete v1/pods",
+		},
+	} {
+		t.Run(tc.method+" "+tc.url, func(t *testing.T) {
+			r, ok := parseAPIRequest(httptest.NewRequest(tc.method, tc.url, nil))
+			assert.True(t, ok)
+			assert.Equal(t, tc.want, r)
+			assert.Equal(t, tc.resource, r.resourceName())
+		})
+	}
+
+	for _, url := range []string{"/healthz", "/version", "/api", "/api/v1", "/apis/apps"} {
+		_, ok := parseAPIRequest(httptest.NewRequest("GET", url, nil))
+		assert.False(t, ok, url)
+	}
+}
+
+func TestIsLeaderElectionRequest(t *testing.T) {
+	assert.True(t, IsLeaderElectionRequest(httptest.NewRequest("PUT", "/apis/coordination.k8s.io/v1/namespaces/kube-system/leases/my-controller", nil)))
+	assert.False(t, IsLeaderElectionRequest(httptest.NewRequest("GET", "/api/v1/namespaces/kube-system/configmaps/my-controller", nil)))
+}
@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package echo
+
+import (
+	"net/http"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/httpsec"
+
+	"github.com/labstack/echo/v5"
+)
+
+func withAppSec(next echo.HandlerFunc, span tracer.Span) echo.HandlerFunc {
+	return func(c *echo.Context) error {
+		params := make(map[string]string)
+		for _, p := range c.PathValues() {
+			params[p.Name] = p.Value
+		}
+		var err error
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c.SetRequest(r)
+			err = next(c)
+			// If the error is a monitoring one, it means appsec actions will take care of writing the response
+			// and handling the error. Otherwise, call the echo error handler now so that the status code of
+			// the response is monitored. It does not respond again once the error is returned.
+			if _, ok := err.(*httpsec.MonitoringError); !ok && err != nil {
+				c.Echo().HTTPErrorHandler(c, err)
+			}
+		})
+		// Wrap the echo response to allow monitoring of the response status code in httpsec.WrapHandler()
+		httpsec.WrapHandler(handler, span, params).ServeHTTP(&statusResponseWriter{ResponseWriter: c.Response()}, c.Request())
+		return err
+	}
+}
+
+// statusResponseWriter wraps an echo response to allow tracking/retrieving its status code through a Status() method
+// without having to rely on the echo error handlers
+type statusResponseWriter struct {
+	http.ResponseWriter
+}
+
+// Status returns the status code of the response
+func (w *statusResponseWriter) Status() int {
+	if resp, err := echo.UnwrapResponse(w.ResponseWriter); err == nil {
+		return resp.Status
+	}
+	return 0
+}
+
+// Unwrap returns the echo response.
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package echo provides functions to trace the labstack/echo package (https://github.com/labstack/echo).
+package echo // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/labstack/echo.v5"
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/httptrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/labstack/echo/v5"
+)
+
+const componentName = "labstack/echo.v5"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Middleware returns echo middleware which will trace incoming requests.
+func Middleware(opts ...Option) echo.MiddlewareFunc {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/labstack/echo.v5: Configuring Middleware: %#v", cfg)
+	spanOpts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(cfg.serviceName),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			// If we have an ignoreRequestFunc, use it to see if we proceed with tracing
+			if cfg.ignoreRequestFunc != nil && cfg.ignoreRequestFunc(c) {
+				return next(c)
+			}
+
+			request := c.Request()
+			route := c.Path()
+			resource := request.Method + " " + route
+			opts := append(spanOpts, tracer.ResourceName(resource), tracer.Tag(ext.HTTPRoute, route))
+
+			if !math.IsNaN(cfg.analyticsRate) {
+				opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+			}
+			opts = append(opts, httptrace.HeaderTagsFromRequest(request, cfg.headerTags))
+			var finishOpts []tracer.FinishOption
+			if cfg.noDebugStack {
+				finishOpts = []tracer.FinishOption{tracer.NoDebugStack()}
+			}
+
+			span, ctx := httptrace.StartRequestSpan(request, opts...)
+			defer func() {
+				span.Finish(finishOpts...)
+			}()
+
+			// pass the span through the request context
+			c.SetRequest(request.WithContext(ctx))
+
+			next := next // avoid modifying the value of next in the outer closure scope
+			if appsec.Enabled() {
+				next = withAppSec(next, span)
+			}
+			// serve the request to the next middleware
+			err := next(c)
+
+			// Echo v5 invokes the HTTP error handler once the error is returned
+			// by the middleware chain. The status code it responds with is
+			// resolved the same way, unless the error handler is customized.
+			_, status := echo.ResolveResponseStatus(c.Response(), err)
+			if cfg.isStatusError(status) {
+				if err != nil {
+					finishOpts = append(finishOpts, tracer.WithError(err))
+				} else {
+					finishOpts = append(finishOpts, tracer.WithError(fmt.Errorf("%d: %s", status, http.StatusText(status))))
+				}
+			}
+			span.SetTag(ext.HTTPCode, strconv.Itoa(status))
+			return err
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package nats_test
+
+import (
+	"context"
+	"log"
+
+	natstrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/nats-io/nats.go"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/nats-io/nats.go"
+)
+
+func Example() {
+	nc, err := nats.Connect(nats.DefaultURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	conn := natstrace.WrapConn(nc, natstrace.WithServiceName("orders"))
+	defer conn.Close()
+
+	// The handling of every message is traced, as a child of the span of
+	// its publication.
+	conn.Subscribe("orders.*", func(m *nats.Msg) {
+		spanctx, _ := natstrace.ExtractSpanContext(m)
+		span := tracer.StartSpan("process.order", tracer.ChildOf(spanctx))
+		defer span.Finish()
+	})
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "create.order")
+	defer span.Finish()
+	conn.PublishWithContext(ctx, "orders.created", []byte("order"))
+}
+
+func ExampleConn_JetStream() {
+	nc, err := nats.Connect(nats.DefaultURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	js, err := natstrace.WrapConn(nc).JetStream()
+	if err != nil {
+		log.Fatal(err)
+	}
+	sub, err := js.PullSubscribe("orders.>", "worker")
+	if err != nil {
+		log.Fatal(err)
+	}
+	msgs, err := js.Fetch(sub, 10)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, m := range msgs {
+		m.Ack()
+	}
+}
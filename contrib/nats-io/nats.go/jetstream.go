@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package nats
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+
+	"github.com/nats-io/nats.go"
+)
+
+// JetStream returns a JetStream context of the connection, traced with the
+// options of the connection.
+func (c *Conn) JetStream(opts ...nats.JSOpt) (*JetStream, error) {
+	js, err := c.Conn.JetStream(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &JetStream{JetStreamContext: js, cfg: c.cfg}, nil
+}
+
+// WrapJetStream wraps a nats.JetStreamContext so that published messages and
+// the messages delivered to subscriptions are traced.
+func WrapJetStream(js nats.JetStreamContext, opts ...Option) *JetStream {
+	wrapped := &JetStream{
+		JetStreamContext: js,
+		cfg:              newConfig(opts...),
+	}
+	log.Debug("contrib/nats-io/nats.go: Wrapping JetStreamContext: %#v", wrapped.cfg)
+	return wrapped
+}
+
+// A JetStream wraps a nats.JetStreamContext.
+type JetStream struct {
+	nats.JetStreamContext
+	cfg *config
+}
+
+// Publish calls nats.JetStreamContext.Publish and traces it.
+func (js *JetStream) Publish(subj string, data []byte, opts ...nats.PubOpt) (*nats.PubAck, error) {
+	return js.PublishMsgWithContext(context.Background(), &nats.Msg{Subject: subj, Data: data}, opts...)
+}
+
+// PublishMsg calls nats.JetStreamContext.PublishMsg and traces it.
+func (js *JetStream) PublishMsg(m *nats.Msg, opts ...nats.PubOpt) (*nats.PubAck, error) {
+	return js.PublishMsgWithContext(context.Background(), m, opts...)
+}
+
+// PublishMsgWithContext is like PublishMsg, but the span is a child of the
+// span found in ctx, if any. The span context is injected into the headers of
+// m, and the span is tagged with the stream and the sequence number of the
+// message acknowledged by the server.
+func (js *JetStream) PublishMsgWithContext(ctx context.Context, m *nats.Msg, opts ...nats.PubOpt) (*nats.PubAck, error) {
+	span := startProduceSpan(ctx, js.cfg, js.cfg.producerSpanName, "Publish", ext.SpanKindProducer, m)
+	injectSpanContext(span, m)
+	ack, err := js.JetStreamContext.PublishMsg(m, opts...)
+	if ack != nil {
+		span.SetTag(ext.NATSStream, ack.Stream)
+		span.SetTag(ext.NATSSequence, ack.Sequence)
+	}
+	span.Finish(tracer.WithError(err))
+	return ack, err
+}
+
+// Subscribe calls nats.JetStreamContext.Subscribe, tracing the handling of
+// each message delivered to cb.
+func (js *JetStream) Subscribe(subj string, cb nats.MsgHandler, opts ...nats.SubOpt) (*nats.Subscription, error) {
+	return js.JetStreamContext.Subscribe(subj, traceHandler(js.cfg, cb), opts...)
+}
+
+// QueueSubscribe calls nats.JetStreamContext.QueueSubscribe, tracing the
+// handling of each message delivered to cb.
+func (js *JetStream) QueueSubscribe(subj, queue string, cb nats.MsgHandler, opts ...nats.SubOpt) (*nats.Subscription, error) {
+	return js.JetStreamContext.QueueSubscribe(subj, queue, traceHandler(js.cfg, cb), opts...)
+}
+
+// Fetch calls sub.Fetch, sub being a pull subscription, and traces the
+// consumption of every fetched message. The span context of the consumption
+// is injected into the headers of each message, so that it can be retrieved
+// using ExtractSpanContext.
+func (js *JetStream) Fetch(sub *nats.Subscription, batch int, opts ...nats.PullOpt) ([]*nats.Msg, error) {
+	msgs, err := sub.Fetch(batch, opts...)
+	for _, m := range msgs {
+		startConsumeSpan(js.cfg, m).Finish()
+	}
+	return msgs, err
+}
@@ -0,0 +1,203 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package nats provides functions to trace the nats-io/nats.go package (https://github.com/nats-io/nats.go).
+//
+// The published messages and requests are traced, and their span context is
+// propagated in the headers of the messages, when the server supports them.
+// The messages delivered to the handlers of the subscriptions are traced as
+// well, including the ones of JetStream.
+package nats // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/nats-io/nats.go"
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/nats-io/nats.go"
+)
+
+const componentName = "nats-io/nats.go"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// WrapConn wraps a nats.Conn so that published messages, requests and the
+// messages delivered to subscriptions are traced.
+func WrapConn(nc *nats.Conn, opts ...Option) *Conn {
+	c := &Conn{
+		Conn: nc,
+		cfg:  newConfig(opts...),
+	}
+	log.Debug("contrib/nats-io/nats.go: Wrapping Conn: %#v", c.cfg)
+	return c
+}
+
+// A Conn wraps a nats.Conn.
+type Conn struct {
+	*nats.Conn
+	cfg *config
+}
+
+// Publish calls nats.Conn.Publish and traces it.
+func (c *Conn) Publish(subj string, data []byte) error {
+	return c.PublishMsgWithContext(context.Background(), &nats.Msg{Subject: subj, Data: data})
+}
+
+// PublishWithContext is like Publish, but the span is a child of the span
+// found in ctx, if any.
+func (c *Conn) PublishWithContext(ctx context.Context, subj string, data []byte) error {
+	return c.PublishMsgWithContext(ctx, &nats.Msg{Subject: subj, Data: data})
+}
+
+// PublishMsg calls nats.Conn.PublishMsg and traces it.
+func (c *Conn) PublishMsg(m *nats.Msg) error {
+	return c.PublishMsgWithContext(context.Background(), m)
+}
+
+// PublishMsgWithContext is like PublishMsg, but the span is a child of the
+// span found in ctx, if any. The span context is injected into the headers
+// of m.
+func (c *Conn) PublishMsgWithContext(ctx context.Context, m *nats.Msg) error {
+	span := c.startSpan(ctx, c.cfg.producerSpanName, "Publish", ext.SpanKindProducer, m)
+	err := c.Conn.PublishMsg(m)
+	span.Finish(tracer.WithError(err))
+	return err
+}
+
+// Request calls nats.Conn.Request and traces it.
+func (c *Conn) Request(subj string, data []byte, timeout time.Duration) (*nats.Msg, error) {
+	m := &nats.Msg{Subject: subj, Data: data}
+	span := c.startSpan(context.Background(), c.cfg.requestSpanName, "Request", ext.SpanKindClient, m)
+	resp, err := c.Conn.RequestMsg(m, timeout)
+	span.Finish(tracer.WithError(err))
+	return resp, err
+}
+
+// RequestWithContext calls nats.Conn.RequestWithContext and traces it.
+func (c *Conn) RequestWithContext(ctx context.Context, subj string, data []byte) (*nats.Msg, error) {
+	return c.RequestMsgWithContext(ctx, &nats.Msg{Subject: subj, Data: data})
+}
+
+// RequestMsgWithContext calls nats.Conn.RequestMsgWithContext and traces it.
+// The span context is injected into the headers of m.
+func (c *Conn) RequestMsgWithContext(ctx context.Context, m *nats.Msg) (*nats.Msg, error) {
+	span := c.startSpan(ctx, c.cfg.requestSpanName, "Request", ext.SpanKindClient, m)
+	resp, err := c.Conn.RequestMsgWithContext(ctx, m)
+	span.Finish(tracer.WithError(err))
+	return resp, err
+}
+
+// Subscribe calls nats.Conn.Subscribe, tracing the handling of each message
+// delivered to cb.
+func (c *Conn) Subscribe(subj string, cb nats.MsgHandler) (*nats.Subscription, error) {
+	return c.Conn.Subscribe(subj, traceHandler(c.cfg, cb))
+}
+
+// QueueSubscribe calls nats.Conn.QueueSubscribe, tracing the handling of each
+// message delivered to cb.
+func (c *Conn) QueueSubscribe(subj, queue string, cb nats.MsgHandler) (*nats.Subscription, error) {
+	return c.Conn.QueueSubscribe(subj, queue, traceHandler(c.cfg, cb))
+}
+
+// startSpan starts a span for sending m, and injects its context into the
+// headers of m if they are supported by the server.
+func (c *Conn) startSpan(ctx context.Context, spanName, operation, kind string, m *nats.Msg) ddtrace.Span {
+	span := startProduceSpan(ctx, c.cfg, spanName, operation, kind, m)
+	if c.Conn.HeadersSupported() {
+		injectSpanContext(span, m)
+	}
+	return span
+}
+
+// startProduceSpan starts a span for sending m, as a child of the span found
+// in ctx, if any.
+func startProduceSpan(ctx context.Context, cfg *config, spanName, operation, kind string, m *nats.Msg) ddtrace.Span {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(cfg.producerServiceName),
+		tracer.ResourceName(operation + " " + m.Subject),
+		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, kind),
+		tracer.Tag(ext.MessagingSystem, ext.MessagingSystemNATS),
+		tracer.Tag(ext.NATSSubject, m.Subject),
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	span, _ := tracer.StartSpanFromContext(ctx, spanName, opts...)
+	return span
+}
+
+func injectSpanContext(span ddtrace.Span, m *nats.Msg) {
+	if m.Header == nil {
+		m.Header = nats.Header{}
+	}
+	if err := tracer.Inject(span.Context(), headerCarrier(m.Header)); err != nil {
+		log.Debug("contrib/nats-io/nats.go: Failed to inject span context into carrier, %v", err)
+	}
+}
+
+// traceHandler returns a handler tracing the handling of the messages by cb.
+// The span context of the consumption is injected into the headers of the
+// messages, so that it can be retrieved by cb using ExtractSpanContext.
+func traceHandler(cfg *config, cb nats.MsgHandler) nats.MsgHandler {
+	return func(m *nats.Msg) {
+		span := startConsumeSpan(cfg, m)
+		defer span.Finish()
+		cb(m)
+	}
+}
+
+// startConsumeSpan starts the span of the consumption of m, as a child of the
+// span context found in its headers, if any.
+func startConsumeSpan(cfg *config, m *nats.Msg) ddtrace.Span {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(cfg.consumerServiceName),
+		tracer.ResourceName("Consume " + subscriptionSubject(m)),
+		tracer.SpanType(ext.SpanTypeMessageConsumer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindConsumer),
+		tracer.Tag(ext.MessagingSystem, ext.MessagingSystemNATS),
+		tracer.Tag(ext.NATSSubject, m.Subject),
+		tracer.Measured(),
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	if md, err := m.Metadata(); err == nil {
+		// the message was delivered by JetStream
+		opts = append(opts,
+			tracer.Tag(ext.NATSStream, md.Stream),
+			tracer.Tag(ext.NATSConsumer, md.Consumer),
+			tracer.Tag(ext.NATSSequence, md.Sequence.Stream),
+		)
+	}
+	if m.Header != nil {
+		if spanctx, err := tracer.Extract(headerCarrier(m.Header)); err == nil {
+			opts = append(opts, tracer.ChildOf(spanctx))
+		}
+	}
+	span := tracer.StartSpan(cfg.consumerSpanName, opts...)
+	injectSpanContext(span, m)
+	return span
+}
+
+// subscriptionSubject returns the subject of the subscription m was delivered
+// to, which may hold wildcards, so that the resource names don't depend on
+// the subjects of the messages.
+func subscriptionSubject(m *nats.Msg) string {
+	if m.Sub != nil && m.Sub.Subject != "" {
+		return m.Sub.Subject
+	}
+	return m.Subject
+}
@@ -0,0 +1,147 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package nats
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	_, ok := os.LookupEnv("INTEGRATION")
+	if !ok {
+		fmt.Println("--- SKIP: to enable integration test, set the INTEGRATION environment variable")
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func connect(t *testing.T, opts ...Option) *Conn {
+	nc, err := nats.Connect(nats.DefaultURL)
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+	return WrapConn(nc, opts...)
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	nc := connect(t, WithServiceName("my-nats"))
+	received := make(chan *nats.Msg, 1)
+	sub, err := nc.Subscribe("orders.*", func(m *nats.Msg) {
+		received <- m
+	})
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	require.NoError(t, nc.PublishWithContext(ctx, "orders.created", []byte("hello")))
+	parent.Finish()
+
+	var m *nats.Msg
+	select {
+	case m = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not received")
+	}
+	// the handler has returned once the span is finished
+	require.Eventually(t, func() bool {
+		return len(mocktracer.FinishedSpansByOperation(mt, "nats.consume")) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	produce := mocktracer.FinishedSpansByOperation(mt, "nats.publish")
+	require.Len(t, produce, 1)
+	assert.Equal(t, "Publish orders.created", produce[0].Tag(ext.ResourceName))
+	assert.Equal(t, "my-nats", produce[0].Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanKindProducer, produce[0].Tag(ext.SpanKind))
+	assert.Equal(t, ext.MessagingSystemNATS, produce[0].Tag(ext.MessagingSystem))
+	assert.Equal(t, "orders.created", produce[0].Tag(ext.NATSSubject))
+	assert.Equal(t, componentName, produce[0].Tag(ext.Component))
+	assert.Equal(t, parent.Context().SpanID(), produce[0].ParentID())
+
+	consume := mocktracer.FinishedSpansByOperation(mt, "nats.consume")[0]
+	assert.Equal(t, "Consume orders.*", consume.Tag(ext.ResourceName))
+	assert.Equal(t, ext.SpanKindConsumer, consume.Tag(ext.SpanKind))
+	assert.Equal(t, "orders.created", consume.Tag(ext.NATSSubject))
+	assert.Equal(t, produce[0].SpanID(), consume.ParentID())
+	assert.Equal(t, produce[0].TraceID(), consume.TraceID())
+
+	spanctx, err := ExtractSpanContext(m)
+	require.NoError(t, err)
+	assert.Equal(t, consume.SpanID(), spanctx.SpanID())
+}
+
+func TestRequest(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	nc := connect(t)
+	sub, err := nc.Subscribe("echo", func(m *nats.Msg) {
+		m.Respond(m.Data)
+	})
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	resp, err := nc.RequestWithContext(context.Background(), "echo", []byte("ping"))
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(resp.Data))
+
+	requests := mocktracer.FinishedSpansByOperation(mt, "nats.request")
+	require.Len(t, requests, 1)
+	assert.Equal(t, "Request echo", requests[0].Tag(ext.ResourceName))
+	assert.Equal(t, ext.SpanKindClient, requests[0].Tag(ext.SpanKind))
+	require.Eventually(t, func() bool {
+		return len(mocktracer.FinishedSpansByOperation(mt, "nats.consume")) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, requests[0].SpanID(), mocktracer.FinishedSpansByOperation(mt, "nats.consume")[0].ParentID())
+}
+
+func TestJetStream(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	nc := connect(t)
+	js, err := nc.JetStream()
+	require.NoError(t, err)
+	_, err = js.AddStream(&nats.StreamConfig{Name: "ORDERS", Subjects: []string{"js.orders.>"}})
+	require.NoError(t, err)
+	defer js.DeleteStream("ORDERS")
+
+	ack, err := js.Publish("js.orders.created", []byte("hello"))
+	require.NoError(t, err)
+
+	sub, err := js.PullSubscribe("js.orders.>", "worker")
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	msgs, err := js.Fetch(sub, 1)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	require.NoError(t, msgs[0].Ack())
+
+	produce := mocktracer.FinishedSpansByOperation(mt, "nats.publish")
+	require.Len(t, produce, 1)
+	assert.Equal(t, "ORDERS", produce[0].Tag(ext.NATSStream))
+	assert.Equal(t, ack.Sequence, produce[0].Tag(ext.NATSSequence))
+
+	consume := mocktracer.FinishedSpansByOperation(mt, "nats.consume")
+	require.Len(t, consume, 1)
+	assert.Equal(t, "ORDERS", consume[0].Tag(ext.NATSStream))
+	assert.Equal(t, "worker", consume[0].Tag(ext.NATSConsumer))
+	assert.Equal(t, ack.Sequence, consume[0].Tag(ext.NATSSequence))
+	assert.Equal(t, produce[0].SpanID(), consume[0].ParentID())
+}
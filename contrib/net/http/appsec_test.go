@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package http
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/httpsec"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closeRecorder is a request body recording whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed int32
+}
+
+func (b *closeRecorder) Close() error {
+	atomic.StoreInt32(&b.closed, 1)
+	return nil
+}
+
+// newRASPServer returns a traced server whose /fetch endpoint sends a request to the URL given by its url query
+// parameter, using a traced client. The endpoint returns early when the outgoing request is blocked by AppSec. The
+// bodies of the outgoing requests are sent to bodies.
+func newRASPServer(bodies chan<- *closeRecorder) *httptest.Server {
+	client := WrapClient(&http.Client{})
+	mux := NewServeMux()
+	mux.HandleFunc("/fetch", func(w http.ResponseWriter, r *http.Request) {
+		body := &closeRecorder{Reader: strings.NewReader("payload")}
+		bodies <- body
+		req, err := http.NewRequestWithContext(r.Context(), "POST", r.URL.Query().Get("url"), body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		res, err := client.Do(req)
+		var blocked *httpsec.MonitoringError
+		if errors.As(err, &blocked) {
+			return
+		}
+		if err == nil {
+			res.Body.Close()
+		}
+		w.Write([]byte("Hello World!\n"))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestAppSecRASP(t *testing.T) {
+	t.Setenv("DD_APPSEC_RULES", "../../../internal/appsec/testdata/rasp.json")
+	appsec.Start()
+	defer appsec.Stop()
+	if !appsec.Enabled() {
+		t.Skip("AppSec needs to be enabled for this test")
+	}
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer target.Close()
+	bodies := make(chan *closeRecorder, 1)
+	srv := newRASPServer(bodies)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name   string
+		url    string
+		status int
+	}{
+		{name: "block/metadata", url: "http://169.254.169.254/latest/meta-data/", status: 403},
+		{name: "block/scheme", url: "file:///etc/passwd", status: 403},
+		{name: "no-block", url: target.URL + "/?next=http://169.254.169.254/", status: 200},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			res, err := srv.Client().Get(srv.URL + "/fetch?url=" + url.QueryEscape(tc.url))
+			require.NoError(t, err)
+			defer res.Body.Close()
+			b, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.Equal(t, tc.status, res.StatusCode)
+
+			body := <-bodies
+			var client, server mocktracer.Span
+			for _, s := range mt.FinishedSpans() {
+				if s.Tag(ext.SpanKind) == ext.SpanKindClient {
+					client = s
+				} else {
+					server = s
+				}
+			}
+			require.NotNil(t, client)
+			require.NotNil(t, server)
+			if tc.status == 403 {
+				require.NotContains(t, string(b), "Hello World!\n")
+				require.Equal(t, int32(1), atomic.LoadInt32(&body.closed))
+				require.NotNil(t, client.Tag(ext.Error))
+				require.Contains(t, server.Tag("_dd.appsec.json"), "rasp-934-100")
+			} else {
+				require.Equal(t, "Hello World!\n", string(b))
+				require.Nil(t, client.Tag(ext.Error))
+				require.Nil(t, server.Tag("_dd.appsec.json"))
+			}
+		})
+	}
+}
+
+func TestAppSecRASPDisabled(t *testing.T) {
+	t.Setenv("DD_APPSEC_RULES", "../../../internal/appsec/testdata/rasp.json")
+	t.Setenv("DD_APPSEC_RASP_ENABLED", "false")
+	appsec.Start()
+	defer appsec.Stop()
+	if !appsec.Enabled() {
+		t.Skip("AppSec needs to be enabled for this test")
+	}
+
+	bodies := make(chan *closeRecorder, 1)
+	srv := newRASPServer(bodies)
+	defer srv.Close()
+
+	// The request is not blocked and fails because of its unsupported scheme instead.
+	res, err := srv.Client().Get(srv.URL + "/fetch?url=" + url.QueryEscape("file:///etc/passwd"))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	<-bodies
+	b, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Equal(t, 200, res.StatusCode)
+	require.Equal(t, "Hello World!\n", string(b))
+}
+
+func TestAppSecHijacked(t *testing.T) {
+	appsec.Start()
+	defer appsec.Stop()
+	if !appsec.Enabled() {
+		t.Skip("AppSec needs to be enabled for this test")
+	}
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	done := make(chan struct{})
+	var finished []mocktracer.Span
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		finished = mt.FinishedSpans()
+		buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		buf.Flush()
+	})
+	srv := httptest.NewServer(WrapHandler(h, "service", "resource"))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade\r\nUpgrade: websocket\r\nUser-Agent: dd-test-scanner-log\r\n\r\n"))
+	require.NoError(t, err)
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	<-done
+
+	// the span is finished once AppSec has tagged it
+	require.Empty(t, finished)
+	require.Eventually(t, func() bool { return len(mt.FinishedSpans()) == 1 }, time.Second, time.Millisecond)
+	span := mt.FinishedSpans()[0]
+	require.Equal(t, true, span.Tag(tagUpgraded))
+	require.Equal(t, "101", span.Tag(ext.HTTPCode))
+	require.Contains(t, span.Tag("_dd.appsec.json"), "ua0-600-55x")
+}
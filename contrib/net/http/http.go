@@ -48,17 +48,19 @@ func (mux *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// get the resource associated to this request
-	_, route := mux.Handler(r)
+	_, pattern := mux.Handler(r)
+	route := patternRoute(pattern)
 	resource := mux.cfg.resourceNamer(r)
 	if resource == "" {
 		resource = r.Method + " " + route
 	}
 	mux.cfg.spanOpts = append(mux.cfg.spanOpts, httptrace.HeaderTagsFromRequest(r, mux.cfg.headerTags))
 	TraceAndServe(mux.ServeMux, w, r, &ServeConfig{
-		Service:  mux.cfg.serviceName,
-		Resource: resource,
-		SpanOpts: mux.cfg.spanOpts,
-		Route:    route,
+		Service:        mux.cfg.serviceName,
+		Resource:       resource,
+		SpanOpts:       mux.cfg.spanOpts,
+		Route:          route,
+		ConnectionSpan: mux.cfg.connSpans,
 	})
 }
 
@@ -83,10 +85,11 @@ func WrapHandler(h http.Handler, service, resource string, opts ...Option) http.
 		}
 
 		TraceAndServe(h, w, req, &ServeConfig{
-			Service:    service,
-			Resource:   resource,
-			FinishOpts: cfg.finishOpts,
-			SpanOpts:   cfg.spanOpts,
+			Service:        service,
+			Resource:       resource,
+			FinishOpts:     cfg.finishOpts,
+			SpanOpts:       cfg.spanOpts,
+			ConnectionSpan: cfg.connSpans,
 		})
 	})
 }
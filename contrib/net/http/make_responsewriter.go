@@ -58,6 +58,10 @@ func wrapResponseWriter(w http.ResponseWriter) (http.ResponseWriter, *responseWr
 {{- end }}
 
 	mw := newResponseWriter(w)
+	if okHijacker {
+		// notify mw of hijacked connections
+		hHijacker = &hijacker{Hijacker: hHijacker, rw: mw}
+	}
 	type monitoredResponseWriter interface {
 		http.ResponseWriter
 		Status() int
@@ -8,6 +8,7 @@ package http
 import (
 	"math"
 	"net/http"
+	"strings"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
@@ -28,6 +29,7 @@ type config struct {
 	ignoreRequest func(*http.Request) bool
 	resourceNamer func(*http.Request) string
 	headerTags    *internal.LockMap
+	connSpans     bool
 }
 
 // MuxOption has been deprecated in favor of Option.
@@ -118,6 +120,19 @@ func WithResourceNamer(namer func(req *http.Request) string) Option {
 	}
 }
 
+// WithConnectionSpans enables the tracing of connections hijacked by handlers,
+// such as WebSocket connections, with a span lasting until the connection is
+// closed. Request spans finish when their connection is hijacked regardless of
+// this option, unless AppSec is enabled, in which case they finish once the
+// handler returns.
+// Hijacked connections are wrapped to be traced, so handlers can't use type
+// assertions to access their underlying type, e.g. *net.TCPConn.
+func WithConnectionSpans(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.connSpans = enabled
+	}
+}
+
 // NoDebugStack prevents stack traces from being attached to spans finishing
 // with an error. This is useful in situations where errors are frequent and
 // performance is critical.
@@ -146,6 +161,22 @@ type roundTripperConfig struct {
 	spanOpts      []ddtrace.StartSpanOption
 	propagation   bool
 	errCheck      func(err error) bool
+
+	requestHeaderTags  *internal.LockMap
+	responseHeaderTags *internal.LockMap
+	requestBody        bodyCapture
+	responseBody       bodyCapture
+}
+
+// A BodyRedactor returns the value a captured HTTP body is tagged with,
+// allowing sensitive data to be removed from it. The header is the one of
+// the request or response the body belongs to.
+type BodyRedactor func(header http.Header, body []byte) []byte
+
+// bodyCapture configures the capture of HTTP bodies as span tags.
+type bodyCapture struct {
+	maxSize int
+	redact  BodyRedactor
 }
 
 func newRoundTripperConfig() *roundTripperConfig {
@@ -267,3 +298,67 @@ func RTWithErrorCheck(fn func(err error) bool) RoundTripperOption {
 		cfg.errCheck = fn
 	}
 }
+
+// RTWithRequestHeaderTags enables the integration to attach the headers of
+// outgoing HTTP requests as span tags. Headers are given in the same format
+// as for WithHeaderTags.
+// Warning:
+// Using this feature can risk exposing sensitive data such as authorization tokens to Datadog.
+func RTWithRequestHeaderTags(headers []string) RoundTripperOption {
+	headerTagsMap := normalizer.HeaderTagSlice(headers)
+	return func(cfg *roundTripperConfig) {
+		cfg.requestHeaderTags = internal.NewLockMap(headerTagsMap)
+	}
+}
+
+// RTWithResponseHeaderTags enables the integration to attach the headers of
+// received HTTP responses as span tags. Headers are given in the same format
+// as for WithHeaderTags, and are tagged as http.response.headers.<header>
+// unless a tag name is specified.
+// Warning:
+// Using this feature can risk exposing sensitive data such as session cookies to Datadog.
+func RTWithResponseHeaderTags(headers []string) RoundTripperOption {
+	headerTagsMap := make(map[string]string)
+	for _, h := range headers {
+		if strings.HasPrefix(h, "x-datadog-") {
+			continue
+		}
+		header, tag := normalizer.HeaderTag(h)
+		if !strings.Contains(h, ":") {
+			tag = ext.HTTPResponseHeaders + strings.TrimPrefix(tag, ext.HTTPRequestHeaders)
+		}
+		headerTagsMap[header] = tag
+	}
+	return func(cfg *roundTripperConfig) {
+		cfg.responseHeaderTags = internal.NewLockMap(headerTagsMap)
+	}
+}
+
+// RTWithRequestBodyCapture enables the integration to attach up to maxSize
+// bytes of the body of outgoing HTTP requests as the http.request.body span
+// tag. If redact is not nil, the span is tagged with its result instead of
+// the captured body. Capture is disabled when maxSize is not positive. Only
+// bodies which can be replayed through http.Request.GetBody are captured, so
+// that streaming request bodies are left untouched.
+// Warning:
+// Using this feature can risk exposing sensitive data to Datadog.
+func RTWithRequestBodyCapture(maxSize int, redact BodyRedactor) RoundTripperOption {
+	return func(cfg *roundTripperConfig) {
+		cfg.requestBody = bodyCapture{maxSize: maxSize, redact: redact}
+	}
+}
+
+// RTWithResponseBodyCapture enables the integration to attach up to maxSize
+// bytes of the body of received HTTP responses as the http.response.body span
+// tag. If redact is not nil, the span is tagged with its result instead of
+// the captured body. Capture is disabled when maxSize is not positive.
+// As the captured bytes are read before RoundTrip returns, this option should
+// not be used with streaming responses. The bodies of 101 Switching Protocols
+// responses are never captured.
+// Warning:
+// Using this feature can risk exposing sensitive data to Datadog.
+func RTWithResponseBodyCapture(maxSize int, redact BodyRedactor) RoundTripperOption {
+	return func(cfg *roundTripperConfig) {
+		cfg.responseBody = bodyCapture{maxSize: maxSize, redact: redact}
+	}
+}
@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+//go:build go1.23
+// +build go1.23
+
+//go:debug httpmuxgo121=0
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestPattern(t *testing.T) {
+	newMux := func() *http.ServeMux {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /users/{id}", handler200)
+		mux.HandleFunc("/static/", handler200)
+		return mux
+	}
+
+	for name, tt := range map[string]struct {
+		target   string
+		resource string
+		route    string
+	}{
+		"method":   {target: "/users/123", resource: "GET /users/{id}", route: "/users/{id}"},
+		"path":     {target: "/static/app.js", resource: "GET /static/", route: "/static/"},
+		"notfound": {target: "/unknown", resource: "http.request"},
+	} {
+		t.Run("WrapHandler/"+name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			h := WrapHandler(newMux(), "service", "")
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.target, nil))
+
+			spans := mt.FinishedSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.resource, spans[0].Tag(ext.ResourceName))
+			if tt.route == "" {
+				assert.NotContains(t, spans[0].Tags(), ext.HTTPRoute)
+			} else {
+				assert.Equal(t, tt.route, spans[0].Tag(ext.HTTPRoute))
+			}
+		})
+	}
+
+	t.Run("WrapHandler/resource", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		h := WrapHandler(newMux(), "service", "resource")
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/123", nil))
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "resource", spans[0].Tag(ext.ResourceName))
+		assert.Equal(t, "/users/{id}", spans[0].Tag(ext.HTTPRoute))
+	})
+
+	t.Run("WrapHandler/appsec", func(t *testing.T) {
+		appsec.Start()
+		defer appsec.Stop()
+		if !appsec.Enabled() {
+			t.Skip("AppSec needs to be enabled for this test")
+		}
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		// The AppSec handler serves a copy of the request to the mux.
+		h := WrapHandler(newMux(), "service", "")
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/123", nil))
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "GET /users/{id}", spans[0].Tag(ext.ResourceName))
+		assert.Equal(t, "/users/{id}", spans[0].Tag(ext.HTTPRoute))
+	})
+
+	t.Run("ServeMux", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		mux := NewServeMux()
+		mux.HandleFunc("GET /users/{id}", handler200)
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/123", nil))
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "GET /users/{id}", spans[0].Tag(ext.ResourceName))
+		assert.Equal(t, "/users/{id}", spans[0].Tag(ext.HTTPRoute))
+	})
+}
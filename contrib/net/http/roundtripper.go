@@ -6,15 +6,26 @@
 package http
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"math"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/httptrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/httpsec"
+)
+
+const (
+	tagRequestBody  = "http.request.body"
+	tagResponseBody = "http.response.body"
 )
 
 type roundTripper struct {
@@ -49,6 +60,9 @@ func (rt *roundTripper) RoundTrip(req *http.Request) (res *http.Response, err er
 	if port, err := strconv.Atoi(url.Port()); err == nil {
 		opts = append(opts, tracer.Tag(ext.NetworkDestinationPort, port))
 	}
+	if rt.cfg.requestHeaderTags != nil {
+		opts = append(opts, httptrace.HeaderTagsFromRequest(req, rt.cfg.requestHeaderTags))
+	}
 	if len(rt.cfg.spanOpts) > 0 {
 		opts = append(opts, rt.cfg.spanOpts...)
 	}
@@ -67,6 +81,15 @@ func (rt *roundTripper) RoundTrip(req *http.Request) (res *http.Response, err er
 		rt.cfg.before(req, span)
 	}
 	r2 := req.Clone(ctx)
+	if rt.cfg.requestBody.maxSize > 0 && r2.Body != nil && r2.Body != http.NoBody && r2.GetBody != nil && r2.ContentLength >= 0 {
+		// Only replayable bodies are captured, from a copy, so that streaming
+		// request bodies are neither buffered nor consumed.
+		if body, err := r2.GetBody(); err == nil {
+			captured, _ := peekBody(body, rt.cfg.requestBody.maxSize)
+			body.Close()
+			span.SetTag(tagRequestBody, rt.cfg.requestBody.value(r2.Header, captured))
+		}
+	}
 	if rt.cfg.propagation {
 		// inject the span context into the http request copy
 		err = tracer.Inject(span.Context(), tracer.HTTPHeadersCarrier(r2.Header))
@@ -75,6 +98,16 @@ func (rt *roundTripper) RoundTrip(req *http.Request) (res *http.Response, err er
 			fmt.Fprintf(os.Stderr, "contrib/net/http.Roundtrip: failed to inject http headers: %v\n", err)
 		}
 	}
+	if appsec.Enabled() {
+		// Block the outgoing request when AppSec detects a server-side request forgery.
+		if err = httpsec.ProtectRoundTrip(ctx, url.String()); err != nil {
+			// RoundTrip must always close the request body, even on errors.
+			if r2.Body != nil {
+				r2.Body.Close()
+			}
+			return nil, err
+		}
+	}
 	res, err = rt.base.RoundTrip(r2)
 	if err != nil {
 		span.SetTag("http.errors", err.Error())
@@ -88,10 +121,57 @@ func (rt *roundTripper) RoundTrip(req *http.Request) (res *http.Response, err er
 			span.SetTag("http.errors", res.Status)
 			span.SetTag(ext.Error, fmt.Errorf("%d: %s", res.StatusCode, http.StatusText(res.StatusCode)))
 		}
+		if rt.cfg.responseHeaderTags != nil {
+			rt.cfg.responseHeaderTags.Iter(func(header, tag string) {
+				if vs, ok := res.Header[header]; ok {
+					span.SetTag(tag, strings.TrimSpace(strings.Join(vs, ",")))
+				}
+			})
+		}
+		// The body of a 101 response is the upgraded connection, which must not be read.
+		if rt.cfg.responseBody.maxSize > 0 && res.StatusCode != http.StatusSwitchingProtocols && res.Body != nil && res.Body != http.NoBody {
+			var body []byte
+			body, res.Body = peekBody(res.Body, rt.cfg.responseBody.maxSize)
+			span.SetTag(tagResponseBody, rt.cfg.responseBody.value(res.Header, body))
+		}
 	}
 	return res, err
 }
 
+// value returns the span tag value of the given captured body.
+func (c bodyCapture) value(header http.Header, body []byte) string {
+	if c.redact != nil {
+		body = c.redact(header, body)
+	}
+	return string(body)
+}
+
+// peekBody reads up to n bytes from body. It returns them along with a body
+// which yields these bytes first, and then the rest of the original body.
+func peekBody(body io.ReadCloser, n int) ([]byte, io.ReadCloser) {
+	buf := make([]byte, n)
+	read, err := io.ReadFull(body, buf)
+	buf = buf[:read]
+	rest := io.Reader(body)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		// surface the read error to the caller once the peeked bytes are consumed
+		rest = errReader{err}
+	}
+	return buf, readCloser{
+		Reader: io.MultiReader(bytes.NewReader(buf), rest),
+		Closer: body,
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
 // Unwrap returns the original http.RoundTripper.
 func (rt *roundTripper) Unwrap() http.RoundTripper {
 	return rt.base
@@ -7,7 +7,9 @@ package http
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -552,6 +554,197 @@ func TestSpanOptions(t *testing.T) {
 	assert.Equal(t, tagValue, spans[0].Tag(tagKey))
 }
 
+func TestRoundTripperHeaderTags(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Response-Id", "res-1")
+		w.Header().Set("Set-Cookie", "secret")
+		w.Write([]byte("Hello World"))
+	}))
+	defer s.Close()
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	rt := WrapRoundTripper(http.DefaultTransport,
+		RTWithRequestHeaderTags([]string{"x-request-id", "x-custom:custom.request.tag"}),
+		RTWithResponseHeaderTags([]string{"x-response-id"}),
+	)
+	client := &http.Client{Transport: rt}
+
+	req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Request-Id", "req-1")
+	req.Header.Set("X-Custom", "custom")
+	req.Header.Set("Authorization", "secret")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	tags := spans[0].Tags()
+	assert.Equal(t, "req-1", tags["http.request.headers.x-request-id"])
+	assert.Equal(t, "custom", tags["custom.request.tag"])
+	assert.Equal(t, "res-1", tags["http.response.headers.x-response-id"])
+	assert.NotContains(t, tags, "http.request.headers.authorization")
+	assert.NotContains(t, tags, "http.response.headers.set-cookie")
+}
+
+func TestRoundTripperBodyCapture(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "request body", string(body))
+		w.Write([]byte("response body"))
+	}))
+	defer s.Close()
+
+	t.Run("disabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		client := &http.Client{Transport: WrapRoundTripper(http.DefaultTransport)}
+
+		resp, err := client.Post(s.URL, "text/plain", strings.NewReader("request body"))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.NotContains(t, spans[0].Tags(), tagRequestBody)
+		assert.NotContains(t, spans[0].Tags(), tagResponseBody)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		client := &http.Client{Transport: WrapRoundTripper(http.DefaultTransport,
+			RTWithRequestBodyCapture(7, nil),
+			RTWithResponseBodyCapture(1024, nil),
+		)}
+
+		resp, err := client.Post(s.URL, "text/plain", strings.NewReader("request body"))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "response body", string(body))
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "request", spans[0].Tag(tagRequestBody))
+		assert.Equal(t, "response body", spans[0].Tag(tagResponseBody))
+	})
+
+	t.Run("redacted", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		redact := func(header http.Header, body []byte) []byte {
+			if header.Get("Content-Type") == "text/plain" {
+				return []byte("<redacted>")
+			}
+			return body
+		}
+		client := &http.Client{Transport: WrapRoundTripper(http.DefaultTransport,
+			RTWithRequestBodyCapture(1024, redact),
+			RTWithResponseBodyCapture(8, redact),
+		)}
+
+		resp, err := client.Post(s.URL, "text/plain", strings.NewReader("request body"))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "response body", string(body))
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "<redacted>", spans[0].Tag(tagRequestBody))
+		assert.Equal(t, "response", spans[0].Tag(tagResponseBody))
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		client := &http.Client{Transport: WrapRoundTripper(http.DefaultTransport,
+			RTWithRequestBodyCapture(1024, nil),
+		)}
+
+		pr, pw := io.Pipe()
+		go func() {
+			pw.Write([]byte("request body"))
+			pw.Close()
+		}()
+		resp, err := client.Post(s.URL, "text/plain", pr)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.NotContains(t, spans[0].Tags(), tagRequestBody)
+	})
+
+	t.Run("upgrade", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer conn.Close()
+			buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+			buf.Flush()
+			io.Copy(conn, buf)
+		}))
+		defer srv.Close()
+		client := &http.Client{Transport: WrapRoundTripper(http.DefaultTransport,
+			RTWithResponseBodyCapture(1024, nil),
+		)}
+
+		req, err := http.NewRequest("GET", srv.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "echo")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+		conn, ok := resp.Body.(io.ReadWriteCloser)
+		require.True(t, ok)
+		_, err = conn.Write([]byte("ping"))
+		require.NoError(t, err)
+		pong := make([]byte, 4)
+		_, err = io.ReadFull(conn, pong)
+		require.NoError(t, err)
+		assert.Equal(t, "ping", string(pong))
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.NotContains(t, spans[0].Tags(), tagResponseBody)
+	})
+}
+
+func TestPeekBody(t *testing.T) {
+	t.Run("error", func(t *testing.T) {
+		readErr := errors.New("read error")
+		body := io.NopCloser(io.MultiReader(strings.NewReader("abc"), errReader{readErr}))
+
+		peeked, rest := peekBody(body, 8)
+		assert.Equal(t, "abc", string(peeked))
+		read, err := io.ReadAll(rest)
+		assert.Equal(t, "abc", string(read))
+		assert.Equal(t, readErr, err)
+	})
+
+	t.Run("short", func(t *testing.T) {
+		peeked, rest := peekBody(io.NopCloser(strings.NewReader("abcdef")), 3)
+		assert.Equal(t, "abc", string(peeked))
+		read, err := io.ReadAll(rest)
+		assert.NoError(t, err)
+		assert.Equal(t, "abcdef", string(read))
+	})
+}
+
 func TestRoundTripperPropagation(t *testing.T) {
 	mt := mocktracer.Start()
 	defer mt.Stop()
@@ -8,7 +8,11 @@ package http // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
 //go:generate sh -c "go run make_responsewriter.go | gofmt > trace_gen.go"
 
 import (
+	"bufio"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/httptrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
@@ -21,6 +25,10 @@ import (
 
 const componentName = "net/http"
 
+// tagUpgraded is set on request spans whose connection was upgraded or
+// otherwise hijacked by the handler.
+const tagUpgraded = "http.upgraded"
+
 func init() {
 	telemetry.LoadIntegration(componentName)
 }
@@ -44,6 +52,10 @@ type ServeConfig struct {
 	FinishOpts []ddtrace.FinishOption
 	// SpanOpts specifies any options to be applied to the request starting span.
 	SpanOpts []ddtrace.StartSpanOption
+	// ConnectionSpan, when true, starts a span covering the lifetime of a
+	// connection hijacked while serving the request, such as a WebSocket
+	// connection. The span finishes when the connection is closed.
+	ConnectionSpan bool
 }
 
 // TraceAndServe serves the handler h using the given ResponseWriter and Request, applying tracing
@@ -64,14 +76,82 @@ func TraceAndServe(h http.Handler, w http.ResponseWriter, r *http.Request, cfg *
 	}
 	span, ctx := httptrace.StartRequestSpan(r, opts...)
 	rw, ddrw := wrapResponseWriter(w)
+	r2 := r.WithContext(ctx)
+	mux, _ := h.(*http.ServeMux)
+	resource := cfg.Resource
+	var once sync.Once
+	finish := func(status int) {
+		once.Do(func() {
+			if cfg.Route == "" {
+				// Use the pattern of the http.ServeMux the request is routed to, if
+				// any, for a low-cardinality route and resource.
+				if route := patternRoute(servedPattern(mux, r2)); route != "" {
+					span.SetTag(ext.HTTPRoute, route)
+					if resource == "" {
+						resource = r.Method + " " + route
+						span.SetTag(ext.ResourceName, resource)
+					}
+				}
+			}
+			httptrace.FinishRequestSpan(span, status, cfg.FinishOpts...)
+		})
+	}
+	// Finish the request span as soon as its connection is hijacked, e.g. for
+	// a WebSocket upgrade, instead of once the handler returns. With AppSec,
+	// the span is tagged once the handler returns, so it is finished then.
+	appsecEnabled := appsec.Enabled()
+	var hijackedStatus int
+	ddrw.hijacked = func(conn net.Conn) net.Conn {
+		span.SetTag(tagUpgraded, true)
+		status := ddrw.status
+		if status == 0 && r.Header.Get("Upgrade") != "" {
+			status = http.StatusSwitchingProtocols
+		}
+		if appsecEnabled {
+			hijackedStatus = status
+		} else {
+			finish(status)
+		}
+		if !cfg.ConnectionSpan {
+			return conn
+		}
+		return newTracedConn(conn, span, cfg.Service, resource)
+	}
 	defer func() {
-		httptrace.FinishRequestSpan(span, ddrw.status, cfg.FinishOpts...)
+		status := ddrw.status
+		if status == 0 {
+			status = hijackedStatus
+		}
+		finish(status)
 	}()
 
-	if appsec.Enabled() {
+	if appsecEnabled {
 		h = httpsec.WrapHandler(h, span, cfg.RouteParams)
 	}
-	h.ServeHTTP(rw, r.WithContext(ctx))
+	h.ServeHTTP(rw, r2)
+}
+
+// servedPattern returns the pattern r was matched with by mux, or the pattern set
+// on r by the http.ServeMux it was served by when mux is nil. The latter is lost
+// when the request is copied by an intermediate handler, such as the AppSec one.
+func servedPattern(mux *http.ServeMux, r *http.Request) string {
+	if mux != nil {
+		_, pattern := mux.Handler(r)
+		return pattern
+	}
+	return requestPattern(r)
+}
+
+// patternRoute returns the path of the given http.ServeMux pattern, which
+// may be prefixed with a method and a host since go1.22.
+func patternRoute(pattern string) string {
+	if i := strings.IndexAny(pattern, " \t"); i >= 0 {
+		pattern = strings.TrimLeft(pattern[i:], " \t")
+	}
+	if i := strings.Index(pattern, "/"); i > 0 {
+		pattern = pattern[i:]
+	}
+	return pattern
 }
 
 // responseWriter is a small wrapper around an http response writer that will
@@ -79,10 +159,13 @@ func TraceAndServe(h http.Handler, w http.ResponseWriter, r *http.Request, cfg *
 type responseWriter struct {
 	http.ResponseWriter
 	status int
+	// hijacked, if not nil, is called with connections hijacked from the
+	// response writer, and returns the connection to hand over to the caller.
+	hijacked func(net.Conn) net.Conn
 }
 
 func newResponseWriter(w http.ResponseWriter) *responseWriter {
-	return &responseWriter{w, 0}
+	return &responseWriter{ResponseWriter: w}
 }
 
 // Status returns the status code that was monitored.
@@ -109,3 +192,54 @@ func (w *responseWriter) WriteHeader(status int) {
 	w.ResponseWriter.WriteHeader(status)
 	w.status = status
 }
+
+// hijacker wraps an http.Hijacker to notify a responseWriter of the
+// connections hijacked from it.
+type hijacker struct {
+	http.Hijacker
+	rw *responseWriter
+}
+
+// Hijack implements http.Hijacker.
+func (h *hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := h.Hijacker.Hijack()
+	if err == nil && h.rw.hijacked != nil {
+		conn = h.rw.hijacked(conn)
+	}
+	return conn, buf, err
+}
+
+// tracedConn is a hijacked connection traced by a span finished when the
+// connection is closed.
+type tracedConn struct {
+	net.Conn
+	span ddtrace.Span
+	once sync.Once
+}
+
+func newTracedConn(conn net.Conn, parent ddtrace.Span, service, resource string) *tracedConn {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ChildOf(parent.Context()),
+		tracer.SpanType(ext.SpanTypeWeb),
+		tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+	}
+	if service != "" {
+		opts = append(opts, tracer.ServiceName(service))
+	}
+	if resource != "" {
+		opts = append(opts, tracer.ResourceName(resource))
+	}
+	return &tracedConn{
+		Conn: conn,
+		span: tracer.StartSpan("http.connection", opts...),
+	}
+}
+
+// Close implements net.Conn.
+func (c *tracedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() {
+		c.span.Finish()
+	})
+	return err
+}
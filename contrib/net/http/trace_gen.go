@@ -24,6 +24,10 @@ func wrapResponseWriter(w http.ResponseWriter) (http.ResponseWriter, *responseWr
 	hHijacker, okHijacker := w.(http.Hijacker)
 
 	mw := newResponseWriter(w)
+	if okHijacker {
+		// notify mw of hijacked connections
+		hHijacker = &hijacker{Hijacker: hHijacker, rw: mw}
+	}
 	type monitoredResponseWriter interface {
 		http.ResponseWriter
 		Status() int
@@ -6,8 +6,10 @@
 package http
 
 import (
+	"bufio"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -18,6 +20,7 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTraceAndServe(t *testing.T) {
@@ -357,6 +360,91 @@ func TestTraceAndServeHost(t *testing.T) {
 	})
 }
 
+func TestPatternRoute(t *testing.T) {
+	for pattern, want := range map[string]string{
+		"":                        "",
+		"/":                       "/",
+		"/users/":                 "/users/",
+		"GET /users/{id}":         "/users/{id}",
+		"example.com/users/{id}":  "/users/{id}",
+		"POST example.com/users/": "/users/",
+		"DELETE  /users/{id...}":  "/users/{id...}",
+		"GET\t/users/{id}/{$}":    "/users/{id}/{$}",
+	} {
+		assert.Equal(t, want, patternRoute(pattern), pattern)
+	}
+}
+
+func TestTraceAndServeHijacked(t *testing.T) {
+	// upgrade starts a server with a handler upgrading connections, and
+	// returns the request span finished while the handler was running.
+	upgrade := func(t *testing.T, mt mocktracer.Tracer, opts ...Option) mocktracer.Span {
+		done := make(chan struct{})
+		var finished []mocktracer.Span
+		h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer close(done)
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			defer conn.Close()
+			finished = mt.FinishedSpans()
+			buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+			buf.Flush()
+		})
+		srv := httptest.NewServer(WrapHandler(h, "service", "resource", opts...))
+		defer srv.Close()
+
+		conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n"))
+		require.NoError(t, err)
+		res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+		<-done
+
+		require.Len(t, finished, 1)
+		return finished[0]
+	}
+
+	t.Run("upgraded", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		span := upgrade(t, mt)
+		assert.Equal(t, true, span.Tag(tagUpgraded))
+		assert.Equal(t, "101", span.Tag(ext.HTTPCode))
+		assert.Equal(t, "resource", span.Tag(ext.ResourceName))
+		assert.Len(t, mt.FinishedSpans(), 1)
+	})
+
+	t.Run("connection", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		span := upgrade(t, mt, WithConnectionSpans(true))
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 2)
+		conn := spans[1]
+		assert.Equal(t, "http.connection", conn.OperationName())
+		assert.Equal(t, span.SpanID(), conn.ParentID())
+		assert.Equal(t, "service", conn.Tag(ext.ServiceName))
+		assert.Equal(t, "resource", conn.Tag(ext.ResourceName))
+		assert.Equal(t, ext.SpanKindServer, conn.Tag(ext.SpanKind))
+	})
+
+	t.Run("not-hijacked", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		r := httptest.NewRequest("GET", "/", nil)
+		TraceAndServe(http.HandlerFunc(handler200), httptest.NewRecorder(), r, &ServeConfig{ConnectionSpan: true})
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.NotContains(t, spans[0].Tags(), tagUpgraded)
+	})
+}
+
 type noopHandler struct{}
 
 func (noopHandler) ServeHTTP(_ http.ResponseWriter, _ *http.Request) {}
@@ -0,0 +1,31 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package openfeature_test
+
+import (
+	"net/http"
+
+	openfeaturetrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/open-feature/go-sdk/openfeature"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+func Example() {
+	// Register the hook for all the evaluations. The provider, such as the
+	// LaunchDarkly provider, is set with openfeature.SetProvider.
+	openfeature.AddHooks(openfeaturetrace.NewHook())
+	client := openfeature.NewClient("checkout")
+
+	http.HandleFunc("/checkout", func(w http.ResponseWriter, r *http.Request) {
+		// The span of the request is tagged with the variant of the flag.
+		enabled, _ := client.BooleanValue(r.Context(), "new-checkout", false, openfeature.NewEvaluationContext("user-1", nil))
+		if enabled {
+			w.Write([]byte("new"))
+			return
+		}
+		w.Write([]byte("old"))
+	})
+}
@@ -0,0 +1,11 @@
+module gopkg.in/DataDog/dd-trace-go.v1/contrib/open-feature/go-sdk/openfeature
+
+go 1.25.1
+
+require (
+	github.com/open-feature/go-sdk v1.8.0
+	github.com/stretchr/testify v1.8.4
+	gopkg.in/DataDog/dd-trace-go.v1 v1.54.0
+)
+
+replace gopkg.in/DataDog/dd-trace-go.v1 => ../../../..
@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package openfeature provides a hook for the OpenFeature Go SDK (https://github.com/open-feature/go-sdk)
+// which tags the active span with the evaluated feature flags.
+//
+// Every flag evaluated with a context holding a span tags the span with the
+// variant the flag resolved to, in the feature_flag.<flag key> tag, so that
+// the requests can be grouped by flag cohort. The hook works with any
+// provider, such as the LaunchDarkly, Flagd or Go Feature Flag providers.
+package openfeature // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/open-feature/go-sdk/openfeature"
+
+import (
+	"context"
+	"fmt"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+const componentName = "open-feature/go-sdk/openfeature"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Tags set on the active span.
+const (
+	tagPrefix       = "feature_flag."
+	tagProviderName = "feature_flag.provider_name"
+	errorSuffix     = ".error"
+)
+
+// Hook is an openfeature.Hook tagging the span found in the context of the
+// evaluations with the evaluated flags. Use NewHook to create it.
+type Hook struct {
+	openfeature.UnimplementedHook
+	cfg *config
+}
+
+var _ openfeature.Hook = (*Hook)(nil)
+
+// NewHook returns a new Hook. It can be registered globally with
+// openfeature.AddHooks, on a client or on a single evaluation.
+func NewHook(opts ...Option) *Hook {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/open-feature/go-sdk/openfeature: Creating Hook: %#v", cfg)
+	return &Hook{cfg: cfg}
+}
+
+// After implements openfeature.Hook. It tags the active span with the
+// variant of the evaluated flag.
+func (h *Hook) After(ctx context.Context, hookContext openfeature.HookContext, details openfeature.InterfaceEvaluationDetails, _ openfeature.HookHints) error {
+	key := hookContext.FlagKey()
+	if !h.cfg.filter(key) {
+		return nil
+	}
+	span, ok := tracer.SpanFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	variant := details.Variant
+	if variant == "" {
+		variant = valueVariant(details.FlagType, details.Value)
+	}
+	if variant != "" {
+		span.SetTag(tagPrefix+key, variant)
+	}
+	if name := hookContext.ProviderMetadata().Name; name != "" {
+		span.SetTag(tagProviderName, name)
+	}
+	return nil
+}
+
+// Error implements openfeature.Hook. It tags the active span with the error
+// the evaluation of the flag failed with.
+func (h *Hook) Error(ctx context.Context, hookContext openfeature.HookContext, err error, _ openfeature.HookHints) {
+	key := hookContext.FlagKey()
+	if !h.cfg.filter(key) {
+		return
+	}
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		span.SetTag(tagPrefix+key+errorSuffix, err.Error())
+	}
+}
+
+// valueVariant returns the variant of a flag resolved without one, which is
+// its value for the flags of scalar types. The values of object flags are not
+// collected.
+func valueVariant(typ openfeature.Type, value interface{}) string {
+	switch typ {
+	case openfeature.Boolean, openfeature.String, openfeature.Int, openfeature.Float:
+		return fmt.Sprint(value)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package openfeature
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hookContext(key string, typ openfeature.Type) openfeature.HookContext {
+	return openfeature.NewHookContext(
+		key,
+		typ,
+		nil,
+		openfeature.NewClientMetadata("test"),
+		openfeature.Metadata{Name: "LaunchDarkly"},
+		openfeature.NewEvaluationContext("user-1", nil),
+	)
+}
+
+func details(key string, typ openfeature.Type, value interface{}, variant string) openfeature.InterfaceEvaluationDetails {
+	return openfeature.InterfaceEvaluationDetails{
+		Value: value,
+		EvaluationDetails: openfeature.EvaluationDetails{
+			FlagKey:          key,
+			FlagType:         typ,
+			ResolutionDetail: openfeature.ResolutionDetail{Variant: variant},
+		},
+	}
+}
+
+func TestHook(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	h := NewHook()
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "web.request")
+	require.NoError(t, h.After(ctx, hookContext("checkout", openfeature.String), details("checkout", openfeature.String, "v2", "treatment"), openfeature.HookHints{}))
+	require.NoError(t, h.After(ctx, hookContext("dark-mode", openfeature.Boolean), details("dark-mode", openfeature.Boolean, true, ""), openfeature.HookHints{}))
+	require.NoError(t, h.After(ctx, hookContext("config", openfeature.Object), details("config", openfeature.Object, map[string]interface{}{"a": 1}, ""), openfeature.HookHints{}))
+	h.Error(ctx, hookContext("broken", openfeature.Int), errors.New("FLAG_NOT_FOUND"), openfeature.HookHints{})
+	span.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "treatment", s.Tag("feature_flag.checkout"))
+	assert.Equal(t, "true", s.Tag("feature_flag.dark-mode"))
+	assert.Nil(t, s.Tag("feature_flag.config"))
+	assert.Equal(t, "FLAG_NOT_FOUND", s.Tag("feature_flag.broken.error"))
+	assert.Equal(t, "LaunchDarkly", s.Tag(tagProviderName))
+}
+
+func TestWithFlags(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	h := NewHook(WithFlags("checkout"))
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "web.request")
+	h.After(ctx, hookContext("checkout", openfeature.String), details("checkout", openfeature.String, "v2", "treatment"), openfeature.HookHints{})
+	h.After(ctx, hookContext("other", openfeature.String), details("other", openfeature.String, "x", "x"), openfeature.HookHints{})
+	span.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "treatment", spans[0].Tag("feature_flag.checkout"))
+	assert.Nil(t, spans[0].Tag("feature_flag.other"))
+}
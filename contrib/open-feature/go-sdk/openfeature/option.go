@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package openfeature
+
+type config struct {
+	filter func(key string) bool
+}
+
+// Option represents an option that can be passed to NewHook.
+type Option func(*config)
+
+func defaults(cfg *config) {
+	cfg.filter = func(string) bool { return true }
+}
+
+// WithFlags restricts the flags the active span is tagged with to the flags
+// with the given keys.
+func WithFlags(keys ...string) Option {
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[k] = struct{}{}
+	}
+	return func(cfg *config) {
+		cfg.filter = func(key string) bool {
+			_, ok := set[key]
+			return ok
+		}
+	}
+}
+
+// WithFlagFilter sets the function determining whether the active span is
+// tagged with the flag with the given key.
+func WithFlagFilter(f func(key string) bool) Option {
+	return func(cfg *config) {
+		cfg.filter = f
+	}
+}
@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package openai_test
+
+import (
+	"context"
+	"log"
+
+	openaitrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/openai/openai-go"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/openai/openai-go"
+)
+
+func Example() {
+	tracer.Start()
+	defer tracer.Stop()
+
+	// Every request made by the client is traced. The API key is read from
+	// the OPENAI_API_KEY environment variable.
+	client := openai.NewClient(openaitrace.RequestOption(
+		openaitrace.WithServiceName("my-llm-app"),
+		openaitrace.WithContentCapture(true),
+	))
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "answer")
+	defer span.Finish()
+
+	resp, err := client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Model: openai.ChatModelGPT4o,
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage("What is distributed tracing?"),
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println(resp.Choices[0].Message.Content)
+}
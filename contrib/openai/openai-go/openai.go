@@ -0,0 +1,193 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package openai provides functions to trace the openai/openai-go package (https://github.com/openai/openai-go).
+//
+// A middleware of the OpenAI client traces every request made to the API. The
+// spans of chat completions, completions and embeddings are tagged with the
+// requested and responding models and with the token usage, and the spans of
+// streamed completions with the time to the first token, so that they can be
+// processed by LLM Observability. The prompts and responses can also be
+// captured, with redaction, with WithContentCapture.
+//
+// The spans of streamed completions finish when the stream is closed or read
+// to the end.
+package openai // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/openai/openai-go"
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/llmobs"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/openai/openai-go/option"
+)
+
+const componentName = "openai/openai-go"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+const modelProvider = "openai"
+
+// Tags set on the request spans.
+const (
+	tagEndpoint      = "openai.request.endpoint"
+	tagMethod        = "openai.request.method"
+	tagRequestModel  = "openai.request.model"
+	tagStream        = "openai.request.stream"
+	tagResponseModel = "openai.response.model"
+	tagFinishReason  = "openai.response.finish_reason"
+)
+
+// Operations with LLM Observability tags.
+var operations = []struct {
+	suffix   string
+	resource string
+	kind     string
+}{
+	{"/chat/completions", "chat.completions", llmobs.SpanKindLLM},
+	{"/completions", "completions", llmobs.SpanKindLLM},
+	{"/embeddings", "embeddings", llmobs.SpanKindEmbedding},
+}
+
+// RequestOption returns an option.RequestOption tracing the requests of an
+// OpenAI client, to be passed to openai.NewClient.
+func RequestOption(opts ...Option) option.RequestOption {
+	return option.WithMiddleware(Middleware(opts...))
+}
+
+// Middleware returns an option.Middleware tracing the requests of an OpenAI
+// client. The spans are children of the span found in the context of the
+// requests, if any.
+func Middleware(opts ...Option) option.Middleware {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/openai/openai-go: Configuring Middleware: %#v", cfg)
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		endpoint := req.URL.Path
+		if i := strings.Index(endpoint, "/v1/"); i >= 0 {
+			endpoint = endpoint[i+len("/v1"):]
+		}
+		resource, kind := req.Method+" "+endpoint, ""
+		for _, op := range operations {
+			if strings.HasSuffix(endpoint, op.suffix) {
+				resource, kind = op.resource, op.kind
+				break
+			}
+		}
+		spanOpts := []ddtrace.StartSpanOption{
+			tracer.ServiceName(cfg.serviceName),
+			tracer.ResourceName(resource),
+			tracer.SpanType(ext.SpanTypeHTTP),
+			tracer.Tag(tagEndpoint, endpoint),
+			tracer.Tag(tagMethod, req.Method),
+			tracer.Tag(ext.Component, componentName),
+			tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		}
+		if !math.IsNaN(cfg.analyticsRate) {
+			spanOpts = append(spanOpts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+		}
+		var body requestBody
+		if kind != "" {
+			body = readRequestBody(req)
+			spanOpts = append(spanOpts,
+				tracer.Tag(llmobs.TagSpanKind, kind),
+				tracer.Tag(llmobs.TagModelProvider, modelProvider),
+				tracer.Tag(tagStream, body.Stream),
+			)
+			if body.Model != "" {
+				spanOpts = append(spanOpts,
+					tracer.Tag(tagRequestModel, body.Model),
+					tracer.Tag(llmobs.TagModelName, body.Model),
+				)
+			}
+		}
+		span, ctx := tracer.StartSpanFromContext(req.Context(), cfg.spanName, spanOpts...)
+		if kind == llmobs.SpanKindEmbedding {
+			cfg.capture.TagDocuments(span, llmobs.TagInputDocuments, body.inputs())
+		} else if kind != "" {
+			cfg.capture.TagMessages(span, llmobs.TagInputMessages, body.messages())
+		}
+		start := time.Now()
+
+		resp, err := next(req.WithContext(ctx))
+		if err != nil {
+			span.Finish(tracer.WithError(err))
+			return resp, err
+		}
+		span.SetTag(ext.HTTPCode, strconv.Itoa(resp.StatusCode))
+		if resp.StatusCode >= 400 {
+			span.Finish(tracer.WithError(fmt.Errorf("%d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))))
+			return resp, nil
+		}
+		if kind == "" {
+			span.Finish()
+			return resp, nil
+		}
+		if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+			resp.Body = newStream(resp.Body, span, cfg, start)
+			return resp, nil
+		}
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(b))
+		if err != nil {
+			span.Finish(tracer.WithError(err))
+			return resp, nil
+		}
+		var rb responseBody
+		if err := rb.unmarshal(b); err == nil {
+			rb.tag(span, cfg, kind)
+		}
+		span.Finish()
+		return resp, nil
+	}
+}
+
+// readRequestBody returns the body of req, which is restored to be sent.
+func readRequestBody(req *http.Request) requestBody {
+	var body requestBody
+	if req.Body == nil || req.Body == http.NoBody {
+		return body
+	}
+	var b []byte
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return body
+		}
+		b, err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return body
+		}
+	} else {
+		var err error
+		b, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(b))
+		if err != nil {
+			return body
+		}
+	}
+	body.unmarshal(b)
+	return body
+}
@@ -0,0 +1,186 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/llmobs"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		json.Unmarshal(b, &body)
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			if body.Stream {
+				w.Header().Set("Content-Type", "text/event-stream")
+				for _, chunk := range []string{
+					`{"id":"1","object":"chat.completion.chunk","model":"gpt-4o-2024-08-06","choices":[{"index":0,"delta":{"role":"assistant","content":"Hello"}}]}`,
+					`{"id":"1","object":"chat.completion.chunk","model":"gpt-4o-2024-08-06","choices":[{"index":0,"delta":{"content":" there"},"finish_reason":"stop"}]}`,
+					`{"id":"1","object":"chat.completion.chunk","model":"gpt-4o-2024-08-06","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`,
+					`[DONE]`,
+				} {
+					fmt.Fprintf(w, "data: %s\n\n", chunk)
+				}
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"id":"1","object":"chat.completion","model":"gpt-4o-2024-08-06","choices":[{"index":0,"message":{"role":"assistant","content":"Hello there"},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`)
+		case "/v1/embeddings":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"object":"list","model":"text-embedding-3-small","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2]}],"usage":{"prompt_tokens":3,"total_tokens":3}}`)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"error":{"message":"not found"}}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newClient(srv *httptest.Server, opts ...Option) openai.Client {
+	return openai.NewClient(
+		option.WithBaseURL(srv.URL+"/v1/"),
+		option.WithAPIKey("sk-test"),
+		option.WithMaxRetries(0),
+		RequestOption(opts...),
+	)
+}
+
+func chatParams() openai.ChatCompletionNewParams {
+	return openai.ChatCompletionNewParams{
+		Model: openai.ChatModelGPT4o,
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage("Hi, I am jane@example.com"),
+		},
+	}
+}
+
+func TestChatCompletion(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	client := newClient(newServer(t), WithContentCapture(true))
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "root")
+	resp, err := client.Chat.Completions.New(ctx, chatParams())
+	require.NoError(t, err)
+	assert.Equal(t, "Hello there", resp.Choices[0].Message.Content)
+	root.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	span := spans[0]
+	assert.Equal(t, "openai.request", span.OperationName())
+	assert.Equal(t, root.Context().SpanID(), span.ParentID())
+	assert.Equal(t, "openai", span.Tag(ext.ServiceName))
+	assert.Equal(t, "chat.completions", span.Tag(ext.ResourceName))
+	assert.Equal(t, "/chat/completions", span.Tag(tagEndpoint))
+	assert.Equal(t, "200", span.Tag(ext.HTTPCode))
+	assert.Equal(t, "gpt-4o", span.Tag(tagRequestModel))
+	assert.Equal(t, "gpt-4o-2024-08-06", span.Tag(tagResponseModel))
+	assert.Equal(t, "gpt-4o-2024-08-06", span.Tag(llmobs.TagModelName))
+	assert.Equal(t, "openai", span.Tag(llmobs.TagModelProvider))
+	assert.Equal(t, llmobs.SpanKindLLM, span.Tag(llmobs.TagSpanKind))
+	assert.Equal(t, false, span.Tag(tagStream))
+	assert.Equal(t, "stop", span.Tag(tagFinishReason))
+	assert.Equal(t, "stop", span.Tag(llmobs.TagStopReason))
+	assert.Equal(t, int64(5), span.Tag(llmobs.TagInputTokens))
+	assert.Equal(t, int64(2), span.Tag(llmobs.TagOutputTokens))
+	assert.Equal(t, int64(7), span.Tag(llmobs.TagTotalTokens))
+	assert.Equal(t, `[{"role":"user","content":"Hi, I am <redacted>"}]`, span.Tag(llmobs.TagInputMessages))
+	assert.Equal(t, `[{"role":"assistant","content":"Hello there"}]`, span.Tag(llmobs.TagOutputMessages))
+	assert.Equal(t, componentName, span.Tag(ext.Component))
+	assert.Equal(t, ext.SpanKindClient, span.Tag(ext.SpanKind))
+	assert.Nil(t, span.Tag(ext.Error))
+}
+
+func TestChatCompletionStreaming(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	client := newClient(newServer(t), WithContentCapture(true))
+
+	stream := client.Chat.Completions.NewStreaming(context.Background(), chatParams())
+	var content string
+	for stream.Next() {
+		chunk := stream.Current()
+		if len(chunk.Choices) > 0 {
+			content += chunk.Choices[0].Delta.Content
+		}
+	}
+	require.NoError(t, stream.Err())
+	require.NoError(t, stream.Close())
+	assert.Equal(t, "Hello there", content)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, true, span.Tag(tagStream))
+	assert.Equal(t, "gpt-4o-2024-08-06", span.Tag(tagResponseModel))
+	assert.Equal(t, "stop", span.Tag(tagFinishReason))
+	assert.Equal(t, int64(7), span.Tag(llmobs.TagTotalTokens))
+	assert.IsType(t, float64(0), span.Tag(llmobs.TagTimeToFirstToken))
+	assert.Equal(t, `[{"role":"assistant","content":"Hello there"}]`, span.Tag(llmobs.TagOutputMessages))
+	assert.Nil(t, span.Tag(ext.Error))
+}
+
+func TestEmbedding(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	client := newClient(newServer(t))
+
+	_, err := client.Embeddings.New(context.Background(), openai.EmbeddingNewParams{
+		Model: openai.EmbeddingModelTextEmbedding3Small,
+		Input: openai.EmbeddingNewParamsInputUnion{OfString: openai.String("Hello")},
+	})
+	require.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "embeddings", span.Tag(ext.ResourceName))
+	assert.Equal(t, llmobs.SpanKindEmbedding, span.Tag(llmobs.TagSpanKind))
+	assert.Equal(t, int64(3), span.Tag(llmobs.TagInputTokens))
+	assert.Nil(t, span.Tag(llmobs.TagOutputTokens))
+	// Contents aren't captured by default.
+	assert.Nil(t, span.Tag(llmobs.TagInputDocuments))
+}
+
+func TestError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	client := newClient(newServer(t))
+
+	_, err := client.Models.Get(context.Background(), "unknown")
+	require.Error(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /models/unknown", span.Tag(ext.ResourceName))
+	assert.Equal(t, "404", span.Tag(ext.HTTPCode))
+	assert.NotNil(t, span.Tag(ext.Error))
+	assert.Nil(t, span.Tag(llmobs.TagSpanKind))
+}
@@ -0,0 +1,218 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package openai
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/llmobs"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// requestBody holds the fields of the requests which are relevant to tracing.
+type requestBody struct {
+	Model    string          `json:"model"`
+	Stream   bool            `json:"stream"`
+	Messages []message       `json:"messages"`
+	Prompt   json.RawMessage `json:"prompt"`
+	Input    json.RawMessage `json:"input"`
+}
+
+type message struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// text returns the text of the content of m, which is either a string or a
+// list of parts, of which only the text parts are kept.
+func (m message) text() string {
+	var s string
+	if err := json.Unmarshal(m.Content, &s); err == nil {
+		return s
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(m.Content, &parts); err != nil {
+		return ""
+	}
+	texts := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p.Type == "text" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func (b *requestBody) unmarshal(data []byte) error {
+	return json.Unmarshal(data, b)
+}
+
+// messages returns the prompt of a chat completion or completion request.
+func (b *requestBody) messages() []llmobs.Message {
+	if len(b.Messages) > 0 {
+		msgs := make([]llmobs.Message, len(b.Messages))
+		for i, m := range b.Messages {
+			msgs[i] = llmobs.Message{Role: m.Role, Content: m.text()}
+		}
+		return msgs
+	}
+	prompts := stringList(b.Prompt)
+	msgs := make([]llmobs.Message, len(prompts))
+	for i, p := range prompts {
+		msgs[i] = llmobs.Message{Content: p}
+	}
+	return msgs
+}
+
+// inputs returns the input of an embedding request.
+func (b *requestBody) inputs() []string {
+	return stringList(b.Input)
+}
+
+// stringList returns the strings held by raw, which is either a string or a
+// list of strings. Token arrays are ignored.
+func stringList(raw json.RawMessage) []string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return []string{s}
+	}
+	var l []string
+	if err := json.Unmarshal(raw, &l); err == nil {
+		return l
+	}
+	return nil
+}
+
+// responseBody holds the fields of the responses, and of the chunks of the
+// streamed responses, which are relevant to tracing.
+type responseBody struct {
+	Model   string `json:"model"`
+	Usage   *usage `json:"usage"`
+	Choices []struct {
+		Message      *message `json:"message"`
+		Delta        *message `json:"delta"`
+		Text         string   `json:"text"`
+		FinishReason string   `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+type usage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+func (b *responseBody) unmarshal(data []byte) error {
+	return json.Unmarshal(data, b)
+}
+
+// tag tags span with the model and usage of the response, and with its
+// output if enabled.
+func (b *responseBody) tag(span ddtrace.Span, cfg *config, kind string) {
+	tagModel(span, b.Model)
+	if b.Usage != nil {
+		output := b.Usage.CompletionTokens
+		if kind == llmobs.SpanKindEmbedding {
+			output = -1
+		}
+		llmobs.TagUsage(span, b.Usage.PromptTokens, output, b.Usage.TotalTokens)
+	}
+	if kind == llmobs.SpanKindEmbedding {
+		return
+	}
+	msgs := make([]llmobs.Message, 0, len(b.Choices))
+	for _, c := range b.Choices {
+		tagFinish(span, c.FinishReason)
+		if c.Message != nil {
+			msgs = append(msgs, llmobs.Message{Role: c.Message.Role, Content: c.Message.text()})
+		} else {
+			msgs = append(msgs, llmobs.Message{Content: c.Text})
+		}
+	}
+	cfg.capture.TagMessages(span, llmobs.TagOutputMessages, msgs)
+}
+
+func tagModel(span ddtrace.Span, model string) {
+	if model != "" {
+		span.SetTag(tagResponseModel, model)
+		span.SetTag(llmobs.TagModelName, model)
+	}
+}
+
+func tagFinish(span ddtrace.Span, reason string) {
+	if reason != "" {
+		span.SetTag(tagFinishReason, reason)
+		span.SetTag(llmobs.TagStopReason, reason)
+	}
+}
+
+// stream tags the span of a streamed response with the chunks it receives,
+// and finishes it when the stream ends.
+type stream struct {
+	span   ddtrace.Span
+	cfg    *config
+	text   llmobs.StreamText
+	model  string
+	role   string
+	usage  *usage
+	finish string
+}
+
+func newStream(body io.ReadCloser, span ddtrace.Span, cfg *config, start time.Time) *llmobs.EventStream {
+	s := &stream{
+		span: span,
+		cfg:  cfg,
+		text: llmobs.StreamText{Span: span, Start: start, Capture: cfg.capture.Enabled},
+	}
+	return &llmobs.EventStream{ReadCloser: body, OnData: s.chunk, OnEnd: s.end}
+}
+
+func (s *stream) chunk(data []byte) {
+	var c responseBody
+	if err := c.unmarshal(data); err != nil {
+		return
+	}
+	if c.Model != "" {
+		s.model = c.Model
+	}
+	if c.Usage != nil {
+		s.usage = c.Usage
+	}
+	for _, choice := range c.Choices {
+		if choice.FinishReason != "" {
+			s.finish = choice.FinishReason
+		}
+		if choice.Delta == nil {
+			s.text.Write(choice.Text)
+			continue
+		}
+		if choice.Delta.Role != "" {
+			s.role = choice.Delta.Role
+		}
+		s.text.Write(choice.Delta.text())
+	}
+}
+
+func (s *stream) end(err error) {
+	tagModel(s.span, s.model)
+	if s.usage != nil {
+		llmobs.TagUsage(s.span, s.usage.PromptTokens, s.usage.CompletionTokens, s.usage.TotalTokens)
+	}
+	tagFinish(s.span, s.finish)
+	if out := s.text.String(); out != "" {
+		s.cfg.capture.TagMessages(s.span, llmobs.TagOutputMessages, []llmobs.Message{
+			{Role: s.role, Content: out},
+		})
+	}
+	s.span.Finish(tracer.WithError(err))
+}
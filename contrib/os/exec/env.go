@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package exec
+
+import (
+	"os"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// envPrefix prefixes the names of the environment variables holding the
+// propagated span context. The rest of the name is the propagation header in
+// upper case, with dashes replaced by underscores, e.g. x-datadog-trace-id is
+// propagated in DD_TRACE_X_DATADOG_TRACE_ID.
+const envPrefix = "DD_TRACE_"
+
+// injectEnv returns env with the environment variables propagating spanctx
+// added, replacing those which were already set.
+func injectEnv(env []string, spanctx ddtrace.SpanContext) []string {
+	carrier := tracer.TextMapCarrier{}
+	if err := tracer.Inject(spanctx, carrier); err != nil {
+		return env
+	}
+	vars := make(map[string]string, len(carrier))
+	for k, v := range carrier {
+		vars[envPrefix+strings.ToUpper(strings.ReplaceAll(k, "-", "_"))] = v
+	}
+	out := make([]string, 0, len(env)+len(vars))
+	for _, kv := range env {
+		if k, _, _ := strings.Cut(kv, "="); vars[k] != "" {
+			continue
+		}
+		out = append(out, kv)
+	}
+	for k, v := range vars {
+		out = append(out, k+"="+v)
+	}
+	return out
+}
+
+// ExtractEnv extracts the span context propagated to the current process by
+// a command traced with WithEnvInjection. The returned span context can be
+// used as the parent of the spans of the process with tracer.ChildOf.
+func ExtractEnv() (ddtrace.SpanContext, error) {
+	carrier := tracer.TextMapCarrier{}
+	for _, kv := range os.Environ() {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(k, envPrefix) {
+			continue
+		}
+		carrier[strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(k, envPrefix), "_", "-"))] = v
+	}
+	return tracer.Extract(carrier)
+}
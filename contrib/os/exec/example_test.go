@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package exec_test
+
+import (
+	"context"
+	"log"
+	"os/exec"
+
+	exectrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/os/exec"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func Example() {
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "job")
+	defer span.Finish()
+
+	// The execution of the command is traced by a child of span.
+	out, err := exectrace.CommandContext(ctx, "git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("HEAD is %s", out)
+}
+
+func Example_envInjection() {
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "job")
+	defer span.Finish()
+
+	// The span context is propagated to the worker, which can extract it
+	// with exectrace.ExtractEnv.
+	cmd := exectrace.WrapCmd(ctx, exec.CommandContext(ctx, "./worker"), exectrace.WithEnvInjection(true))
+	if err := cmd.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,252 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package exec provides functions to trace the execution of commands with the
+// os/exec package (https://golang.org/pkg/os/exec).
+//
+// Every execution of a command is traced by a span which starts when the
+// command is started and finishes when it has been waited for. The resource of
+// the span is the name of the program. The span is tagged with the argument
+// list of the command, where the values of the arguments which look like
+// secrets are redacted, and with the exit code of the command, or the signal
+// which terminated it.
+//
+// With WithEnvInjection, the span context is propagated to the command through
+// environment variables prefixed with DD_TRACE_, which Go programs can extract
+// with ExtractEnv.
+package exec // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/os/exec"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+)
+
+const componentName = "os/exec"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Tags set on the command spans.
+const (
+	tagExitCode = "cmd.exit_code"
+	tagSignal   = "cmd.signal"
+	tagPath     = "cmd.path"
+	tagArgs     = "cmd.exec"
+)
+
+// maxStderrCapture is the number of bytes of standard error kept at the
+// beginning and at the end of the output by Output, like exec.Cmd.Output does.
+const maxStderrCapture = 32 << 10
+
+// Cmd is a traced exec.Cmd. The Start, Run, Wait, Output and CombinedOutput
+// methods are traced, the others are those of the embedded exec.Cmd.
+type Cmd struct {
+	*exec.Cmd
+	ctx  context.Context
+	cfg  *config
+	span ddtrace.Span
+}
+
+// Command returns a traced version of exec.Command.
+func Command(name string, arg ...string) *Cmd {
+	return WrapCmd(context.Background(), exec.Command(name, arg...))
+}
+
+// CommandContext returns a traced version of exec.CommandContext. The spans of
+// the command are children of the span found in ctx, if any.
+func CommandContext(ctx context.Context, name string, arg ...string) *Cmd {
+	return WrapCmd(ctx, exec.CommandContext(ctx, name, arg...))
+}
+
+// WrapCmd wraps the given command so that its execution is traced by a child
+// of the span found in ctx, if any.
+func WrapCmd(ctx context.Context, cmd *exec.Cmd, opts ...Option) *Cmd {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/os/exec: Wrapping Cmd: %#v", cfg)
+	return &Cmd{Cmd: cmd, ctx: ctx, cfg: cfg}
+}
+
+// Start starts the command, like exec.Cmd.Start, and starts its span.
+func (c *Cmd) Start() error {
+	name := c.Path
+	if len(c.Args) > 0 {
+		name = c.Args[0]
+	}
+	opts := []ddtrace.StartSpanOption{
+		tracer.ResourceName(name),
+		tracer.Tag(tagPath, c.Path),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+	}
+	if c.cfg.serviceName != "" {
+		opts = append(opts, tracer.ServiceName(c.cfg.serviceName))
+	}
+	if !math.IsNaN(c.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
+	}
+	if args, err := json.Marshal(c.cfg.redact(c.Args)); err == nil {
+		opts = append(opts, tracer.Tag(tagArgs, string(args)))
+	}
+	span, _ := tracer.StartSpanFromContext(c.ctx, c.cfg.spanName, opts...)
+	if c.cfg.injectEnv {
+		if c.Env == nil {
+			c.Env = os.Environ()
+		}
+		c.Env = injectEnv(c.Env, span.Context())
+	}
+	if err := c.Cmd.Start(); err != nil {
+		span.Finish(tracer.WithError(err))
+		return err
+	}
+	c.span = span
+	return nil
+}
+
+// Wait waits for the command to exit, like exec.Cmd.Wait, and finishes its
+// span.
+func (c *Cmd) Wait() error {
+	err := c.Cmd.Wait()
+	if c.span == nil {
+		return err
+	}
+	if ps := c.ProcessState; ps != nil {
+		code := ps.ExitCode()
+		c.span.SetTag(tagExitCode, code)
+		if code == -1 && !ps.Exited() {
+			// The command was terminated by a signal.
+			c.span.SetTag(tagSignal, strings.TrimPrefix(ps.String(), "signal: "))
+		}
+	}
+	c.span.Finish(tracer.WithError(err))
+	c.span = nil
+	return err
+}
+
+// Run starts the command and waits for it to complete, like exec.Cmd.Run.
+func (c *Cmd) Run() error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
+
+// Output runs the command and returns its standard output, like
+// exec.Cmd.Output. As with exec.Cmd.Output, when the command fails and Stderr
+// is not set, the error holds the first and last 32KB of its standard error.
+func (c *Cmd) Output() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	var stdout bytes.Buffer
+	stderr := &prefixSuffixSaver{n: maxStderrCapture}
+	c.Stdout = &stdout
+	captureErr := c.Stderr == nil
+	if captureErr {
+		c.Stderr = stderr
+	}
+	err := c.Run()
+	var ee *exec.ExitError
+	if err != nil && captureErr && errors.As(err, &ee) {
+		ee.Stderr = stderr.Bytes()
+	}
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput runs the command and returns its combined standard output
+// and standard error, like exec.Cmd.CombinedOutput.
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	if c.Stderr != nil {
+		return nil, errors.New("exec: Stderr already set")
+	}
+	var b bytes.Buffer
+	c.Stdout = &b
+	c.Stderr = &b
+	err := c.Run()
+	return b.Bytes(), err
+}
+
+// prefixSuffixSaver is an io.Writer which keeps the first n bytes and the last
+// n bytes written to it, and counts the bytes skipped in between.
+type prefixSuffixSaver struct {
+	n       int
+	prefix  []byte
+	suffix  []byte // ring buffer once it holds n bytes
+	off     int    // start of the ring buffer
+	skipped int64
+}
+
+func (w *prefixSuffixSaver) Write(p []byte) (int, error) {
+	total := len(p)
+	p = w.fill(&w.prefix, p)
+	// only the last n bytes of p can end up in the suffix
+	if over := len(p) - w.n; over > 0 {
+		p = p[over:]
+		w.skipped += int64(over)
+	}
+	p = w.fill(&w.suffix, p)
+	// the suffix is full: overwrite its oldest bytes
+	for len(p) > 0 {
+		n := copy(w.suffix[w.off:], p)
+		p = p[n:]
+		w.skipped += int64(n)
+		w.off += n
+		if w.off == w.n {
+			w.off = 0
+		}
+	}
+	return total, nil
+}
+
+// fill appends to *dst as much of p as fits in n bytes, and returns the rest.
+func (w *prefixSuffixSaver) fill(dst *[]byte, p []byte) []byte {
+	if room := w.n - len(*dst); room > 0 {
+		if room > len(p) {
+			room = len(p)
+		}
+		*dst = append(*dst, p[:room]...)
+		p = p[room:]
+	}
+	return p
+}
+
+// Bytes returns the bytes kept, with a note of how many were skipped, if any.
+func (w *prefixSuffixSaver) Bytes() []byte {
+	if w.suffix == nil {
+		return w.prefix
+	}
+	if w.skipped == 0 {
+		return append(w.prefix, w.suffix...)
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(w.prefix) + len(w.suffix) + 50)
+	buf.Write(w.prefix)
+	buf.WriteString("\n... omitting ")
+	buf.WriteString(strconv.FormatInt(w.skipped, 10))
+	buf.WriteString(" bytes ...\n")
+	buf.Write(w.suffix[w.off:])
+	buf.Write(w.suffix[:w.off])
+	return buf.Bytes()
+}
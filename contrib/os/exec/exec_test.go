@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package exec
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactArgs(t *testing.T) {
+	for _, tc := range []struct {
+		in, out []string
+	}{
+		{[]string{"ls", "-la"}, []string{"ls", "-la"}},
+		{[]string{"mysql", "--password=hunter2", "-u", "root"}, []string{"mysql", "--password=?", "-u", "root"}},
+		{[]string{"curl", "--token", "abc", "http://x"}, []string{"curl", "--token", "?", "http://x"}},
+		{[]string{"env", "API_KEY=abc", "HOME=/root"}, []string{"env", "API_KEY=?", "HOME=/root"}},
+	} {
+		assert.Equal(t, tc.out, redactArgs(tc.in))
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "root")
+	out, err := CommandContext(ctx, "sh", "-c", "echo hello").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", string(out))
+	err = CommandContext(ctx, "sh", "-c", "exit 3").Run()
+	require.Error(t, err)
+	root.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 3)
+	s := spans[0]
+	assert.Equal(t, defaultSpanName, s.OperationName())
+	assert.Equal(t, "sh", s.Tag(ext.ResourceName))
+	assert.Equal(t, `["sh","-c","echo hello"]`, s.Tag(tagArgs))
+	assert.Equal(t, 0, s.Tag(tagExitCode))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, root.Context().SpanID(), s.ParentID())
+	assert.Nil(t, s.Tag(ext.Error))
+
+	s = spans[1]
+	assert.Equal(t, 3, s.Tag(tagExitCode))
+	assert.Nil(t, s.Tag(tagSignal))
+	assert.NotNil(t, s.Tag(ext.Error))
+}
+
+func TestRedactedArgsTag(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	cmd := Command("dd-trace-go-does-not-exist", "--password=hunter2")
+	require.Error(t, cmd.Run())
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "dd-trace-go-does-not-exist", spans[0].Tag(ext.ResourceName))
+	assert.Equal(t, `["dd-trace-go-does-not-exist","--password=?"]`, spans[0].Tag(tagArgs))
+}
+
+func TestOutputStderr(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	// the standard error is captured, up to 32KB at each end
+	_, err := Command("sh", "-c", "echo oops >&2; exit 1").Output()
+	var ee *exec.ExitError
+	require.ErrorAs(t, err, &ee)
+	assert.Equal(t, "oops\n", string(ee.Stderr))
+
+	_, err = Command("sh", "-c", "head -c 100000 /dev/zero | tr '\\0' a >&2; exit 1").Output()
+	require.ErrorAs(t, err, &ee)
+	assert.Less(t, len(ee.Stderr), 2*maxStderrCapture+50)
+	assert.Contains(t, string(ee.Stderr), fmt.Sprintf("\n... omitting %d bytes ...\n", 100000-2*maxStderrCapture))
+}
+
+func TestPrefixSuffixSaver(t *testing.T) {
+	for _, tc := range []struct {
+		writes []string
+		want   string
+	}{
+		{writes: []string{"ab"}, want: "ab"},
+		{writes: []string{"abcdef"}, want: "abcdef"},
+		{writes: []string{"abc", "de", "f"}, want: "abcdef"},
+		{writes: []string{"abcdefgh"}, want: "abc\n... omitting 2 bytes ...\nfgh"},
+		{writes: []string{"ab", "cdefg", "hij"}, want: "abc\n... omitting 4 bytes ...\nhij"},
+		{writes: []string{"abcd", "e", "f", "g"}, want: "abc\n... omitting 1 bytes ...\nefg"},
+	} {
+		w := &prefixSuffixSaver{n: 3}
+		for _, s := range tc.writes {
+			n, err := w.Write([]byte(s))
+			require.NoError(t, err)
+			require.Equal(t, len(s), n)
+		}
+		assert.Equal(t, tc.want, string(w.Bytes()), "writes: %q", tc.writes)
+	}
+}
+
+func TestSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	err := Command("sh", "-c", "kill -9 $$").Run()
+	require.Error(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, -1, spans[0].Tag(tagExitCode))
+	assert.Equal(t, "killed", spans[0].Tag(tagSignal))
+}
+
+func TestStartError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	err := Command("dd-trace-go-does-not-exist").Run()
+	require.Error(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.NotNil(t, spans[0].Tag(ext.Error))
+}
+
+func TestEnvInjection(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "root")
+	cmd := WrapCmd(ctx, exec.CommandContext(ctx, "sh", "-c", "env"), WithEnvInjection(true))
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	root.Finish()
+
+	var env []string
+	for _, kv := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(kv, envPrefix) {
+			env = append(env, kv)
+		}
+	}
+	require.NotEmpty(t, env)
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+	spanctx, err := ExtractEnv()
+	require.NoError(t, err)
+	assert.Equal(t, root.Context().TraceID(), spanctx.TraceID())
+	assert.Equal(t, mt.FinishedSpans()[0].SpanID(), spanctx.SpanID())
+}
@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package exec
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+)
+
+const (
+	defaultSpanName = "command_execution"
+	redacted        = "?"
+)
+
+// sensitiveArg matches the names of the flags and variables whose values are
+// redacted by default.
+var sensitiveArg = regexp.MustCompile(`(?i)(pass(wd|word|phrase)?|secret|token|api[-_]?key|auth|credential|private[-_]?key)`)
+
+type config struct {
+	serviceName   string
+	spanName      string
+	analyticsRate float64
+	injectEnv     bool
+	redact        func(args []string) []string
+}
+
+// Option represents an option that can be passed to WrapCmd.
+type Option func(*config)
+
+func defaults(cfg *config) {
+	cfg.spanName = defaultSpanName
+	if internal.BoolEnv("DD_TRACE_EXEC_ANALYTICS_ENABLED", false) {
+		cfg.analyticsRate = 1.0
+	} else {
+		cfg.analyticsRate = math.NaN()
+	}
+	cfg.redact = redactArgs
+}
+
+// redactArgs returns a copy of args where the values of the sensitive flags,
+// such as --password=value or -token value, and of the sensitive variables,
+// such as API_KEY=value, are redacted.
+func redactArgs(args []string) []string {
+	out := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		switch {
+		case redactNext:
+			out[i] = redacted
+			redactNext = false
+		case strings.Contains(arg, "="):
+			k, _, _ := strings.Cut(arg, "=")
+			if sensitiveArg.MatchString(k) {
+				out[i] = k + "=" + redacted
+			} else {
+				out[i] = arg
+			}
+		default:
+			out[i] = arg
+			redactNext = i > 0 && strings.HasPrefix(arg, "-") && sensitiveArg.MatchString(arg)
+		}
+	}
+	return out
+}
+
+// WithServiceName sets the given service name for the command spans. By
+// default, the spans inherit the service of their parent.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithSpanName sets the operation name of the command spans. It defaults to
+// "command_execution".
+func WithSpanName(name string) Option {
+	return func(cfg *config) {
+		cfg.spanName = name
+	}
+}
+
+// WithRedactor sets the function returning the arguments of a command its span
+// is tagged with. It replaces the default redaction of the values of
+// the arguments which look like secrets.
+func WithRedactor(fn func(args []string) []string) Option {
+	return func(cfg *config) {
+		cfg.redact = fn
+	}
+}
+
+// WithEnvInjection enables or disables the propagation of the span context to
+// the command through its environment. See ExtractEnv.
+func WithEnvInjection(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.injectEnv = enabled
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
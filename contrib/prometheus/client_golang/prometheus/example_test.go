@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package prometheus_test
+
+import (
+	"net/http"
+	"time"
+
+	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+	prometheustrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var latency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name: "http_request_duration_seconds",
+}, []string{"path"})
+
+func Example() {
+	mux := httptrace.NewServeMux()
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		w.Write([]byte("[]"))
+		// The observation is attached an exemplar holding the trace ID of
+		// the request.
+		prometheustrace.Observe(r.Context(), latency.WithLabelValues("/users"), time.Since(start).Seconds())
+	})
+	// Exemplars are only exposed in the OpenMetrics format.
+	mux.Handle("/metrics", promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	}))
+	http.ListenAndServe(":8080", mux)
+}
@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package prometheus provides functions to correlate the metrics of the
+// prometheus/client_golang package (https://github.com/prometheus/client_golang)
+// with Datadog traces.
+//
+// The values observed by histograms and summaries, and added to counters, from
+// within a traced request are attached an OpenMetrics exemplar holding the
+// trace and span IDs of the span found in the context of the request, so that
+// the trace behind a data point can be found from Prometheus or Grafana. The
+// trace IDs are formatted in decimal, like in the dd.trace_id attribute of
+// correlated logs. Exemplars are only exposed by the OpenMetrics format, which
+// must be enabled with promhttp.HandlerOpts.EnableOpenMetrics.
+package prometheus // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/prometheus/client_golang/prometheus"
+
+import (
+	"context"
+	"strconv"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const componentName = "prometheus/client_golang/prometheus"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Names of the exemplar labels.
+const (
+	labelTraceID = "trace_id"
+	labelSpanID  = "span_id"
+)
+
+// Exemplar returns the labels of an exemplar holding the trace and span IDs of
+// the span found in ctx. It returns nil if there is no span in ctx.
+func Exemplar(ctx context.Context) prometheus.Labels {
+	span, ok := tracer.SpanFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	spanctx := span.Context()
+	if spanctx.TraceID() == 0 {
+		// The tracer isn't started.
+		return nil
+	}
+	return prometheus.Labels{
+		labelTraceID: strconv.FormatUint(spanctx.TraceID(), 10),
+		labelSpanID:  strconv.FormatUint(spanctx.SpanID(), 10),
+	}
+}
+
+// Observe adds v to o, like prometheus.Observer.Observe. The observation is
+// attached an exemplar holding the IDs of the span found in ctx, if any and if
+// o supports exemplars.
+func Observe(ctx context.Context, o prometheus.Observer, v float64) {
+	if eo, ok := o.(prometheus.ExemplarObserver); ok {
+		if labels := Exemplar(ctx); labels != nil {
+			eo.ObserveWithExemplar(v, labels)
+			return
+		}
+	}
+	o.Observe(v)
+}
+
+// Add adds v to c, like prometheus.Counter.Add. The increment is attached an
+// exemplar holding the IDs of the span found in ctx, if any and if c supports
+// exemplars.
+func Add(ctx context.Context, c prometheus.Counter, v float64) {
+	if ea, ok := c.(prometheus.ExemplarAdder); ok {
+		if labels := Exemplar(ctx); labels != nil {
+			ea.AddWithExemplar(v, labels)
+			return
+		}
+	}
+	c.Add(v)
+}
+
+// Inc increments c by 1, like prometheus.Counter.Inc. The increment is
+// attached an exemplar holding the IDs of the span found in ctx, if any and if
+// c supports exemplars.
+func Inc(ctx context.Context, c prometheus.Counter) {
+	Add(ctx, c, 1)
+}
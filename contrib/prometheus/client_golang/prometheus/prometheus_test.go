@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package prometheus
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func labelValues(e *dto.Exemplar) map[string]string {
+	m := make(map[string]string)
+	for _, l := range e.GetLabel() {
+		m[l.GetName()] = l.GetValue()
+	}
+	return m
+}
+
+func TestCounter(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "requests_total"})
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "web.request")
+	Inc(ctx, c)
+	span.Finish()
+
+	var m dto.Metric
+	require.NoError(t, c.Write(&m))
+	assert.Equal(t, 1.0, m.GetCounter().GetValue())
+	e := m.GetCounter().GetExemplar()
+	require.NotNil(t, e)
+	assert.Equal(t, map[string]string{
+		labelTraceID: strconv.FormatUint(span.Context().TraceID(), 10),
+		labelSpanID:  strconv.FormatUint(span.Context().SpanID(), 10),
+	}, labelValues(e))
+}
+
+func TestHistogram(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency_seconds", Buckets: []float64{1}})
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "web.request")
+	Observe(ctx, h, 0.5)
+	span.Finish()
+
+	var m dto.Metric
+	require.NoError(t, h.Write(&m))
+	buckets := m.GetHistogram().GetBucket()
+	require.Len(t, buckets, 1)
+	e := buckets[0].GetExemplar()
+	require.NotNil(t, e)
+	assert.Equal(t, 0.5, e.GetValue())
+	assert.Equal(t, strconv.FormatUint(span.Context().TraceID(), 10), labelValues(e)[labelTraceID])
+}
+
+func TestNoSpan(t *testing.T) {
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "requests_total"})
+	Add(context.Background(), c, 2)
+
+	var m dto.Metric
+	require.NoError(t, c.Write(&m))
+	assert.Equal(t, 2.0, m.GetCounter().GetValue())
+	assert.Nil(t, m.GetCounter().GetExemplar())
+	assert.Nil(t, Exemplar(context.Background()))
+}
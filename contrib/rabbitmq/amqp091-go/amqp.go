@@ -0,0 +1,183 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package amqp provides functions to trace the rabbitmq/amqp091-go package (https://github.com/rabbitmq/amqp091-go).
+// When Data Streams Monitoring is enabled, the published and consumed messages
+// are also checkpointed, so that they appear in the Data Streams map.
+package amqp // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/rabbitmq/amqp091-go"
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const componentName = "rabbitmq/amqp091-go"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// WrapChannel wraps an amqp.Channel so that published and consumed messages
+// are traced.
+func WrapChannel(ch *amqp.Channel, opts ...Option) *Channel {
+	wrapped := &Channel{
+		Channel: ch,
+		cfg:     newConfig(opts...),
+	}
+	log.Debug("contrib/rabbitmq/amqp091-go: Wrapping Channel: %#v", wrapped.cfg)
+	return wrapped
+}
+
+// A Channel wraps an amqp.Channel.
+type Channel struct {
+	*amqp.Channel
+	cfg *config
+}
+
+// Publish calls amqp.Channel.Publish and traces the request.
+func (c *Channel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	return c.PublishWithContext(context.Background(), exchange, key, mandatory, immediate, msg)
+}
+
+// PublishWithContext calls amqp.Channel.PublishWithContext and traces the
+// request. The span context and the Data Streams pathway are injected into
+// the headers of msg.
+func (c *Channel) PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	span, ctx := c.startPublishSpan(ctx, exchange, key, &msg)
+	err := c.Channel.PublishWithContext(ctx, exchange, key, mandatory, immediate, msg)
+	span.Finish(tracer.WithError(err))
+	return err
+}
+
+// PublishWithDeferredConfirmWithContext calls
+// amqp.Channel.PublishWithDeferredConfirmWithContext and traces the request.
+// The span context and the Data Streams pathway are injected into the headers
+// of msg.
+func (c *Channel) PublishWithDeferredConfirmWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) (*amqp.DeferredConfirmation, error) {
+	span, ctx := c.startPublishSpan(ctx, exchange, key, &msg)
+	confirm, err := c.Channel.PublishWithDeferredConfirmWithContext(ctx, exchange, key, mandatory, immediate, msg)
+	span.Finish(tracer.WithError(err))
+	return confirm, err
+}
+
+func (c *Channel) startPublishSpan(ctx context.Context, exchange, key string, msg *amqp.Publishing) (ddtrace.Span, context.Context) {
+	opts := []tracer.StartSpanOption{
+		tracer.ServiceName(c.cfg.producerServiceName),
+		tracer.ResourceName("Publish Exchange " + exchange),
+		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindProducer),
+		tracer.Tag(ext.MessagingSystem, ext.MessagingSystemRabbitMQ),
+		tracer.Tag(ext.RabbitMQExchange, exchange),
+		tracer.Tag(ext.RabbitMQRoutingKey, key),
+	}
+	if !math.IsNaN(c.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
+	}
+	if msg.Headers == nil {
+		msg.Headers = amqp.Table{}
+	}
+	carrier := tableCarrier(msg.Headers)
+	span, ctx := tracer.StartSpanFromContext(ctx, c.cfg.producerSpanName, opts...)
+	if err := tracer.Inject(span.Context(), carrier); err != nil {
+		log.Debug("contrib/rabbitmq/amqp091-go: Failed to inject span context into carrier, %v", err)
+	}
+	if p := datastreams.GetGlobalProcessor(); p != nil {
+		ctx = p.SetCheckpointWithParams(ctx,
+			datastreams.CheckpointParams{PayloadSize: int64(len(msg.Body))},
+			"direction:out",
+			"exchange:"+exchange,
+			"has_routing_key:"+strconv.FormatBool(key != ""),
+			"type:rabbitmq",
+		)
+		datastreams.InjectToBase64Carrier(ctx, carrier)
+	}
+	return span, ctx
+}
+
+// Consume calls amqp.Channel.Consume and traces every delivery read from the
+// returned channel. The span context and the Data Streams pathway of the
+// consumer are injected into the headers of each delivery, so that they can be
+// retrieved using ExtractSpanContext and ContextWithDelivery.
+func (c *Channel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	deliveries, err := c.Channel.Consume(queue, consumer, autoAck, exclusive, noLocal, noWait, args)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan amqp.Delivery)
+	go func() {
+		defer close(out)
+		for d := range deliveries {
+			c.traceDelivery(queue, &d)
+			out <- d
+		}
+	}()
+	return out, nil
+}
+
+// Get calls amqp.Channel.Get and traces the delivery, if any.
+func (c *Channel) Get(queue string, autoAck bool) (amqp.Delivery, bool, error) {
+	d, ok, err := c.Channel.Get(queue, autoAck)
+	if ok && err == nil {
+		c.traceDelivery(queue, &d)
+	}
+	return d, ok, err
+}
+
+// traceDelivery records the consumption of d from queue, and re-injects the
+// resulting span context and pathway into its headers.
+func (c *Channel) traceDelivery(queue string, d *amqp.Delivery) {
+	opts := []tracer.StartSpanOption{
+		tracer.ServiceName(c.cfg.consumerServiceName),
+		tracer.ResourceName("Consume Queue " + queue),
+		tracer.SpanType(ext.SpanTypeMessageConsumer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindConsumer),
+		tracer.Tag(ext.MessagingSystem, ext.MessagingSystemRabbitMQ),
+		tracer.Tag(ext.RabbitMQQueue, queue),
+		tracer.Tag(ext.RabbitMQExchange, d.Exchange),
+		tracer.Tag(ext.RabbitMQRoutingKey, d.RoutingKey),
+		tracer.Measured(),
+	}
+	if d.ConsumerTag != "" {
+		opts = append(opts, tracer.Tag(ext.RabbitMQConsumerTag, d.ConsumerTag))
+	}
+	if !math.IsNaN(c.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
+	}
+	if d.Headers == nil {
+		d.Headers = amqp.Table{}
+	}
+	carrier := tableCarrier(d.Headers)
+	if spanctx, err := tracer.Extract(carrier); err == nil {
+		opts = append(opts, tracer.ChildOf(spanctx))
+	}
+	span := tracer.StartSpan(c.cfg.consumerSpanName, opts...)
+	// reinject the span context so consumers can pick it up
+	if err := tracer.Inject(span.Context(), carrier); err != nil {
+		log.Debug("contrib/rabbitmq/amqp091-go: Failed to inject span context into carrier, %v", err)
+	}
+	span.Finish()
+	if p := datastreams.GetGlobalProcessor(); p != nil {
+		ctx := datastreams.ExtractFromBase64Carrier(context.Background(), carrier)
+		ctx = p.SetCheckpointWithParams(ctx,
+			datastreams.CheckpointParams{PayloadSize: int64(len(d.Body))},
+			"direction:in",
+			"topic:"+queue,
+			"type:rabbitmq",
+		)
+		datastreams.InjectToBase64Carrier(ctx, carrier)
+	}
+}
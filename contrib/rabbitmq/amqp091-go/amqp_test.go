@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package amqp
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishConsume(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := &Channel{cfg: newConfig()}
+	msg := amqp.Publishing{Body: []byte("hello")}
+	span, _ := c.startPublishSpan(context.Background(), "exchange", "key", &msg)
+	span.Finish()
+
+	d := amqp.Delivery{
+		Headers:     msg.Headers,
+		Exchange:    "exchange",
+		RoutingKey:  "key",
+		ConsumerTag: "consumer",
+		Body:        msg.Body,
+	}
+	c.traceDelivery("queue", &d)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	s0, s1 := spans[0], spans[1]
+	assert.Equal(t, "amqp.publish", s0.OperationName())
+	assert.Equal(t, "Publish Exchange exchange", s0.Tag(ext.ResourceName))
+	assert.Equal(t, ext.SpanKindProducer, s0.Tag(ext.SpanKind))
+	assert.Equal(t, ext.MessagingSystemRabbitMQ, s0.Tag(ext.MessagingSystem))
+	assert.Equal(t, "exchange", s0.Tag(ext.RabbitMQExchange))
+	assert.Equal(t, "key", s0.Tag(ext.RabbitMQRoutingKey))
+	assert.Equal(t, componentName, s0.Tag(ext.Component))
+
+	assert.Equal(t, "amqp.consume", s1.OperationName())
+	assert.Equal(t, "Consume Queue queue", s1.Tag(ext.ResourceName))
+	assert.Equal(t, ext.SpanKindConsumer, s1.Tag(ext.SpanKind))
+	assert.Equal(t, "queue", s1.Tag(ext.RabbitMQQueue))
+	assert.Equal(t, "consumer", s1.Tag(ext.RabbitMQConsumerTag))
+	assert.Equal(t, s0.SpanID(), s1.ParentID())
+	assert.Equal(t, s0.TraceID(), s1.TraceID())
+
+	spanctx, err := ExtractSpanContext(d)
+	require.NoError(t, err)
+	assert.Equal(t, s1.SpanID(), spanctx.SpanID())
+}
+
+func TestDataStreams(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	p := datastreams.NewProcessor(&statsd.NoOpClient{}, "env", "service", &url.URL{Scheme: "http", Host: "agent-address"}, nil)
+	datastreams.SetGlobalProcessor(p)
+	defer datastreams.SetGlobalProcessor(nil)
+
+	c := &Channel{cfg: newConfig()}
+	msg := amqp.Publishing{Body: []byte("hello")}
+	span, ctx := c.startPublishSpan(context.Background(), "exchange", "key", &msg)
+	span.Finish()
+	produced, ok := datastreams.PathwayFromContext(ctx)
+	require.True(t, ok)
+	assert.Contains(t, msg.Headers, datastreams.PropagationKeyBase64)
+
+	d := amqp.Delivery{Headers: msg.Headers, Body: msg.Body}
+	c.traceDelivery("queue", &d)
+	consumed, ok := datastreams.PathwayFromContext(ContextWithDelivery(context.Background(), d))
+	require.True(t, ok)
+	assert.NotEqual(t, produced.GetHash(), consumed.GetHash())
+	assert.Equal(t, produced.PathwayStart(), consumed.PathwayStart())
+}
+
+func TestNoDataStreams(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := &Channel{cfg: newConfig()}
+	msg := amqp.Publishing{}
+	span, ctx := c.startPublishSpan(context.Background(), "exchange", "", &msg)
+	span.Finish()
+	_, ok := datastreams.PathwayFromContext(ctx)
+	assert.False(t, ok)
+	assert.NotContains(t, msg.Headers, datastreams.PropagationKeyBase64)
+}
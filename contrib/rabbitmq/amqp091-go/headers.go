@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package amqp
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// A tableCarrier implements TextMapReader/TextMapWriter for extracting/injecting
+// traces and Data Streams pathways on the headers of an amqp message.
+type tableCarrier amqp.Table
+
+var _ interface {
+	tracer.TextMapReader
+	tracer.TextMapWriter
+} = (*tableCarrier)(nil)
+
+// ForeachKey conforms to the TextMapReader interface.
+func (c tableCarrier) ForeachKey(handler func(key, val string) error) error {
+	for k, v := range c {
+		s, ok := v.(string)
+		if !ok {
+			// only string headers are set by the tracer
+			continue
+		}
+		if err := handler(k, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set implements TextMapWriter
+func (c tableCarrier) Set(key, val string) {
+	c[key] = val
+}
+
+// ExtractSpanContext retrieves the SpanContext from an amqp.Delivery.
+func ExtractSpanContext(d amqp.Delivery) (ddtrace.SpanContext, error) {
+	return tracer.Extract(tableCarrier(d.Headers))
+}
+
+// ContextWithDelivery returns a copy of ctx holding the Data Streams pathway
+// found in the headers of d, if any. Passing the returned context to
+// Channel.PublishWithContext links the published messages to the consumed one
+// in the Data Streams map.
+func ContextWithDelivery(ctx context.Context, d amqp.Delivery) context.Context {
+	return datastreams.ExtractFromBase64Carrier(ctx, tableCarrier(d.Headers))
+}
@@ -0,0 +1,170 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// clusterSlots is the number of hash slots of a Redis Cluster.
+const clusterSlots = 16384
+
+// clusterSpanKey is the context key holding the span of a command processed
+// by a cluster client.
+type clusterSpanKey struct{}
+
+// clusterPipelineSpanKey is the context key holding the span of a pipeline
+// processed by a cluster client.
+type clusterPipelineSpanKey struct{}
+
+// nodeHook is added to the clients of the nodes of a traced cluster. It tags
+// the spans of the cluster client with the address of the node which processed
+// the commands.
+type nodeHook struct {
+	*params
+	host, port string
+}
+
+func newNodeHook(p *params, addr string) *nodeHook {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+		port = "6379"
+	}
+	return &nodeHook{params: p, host: host, port: port}
+}
+
+func (h *nodeHook) DialHook(hook redis.DialHook) redis.DialHook {
+	return hook
+}
+
+func (h *nodeHook) ProcessHook(hook redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if span, ok := ctx.Value(clusterSpanKey{}).(ddtrace.Span); ok {
+			// commands redirected to another node are processed again,
+			// the last node processing the command wins.
+			span.SetTag(ext.TargetHost, h.host)
+			span.SetTag(ext.TargetPort, h.port)
+		}
+		return hook(ctx, cmd)
+	}
+}
+
+// ProcessPipelineHook traces the batch of commands of a cluster pipeline sent
+// to the node, as a child of the span of the pipeline.
+func (h *nodeHook) ProcessPipelineHook(hook redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if _, ok := ctx.Value(clusterPipelineSpanKey{}).(ddtrace.Span); !ok {
+			return hook(ctx, cmds)
+		}
+		p := h.params
+		startOpts := make([]ddtrace.StartSpanOption, 0, 5+1+len(p.additionalTags)+1) // 5 options below + redis.raw_command + p.additionalTags + analyticsRate
+		startOpts = append(startOpts,
+			tracer.ServiceName(p.config.serviceName),
+			tracer.ResourceName("redis.pipeline"),
+			tracer.Tag("redis.pipeline_length", strconv.Itoa(len(cmds))),
+			tracer.Tag("redis.pipeline_commands", commandsBreakdown(cmds)),
+			tracer.Tag(ext.TargetHost, h.host),
+			tracer.Tag(ext.TargetPort, h.port),
+		)
+		if !p.config.skipRaw {
+			startOpts = append(startOpts, tracer.Tag("redis.raw_command", commandsToString(cmds)))
+		}
+		startOpts = append(startOpts, p.additionalTags...)
+		if !math.IsNaN(p.config.analyticsRate) {
+			startOpts = append(startOpts, tracer.Tag(ext.EventSampleRate, p.config.analyticsRate))
+		}
+		span, ctx := tracer.StartSpanFromContext(ctx, p.config.spanName, startOpts...)
+
+		err := hook(ctx, cmds)
+
+		var finishOpts []ddtrace.FinishOption
+		if err != nil && err != redis.Nil && p.config.errCheck(err) {
+			finishOpts = append(finishOpts, tracer.WithError(err))
+		}
+		span.Finish(finishOpts...)
+		return err
+	}
+}
+
+// cmdSlot returns the hash slot of the first key of cmd. It reports false if
+// the key of the command can't be found.
+func cmdSlot(cmd redis.Cmder) (int, bool) {
+	pos := cmdFirstKeyPos(cmd)
+	args := cmd.Args()
+	if pos <= 0 || pos >= len(args) {
+		return 0, false
+	}
+	return keySlot(fmt.Sprint(args[pos])), true
+}
+
+// cmdFirstKeyPos returns the position of the first key in the arguments of
+// cmd, or 0 if the command has no key. The position is guessed from the name
+// of the command, as the command info is only known by the cluster client.
+func cmdFirstKeyPos(cmd redis.Cmder) int {
+	args := cmd.Args()
+	switch cmd.Name() {
+	case "eval", "evalsha", "eval_ro", "evalsha_ro", "fcall", "fcall_ro":
+		if len(args) > 2 && fmt.Sprint(args[2]) != "0" {
+			return 3
+		}
+		return 0
+	case "xread", "xreadgroup":
+		for i, arg := range args {
+			if s, ok := arg.(string); ok && strings.EqualFold(s, "streams") {
+				return i + 1
+			}
+		}
+		return 0
+	case "memory":
+		if len(args) > 2 && strings.EqualFold(fmt.Sprint(args[1]), "usage") {
+			return 2
+		}
+		return 0
+	}
+	if len(args) > 1 {
+		return 1
+	}
+	return 0
+}
+
+// keySlot returns the hash slot of key, honoring hash tags.
+func keySlot(key string) int {
+	if s := strings.IndexByte(key, '{'); s > -1 {
+		if e := strings.IndexByte(key[s+1:], '}'); e > 0 {
+			key = key[s+1 : s+e+1]
+		}
+	}
+	return int(crc16(key)) % clusterSlots
+}
+
+// crc16 implements the CRC16-CCITT (XMODEM) checksum used by Redis Cluster
+// to compute the hash slots.
+func crc16(key string) uint16 {
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -11,6 +11,7 @@ import (
 	"context"
 	"math"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -36,6 +37,7 @@ type datadogHook struct {
 type params struct {
 	config         *clientConfig
 	additionalTags []ddtrace.StartSpanOption
+	cluster        bool // whether the client is a cluster client
 }
 
 // NewClient returns a new Client that is traced with the default tracer under
@@ -47,7 +49,10 @@ func NewClient(opt *redis.Options, opts ...ClientOption) redis.UniversalClient {
 }
 
 // WrapClient adds a hook to the given client that traces with the default tracer under
-// the service name "redis".
+// the service name "redis". The spans of a *redis.ClusterClient are tagged with
+// the hash slot of the command and the address of the node which processed it,
+// and a child span is started for the batch of commands of a pipeline sent to
+// each node, for the nodes created after the client is wrapped.
 func WrapClient(client redis.UniversalClient, opts ...ClientOption) {
 	cfg := new(clientConfig)
 	defaults(cfg)
@@ -60,6 +65,14 @@ func WrapClient(client redis.UniversalClient, opts ...ClientOption) {
 		config:         cfg,
 	}
 
+	if cluster, ok := client.(*redis.ClusterClient); ok {
+		// The commands are processed by the client of each node of the
+		// cluster, whose hook tags the spans with the topology of the cluster.
+		hookParams.cluster = true
+		cluster.OnNewNode(func(node *redis.Client) {
+			node.AddHook(newNodeHook(hookParams, node.Options().Addr))
+		})
+	}
 	client.AddHook(&datadogHook{params: hookParams})
 }
 
@@ -150,8 +163,15 @@ func (ddh *datadogHook) ProcessHook(hook redis.ProcessHook) redis.ProcessHook {
 			startOpts = append(startOpts, tracer.Tag(ext.EventSampleRate, p.config.analyticsRate))
 		}
 		span, ctx := tracer.StartSpanFromContext(ctx, p.config.spanName, startOpts...)
+		if p.cluster {
+			if slot, ok := cmdSlot(cmd); ok {
+				span.SetTag("redis.cluster_slot", strconv.Itoa(slot))
+			}
+			ctx = context.WithValue(ctx, clusterSpanKey{}, span)
+		}
 
 		err := hook(ctx, cmd)
+		setStreamCheckpoints(ctx, cmd)
 
 		var finishOpts []ddtrace.FinishOption
 		if err != nil && err != redis.Nil && ddh.config.errCheck(err) {
@@ -165,11 +185,12 @@ func (ddh *datadogHook) ProcessHook(hook redis.ProcessHook) redis.ProcessHook {
 func (ddh *datadogHook) ProcessPipelineHook(hook redis.ProcessPipelineHook) redis.ProcessPipelineHook {
 	return func(ctx context.Context, cmds []redis.Cmder) error {
 		p := ddh.params
-		startOpts := make([]ddtrace.StartSpanOption, 0, 3+1+len(ddh.additionalTags)+1) // 3 options below + redis.raw_command + ddh.additionalTags + analyticsRate
+		startOpts := make([]ddtrace.StartSpanOption, 0, 4+1+len(ddh.additionalTags)+1) // 4 options below + redis.raw_command + ddh.additionalTags + analyticsRate
 		startOpts = append(startOpts,
 			tracer.ServiceName(p.config.serviceName),
 			tracer.ResourceName("redis.pipeline"),
 			tracer.Tag("redis.pipeline_length", strconv.Itoa(len(cmds))),
+			tracer.Tag("redis.pipeline_commands", commandsBreakdown(cmds)),
 		)
 		if !p.config.skipRaw {
 			raw := commandsToString(cmds)
@@ -180,8 +201,12 @@ func (ddh *datadogHook) ProcessPipelineHook(hook redis.ProcessPipelineHook) redi
 			startOpts = append(startOpts, tracer.Tag(ext.EventSampleRate, p.config.analyticsRate))
 		}
 		span, ctx := tracer.StartSpanFromContext(ctx, p.config.spanName, startOpts...)
+		if p.cluster {
+			ctx = context.WithValue(ctx, clusterPipelineSpanKey{}, span)
+		}
 
 		err := hook(ctx, cmds)
+		setStreamCheckpoints(ctx, cmds...)
 
 		var finishOpts []ddtrace.FinishOption
 		if err != nil && err != redis.Nil && ddh.config.errCheck(err) {
@@ -192,6 +217,30 @@ func (ddh *datadogHook) ProcessPipelineHook(hook redis.ProcessPipelineHook) redi
 	}
 }
 
+// commandsBreakdown returns the number of occurrences of each command of a
+// pipeline, sorted by command name, e.g. "get:2,set:1".
+func commandsBreakdown(cmds []redis.Cmder) string {
+	counts := make(map[string]int)
+	for _, cmd := range cmds {
+		counts[cmd.Name()]++
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(counts[name]))
+	}
+	return b.String()
+}
+
 // commandsToString returns a string representation of a slice of redis Commands, separated by newlines.
 func commandsToString(cmds []redis.Cmder) string {
 	var b bytes.Buffer
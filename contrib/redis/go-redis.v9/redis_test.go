@@ -351,11 +351,70 @@ func TestPipeline(t *testing.T) {
 	assert.Equal("my-redis", span.Tag(ext.ServiceName))
 	assert.Equal("redis.pipeline", span.Tag(ext.ResourceName))
 	assert.Equal("2", span.Tag("redis.pipeline_length"))
+	assert.Equal("expire:2", span.Tag("redis.pipeline_commands"))
 	assert.Equal("redis/go-redis.v9", span.Tag(ext.Component))
 	assert.Equal(ext.SpanKindClient, span.Tag(ext.SpanKind))
 	assert.Equal("redis", span.Tag(ext.DBSystem))
 }
 
+func TestPipelineCommands(t *testing.T) {
+	ctx := context.Background()
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	client := NewClient(&redis.Options{Addr: "127.0.0.1:6379"})
+	pipeline := client.Pipeline()
+	pipeline.Set(ctx, "pipeline_key", "value", time.Hour)
+	pipeline.Get(ctx, "pipeline_key")
+	pipeline.Get(ctx, "pipeline_key")
+	pipeline.Exec(ctx)
+
+	var span mocktracer.Span
+	for _, s := range mt.FinishedSpans() {
+		if s.Tag(ext.ResourceName) == "redis.pipeline" {
+			span = s
+		}
+	}
+	require.NotNil(t, span)
+	assert.Equal(t, "3", span.Tag("redis.pipeline_length"))
+	assert.Equal(t, "get:2,set:1", span.Tag("redis.pipeline_commands"))
+}
+
+func TestKeySlot(t *testing.T) {
+	assert.Equal(t, 12182, keySlot("foo"))
+	assert.Equal(t, keySlot("user1000"), keySlot("{user1000}.following"))
+	assert.Equal(t, 0, keySlot(""))
+}
+
+func TestCmdSlot(t *testing.T) {
+	ctx := context.Background()
+	for _, tt := range []struct {
+		cmd  redis.Cmder
+		key  string
+		keys bool
+	}{
+		{cmd: redis.NewStringCmd(ctx, "get", "foo"), key: "foo", keys: true},
+		{cmd: redis.NewCmd(ctx, "evalsha", "sha", 1, "foo"), key: "foo", keys: true},
+		{cmd: redis.NewCmd(ctx, "evalsha", "sha", 0), keys: false},
+		{cmd: redis.NewXStreamSliceCmd(ctx, "xreadgroup", "group", "g", "c", "count", 1, "streams", "foo", ">"), key: "foo", keys: true},
+		{cmd: redis.NewStatusCmd(ctx, "ping"), keys: false},
+	} {
+		t.Run(tt.cmd.Name(), func(t *testing.T) {
+			slot, ok := cmdSlot(tt.cmd)
+			assert.Equal(t, tt.keys, ok)
+			if tt.keys {
+				assert.Equal(t, keySlot(tt.key), slot)
+			}
+		})
+	}
+}
+
+func TestXAddPayloadSize(t *testing.T) {
+	assert.Equal(t, int64(7), xaddPayloadSize([]interface{}{"xadd", "stream", "*", "key", "val1"}))
+	assert.Equal(t, int64(7), xaddPayloadSize([]interface{}{"xadd", "stream", "nomkstream", "maxlen", "~", 10, "limit", 5, "1-1", "key", "val1"}))
+	assert.Equal(t, int64(7), xaddPayloadSize([]interface{}{"xadd", "stream", "minid", "0-1", "*", "key", "val1"}))
+}
+
 func TestChildSpan(t *testing.T) {
 	ctx := context.Background()
 	opts := &redis.Options{Addr: "127.0.0.1:6379"}
@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// valuesCarrier implements TextMapReader for extracting Data Streams pathways
+// out of the values of a stream message.
+type valuesCarrier map[string]interface{}
+
+var _ datastreams.TextMapReader = (*valuesCarrier)(nil)
+
+// ForeachKey conforms to the TextMapReader interface.
+func (c valuesCarrier) ForeachKey(handler func(key, val string) error) error {
+	for k, v := range c {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if err := handler(k, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setStreamCheckpoints sets the Data Streams checkpoints of the messages added
+// to streams with XADD and read from them with XREADGROUP.
+func setStreamCheckpoints(ctx context.Context, cmds ...redis.Cmder) {
+	p := datastreams.GetGlobalProcessor()
+	if p == nil {
+		return
+	}
+	for _, cmd := range cmds {
+		if cmd.Err() != nil {
+			continue
+		}
+		switch cmd.Name() {
+		case "xadd":
+			args := cmd.Args()
+			if len(args) < 2 {
+				continue
+			}
+			p.SetCheckpointWithParams(ctx,
+				datastreams.CheckpointParams{PayloadSize: xaddPayloadSize(args)},
+				"direction:out",
+				"topic:"+fmt.Sprint(args[1]),
+				"type:redis",
+			)
+		case "xreadgroup":
+			c, ok := cmd.(*redis.XStreamSliceCmd)
+			if !ok || len(c.Args()) < 3 {
+				continue
+			}
+			group := fmt.Sprint(c.Args()[2])
+			for _, stream := range c.Val() {
+				for _, msg := range stream.Messages {
+					ctx := datastreams.ExtractFromBase64Carrier(context.Background(), valuesCarrier(msg.Values))
+					p.SetCheckpointWithParams(ctx,
+						datastreams.CheckpointParams{PayloadSize: valuesSize(msg.Values)},
+						"direction:in",
+						"group:"+group,
+						"topic:"+stream.Stream,
+						"type:redis",
+					)
+				}
+			}
+		}
+	}
+}
+
+// xaddPayloadSize returns the size of the field-value pairs of the message
+// added by the arguments of a XADD command.
+func xaddPayloadSize(args []interface{}) int64 {
+	// skip the options preceding the id of the message
+	i := 2
+options:
+	for i < len(args) {
+		switch strings.ToLower(fmt.Sprint(args[i])) {
+		case "nomkstream":
+			i++
+		case "maxlen", "minid":
+			i++
+			if i < len(args) {
+				if op := fmt.Sprint(args[i]); op == "~" || op == "=" {
+					i++
+				}
+			}
+			i++
+		case "limit":
+			i += 2
+		default:
+			break options
+		}
+	}
+	var size int64
+	for i++; i < len(args); i++ {
+		size += int64(len(fmt.Sprint(args[i])))
+	}
+	return size
+}
+
+// valuesSize returns the size of the field-value pairs of a stream message.
+func valuesSize(values map[string]interface{}) int64 {
+	var size int64
+	for k, v := range values {
+		size += int64(len(k) + len(fmt.Sprint(v)))
+	}
+	return size
+}
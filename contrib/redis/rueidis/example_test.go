@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package rueidis_test
+
+import (
+	"context"
+	"log"
+	"time"
+
+	rueidistrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/redis/rueidis"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/redis/rueidis"
+)
+
+// To start tracing Redis commands, create a client using NewClient.
+func Example() {
+	tracer.Start()
+	defer tracer.Stop()
+
+	client, err := rueidistrace.NewClient(rueidis.ClientOption{InitAddress: []string{"127.0.0.1:6379"}},
+		rueidistrace.WithServiceName("my-redis"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	// Spans are children of the span in the context, if any.
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "parent.request")
+	defer span.Finish()
+
+	client.Do(ctx, client.B().Set().Key("key").Value("value").Build())
+	// The span of cached commands is tagged with redis.client_cache.hit.
+	client.DoCache(ctx, client.B().Get().Key("key").Cache(), time.Minute)
+}
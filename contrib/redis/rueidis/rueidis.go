@@ -0,0 +1,308 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package rueidis provides functions to trace the redis/rueidis package (https://github.com/redis/rueidis).
+package rueidis // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/redis/rueidis"
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/redis/rueidis"
+)
+
+const componentName = "redis/rueidis"
+
+const (
+	// tagClientCacheHit reports whether the response to a command sent with
+	// DoCache was served from the client-side cache.
+	tagClientCacheHit = "redis.client_cache.hit"
+	// tagClientCacheHits is the number of the responses to the commands sent
+	// with DoMultiCache served from the client-side cache.
+	tagClientCacheHits = "redis.client_cache.hits"
+	// tagClientCacheMisses is the number of the responses to the commands sent
+	// with DoMultiCache not served from the client-side cache.
+	tagClientCacheMisses = "redis.client_cache.misses"
+)
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// params holds the configuration and a set of tags which are recorded with every span.
+type params struct {
+	config         *clientConfig
+	additionalTags []ddtrace.StartSpanOption
+}
+
+// NewClient returns a new Client that is traced with the default tracer under
+// the service name "redis.client".
+func NewClient(option rueidis.ClientOption, opts ...ClientOption) (rueidis.Client, error) {
+	c, err := rueidis.NewClient(option)
+	if err != nil {
+		return nil, err
+	}
+	cfg := newConfig(opts)
+	var tags []ddtrace.StartSpanOption
+	if len(option.InitAddress) == 1 {
+		tags = addrTags(option.InitAddress[0])
+	} else {
+		tags = []ddtrace.StartSpanOption{tracer.Tag("addrs", strings.Join(option.InitAddress, ", "))}
+	}
+	tags = append(tags, tracer.Tag("out.db", strconv.Itoa(option.SelectDB)))
+	return wrap(c, cfg, tags), nil
+}
+
+// WrapClient returns a traced version of the given client, traced with the
+// default tracer under the service name "redis.client".
+func WrapClient(c rueidis.Client, opts ...ClientOption) rueidis.Client {
+	return wrap(c, newConfig(opts), nil)
+}
+
+func newConfig(opts []ClientOption) *clientConfig {
+	cfg := new(clientConfig)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return cfg
+}
+
+func wrap(c rueidis.Client, cfg *clientConfig, tags []ddtrace.StartSpanOption) *client {
+	tags = append(tags,
+		tracer.ServiceName(cfg.serviceName),
+		tracer.SpanType(ext.SpanTypeRedis),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		tracer.Tag(ext.DBSystem, ext.DBSystemRedis),
+	)
+	if !math.IsNaN(cfg.analyticsRate) {
+		tags = append(tags, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	return &client{Client: c, params: &params{config: cfg, additionalTags: tags}}
+}
+
+// addrTags returns the tags of the host and port of the address addr.
+func addrTags(addr string) []ddtrace.StartSpanOption {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+		port = "6379"
+	}
+	return []ddtrace.StartSpanOption{
+		tracer.Tag(ext.TargetHost, host),
+		tracer.Tag(ext.TargetPort, port),
+	}
+}
+
+// startSpan starts the span of a command. It must be called before the
+// command is sent, as rueidis recycles the commands it sent.
+func (p *params) startSpan(ctx context.Context, cmd []string, opts ...ddtrace.StartSpanOption) ddtrace.Span {
+	var resource string
+	if len(cmd) > 0 {
+		resource = cmd[0]
+	}
+	opts = append(opts,
+		tracer.ResourceName(resource),
+		tracer.Tag("redis.args_length", strconv.Itoa(len(cmd)-1)),
+	)
+	if !p.config.skipRaw {
+		opts = append(opts, tracer.Tag("redis.raw_command", strings.Join(cmd, " ")))
+	}
+	return p.start(ctx, opts...)
+}
+
+// startPipelineSpan starts the span of a batch of commands. It must be called
+// before the commands are sent.
+func (p *params) startPipelineSpan(ctx context.Context, cmds [][]string, opts ...ddtrace.StartSpanOption) ddtrace.Span {
+	opts = append(opts,
+		tracer.ResourceName("redis.pipeline"),
+		tracer.Tag("redis.pipeline_length", strconv.Itoa(len(cmds))),
+	)
+	if !p.config.skipRaw {
+		raw := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			raw[i] = strings.Join(cmd, " ")
+		}
+		opts = append(opts, tracer.Tag("redis.raw_command", strings.Join(raw, "\n")))
+	}
+	return p.start(ctx, opts...)
+}
+
+func (p *params) start(ctx context.Context, opts ...ddtrace.StartSpanOption) ddtrace.Span {
+	opts = append(opts, p.additionalTags...)
+	span, _ := tracer.StartSpanFromContext(ctx, p.config.spanName, opts...)
+	return span
+}
+
+// finishSpan finishes span with the error err, unless it is a nil response.
+func (p *params) finishSpan(span ddtrace.Span, err error) {
+	var opts []ddtrace.FinishOption
+	if err != nil && !rueidis.IsRedisNil(err) && p.config.errCheck(err) {
+		opts = append(opts, tracer.WithError(err))
+	}
+	span.Finish(opts...)
+}
+
+// firstError returns the first error of the results of a batch of commands.
+func firstError(results []rueidis.RedisResult) error {
+	for _, r := range results {
+		if err := r.Error(); err != nil && !rueidis.IsRedisNil(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func multiCommands(multi []rueidis.Completed) [][]string {
+	cmds := make([][]string, len(multi))
+	for i := range multi {
+		cmds[i] = multi[i].Commands()
+	}
+	return cmds
+}
+
+func (p *params) do(ctx context.Context, cmd rueidis.Completed, do func(context.Context, rueidis.Completed) rueidis.RedisResult) rueidis.RedisResult {
+	span := p.startSpan(ctx, cmd.Commands())
+	resp := do(ctx, cmd)
+	p.finishSpan(span, resp.Error())
+	return resp
+}
+
+func (p *params) doMulti(ctx context.Context, multi []rueidis.Completed, do func(context.Context, ...rueidis.Completed) []rueidis.RedisResult) []rueidis.RedisResult {
+	span := p.startPipelineSpan(ctx, multiCommands(multi))
+	resps := do(ctx, multi...)
+	p.finishSpan(span, firstError(resps))
+	return resps
+}
+
+func (p *params) receive(ctx context.Context, subscribe rueidis.Completed, fn func(rueidis.PubSubMessage), receive func(context.Context, rueidis.Completed, func(rueidis.PubSubMessage)) error) error {
+	span := p.startSpan(ctx, subscribe.Commands())
+	err := receive(ctx, subscribe, fn)
+	p.finishSpan(span, err)
+	return err
+}
+
+// client is a traced rueidis.Client.
+type client struct {
+	rueidis.Client
+	*params
+}
+
+// Do implements rueidis.Client.
+func (c *client) Do(ctx context.Context, cmd rueidis.Completed) rueidis.RedisResult {
+	return c.do(ctx, cmd, c.Client.Do)
+}
+
+// DoMulti implements rueidis.Client.
+func (c *client) DoMulti(ctx context.Context, multi ...rueidis.Completed) []rueidis.RedisResult {
+	return c.doMulti(ctx, multi, c.Client.DoMulti)
+}
+
+// Receive implements rueidis.Client. The span covers the whole subscription.
+func (c *client) Receive(ctx context.Context, subscribe rueidis.Completed, fn func(msg rueidis.PubSubMessage)) error {
+	return c.receive(ctx, subscribe, fn, c.Client.Receive)
+}
+
+// DoCache implements rueidis.Client.
+func (c *client) DoCache(ctx context.Context, cmd rueidis.Cacheable, ttl time.Duration) rueidis.RedisResult {
+	span := c.startSpan(ctx, cmd.Commands(), tracer.Tag("redis.client_cache.ttl", ttl.String()))
+	resp := c.Client.DoCache(ctx, cmd, ttl)
+	span.SetTag(tagClientCacheHit, resp.IsCacheHit())
+	c.finishSpan(span, resp.Error())
+	return resp
+}
+
+// DoMultiCache implements rueidis.Client.
+func (c *client) DoMultiCache(ctx context.Context, multi ...rueidis.CacheableTTL) []rueidis.RedisResult {
+	cmds := make([][]string, len(multi))
+	for i := range multi {
+		cmds[i] = multi[i].Cmd.Commands()
+	}
+	span := c.startPipelineSpan(ctx, cmds)
+	resps := c.Client.DoMultiCache(ctx, multi...)
+	var hits int
+	for _, r := range resps {
+		if r.IsCacheHit() {
+			hits++
+		}
+	}
+	span.SetTag(tagClientCacheHits, hits)
+	span.SetTag(tagClientCacheMisses, len(resps)-hits)
+	c.finishSpan(span, firstError(resps))
+	return resps
+}
+
+// DoStream implements rueidis.Client. The span does not cover the reading of
+// the response from the stream.
+func (c *client) DoStream(ctx context.Context, cmd rueidis.Completed) rueidis.RedisResultStream {
+	span := c.startSpan(ctx, cmd.Commands())
+	s := c.Client.DoStream(ctx, cmd)
+	c.finishSpan(span, s.Error())
+	return s
+}
+
+// DoMultiStream implements rueidis.Client. The span does not cover the
+// reading of the responses from the stream.
+func (c *client) DoMultiStream(ctx context.Context, multi ...rueidis.Completed) rueidis.MultiRedisResultStream {
+	span := c.startPipelineSpan(ctx, multiCommands(multi))
+	s := c.Client.DoMultiStream(ctx, multi...)
+	c.finishSpan(span, s.Error())
+	return s
+}
+
+// Dedicated implements rueidis.Client. The client passed to fn is traced.
+func (c *client) Dedicated(fn func(rueidis.DedicatedClient) error) error {
+	return c.Client.Dedicated(func(dc rueidis.DedicatedClient) error {
+		return fn(&dedicatedClient{DedicatedClient: dc, params: c.params})
+	})
+}
+
+// Dedicate implements rueidis.Client. The returned client is traced.
+func (c *client) Dedicate() (rueidis.DedicatedClient, func()) {
+	dc, cancel := c.Client.Dedicate()
+	return &dedicatedClient{DedicatedClient: dc, params: c.params}, cancel
+}
+
+// Nodes implements rueidis.Client. The returned clients are traced.
+func (c *client) Nodes() map[string]rueidis.Client {
+	nodes := c.Client.Nodes()
+	traced := make(map[string]rueidis.Client, len(nodes))
+	for addr, node := range nodes {
+		traced[addr] = wrap(node, c.config, addrTags(addr))
+	}
+	return traced
+}
+
+// dedicatedClient is a traced rueidis.DedicatedClient.
+type dedicatedClient struct {
+	rueidis.DedicatedClient
+	*params
+}
+
+// Do implements rueidis.DedicatedClient.
+func (c *dedicatedClient) Do(ctx context.Context, cmd rueidis.Completed) rueidis.RedisResult {
+	return c.do(ctx, cmd, c.DedicatedClient.Do)
+}
+
+// DoMulti implements rueidis.DedicatedClient.
+func (c *dedicatedClient) DoMulti(ctx context.Context, multi ...rueidis.Completed) []rueidis.RedisResult {
+	return c.doMulti(ctx, multi, c.DedicatedClient.DoMulti)
+}
+
+// Receive implements rueidis.DedicatedClient. The span covers the whole
+// subscription.
+func (c *dedicatedClient) Receive(ctx context.Context, subscribe rueidis.Completed, fn func(msg rueidis.PubSubMessage)) error {
+	return c.receive(ctx, subscribe, fn, c.DedicatedClient.Receive)
+}
@@ -0,0 +1,296 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package rueidis
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// builder is the command builder of a single client. Such a client is created
+// even though it fails to connect.
+var builder = func() rueidis.Builder {
+	c, _ := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:       []string{"127.0.0.1:6379"},
+		ForceSingleClient: true,
+		DialCtxFn: func(context.Context, string, *net.Dialer, *tls.Config) (net.Conn, error) {
+			return nil, errors.New("no connection")
+		},
+	})
+	defer c.Close()
+	return c.B()
+}()
+
+// fakeClient is a rueidis.Client responding to the commands with err.
+type fakeClient struct {
+	rueidis.Client
+	err error
+}
+
+func (c *fakeClient) B() rueidis.Builder {
+	return builder
+}
+
+func (c *fakeClient) Do(_ context.Context, _ rueidis.Completed) rueidis.RedisResult {
+	return rueidis.NewErrorResult(c.err)
+}
+
+func (c *fakeClient) DoMulti(_ context.Context, multi ...rueidis.Completed) []rueidis.RedisResult {
+	resps := make([]rueidis.RedisResult, len(multi))
+	for i := range resps {
+		resps[i] = rueidis.NewErrorResult(c.err)
+	}
+	return resps
+}
+
+func (c *fakeClient) DoCache(ctx context.Context, cmd rueidis.Cacheable, _ time.Duration) rueidis.RedisResult {
+	return rueidis.NewErrorResult(c.err)
+}
+
+func (c *fakeClient) DoMultiCache(_ context.Context, multi ...rueidis.CacheableTTL) []rueidis.RedisResult {
+	resps := make([]rueidis.RedisResult, len(multi))
+	for i := range resps {
+		resps[i] = rueidis.NewErrorResult(c.err)
+	}
+	return resps
+}
+
+func (c *fakeClient) Receive(_ context.Context, _ rueidis.Completed, fn func(rueidis.PubSubMessage)) error {
+	fn(rueidis.PubSubMessage{Channel: "ch", Message: "msg"})
+	return c.err
+}
+
+func (c *fakeClient) Dedicated(fn func(rueidis.DedicatedClient) error) error {
+	return fn(&fakeDedicatedClient{client: c})
+}
+
+func (c *fakeClient) Nodes() map[string]rueidis.Client {
+	return map[string]rueidis.Client{"10.0.0.1:7000": c}
+}
+
+type fakeDedicatedClient struct {
+	rueidis.DedicatedClient
+	client *fakeClient
+}
+
+func (c *fakeDedicatedClient) B() rueidis.Builder {
+	return c.client.B()
+}
+
+func (c *fakeDedicatedClient) Do(ctx context.Context, cmd rueidis.Completed) rueidis.RedisResult {
+	return c.client.Do(ctx, cmd)
+}
+
+func TestDo(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := WrapClient(&fakeClient{}, WithServiceName("my-redis"))
+	require.NoError(t, c.Do(context.Background(), c.B().Set().Key("key").Value("value").Build()).Error())
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "redis.command", s.OperationName())
+	assert.Equal(t, "SET", s.Tag(ext.ResourceName))
+	assert.Equal(t, "my-redis", s.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanTypeRedis, s.Tag(ext.SpanType))
+	assert.Equal(t, "SET key value", s.Tag("redis.raw_command"))
+	assert.Equal(t, "2", s.Tag("redis.args_length"))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, ext.SpanKindClient, s.Tag(ext.SpanKind))
+	assert.Equal(t, ext.DBSystemRedis, s.Tag(ext.DBSystem))
+	assert.Nil(t, s.Tag(ext.Error))
+}
+
+func TestDoMulti(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := WrapClient(&fakeClient{})
+	c.DoMulti(context.Background(),
+		c.B().Set().Key("key").Value("value").Build(),
+		c.B().Expire().Key("key").Seconds(10).Build(),
+	)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "redis.pipeline", s.Tag(ext.ResourceName))
+	assert.Equal(t, "2", s.Tag("redis.pipeline_length"))
+	assert.Equal(t, "SET key value\nEXPIRE key 10", s.Tag("redis.raw_command"))
+}
+
+func TestDoCache(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := WrapClient(&fakeClient{})
+	c.DoCache(context.Background(), c.B().Get().Key("key").Cache(), time.Minute)
+	c.DoMultiCache(context.Background(),
+		rueidis.CT(c.B().Get().Key("k1").Cache(), time.Minute),
+		rueidis.CT(c.B().Get().Key("k2").Cache(), time.Minute),
+	)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "GET", spans[0].Tag(ext.ResourceName))
+	assert.Equal(t, false, spans[0].Tag(tagClientCacheHit))
+	assert.Equal(t, "1m0s", spans[0].Tag("redis.client_cache.ttl"))
+	assert.Equal(t, "redis.pipeline", spans[1].Tag(ext.ResourceName))
+	assert.Equal(t, 0, spans[1].Tag(tagClientCacheHits))
+	assert.Equal(t, 2, spans[1].Tag(tagClientCacheMisses))
+}
+
+func TestReceive(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := WrapClient(&fakeClient{})
+	var received []string
+	err := c.Receive(context.Background(), c.B().Subscribe().Channel("ch").Build(), func(msg rueidis.PubSubMessage) {
+		received = append(received, msg.Message)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"msg"}, received)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "SUBSCRIBE", spans[0].Tag(ext.ResourceName))
+}
+
+func TestErrors(t *testing.T) {
+	for name, tt := range map[string]struct {
+		err     error
+		opts    []ClientOption
+		spanErr bool
+	}{
+		"error": {
+			err:     errors.New("connection refused"),
+			spanErr: true,
+		},
+		"nil": {
+			err: rueidis.Nil,
+		},
+		"check": {
+			err:  errors.New("connection refused"),
+			opts: []ClientOption{WithErrorCheck(func(error) bool { return false })},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			c := WrapClient(&fakeClient{err: tt.err}, tt.opts...)
+			c.Do(context.Background(), c.B().Get().Key("key").Build())
+			c.DoMulti(context.Background(), c.B().Get().Key("key").Build())
+
+			spans := mt.FinishedSpans()
+			require.Len(t, spans, 2)
+			for _, s := range spans {
+				if tt.spanErr {
+					assert.Equal(t, tt.err, s.Tag(ext.Error))
+				} else {
+					assert.Nil(t, s.Tag(ext.Error))
+				}
+			}
+		})
+	}
+}
+
+func TestSkipRawCommand(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := WrapClient(&fakeClient{}, WithSkipRawCommand(true))
+	c.Do(context.Background(), c.B().Auth().Password("secret").Build())
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.NotContains(t, spans[0].Tags(), "redis.raw_command")
+	assert.Equal(t, "AUTH", spans[0].Tag(ext.ResourceName))
+}
+
+func TestDedicated(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := WrapClient(&fakeClient{})
+	err := c.Dedicated(func(dc rueidis.DedicatedClient) error {
+		return dc.Do(context.Background(), dc.B().Get().Key("key").Build()).Error()
+	})
+	require.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET", spans[0].Tag(ext.ResourceName))
+}
+
+func TestNodes(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := WrapClient(&fakeClient{})
+	for _, node := range c.Nodes() {
+		node.Do(context.Background(), node.B().Ping().Build())
+	}
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "10.0.0.1", spans[0].Tag(ext.TargetHost))
+	assert.Equal(t, "7000", spans[0].Tag(ext.TargetPort))
+}
+
+func TestAnalyticsSettings(t *testing.T) {
+	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...ClientOption) {
+		c := WrapClient(&fakeClient{}, opts...)
+		c.Do(context.Background(), c.B().Ping().Build())
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, rate, spans[0].Tag(ext.EventSampleRate))
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, nil)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 1.0, WithAnalytics(true))
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("DD_TRACE_REDIS_ANALYTICS_ENABLED", "true")
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 1.0)
+	})
+
+	t.Run("rate", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 0.23, WithAnalyticsRate(0.23))
+	})
+}
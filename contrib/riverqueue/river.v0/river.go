@@ -0,0 +1,241 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package river provides functions to trace the riverqueue/river package (https://github.com/riverqueue/river).
+//
+// The insertion of jobs and their work are traced, and the span context is
+// propagated from the one to the other in the metadata of the jobs, so that
+// background jobs are part of the trace of the requests which inserted them.
+package river // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/riverqueue/river.v0"
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+)
+
+const componentName = "riverqueue/river.v0"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+const (
+	tagJobKind       = "river.job.kind"
+	tagJobID         = "river.job.id"
+	tagQueue         = "river.queue"
+	tagAttempt       = "river.job.attempt"
+	tagMaxAttempts   = "river.job.max_attempts"
+	tagInsertedCount = "river.job.inserted_count"
+)
+
+// metadataKey is the key under which the span context is added to the
+// metadata of jobs.
+const metadataKey = "_dd_trace"
+
+// WrapClient wraps a river.Client so that the insertion of jobs is traced.
+func WrapClient[TTx any](c *river.Client[TTx], opts ...Option) *Client[TTx] {
+	wrapped := &Client[TTx]{
+		Client: c,
+		cfg:    newConfig(opts...),
+	}
+	log.Debug("contrib/riverqueue/river.v0: Wrapping Client: %#v", wrapped.cfg)
+	return wrapped
+}
+
+// A Client wraps a river.Client.
+type Client[TTx any] struct {
+	*river.Client[TTx]
+	cfg *config
+}
+
+// Insert calls river.Client.Insert and traces the request. The span context
+// is added to the metadata of the job.
+func (c *Client[TTx]) Insert(ctx context.Context, args river.JobArgs, opts *river.InsertOpts) (*rivertype.JobRow, error) {
+	span, ctx := c.startInsertSpan(ctx, args.Kind())
+	job, err := c.Client.Insert(ctx, args, withMetadata(opts, span.Context()))
+	finishInsertSpan(span, job, err)
+	return job, err
+}
+
+// InsertTx calls river.Client.InsertTx and traces the request. The span
+// context is added to the metadata of the job.
+func (c *Client[TTx]) InsertTx(ctx context.Context, tx TTx, args river.JobArgs, opts *river.InsertOpts) (*rivertype.JobRow, error) {
+	span, ctx := c.startInsertSpan(ctx, args.Kind())
+	job, err := c.Client.InsertTx(ctx, tx, args, withMetadata(opts, span.Context()))
+	finishInsertSpan(span, job, err)
+	return job, err
+}
+
+// InsertMany calls river.Client.InsertMany and traces the request. The span
+// context is added to the metadata of every job.
+func (c *Client[TTx]) InsertMany(ctx context.Context, params []river.InsertManyParams) (int64, error) {
+	span, ctx := c.startInsertSpan(ctx, insertManyResource(params))
+	n, err := c.Client.InsertMany(ctx, withManyMetadata(params, span.Context()))
+	span.SetTag(tagInsertedCount, n)
+	span.Finish(tracer.WithError(err))
+	return n, err
+}
+
+// InsertManyTx calls river.Client.InsertManyTx and traces the request. The
+// span context is added to the metadata of every job.
+func (c *Client[TTx]) InsertManyTx(ctx context.Context, tx TTx, params []river.InsertManyParams) (int64, error) {
+	span, ctx := c.startInsertSpan(ctx, insertManyResource(params))
+	n, err := c.Client.InsertManyTx(ctx, tx, withManyMetadata(params, span.Context()))
+	span.SetTag(tagInsertedCount, n)
+	span.Finish(tracer.WithError(err))
+	return n, err
+}
+
+func (c *Client[TTx]) startInsertSpan(ctx context.Context, resource string) (ddtrace.Span, context.Context) {
+	opts := []tracer.StartSpanOption{
+		tracer.ServiceName(c.cfg.producerServiceName),
+		tracer.ResourceName(resource),
+		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindProducer),
+	}
+	if !math.IsNaN(c.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
+	}
+	return tracer.StartSpanFromContext(ctx, c.cfg.producerSpanName, opts...)
+}
+
+func finishInsertSpan(span ddtrace.Span, job *rivertype.JobRow, err error) {
+	if err == nil && job != nil {
+		span.SetTag(tagJobKind, job.Kind)
+		span.SetTag(tagJobID, job.ID)
+		span.SetTag(tagQueue, job.Queue)
+		span.SetTag(tagMaxAttempts, job.MaxAttempts)
+	}
+	span.Finish(tracer.WithError(err))
+}
+
+// insertManyResource returns the resource name of the insertion of the jobs
+// of params, which names their kind if they are all of the same one.
+func insertManyResource(params []river.InsertManyParams) string {
+	if len(params) == 0 {
+		return "InsertMany"
+	}
+	kind := params[0].Args.Kind()
+	for _, p := range params[1:] {
+		if p.Args.Kind() != kind {
+			return "InsertMany"
+		}
+	}
+	return kind
+}
+
+// withMetadata returns a copy of opts with the span context spanctx added to
+// the metadata, if it is a JSON object.
+func withMetadata(opts *river.InsertOpts, spanctx ddtrace.SpanContext) *river.InsertOpts {
+	var o river.InsertOpts
+	if opts != nil {
+		o = *opts
+	}
+	o.Metadata = injectMetadata(o.Metadata, spanctx)
+	return &o
+}
+
+func withManyMetadata(params []river.InsertManyParams, spanctx ddtrace.SpanContext) []river.InsertManyParams {
+	out := make([]river.InsertManyParams, len(params))
+	for i, p := range params {
+		out[i] = river.InsertManyParams{
+			Args:       p.Args,
+			InsertOpts: withMetadata(p.InsertOpts, spanctx),
+		}
+	}
+	return out
+}
+
+// injectMetadata returns the job metadata md with the span context spanctx
+// added under metadataKey, if md is empty or a JSON object. Otherwise, md is
+// returned unchanged.
+func injectMetadata(md []byte, spanctx ddtrace.SpanContext) []byte {
+	if len(md) == 0 {
+		md = []byte("{}")
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(md, &obj); err != nil || obj == nil {
+		return md
+	}
+	carrier := tracer.TextMapCarrier{}
+	if err := tracer.Inject(spanctx, carrier); err != nil {
+		log.Debug("contrib/riverqueue/river.v0: Failed to inject span context into carrier, %v", err)
+		return md
+	}
+	v, err := json.Marshal(carrier)
+	if err != nil {
+		return md
+	}
+	obj[metadataKey] = v
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return md
+	}
+	return out
+}
+
+// ExtractSpanContext retrieves the span context from the metadata of job.
+func ExtractSpanContext(job *rivertype.JobRow) (ddtrace.SpanContext, error) {
+	var obj struct {
+		Metadata tracer.TextMapCarrier `json:"_dd_trace"`
+	}
+	if err := json.Unmarshal(job.Metadata, &obj); err != nil || obj.Metadata == nil {
+		return nil, tracer.ErrSpanContextNotFound
+	}
+	return tracer.Extract(obj.Metadata)
+}
+
+// WrapWorker wraps a river.Worker so that the work of jobs is traced. The span
+// is a child of the span context found in the metadata of the job, if any, and
+// is available from the context passed to Work.
+func WrapWorker[T river.JobArgs](w river.Worker[T], opts ...Option) river.Worker[T] {
+	cfg := newConfig(opts...)
+	log.Debug("contrib/riverqueue/river.v0: Wrapping Worker: %#v", cfg)
+	return &worker[T]{Worker: w, cfg: cfg}
+}
+
+type worker[T river.JobArgs] struct {
+	river.Worker[T]
+	cfg *config
+}
+
+// Work implements river.Worker.
+func (w *worker[T]) Work(ctx context.Context, job *river.Job[T]) error {
+	opts := []tracer.StartSpanOption{
+		tracer.ServiceName(w.cfg.consumerServiceName),
+		tracer.ResourceName(job.Kind),
+		tracer.SpanType(ext.SpanTypeMessageConsumer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindConsumer),
+		tracer.Tag(tagJobKind, job.Kind),
+		tracer.Tag(tagJobID, job.ID),
+		tracer.Tag(tagQueue, job.Queue),
+		// attempts after the first one are retries
+		tracer.Tag(tagAttempt, job.Attempt),
+		tracer.Tag(tagMaxAttempts, job.MaxAttempts),
+		tracer.Measured(),
+	}
+	if !math.IsNaN(w.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, w.cfg.analyticsRate))
+	}
+	if spanctx, err := ExtractSpanContext(job.JobRow); err == nil {
+		opts = append(opts, tracer.ChildOf(spanctx))
+	}
+	span, ctx := tracer.StartSpanFromContext(ctx, w.cfg.consumerSpanName, opts...)
+	err := w.Worker.Work(ctx, job)
+	span.Finish(tracer.WithError(err))
+	return err
+}
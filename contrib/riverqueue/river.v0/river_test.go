@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package river
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type emailArgs struct {
+	To string `json:"to"`
+}
+
+func (emailArgs) Kind() string { return "email" }
+
+type emailWorker struct {
+	river.WorkerDefaults[emailArgs]
+	work func(ctx context.Context, job *river.Job[emailArgs]) error
+}
+
+func (w *emailWorker) Work(ctx context.Context, job *river.Job[emailArgs]) error {
+	return w.work(ctx, job)
+}
+
+func TestWorker(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	parent := tracer.StartSpan("parent")
+	opts := withMetadata(&river.InsertOpts{Metadata: []byte(`{"tenant":"a"}`)}, parent.Context())
+	parent.Finish()
+	assert.JSONEq(t, `"a"`, string(mustField(t, opts.Metadata, "tenant")))
+
+	workErr := errors.New("work failed")
+	w := WrapWorker[emailArgs](&emailWorker{work: func(ctx context.Context, _ *river.Job[emailArgs]) error {
+		_, ok := tracer.SpanFromContext(ctx)
+		assert.True(t, ok)
+		return workErr
+	}}, WithServiceName("jobs"))
+	job := &river.Job[emailArgs]{
+		JobRow: &rivertype.JobRow{
+			ID:          1,
+			Attempt:     2,
+			MaxAttempts: 25,
+			Kind:        "email",
+			Queue:       "default",
+			Metadata:    opts.Metadata,
+		},
+		Args: emailArgs{To: "user@example.com"},
+	}
+	assert.Equal(t, workErr, w.Work(context.Background(), job))
+
+	spans := mocktracer.FinishedSpansByOperation(mt, "river.work")
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "email", s.Tag(ext.ResourceName))
+	assert.Equal(t, "jobs", s.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanKindConsumer, s.Tag(ext.SpanKind))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, int64(1), s.Tag(tagJobID))
+	assert.Equal(t, "default", s.Tag(tagQueue))
+	assert.Equal(t, 2, s.Tag(tagAttempt))
+	assert.Equal(t, 25, s.Tag(tagMaxAttempts))
+	assert.Equal(t, workErr, s.Tag(ext.Error))
+	assert.Equal(t, parent.Context().SpanID(), s.ParentID())
+}
+
+func mustField(t *testing.T, md []byte, key string) []byte {
+	var obj map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(md, &obj))
+	return obj[key]
+}
+
+func TestMetadata(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span := tracer.StartSpan("parent")
+	defer span.Finish()
+
+	opts := withMetadata(nil, span.Context())
+	spanctx, err := ExtractSpanContext(&rivertype.JobRow{Metadata: opts.Metadata})
+	require.NoError(t, err)
+	assert.Equal(t, span.Context().SpanID(), spanctx.SpanID())
+
+	// the options of the caller are left untouched
+	orig := &river.InsertOpts{Queue: "q"}
+	assert.Equal(t, "q", withMetadata(orig, span.Context()).Queue)
+	assert.Nil(t, orig.Metadata)
+
+	assert.Equal(t, "[]", string(injectMetadata([]byte("[]"), span.Context())))
+	_, err = ExtractSpanContext(&rivertype.JobRow{Metadata: []byte("{}")})
+	assert.Equal(t, tracer.ErrSpanContextNotFound, err)
+}
+
+func TestInsertManyResource(t *testing.T) {
+	assert.Equal(t, "InsertMany", insertManyResource(nil))
+	assert.Equal(t, "email", insertManyResource([]river.InsertManyParams{{Args: emailArgs{}}, {Args: emailArgs{}}}))
+}
@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package cron provides functions to trace the robfig/cron/v3 package (https://github.com/robfig/cron).
+//
+// Every run of the jobs added to a wrapped Cron is traced by a root span,
+// tagged with the schedule of the job, the time the run was scheduled at and
+// how late it started.
+package cron // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/robfig/cron.v3"
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/robfig/cron/v3"
+)
+
+const componentName = "robfig/cron.v3"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+const (
+	tagJobName       = "cron.job.name"
+	tagSchedule      = "cron.schedule"
+	tagEntryID       = "cron.entry_id"
+	tagScheduledTime = "cron.job.scheduled_time"
+	tagStartSkew     = "cron.job.start_skew_ms"
+)
+
+// WrapCron wraps a cron.Cron so that the runs of the jobs added to it are
+// traced.
+func WrapCron(c *cron.Cron, opts ...Option) *Cron {
+	wrapped := &Cron{
+		Cron: c,
+		cfg:  newConfig(opts...),
+	}
+	log.Debug("contrib/robfig/cron.v3: Wrapping Cron: %#v", wrapped.cfg)
+	return wrapped
+}
+
+// A Cron wraps a cron.Cron.
+type Cron struct {
+	*cron.Cron
+	cfg *config
+}
+
+// AddFunc calls cron.Cron.AddFunc, tracing the runs of cmd. The job is named
+// after the function.
+func (c *Cron) AddFunc(spec string, cmd func()) (cron.EntryID, error) {
+	return c.addJob(spec, funcName(cmd), func(context.Context) { cmd() })
+}
+
+// AddFuncContext is like AddFunc, but the span of each run is available from
+// the context passed to cmd, so that the work of the job can be traced.
+func (c *Cron) AddFuncContext(spec string, cmd func(ctx context.Context)) (cron.EntryID, error) {
+	return c.addJob(spec, funcName(cmd), cmd)
+}
+
+// AddJob calls cron.Cron.AddJob, tracing the runs of cmd. The job is named
+// after the type of cmd.
+func (c *Cron) AddJob(spec string, cmd cron.Job) (cron.EntryID, error) {
+	return c.addJob(spec, fmt.Sprintf("%T", cmd), func(context.Context) { cmd.Run() })
+}
+
+// Schedule calls cron.Cron.Schedule, tracing the runs of cmd. The job is
+// named after the type of cmd.
+func (c *Cron) Schedule(schedule cron.Schedule, cmd cron.Job) cron.EntryID {
+	j := &job{cron: c, name: fmt.Sprintf("%T", cmd), run: func(context.Context) { cmd.Run() }}
+	id := c.Cron.Schedule(schedule, j)
+	atomic.StoreInt64(&j.id, int64(id))
+	return id
+}
+
+func (c *Cron) addJob(spec, name string, run func(context.Context)) (cron.EntryID, error) {
+	j := &job{cron: c, name: name, spec: spec, run: run}
+	id, err := c.Cron.AddJob(spec, j)
+	atomic.StoreInt64(&j.id, int64(id))
+	return id, err
+}
+
+// job is a traced cron.Job.
+type job struct {
+	cron *Cron
+	name string
+	spec string
+	run  func(context.Context)
+	id   int64 // cron.EntryID, set once the job is added
+}
+
+// Run implements cron.Job.
+func (j *job) Run() {
+	start := time.Now()
+	cfg := j.cron.cfg
+	opts := []tracer.StartSpanOption{
+		tracer.ServiceName(cfg.serviceName),
+		tracer.ResourceName(j.name),
+		tracer.StartTime(start),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(tagJobName, j.name),
+		tracer.Measured(),
+	}
+	if j.spec != "" {
+		opts = append(opts, tracer.Tag(tagSchedule, j.spec))
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	id := cron.EntryID(atomic.LoadInt64(&j.id))
+	if id != 0 {
+		opts = append(opts, tracer.Tag(tagEntryID, int(id)))
+		// the entry is updated by the scheduler before it serves this request,
+		// so Prev is the time this run was scheduled at.
+		if scheduled := j.cron.Entry(id).Prev; !scheduled.IsZero() {
+			opts = append(opts, scheduleTags(scheduled, start)...)
+		}
+	}
+	span, ctx := tracer.StartSpanFromContext(context.Background(), cfg.spanName, opts...)
+	defer finishSpan(span)
+	j.run(ctx)
+}
+
+// scheduleTags returns the tags of a run scheduled at scheduled and started at
+// start.
+func scheduleTags(scheduled, start time.Time) []tracer.StartSpanOption {
+	return []tracer.StartSpanOption{
+		tracer.Tag(tagScheduledTime, scheduled.UTC().Format(time.RFC3339Nano)),
+		tracer.Tag(tagStartSkew, float64(start.Sub(scheduled))/float64(time.Millisecond)),
+	}
+}
+
+// finishSpan finishes span, with the panic of the job as error, if any.
+func finishSpan(span ddtrace.Span) {
+	if r := recover(); r != nil {
+		span.Finish(tracer.WithError(fmt.Errorf("%v", r)))
+		panic(r)
+	}
+	span.Finish()
+}
+
+// funcName returns the name of the function fn.
+func funcName(fn interface{}) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+		return f.Name()
+	}
+	return "cron.job"
+}
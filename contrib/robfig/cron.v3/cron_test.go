@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package cron
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// soon is a cron.Schedule running jobs every 10ms.
+type soon struct{}
+
+func (soon) Next(t time.Time) time.Time { return t.Add(10 * time.Millisecond) }
+
+func TestSchedule(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := WrapCron(cron.New(), WithServiceName("jobs"))
+	done := make(chan struct{})
+	id := c.Schedule(soon{}, cron.FuncJob(func() {
+		select {
+		case done <- struct{}{}:
+		default:
+		}
+	}))
+	c.Start()
+	<-done
+	<-c.Stop().Done()
+
+	spans := mt.FinishedSpans()
+	require.NotEmpty(t, spans)
+	s := spans[0]
+	assert.Equal(t, "cron.job", s.OperationName())
+	assert.Equal(t, "cron.FuncJob", s.Tag(ext.ResourceName))
+	assert.Equal(t, "cron.FuncJob", s.Tag(tagJobName))
+	assert.Equal(t, "jobs", s.Tag(ext.ServiceName))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, int(id), s.Tag(tagEntryID))
+	assert.Nil(t, s.Tag(tagSchedule))
+	assert.Zero(t, s.ParentID())
+
+	scheduled, err := time.Parse(time.RFC3339Nano, s.Tag(tagScheduledTime).(string))
+	require.NoError(t, err)
+	assert.False(t, s.StartTime().Before(scheduled))
+	assert.GreaterOrEqual(t, s.Tag(tagStartSkew).(float64), 0.0)
+}
+
+func TestAddFuncContext(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := WrapCron(cron.New())
+	var ran bool
+	id, err := c.AddFuncContext("@hourly", func(ctx context.Context) {
+		_, ran = tracer.SpanFromContext(ctx)
+	})
+	require.NoError(t, err)
+	// the cron is not started, so the run was not scheduled
+	c.Entry(id).Job.Run()
+	assert.True(t, ran)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "@hourly", s.Tag(tagSchedule))
+	assert.Contains(t, s.Tag(tagJobName), "TestAddFuncContext")
+	assert.Nil(t, s.Tag(tagScheduledTime))
+	assert.Nil(t, s.Tag(tagStartSkew))
+
+	_, err = c.AddFunc("invalid", func() {})
+	assert.Error(t, err)
+}
+
+func TestPanic(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := WrapCron(cron.New())
+	id, err := c.AddFunc("@hourly", func() { panic("job failed") })
+	require.NoError(t, err)
+	assert.Panics(t, c.Entry(id).Job.Run)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.EqualError(t, spans[0].Tag(ext.Error).(error), "job failed")
+}
@@ -0,0 +1,27 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package cron_test
+
+import (
+	"context"
+
+	crontrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/robfig/cron.v3"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/robfig/cron/v3"
+)
+
+func Example() {
+	c := crontrace.WrapCron(cron.New(), crontrace.WithServiceName("reports"))
+	c.AddFuncContext("@daily", func(ctx context.Context) {
+		// the work of the job is part of the trace of its run
+		span, ctx := tracer.StartSpanFromContext(ctx, "report.generate")
+		defer span.Finish()
+		_ = ctx
+	})
+	c.Start()
+	defer c.Stop()
+}
@@ -13,6 +13,7 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
 
@@ -114,6 +115,9 @@ func (r *Reader) ReadMessage(ctx context.Context) (kafka.Message, error) {
 		return kafka.Message{}, err
 	}
 	r.prev = r.startSpan(ctx, &msg)
+	r.trackHighWatermark(msg)
+	// messages read with a consumer group are committed automatically
+	r.trackCommit(msg)
 	return msg, nil
 }
 
@@ -128,9 +132,40 @@ func (r *Reader) FetchMessage(ctx context.Context) (kafka.Message, error) {
 		return msg, err
 	}
 	r.prev = r.startSpan(ctx, &msg)
+	r.trackHighWatermark(msg)
 	return msg, nil
 }
 
+// CommitMessages calls kafka.Reader.CommitMessages. When Data Streams
+// Monitoring is enabled, the committed offsets are reported to compute the
+// backlog of the consumer group.
+func (r *Reader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	err := r.Reader.CommitMessages(ctx, msgs...)
+	if err == nil {
+		for _, msg := range msgs {
+			r.trackCommit(msg)
+		}
+	}
+	return err
+}
+
+// trackHighWatermark reports the high watermark of the partition of msg to
+// Data Streams Monitoring, if it is enabled and the reader has a group.
+func (r *Reader) trackHighWatermark(msg kafka.Message) {
+	if p := datastreams.GetGlobalProcessor(); p != nil && r.Config().GroupID != "" {
+		p.TrackKafkaHighWatermarkOffset(r.Config().GroupID, msg.Topic, int32(msg.Partition), msg.HighWaterMark)
+	}
+}
+
+// trackCommit reports the commit of msg to Data Streams Monitoring, if it is
+// enabled and the reader has a group. As in Kafka, the committed offset is the
+// one of the next message to read.
+func (r *Reader) trackCommit(msg kafka.Message) {
+	if p := datastreams.GetGlobalProcessor(); p != nil && r.Config().GroupID != "" {
+		p.TrackKafkaCommitOffset(r.Config().GroupID, msg.Topic, int32(msg.Partition), msg.Offset+1)
+	}
+}
+
 // WrapWriter wraps a kafka.Writer so requests are traced.
 func WrapWriter(w *kafka.Writer, opts ...Option) *Writer {
 	writer := &Writer{
@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package temporal_test
+
+import (
+	"log"
+
+	temporaltrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/temporalio/sdk-go"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/worker"
+)
+
+func Example() {
+	// the interceptor traces the workflows started by the client, and the
+	// workflows and activities run by the workers created from it
+	c, err := client.Dial(client.Options{
+		Interceptors: []interceptor.ClientInterceptor{temporaltrace.NewInterceptor()},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+
+	w := worker.New(c, "task-queue", worker.Options{})
+	if err := w.Run(worker.InterruptCh()); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package temporal
+
+import (
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+)
+
+const (
+	defaultServiceName = "temporal"
+	// defaultHeaderKey is the key of the Temporal header holding the span
+	// context.
+	defaultHeaderKey = "_dd-tracer-data"
+)
+
+type config struct {
+	serviceName          string
+	analyticsRate        float64
+	headerKey            string
+	disableSignalTracing bool
+	disableQueryTracing  bool
+}
+
+// Option represents an option that can be used to customize the Tracer.
+type Option func(*config)
+
+func defaults(cfg *config) {
+	cfg.serviceName = namingschema.NewDefaultServiceName(defaultServiceName).GetName()
+	cfg.headerKey = defaultHeaderKey
+	// cfg.analyticsRate = globalconfig.AnalyticsRate()
+	if internal.BoolEnv("DD_TRACE_TEMPORAL_ANALYTICS_ENABLED", false) {
+		cfg.analyticsRate = 1.0
+	} else {
+		cfg.analyticsRate = math.NaN()
+	}
+}
+
+// WithServiceName sets the given service name for the spans.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithHeaderKey sets the key of the Temporal header the span context is
+// propagated in. It defaults to "_dd-tracer-data".
+func WithHeaderKey(key string) Option {
+	return func(cfg *config) {
+		cfg.headerKey = key
+	}
+}
+
+// WithSignalTracing enables or disables the tracing of signals. It is enabled
+// by default.
+func WithSignalTracing(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.disableSignalTracing = !enabled
+	}
+}
+
+// WithQueryTracing enables or disables the tracing of queries. It is enabled
+// by default.
+func WithQueryTracing(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.disableQueryTracing = !enabled
+	}
+}
@@ -0,0 +1,192 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package temporal provides interceptors to trace the temporalio/sdk-go package (https://github.com/temporalio/sdk-go).
+//
+// The interceptors create spans for workflow executions, activities, child
+// workflows, signals and queries, and propagate the span context in the
+// Temporal headers, so that workflows and activities are part of the trace of
+// the code that started them. The start of workflow spans is taken from the
+// workflow clock, and no span is created while a workflow is replayed, so
+// that tracing does not break the determinism of workflows.
+package temporal // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/temporalio/sdk-go"
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"unicode"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"go.temporal.io/sdk/interceptor"
+	tlog "go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/workflow"
+)
+
+const componentName = "temporalio/sdk-go"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// NewInterceptor returns an interceptor tracing Temporal clients and workers.
+// It is meant to be added to the Interceptors of client.Options, which are
+// also used by the workers created from the client.
+func NewInterceptor(opts ...Option) interceptor.Interceptor {
+	return interceptor.NewTracingInterceptor(NewTracer(opts...))
+}
+
+// NewTracer returns an interceptor.Tracer creating Datadog spans, for use with
+// interceptor.NewTracingInterceptor.
+func NewTracer(opts ...Option) interceptor.Tracer {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/temporalio/sdk-go: Configuring Tracer: %#v", cfg)
+	return &temporalTracer{cfg: cfg}
+}
+
+// contextKey is the key of the span in the contexts of workflows.
+type contextKey struct{}
+
+type temporalTracer struct {
+	interceptor.BaseTracer
+	cfg *config
+}
+
+// span is a running span. Unmarshaled span contexts are used as is as span
+// references.
+type span struct {
+	span ddtrace.Span
+}
+
+// Finish implements interceptor.TracerSpan.
+func (s *span) Finish(opts *interceptor.TracerFinishSpanOptions) {
+	var err error
+	if opts != nil {
+		err = opts.Error
+	}
+	var continueAsNew *workflow.ContinueAsNewError
+	if errors.As(err, &continueAsNew) {
+		// continuing as new is the way long running workflows end normally
+		err = nil
+	}
+	s.span.Finish(tracer.WithError(err))
+}
+
+// Options implements interceptor.Tracer.
+func (t *temporalTracer) Options() interceptor.TracerOptions {
+	return interceptor.TracerOptions{
+		SpanContextKey:       contextKey{},
+		HeaderKey:            t.cfg.headerKey,
+		DisableSignalTracing: t.cfg.disableSignalTracing,
+		DisableQueryTracing:  t.cfg.disableQueryTracing,
+		// a span context which can't be extracted must not fail workflows
+		AllowInvalidParentSpans: true,
+	}
+}
+
+// UnmarshalSpan implements interceptor.Tracer.
+func (t *temporalTracer) UnmarshalSpan(m map[string]string) (interceptor.TracerSpanRef, error) {
+	return tracer.Extract(tracer.TextMapCarrier(m))
+}
+
+// MarshalSpan implements interceptor.Tracer.
+func (t *temporalTracer) MarshalSpan(s interceptor.TracerSpan) (map[string]string, error) {
+	carrier := tracer.TextMapCarrier{}
+	if err := tracer.Inject(s.(*span).span.Context(), carrier); err != nil {
+		return nil, err
+	}
+	return carrier, nil
+}
+
+// SpanFromContext implements interceptor.Tracer.
+func (t *temporalTracer) SpanFromContext(ctx context.Context) interceptor.TracerSpan {
+	s, ok := tracer.SpanFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return &span{span: s}
+}
+
+// ContextWithSpan implements interceptor.Tracer.
+func (t *temporalTracer) ContextWithSpan(ctx context.Context, s interceptor.TracerSpan) context.Context {
+	return tracer.ContextWithSpan(ctx, s.(*span).span)
+}
+
+// StartSpan implements interceptor.Tracer.
+func (t *temporalTracer) StartSpan(opts *interceptor.TracerStartSpanOptions) (interceptor.TracerSpan, error) {
+	startOpts := []tracer.StartSpanOption{
+		tracer.ServiceName(t.cfg.serviceName),
+		tracer.ResourceName(opts.Name),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Measured(),
+	}
+	switch {
+	case opts.ToHeader:
+		// the span context is sent to a workflow or an activity
+		startOpts = append(startOpts, tracer.Tag(ext.SpanKind, ext.SpanKindClient))
+	case opts.FromHeader:
+		// the span context was received from a client
+		startOpts = append(startOpts, tracer.Tag(ext.SpanKind, ext.SpanKindServer))
+	}
+	if !math.IsNaN(t.cfg.analyticsRate) {
+		startOpts = append(startOpts, tracer.Tag(ext.EventSampleRate, t.cfg.analyticsRate))
+	}
+	if !opts.Time.IsZero() {
+		// within workflows, this is the deterministic workflow time
+		startOpts = append(startOpts, tracer.StartTime(opts.Time))
+	}
+	switch parent := opts.Parent.(type) {
+	case *span:
+		startOpts = append(startOpts, tracer.ChildOf(parent.span.Context()))
+	case ddtrace.SpanContext:
+		startOpts = append(startOpts, tracer.ChildOf(parent))
+	}
+	for k, v := range opts.Tags {
+		startOpts = append(startOpts, tracer.Tag(k, v))
+	}
+	return &span{span: tracer.StartSpan(spanName(opts.Operation), startOpts...)}, nil
+}
+
+// GetLogger implements interceptor.Tracer, adding the trace and span IDs of
+// ref to the entries of logger, to correlate them with the trace.
+func (t *temporalTracer) GetLogger(logger tlog.Logger, ref interceptor.TracerSpanRef) tlog.Logger {
+	var ctx ddtrace.SpanContext
+	switch ref := ref.(type) {
+	case *span:
+		ctx = ref.span.Context()
+	case ddtrace.SpanContext:
+		ctx = ref
+	default:
+		return logger
+	}
+	return tlog.With(logger, "dd.trace_id", ctx.TraceID(), "dd.span_id", ctx.SpanID())
+}
+
+// spanName returns the name of the spans of a Temporal operation, such as
+// "temporal.run_workflow" for "RunWorkflow".
+func spanName(operation string) string {
+	var b strings.Builder
+	b.WriteString("temporal.")
+	for i, r := range operation {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
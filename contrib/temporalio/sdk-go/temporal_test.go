@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package temporal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestPropagation(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	tr := NewTracer(WithServiceName("workflows"))
+	client, err := tr.StartSpan(&interceptor.TracerStartSpanOptions{
+		Operation: "StartWorkflow",
+		Name:      "MyWorkflow",
+		ToHeader:  true,
+		Tags:      map[string]string{"temporalWorkflowID": "id"},
+	})
+	require.NoError(t, err)
+	header, err := tr.MarshalSpan(client)
+	require.NoError(t, err)
+	client.Finish(&interceptor.TracerFinishSpanOptions{})
+
+	parent, err := tr.UnmarshalSpan(header)
+	require.NoError(t, err)
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	server, err := tr.StartSpan(&interceptor.TracerStartSpanOptions{
+		Parent:     parent,
+		Operation:  "RunWorkflow",
+		Name:       "MyWorkflow",
+		Time:       start,
+		FromHeader: true,
+	})
+	require.NoError(t, err)
+	ctx := tr.ContextWithSpan(context.Background(), server)
+	assert.NotNil(t, tr.SpanFromContext(ctx))
+	server.Finish(&interceptor.TracerFinishSpanOptions{Error: errors.New("workflow failed")})
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	s0, s1 := spans[0], spans[1]
+	assert.Equal(t, "temporal.start_workflow", s0.OperationName())
+	assert.Equal(t, "MyWorkflow", s0.Tag(ext.ResourceName))
+	assert.Equal(t, "workflows", s0.Tag(ext.ServiceName))
+	assert.Equal(t, ext.SpanKindClient, s0.Tag(ext.SpanKind))
+	assert.Equal(t, componentName, s0.Tag(ext.Component))
+	assert.Equal(t, "id", s0.Tag("temporalWorkflowID"))
+	assert.Nil(t, s0.Tag(ext.Error))
+
+	assert.Equal(t, "temporal.run_workflow", s1.OperationName())
+	assert.Equal(t, ext.SpanKindServer, s1.Tag(ext.SpanKind))
+	assert.Equal(t, start, s1.StartTime())
+	assert.Equal(t, s0.SpanID(), s1.ParentID())
+	assert.Equal(t, s0.TraceID(), s1.TraceID())
+	assert.NotNil(t, s1.Tag(ext.Error))
+}
+
+func TestContinueAsNew(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	tr := NewTracer()
+	s, err := tr.StartSpan(&interceptor.TracerStartSpanOptions{Operation: "RunWorkflow", Name: "MyWorkflow"})
+	require.NoError(t, err)
+	s.Finish(&interceptor.TracerFinishSpanOptions{Error: &workflow.ContinueAsNewError{}})
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Nil(t, spans[0].Tag(ext.Error))
+	assert.Nil(t, spans[0].Tag(ext.SpanKind))
+}
+
+func TestSpanFromContext(t *testing.T) {
+	tr := NewTracer()
+	assert.Nil(t, tr.SpanFromContext(context.Background()))
+}
+
+func TestOptions(t *testing.T) {
+	opts := NewTracer(WithHeaderKey("key"), WithSignalTracing(false)).Options()
+	assert.Equal(t, "key", opts.HeaderKey)
+	assert.True(t, opts.DisableSignalTracing)
+	assert.False(t, opts.DisableQueryTracing)
+	assert.True(t, opts.AllowInvalidParentSpans)
+}
+
+func TestSpanName(t *testing.T) {
+	assert.Equal(t, "temporal.run_activity", spanName("RunActivity"))
+	assert.Equal(t, "temporal.signal_child_workflow", spanName("SignalChildWorkflow"))
+}
@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package langchaingo_test
+
+import (
+	"context"
+	"log"
+
+	langchaingotrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/tmc/langchaingo"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/tmc/langchaingo/chains"
+	"github.com/tmc/langchaingo/llms/openai"
+	"github.com/tmc/langchaingo/prompts"
+)
+
+func Example() {
+	tracer.Start()
+	defer tracer.Stop()
+
+	// The same handler traces the runs of the model and of the chains.
+	handler := langchaingotrace.NewHandler(langchaingotrace.WithServiceName("my-llm-app"))
+	llm, err := openai.New(openai.WithCallback(handler))
+	if err != nil {
+		log.Fatal(err)
+	}
+	chain := chains.NewLLMChain(llm, prompts.NewPromptTemplate("Explain {{.topic}} briefly.", []string{"topic"}))
+	chain.CallbacksHandler = handler
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "answer")
+	defer span.Finish()
+
+	out, err := chains.Run(ctx, chain, "distributed tracing")
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println(out)
+}
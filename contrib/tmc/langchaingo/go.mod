@@ -0,0 +1,11 @@
+module gopkg.in/DataDog/dd-trace-go.v1/contrib/tmc/langchaingo
+
+go 1.25.1
+
+require (
+	github.com/stretchr/testify v1.8.4
+	github.com/tmc/langchaingo v0.1.13
+	gopkg.in/DataDog/dd-trace-go.v1 v1.54.0
+)
+
+replace gopkg.in/DataDog/dd-trace-go.v1 => ../../..
@@ -0,0 +1,390 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package langchaingo provides functions to trace the tmc/langchaingo package (https://github.com/tmc/langchaingo).
+//
+// A Handler, passed as the callbacks handler of the models, chains and agents,
+// traces the runs of chains, models, tools and retrievers. Their spans are
+// nested as the runs are, and tagged like the spans of the langchain
+// integration of dd-trace-py so that they can be processed by LLM
+// Observability. The inputs and outputs of the runs can also be captured, with
+// redaction, with WithContentCapture.
+//
+// Since the callbacks of langchaingo can't change the context of the runs,
+// the spans of the runs aren't found in their context. The spans of the runs
+// of a context are children of the span of that context, if any, and runs
+// made concurrently should be given contexts holding different spans.
+package langchaingo // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/tmc/langchaingo"
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/llmobs"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+const componentName = "tmc/langchaingo"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Tags set on the spans.
+const (
+	tagRunType            = "langchain.request.type"
+	tagAgentTool          = "langchain.agent.tool"
+	tagRetrievedDocuments = "langchain.retriever.documents"
+)
+
+// Types of runs, set in the langchain.request.type tag.
+const (
+	runChain     = "chain"
+	runLLM       = "llm"
+	runTool      = "tool"
+	runRetriever = "retriever"
+)
+
+// run is a run of a chain, model, tool or retriever in progress. Its fields
+// are only accessed by the callbacks of the runs of a same context, which are
+// called sequentially.
+type run struct {
+	typ  string
+	span ddtrace.Span
+	// text measures the time to the first streamed chunk of a model.
+	text llmobs.StreamText
+	// generating reports whether HandleLLMGenerateContentStart was called
+	// for the run of a model.
+	generating bool
+	// tool holds the name of the tool of the last action of an agent, which
+	// is the next tool to run.
+	tool string
+}
+
+// Handler is a callbacks.Handler tracing the runs of chains, models, tools
+// and retrievers. It can be combined with other handlers with
+// callbacks.CombiningHandler.
+type Handler struct {
+	// SimpleHandler implements the callbacks which aren't traced.
+	callbacks.SimpleHandler
+
+	cfg *config
+
+	mu sync.Mutex
+	// runs holds the stack of runs in progress of every parent span, by ID.
+	runs map[uint64][]*run
+}
+
+var _ callbacks.Handler = (*Handler)(nil)
+
+// NewHandler returns a new Handler.
+func NewHandler(opts ...Option) *Handler {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/tmc/langchaingo: Configuring Handler: %#v", cfg)
+	return &Handler{cfg: cfg, runs: make(map[uint64][]*run)}
+}
+
+// parentKey returns the key of the runs of ctx, and the span of ctx, if any.
+func parentKey(ctx context.Context) (uint64, ddtrace.Span) {
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		return span.Context().SpanID(), span
+	}
+	return 0, nil
+}
+
+// start starts a run of the given type, which is a child of the last run in
+// progress of ctx, if any.
+func (h *Handler) start(ctx context.Context, typ, kind, resource string) *run {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(h.cfg.serviceName),
+		tracer.ResourceName(resource),
+		tracer.Tag(tagRunType, typ),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(llmobs.TagSpanKind, kind),
+	}
+	if !math.IsNaN(h.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, h.cfg.analyticsRate))
+	}
+	key, parent := parentKey(ctx)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	runs := h.runs[key]
+	if n := len(runs); n > 0 {
+		opts = append(opts, tracer.ChildOf(runs[n-1].span.Context()))
+	} else if parent != nil {
+		opts = append(opts, tracer.ChildOf(parent.Context()))
+	}
+	r := &run{typ: typ, span: tracer.StartSpan(h.cfg.spanName, opts...)}
+	h.runs[key] = append(runs, r)
+	return r
+}
+
+// last returns the last run of the given type in progress of ctx, or nil.
+func (h *Handler) last(ctx context.Context, typ string) *run {
+	key, _ := parentKey(ctx)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	runs := h.runs[key]
+	for i := len(runs) - 1; i >= 0; i-- {
+		if runs[i].typ == typ {
+			return runs[i]
+		}
+	}
+	return nil
+}
+
+// end removes the last run of the given type in progress of ctx, and returns
+// it. It returns nil if there's none.
+func (h *Handler) end(ctx context.Context, typ string) *run {
+	key, _ := parentKey(ctx)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	runs := h.runs[key]
+	for i := len(runs) - 1; i >= 0; i-- {
+		if runs[i].typ != typ {
+			continue
+		}
+		r := runs[i]
+		runs = append(runs[:i], runs[i+1:]...)
+		if len(runs) == 0 {
+			delete(h.runs, key)
+		} else {
+			h.runs[key] = runs
+		}
+		return r
+	}
+	return nil
+}
+
+// fail ends the last run of the given type in progress of ctx with err.
+func (h *Handler) fail(ctx context.Context, typ string, err error) {
+	if r := h.end(ctx, typ); r != nil {
+		r.span.Finish(tracer.WithError(err))
+	}
+}
+
+// HandleChainStart implements callbacks.Handler.
+func (h *Handler) HandleChainStart(ctx context.Context, inputs map[string]any) {
+	r := h.start(ctx, runChain, llmobs.SpanKindWorkflow, runChain)
+	h.cfg.capture.TagValue(r.span, llmobs.TagInputValue, values(inputs))
+}
+
+// HandleChainEnd implements callbacks.Handler.
+func (h *Handler) HandleChainEnd(ctx context.Context, outputs map[string]any) {
+	if r := h.end(ctx, runChain); r != nil {
+		h.cfg.capture.TagValue(r.span, llmobs.TagOutputValue, values(outputs))
+		r.span.Finish()
+	}
+}
+
+// HandleChainError implements callbacks.Handler.
+func (h *Handler) HandleChainError(ctx context.Context, err error) {
+	h.fail(ctx, runChain, err)
+}
+
+// HandleAgentAction implements callbacks.Handler. The chain running the agent
+// is tagged as an agent, and the tool of the action names the span of the
+// next run of a tool.
+func (h *Handler) HandleAgentAction(ctx context.Context, action schema.AgentAction) {
+	r := h.last(ctx, runChain)
+	if r == nil {
+		return
+	}
+	r.span.SetTag(llmobs.TagSpanKind, llmobs.SpanKindAgent)
+	r.span.SetTag(tagAgentTool, action.Tool)
+	r.tool = action.Tool
+}
+
+// HandleAgentFinish implements callbacks.Handler.
+func (h *Handler) HandleAgentFinish(ctx context.Context, _ schema.AgentFinish) {
+	if r := h.last(ctx, runChain); r != nil {
+		r.span.SetTag(llmobs.TagSpanKind, llmobs.SpanKindAgent)
+	}
+}
+
+// HandleToolStart implements callbacks.Handler.
+func (h *Handler) HandleToolStart(ctx context.Context, input string) {
+	resource := runTool
+	if agent := h.last(ctx, runChain); agent != nil && agent.tool != "" {
+		resource, agent.tool = agent.tool, ""
+	}
+	r := h.start(ctx, runTool, llmobs.SpanKindTool, resource)
+	h.cfg.capture.TagValue(r.span, llmobs.TagInputValue, input)
+}
+
+// HandleToolEnd implements callbacks.Handler.
+func (h *Handler) HandleToolEnd(ctx context.Context, output string) {
+	if r := h.end(ctx, runTool); r != nil {
+		h.cfg.capture.TagValue(r.span, llmobs.TagOutputValue, output)
+		r.span.Finish()
+	}
+}
+
+// HandleToolError implements callbacks.Handler.
+func (h *Handler) HandleToolError(ctx context.Context, err error) {
+	h.fail(ctx, runTool, err)
+}
+
+// HandleRetrieverStart implements callbacks.Handler.
+func (h *Handler) HandleRetrieverStart(ctx context.Context, query string) {
+	r := h.start(ctx, runRetriever, llmobs.SpanKindRetrieval, runRetriever)
+	h.cfg.capture.TagValue(r.span, llmobs.TagInputValue, query)
+}
+
+// HandleRetrieverEnd implements callbacks.Handler.
+func (h *Handler) HandleRetrieverEnd(ctx context.Context, _ string, documents []schema.Document) {
+	r := h.end(ctx, runRetriever)
+	if r == nil {
+		return
+	}
+	r.span.SetTag(tagRetrievedDocuments, len(documents))
+	texts := make([]string, len(documents))
+	for i, d := range documents {
+		texts[i] = d.PageContent
+	}
+	h.cfg.capture.TagDocuments(r.span, llmobs.TagOutputDocuments, texts)
+	r.span.Finish()
+}
+
+// HandleLLMStart implements callbacks.Handler.
+func (h *Handler) HandleLLMStart(ctx context.Context, prompts []string) {
+	r := h.startLLM(ctx)
+	msgs := make([]llmobs.Message, len(prompts))
+	for i, p := range prompts {
+		msgs[i] = llmobs.Message{Content: p}
+	}
+	h.cfg.capture.TagMessages(r.span, llmobs.TagInputMessages, msgs)
+}
+
+// HandleLLMGenerateContentStart implements callbacks.Handler. The run started
+// by HandleLLMStart, if any, is continued.
+func (h *Handler) HandleLLMGenerateContentStart(ctx context.Context, ms []llms.MessageContent) {
+	r := h.last(ctx, runLLM)
+	if r == nil || r.generating {
+		r = h.startLLM(ctx)
+	}
+	r.generating = true
+	msgs := make([]llmobs.Message, len(ms))
+	for i, m := range ms {
+		msgs[i] = llmobs.Message{Role: string(m.Role), Content: messageText(m)}
+	}
+	h.cfg.capture.TagMessages(r.span, llmobs.TagInputMessages, msgs)
+}
+
+func (h *Handler) startLLM(ctx context.Context) *run {
+	r := h.start(ctx, runLLM, llmobs.SpanKindLLM, runLLM)
+	r.text = llmobs.StreamText{Span: r.span, Start: time.Now()}
+	return r
+}
+
+// HandleStreamingFunc implements callbacks.Handler.
+func (h *Handler) HandleStreamingFunc(ctx context.Context, chunk []byte) {
+	if r := h.last(ctx, runLLM); r != nil {
+		r.text.Write(string(chunk))
+	}
+}
+
+// HandleLLMGenerateContentEnd implements callbacks.Handler.
+func (h *Handler) HandleLLMGenerateContentEnd(ctx context.Context, res *llms.ContentResponse) {
+	r := h.end(ctx, runLLM)
+	if r == nil {
+		return
+	}
+	if res != nil && len(res.Choices) > 0 {
+		msgs := make([]llmobs.Message, len(res.Choices))
+		for i, c := range res.Choices {
+			msgs[i] = llmobs.Message{Role: "assistant", Content: c.Content}
+		}
+		h.cfg.capture.TagMessages(r.span, llmobs.TagOutputMessages, msgs)
+		// The usage and stop reason are reported by the first choice.
+		c := res.Choices[0]
+		if c.StopReason != "" {
+			r.span.SetTag(llmobs.TagStopReason, c.StopReason)
+		}
+		tagUsage(r.span, c.GenerationInfo)
+	}
+	r.span.Finish()
+}
+
+// HandleLLMError implements callbacks.Handler.
+func (h *Handler) HandleLLMError(ctx context.Context, err error) {
+	h.fail(ctx, runLLM, err)
+}
+
+// messageText returns the text parts of m.
+func messageText(m llms.MessageContent) string {
+	texts := make([]string, 0, len(m.Parts))
+	for _, p := range m.Parts {
+		if t, ok := p.(llms.TextContent); ok {
+			texts = append(texts, t.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// values returns the JSON encoding of the inputs or outputs of a chain.
+func values(v map[string]any) string {
+	if len(v) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// Keys of the token counts in the generation info of the models, which
+// depend on their provider.
+var (
+	inputTokensKeys  = []string{"PromptTokens", "InputTokens"}
+	outputTokensKeys = []string{"CompletionTokens", "OutputTokens"}
+	totalTokensKeys  = []string{"TotalTokens"}
+)
+
+// tagUsage tags span with the token counts found in the generation info of
+// a model.
+func tagUsage(span ddtrace.Span, info map[string]any) {
+	input, output, total := tokens(info, inputTokensKeys), tokens(info, outputTokensKeys), tokens(info, totalTokensKeys)
+	if input < 0 && output < 0 && total < 0 {
+		return
+	}
+	llmobs.TagUsage(span, input, output, total)
+}
+
+// tokens returns the token count found in info under one of the given keys,
+// or -1.
+func tokens(info map[string]any, keys []string) int64 {
+	for _, k := range keys {
+		switch v := info[k].(type) {
+		case int:
+			return int64(v)
+		case int32:
+			return int64(v)
+		case int64:
+			return v
+		case float64:
+			return int64(v)
+		}
+	}
+	return -1
+}
@@ -0,0 +1,154 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package langchaingo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/llmobs"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// spansByResource returns the finished spans of mt by resource.
+func spansByResource(mt mocktracer.Tracer) map[string]mocktracer.Span {
+	spans := make(map[string]mocktracer.Span)
+	for _, s := range mt.FinishedSpans() {
+		spans[s.Tag(ext.ResourceName).(string)] = s
+	}
+	return spans
+}
+
+func TestAgent(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	h := NewHandler(WithContentCapture(true))
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "root")
+	h.HandleChainStart(ctx, map[string]any{"input": "What is 2+2?"})
+	h.HandleLLMGenerateContentStart(ctx, []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "What is 2+2?"),
+	})
+	h.HandleStreamingFunc(ctx, []byte("Action"))
+	h.HandleLLMGenerateContentEnd(ctx, &llms.ContentResponse{Choices: []*llms.ContentChoice{{
+		Content:    "Action: calculator",
+		StopReason: "stop",
+		GenerationInfo: map[string]any{
+			"PromptTokens":     10,
+			"CompletionTokens": 3,
+			"TotalTokens":      13,
+		},
+	}}})
+	h.HandleAgentAction(ctx, schema.AgentAction{Tool: "calculator", ToolInput: "2+2"})
+	h.HandleToolStart(ctx, "2+2")
+	h.HandleToolEnd(ctx, "4")
+	h.HandleAgentFinish(ctx, schema.AgentFinish{ReturnValues: map[string]any{"output": "4"}})
+	h.HandleChainEnd(ctx, map[string]any{"output": "4"})
+	root.Finish()
+
+	spans := spansByResource(mt)
+	require.Len(t, spans, 4)
+	chain, llm, tool := spans["chain"], spans["llm"], spans["calculator"]
+	require.NotNil(t, chain)
+	require.NotNil(t, llm)
+	require.NotNil(t, tool)
+
+	assert.Equal(t, root.Context().SpanID(), chain.ParentID())
+	assert.Equal(t, chain.SpanID(), llm.ParentID())
+	assert.Equal(t, chain.SpanID(), tool.ParentID())
+
+	assert.Equal(t, "langchain.request", chain.OperationName())
+	assert.Equal(t, "langchain", chain.Tag(ext.ServiceName))
+	assert.Equal(t, componentName, chain.Tag(ext.Component))
+	assert.Equal(t, llmobs.SpanKindAgent, chain.Tag(llmobs.TagSpanKind))
+	assert.Equal(t, "calculator", chain.Tag(tagAgentTool))
+	assert.Equal(t, `{"input":"What is 2+2?"}`, chain.Tag(llmobs.TagInputValue))
+	assert.Equal(t, `{"output":"4"}`, chain.Tag(llmobs.TagOutputValue))
+
+	assert.Equal(t, runLLM, llm.Tag(tagRunType))
+	assert.Equal(t, llmobs.SpanKindLLM, llm.Tag(llmobs.TagSpanKind))
+	assert.Equal(t, `[{"role":"human","content":"What is 2+2?"}]`, llm.Tag(llmobs.TagInputMessages))
+	assert.Equal(t, `[{"role":"assistant","content":"Action: calculator"}]`, llm.Tag(llmobs.TagOutputMessages))
+	assert.Equal(t, "stop", llm.Tag(llmobs.TagStopReason))
+	assert.Equal(t, int64(10), llm.Tag(llmobs.TagInputTokens))
+	assert.Equal(t, int64(3), llm.Tag(llmobs.TagOutputTokens))
+	assert.Equal(t, int64(13), llm.Tag(llmobs.TagTotalTokens))
+	assert.IsType(t, float64(0), llm.Tag(llmobs.TagTimeToFirstToken))
+
+	assert.Equal(t, llmobs.SpanKindTool, tool.Tag(llmobs.TagSpanKind))
+	assert.Equal(t, "2+2", tool.Tag(llmobs.TagInputValue))
+	assert.Equal(t, "4", tool.Tag(llmobs.TagOutputValue))
+}
+
+func TestRetriever(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	h := NewHandler()
+
+	ctx := context.Background()
+	h.HandleChainStart(ctx, map[string]any{"query": "tracing"})
+	h.HandleRetrieverStart(ctx, "tracing")
+	h.HandleRetrieverEnd(ctx, "tracing", []schema.Document{{PageContent: "a"}, {PageContent: "b"}})
+	h.HandleChainEnd(ctx, map[string]any{"text": "answer"})
+
+	spans := spansByResource(mt)
+	require.Len(t, spans, 2)
+	chain, retriever := spans["chain"], spans["retriever"]
+	assert.Equal(t, chain.SpanID(), retriever.ParentID())
+	assert.Equal(t, llmobs.SpanKindWorkflow, chain.Tag(llmobs.TagSpanKind))
+	assert.Equal(t, llmobs.SpanKindRetrieval, retriever.Tag(llmobs.TagSpanKind))
+	assert.Equal(t, 2, retriever.Tag(tagRetrievedDocuments))
+	// Contents aren't captured by default.
+	assert.Nil(t, chain.Tag(llmobs.TagInputValue))
+	assert.Nil(t, retriever.Tag(llmobs.TagOutputDocuments))
+	assert.Empty(t, h.runs)
+}
+
+func TestLLMError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	h := NewHandler()
+
+	ctx := context.Background()
+	// The run started by HandleLLMStart is continued.
+	h.HandleLLMStart(ctx, []string{"hi"})
+	h.HandleLLMGenerateContentStart(ctx, []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "hi")})
+	err := errors.New("rate limited")
+	h.HandleLLMError(ctx, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, err, spans[0].Tag(ext.Error))
+	assert.Empty(t, h.runs)
+}
+
+func TestConcurrentContexts(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	h := NewHandler()
+
+	a, ctxA := tracer.StartSpanFromContext(context.Background(), "a")
+	b, ctxB := tracer.StartSpanFromContext(context.Background(), "b")
+	h.HandleToolStart(ctxA, "a")
+	h.HandleToolStart(ctxB, "b")
+	h.HandleToolEnd(ctxA, "a")
+	h.HandleToolEnd(ctxB, "b")
+	a.Finish()
+	b.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 4)
+	assert.Equal(t, a.Context().SpanID(), spans[0].ParentID())
+	assert.Equal(t, b.Context().SpanID(), spans[1].ParentID())
+}
@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package langchaingo
+
+import (
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/llmobs"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+)
+
+const (
+	defaultServiceName = "langchain"
+	defaultSpanName    = "langchain.request"
+)
+
+type config struct {
+	serviceName   string
+	spanName      string
+	analyticsRate float64
+	capture       llmobs.Capture
+}
+
+// Option represents an option that can be passed to NewHandler.
+type Option func(*config)
+
+func defaults(cfg *config) {
+	cfg.serviceName = namingschema.NewDefaultServiceName(
+		defaultServiceName,
+		namingschema.WithOverrideV0(defaultServiceName),
+	).GetName()
+	cfg.spanName = defaultSpanName
+	if internal.BoolEnv("DD_TRACE_LANGCHAINGO_ANALYTICS_ENABLED", false) {
+		cfg.analyticsRate = 1.0
+	} else {
+		cfg.analyticsRate = math.NaN()
+	}
+	cfg.capture.Enabled = internal.BoolEnv("DD_TRACE_LANGCHAINGO_CONTENT_CAPTURE_ENABLED", false)
+	cfg.capture.Redact = llmobs.DefaultRedact
+}
+
+// WithServiceName sets the given service name for the spans.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithContentCapture enables or disables the capture of the inputs and
+// outputs of the chains, models, tools and retrievers in the tags of the
+// spans. It is disabled by default, unless the
+// DD_TRACE_LANGCHAINGO_CONTENT_CAPTURE_ENABLED environment variable is set to
+// true.
+func WithContentCapture(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.capture.Enabled = enabled
+	}
+}
+
+// WithRedactor sets the function returning the value a captured input or
+// output is tagged with. By default, email addresses, payment card numbers
+// and API keys are redacted. A nil function disables the redaction.
+func WithRedactor(fn func(content string) string) Option {
+	return func(cfg *config) {
+		cfg.capture.Redact = fn
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package kgo_test
+
+import (
+	"context"
+	"log"
+
+	kgotrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/twmb/franz-go"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func ExampleClient_Produce() {
+	c, err := kgotrace.NewClient([]kgo.Opt{kgo.SeedBrokers("localhost:9092")})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+
+	r := &kgo.Record{Topic: "some-topic", Value: []byte("value")}
+	if err := c.ProduceSync(context.Background(), r).FirstErr(); err != nil {
+		log.Fatal("Failed to produce record", err)
+	}
+}
+
+func ExampleClient_PollFetches() {
+	c, err := kgotrace.NewClient([]kgo.Opt{
+		kgo.SeedBrokers("localhost:9092"),
+		kgo.ConsumerGroup("group-id"),
+		kgo.ConsumeTopics("some-topic"),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+
+	fetches := c.PollFetches(context.Background())
+	fetches.EachRecord(func(r *kgo.Record) {
+		// create a child span of the consumer span using the span context
+		// found in the record headers
+		spanContext, err := kgotrace.ExtractSpanContext(r)
+		if err != nil {
+			log.Fatal("Failed to extract span context from carrier", err)
+		}
+		s := tracer.StartSpan("child-span", tracer.ChildOf(spanContext))
+		defer s.Finish()
+	})
+}
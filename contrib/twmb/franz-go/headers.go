@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package kgo
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// A recordCarrier implements TextMapReader/TextMapWriter for extracting/injecting traces on a kgo.Record
+type recordCarrier struct {
+	r *kgo.Record
+}
+
+var _ interface {
+	tracer.TextMapReader
+	tracer.TextMapWriter
+} = (*recordCarrier)(nil)
+
+// ForeachKey conforms to the TextMapReader interface.
+func (c recordCarrier) ForeachKey(handler func(key, val string) error) error {
+	for _, h := range c.r.Headers {
+		err := handler(h.Key, string(h.Value))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set implements TextMapWriter
+func (c recordCarrier) Set(key, val string) {
+	// ensure uniqueness of keys
+	for i := 0; i < len(c.r.Headers); i++ {
+		if c.r.Headers[i].Key == key {
+			c.r.Headers = append(c.r.Headers[:i], c.r.Headers[i+1:]...)
+			i--
+		}
+	}
+	c.r.Headers = append(c.r.Headers, kgo.RecordHeader{
+		Key:   key,
+		Value: []byte(val),
+	})
+}
+
+// ExtractSpanContext retrieves the SpanContext from a kgo.Record
+func ExtractSpanContext(r *kgo.Record) (ddtrace.SpanContext, error) {
+	return tracer.Extract(recordCarrier{r})
+}
+
+// ContextWithRecord returns a copy of ctx holding the Data Streams pathway
+// found in the headers of r, if any. Passing the returned context to
+// Client.Produce links the produced records to the consumed one in the Data
+// Streams map.
+func ContextWithRecord(ctx context.Context, r *kgo.Record) context.Context {
+	return datastreams.ExtractFromBase64Carrier(ctx, recordCarrier{r})
+}
@@ -0,0 +1,307 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package kgo provides functions to trace the twmb/franz-go package (https://github.com/twmb/franz-go).
+// When Data Streams Monitoring is enabled, the produced and consumed records
+// are also checkpointed, and the produced, committed and high watermark
+// offsets are reported, so that the consumer lag can be computed.
+package kgo // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/twmb/franz-go"
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const componentName = "twmb/franz-go"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// NewClient calls kgo.NewClient with kopts and wraps the resulting Client.
+func NewClient(kopts []kgo.Opt, opts ...Option) (*Client, error) {
+	c, err := kgo.NewClient(kopts...)
+	if err != nil {
+		return nil, err
+	}
+	return WrapClient(c, opts...), nil
+}
+
+// WrapClient wraps a kgo.Client so that produced and polled records are
+// traced.
+func WrapClient(c *kgo.Client, opts ...Option) *Client {
+	wrapped := &Client{
+		Client: c,
+		cfg:    newConfig(opts...),
+	}
+	if group, ok := c.OptValue(kgo.ConsumerGroup).(string); ok {
+		wrapped.groupID = group
+	}
+	if brokers, ok := c.OptValue(kgo.SeedBrokers).([]string); ok {
+		wrapped.bootstrapServers = strings.Join(brokers, ",")
+	}
+	log.Debug("contrib/twmb/franz-go: Wrapping Client: %#v", wrapped.cfg)
+	return wrapped
+}
+
+// A Client wraps a kgo.Client.
+type Client struct {
+	*kgo.Client
+	cfg              *config
+	groupID          string
+	bootstrapServers string
+	prev             ddtrace.Span
+}
+
+// Produce calls kgo.Client.Produce and traces the request. The span is
+// finished when the record is acknowledged, before promise is called. The
+// span context and the Data Streams pathway are injected into the headers of
+// r.
+func (c *Client) Produce(ctx context.Context, r *kgo.Record, promise func(*kgo.Record, error)) {
+	span := c.startProduceSpan(ctx, r)
+	c.Client.Produce(ctx, r, func(r *kgo.Record, err error) {
+		finishProduceSpan(span, r, err)
+		if promise != nil {
+			promise(r, err)
+		}
+	})
+}
+
+// ProduceSync calls kgo.Client.ProduceSync and traces the request of each
+// record.
+func (c *Client) ProduceSync(ctx context.Context, rs ...*kgo.Record) kgo.ProduceResults {
+	// although the records are produced in batches, they are treated
+	// individually, so we create a span for each one
+	spans := make([]ddtrace.Span, len(rs))
+	for i, r := range rs {
+		spans[i] = c.startProduceSpan(ctx, r)
+	}
+	results := c.Client.ProduceSync(ctx, rs...)
+	for i, res := range results {
+		finishProduceSpan(spans[i], res.Record, res.Err)
+	}
+	return results
+}
+
+func (c *Client) startProduceSpan(ctx context.Context, r *kgo.Record) ddtrace.Span {
+	opts := []tracer.StartSpanOption{
+		tracer.ServiceName(c.cfg.producerServiceName),
+		tracer.ResourceName("Produce Topic " + r.Topic),
+		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindProducer),
+		tracer.Tag(ext.MessagingSystem, ext.MessagingSystemKafka),
+		tracer.Tag(ext.KafkaBootstrapServers, c.bootstrapServers),
+	}
+	if !math.IsNaN(c.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
+	}
+	carrier := recordCarrier{r}
+	span, ctx := tracer.StartSpanFromContext(ctx, c.cfg.producerSpanName, opts...)
+	if err := tracer.Inject(span.Context(), carrier); err != nil {
+		log.Debug("contrib/twmb/franz-go: Failed to inject span context into carrier, %v", err)
+	}
+	if p := datastreams.GetGlobalProcessor(); p != nil {
+		ctx = p.SetCheckpointWithParams(ctx,
+			datastreams.CheckpointParams{PayloadSize: int64(len(r.Key) + len(r.Value))},
+			"direction:out",
+			"topic:"+r.Topic,
+			"type:kafka",
+		)
+		datastreams.InjectToBase64Carrier(ctx, carrier)
+	}
+	return span
+}
+
+func finishProduceSpan(span ddtrace.Span, r *kgo.Record, err error) {
+	if err == nil {
+		span.SetTag(ext.MessagingKafkaPartition, r.Partition)
+		span.SetTag("offset", r.Offset)
+		if p := datastreams.GetGlobalProcessor(); p != nil {
+			p.TrackKafkaProduceOffset(r.Topic, r.Partition, r.Offset)
+		}
+	}
+	span.Finish(tracer.WithError(err))
+}
+
+// PollFetches calls kgo.Client.PollFetches and traces the polled records.
+// See PollRecords.
+func (c *Client) PollFetches(ctx context.Context) kgo.Fetches {
+	c.finishPrev()
+	fetches := c.Client.PollFetches(ctx)
+	c.traceFetches(ctx, fetches)
+	return fetches
+}
+
+// PollRecords calls kgo.Client.PollRecords and traces the polled records.
+// A single span is created for all the records of a poll, which is finished
+// on the next poll or when the client is closed. When a single record is
+// polled, the span is a child of the span of its production; otherwise, it
+// links to the span of the production of each record. The span context of
+// the consumer and the Data Streams pathway are injected into the headers of
+// each record, so that they can be retrieved using ExtractSpanContext and
+// ContextWithRecord.
+func (c *Client) PollRecords(ctx context.Context, maxPollRecords int) kgo.Fetches {
+	c.finishPrev()
+	fetches := c.Client.PollRecords(ctx, maxPollRecords)
+	c.traceFetches(ctx, fetches)
+	return fetches
+}
+
+// Close calls kgo.Client.Close and finishes the span of the last poll, if
+// any.
+func (c *Client) Close() {
+	c.Client.Close()
+	c.finishPrev()
+}
+
+func (c *Client) finishPrev() {
+	if c.prev != nil {
+		c.prev.Finish()
+		c.prev = nil
+	}
+}
+
+func (c *Client) traceFetches(ctx context.Context, fetches kgo.Fetches) {
+	if p := datastreams.GetGlobalProcessor(); p != nil && c.groupID != "" {
+		fetches.EachPartition(func(fp kgo.FetchTopicPartition) {
+			p.TrackKafkaHighWatermarkOffset(c.groupID, fp.Topic, fp.Partition, fp.HighWatermark)
+		})
+	}
+	c.prev = c.traceRecords(ctx, fetches.Records())
+}
+
+// traceRecords starts the span of the consumption of rs, and re-injects the
+// resulting span context and pathway into their headers. It returns nil if
+// rs is empty.
+func (c *Client) traceRecords(ctx context.Context, rs []*kgo.Record) ddtrace.Span {
+	if len(rs) == 0 {
+		return nil
+	}
+	opts := []tracer.StartSpanOption{
+		tracer.ServiceName(c.cfg.consumerServiceName),
+		tracer.ResourceName(consumeResource(rs)),
+		tracer.SpanType(ext.SpanTypeMessageConsumer),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindConsumer),
+		tracer.Tag(ext.MessagingSystem, ext.MessagingSystemKafka),
+		tracer.Tag(ext.KafkaBootstrapServers, c.bootstrapServers),
+		tracer.Tag(ext.MessagingBatchMessageCount, len(rs)),
+		tracer.Measured(),
+	}
+	if !math.IsNaN(c.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
+	}
+	if len(rs) == 1 {
+		opts = append(opts,
+			tracer.Tag(ext.MessagingKafkaPartition, rs[0].Partition),
+			tracer.Tag("offset", rs[0].Offset),
+		)
+		if spanctx, err := ExtractSpanContext(rs[0]); err == nil {
+			opts = append(opts, tracer.ChildOf(spanctx))
+		}
+	} else {
+		// a span can only have one parent, so the records are linked to
+		// instead, to preserve causality information
+		links := make([]ddtrace.SpanLink, 0, len(rs))
+		for _, r := range rs {
+			if spanctx, err := ExtractSpanContext(r); err == nil {
+				links = append(links, spanLink(spanctx))
+			}
+		}
+		if len(links) > 0 {
+			opts = append(opts, tracer.WithSpanLinks(links))
+		}
+	}
+	span, _ := tracer.StartSpanFromContext(ctx, c.cfg.consumerSpanName, opts...)
+	p := datastreams.GetGlobalProcessor()
+	for _, r := range rs {
+		carrier := recordCarrier{r}
+		// reinject the span context so consumers can pick it up
+		if err := tracer.Inject(span.Context(), carrier); err != nil {
+			log.Debug("contrib/twmb/franz-go: Failed to inject span context into carrier, %v", err)
+		}
+		if p == nil {
+			continue
+		}
+		edges := []string{"direction:in", "topic:" + r.Topic, "type:kafka"}
+		if c.groupID != "" {
+			edges = append(edges, "group:"+c.groupID)
+		}
+		ctx := datastreams.ExtractFromBase64Carrier(context.Background(), carrier)
+		ctx = p.SetCheckpointWithParams(ctx,
+			datastreams.CheckpointParams{PayloadSize: int64(len(r.Key) + len(r.Value))},
+			edges...,
+		)
+		datastreams.InjectToBase64Carrier(ctx, carrier)
+	}
+	return span
+}
+
+// consumeResource returns the resource name of the span of the consumption of
+// rs, which names their topic if they all belong to the same one.
+func consumeResource(rs []*kgo.Record) string {
+	for _, r := range rs[1:] {
+		if r.Topic != rs[0].Topic {
+			return "Consume"
+		}
+	}
+	return "Consume Topic " + rs[0].Topic
+}
+
+// spanLink returns a span link pointing to the span identified by ctx.
+func spanLink(ctx ddtrace.SpanContext) ddtrace.SpanLink {
+	link := ddtrace.SpanLink{
+		TraceID: ctx.TraceID(),
+		SpanID:  ctx.SpanID(),
+	}
+	if w3c, ok := ctx.(ddtrace.SpanContextW3C); ok {
+		id := w3c.TraceID128Bytes()
+		link.TraceIDHigh = binary.BigEndian.Uint64(id[:8])
+	}
+	return link
+}
+
+// CommitRecords calls kgo.Client.CommitRecords. When Data Streams Monitoring
+// is enabled, the committed offsets are reported to compute the backlog of
+// the consumer group.
+func (c *Client) CommitRecords(ctx context.Context, rs ...*kgo.Record) error {
+	err := c.Client.CommitRecords(ctx, rs...)
+	if p := datastreams.GetGlobalProcessor(); err == nil && p != nil && c.groupID != "" {
+		for _, r := range rs {
+			// as in Kafka, the committed offset is the one of the next
+			// record to read
+			p.TrackKafkaCommitOffset(c.groupID, r.Topic, r.Partition, r.Offset+1)
+		}
+	}
+	return err
+}
+
+// CommitUncommittedOffsets calls kgo.Client.CommitUncommittedOffsets. When
+// Data Streams Monitoring is enabled, the committed offsets are reported to
+// compute the backlog of the consumer group.
+func (c *Client) CommitUncommittedOffsets(ctx context.Context) error {
+	offsets := c.Client.UncommittedOffsets()
+	err := c.Client.CommitUncommittedOffsets(ctx)
+	if p := datastreams.GetGlobalProcessor(); err == nil && p != nil && c.groupID != "" {
+		for topic, partitions := range offsets {
+			for partition, o := range partitions {
+				p.TrackKafkaCommitOffset(c.groupID, topic, partition, o.Offset)
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package kgo
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestProduceConsume(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := &Client{cfg: newConfig(), bootstrapServers: "localhost:9092"}
+	r := &kgo.Record{Topic: "topic", Value: []byte("hello")}
+	span := c.startProduceSpan(context.Background(), r)
+	r.Partition, r.Offset = 1, 42
+	finishProduceSpan(span, r, nil)
+
+	consume := c.traceRecords(context.Background(), []*kgo.Record{r})
+	require.NotNil(t, consume)
+	consume.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	s0, s1 := spans[0], spans[1]
+	assert.Equal(t, "kafka.produce", s0.OperationName())
+	assert.Equal(t, "Produce Topic topic", s0.Tag(ext.ResourceName))
+	assert.Equal(t, ext.SpanKindProducer, s0.Tag(ext.SpanKind))
+	assert.Equal(t, ext.MessagingSystemKafka, s0.Tag(ext.MessagingSystem))
+	assert.Equal(t, "localhost:9092", s0.Tag(ext.KafkaBootstrapServers))
+	assert.Equal(t, int32(1), s0.Tag(ext.MessagingKafkaPartition))
+	assert.Equal(t, int64(42), s0.Tag("offset"))
+	assert.Equal(t, componentName, s0.Tag(ext.Component))
+
+	assert.Equal(t, "kafka.consume", s1.OperationName())
+	assert.Equal(t, "Consume Topic topic", s1.Tag(ext.ResourceName))
+	assert.Equal(t, ext.SpanKindConsumer, s1.Tag(ext.SpanKind))
+	assert.Equal(t, 1, s1.Tag(ext.MessagingBatchMessageCount))
+	assert.Equal(t, int32(1), s1.Tag(ext.MessagingKafkaPartition))
+	assert.Equal(t, s0.SpanID(), s1.ParentID())
+	assert.Equal(t, s0.TraceID(), s1.TraceID())
+
+	spanctx, err := ExtractSpanContext(r)
+	require.NoError(t, err)
+	assert.Equal(t, s1.SpanID(), spanctx.SpanID())
+}
+
+func TestConsumeBatch(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := &Client{cfg: newConfig()}
+	rs := []*kgo.Record{{Topic: "a"}, {Topic: "b"}}
+	for _, r := range rs {
+		c.startProduceSpan(context.Background(), r).Finish()
+	}
+	consume := c.traceRecords(context.Background(), rs)
+	require.NotNil(t, consume)
+	consume.Finish()
+
+	spans := mocktracer.FinishedSpansByOperation(mt, "kafka.consume")
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "Consume", s.Tag(ext.ResourceName))
+	assert.Equal(t, 2, s.Tag(ext.MessagingBatchMessageCount))
+	// the records are linked to, rather than parents of the span
+	assert.Zero(t, s.ParentID())
+	for _, r := range rs {
+		spanctx, err := ExtractSpanContext(r)
+		require.NoError(t, err)
+		assert.Equal(t, s.SpanID(), spanctx.SpanID())
+	}
+
+	assert.Nil(t, c.traceRecords(context.Background(), nil))
+}
+
+func TestSpanLink(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span := mt.StartSpan("produce")
+	link := spanLink(span.Context())
+	assert.Equal(t, span.Context().TraceID(), link.TraceID)
+	assert.Equal(t, span.Context().SpanID(), link.SpanID)
+}
+
+func TestProduceError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := &Client{cfg: newConfig()}
+	r := &kgo.Record{Topic: "topic"}
+	finishProduceSpan(c.startProduceSpan(context.Background(), r), r, errors.New("produce failed"))
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.NotNil(t, spans[0].Tag(ext.Error))
+	assert.Nil(t, spans[0].Tag(ext.MessagingKafkaPartition))
+}
+
+func TestDataStreams(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	p := datastreams.NewProcessor(&statsd.NoOpClient{}, "env", "service", &url.URL{Scheme: "http", Host: "agent-address"}, nil)
+	datastreams.SetGlobalProcessor(p)
+	defer datastreams.SetGlobalProcessor(nil)
+
+	c := &Client{cfg: newConfig(), groupID: "group"}
+	r := &kgo.Record{Topic: "topic", Value: []byte("hello")}
+	c.startProduceSpan(context.Background(), r).Finish()
+	produced, ok := datastreams.PathwayFromContext(ContextWithRecord(context.Background(), r))
+	require.True(t, ok)
+
+	c.traceRecords(context.Background(), []*kgo.Record{r}).Finish()
+	consumed, ok := datastreams.PathwayFromContext(ContextWithRecord(context.Background(), r))
+	require.True(t, ok)
+	assert.NotEqual(t, produced.GetHash(), consumed.GetHash())
+	assert.Equal(t, produced.PathwayStart(), consumed.PathwayStart())
+}
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package fasthttp
+
+import (
+	"net/http"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/httpsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+// withAppSec monitors the requests served by h with AppSec, by serving them
+// through httpsec.WrapHandler as net/http requests.
+func withAppSec(h fasthttp.RequestHandler, span tracer.Span) fasthttp.RequestHandler {
+	return func(fctx *fasthttp.RequestCtx) {
+		var r http.Request
+		if err := fasthttpadaptor.ConvertRequest(fctx, &r, true); err != nil {
+			log.Debug("contrib/valyala/fasthttp.v1: failed to convert request for appsec: %v", err)
+			h(fctx)
+			return
+		}
+		w := &responseWriter{fctx: fctx, header: make(http.Header)}
+		handler := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			// Expose the context of the appsec operation to the handler.
+			fctx.SetUserValue(contextKey{}, r.Context())
+			h(fctx)
+			// Let appsec monitor the response headers set by h.
+			fctx.Response.Header.VisitAll(func(k, v []byte) {
+				w.header.Add(string(k), string(v))
+			})
+		})
+		httpsec.WrapHandler(handler, span, nil).ServeHTTP(w, r.WithContext(Context(fctx)))
+	}
+}
+
+// responseWriter is an http.ResponseWriter writing to the response of a
+// fasthttp request, such as the one of a request blocked by appsec.
+type responseWriter struct {
+	fctx        *fasthttp.RequestCtx
+	header      http.Header
+	wroteHeader bool
+}
+
+// Header implements http.ResponseWriter.
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *responseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	for k, vs := range w.header {
+		w.fctx.Response.Header.Del(k)
+		for _, v := range vs {
+			w.fctx.Response.Header.Add(k, v)
+		}
+	}
+	w.fctx.SetStatusCode(status)
+}
+
+// Write implements http.ResponseWriter.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.fctx.Write(b)
+}
+
+// Status returns the status code of the response, for httpsec.WrapHandler.
+func (w *responseWriter) Status() int {
+	return w.fctx.Response.StatusCode()
+}
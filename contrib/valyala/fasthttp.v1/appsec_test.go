@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package fasthttp
+
+import (
+	"strings"
+	"testing"
+
+	pappsec "gopkg.in/DataDog/dd-trace-go.v1/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func TestAppSec(t *testing.T) {
+	appsec.Start()
+	defer appsec.Stop()
+
+	if !appsec.Enabled() {
+		t.Skip("appsec disabled")
+	}
+
+	client := startServer(t, WrapHandler(func(fctx *fasthttp.RequestCtx) {
+		if string(fctx.Path()) == "/body" {
+			pappsec.MonitorParsedHTTPBody(Context(fctx), "$globals")
+		}
+		fctx.Response.Header.Set("X-Handler", "called")
+		fctx.WriteString("Hello World!\n")
+	}))
+
+	do := func(t *testing.T, method, url string) *fasthttp.Response {
+		req := fasthttp.AcquireRequest()
+		defer fasthttp.ReleaseRequest(req)
+		resp := new(fasthttp.Response)
+		req.Header.SetMethod(method)
+		req.SetRequestURI(url)
+		require.NoError(t, client.Do(req, resp))
+		return resp
+	}
+
+	t.Run("query", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		// Send a security scanner attack (according to appsec rule id crs-913-120)
+		resp := do(t, "GET", "http://example.com/?q=appscan_fingerprint")
+		assert.Equal(t, "Hello World!\n", string(resp.Body()))
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		event, _ := spans[0].Tag("_dd.appsec.json").(string)
+		assert.True(t, strings.Contains(event, "crs-913-120"), event)
+		assert.Equal(t, "called", spans[0].Tag("http.response.headers.x-handler"))
+	})
+
+	t.Run("body", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		do(t, "POST", "http://example.com/body")
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		event, _ := spans[0].Tag("_dd.appsec.json").(string)
+		assert.True(t, strings.Contains(event, "crs-933-130"), event)
+	})
+}
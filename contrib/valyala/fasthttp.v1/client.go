@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package fasthttp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/httpsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Client is a fasthttp.Client tracing the requests sent with its Do,
+// DoTimeout, DoDeadline and DoRedirects methods. Requests sent with other
+// methods, such as Get or Post, are not traced.
+type Client struct {
+	*fasthttp.Client
+	cfg *config
+	ctx context.Context
+}
+
+// WrapClient returns a Client tracing the requests sent with c.
+func WrapClient(c *fasthttp.Client, opts ...Option) *Client {
+	cfg := newClientConfig(opts...)
+	log.Debug("contrib/valyala/fasthttp.v1: Wrapping Client: %#v", cfg)
+	return &Client{Client: c, cfg: cfg, ctx: context.Background()}
+}
+
+// WithContext returns a copy of the client tracing its requests as children
+// of the span found in ctx, if any.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	c2 := *c
+	c2.ctx = ctx
+	return &c2
+}
+
+// Do calls fasthttp.Client.Do and traces the request.
+func (c *Client) Do(req *fasthttp.Request, resp *fasthttp.Response) error {
+	return c.trace(req, resp, func() error {
+		return c.Client.Do(req, resp)
+	})
+}
+
+// DoTimeout calls fasthttp.Client.DoTimeout and traces the request.
+func (c *Client) DoTimeout(req *fasthttp.Request, resp *fasthttp.Response, timeout time.Duration) error {
+	return c.trace(req, resp, func() error {
+		return c.Client.DoTimeout(req, resp, timeout)
+	})
+}
+
+// DoDeadline calls fasthttp.Client.DoDeadline and traces the request.
+func (c *Client) DoDeadline(req *fasthttp.Request, resp *fasthttp.Response, deadline time.Time) error {
+	return c.trace(req, resp, func() error {
+		return c.Client.DoDeadline(req, resp, deadline)
+	})
+}
+
+// DoRedirects calls fasthttp.Client.DoRedirects and traces the request.
+func (c *Client) DoRedirects(req *fasthttp.Request, resp *fasthttp.Response, maxRedirectsCount int) error {
+	return c.trace(req, resp, func() error {
+		return c.Client.DoRedirects(req, resp, maxRedirectsCount)
+	})
+}
+
+// trace traces the request req sent by do.
+func (c *Client) trace(req *fasthttp.Request, resp *fasthttp.Response, do func() error) (err error) {
+	if c.cfg.ignoreRequest(req) {
+		return do()
+	}
+	uri := req.URI()
+	// Do not include userinfo nor the query in the HTTPURL tag.
+	u := url.URL{
+		Scheme: string(uri.Scheme()),
+		Host:   string(uri.Host()),
+		Path:   string(uri.Path()),
+	}
+	spanOpts := []ddtrace.StartSpanOption{
+		tracer.SpanType(ext.SpanTypeHTTP),
+		tracer.ResourceName(c.cfg.resourceNamer(req.Header.Method(), uri.Path())),
+		tracer.Tag(ext.HTTPMethod, string(req.Header.Method())),
+		tracer.Tag(ext.HTTPURL, u.String()),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		tracer.Tag(ext.NetworkDestinationName, u.Hostname()),
+	}
+	if c.cfg.serviceName != "" {
+		spanOpts = append(spanOpts, tracer.ServiceName(c.cfg.serviceName))
+	}
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		spanOpts = append(spanOpts, tracer.Tag(ext.NetworkDestinationPort, port))
+	}
+	if !math.IsNaN(c.cfg.analyticsRate) {
+		spanOpts = append(spanOpts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
+	}
+	spanOpts = append(spanOpts, c.cfg.spanOpts...)
+	span, ctx := tracer.StartSpanFromContext(c.ctx, c.cfg.spanName, spanOpts...)
+	defer func() {
+		span.Finish(tracer.WithError(err))
+	}()
+
+	if err := tracer.Inject(span.Context(), requestHeaderCarrier{&req.Header}); err != nil {
+		// this should never happen
+		log.Debug("contrib/valyala/fasthttp.v1: failed to inject http headers: %v", err)
+	}
+	if appsec.Enabled() {
+		// Block the outgoing request when AppSec detects a server-side request forgery.
+		if err := httpsec.ProtectRoundTrip(ctx, uri.String()); err != nil {
+			return err
+		}
+	}
+	if err := do(); err != nil {
+		return err
+	}
+	status := resp.StatusCode()
+	span.SetTag(ext.HTTPCode, strconv.Itoa(status))
+	if c.cfg.isStatusError(status) {
+		span.SetTag(ext.Error, fmt.Errorf("%d: %s", status, http.StatusText(status)))
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package fasthttp_test
+
+import (
+	fasthttptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/valyala/fasthttp.v1"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/valyala/fasthttp"
+)
+
+func Example() {
+	tracer.Start()
+	defer tracer.Stop()
+
+	handler := func(fctx *fasthttp.RequestCtx) {
+		fctx.WriteString("Hello World!\n")
+	}
+	fasthttp.ListenAndServe(":8080", fasthttptrace.WrapHandler(handler, fasthttptrace.WithServiceName("fasthttp-server")))
+}
+
+func ExampleWrapClient() {
+	tracer.Start()
+	defer tracer.Stop()
+
+	client := fasthttptrace.WrapClient(&fasthttp.Client{})
+	handler := func(fctx *fasthttp.RequestCtx) {
+		req := fasthttp.AcquireRequest()
+		defer fasthttp.ReleaseRequest(req)
+		resp := fasthttp.AcquireResponse()
+		defer fasthttp.ReleaseResponse(resp)
+		req.SetRequestURI("http://example.com/")
+
+		// Trace the request as a child of the request being served.
+		if err := client.WithContext(fasthttptrace.Context(fctx)).Do(req, resp); err != nil {
+			fctx.Error(err.Error(), fasthttp.StatusBadGateway)
+			return
+		}
+		fctx.SetBody(resp.Body())
+	}
+	fasthttp.ListenAndServe(":8080", fasthttptrace.WrapHandler(handler))
+}
@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package fasthttp provides functions to trace the valyala/fasthttp package (https://github.com/valyala/fasthttp).
+package fasthttp // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/valyala/fasthttp.v1"
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/valyala/fasthttp"
+)
+
+const componentName = "valyala/fasthttp.v1"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// contextKey is the user value key of the traced context of a request.
+type contextKey struct{}
+
+// WrapHandler returns a request handler tracing the requests served by h.
+// The span of a request is available from the context returned by Context.
+func WrapHandler(h fasthttp.RequestHandler, opts ...Option) fasthttp.RequestHandler {
+	cfg := newServerConfig(opts...)
+	log.Debug("contrib/valyala/fasthttp.v1: Wrapping Handler: %#v", cfg)
+	return func(fctx *fasthttp.RequestCtx) {
+		if cfg.ignoreRequest(&fctx.Request) {
+			h(fctx)
+			return
+		}
+		scheme := "http"
+		if fctx.IsTLS() {
+			scheme = "https"
+		}
+		spanOpts := []ddtrace.StartSpanOption{
+			tracer.SpanType(ext.SpanTypeWeb),
+			tracer.ServiceName(cfg.serviceName),
+			tracer.ResourceName(cfg.resourceNamer(fctx.Method(), fctx.Path())),
+			tracer.Tag(ext.HTTPMethod, string(fctx.Method())),
+			tracer.Tag(ext.HTTPURL, scheme+"://"+string(fctx.Host())+string(fctx.Path())),
+			tracer.Tag(ext.HTTPUserAgent, string(fctx.UserAgent())),
+			tracer.Tag(ext.Component, componentName),
+			tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+			tracer.Measured(),
+		}
+		if host := fctx.Host(); len(host) > 0 {
+			spanOpts = append(spanOpts, tracer.Tag("http.host", string(host)))
+		}
+		if !math.IsNaN(cfg.analyticsRate) {
+			spanOpts = append(spanOpts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+		}
+		if spanctx, err := tracer.Extract(requestHeaderCarrier{&fctx.Request.Header}); err == nil {
+			spanOpts = append(spanOpts, tracer.ChildOf(spanctx))
+		}
+		spanOpts = append(spanOpts, cfg.spanOpts...)
+		span, ctx := tracer.StartSpanFromContext(fctx, cfg.spanName, spanOpts...)
+		fctx.SetUserValue(contextKey{}, ctx)
+		defer func() {
+			status := fctx.Response.StatusCode()
+			span.SetTag(ext.HTTPCode, strconv.Itoa(status))
+			if cfg.isStatusError(status) {
+				span.SetTag(ext.Error, fmt.Errorf("%d: %s", status, http.StatusText(status)))
+			}
+			span.Finish()
+		}()
+
+		handler := h
+		if appsec.Enabled() {
+			handler = withAppSec(h, span)
+		}
+		handler(fctx)
+	}
+}
+
+// Context returns the context of a request traced by WrapHandler, holding its
+// span. The request context itself is returned if it is not traced.
+func Context(fctx *fasthttp.RequestCtx) context.Context {
+	if ctx, ok := fctx.UserValue(contextKey{}).(context.Context); ok {
+		return ctx
+	}
+	return fctx
+}
+
+// requestHeaderCarrier implements tracer.TextMapWriter and
+// tracer.TextMapReader on the headers of a fasthttp request.
+type requestHeaderCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+var (
+	_ tracer.TextMapWriter = (*requestHeaderCarrier)(nil)
+	_ tracer.TextMapReader = (*requestHeaderCarrier)(nil)
+)
+
+// Set implements tracer.TextMapWriter.
+func (c requestHeaderCarrier) Set(key, val string) {
+	c.header.Set(key, val)
+}
+
+// ForeachKey implements tracer.TextMapReader.
+func (c requestHeaderCarrier) ForeachKey(handler func(key, val string) error) error {
+	var err error
+	c.header.VisitAll(func(k, v []byte) {
+		if err == nil {
+			err = handler(string(k), string(v))
+		}
+	})
+	return err
+}
@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package fasthttp
+
+import (
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+
+	"github.com/valyala/fasthttp"
+)
+
+const defaultServiceName = "fasthttp"
+
+type config struct {
+	serviceName   string
+	spanName      string
+	analyticsRate float64
+	isStatusError func(statusCode int) bool
+	resourceNamer func(method, path []byte) string
+	ignoreRequest func(*fasthttp.Request) bool
+	spanOpts      []ddtrace.StartSpanOption
+}
+
+// Option represents an option that can be passed to WrapHandler or
+// WrapClient.
+type Option func(*config)
+
+func newServerConfig(opts ...Option) *config {
+	cfg := &config{
+		serviceName: namingschema.NewDefaultServiceName(defaultServiceName).GetName(),
+		spanName:    namingschema.NewHTTPServerOp().GetName(),
+	}
+	return cfg.apply(opts)
+}
+
+func newClientConfig(opts ...Option) *config {
+	cfg := &config{
+		serviceName: namingschema.NewDefaultServiceName(
+			"",
+			namingschema.WithOverrideV0(""),
+		).GetName(),
+		spanName: namingschema.NewHTTPClientOp().GetName(),
+	}
+	return cfg.apply(opts)
+}
+
+func (cfg *config) apply(opts []Option) *config {
+	cfg.isStatusError = isServerError
+	cfg.resourceNamer = defaultResourceNamer
+	cfg.ignoreRequest = func(*fasthttp.Request) bool { return false }
+	if internal.BoolEnv("DD_TRACE_FASTHTTP_ANALYTICS_ENABLED", false) {
+		cfg.analyticsRate = 1.0
+	} else {
+		cfg.analyticsRate = globalconfig.AnalyticsRate()
+	}
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return cfg
+}
+
+// WithServiceName sets the given service name for the started spans.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithSpanOptions applies the given set of options to the started spans.
+func WithSpanOptions(opts ...ddtrace.StartSpanOption) Option {
+	return func(cfg *config) {
+		cfg.spanOpts = append(cfg.spanOpts, opts...)
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithStatusCheck specifies a function telling whether a response status code
+// marks the span as an error. By default, 5xx status codes do.
+func WithStatusCheck(fn func(statusCode int) bool) Option {
+	return func(cfg *config) {
+		cfg.isStatusError = fn
+	}
+}
+
+// WithResourceNamer specifies a function returning the resource name of the
+// span of a request given its method and path. By default, the method is used,
+// as paths may have a high cardinality.
+func WithResourceNamer(fn func(method, path []byte) string) Option {
+	return func(cfg *config) {
+		cfg.resourceNamer = fn
+	}
+}
+
+// WithIgnoreRequest specifies a function telling whether a request should not
+// be traced.
+func WithIgnoreRequest(fn func(*fasthttp.Request) bool) Option {
+	return func(cfg *config) {
+		cfg.ignoreRequest = fn
+	}
+}
+
+func defaultResourceNamer(method, _ []byte) string {
+	return string(method)
+}
+
+func isServerError(statusCode int) bool {
+	return statusCode >= 500 && statusCode < 600
+}
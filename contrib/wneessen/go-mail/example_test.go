@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package mail_test
+
+import (
+	"context"
+	"log"
+
+	mailtrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/wneessen/go-mail"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/wneessen/go-mail"
+)
+
+func Example() {
+	c, err := mailtrace.NewClient("smtp.example.com", []mail.Option{
+		mail.WithSMTPAuth(mail.SMTPAuthPlain),
+		mail.WithUsername("user"),
+		mail.WithPassword("secret"),
+	}, mailtrace.WithServiceName("mailer"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	m := mail.NewMsg()
+	if err := m.From("noreply@example.com"); err != nil {
+		log.Fatal(err)
+	}
+	if err := m.To("gopher@example.com"); err != nil {
+		log.Fatal(err)
+	}
+	m.Subject("Welcome")
+	m.SetBodyString(mail.TypeTextPlain, "Hello!")
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "signup")
+	defer span.Finish()
+
+	// Sending the message is traced by a child of span.
+	if err := c.DialAndSendWithContext(ctx, m); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,147 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package mail provides functions to trace the wneessen/go-mail package (https://github.com/wneessen/go-mail).
+//
+// Every call sending messages is traced by a span tagged with the address of
+// the SMTP server, the number of messages, their total size and their total
+// number of recipients. The addresses of the recipients are never collected.
+package mail // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/wneessen/go-mail"
+
+import (
+	"context"
+	"io"
+	"math"
+	"net"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/wneessen/go-mail"
+)
+
+const componentName = "wneessen/go-mail"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// Tags set on the send spans.
+const (
+	tagMessageCount   = "smtp.message_count"
+	tagMessageSize    = "smtp.message_size"
+	tagRecipientCount = "smtp.recipient_count"
+)
+
+// Client is a traced mail.Client. The methods sending messages are traced,
+// the others are those of the embedded mail.Client.
+type Client struct {
+	*mail.Client
+	cfg *config
+}
+
+// NewClient calls mail.NewClient with the given host and options and wraps
+// the returned client.
+func NewClient(host string, mailOpts []mail.Option, opts ...Option) (*Client, error) {
+	c, err := mail.NewClient(host, mailOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return WrapClient(c, opts...), nil
+}
+
+// WrapClient wraps the given client so that the messages it sends are traced.
+func WrapClient(c *mail.Client, opts ...Option) *Client {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/wneessen/go-mail: Wrapping Client: %#v", cfg)
+	return &Client{Client: c, cfg: cfg}
+}
+
+// Send sends the given messages over the current connection, like
+// mail.Client.Send.
+func (c *Client) Send(msgs ...*mail.Msg) error {
+	return c.SendWithContext(context.Background(), msgs...)
+}
+
+// SendWithContext is like Send, but the span of the call is a child of the
+// span found in ctx, if any.
+func (c *Client) SendWithContext(ctx context.Context, msgs ...*mail.Msg) error {
+	span := c.startSpan(ctx, "send", msgs)
+	err := c.Client.Send(msgs...)
+	c.finishSpan(span, err)
+	return err
+}
+
+// DialAndSend connects to the server and sends the given messages, like
+// mail.Client.DialAndSend.
+func (c *Client) DialAndSend(msgs ...*mail.Msg) error {
+	return c.DialAndSendWithContext(context.Background(), msgs...)
+}
+
+// DialAndSendWithContext connects to the server and sends the given messages,
+// like mail.Client.DialAndSendWithContext. The span of the call is a child of
+// the span found in ctx, if any.
+func (c *Client) DialAndSendWithContext(ctx context.Context, msgs ...*mail.Msg) error {
+	span := c.startSpan(ctx, "dial_and_send", msgs)
+	err := c.Client.DialAndSendWithContext(ctx, msgs...)
+	c.finishSpan(span, err)
+	return err
+}
+
+func (c *Client) startSpan(ctx context.Context, resource string, msgs []*mail.Msg) ddtrace.Span {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(c.cfg.serviceName),
+		tracer.ResourceName(resource),
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		tracer.Tag(tagMessageCount, len(msgs)),
+	}
+	if !math.IsNaN(c.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
+	}
+	if host, port, err := net.SplitHostPort(c.ServerAddr()); err == nil {
+		opts = append(opts,
+			tracer.Tag(ext.TargetHost, host),
+			tracer.Tag(ext.TargetPort, port),
+		)
+	}
+	recipients := 0
+	for _, m := range msgs {
+		if rcpts, err := m.GetRecipients(); err == nil {
+			recipients += len(rcpts)
+		}
+	}
+	opts = append(opts, tracer.Tag(tagRecipientCount, recipients))
+	if c.cfg.messageSize {
+		var size int64
+		for _, m := range msgs {
+			n, err := m.WriteTo(io.Discard)
+			if err != nil {
+				size = -1
+				break
+			}
+			size += n
+		}
+		if size >= 0 {
+			opts = append(opts, tracer.Tag(tagMessageSize, size))
+		}
+	}
+	span, _ := tracer.StartSpanFromContext(ctx, c.cfg.spanName, opts...)
+	return span
+}
+
+func (c *Client) finishSpan(span ddtrace.Span, err error) {
+	if err != nil && c.cfg.errCheck != nil && !c.cfg.errCheck(err) {
+		err = nil
+	}
+	span.Finish(tracer.WithError(err))
+}
@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package mail
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wneessen/go-mail"
+)
+
+// serveSMTP accepts SMTP sessions on l and replies to every command with
+// success, until l is closed.
+func serveSMTP(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+			reply("220 localhost ESMTP")
+			data := false
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				line = strings.TrimRight(line, "\r\n")
+				if data {
+					if line == "." {
+						data = false
+						reply("250 OK")
+					}
+					continue
+				}
+				switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+				case "EHLO", "HELO":
+					reply("250 localhost")
+				case "DATA":
+					data = true
+					reply("354 Go ahead")
+				case "QUIT":
+					reply("221 Bye")
+					return
+				default:
+					reply("250 OK")
+				}
+			}
+		}(conn)
+	}
+}
+
+func TestDialAndSend(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go serveSMTP(l)
+	host, port, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+	p, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c, err := NewClient(host, []mail.Option{mail.WithPort(p), mail.WithTLSPolicy(mail.NoTLS)}, WithServiceName("mailer"))
+	require.NoError(t, err)
+	m := mail.NewMsg()
+	require.NoError(t, m.From("from@example.com"))
+	require.NoError(t, m.To("a@example.com", "b@example.com"))
+	require.NoError(t, m.Cc("c@example.com"))
+	m.Subject("hello")
+	m.SetBodyString(mail.TypeTextPlain, "hello world")
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "root")
+	require.NoError(t, c.DialAndSendWithContext(ctx, m))
+	root.Finish()
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 2)
+	s := spans[0]
+	assert.Equal(t, "smtp.send", s.OperationName())
+	assert.Equal(t, "dial_and_send", s.Tag(ext.ResourceName))
+	assert.Equal(t, "mailer", s.Tag(ext.ServiceName))
+	assert.Equal(t, host, s.Tag(ext.TargetHost))
+	assert.Equal(t, port, s.Tag(ext.TargetPort))
+	assert.Equal(t, 1, s.Tag(tagMessageCount))
+	assert.Equal(t, 3, s.Tag(tagRecipientCount))
+	assert.Greater(t, s.Tag(tagMessageSize), int64(0))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+	assert.Equal(t, ext.SpanKindClient, s.Tag(ext.SpanKind))
+	assert.Equal(t, root.Context().SpanID(), s.ParentID())
+	assert.Nil(t, s.Tag(ext.Error))
+}
+
+func TestSendError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	// The client isn't connected.
+	c, err := NewClient("localhost", nil, WithMessageSize(false))
+	require.NoError(t, err)
+	m := mail.NewMsg()
+	require.NoError(t, m.To("a@example.com"))
+	assert.Error(t, c.Send(m))
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "send", spans[0].Tag(ext.ResourceName))
+	assert.Nil(t, spans[0].Tag(tagMessageSize))
+	assert.NotNil(t, spans[0].Tag(ext.Error))
+}
@@ -0,0 +1,12 @@
+module gopkg.in/DataDog/dd-trace-go.v1/contrib/zeromicro/go-zero
+
+go 1.25.1
+
+require (
+	github.com/stretchr/testify v1.8.4
+	github.com/zeromicro/go-zero v1.6.3
+	google.golang.org/grpc v1.61.0
+	gopkg.in/DataDog/dd-trace-go.v1 v1.54.0
+)
+
+replace gopkg.in/DataDog/dd-trace-go.v1 => ../../..
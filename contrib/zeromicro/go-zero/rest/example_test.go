@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package rest_test
+
+import (
+	"net/http"
+
+	resttrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/zeromicro/go-zero/rest"
+
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/rest"
+)
+
+func Example() {
+	var c rest.RestConf
+	conf.MustLoad("etc/user-api.yaml", &c)
+
+	server := rest.MustNewServer(c)
+	defer server.Stop()
+	// The spans are named after the name of the server.
+	server.Use(resttrace.Middleware(c))
+	server.AddRoute(rest.Route{
+		Method: http.MethodGet,
+		Path:   "/users/:id",
+		Handler: func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte("ok"))
+		},
+	})
+	server.Start()
+}
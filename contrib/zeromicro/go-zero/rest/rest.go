@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package rest provides functions to trace the zeromicro/go-zero/rest package (https://github.com/zeromicro/go-zero).
+package rest // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/zeromicro/go-zero/rest"
+
+import (
+	"math"
+	"net/http"
+
+	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/zeromicro/go-zero/rest"
+)
+
+const componentName = "zeromicro/go-zero/rest"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+type config struct {
+	serviceName   string
+	analyticsRate float64
+	ignoreRequest func(*http.Request) bool
+	spanOpts      []ddtrace.StartSpanOption
+}
+
+// Option represents an option that can be passed to Middleware.
+type Option func(*config)
+
+// WithServiceName sets the given service name for the started spans.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithIgnoreRequest specifies a function telling whether a request should not
+// be traced.
+func WithIgnoreRequest(fn func(*http.Request) bool) Option {
+	return func(cfg *config) {
+		cfg.ignoreRequest = fn
+	}
+}
+
+// Middleware returns a middleware, to be registered with the Use method of a
+// rest.Server, tracing the requests it serves. The spans are named after the
+// Name of the server configuration, unless DD_SERVICE or WithServiceName are
+// set.
+func Middleware(conf rest.RestConf, opts ...Option) rest.Middleware {
+	fallback := "go-zero"
+	if conf.Name != "" {
+		fallback = conf.Name
+	}
+	cfg := &config{
+		serviceName:   namingschema.NewDefaultServiceName(fallback).GetName(),
+		ignoreRequest: func(*http.Request) bool { return false },
+	}
+	if internal.BoolEnv("DD_TRACE_GOZERO_ANALYTICS_ENABLED", false) {
+		cfg.analyticsRate = 1.0
+	} else {
+		cfg.analyticsRate = globalconfig.AnalyticsRate()
+	}
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	log.Debug("contrib/zeromicro/go-zero/rest: Configuring Middleware: %#v", cfg)
+	spanOpts := append([]ddtrace.StartSpanOption{
+		tracer.Tag(ext.Component, componentName),
+		tracer.Tag(ext.SpanKind, ext.SpanKindServer),
+	}, cfg.spanOpts...)
+	if !math.IsNaN(cfg.analyticsRate) {
+		spanOpts = append(spanOpts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if cfg.ignoreRequest(r) {
+				next(w, r)
+				return
+			}
+			httptrace.TraceAndServe(next, w, r, &httptrace.ServeConfig{
+				Service:  cfg.serviceName,
+				SpanOpts: spanOpts,
+			})
+		}
+	}
+}
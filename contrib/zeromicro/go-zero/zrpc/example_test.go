@@ -0,0 +1,36 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package zrpc_test
+
+import (
+	zrpctrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/zeromicro/go-zero/zrpc"
+
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/zrpc"
+	"google.golang.org/grpc"
+)
+
+func Example() {
+	var c zrpc.RpcServerConf
+	conf.MustLoad("etc/user.yaml", &c)
+
+	s := zrpc.MustNewServer(c, func(grpcServer *grpc.Server) {
+		// register the services
+	})
+	// The spans are named after the etcd key the server registers with.
+	zrpctrace.InstrumentServer(s, c)
+	defer s.Stop()
+	s.Start()
+}
+
+func ExampleClientOptions() {
+	var c zrpc.RpcClientConf
+	conf.MustLoad("etc/client.yaml", &c)
+
+	// The spans are tagged with the etcd key of the called service.
+	client := zrpc.MustNewClient(c, zrpctrace.ClientOptions(c)...)
+	_ = client.Conn()
+}
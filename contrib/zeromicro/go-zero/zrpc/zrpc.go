@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package zrpc provides functions to trace the zeromicro/go-zero/zrpc package (https://github.com/zeromicro/go-zero).
+//
+// The calls are traced by the interceptors of the google.golang.org/grpc
+// integration, configured after the service registry keys of the servers and
+// clients: the spans of servers are named after the key the server registers
+// itself with, and the spans of clients are tagged with the key of the service
+// they call as peer.service.
+package zrpc // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/zeromicro/go-zero/zrpc"
+
+import (
+	grpctrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/google.golang.org/grpc"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/zeromicro/go-zero/zrpc"
+	"google.golang.org/grpc"
+)
+
+const componentName = "zeromicro/go-zero/zrpc"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+}
+
+// InstrumentServer adds the tracing interceptors to the given server, created
+// with the given configuration. The opts are applied after the ones derived
+// from the configuration.
+func InstrumentServer(s *zrpc.RpcServer, conf zrpc.RpcServerConf, opts ...grpctrace.Option) {
+	opts = serverOptions(conf, opts)
+	log.Debug("contrib/zeromicro/go-zero/zrpc: Instrumenting RpcServer %q", conf.Name)
+	s.AddUnaryInterceptors(grpctrace.UnaryServerInterceptor(opts...))
+	s.AddStreamInterceptors(grpctrace.StreamServerInterceptor(opts...))
+}
+
+// ClientOptions returns the options adding the tracing interceptors to a
+// client created with the given configuration, such as with
+// zrpc.MustNewClient. The opts are applied after the ones derived from the
+// configuration.
+func ClientOptions(conf zrpc.RpcClientConf, opts ...grpctrace.Option) []zrpc.ClientOption {
+	opts = clientOptions(conf, opts)
+	log.Debug("contrib/zeromicro/go-zero/zrpc: Configuring client of %q", clientTarget(conf))
+	return []zrpc.ClientOption{
+		zrpc.WithUnaryClientInterceptor(grpctrace.UnaryClientInterceptor(opts...)),
+		zrpc.WithStreamClientInterceptor(grpctrace.StreamClientInterceptor(opts...)),
+	}
+}
+
+// UnaryServerInterceptor returns the tracing unary interceptor of servers
+// created with the given configuration.
+func UnaryServerInterceptor(conf zrpc.RpcServerConf, opts ...grpctrace.Option) grpc.UnaryServerInterceptor {
+	return grpctrace.UnaryServerInterceptor(serverOptions(conf, opts)...)
+}
+
+// UnaryClientInterceptor returns the tracing unary interceptor of clients
+// created with the given configuration.
+func UnaryClientInterceptor(conf zrpc.RpcClientConf, opts ...grpctrace.Option) grpc.UnaryClientInterceptor {
+	return grpctrace.UnaryClientInterceptor(clientOptions(conf, opts)...)
+}
+
+func serverOptions(conf zrpc.RpcServerConf, opts []grpctrace.Option) []grpctrace.Option {
+	base := []grpctrace.Option{
+		grpctrace.WithSpanOptions(tracer.Tag(ext.Component, componentName)),
+	}
+	// DD_SERVICE takes precedence over the name of the server.
+	if globalconfig.ServiceName() == "" {
+		if name := serverName(conf); name != "" {
+			base = append(base, grpctrace.WithServiceName(name))
+		}
+	}
+	return append(base, opts...)
+}
+
+func clientOptions(conf zrpc.RpcClientConf, opts []grpctrace.Option) []grpctrace.Option {
+	base := []grpctrace.Option{
+		grpctrace.WithSpanOptions(tracer.Tag(ext.Component, componentName)),
+	}
+	if target := clientTarget(conf); target != "" {
+		base = append(base, grpctrace.WithSpanOptions(tracer.Tag(ext.PeerService, target)))
+	}
+	return append(base, opts...)
+}
+
+// serverName returns the key the server registers itself with in etcd, or
+// else its name.
+func serverName(conf zrpc.RpcServerConf) string {
+	if conf.Etcd.Key != "" {
+		return conf.Etcd.Key
+	}
+	return conf.Name
+}
+
+// clientTarget returns the key of the service discovered in etcd by the
+// client, or else its target.
+func clientTarget(conf zrpc.RpcClientConf) string {
+	if conf.Etcd.Key != "" {
+		return conf.Etcd.Key
+	}
+	return conf.Target
+}
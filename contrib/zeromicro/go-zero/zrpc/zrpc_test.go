@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package zrpc
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeromicro/go-zero/core/discov"
+	"github.com/zeromicro/go-zero/core/service"
+	"github.com/zeromicro/go-zero/zrpc"
+	"google.golang.org/grpc"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	conf := zrpc.RpcServerConf{
+		ServiceConf: service.ServiceConf{Name: "user-rpc"},
+		Etcd:        discov.EtcdConf{Hosts: []string{"127.0.0.1:2379"}, Key: "user.rpc"},
+	}
+	interceptor := UnaryServerInterceptor(conf)
+	info := &grpc.UnaryServerInfo{FullMethod: "/user.User/GetUser"}
+	_, err := interceptor(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "user.rpc", s.Tag(ext.ServiceName))
+	assert.Equal(t, "/user.User/GetUser", s.Tag(ext.ResourceName))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	conf := zrpc.RpcClientConf{Etcd: discov.EtcdConf{Hosts: []string{"127.0.0.1:2379"}, Key: "user.rpc"}}
+	interceptor := UnaryClientInterceptor(conf)
+	err := interceptor(context.Background(), "/user.User/GetUser", nil, nil, nil,
+		func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+			return nil
+		})
+	require.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "user.rpc", s.Tag(ext.PeerService))
+	assert.Equal(t, ext.SpanKindClient, s.Tag(ext.SpanKind))
+	assert.Equal(t, componentName, s.Tag(ext.Component))
+}
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, "user-rpc", serverName(zrpc.RpcServerConf{ServiceConf: service.ServiceConf{Name: "user-rpc"}}))
+	assert.Equal(t, "127.0.0.1:8080", clientTarget(zrpc.RpcClientConf{Target: "127.0.0.1:8080"}))
+}
@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package datastreams provides functions to manually instrument message queues
+// which aren't supported by the contrib packages, such as Redis streams,
+// custom queues or Kafka over REST, with Data Streams Monitoring.
+//
+// Data Streams Monitoring is enabled with the DD_DATA_STREAMS_ENABLED
+// environment variable, and the functions of this package are no-ops when it
+// is disabled or when the tracer is not started. A produce checkpoint must be
+// set when a message is sent, and a consume checkpoint when it is received:
+//
+//	// producer
+//	headers := make(tracer.TextMapCarrier)
+//	ctx = datastreams.SetProduceCheckpoint(ctx, "redis", "orders", headers, datastreams.WithPayloadSize(int64(len(payload))))
+//	send(payload, headers)
+//
+//	// consumer
+//	payload, headers := receive()
+//	ctx = datastreams.SetConsumeCheckpoint(ctx, "redis", "orders", headers)
+//
+// The schema of the messages can be reported with WithSchema, to track schema
+// changes along the pipelines.
+package datastreams
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+)
+
+// TextMapWriter allows setting key/value pairs of strings on the underlying
+// data structure, such as message headers. tracer.TextMapCarrier and
+// tracer.HTTPHeadersCarrier implement it.
+type TextMapWriter = datastreams.TextMapWriter
+
+// TextMapReader allows iterating over sets of key/value pairs, such as message
+// headers. tracer.TextMapCarrier and tracer.HTTPHeadersCarrier implement it.
+type TextMapReader = datastreams.TextMapReader
+
+// CheckpointOption is a configuration option for the checkpoints.
+type CheckpointOption func(cfg *checkpointConfig)
+
+type checkpointConfig struct {
+	payloadSize int64
+	edgeTags    []string
+	schema      *datastreams.Schema
+}
+
+// Schema types supported by WithSchema.
+const (
+	SchemaTypeAvro     = datastreams.SchemaTypeAvro
+	SchemaTypeProtobuf = datastreams.SchemaTypeProtobuf
+	SchemaTypeJSON     = datastreams.SchemaTypeJSON
+)
+
+// WithPayloadSize sets the size of the message payload, in bytes, going
+// through the checkpoint.
+func WithPayloadSize(size int64) CheckpointOption {
+	return func(cfg *checkpointConfig) {
+		cfg.payloadSize = size
+	}
+}
+
+// WithEdgeTags adds the given edge tags, in the "key:value" format, to the
+// checkpoint. They identify the checkpoint along with the direction, topic and
+// type tags, e.g. "group:my-consumer-group".
+func WithEdgeTags(tags ...string) CheckpointOption {
+	return func(cfg *checkpointConfig) {
+		cfg.edgeTags = append(cfg.edgeTags, tags...)
+	}
+}
+
+// WithSchema sets the schema of the message going through the checkpoint.
+// schemaType is one of the SchemaType constants, and definition is the schema
+// definition, e.g. the JSON representation of an Avro schema. Schemas are
+// fingerprinted and sampled, so that changes of the schemas going through each
+// checkpoint can be tracked in Data Streams Monitoring.
+func WithSchema(schemaType, definition string) CheckpointOption {
+	return func(cfg *checkpointConfig) {
+		cfg.schema = &datastreams.Schema{Type: schemaType, Definition: definition}
+	}
+}
+
+// SetProduceCheckpoint sets a checkpoint for a message produced to the given
+// topic of a queue of type queueType, e.g. "redis" or "sqs", and injects the
+// resulting pathway into the carrier, which must be sent along with the
+// message. It returns a copy of ctx holding the pathway.
+func SetProduceCheckpoint(ctx context.Context, queueType, topic string, carrier TextMapWriter, opts ...CheckpointOption) context.Context {
+	ctx, ok := setCheckpoint(ctx, "out", queueType, topic, opts)
+	if ok && carrier != nil {
+		datastreams.InjectToBase64Carrier(ctx, carrier)
+	}
+	return ctx
+}
+
+// SetConsumeCheckpoint extracts the pathway of a message consumed from the
+// given topic of a queue of type queueType out of the carrier, and sets a
+// checkpoint on it. It returns a copy of ctx holding the pathway, which must be
+// used to set the checkpoints of any message produced while processing the
+// consumed one.
+func SetConsumeCheckpoint(ctx context.Context, queueType, topic string, carrier TextMapReader, opts ...CheckpointOption) context.Context {
+	if carrier != nil {
+		ctx = datastreams.ExtractFromBase64Carrier(ctx, carrier)
+	}
+	ctx, _ = setCheckpoint(ctx, "in", queueType, topic, opts)
+	return ctx
+}
+
+// setCheckpoint sets a checkpoint with the given direction, queue type and
+// topic. It reports false if Data Streams Monitoring is disabled.
+func setCheckpoint(ctx context.Context, direction, queueType, topic string, opts []CheckpointOption) (context.Context, bool) {
+	p := datastreams.GetGlobalProcessor()
+	if p == nil {
+		return ctx, false
+	}
+	var cfg checkpointConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	edgeTags := []string{"direction:" + direction}
+	if topic != "" {
+		edgeTags = append(edgeTags, "topic:"+topic)
+	}
+	edgeTags = append(edgeTags, "type:"+queueType)
+	edgeTags = append(edgeTags, cfg.edgeTags...)
+	params := datastreams.CheckpointParams{
+		PayloadSize: cfg.payloadSize,
+		Schema:      cfg.schema,
+	}
+	return p.SetCheckpointWithParams(ctx, params, edgeTags...), true
+}
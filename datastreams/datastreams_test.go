@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package datastreams
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type carrier map[string]string
+
+func (c carrier) Set(key, val string) { c[key] = val }
+
+func (c carrier) ForeachKey(handler func(key, val string) error) error {
+	for k, v := range c {
+		if err := handler(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestCheckpoints(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		headers := carrier{}
+		ctx := SetProduceCheckpoint(context.Background(), "redis", "orders", headers)
+		assert.Empty(t, headers)
+		_, ok := datastreams.PathwayFromContext(ctx)
+		assert.False(t, ok)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		u, _ := url.Parse("http://localhost:8126")
+		datastreams.SetGlobalProcessor(datastreams.NewProcessor(&statsd.NoOpClient{}, "env", "service", u, nil))
+		defer datastreams.SetGlobalProcessor(nil)
+
+		headers := carrier{}
+		ctx := SetProduceCheckpoint(context.Background(), "redis", "orders", headers, WithPayloadSize(100))
+		produced, ok := datastreams.PathwayFromContext(ctx)
+		require.True(t, ok)
+		assert.Contains(t, headers, datastreams.PropagationKeyBase64)
+
+		// the consumer continues the pathway of the producer
+		ctx = SetConsumeCheckpoint(context.Background(), "redis", "orders", headers, WithEdgeTags("group:workers"))
+		consumed, ok := datastreams.PathwayFromContext(ctx)
+		require.True(t, ok)
+		assert.NotEqual(t, produced.GetHash(), consumed.GetHash())
+		assert.True(t, consumed.PathwayStart().Equal(produced.PathwayStart()))
+
+		// a consumer without pathway starts a new one
+		ctx = SetConsumeCheckpoint(context.Background(), "redis", "orders", carrier{})
+		other, ok := datastreams.PathwayFromContext(ctx)
+		require.True(t, ok)
+		assert.NotEqual(t, consumed.GetHash(), other.GetHash())
+	})
+}
+
+func TestWithSchema(t *testing.T) {
+	var cfg checkpointConfig
+	WithSchema(SchemaTypeAvro, `{"type":"record"}`)(&cfg)
+	require.NotNil(t, cfg.schema)
+	assert.Equal(t, "avro", cfg.schema.Type)
+	assert.Equal(t, `{"type":"record"}`, cfg.schema.Definition)
+}
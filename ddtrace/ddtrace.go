@@ -144,6 +144,33 @@ type StartSpanConfig struct {
 
 	// Context is the parent context where the span should be stored.
 	Context context.Context
+
+	// SpanLinks represents causal relationships between the Span and other Spans
+	// which are not its parent, such as the messages that triggered a batch consumer.
+	SpanLinks []SpanLink
+}
+
+// SpanLink represents a reference to a span that exists outside of the trace.
+type SpanLink struct {
+	// TraceID represents the low 64 bits of the linked span's trace id. This field is required.
+	TraceID uint64 `json:"trace_id"`
+
+	// TraceIDHigh represents the high 64 bits of the linked span's trace id. This field is
+	// only set if the linked span's trace id is 128 bits.
+	TraceIDHigh uint64 `json:"trace_id_high,omitempty"`
+
+	// SpanID represents the linked span's span id. This field is required.
+	SpanID uint64 `json:"span_id"`
+
+	// Attributes is a mapping of keys to string values. These values are used to add
+	// additional context to the span link.
+	Attributes map[string]string `json:"attributes,omitempty"`
+
+	// Tracestate is the tracestate of the linked span. This field is optional.
+	Tracestate string `json:"tracestate,omitempty"`
+
+	// Flags represents the W3C trace flags of the linked span. This field is optional.
+	Flags uint32 `json:"flags,omitempty"`
 }
 
 // Logger implementations are able to log given messages that the tracer or profiler might output.
@@ -84,4 +84,10 @@ const (
 
 	// CassandraContactPoints holds the list of cassandra initial seed nodes used to discover the cluster.
 	CassandraContactPoints = "db.cassandra.contact.points"
+
+	// CassandraAttempt specifies the tag name for the attempt number of a query or batch.
+	CassandraAttempt = "cassandra.attempt"
+
+	// CassandraBatchSize specifies the tag name for the number of statements in a batch.
+	CassandraBatchSize = "cassandra.batch_size"
 )
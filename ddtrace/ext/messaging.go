@@ -8,18 +8,89 @@ package ext
 const (
 	// MessagingSystem identifies which messaging system created this span (kafka, rabbitmq, amazonsqs, googlepubsub...)
 	MessagingSystem = "messaging.system"
+	// MessagingBatchMessageCount holds the number of messages received in a batch.
+	MessagingBatchMessageCount = "messaging.batch.message_count"
 )
 
 // Available values for messaging.system.
 const (
-	MessagingSystemGCPPubsub = "googlepubsub"
-	MessagingSystemKafka     = "kafka"
+	MessagingSystemGCPPubsub  = "googlepubsub"
+	MessagingSystemKafka      = "kafka"
+	MessagingSystemRabbitMQ   = "rabbitmq"
+	MessagingSystemNATS       = "nats"
+	MessagingSystemServiceBus = "servicebus"
+	MessagingSystemMQTT       = "mqtt"
+	MessagingSystemPulsar     = "pulsar"
 )
 
 // Kafka tags.
 const (
 	// MessagingKafkaPartition defines the Kafka partition the trace is associated with.
 	MessagingKafkaPartition = "messaging.kafka.partition"
+	// MessagingKafkaConsumerGroup holds the name of the consumer group of a Kafka consumer.
+	MessagingKafkaConsumerGroup = "messaging.kafka.consumer.group"
 	// KafkaBootstrapServers holds a comma separated list of bootstrap servers as defined in producer or consumer config.
 	KafkaBootstrapServers = "messaging.kafka.bootstrap.servers"
 )
+
+// RabbitMQ tags.
+const (
+	// RabbitMQExchange holds the name of the exchange a message is published to.
+	RabbitMQExchange = "messaging.rabbitmq.exchange"
+	// RabbitMQRoutingKey holds the routing key of a published or consumed message.
+	RabbitMQRoutingKey = "messaging.rabbitmq.routing_key"
+	// RabbitMQQueue holds the name of the queue a message is consumed from.
+	RabbitMQQueue = "messaging.rabbitmq.queue"
+	// RabbitMQConsumerTag holds the tag of the consumer a message is delivered to.
+	RabbitMQConsumerTag = "messaging.rabbitmq.consumer_tag"
+)
+
+// NATS tags.
+const (
+	// NATSSubject holds the subject a message is published to or consumed from.
+	NATSSubject = "messaging.nats.subject"
+	// NATSStream holds the JetStream stream a message is stored in.
+	NATSStream = "messaging.nats.stream"
+	// NATSConsumer holds the name of the JetStream consumer a message is consumed by.
+	NATSConsumer = "messaging.nats.consumer"
+	// NATSSequence holds the sequence number of a message in its JetStream stream.
+	NATSSequence = "messaging.nats.sequence"
+)
+
+// Azure Service Bus tags.
+const (
+	// ServiceBusEntity holds the name of the queue or topic a message is sent to or received from.
+	ServiceBusEntity = "messaging.servicebus.entity"
+	// ServiceBusSubscription holds the name of the subscription of the topic a message is received from.
+	ServiceBusSubscription = "messaging.servicebus.subscription"
+	// ServiceBusMessageID holds the identifier of a message.
+	ServiceBusMessageID = "messaging.servicebus.message_id"
+	// ServiceBusDeliveryCount holds the number of deliveries of a received message.
+	ServiceBusDeliveryCount = "messaging.servicebus.delivery_count"
+)
+
+// MQTT tags.
+const (
+	// MQTTTopic holds the topic a message is published to or received from.
+	MQTTTopic = "messaging.mqtt.topic"
+	// MQTTQoS holds the quality of service level of a message.
+	MQTTQoS = "messaging.mqtt.qos"
+	// MQTTRetained holds whether a message is retained by the broker.
+	MQTTRetained = "messaging.mqtt.retained"
+	// MQTTMessageID holds the packet identifier of a received message.
+	MQTTMessageID = "messaging.mqtt.message_id"
+	// MQTTDuplicate holds whether a received message is a redelivery.
+	MQTTDuplicate = "messaging.mqtt.duplicate"
+)
+
+// Pulsar tags.
+const (
+	// PulsarTopic holds the topic a message is sent to or consumed from.
+	PulsarTopic = "messaging.pulsar.topic"
+	// PulsarSubscription holds the name of the subscription of a consumer.
+	PulsarSubscription = "messaging.pulsar.subscription"
+	// PulsarProducerName holds the name of the producer of a message.
+	PulsarProducerName = "messaging.pulsar.producer_name"
+	// PulsarRedeliveryCount holds the number of redeliveries of a consumed message.
+	PulsarRedeliveryCount = "messaging.pulsar.redelivery_count"
+)
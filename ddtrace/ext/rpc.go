@@ -24,6 +24,8 @@ const (
 	RPCSystemGRPC = "grpc"
 	// RPCSystemTwirp identifies Twirp.
 	RPCSystemTwirp = "twirp"
+	// RPCSystemConnect identifies Connect.
+	RPCSystemConnect = "connect_rpc"
 )
 
 // gRPC specific tags.
@@ -32,3 +34,9 @@ const (
 	// format: /$package.$service/$method
 	GRPCFullMethod = "rpc.grpc.full_method"
 )
+
+// Connect specific tags.
+const (
+	// ConnectRPCErrorCode represents the Connect code of the error of a failed call.
+	ConnectRPCErrorCode = "rpc.connect_rpc.error_code"
+)
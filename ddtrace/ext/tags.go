@@ -58,6 +58,23 @@ const (
 	// See https://docs.datadoghq.com/tracing/trace_collection/tracing_naming_convention/#http-requests
 	HTTPRequestHeaders = "http.request.headers"
 
+	// HTTPResponseHeaders sets the HTTP response headers partial tag
+	// This tag is meant to be composed, i.e http.response.headers.headerX, http.response.headers.headerY, etc...
+	// See https://docs.datadoghq.com/tracing/trace_collection/tracing_naming_convention/#http-responses
+	HTTPResponseHeaders = "http.response.headers"
+
+	// OpenAPIOperationID is the operationId of the OpenAPI operation serving
+	// an HTTP request.
+	OpenAPIOperationID = "openapi.operation_id"
+
+	// OpenAPIOperationTags is the comma-separated list of the tags of the
+	// OpenAPI operation serving an HTTP request.
+	OpenAPIOperationTags = "openapi.operation.tags"
+
+	// OpenAPIRequestSchema is the reference of the OpenAPI schema of the
+	// request body of an HTTP request.
+	OpenAPIRequestSchema = "openapi.request.schema"
+
 	// SpanName is a pseudo-key for setting a span's operation name by means of
 	// a tag. It is mostly here to facilitate vendor-agnostic frameworks like Opentracing
 	// and OpenCensus.
@@ -90,6 +107,10 @@ const (
 	// ErrorDetails holds details about an error which implements a formatter.
 	ErrorDetails = "error.details"
 
+	// ErrorFingerprint holds a hash of the error type and its normalized message,
+	// which can be used to group similar errors together.
+	ErrorFingerprint = "error.fingerprint"
+
 	// Environment specifies the environment to use with a trace.
 	Environment = "env"
 
@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package ext
+
+// Span types used by CI Visibility to categorize test events.
+const (
+	// SpanTypeTest marks a span as a single test.
+	SpanTypeTest = "test"
+
+	// SpanTypeTestSuite marks a span as a test suite, grouping the tests of a file.
+	SpanTypeTestSuite = "test_suite_end"
+
+	// SpanTypeTestModule marks a span as a test module, grouping the suites of a package.
+	SpanTypeTestModule = "test_module_end"
+
+	// SpanTypeTestSession marks a span as a test session, grouping all the modules
+	// run by a single test command.
+	SpanTypeTestSession = "test_session_end"
+)
+
+// Tags used by CI Visibility to describe test events.
+const (
+	// TestSessionID is the ID of the test session a test event belongs to.
+	TestSessionID = "test_session_id"
+
+	// TestModuleID is the ID of the test module a test event belongs to.
+	TestModuleID = "test_module_id"
+
+	// TestSuiteID is the ID of the test suite a test event belongs to.
+	TestSuiteID = "test_suite_id"
+
+	// TestName is the name of the test.
+	TestName = "test.name"
+
+	// TestSuite is the name of the test suite.
+	TestSuite = "test.suite"
+
+	// TestModule is the name of the test module.
+	TestModule = "test.module"
+
+	// TestFramework is the name of the framework running the tests.
+	TestFramework = "test.framework"
+
+	// TestFrameworkVersion is the version of the framework running the tests.
+	TestFrameworkVersion = "test.framework_version"
+
+	// TestCommand is the command used to run the tests.
+	TestCommand = "test.command"
+
+	// TestType is the type of the test, e.g. "test" or "benchmark".
+	TestType = "test.type"
+
+	// TestStatus is the status of a test event, one of TestStatusPass,
+	// TestStatusFail or TestStatusSkip.
+	TestStatus = "test.status"
+
+	// TestSkipReason is the reason a test was skipped.
+	TestSkipReason = "test.skip_reason"
+
+	// TestSourceFile is the file holding the source code of the test.
+	TestSourceFile = "test.source.file"
+
+	// TestSourceStartLine is the line on which the test starts in its source file.
+	TestSourceStartLine = "test.source.start"
+
+	// TestIsRetry marks the test events of retried tests.
+	TestIsRetry = "test.is_retry"
+
+	// TestIsNew marks the test events of tests unknown to Datadog.
+	TestIsNew = "test.is_new"
+
+	// TestEarlyFlakeEnabled reports whether Early Flake Detection is enabled for
+	// a test session.
+	TestEarlyFlakeEnabled = "test.early_flake.enabled"
+
+	// TestEarlyFlakeAbortReason is the reason Early Flake Detection was aborted
+	// for a test session.
+	TestEarlyFlakeAbortReason = "test.early_flake.abort_reason"
+
+	// TestCodeCoverageEnabled reports whether code coverage was collected.
+	TestCodeCoverageEnabled = "test.code_coverage.enabled"
+
+	// TestCodeCoverageLinesPercentage is the percentage of statements covered
+	// by the tests of a session or module.
+	TestCodeCoverageLinesPercentage = "test.code_coverage.lines_pct"
+
+	// CIProviderName is the name of the CI provider running the tests.
+	CIProviderName = "ci.provider.name"
+
+	// CIPipelineURL is the URL of the CI pipeline running the tests.
+	CIPipelineURL = "ci.pipeline.url"
+
+	// CIWorkspacePath is the path of the workspace in which the tests run.
+	CIWorkspacePath = "ci.workspace_path"
+
+	// GitBranch is the branch of the code being tested.
+	GitBranch = "git.branch"
+)
+
+// Values of the TestStatus tag.
+const (
+	TestStatusPass = "pass"
+	TestStatusFail = "fail"
+	TestStatusSkip = "skip"
+)
@@ -0,0 +1,140 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package mocktracer
+
+import (
+	"fmt"
+	"reflect"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+)
+
+// TestingT is the subset of testing.TB used to report failed assertions.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// FinishedSpansByOperation returns the spans finished by t with the given
+// operation name, in the order they were finished.
+func FinishedSpansByOperation(t Tracer, name string) []Span {
+	var spans []Span
+	for _, s := range t.FinishedSpans() {
+		if s.OperationName() == name {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+// A SpanAssertion checks a property of a span. It returns an error describing
+// the mismatch when the span doesn't satisfy it.
+type SpanAssertion func(s Span) error
+
+// AssertSpan checks that the span satisfies all the given assertions, and
+// reports the ones which failed to t. It returns whether they all succeeded.
+// For example:
+//
+//	mocktracer.AssertSpan(t, spans[1],
+//		mocktracer.WithName("http.request"),
+//		mocktracer.WithTag(ext.HTTPCode, "200"),
+//		mocktracer.WithParent(spans[0]),
+//	)
+func AssertSpan(t TestingT, s Span, assertions ...SpanAssertion) bool {
+	t.Helper()
+	if s == nil {
+		t.Errorf("expected a span, got nil")
+		return false
+	}
+	ok := true
+	for _, a := range assertions {
+		if err := a(s); err != nil {
+			t.Errorf("span %q (id %d): %v", s.OperationName(), s.SpanID(), err)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// WithName asserts that the operation name of the span is name.
+func WithName(name string) SpanAssertion {
+	return func(s Span) error {
+		if got := s.OperationName(); got != name {
+			return fmt.Errorf("expected operation name %q, got %q", name, got)
+		}
+		return nil
+	}
+}
+
+// WithService asserts that the service name of the span is service.
+func WithService(service string) SpanAssertion {
+	return WithTag(ext.ServiceName, service)
+}
+
+// WithResource asserts that the resource name of the span is resource.
+func WithResource(resource string) SpanAssertion {
+	return WithTag(ext.ResourceName, resource)
+}
+
+// WithTag asserts that the span has the tag k, with a value deeply equal to v.
+func WithTag(k string, v interface{}) SpanAssertion {
+	return func(s Span) error {
+		got, ok := s.Tags()[k]
+		if !ok {
+			return fmt.Errorf("expected tag %q to be %#v, but it isn't set", k, v)
+		}
+		if !reflect.DeepEqual(got, v) {
+			return fmt.Errorf("expected tag %q to be %#v (%T), got %#v (%T)", k, v, v, got, got)
+		}
+		return nil
+	}
+}
+
+// WithTags asserts that the span has all the given tags. Other tags set on the
+// span are ignored.
+func WithTags(tags map[string]interface{}) SpanAssertion {
+	return func(s Span) error {
+		for k, v := range tags {
+			if err := WithTag(k, v)(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// WithoutTag asserts that the tag k isn't set on the span.
+func WithoutTag(k string) SpanAssertion {
+	return func(s Span) error {
+		if v, ok := s.Tags()[k]; ok {
+			return fmt.Errorf("expected tag %q not to be set, got %#v", k, v)
+		}
+		return nil
+	}
+}
+
+// WithParent asserts that the span is a child of parent, in the same trace.
+func WithParent(parent Span) SpanAssertion {
+	return func(s Span) error {
+		if s.ParentID() != parent.SpanID() {
+			return fmt.Errorf("expected parent ID %d, got %d", parent.SpanID(), s.ParentID())
+		}
+		if s.TraceID() != parent.TraceID() {
+			return fmt.Errorf("expected trace ID %d, got %d", parent.TraceID(), s.TraceID())
+		}
+		return nil
+	}
+}
+
+// WithNoParent asserts that the span is the root of its trace.
+func WithNoParent() SpanAssertion {
+	return func(s Span) error {
+		if id := s.ParentID(); id != 0 {
+			return fmt.Errorf("expected no parent, got parent ID %d", id)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package mocktracer
+
+import (
+	"fmt"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder is a TestingT recording the reported errors.
+type recorder struct {
+	errors []string
+}
+
+func (*recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertSpan(t *testing.T) {
+	mt := newMockTracer()
+	parent := mt.StartSpan("parent")
+	child := mt.StartSpan("child",
+		tracer.ChildOf(parent.Context()),
+		tracer.ServiceName("service"),
+		tracer.ResourceName("resource"),
+		tracer.Tag("key", 42),
+	)
+	child.Finish()
+	parent.Finish()
+	spans := mt.FinishedSpans()
+
+	t.Run("success", func(t *testing.T) {
+		r := &recorder{}
+		ok := AssertSpan(r, spans[0],
+			WithName("child"),
+			WithService("service"),
+			WithResource("resource"),
+			WithTag("key", 42),
+			WithTags(map[string]interface{}{ext.ServiceName: "service", "key": 42}),
+			WithoutTag("missing"),
+			WithParent(spans[1]),
+		)
+		assert.True(t, ok)
+		assert.Empty(t, r.errors)
+		assert.True(t, AssertSpan(r, spans[1], WithNoParent()))
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		r := &recorder{}
+		ok := AssertSpan(r, spans[0],
+			WithName("other"),
+			WithTag("key", "42"),
+			WithTag("missing", 1),
+			WithoutTag("key"),
+			WithNoParent(),
+			WithParent(spans[0]),
+		)
+		assert.False(t, ok)
+		assert.Len(t, r.errors, 6)
+		assert.Contains(t, r.errors[0], `expected operation name "other", got "child"`)
+		assert.Contains(t, r.errors[1], `expected tag "key" to be "42" (string), got 42 (int)`)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		r := &recorder{}
+		assert.False(t, AssertSpan(r, nil, WithName("child")))
+		assert.Len(t, r.errors, 1)
+	})
+}
+
+func TestFinishedSpansByOperation(t *testing.T) {
+	mt := newMockTracer()
+	mt.StartSpan("a").Finish()
+	mt.StartSpan("b").Finish()
+	mt.StartSpan("a", tracer.ResourceName("second")).Finish()
+
+	spans := FinishedSpansByOperation(mt, "a")
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "a", spans[0].Tag(ext.ResourceName))
+	assert.Equal(t, "second", spans[1].Tag(ext.ResourceName))
+	assert.Empty(t, FinishedSpansByOperation(mt, "c"))
+}
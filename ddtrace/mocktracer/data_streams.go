@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package mocktracer
+
+import (
+	"net/url"
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+)
+
+// Pathway holds the properties under which the stats of a Data Streams
+// Monitoring pathway were aggregated, over a stats bucket.
+type Pathway struct {
+	// Service is the service the checkpoint was set in.
+	Service string
+	// EdgeTags are the tags of the checkpoint, e.g. "direction:in" or
+	// "topic:orders".
+	EdgeTags []string
+	// Hash identifies the pathway.
+	Hash uint64
+	// ParentHash identifies the upstream pathway, or is 0 for the first
+	// checkpoint of the pathway.
+	ParentHash uint64
+}
+
+// Backlog holds a queue offset tracked by Data Streams Monitoring.
+type Backlog struct {
+	// Tags identify the queue partition, and the type of offset, e.g.
+	// "type:kafka_commit".
+	Tags []string
+	// Value is the offset.
+	Value int64
+}
+
+// SentPathways flushes the Data Streams Monitoring checkpoints and returns the
+// pathways which would have been sent to the agent, one per pathway hash and
+// stats bucket. Data Streams Monitoring is only enabled in the mock tracer when
+// DD_DATA_STREAMS_ENABLED is true at the time Start is called; otherwise, or if
+// t was not returned by Start, it returns nil.
+func SentPathways(t Tracer) []Pathway {
+	mt, ok := t.(*mocktracer)
+	if !ok || mt.dsm == nil {
+		return nil
+	}
+	return mt.dsm.sentPathways()
+}
+
+// SentDSMBacklogs flushes the Data Streams Monitoring stats and returns the
+// backlogs, such as the Kafka offsets, which would have been sent to the agent.
+// Like SentPathways, it returns nil unless Data Streams Monitoring is enabled.
+func SentDSMBacklogs(t Tracer) []Backlog {
+	mt, ok := t.(*mocktracer)
+	if !ok || mt.dsm == nil {
+		return nil
+	}
+	return mt.dsm.sentBacklogs()
+}
+
+// dsmRecorder records the Data Streams Monitoring payloads which the real
+// tracer would send to the agent.
+type dsmRecorder struct {
+	processor *datastreams.Processor
+
+	mu       sync.Mutex // guards below fields
+	pathways []Pathway
+	backlogs []Backlog
+}
+
+func newDSMRecorder() *dsmRecorder {
+	r := &dsmRecorder{}
+	r.processor = datastreams.NewProcessor(
+		&statsd.NoOpClient{},
+		"env",
+		"service",
+		&url.URL{Scheme: "http", Host: "localhost:8126"},
+		nil,
+		datastreams.WithTransport(r.record),
+	)
+	return r
+}
+
+func (r *dsmRecorder) record(p *datastreams.StatsPayload) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, b := range p.Stats {
+		for _, sp := range b.Stats {
+			r.pathways = append(r.pathways, Pathway{
+				Service:    sp.Service,
+				EdgeTags:   sp.EdgeTags,
+				Hash:       sp.Hash,
+				ParentHash: sp.ParentHash,
+			})
+		}
+		for _, bl := range b.Backlogs {
+			r.backlogs = append(r.backlogs, Backlog{Tags: bl.Tags, Value: bl.Value})
+		}
+	}
+	return nil
+}
+
+func (r *dsmRecorder) start() {
+	r.processor.Start()
+	datastreams.SetGlobalProcessor(r.processor)
+}
+
+func (r *dsmRecorder) stop() {
+	if datastreams.GetGlobalProcessor() == r.processor {
+		datastreams.SetGlobalProcessor(nil)
+	}
+	r.processor.Stop()
+}
+
+func (r *dsmRecorder) sentPathways() []Pathway {
+	r.processor.Flush()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Pathway(nil), r.pathways...)
+}
+
+func (r *dsmRecorder) sentBacklogs() []Backlog {
+	r.processor.Flush()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Backlog(nil), r.backlogs...)
+}
+
+func (r *dsmRecorder) reset() {
+	r.processor.Flush()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pathways = nil
+	r.backlogs = nil
+}
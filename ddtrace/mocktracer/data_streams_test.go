@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package mocktracer
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataStreams(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		mt := Start()
+		defer mt.Stop()
+		assert.Nil(t, datastreams.GetGlobalProcessor())
+		assert.Nil(t, SentPathways(mt))
+		assert.Nil(t, SentDSMBacklogs(mt))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		t.Setenv("DD_DATA_STREAMS_ENABLED", "true")
+		mt := Start()
+		p := datastreams.GetGlobalProcessor()
+		require.NotNil(t, p)
+
+		ctx := p.SetCheckpoint(context.Background(), "direction:out", "topic:orders", "type:kafka")
+		p.SetCheckpoint(ctx, "direction:in", "group:g", "topic:orders", "type:kafka")
+		p.TrackKafkaCommitOffset("g", "orders", 1, 10)
+
+		pathways := SentPathways(mt)
+		require.Len(t, pathways, 2)
+		tags := map[uint64][]string{}
+		for _, sp := range pathways {
+			tags[sp.ParentHash] = sp.EdgeTags
+		}
+		assert.Equal(t, []string{"direction:out", "topic:orders", "type:kafka"}, tags[0])
+		backlogs := SentDSMBacklogs(mt)
+		require.Len(t, backlogs, 1)
+		assert.Equal(t, int64(10), backlogs[0].Value)
+		assert.Contains(t, backlogs[0].Tags, "type:kafka_commit")
+
+		mt.Reset()
+		assert.Empty(t, SentPathways(mt))
+
+		mt.Stop()
+		assert.Nil(t, datastreams.GetGlobalProcessor())
+	})
+}
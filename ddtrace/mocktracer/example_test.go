@@ -6,6 +6,9 @@
 package mocktracer_test
 
 import (
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
 )
 
@@ -25,3 +28,20 @@ func Example() {
 
 	// Run assertions...
 }
+
+func ExampleAssertSpan() {
+	var t *testing.T // the test being run
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	// ...run some code with generates spans.
+
+	for _, span := range mocktracer.FinishedSpansByOperation(mt, "http.request") {
+		mocktracer.AssertSpan(t, span,
+			mocktracer.WithService("my-service"),
+			mocktracer.WithTag(ext.HTTPCode, "200"),
+			mocktracer.WithNoParent(),
+		)
+	}
+}
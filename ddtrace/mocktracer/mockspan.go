@@ -84,6 +84,7 @@ func newSpan(t *mocktracer, operationName string, cfg *ddtrace.StartSpanConfig)
 		s.context.priority = ctx.samplingPriority()
 		s.context.hasPriority = ctx.hasSamplingPriority()
 		s.context.traceID = ctx.traceID
+		s.context.origin = ctx.origin
 		s.context.baggage = make(map[string]string, len(ctx.baggage))
 		ctx.ForeachBaggageItem(func(k, v string) bool {
 			s.context.baggage[k] = v
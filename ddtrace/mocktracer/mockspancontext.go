@@ -19,6 +19,7 @@ type spanContext struct {
 	baggage      map[string]string
 	priority     int
 	hasPriority  bool
+	origin       string
 
 	spanID  uint64
 	traceID uint64
@@ -13,13 +13,11 @@
 package mocktracer
 
 import (
-	"strconv"
-	"strings"
 	"sync"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
-	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	sharedinternal "gopkg.in/DataDog/dd-trace-go.v1/internal"
 )
 
 var _ ddtrace.Tracer = (*mocktracer)(nil)
@@ -49,6 +47,10 @@ type Tracer interface {
 // interface to query the tracer's state.
 func Start() Tracer {
 	t := newMockTracer()
+	if sharedinternal.BoolEnv("DD_DATA_STREAMS_ENABLED", false) {
+		t.dsm = newDSMRecorder()
+		t.dsm.start()
+	}
 	internal.SetGlobalTracer(t)
 	internal.Testing = true
 	return t
@@ -58,6 +60,8 @@ type mocktracer struct {
 	sync.RWMutex  // guards below spans
 	finishedSpans []Span
 	openSpans     map[uint64]Span
+	subscriptions []*subscription
+	dsm           *dsmRecorder // nil when Data Streams Monitoring is disabled
 }
 
 func newMockTracer() *mocktracer {
@@ -67,7 +71,11 @@ func newMockTracer() *mocktracer {
 }
 
 // Stop deactivates the mock tracer and sets the active tracer to a no-op.
-func (*mocktracer) Stop() {
+func (t *mocktracer) Stop() {
+	t.stopSubscriptions()
+	if t.dsm != nil {
+		t.dsm.stop()
+	}
 	internal.SetGlobalTracer(&internal.NoopTracer{})
 	internal.Testing = false
 }
@@ -109,6 +117,9 @@ func (t *mocktracer) Reset() {
 		delete(t.openSpans, k)
 	}
 	t.finishedSpans = nil
+	if t.dsm != nil {
+		t.dsm.reset()
+	}
 }
 
 func (t *mocktracer) addFinishedSpan(s Span) {
@@ -119,76 +130,7 @@ func (t *mocktracer) addFinishedSpan(s Span) {
 		t.finishedSpans = make([]Span, 0, 1)
 	}
 	t.finishedSpans = append(t.finishedSpans, s)
-}
-
-const (
-	traceHeader    = tracer.DefaultTraceIDHeader
-	spanHeader     = tracer.DefaultParentIDHeader
-	priorityHeader = tracer.DefaultPriorityHeader
-	baggagePrefix  = tracer.DefaultBaggageHeaderPrefix
-)
-
-func (t *mocktracer) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
-	reader, ok := carrier.(tracer.TextMapReader)
-	if !ok {
-		return nil, tracer.ErrInvalidCarrier
-	}
-	var sc spanContext
-	err := reader.ForeachKey(func(key, v string) error {
-		k := strings.ToLower(key)
-		if k == traceHeader {
-			id, err := strconv.ParseUint(v, 10, 64)
-			if err != nil {
-				return tracer.ErrSpanContextCorrupted
-			}
-			sc.traceID = id
-		}
-		if k == spanHeader {
-			id, err := strconv.ParseUint(v, 10, 64)
-			if err != nil {
-				return tracer.ErrSpanContextCorrupted
-			}
-			sc.spanID = id
-		}
-		if k == priorityHeader {
-			p, err := strconv.Atoi(v)
-			if err != nil {
-				return tracer.ErrSpanContextCorrupted
-			}
-			sc.priority = p
-			sc.hasPriority = true
-		}
-		if strings.HasPrefix(k, baggagePrefix) {
-			sc.setBaggageItem(strings.TrimPrefix(k, baggagePrefix), v)
-		}
-		return nil
-	})
-	if err != nil {
-		return nil, err
-	}
-	if sc.traceID == 0 || sc.spanID == 0 {
-		return nil, tracer.ErrSpanContextNotFound
-	}
-	return &sc, err
-}
-
-func (t *mocktracer) Inject(context ddtrace.SpanContext, carrier interface{}) error {
-	writer, ok := carrier.(tracer.TextMapWriter)
-	if !ok {
-		return tracer.ErrInvalidCarrier
-	}
-	ctx, ok := context.(*spanContext)
-	if !ok || ctx.traceID == 0 || ctx.spanID == 0 {
-		return tracer.ErrInvalidSpanContext
-	}
-	writer.Set(traceHeader, strconv.FormatUint(ctx.traceID, 10))
-	writer.Set(spanHeader, strconv.FormatUint(ctx.spanID, 10))
-	if ctx.hasSamplingPriority() {
-		writer.Set(priorityHeader, strconv.Itoa(ctx.priority))
+	for _, sub := range t.subscriptions {
+		sub.push(s)
 	}
-	ctx.ForeachBaggageItem(func(k, v string) bool {
-		writer.Set(baggagePrefix+k, v)
-		return true
-	})
-	return nil
 }
@@ -267,3 +267,98 @@ func TestTracerExtract(t *testing.T) {
 		assert.Equal("B", got.baggageItem("a"))
 	})
 }
+
+func TestTracerPropagationDefault(t *testing.T) {
+	var mt mocktracer
+	assert := assert.New(t)
+	carrier := tracer.TextMapCarrier(make(map[string]string))
+	assert.Nil(mt.Inject(&spanContext{traceID: 1, spanID: 2}, carrier))
+	assert.Equal("1", carrier[traceHeader])
+	assert.NotContains(carrier, traceparentHeader)
+	assert.NotContains(carrier, tracestateHeader)
+
+	_, err := mt.Extract(tracer.TextMapCarrier(map[string]string{
+		traceparentHeader: "00-00000000000000000000000000000001-0000000000000002-01",
+	}))
+	assert.Equal(tracer.ErrSpanContextNotFound, err)
+}
+
+func TestTracerPropagationW3C(t *testing.T) {
+	var mt mocktracer
+	t.Setenv("DD_TRACE_PROPAGATION_STYLE", "datadog,tracecontext")
+
+	t.Run("inject", func(t *testing.T) {
+		assert := assert.New(t)
+		sctx := &spanContext{traceID: 1, spanID: 2, priority: 2, hasPriority: true, origin: "synthetics"}
+		carrier := tracer.TextMapCarrier(make(map[string]string))
+		assert.Nil(mt.Inject(sctx, carrier))
+		assert.Equal("1", carrier[traceHeader])
+		assert.Equal("2", carrier[spanHeader])
+		assert.Equal("2", carrier[priorityHeader])
+		assert.Equal("synthetics", carrier[originHeader])
+		assert.Equal("00-00000000000000000000000000000001-0000000000000002-01", carrier[traceparentHeader])
+		assert.Equal("dd=s:2;o:synthetics", carrier[tracestateHeader])
+	})
+
+	t.Run("extract", func(t *testing.T) {
+		assert := assert.New(t)
+		carrier := tracer.TextMapCarrier(map[string]string{
+			traceparentHeader:   "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			tracestateHeader:    "foo=bar,dd=s:2;o:rum",
+			baggagePrefix + "k": "v",
+		})
+		ctx, err := mt.Extract(carrier)
+		assert.Nil(err)
+		sc := ctx.(*spanContext)
+		assert.Equal(uint64(0xa3ce929d0e0e4736), sc.traceID)
+		assert.Equal(uint64(0x00f067aa0ba902b7), sc.spanID)
+		assert.Equal(2, sc.samplingPriority())
+		assert.Equal("rum", sc.origin)
+		assert.Equal("v", sc.baggageItem("k"))
+	})
+
+	t.Run("sampled-flag", func(t *testing.T) {
+		assert := assert.New(t)
+		ctx, err := mt.Extract(tracer.TextMapCarrier(map[string]string{
+			traceparentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			tracestateHeader:  "dd=s:2",
+		}))
+		assert.Nil(err)
+		assert.Equal(0, ctx.(*spanContext).samplingPriority())
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		for _, v := range []string{
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+			"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		} {
+			_, err := mt.Extract(tracer.TextMapCarrier(map[string]string{traceparentHeader: v}))
+			assert.Equal(t, tracer.ErrSpanContextCorrupted, err, v)
+		}
+	})
+
+	t.Run("datadog-first", func(t *testing.T) {
+		ctx, err := mt.Extract(tracer.TextMapCarrier(map[string]string{
+			traceHeader:       "5",
+			spanHeader:        "6",
+			traceparentHeader: "00-00000000000000000000000000000001-0000000000000002-01",
+		}))
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(5), ctx.TraceID())
+	})
+
+	t.Run("styles", func(t *testing.T) {
+		assert := assert.New(t)
+		t.Setenv("DD_TRACE_PROPAGATION_STYLE", "tracecontext")
+		carrier := tracer.TextMapCarrier(make(map[string]string))
+		assert.Nil(mt.Inject(&spanContext{traceID: 1, spanID: 2}, carrier))
+		assert.NotContains(carrier, traceHeader)
+		assert.Contains(carrier, traceparentHeader)
+
+		t.Setenv("DD_TRACE_PROPAGATION_STYLE_EXTRACT", "datadog")
+		_, err := mt.Extract(carrier)
+		assert.Equal(tracer.ErrSpanContextNotFound, err)
+	})
+}
@@ -0,0 +1,281 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package mocktracer
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	traceHeader       = tracer.DefaultTraceIDHeader
+	spanHeader        = tracer.DefaultParentIDHeader
+	priorityHeader    = tracer.DefaultPriorityHeader
+	baggagePrefix     = tracer.DefaultBaggageHeaderPrefix
+	originHeader      = "x-datadog-origin"
+	traceparentHeader = "traceparent"
+	tracestateHeader  = "tracestate"
+)
+
+const (
+	styleDatadog      = "datadog"
+	styleTraceContext = "tracecontext"
+)
+
+// propagationStyles returns the propagation styles enabled by the environment
+// variable env, falling back to DD_TRACE_PROPAGATION_STYLE. Like the real
+// tracer, only the Datadog style is used by default or when none of the given
+// styles are supported by the mock tracer.
+func propagationStyles(env string) []string {
+	ps := os.Getenv(env)
+	if ps == "" {
+		ps = os.Getenv("DD_TRACE_PROPAGATION_STYLE")
+	}
+	ps = strings.ToLower(strings.TrimSpace(ps))
+	if ps == "none" {
+		return nil
+	}
+	var styles []string
+	for _, v := range strings.Split(ps, ",") {
+		switch v = strings.TrimSpace(v); v {
+		case styleDatadog, styleTraceContext:
+			styles = append(styles, v)
+		}
+	}
+	if len(styles) == 0 {
+		return []string{styleDatadog}
+	}
+	return styles
+}
+
+// Extract extracts a span context from the carrier, using the same headers as
+// the real tracer. Only the Datadog headers are read, unless
+// DD_TRACE_PROPAGATION_STYLE_EXTRACT or DD_TRACE_PROPAGATION_STYLE also enable
+// the W3C traceparent and tracestate headers, in which case the styles are
+// tried in the given order.
+func (t *mocktracer) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
+	reader, ok := carrier.(tracer.TextMapReader)
+	if !ok {
+		return nil, tracer.ErrInvalidCarrier
+	}
+	for _, style := range propagationStyles("DD_TRACE_PROPAGATION_STYLE_EXTRACT") {
+		var (
+			sc  *spanContext
+			err error
+		)
+		switch style {
+		case styleDatadog:
+			sc, err = extractDatadog(reader)
+		case styleTraceContext:
+			sc, err = extractW3C(reader)
+		}
+		if err == tracer.ErrSpanContextNotFound {
+			continue
+		}
+		return sc, err
+	}
+	return nil, tracer.ErrSpanContextNotFound
+}
+
+func extractDatadog(reader tracer.TextMapReader) (*spanContext, error) {
+	var sc spanContext
+	err := reader.ForeachKey(func(key, v string) error {
+		k := strings.ToLower(key)
+		switch {
+		case k == traceHeader:
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return tracer.ErrSpanContextCorrupted
+			}
+			sc.traceID = id
+		case k == spanHeader:
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return tracer.ErrSpanContextCorrupted
+			}
+			sc.spanID = id
+		case k == priorityHeader:
+			p, err := strconv.Atoi(v)
+			if err != nil {
+				return tracer.ErrSpanContextCorrupted
+			}
+			sc.priority = p
+			sc.hasPriority = true
+		case k == originHeader:
+			sc.origin = v
+		case strings.HasPrefix(k, baggagePrefix):
+			sc.setBaggageItem(strings.TrimPrefix(k, baggagePrefix), v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if sc.traceID == 0 || sc.spanID == 0 {
+		return nil, tracer.ErrSpanContextNotFound
+	}
+	return &sc, nil
+}
+
+func extractW3C(reader tracer.TextMapReader) (*spanContext, error) {
+	var (
+		sc                  spanContext
+		parent, state       string
+		hasParent, hasState bool
+	)
+	err := reader.ForeachKey(func(key, v string) error {
+		k := strings.ToLower(key)
+		switch {
+		case k == traceparentHeader:
+			if hasParent {
+				return tracer.ErrSpanContextCorrupted
+			}
+			parent, hasParent = v, true
+		case k == tracestateHeader:
+			if hasState {
+				state += ","
+			}
+			state, hasState = state+v, true
+		case strings.HasPrefix(k, baggagePrefix):
+			sc.setBaggageItem(strings.TrimPrefix(k, baggagePrefix), v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := parseTraceparent(&sc, parent); err != nil {
+		return nil, err
+	}
+	parseTracestate(&sc, state)
+	return &sc, nil
+}
+
+// parseTraceparent parses a traceparent header of the form
+// "version-traceid-parentid-flags" into sc. Only the lower 64 bits of the
+// trace ID are kept, since the mock tracer only supports 64-bit trace IDs.
+func parseTraceparent(sc *spanContext, header string) error {
+	header = strings.ToLower(strings.Trim(header, "\t -"))
+	if header == "" {
+		return tracer.ErrSpanContextNotFound
+	}
+	parts := strings.Split(header, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return tracer.ErrSpanContextCorrupted
+	}
+	version, err := strconv.ParseUint(parts[0], 16, 8)
+	if err != nil || version == 0xff || (version == 0 && len(parts) != 4) {
+		return tracer.ErrSpanContextCorrupted
+	}
+	if _, err := strconv.ParseUint(parts[1][:16], 16, 64); err != nil {
+		return tracer.ErrSpanContextCorrupted
+	}
+	if sc.traceID, err = strconv.ParseUint(parts[1][16:], 16, 64); err != nil {
+		return tracer.ErrSpanContextCorrupted
+	}
+	if sc.spanID, err = strconv.ParseUint(parts[2], 16, 64); err != nil {
+		return tracer.ErrSpanContextCorrupted
+	}
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return tracer.ErrSpanContextCorrupted
+	}
+	if sc.traceID == 0 || sc.spanID == 0 {
+		return tracer.ErrSpanContextNotFound
+	}
+	sc.priority = int(flags & 0x1)
+	sc.hasPriority = true
+	return nil
+}
+
+// parseTracestate reads the sampling priority and origin from the dd
+// list-member of a tracestate header. The priority found in tracestate is
+// only used when it agrees with the sampled flag of the traceparent header.
+func parseTracestate(sc *spanContext, header string) {
+	for _, member := range strings.Split(header, ",") {
+		member = strings.TrimSpace(member)
+		if !strings.HasPrefix(member, "dd=") {
+			continue
+		}
+		for _, kv := range strings.Split(member[len("dd="):], ";") {
+			k, v, ok := strings.Cut(kv, ":")
+			if !ok {
+				continue
+			}
+			switch k {
+			case "o":
+				sc.origin = strings.ReplaceAll(v, "~", "=")
+			case "s":
+				p, err := strconv.Atoi(v)
+				if err != nil {
+					continue
+				}
+				if (sc.priority > 0) == (p > 0) {
+					sc.priority = p
+				}
+			}
+		}
+	}
+}
+
+// Inject injects the span context into the carrier, using the same headers as
+// the real tracer. Only the Datadog headers are set, unless
+// DD_TRACE_PROPAGATION_STYLE_INJECT or DD_TRACE_PROPAGATION_STYLE also enable
+// the W3C traceparent and tracestate headers.
+func (t *mocktracer) Inject(context ddtrace.SpanContext, carrier interface{}) error {
+	writer, ok := carrier.(tracer.TextMapWriter)
+	if !ok {
+		return tracer.ErrInvalidCarrier
+	}
+	ctx, ok := context.(*spanContext)
+	if !ok || ctx.traceID == 0 || ctx.spanID == 0 {
+		return tracer.ErrInvalidSpanContext
+	}
+	for _, style := range propagationStyles("DD_TRACE_PROPAGATION_STYLE_INJECT") {
+		switch style {
+		case styleDatadog:
+			injectDatadog(ctx, writer)
+		case styleTraceContext:
+			injectW3C(ctx, writer)
+		}
+	}
+	ctx.ForeachBaggageItem(func(k, v string) bool {
+		writer.Set(baggagePrefix+k, v)
+		return true
+	})
+	return nil
+}
+
+func injectDatadog(ctx *spanContext, writer tracer.TextMapWriter) {
+	writer.Set(traceHeader, strconv.FormatUint(ctx.traceID, 10))
+	writer.Set(spanHeader, strconv.FormatUint(ctx.spanID, 10))
+	if ctx.hasSamplingPriority() {
+		writer.Set(priorityHeader, strconv.Itoa(ctx.samplingPriority()))
+	}
+	if ctx.origin != "" {
+		writer.Set(originHeader, ctx.origin)
+	}
+}
+
+func injectW3C(ctx *spanContext, writer tracer.TextMapWriter) {
+	p := ctx.samplingPriority()
+	flags := "00"
+	if ctx.hasSamplingPriority() && p >= ext.PriorityAutoKeep {
+		flags = "01"
+	}
+	writer.Set(traceparentHeader, fmt.Sprintf("00-%032x-%016x-%s", ctx.traceID, ctx.spanID, flags))
+	state := "dd=s:" + strconv.Itoa(p)
+	if ctx.origin != "" {
+		state += ";o:" + strings.ReplaceAll(ctx.origin, "=", "~")
+	}
+	writer.Set(tracestateHeader, state)
+}
@@ -0,0 +1,261 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package mocktracer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+)
+
+// snapshotSpan is a span in the JSON format of the snapshots of the Datadog
+// APM test agent (https://github.com/DataDog/dd-apm-test-agent).
+type snapshotSpan struct {
+	Name     string             `json:"name"`
+	Service  string             `json:"service"`
+	Resource string             `json:"resource"`
+	TraceID  uint64             `json:"trace_id"`
+	SpanID   uint64             `json:"span_id"`
+	ParentID uint64             `json:"parent_id"`
+	Type     string             `json:"type,omitempty"`
+	Error    int32              `json:"error"`
+	Meta     map[string]string  `json:"meta,omitempty"`
+	Metrics  map[string]float64 `json:"metrics,omitempty"`
+	Start    int64              `json:"start"`
+	Duration int64              `json:"duration"`
+}
+
+// defaultIgnoredSnapshotKeys are the keys which are not compared by
+// CompareSnapshots, as they change from one run to the next.
+var defaultIgnoredSnapshotKeys = []string{
+	"start",
+	"duration",
+	"meta.error.stack",
+	"meta.runtime-id",
+	"metrics.process_id",
+}
+
+// keySamplingPriority is the metric holding the sampling priority of the
+// spans sent by the real tracer.
+const keySamplingPriority = "_sampling_priority_v1"
+
+// WriteSnapshot writes the spans finished by t to w in the JSON snapshot format
+// of the Datadog APM test agent, for golden file testing. The snapshots can be
+// compared using CompareSnapshots.
+func WriteSnapshot(t Tracer, w io.Writer) error {
+	return writeSnapshot(w, t.FinishedSpans())
+}
+
+// writeSnapshot writes spans to w, grouped by trace, in the format of the
+// snapshots of the APM test agent. Like the test agent, trace and span IDs
+// are replaced with sequential numbers, so that the snapshots of different
+// runs can be compared.
+func writeSnapshot(w io.Writer, spans []Span) error {
+	spans = append([]Span(nil), spans...)
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].StartTime().Before(spans[j].StartTime())
+	})
+	var (
+		traces   [][]snapshotSpan
+		traceIdx = make(map[uint64]int)
+		spanIDs  = make(map[uint64]uint64, len(spans))
+	)
+	for _, s := range spans {
+		if _, ok := traceIdx[s.TraceID()]; !ok {
+			traceIdx[s.TraceID()] = len(traces)
+			traces = append(traces, nil)
+		}
+		spanIDs[s.SpanID()] = uint64(len(spanIDs) + 1)
+	}
+	for _, s := range spans {
+		idx := traceIdx[s.TraceID()]
+		ss := newSnapshotSpan(s)
+		ss.TraceID = uint64(idx)
+		ss.SpanID = spanIDs[s.SpanID()]
+		ss.ParentID = spanIDs[s.ParentID()]
+		traces[idx] = append(traces[idx], ss)
+	}
+	if traces == nil {
+		traces = [][]snapshotSpan{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(traces)
+}
+
+// newSnapshotSpan converts s, setting its tags as meta or metrics the same
+// way the real tracer does.
+func newSnapshotSpan(s Span) snapshotSpan {
+	ss := snapshotSpan{
+		Name:     s.OperationName(),
+		Resource: s.OperationName(),
+		Start:    s.StartTime().UnixNano(),
+		Duration: s.FinishTime().Sub(s.StartTime()).Nanoseconds(),
+		Meta:     make(map[string]string),
+		Metrics:  make(map[string]float64),
+	}
+	for k, v := range s.Tags() {
+		switch k {
+		case ext.ServiceName:
+			ss.Service = fmt.Sprint(v)
+			continue
+		case ext.ResourceName:
+			ss.Resource = fmt.Sprint(v)
+			continue
+		case ext.SpanType:
+			ss.Type = fmt.Sprint(v)
+			continue
+		case ext.SamplingPriority:
+			k = keySamplingPriority
+		case ext.Error:
+			switch v := v.(type) {
+			case bool:
+				if v {
+					ss.Error = 1
+				}
+			case error:
+				ss.Error = 1
+				ss.Meta[ext.ErrorMsg] = v.Error()
+				ss.Meta[ext.ErrorType] = reflect.TypeOf(v).String()
+			case nil:
+			default:
+				ss.Error = 1
+			}
+			continue
+		}
+		if f, ok := toFloat64(v); ok {
+			ss.Metrics[k] = f
+			continue
+		}
+		ss.Meta[k] = fmt.Sprint(v)
+	}
+	if len(ss.Meta) == 0 {
+		ss.Meta = nil
+	}
+	if len(ss.Metrics) == 0 {
+		ss.Metrics = nil
+	}
+	return ss
+}
+
+// toFloat64 converts the numeric value v to a float64.
+func toFloat64(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// CompareSnapshots compares the snapshot got, typically written by
+// WriteSnapshot, to the golden snapshot want. It returns an error listing all
+// the differences, or nil if there are none.
+//
+// The start and duration of the spans, as well as the error stacks, are not
+// compared. Other keys can be ignored by passing their path, such as "service",
+// "meta.http.url" or "metrics.retries". Like in the APM test agent, the
+// snapshots are compared trace by trace, and span by span.
+func CompareSnapshots(want, got []byte, ignoredKeys ...string) error {
+	var wantTraces, gotTraces [][]map[string]interface{}
+	if err := json.Unmarshal(want, &wantTraces); err != nil {
+		return fmt.Errorf("invalid expected snapshot: %v", err)
+	}
+	if err := json.Unmarshal(got, &gotTraces); err != nil {
+		return fmt.Errorf("invalid snapshot: %v", err)
+	}
+	if len(wantTraces) != len(gotTraces) {
+		return fmt.Errorf("expected %d traces, got %d", len(wantTraces), len(gotTraces))
+	}
+	ignored := append(append([]string(nil), defaultIgnoredSnapshotKeys...), ignoredKeys...)
+	var diffs []string
+	for i := range wantTraces {
+		if len(wantTraces[i]) != len(gotTraces[i]) {
+			diffs = append(diffs, fmt.Sprintf("trace %d: expected %d spans, got %d", i, len(wantTraces[i]), len(gotTraces[i])))
+			continue
+		}
+		for j := range wantTraces[i] {
+			w, g := flattenSnapshotSpan(wantTraces[i][j]), flattenSnapshotSpan(gotTraces[i][j])
+			for _, k := range ignored {
+				delete(w, k)
+				delete(g, k)
+			}
+			for _, d := range diffSnapshotSpans(w, g) {
+				diffs = append(diffs, fmt.Sprintf("trace %d, span %d (%v): %s", i, j, wantTraces[i][j]["name"], d))
+			}
+		}
+	}
+	if len(diffs) > 0 {
+		return errors.New(strings.Join(diffs, "\n"))
+	}
+	return nil
+}
+
+// flattenSnapshotSpan returns the fields of a snapshot span, with the meta
+// and metrics entries prefixed with "meta." and "metrics." respectively.
+func flattenSnapshotSpan(span map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{}, len(span))
+	for k, v := range span {
+		m, ok := v.(map[string]interface{})
+		if !ok || (k != "meta" && k != "metrics") {
+			flat[k] = v
+			continue
+		}
+		for mk, mv := range m {
+			flat[k+"."+mk] = mv
+		}
+	}
+	return flat
+}
+
+// diffSnapshotSpans returns the differences between two flattened snapshot
+// spans, sorted by key.
+func diffSnapshotSpans(want, got map[string]interface{}) []string {
+	var diffs []string
+	for k, w := range want {
+		g, ok := got[k]
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("missing %s: expected %#v", k, w))
+		case !reflect.DeepEqual(w, g):
+			diffs = append(diffs, fmt.Sprintf("%s: expected %#v, got %#v", k, w, g))
+		}
+	}
+	for k, g := range got {
+		if _, ok := want[k]; !ok {
+			diffs = append(diffs, fmt.Sprintf("unexpected %s: %#v", k, g))
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package mocktracer
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const goldenSnapshot = `[
+  [
+    {
+      "name": "http.request",
+      "service": "web",
+      "resource": "GET /users",
+      "trace_id": 0,
+      "span_id": 1,
+      "parent_id": 0,
+      "type": "web",
+      "error": 0,
+      "meta": {
+        "http.method": "GET"
+      },
+      "metrics": {
+        "_sampling_priority_v1": 1
+      },
+      "start": 1685000000000000000,
+      "duration": 2000000
+    },
+    {
+      "name": "db.query",
+      "service": "web",
+      "resource": "db.query",
+      "trace_id": 0,
+      "span_id": 2,
+      "parent_id": 1,
+      "error": 1,
+      "meta": {
+        "error.message": "boom",
+        "error.type": "*errors.errorString"
+      },
+      "metrics": {
+        "_sampling_priority_v1": 1,
+        "db.row_count": 3
+      },
+      "start": 1685000000001000000,
+      "duration": 1000000
+    }
+  ]
+]
+`
+
+func startSnapshotSpans(mt *mocktracer) {
+	start := time.Unix(1685000000, 0)
+	root := mt.StartSpan("http.request",
+		tracer.StartTime(start),
+		tracer.ServiceName("web"),
+		tracer.ResourceName("GET /users"),
+		tracer.SpanType(ext.SpanTypeWeb),
+		tracer.Tag(ext.HTTPMethod, "GET"),
+		tracer.Tag(ext.SamplingPriority, ext.PriorityAutoKeep),
+	)
+	child := mt.StartSpan("db.query", tracer.ChildOf(root.Context()), tracer.StartTime(start.Add(time.Millisecond)))
+	child.SetTag("db.row_count", 3)
+	child.Finish(tracer.FinishTime(start.Add(2*time.Millisecond)), tracer.WithError(errors.New("boom")))
+	root.Finish(tracer.FinishTime(start.Add(2 * time.Millisecond)))
+}
+
+func TestWriteSnapshot(t *testing.T) {
+	mt := newMockTracer()
+	startSnapshotSpans(mt)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSnapshot(mt, &buf))
+	assert.Equal(t, goldenSnapshot, buf.String())
+
+	t.Run("empty", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteSnapshot(newMockTracer(), &buf))
+		assert.Equal(t, "[]\n", buf.String())
+	})
+}
+
+func TestCompareSnapshots(t *testing.T) {
+	mt := newMockTracer()
+	startSnapshotSpans(mt)
+	var buf bytes.Buffer
+	require.NoError(t, WriteSnapshot(mt, &buf))
+	got := buf.String()
+
+	t.Run("equal", func(t *testing.T) {
+		assert.NoError(t, CompareSnapshots([]byte(goldenSnapshot), []byte(got)))
+	})
+
+	t.Run("timings", func(t *testing.T) {
+		want := strings.Replace(goldenSnapshot, `"duration": 2000000`, `"duration": 5`, 1)
+		assert.NoError(t, CompareSnapshots([]byte(want), []byte(got)))
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		want := strings.Replace(goldenSnapshot, `"http.method": "GET"`, `"http.method": "POST"`, 1)
+		err := CompareSnapshots([]byte(want), []byte(got))
+		require.Error(t, err)
+		assert.Equal(t, `trace 0, span 0 (http.request): meta.http.method: expected "POST", got "GET"`, err.Error())
+		assert.NoError(t, CompareSnapshots([]byte(want), []byte(got), "meta.http.method"))
+	})
+
+	t.Run("spans", func(t *testing.T) {
+		err := CompareSnapshots([]byte(goldenSnapshot), []byte("[[]]"))
+		assert.EqualError(t, err, "trace 0: expected 2 spans, got 0")
+		err = CompareSnapshots([]byte(goldenSnapshot), []byte("[]"))
+		assert.EqualError(t, err, "expected 1 traces, got 0")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Error(t, CompareSnapshots([]byte(goldenSnapshot), []byte("{")))
+	})
+}
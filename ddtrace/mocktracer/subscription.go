@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package mocktracer
+
+import "sync"
+
+// SubscribeFinishedSpans returns a channel receiving the spans finished by t,
+// starting with the ones which are already finished. It allows waiting for the
+// spans of asynchronous operations, e.g. with a timeout, instead of polling
+// FinishedSpans. The subscription is not affected by Reset.
+//
+// The channel is closed by Stop, once the spans finished before Stop are
+// received, or right away by the returned cancel function, which should be
+// called when the subscriber stops receiving. If t was not returned by Start,
+// the channel is closed.
+func SubscribeFinishedSpans(t Tracer) (spans <-chan Span, cancel func()) {
+	mt, ok := t.(*mocktracer)
+	if !ok {
+		ch := make(chan Span)
+		close(ch)
+		return ch, func() {}
+	}
+	return mt.subscribe()
+}
+
+// subscription delivers finished spans to a subscriber. Spans are queued
+// without limit, so that finishing a span never blocks on a slow subscriber.
+type subscription struct {
+	mu      sync.Mutex // guards pending
+	pending []Span
+
+	notify     chan struct{} // signals that spans were queued
+	stopped    chan struct{} // closed when no more spans are queued
+	done       chan struct{} // closed when the subscription is cancelled
+	cancelOnce sync.Once
+	out        chan Span
+}
+
+func newSubscription(spans []Span) *subscription {
+	s := &subscription{
+		pending: append([]Span(nil), spans...),
+		notify:  make(chan struct{}, 1),
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+		out:     make(chan Span),
+	}
+	go s.run()
+	return s
+}
+
+// push queues span for delivery.
+func (s *subscription) push(span Span) {
+	s.mu.Lock()
+	s.pending = append(s.pending, span)
+	s.mu.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// stop closes the channel of the subscriber once the queued spans are
+// delivered. No span must be pushed afterwards.
+func (s *subscription) stop() { close(s.stopped) }
+
+// cancel stops the delivery of spans and closes the channel of the subscriber.
+func (s *subscription) cancel() {
+	s.cancelOnce.Do(func() { close(s.done) })
+}
+
+func (s *subscription) run() {
+	defer close(s.out)
+	for {
+		s.mu.Lock()
+		pending := s.pending
+		s.pending = nil
+		s.mu.Unlock()
+		for _, span := range pending {
+			select {
+			case s.out <- span:
+			case <-s.done:
+				return
+			}
+		}
+		if len(pending) > 0 {
+			continue
+		}
+		select {
+		case <-s.notify:
+		case <-s.stopped:
+			s.mu.Lock()
+			drained := len(s.pending) == 0
+			s.mu.Unlock()
+			if drained {
+				return
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (t *mocktracer) subscribe() (<-chan Span, func()) {
+	t.Lock()
+	defer t.Unlock()
+	s := newSubscription(t.finishedSpans)
+	t.subscriptions = append(t.subscriptions, s)
+	return s.out, func() {
+		t.unsubscribe(s)
+		s.cancel()
+	}
+}
+
+// unsubscribe stops queuing finished spans for s.
+func (t *mocktracer) unsubscribe(s *subscription) {
+	t.Lock()
+	defer t.Unlock()
+	for i, sub := range t.subscriptions {
+		if sub == s {
+			t.subscriptions = append(t.subscriptions[:i:i], t.subscriptions[i+1:]...)
+			return
+		}
+	}
+}
+
+// stopSubscriptions closes the channels of all the subscribers once they have
+// received the spans finished so far.
+func (t *mocktracer) stopSubscriptions() {
+	t.Lock()
+	defer t.Unlock()
+	for _, s := range t.subscriptions {
+		s.stop()
+	}
+	t.subscriptions = nil
+}
@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package mocktracer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeFinishedSpans(t *testing.T) {
+	receive := func(t *testing.T, ch <-chan Span) Span {
+		t.Helper()
+		select {
+		case s := <-ch:
+			return s
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for a span")
+			return nil
+		}
+	}
+
+	t.Run("replay", func(t *testing.T) {
+		mt := newMockTracer()
+		defer mt.Stop()
+		mt.StartSpan("first").Finish()
+		ch, cancel := SubscribeFinishedSpans(mt)
+		defer cancel()
+		assert.Equal(t, "first", receive(t, ch).OperationName())
+	})
+
+	t.Run("async", func(t *testing.T) {
+		mt := newMockTracer()
+		defer mt.Stop()
+		ch, cancel := SubscribeFinishedSpans(mt)
+		defer cancel()
+		go func() {
+			for i := 0; i < 10; i++ {
+				mt.StartSpan("async").Finish()
+			}
+			mt.StartSpan("last").Finish()
+		}()
+		var n int
+		for s := receive(t, ch); s.OperationName() != "last"; s = receive(t, ch) {
+			n++
+		}
+		assert.Equal(t, 10, n)
+	})
+
+	t.Run("multiple", func(t *testing.T) {
+		mt := newMockTracer()
+		defer mt.Stop()
+		ch1, cancel1 := SubscribeFinishedSpans(mt)
+		defer cancel1()
+		ch2, cancel2 := SubscribeFinishedSpans(mt)
+		defer cancel2()
+		mt.StartSpan("op").Finish()
+		assert.Equal(t, "op", receive(t, ch1).OperationName())
+		assert.Equal(t, "op", receive(t, ch2).OperationName())
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		mt := newMockTracer()
+		ch, cancel := SubscribeFinishedSpans(mt)
+		defer cancel()
+		mt.StartSpan("first").Finish()
+		mt.StartSpan("second").Finish()
+		mt.Stop()
+		// the spans finished before Stop are received before the channel is closed
+		assert.Equal(t, "first", receive(t, ch).OperationName())
+		assert.Equal(t, "second", receive(t, ch).OperationName())
+		select {
+		case _, ok := <-ch:
+			assert.False(t, ok)
+		case <-time.After(time.Second):
+			t.Fatal("channel not closed")
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		mt := newMockTracer()
+		defer mt.Stop()
+		ch, cancel := SubscribeFinishedSpans(mt)
+		mt.StartSpan("op").Finish() // may not be received, must not block
+		cancel()
+		cancel()
+		timeout := time.After(time.Second)
+		for {
+			select {
+			case _, ok := <-ch:
+				if !ok {
+					assert.Empty(t, mt.subscriptions)
+					mt.StartSpan("op").Finish()
+					return
+				}
+			case <-timeout:
+				t.Fatal("channel not closed")
+			}
+		}
+	})
+
+	t.Run("other", func(t *testing.T) {
+		var tr Tracer
+		ch, cancel := SubscribeFinishedSpans(tr)
+		cancel()
+		_, ok := <-ch
+		assert.False(t, ok)
+	})
+}
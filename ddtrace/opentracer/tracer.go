@@ -26,6 +26,7 @@ package opentracer
 
 import (
 	"context"
+	"encoding/binary"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
@@ -56,14 +57,25 @@ func (t *opentracer) StartSpan(operationName string, options ...opentracing.Star
 		o.Apply(&sso)
 	}
 	opts := []ddtrace.StartSpanOption{tracer.StartTime(sso.StartTime)}
+	parent := parentRef(sso.References)
+	if parent != nil {
+		opts = append(opts, tracer.ChildOf(parent))
+	}
+	var links []ddtrace.SpanLink
 	for _, ref := range sso.References {
-		if v, ok := ref.ReferencedContext.(ddtrace.SpanContext); ok {
-			// opentracing.ChildOfRef and opentracing.FollowsFromRef will both be represented as
-			// children because Datadog APM does not have a concept of FollowsFrom references.
-			opts = append(opts, tracer.ChildOf(v))
-			break // can only have one parent
+		if ref.Type != opentracing.FollowsFromRef {
+			continue
+		}
+		// The reference chosen as parent is already part of the parent chain.
+		if v, ok := ref.ReferencedContext.(ddtrace.SpanContext); ok && !sameContext(v, parent) {
+			// Datadog APM does not have a concept of FollowsFrom references, so
+			// they are recorded as span links to preserve causality information.
+			links = append(links, spanLink(v))
 		}
 	}
+	if len(links) > 0 {
+		opts = append(opts, tracer.WithSpanLinks(links))
+	}
 	for k, v := range sso.Tags {
 		opts = append(opts, tracer.Tag(k, v))
 	}
@@ -74,6 +86,45 @@ func (t *opentracer) StartSpan(operationName string, options ...opentracing.Star
 	}
 }
 
+// parentRef returns the span context to be used as the parent of a span started
+// with the given references. A ChildOf reference is preferred; otherwise the first
+// FollowsFrom reference is used, since a span can only have one parent.
+func parentRef(refs []opentracing.SpanReference) ddtrace.SpanContext {
+	var parent ddtrace.SpanContext
+	for _, ref := range refs {
+		v, ok := ref.ReferencedContext.(ddtrace.SpanContext)
+		if !ok {
+			continue
+		}
+		if ref.Type == opentracing.ChildOfRef {
+			return v
+		}
+		if parent == nil {
+			parent = v
+		}
+	}
+	return parent
+}
+
+// sameContext reports whether a and b identify the same span.
+func sameContext(a, b ddtrace.SpanContext) bool {
+	return b != nil && a.TraceID() == b.TraceID() && a.SpanID() == b.SpanID()
+}
+
+// spanLink returns a span link pointing to the span identified by ctx.
+func spanLink(ctx ddtrace.SpanContext) ddtrace.SpanLink {
+	link := ddtrace.SpanLink{
+		TraceID:    ctx.TraceID(),
+		SpanID:     ctx.SpanID(),
+		Attributes: map[string]string{"opentracing.ref_type": "follows_from"},
+	}
+	if w3c, ok := ctx.(ddtrace.SpanContextW3C); ok {
+		id := w3c.TraceID128Bytes()
+		link.TraceIDHigh = binary.BigEndian.Uint64(id[:8])
+	}
+	return link
+}
+
 // Inject implements opentracing.Tracer.
 func (t *opentracer) Inject(ctx opentracing.SpanContext, format interface{}, carrier interface{}) error {
 	sctx, ok := ctx.(ddtrace.SpanContext)
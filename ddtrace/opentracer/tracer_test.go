@@ -6,17 +6,25 @@
 package opentracer
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"io"
+	"net/http"
 	"testing"
+	"time"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/httpmem"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry/telemetrytest"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
 )
 
 func TestStart(t *testing.T) {
@@ -122,3 +130,90 @@ func TestSpanTelemetry(t *testing.T) {
 	telemetryClient.AssertCalled(t, "Count", telemetry.NamespaceTracers, "spans_created", 1.0, telemetryTags, true)
 	telemetryClient.AssertNumberOfCalls(t, "Count", 1)
 }
+
+// startTestTracer starts an opentracer sending its payloads, decoded as JSON,
+// to the returned channel.
+func startTestTracer(t *testing.T) (opentracing.Tracer, chan string) {
+	payloads := make(chan string, 10)
+	s, c := httpmem.ServerAndClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v0.4/traces" && r.Header.Get("X-Datadog-Trace-Count") != "0" {
+			buf, err := io.ReadAll(r.Body)
+			if err == nil && len(buf) > 0 {
+				var js bytes.Buffer
+				msgp.UnmarshalAsJSON(&js, buf)
+				payloads <- js.String()
+			}
+		}
+		w.WriteHeader(200)
+	}))
+	t.Cleanup(func() { s.Close() })
+	return New(tracer.WithHTTPClient(c)), payloads
+}
+
+func TestStartSpanReferences(t *testing.T) {
+	assert := assert.New(t)
+	ot, payloads := startTestTracer(t)
+	defer tracer.Stop()
+
+	parent := ot.StartSpan("parent")
+	producer := ot.StartSpan("producer")
+
+	t.Run("follows-from", func(t *testing.T) {
+		other := ot.StartSpan("other")
+		s := ot.StartSpan("consumer",
+			opentracing.FollowsFrom(producer.Context()),
+			opentracing.FollowsFrom(other.Context()),
+		)
+		sctx := s.Context().(ddtrace.SpanContext)
+		pctx := producer.Context().(ddtrace.SpanContext)
+		assert.Equal(pctx.TraceID(), sctx.TraceID())
+		s.Finish()
+		producer.Finish()
+		tracer.Flush()
+
+		var p string
+		select {
+		case p = <-payloads:
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for traces")
+		}
+		var traces [][]struct {
+			Name     string            `json:"name"`
+			ParentID uint64            `json:"parent_id"`
+			Meta     map[string]string `json:"meta"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(p), &traces))
+		require.Len(t, traces, 1)
+		var found bool
+		for _, span := range traces[0] {
+			if span.Name != "consumer" {
+				continue
+			}
+			found = true
+			assert.Equal(pctx.SpanID(), span.ParentID)
+			// The first FollowsFrom reference is the parent, only the other
+			// one is recorded as a link.
+			var links []ddtrace.SpanLink
+			require.NoError(t, json.Unmarshal([]byte(span.Meta["_dd.span_links"]), &links))
+			assert.Equal([]ddtrace.SpanLink{spanLink(other.Context().(ddtrace.SpanContext))}, links)
+		}
+		assert.True(found)
+	})
+
+	t.Run("child-of-and-follows-from", func(t *testing.T) {
+		sso := []opentracing.SpanReference{
+			opentracing.FollowsFrom(producer.Context()),
+			opentracing.ChildOf(parent.Context()),
+		}
+		p := parentRef(sso)
+		assert.Equal(parent.Context(), p)
+	})
+
+	t.Run("link", func(t *testing.T) {
+		pctx := producer.Context().(ddtrace.SpanContext)
+		link := spanLink(pctx)
+		assert.Equal(pctx.TraceID(), link.TraceID)
+		assert.Equal(pctx.SpanID(), link.SpanID)
+		assert.Equal("follows_from", link.Attributes["opentracing.ref_type"])
+	})
+}
@@ -0,0 +1,266 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/version"
+)
+
+const (
+	// ciVisibilityEVPPath is the path of the agent's EVP proxy endpoint
+	// forwarding test events to the CI Visibility intake.
+	ciVisibilityEVPPath = "/evp_proxy/v2/api/v2/citestcycle"
+
+	// ciVisibilityEVPSubdomain is the intake subdomain targeted through the EVP proxy.
+	ciVisibilityEVPSubdomain = "citestcycle-intake"
+
+	// ciVisibilityAgentlessURL is the format of the CI Visibility intake URL,
+	// given a Datadog site.
+	ciVisibilityAgentlessURL = "https://citestcycle-intake.%s/api/v2/citestcycle"
+)
+
+// ciVisibilityTraceWriter encodes spans as CI Visibility test events and sends
+// them to the CI Visibility intake, either through the agent's EVP proxy or
+// directly (agentless mode).
+//
+// The payload is a msgpack map of the form:
+//
+//	{"version": 1, "metadata": {"*": {...}}, "events": [{"type": ..., "version": 1, "content": span}]}
+//
+// where the type of each event is derived from its span type. Spans which are
+// not test events are sent with the "span" type.
+type ciVisibilityTraceWriter struct {
+	// config holds the tracer configuration
+	config *config
+
+	// events holds the msgpack-encoded events waiting to be sent
+	events []byte
+
+	// count holds the number of events encoded in events
+	count int
+
+	// climit limits the number of concurrent outgoing connections
+	climit chan struct{}
+
+	// wg waits for all uploads to finish
+	wg sync.WaitGroup
+
+	// statsd is used to send metrics
+	statsd statsdClient
+
+	// health holds the tracer's health counters, if any
+	health *healthCounters
+}
+
+func newCIVisibilityTraceWriter(c *config, statsdClient statsdClient) *ciVisibilityTraceWriter {
+	return &ciVisibilityTraceWriter{
+		config: c,
+		climit: make(chan struct{}, concurrentConnectionLimit),
+		statsd: statsdClient,
+	}
+}
+
+func (h *ciVisibilityTraceWriter) add(trace []*span) {
+	start := time.Now()
+	for _, s := range trace {
+		h.events = encodeCIVisibilityEvent(h.events, s)
+		h.count++
+	}
+	h.health.addEncodeTime(time.Since(start))
+	if len(h.events) > payloadSizeLimit {
+		h.statsd.Incr("datadog.tracer.flush_triggered", []string{"reason:size"}, 1)
+		h.flush()
+	}
+}
+
+func (h *ciVisibilityTraceWriter) stop() {
+	h.statsd.Incr("datadog.tracer.flush_triggered", []string{"reason:shutdown"}, 1)
+	h.flush()
+	h.wg.Wait()
+}
+
+// flush sends any currently buffered events to the intake.
+func (h *ciVisibilityTraceWriter) flush() {
+	if h.count == 0 {
+		return
+	}
+	body := h.encodePayload()
+	count := h.count
+	h.events, h.count = nil, 0
+	h.wg.Add(1)
+	h.climit <- struct{}{}
+	go func() {
+		defer func(start time.Time) {
+			<-h.climit
+			h.wg.Done()
+			h.statsd.Timing("datadog.tracer.flush_duration", time.Since(start), nil, 1)
+		}(time.Now())
+
+		var err error
+		for attempt := 0; attempt <= h.config.sendRetries; attempt++ {
+			log.Debug("Sending CI Visibility payload: size: %d events: %d\n", len(body), count)
+			if err = h.send(body); err == nil {
+				h.statsd.Count("datadog.tracer.flush_bytes", int64(len(body)), nil, 1)
+				return
+			}
+			h.health.addFlushError()
+			log.Error("failure sending CI Visibility events (attempt %d), will retry: %v", attempt+1, err)
+			time.Sleep(time.Millisecond)
+		}
+		h.statsd.Count("datadog.tracer.traces_dropped", int64(count), []string{"reason:send_failed"}, 1)
+		log.Error("lost %d CI Visibility events: %v", count, err)
+	}()
+}
+
+// encodePayload returns the msgpack-encoded payload holding all buffered events.
+func (h *ciVisibilityTraceWriter) encodePayload() []byte {
+	b := make([]byte, 0, len(h.events)+256)
+	b = msgp.AppendMapHeader(b, 3)
+	b = msgp.AppendString(b, "version")
+	b = msgp.AppendInt(b, 1)
+	b = msgp.AppendString(b, "metadata")
+	b = msgp.AppendMapHeader(b, 1)
+	b = msgp.AppendString(b, "*")
+	b = msgp.AppendMapHeader(b, 5)
+	b = msgp.AppendString(b, "language")
+	b = msgp.AppendString(b, "go")
+	b = msgp.AppendString(b, "runtime-id")
+	b = msgp.AppendString(b, globalconfig.RuntimeID())
+	b = msgp.AppendString(b, "library_version")
+	b = msgp.AppendString(b, version.Tag)
+	b = msgp.AppendString(b, ext.Environment)
+	b = msgp.AppendString(b, h.config.env)
+	b = msgp.AppendString(b, "service")
+	b = msgp.AppendString(b, h.config.serviceName)
+	b = msgp.AppendString(b, "events")
+	b = msgp.AppendArrayHeader(b, uint32(h.count))
+	return append(b, h.events...)
+}
+
+// send posts the encoded payload to the intake.
+func (h *ciVisibilityTraceWriter) send(body []byte) error {
+	var url string
+	if h.config.ciVisibilityAgentless {
+		site := os.Getenv("DD_SITE")
+		if site == "" {
+			site = "datadoghq.com"
+		}
+		url = fmt.Sprintf(ciVisibilityAgentlessURL, site)
+	} else {
+		url = h.config.agentURL.String() + ciVisibilityEVPPath
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("cannot create http request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/msgpack")
+	req.Header.Set("Datadog-Meta-Lang", "go")
+	req.Header.Set("Datadog-Meta-Tracer-Version", version.Tag)
+	if h.config.ciVisibilityAgentless {
+		req.Header.Set("dd-api-key", os.Getenv("DD_API_KEY"))
+	} else {
+		req.Header.Set("X-Datadog-EVP-Subdomain", ciVisibilityEVPSubdomain)
+	}
+	resp, err := h.config.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if code := resp.StatusCode; code >= 400 {
+		return fmt.Errorf("%s", http.StatusText(code))
+	}
+	return nil
+}
+
+// ciVisibilityEventType returns the CI Visibility event type of span s.
+func ciVisibilityEventType(s *span) string {
+	switch s.Type {
+	case ext.SpanTypeTest, ext.SpanTypeTestSuite, ext.SpanTypeTestModule, ext.SpanTypeTestSession:
+		return s.Type
+	default:
+		return "span"
+	}
+}
+
+// ciVisibilityIDTags lists the tags holding the IDs of the test session, module
+// and suite a test event belongs to. They are moved out of the span's meta and
+// into the event content.
+var ciVisibilityIDTags = []string{ext.TestSessionID, ext.TestModuleID, ext.TestSuiteID}
+
+// encodeCIVisibilityEvent appends the CI Visibility event of span s to b and
+// returns the extended buffer.
+func encodeCIVisibilityEvent(b []byte, s *span) []byte {
+	ids := make(map[string]uint64, len(ciVisibilityIDTags))
+	for _, k := range ciVisibilityIDTags {
+		if v, ok := s.Meta[k]; ok {
+			if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+				ids[k] = id
+			}
+		}
+	}
+	b = msgp.AppendMapHeader(b, 3)
+	b = msgp.AppendString(b, "type")
+	b = msgp.AppendString(b, ciVisibilityEventType(s))
+	b = msgp.AppendString(b, "version")
+	b = msgp.AppendInt(b, 1)
+	b = msgp.AppendString(b, "content")
+	b = msgp.AppendMapHeader(b, uint32(12+len(ids)))
+	b = msgp.AppendString(b, "trace_id")
+	b = msgp.AppendUint64(b, s.TraceID)
+	b = msgp.AppendString(b, "span_id")
+	b = msgp.AppendUint64(b, s.SpanID)
+	b = msgp.AppendString(b, "parent_id")
+	b = msgp.AppendUint64(b, s.ParentID)
+	b = msgp.AppendString(b, "name")
+	b = msgp.AppendString(b, s.Name)
+	b = msgp.AppendString(b, "resource")
+	b = msgp.AppendString(b, s.Resource)
+	b = msgp.AppendString(b, "service")
+	b = msgp.AppendString(b, s.Service)
+	b = msgp.AppendString(b, "type")
+	b = msgp.AppendString(b, s.Type)
+	b = msgp.AppendString(b, "start")
+	b = msgp.AppendInt64(b, s.Start)
+	b = msgp.AppendString(b, "duration")
+	b = msgp.AppendInt64(b, s.Duration)
+	b = msgp.AppendString(b, "error")
+	b = msgp.AppendInt32(b, s.Error)
+	for _, k := range ciVisibilityIDTags {
+		if id, ok := ids[k]; ok {
+			b = msgp.AppendString(b, k)
+			b = msgp.AppendUint64(b, id)
+		}
+	}
+	b = msgp.AppendString(b, "meta")
+	b = msgp.AppendMapHeader(b, uint32(len(s.Meta)-len(ids)))
+	for k, v := range s.Meta {
+		if _, ok := ids[k]; ok {
+			continue
+		}
+		b = msgp.AppendString(b, k)
+		b = msgp.AppendString(b, v)
+	}
+	b = msgp.AppendString(b, "metrics")
+	b = msgp.AppendMapHeader(b, uint32(len(s.Metrics)))
+	for k, v := range s.Metrics {
+		b = msgp.AppendString(b, k)
+		b = msgp.AppendFloat64(b, v)
+	}
+	return b
+}
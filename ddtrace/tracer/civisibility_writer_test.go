@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinylib/msgp/msgp"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+)
+
+func TestCIVisibilityTraceWriter(t *testing.T) {
+	assert := assert.New(t)
+	var (
+		body    []byte
+		headers http.Header
+		path    string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/info" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		path, headers = r.URL.Path, r.Header
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	c := newConfig(WithAgentAddr(srv.Listener.Addr().String()), WithCIVisibility(true), WithEnv("ci"))
+	h := newCIVisibilityTraceWriter(c, &testStatsdClient{})
+	test := newBasicSpan("go.test")
+	test.Type = ext.SpanTypeTest
+	test.Meta[ext.TestSuiteID] = strconv.FormatUint(1<<63+1, 10)
+	test.Meta[ext.TestName] = "TestFoo"
+	child := newBasicSpan("http.request")
+	h.add([]*span{test, child})
+	h.stop()
+
+	assert.Equal(ciVisibilityEVPPath, path)
+	assert.Equal(ciVisibilityEVPSubdomain, headers.Get("X-Datadog-EVP-Subdomain"))
+	v, _, err := msgp.ReadIntfBytes(body)
+	assert.NoError(err)
+	payload := v.(map[string]interface{})
+	assert.EqualValues(1, payload["version"])
+	metadata := payload["metadata"].(map[string]interface{})["*"].(map[string]interface{})
+	assert.Equal("go", metadata["language"])
+	assert.Equal("ci", metadata["env"])
+	events := payload["events"].([]interface{})
+	assert.Len(events, 2)
+
+	event := events[0].(map[string]interface{})
+	assert.Equal(ext.SpanTypeTest, event["type"])
+	content := event["content"].(map[string]interface{})
+	assert.EqualValues(uint64(1<<63+1), content[ext.TestSuiteID])
+	assert.Equal("go.test", content["name"])
+	meta := content["meta"].(map[string]interface{})
+	assert.Equal("TestFoo", meta[ext.TestName])
+	assert.NotContains(meta, ext.TestSuiteID)
+
+	event = events[1].(map[string]interface{})
+	assert.Equal("span", event["type"])
+	assert.Equal("http.request", event["content"].(map[string]interface{})["name"])
+}
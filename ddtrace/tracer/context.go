@@ -9,7 +9,9 @@ import (
 	"context"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/samplernames"
 )
 
 type contextKey struct{}
@@ -60,3 +62,42 @@ func StartSpanFromContext(ctx context.Context, operationName string, opts ...Sta
 	}
 	return s, ContextWithSpan(ctx, s)
 }
+
+// KeepTrace marks the trace of the span found in ctx to be kept, regardless of
+// any sampling decision taken so far. The user-keep sampling priority is set on
+// the local root span of the trace, so it can be called from any span in the
+// request path. It reports whether the decision could be applied, which is not
+// the case when ctx holds no span or the local root span has already finished.
+func KeepTrace(ctx context.Context) bool {
+	return setTraceSamplingPriority(ctx, ext.PriorityUserKeep)
+}
+
+// DropTrace marks the trace of the span found in ctx to be dropped, regardless
+// of any sampling decision taken so far. See KeepTrace for more details.
+func DropTrace(ctx context.Context) bool {
+	return setTraceSamplingPriority(ctx, ext.PriorityUserReject)
+}
+
+// setTraceSamplingPriority sets the given priority with the manual sampling
+// mechanism on the local root span of the span found in ctx.
+func setTraceSamplingPriority(ctx context.Context, priority int) bool {
+	s, ok := SpanFromContext(ctx)
+	if !ok {
+		return false
+	}
+	sp, ok := s.(*span)
+	if !ok {
+		return false
+	}
+	root := sp.root()
+	if root == nil {
+		return false
+	}
+	root.Lock()
+	defer root.Unlock()
+	if root.finished {
+		return false
+	}
+	root.setSamplingPriorityLocked(priority, samplernames.Manual)
+	return true
+}
@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
 
 	"github.com/stretchr/testify/assert"
@@ -162,3 +163,39 @@ func TestStartSpanFromNilContext(t *testing.T) {
 	assert.True(ok)
 	assert.Equal(child, ctxSpan)
 }
+
+func TestKeepDropTrace(t *testing.T) {
+	tracer, _, _, stop := startTestTracer(t)
+	defer stop()
+
+	for name, tt := range map[string]struct {
+		fn       func(context.Context) bool
+		priority int
+		dm       string
+	}{
+		"keep": {fn: KeepTrace, priority: ext.PriorityUserKeep, dm: "-4"},
+		"drop": {fn: DropTrace, priority: ext.PriorityUserReject},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			root := tracer.StartSpan("root").(*span)
+			child, ctx := StartSpanFromContext(ContextWithSpan(context.Background(), root), "child")
+
+			assert.True(tt.fn(ctx))
+			assert.Equal(float64(tt.priority), root.Metrics[keySamplingPriority])
+			assert.Equal(tt.dm, root.context.trace.propagatingTags[keyDecisionMaker])
+			p, ok := child.Context().(*spanContext).samplingPriority()
+			assert.True(ok)
+			assert.Equal(tt.priority, p)
+
+			child.Finish()
+			root.Finish()
+			assert.False(tt.fn(ctx))
+		})
+	}
+
+	t.Run("no-span", func(t *testing.T) {
+		assert.False(t, KeepTrace(context.Background()))
+		assert.False(t, DropTrace(context.Background()))
+	})
+}
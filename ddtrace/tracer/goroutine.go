@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"sync/atomic"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+// Go runs fn in a new goroutine, passing it ctx. Spans started by fn using
+// StartSpanFromContext become children of the span found in ctx, if any, and
+// the new goroutine carries the profiler labels of that span, so that its CPU
+// samples are attributed to the span and its endpoint by the Code Hotspots and
+// Endpoint Profiling features. Additionally, the span found in ctx is allowed to
+// be finished from the new goroutine without triggering the warning enabled by
+// WithGoroutineCheck.
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	var labels context.Context
+	if s, ok := SpanFromContext(ctx); ok {
+		if sp, ok := s.(*span); ok {
+			atomic.StoreUint32(&sp.propagated, 1)
+			sp.RLock()
+			labels = sp.pprofCtxActive
+			sp.RUnlock()
+		}
+	}
+	go func() {
+		if labels != nil {
+			pprof.SetGoroutineLabels(labels)
+		}
+		fn(ctx)
+	}()
+}
+
+// checkFinishGoroutine logs a warning if s is finished on a different goroutine
+// than the one it was started on, without having been propagated using Go.
+func checkFinishGoroutine(s *span) {
+	if s.goroutineID == 0 || atomic.LoadUint32(&s.propagated) == 1 {
+		return
+	}
+	if id := currentGoroutineID(); id != 0 && id != s.goroutineID {
+		log.Warn("Span %q (span_id: %d) was started on goroutine %d and finished on goroutine %d "+
+			"without its context being propagated. Use tracer.Go or tracer.StartSpanFromContext "+
+			"to propagate spans across goroutines and avoid orphaned spans.",
+			s.Name, s.SpanID, s.goroutineID, id)
+	}
+}
+
+var goroutinePrefix = []byte("goroutine ")
+
+// currentGoroutineID returns the identifier of the calling goroutine, or 0 if
+// it could not be determined. It is slow and should only be used for debugging.
+func currentGoroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, goroutinePrefix)
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrentGoroutineID(t *testing.T) {
+	id := currentGoroutineID()
+	assert.NotZero(t, id)
+	assert.Equal(t, id, currentGoroutineID())
+
+	var other uint64
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		other = currentGoroutineID()
+	}()
+	wg.Wait()
+	assert.NotZero(t, other)
+	assert.NotEqual(t, id, other)
+}
+
+func TestGoroutineCheck(t *testing.T) {
+	warnings := func(tp *log.RecordLogger) int {
+		var n int
+		for _, l := range tp.Logs() {
+			if strings.Contains(l, "without its context being propagated") {
+				n++
+			}
+		}
+		return n
+	}
+	finishIn := func(fn func(func())) func(Span) {
+		return func(s Span) {
+			var wg sync.WaitGroup
+			wg.Add(1)
+			fn(func() {
+				defer wg.Done()
+				s.Finish()
+			})
+			wg.Wait()
+		}
+	}
+
+	t.Run("unpropagated", func(t *testing.T) {
+		tp := new(log.RecordLogger)
+		tracer, _, _, stop := startTestTracer(t, WithLogger(tp), WithGoroutineCheck(true))
+		defer stop()
+
+		finishIn(func(f func()) { go f() })(tracer.StartSpan("op"))
+		assert.Equal(t, 1, warnings(tp))
+	})
+
+	t.Run("propagated", func(t *testing.T) {
+		tp := new(log.RecordLogger)
+		tracer, _, _, stop := startTestTracer(t, WithLogger(tp), WithGoroutineCheck(true))
+		defer stop()
+
+		s := tracer.StartSpan("op")
+		ctx := ContextWithSpan(context.Background(), s)
+		finishIn(func(f func()) { Go(ctx, func(context.Context) { f() }) })(s)
+		assert.Equal(t, 0, warnings(tp))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		tp := new(log.RecordLogger)
+		tracer, _, _, stop := startTestTracer(t, WithLogger(tp))
+		defer stop()
+
+		finishIn(func(f func()) { go f() })(tracer.StartSpan("op"))
+		assert.Equal(t, 0, warnings(tp))
+	})
+}
@@ -6,7 +6,7 @@
 package tracer
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -57,18 +57,7 @@ type startupInfo struct {
 // If the endpoint is not reachable, checkEndpoint returns an error
 // explaining why.
 func checkEndpoint(c *http.Client, endpoint string) error {
-	req, err := http.NewRequest("POST", endpoint, bytes.NewReader([]byte{0x90}))
-	if err != nil {
-		return fmt.Errorf("cannot create http request: %v", err)
-	}
-	req.Header.Set(traceCountHeader, "0")
-	req.Header.Set("Content-Type", "application/msgpack")
-	res, err := c.Do(req)
-	if err != nil {
-		return err
-	}
-	defer res.Body.Close()
-	return nil
+	return checkEndpointContext(context.Background(), c, endpoint)
 }
 
 // logStartup generates a startupInfo for a tracer and writes it to the log in
@@ -11,6 +11,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 )
 
@@ -97,14 +98,120 @@ func (t *tracer) reportRuntimeMetrics(interval time.Duration) {
 func (t *tracer) reportHealthMetrics(interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
+	var last HealthStats
 	for {
 		select {
 		case <-ticker.C:
 			t.statsd.Count("datadog.tracer.spans_started", int64(atomic.SwapUint32(&t.spansStarted, 0)), nil, 1)
 			t.statsd.Count("datadog.tracer.spans_finished", int64(atomic.SwapUint32(&t.spansFinished, 0)), nil, 1)
 			t.statsd.Count("datadog.tracer.traces_dropped", int64(atomic.SwapUint32(&t.tracesDropped, 0)), []string{"reason:trace_too_large"}, 1)
+			t.statsd.Count("datadog.tracer.traces_dropped", int64(atomic.SwapUint32(&t.tracesDroppedQueueFull, 0)), []string{"reason:queue_full"}, 1)
+
+			stats := t.healthStats()
+			t.statsd.Count("datadog.tracer.flush_errors", int64(stats.FlushErrors-last.FlushErrors), nil, 1)
+			t.statsd.Gauge("datadog.tracer.queue.fill_ratio", stats.QueueFillRatio, nil, 1)
+			t.statsd.Timing("datadog.tracer.encode_duration", stats.EncodeTime-last.EncodeTime, nil, 1)
+			if fn := t.config.healthMetricsCallback; fn != nil {
+				fn(stats)
+			}
+			last = stats
 		case <-t.stop:
 			return
 		}
 	}
 }
+
+// HealthStats holds health metrics about a running tracer. Counters are cumulative
+// since the tracer was started.
+type HealthStats struct {
+	// SpansStarted is the number of spans started.
+	SpansStarted uint64
+
+	// SpansFinished is the number of spans finished as part of a complete trace.
+	SpansFinished uint64
+
+	// TracesDropped is the number of traces dropped for any reason other than
+	// sampling, such as a full trace queue, encoding errors or failed sends.
+	TracesDropped uint64
+
+	// FlushErrors is the number of failed attempts at sending a payload.
+	FlushErrors uint64
+
+	// QueueFillRatio is the current fill ratio of the queue of finished traces
+	// waiting to be encoded, between 0 and 1.
+	QueueFillRatio float64
+
+	// EncodeTime is the total time spent encoding traces into payloads.
+	EncodeTime time.Duration
+}
+
+// Stats returns health metrics about the running tracer. It returns empty stats
+// if the tracer is not started.
+func Stats() HealthStats {
+	if t, ok := internal.GetGlobalTracer().(*tracer); ok {
+		return t.healthStats()
+	}
+	return HealthStats{}
+}
+
+// healthStats returns a snapshot of the tracer's health metrics.
+func (t *tracer) healthStats() HealthStats {
+	stats := t.health.snapshot()
+	if c := cap(t.out); c > 0 {
+		stats.QueueFillRatio = float64(len(t.out)) / float64(c)
+	}
+	return stats
+}
+
+// healthCounters holds the cumulative counters backing HealthStats. A nil
+// *healthCounters is valid and ignores all updates.
+type healthCounters struct {
+	spansStarted  uint64
+	spansFinished uint64
+	tracesDropped uint64
+	flushErrors   uint64
+	encodeTime    int64 // nanoseconds
+}
+
+func (h *healthCounters) addSpansStarted(n int) {
+	if h != nil {
+		atomic.AddUint64(&h.spansStarted, uint64(n))
+	}
+}
+
+func (h *healthCounters) addSpansFinished(n int) {
+	if h != nil {
+		atomic.AddUint64(&h.spansFinished, uint64(n))
+	}
+}
+
+func (h *healthCounters) addTracesDropped(n int) {
+	if h != nil {
+		atomic.AddUint64(&h.tracesDropped, uint64(n))
+	}
+}
+
+func (h *healthCounters) addFlushError() {
+	if h != nil {
+		atomic.AddUint64(&h.flushErrors, 1)
+	}
+}
+
+func (h *healthCounters) addEncodeTime(d time.Duration) {
+	if h != nil {
+		atomic.AddInt64(&h.encodeTime, int64(d))
+	}
+}
+
+func (h *healthCounters) snapshot() HealthStats {
+	if h == nil {
+		return HealthStats{}
+	}
+	return HealthStats{
+		SpansStarted:  atomic.LoadUint64(&h.spansStarted),
+		SpansFinished: atomic.LoadUint64(&h.spansFinished),
+		TracesDropped: atomic.LoadUint64(&h.tracesDropped),
+		FlushErrors:   atomic.LoadUint64(&h.flushErrors),
+		EncodeTime:    time.Duration(atomic.LoadInt64(&h.encodeTime)),
+	}
+}
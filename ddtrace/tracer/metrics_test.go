@@ -292,6 +292,50 @@ func TestReportHealthMetrics(t *testing.T) {
 	assert.Equal(int64(0), counts["datadog.tracer.traces_dropped"])
 }
 
+func TestHealthStats(t *testing.T) {
+	assert := assert.New(t)
+	var tg testStatsdClient
+
+	defer func(old time.Duration) { statsInterval = old }(statsInterval)
+	statsInterval = time.Millisecond
+
+	reported := make(chan HealthStats, 1)
+	tracer, _, flush, stop := startTestTracer(t,
+		withStatsdClient(&tg),
+		WithHealthMetricsCallback(func(s HealthStats) {
+			select {
+			case reported <- s:
+			default:
+			}
+		}),
+	)
+	defer stop()
+
+	root := tracer.StartSpan("operation")
+	tracer.StartSpan("child", ChildOf(root.Context())).Finish()
+	root.Finish()
+	flush(1)
+
+	stats := Stats()
+	assert.Equal(uint64(2), stats.SpansStarted)
+	assert.Equal(uint64(2), stats.SpansFinished)
+	assert.Equal(uint64(0), stats.TracesDropped)
+	assert.Equal(uint64(0), stats.FlushErrors)
+	assert.True(stats.EncodeTime > 0)
+
+	select {
+	case s := <-reported:
+		assert.True(s.QueueFillRatio >= 0 && s.QueueFillRatio <= 1)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for health metrics callback")
+	}
+	assert.Contains(tg.CallNames(), "datadog.tracer.queue.fill_ratio")
+}
+
+func TestHealthStatsNoTracer(t *testing.T) {
+	assert.Equal(t, HealthStats{}, Stats())
+}
+
 func TestTracerMetrics(t *testing.T) {
 	assert := assert.New(t)
 	var tg testStatsdClient
@@ -8,6 +8,7 @@ package tracer
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"net"
@@ -23,6 +24,7 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
@@ -63,13 +65,22 @@ type config struct {
 	logToStdout bool
 
 	// sendRetries is the number of times a trace payload send is retried upon
-	// failure.
+	// failure. It is sendRetriesUnset until resolved by newConfig.
 	sendRetries int
 
+	// serverless reports whether the tracer runs in a serverless environment,
+	// such as Google Cloud Functions or Cloud Run, where the instance may be
+	// frozen as soon as a request has been responded to.
+	serverless bool
+
 	// logStartup, when true, causes various startup info to be written
 	// when the tracer starts.
 	logStartup bool
 
+	// dryRun, when true, prevents newConfig from installing the configured
+	// logger and from querying the agent. It is used by SelfCheck.
+	dryRun bool
+
 	// serviceName specifies the name of this application.
 	serviceName string
 
@@ -141,6 +152,14 @@ type config struct {
 	// errors will record a stack trace when this option is set.
 	noDebugStack bool
 
+	// errorStackFrames specifies the maximum number of stack frames recorded for errors,
+	// unless overridden using the StackFrames FinishOption.
+	errorStackFrames uint
+
+	// errorStackCapture forces the collection of stack traces for errors which do not
+	// carry a stack trace of their own, even when debug stacks are disabled.
+	errorStackCapture bool
+
 	// profilerHotspots specifies whether profiler Code Hotspots is enabled.
 	profilerHotspots bool
 
@@ -161,6 +180,50 @@ type config struct {
 
 	// peerServiceMappings holds a set of service mappings to dynamically rename peer.service values.
 	peerServiceMappings map[string]string
+
+	// traceQueuePolicy specifies what happens to finished traces when the queue
+	// of traces waiting to be encoded is full.
+	traceQueuePolicy TraceQueuePolicy
+
+	// traceQueueTimeout specifies how long a finished trace may wait for space in
+	// the queue when traceQueuePolicy is BlockWithTimeout.
+	traceQueueTimeout time.Duration
+
+	// traceDropCallback, if set, is called every time a trace is dropped because
+	// the trace queue is full.
+	traceDropCallback func(spans int)
+
+	// healthMetricsCallback, if set, is called with the tracer's health metrics
+	// every time they are reported.
+	healthMetricsCallback func(HealthStats)
+
+	// spanStartHooks are called synchronously, in order, for every started span.
+	spanStartHooks []func(ddtrace.Span)
+
+	// spanFinishHooks are called synchronously, in order, for every span right
+	// before it finishes.
+	spanFinishHooks []func(ddtrace.Span)
+
+	// goroutineCheck enables warnings about spans finished on a different goroutine
+	// than the one they were started on, without context propagation.
+	goroutineCheck bool
+
+	// tagLimits holds the limits enforced on tags set using SetTag.
+	tagLimits tagLimits
+
+	// ciVisibilityEnabled reports whether traces are sent to the CI Visibility
+	// intake, as test events, instead of the agent's trace endpoint.
+	ciVisibilityEnabled bool
+
+	// ciVisibilityAgentless reports whether CI Visibility events are sent
+	// directly to the intake, instead of through the agent's EVP proxy.
+	ciVisibilityAgentless bool
+
+	// dataStreamsMonitoringEnabled specifies whether Data Streams Monitoring is enabled.
+	dataStreamsMonitoringEnabled bool
+
+	// dataStreams holds the options of the Data Streams Monitoring processor.
+	dataStreams []datastreams.ProcessorOption
 }
 
 // HasFeature reports whether feature f is enabled.
@@ -227,6 +290,13 @@ func newConfig(opts ...StartOption) *config {
 		// See: https://docs.aws.amazon.com/lambda/latest/dg/configuration-envvars.html
 		c.logToStdout = true
 	}
+	if os.Getenv("FUNCTION_TARGET") != "" {
+		// FUNCTION_TARGET is set in Google Cloud Functions only, whereas K_SERVICE is
+		// also set by Cloud Run services, which are not frozen between requests.
+		// See: https://cloud.google.com/functions/docs/configuring/env-var#runtime_environment_variables_set_automatically
+		c.serverless = true
+	}
+	c.serverless = internal.BoolEnv("DD_TRACE_SERVERLESS_MODE_ENABLED", c.serverless)
 	c.logStartup = internal.BoolEnv("DD_TRACE_STARTUP_LOGS", true)
 	c.runtimeMetrics = internal.BoolEnv("DD_RUNTIME_METRICS_ENABLED", false)
 	c.debug = internal.BoolEnv("DD_TRACE_DEBUG", false)
@@ -257,6 +327,13 @@ func newConfig(opts ...StartOption) *config {
 	if v := os.Getenv("DD_TRACE_PEER_SERVICE_MAPPING"); v != "" {
 		internal.ForEachStringTag(v, func(key, val string) { c.peerServiceMappings[key] = val })
 	}
+	c.traceQueueTimeout = defaultTraceQueueTimeout
+	c.tagLimits.maxValueLength = internal.IntEnv("DD_TRACE_TAG_VALUE_MAX_LENGTH", 0)
+	c.tagLimits.maxTags = internal.IntEnv("DD_TRACE_SPAN_MAX_TAGS", 0)
+	c.ciVisibilityEnabled = internal.BoolEnv("DD_CIVISIBILITY_ENABLED", false)
+	c.ciVisibilityAgentless = internal.BoolEnv("DD_CIVISIBILITY_AGENTLESS_ENABLED", false)
+	c.dataStreamsMonitoringEnabled = internal.BoolEnv("DD_DATA_STREAMS_ENABLED", false)
+	c.sendRetries = sendRetriesUnset
 
 	for _, fn := range opts {
 		fn(c)
@@ -277,7 +354,17 @@ func newConfig(opts ...StartOption) *config {
 			Host:   fmt.Sprintf("UDS_%s", strings.NewReplacer(":", "_", "/", "_", `\`, "_").Replace(c.agentURL.Path)),
 		}
 	} else if c.httpClient == nil {
-		c.httpClient = defaultClient
+		if c.serverless {
+			c.httpClient = serverlessClient
+		} else {
+			c.httpClient = defaultClient
+		}
+	}
+	if c.sendRetries == sendRetriesUnset {
+		c.sendRetries = 0
+		if c.serverless {
+			c.sendRetries = defaultServerlessSendRetries
+		}
 	}
 	WithGlobalTag(ext.RuntimeID, globalconfig.RuntimeID())(c)
 	if c.env == "" {
@@ -322,13 +409,18 @@ func newConfig(opts ...StartOption) *config {
 			MaxTagsHeaderLen: max,
 		})
 	}
-	if c.logger != nil {
-		log.UseLogger(c.logger)
+	if !c.dryRun {
+		if c.logger != nil {
+			log.UseLogger(c.logger)
+		}
+		if c.debug {
+			log.SetLevel(log.LevelDebug)
+		}
+		c.loadAgentFeatures()
 	}
-	if c.debug {
-		log.SetLevel(log.LevelDebug)
+	if t, ok := c.transport.(*httpTransport); ok && c.canUseV07() {
+		t.traceURL = fmt.Sprintf("%s/v0.7/traces", c.agentURL)
 	}
-	c.loadAgentFeatures()
 	if c.statsdClient == nil {
 		// configure statsd client
 		addr := c.dogstatsdAddr
@@ -351,6 +443,7 @@ func newConfig(opts ...StartOption) *config {
 		}
 		c.dogstatsdAddr = addr
 	}
+	globalconfig.SetDogstatsdAddr(c.dogstatsdAddr)
 
 	return c
 }
@@ -425,10 +518,17 @@ type agentFeatures struct {
 	// the /v0.6/stats endpoint.
 	Stats bool
 
+	// V07 reports whether the agent can receive traces encoded using the v0.7
+	// protocol on the /v0.7/traces endpoint.
+	V07 bool
+
 	// StatsdPort specifies the Dogstatsd port as provided by the agent.
 	// If it's the default, it will be 0, which means 8125.
 	StatsdPort int
 
+	// Version is the version of the agent.
+	Version string
+
 	// featureFlags specifies all the feature flags reported by the trace-agent.
 	featureFlags map[string]struct{}
 }
@@ -447,17 +547,36 @@ func (c *config) loadAgentFeatures() {
 		// there is no agent; all features off
 		return
 	}
-	resp, err := c.httpClient.Get(fmt.Sprintf("%s/info", c.agentURL))
-	if err != nil {
+	features, err := fetchAgentFeatures(context.Background(), c.httpClient, c.agentURL)
+	if err != nil && err != errAgentFeaturesUndiscoverable {
 		log.Error("Loading features: %v", err)
 		return
 	}
-	if resp.StatusCode == http.StatusNotFound {
-		// agent is older than 7.28.0, features not discoverable
-		return
+	c.agent = features
+}
+
+// errAgentFeaturesUndiscoverable is returned by fetchAgentFeatures when the
+// agent does not expose its features.
+var errAgentFeaturesUndiscoverable = errors.New("agent is older than 7.28.0: features are not discoverable")
+
+// fetchAgentFeatures queries the /info endpoint of the agent at agentURL for its
+// capabilities. The request is aborted when ctx is done.
+func fetchAgentFeatures(ctx context.Context, client *http.Client, agentURL *url.URL) (agentFeatures, error) {
+	var features agentFeatures
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/info", agentURL), nil)
+	if err != nil {
+		return features, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return features, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return features, errAgentFeaturesUndiscoverable
+	}
 	type infoResponse struct {
+		Version       string   `json:"version"`
 		Endpoints     []string `json:"endpoints"`
 		ClientDropP0s bool     `json:"client_drop_p0s"`
 		StatsdPort    int      `json:"statsd_port"`
@@ -465,27 +584,36 @@ func (c *config) loadAgentFeatures() {
 	}
 	var info infoResponse
 	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
-		log.Error("Decoding features: %v", err)
-		return
+		return features, fmt.Errorf("cannot decode agent info: %v", err)
 	}
-	c.agent.DropP0s = info.ClientDropP0s
-	c.agent.StatsdPort = info.StatsdPort
+	features.Version = info.Version
+	features.DropP0s = info.ClientDropP0s
+	features.StatsdPort = info.StatsdPort
 	for _, endpoint := range info.Endpoints {
 		switch endpoint {
 		case "/v0.6/stats":
-			c.agent.Stats = true
+			features.Stats = true
+		case "/v0.7/traces":
+			features.V07 = true
 		}
 	}
-	c.agent.featureFlags = make(map[string]struct{}, len(info.FeatureFlags))
+	features.featureFlags = make(map[string]struct{}, len(info.FeatureFlags))
 	for _, flag := range info.FeatureFlags {
-		c.agent.featureFlags[flag] = struct{}{}
+		features.featureFlags[flag] = struct{}{}
 	}
+	return features, nil
 }
 
 func (c *config) canComputeStats() bool {
 	return c.agent.Stats && c.HasFeature("discovery")
 }
 
+// canUseV07 reports whether traces should be sent using the v0.7 protocol. It
+// requires both agent support and the "v0.7" feature flag in DD_TRACE_FEATURES.
+func (c *config) canUseV07() bool {
+	return c.agent.V07 && c.HasFeature("v0.7")
+}
+
 func (c *config) canDropP0s() bool {
 	return c.canComputeStats() && c.agent.DropP0s
 }
@@ -513,6 +641,14 @@ func statsTags(c *config) []string {
 	return tags
 }
 
+// withDryRun is used by SelfCheck to build a configuration without installing
+// the logger or querying the agent.
+func withDryRun() StartOption {
+	return func(c *config) {
+		c.dryRun = true
+	}
+}
+
 // withNoopStats is used for testing to disable statsd client
 func withNoopStats() StartOption {
 	return func(c *config) {
@@ -562,6 +698,25 @@ func WithDebugStack(enabled bool) StartOption {
 	}
 }
 
+// WithErrorStackTraceLimit sets the maximum number of stack frames recorded in the
+// "error.stack" tag of spans reporting errors. It defaults to 32. The StackFrames
+// FinishOption takes precedence over this setting.
+func WithErrorStackTraceLimit(n uint) StartOption {
+	return func(c *config) {
+		c.errorStackFrames = n
+	}
+}
+
+// WithErrorStackCapture enables capturing a stack trace for errors set on spans, through
+// WithError or the "error" tag, which do not carry a stack trace of their own (i.e. errors
+// not implementing fmt.Formatter or xerrors.Formatter), even when debug stacks are disabled
+// using WithDebugStack(false) or NoDebugStack.
+func WithErrorStackCapture(enabled bool) StartOption {
+	return func(c *config) {
+		c.errorStackCapture = enabled
+	}
+}
+
 // WithDebugMode enables debug mode on the tracer, resulting in more verbose logging.
 func WithDebugMode(enabled bool) StartOption {
 	return func(c *config) {
@@ -587,6 +742,21 @@ func WithSendRetries(retries int) StartOption {
 	}
 }
 
+// WithServerlessMode enables serverless mode on the tracer, for use with
+// environments such as Google Cloud Functions and Cloud Run, where the instance
+// may be frozen as soon as a request has been responded to. In serverless mode,
+// Flush waits for the buffered traces to be sent to the agent, the requests to
+// the agent time out sooner and failed sends are retried, unless WithSendRetries
+// or WithHTTPClient are used. It is enabled by default when running in Google
+// Cloud Functions, and can also be enabled by setting the
+// DD_TRACE_SERVERLESS_MODE_ENABLED environment variable to true, e.g. in Cloud
+// Run services configured to only allocate CPU during requests.
+func WithServerlessMode(enabled bool) StartOption {
+	return func(c *config) {
+		c.serverless = enabled
+	}
+}
+
 // WithPropagator sets an alternative propagator to be used by the tracer.
 func WithPropagator(p Propagator) StartOption {
 	return func(c *config) {
@@ -842,6 +1012,156 @@ func WithProfilerEndpoints(enabled bool) StartOption {
 	}
 }
 
+// TraceQueuePolicy specifies how the tracer behaves when its in-memory queue of
+// finished traces waiting to be encoded is full.
+type TraceQueuePolicy int
+
+const (
+	// DropNewest drops the trace that could not be added to the queue. This is the default.
+	DropNewest TraceQueuePolicy = iota
+
+	// DropOldest drops the oldest trace in the queue to make room for the new one.
+	DropOldest
+
+	// BlockWithTimeout blocks the goroutine finishing the trace until there is room
+	// in the queue, or until the timeout set using WithTraceQueueTimeout expires,
+	// in which case the trace is dropped.
+	BlockWithTimeout
+)
+
+// defaultTraceQueueTimeout is the default time a finished trace waits for room in
+// the queue when using the BlockWithTimeout policy.
+const defaultTraceQueueTimeout = 100 * time.Millisecond
+
+// WithTraceQueuePolicy sets the policy applied when the queue of finished traces
+// is full. Traces dropped this way are reported through the
+// "datadog.tracer.traces_dropped" health metric using the "reason:queue_full" tag.
+func WithTraceQueuePolicy(p TraceQueuePolicy) StartOption {
+	return func(c *config) {
+		c.traceQueuePolicy = p
+	}
+}
+
+// WithTraceQueueTimeout sets the maximum time a finished trace waits for room in
+// the queue when using the BlockWithTimeout policy. It defaults to 100ms.
+func WithTraceQueueTimeout(d time.Duration) StartOption {
+	return func(c *config) {
+		c.traceQueueTimeout = d
+	}
+}
+
+// WithTraceDropCallback registers fn to be called every time a trace is dropped
+// because the queue of finished traces is full. It receives the number of spans
+// in the dropped trace. fn is called synchronously and must not block.
+func WithTraceDropCallback(fn func(spans int)) StartOption {
+	return func(c *config) {
+		c.traceDropCallback = fn
+	}
+}
+
+// WithHealthMetricsCallback registers fn to be called with the tracer's health
+// metrics every time they are reported to statsd, which happens every 10 seconds.
+// fn is called from a background goroutine and must not block.
+func WithHealthMetricsCallback(fn func(HealthStats)) StartOption {
+	return func(c *config) {
+		c.healthMetricsCallback = fn
+	}
+}
+
+// WithSpanStartHook registers fn to be called synchronously every time a span is
+// started, after all of its start options were applied. It can be used to enrich
+// all spans, e.g. with tenant or region tags, without wrapping every integration.
+// Hooks are called in the order they were registered and must be fast, as they
+// run on the hot path of every span.
+func WithSpanStartHook(fn func(ddtrace.Span)) StartOption {
+	return func(c *config) {
+		c.spanStartHooks = append(c.spanStartHooks, fn)
+	}
+}
+
+// WithSpanFinishHook registers fn to be called synchronously every time a span
+// is finished, right before it is marked as finished, so tags can still be set
+// on it. Unlike trace-level processing, it is called for each individual span.
+// Hooks are called in the order they were registered and must be fast, as they
+// run on the hot path of every span.
+func WithSpanFinishHook(fn func(ddtrace.Span)) StartOption {
+	return func(c *config) {
+		c.spanFinishHooks = append(c.spanFinishHooks, fn)
+	}
+}
+
+// WithGoroutineCheck enables a debugging check which logs a warning every time a span
+// is finished on a different goroutine than the one it was started on, without having
+// been propagated using Go. This usually points to spans which are not passed around
+// using a context.Context, resulting in orphaned spans. The check adds a significant
+// overhead to starting spans and should not be enabled in production.
+func WithGoroutineCheck(enabled bool) StartOption {
+	return func(c *config) {
+		c.goroutineCheck = enabled
+	}
+}
+
+// WithTagValueMaxLength sets the maximum length of string tag values set on spans
+// using SetTag. Longer values are truncated and end with "...". A value of 0, the
+// default, disables the limit. It can also be set using the
+// DD_TRACE_TAG_VALUE_MAX_LENGTH environment variable.
+func WithTagValueMaxLength(n int) StartOption {
+	return func(c *config) {
+		c.tagLimits.maxValueLength = n
+	}
+}
+
+// WithMaxTagsPerSpan sets the maximum number of tags a span can hold. Once it is
+// reached, new tags set using SetTag are dropped and counted in the
+// "_dd.span.tags_dropped" metric of the span. A value of 0, the default, disables
+// the limit. It can also be set using the DD_TRACE_SPAN_MAX_TAGS environment variable.
+func WithMaxTagsPerSpan(n int) StartOption {
+	return func(c *config) {
+		c.tagLimits.maxTags = n
+	}
+}
+
+// WithCIVisibility enables CI Visibility mode, in which all traces are kept and
+// sent as test events to the CI Visibility intake, through the agent's EVP proxy.
+// When the DD_CIVISIBILITY_AGENTLESS_ENABLED environment variable is true, events
+// are sent directly to the intake of DD_SITE using DD_API_KEY instead. It can also
+// be enabled using the DD_CIVISIBILITY_ENABLED environment variable. It is usually
+// not needed to use this option directly; see the civisibility/gotesting package.
+func WithCIVisibility(enabled bool) StartOption {
+	return func(c *config) {
+		c.ciVisibilityEnabled = enabled
+	}
+}
+
+// WithDataStreamsBucketDuration sets the span of time covered by one Data
+// Streams Monitoring stats bucket, which is the resolution of the stats.
+// It defaults to 10 seconds.
+func WithDataStreamsBucketDuration(d time.Duration) StartOption {
+	return func(c *config) {
+		c.dataStreams = append(c.dataStreams, datastreams.WithBucketDuration(d))
+	}
+}
+
+// WithDataStreamsFlushInterval sets the interval at which the completed Data
+// Streams Monitoring stats buckets are sent to the agent. It defaults to the
+// bucket duration. A longer interval reduces the number of payloads sent by
+// batch pipelines.
+func WithDataStreamsFlushInterval(d time.Duration) StartOption {
+	return func(c *config) {
+		c.dataStreams = append(c.dataStreams, datastreams.WithFlushInterval(d))
+	}
+}
+
+// WithDataStreamsMaxBuckets sets the maximum number of Data Streams Monitoring
+// stats buckets kept in memory while waiting to be flushed. When it is reached,
+// the oldest bucket is sent right away. A value of 0, the default, disables the
+// limit.
+func WithDataStreamsMaxBuckets(n int) StartOption {
+	return func(c *config) {
+		c.dataStreams = append(c.dataStreams, datastreams.WithMaxBuckets(n))
+	}
+}
+
 // StartSpanOption is a configuration option for StartSpan. It is aliased in order
 // to help godoc group all the functions returning it together. It is considered
 // more correct to refer to it as the type as the origin, ddtrace.StartSpanOption.
@@ -899,6 +1219,15 @@ func ChildOf(ctx ddtrace.SpanContext) StartSpanOption {
 	}
 }
 
+// WithSpanLinks sets span links on the started span. Span links describe causal
+// relationships with spans which are not the parent of the started span, possibly
+// belonging to a different trace.
+func WithSpanLinks(links []ddtrace.SpanLink) StartSpanOption {
+	return func(cfg *ddtrace.StartSpanConfig) {
+		cfg.SpanLinks = append(cfg.SpanLinks, links...)
+	}
+}
+
 // withContext associates the ctx with the span.
 func withContext(ctx context.Context) StartSpanOption {
 	return func(cfg *ddtrace.StartSpanConfig) {
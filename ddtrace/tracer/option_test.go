@@ -335,6 +335,7 @@ func TestTracerOptionsDefaults(t *testing.T) {
 			defer tracer.Stop()
 			c := tracer.config
 			assert.Equal(t, c.dogstatsdAddr, "10.1.0.12:4002")
+			assert.Equal(t, "10.1.0.12:4002", globalconfig.DogstatsdAddr())
 		})
 	})
 
@@ -1091,3 +1092,58 @@ func TestHostnameDisabled(t *testing.T) {
 		assert.False(t, c.enableHostnameDetection)
 	})
 }
+
+func TestServerlessMode(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		c := newConfig()
+		assert.False(t, c.serverless)
+		assert.Same(t, defaultClient, c.httpClient)
+		assert.Zero(t, c.sendRetries)
+	})
+	t.Run("option", func(t *testing.T) {
+		c := newConfig(WithServerlessMode(true))
+		assert.True(t, c.serverless)
+		assert.Same(t, serverlessClient, c.httpClient)
+		assert.Equal(t, defaultServerlessSendRetries, c.sendRetries)
+	})
+	t.Run("cloud-functions", func(t *testing.T) {
+		t.Setenv("FUNCTION_TARGET", "HelloWorld")
+		t.Setenv("K_SERVICE", "my-function")
+		c := newConfig()
+		assert.True(t, c.serverless)
+	})
+	t.Run("cloud-run", func(t *testing.T) {
+		t.Setenv("K_SERVICE", "my-service")
+		c := newConfig()
+		assert.False(t, c.serverless)
+		assert.Zero(t, c.sendRetries)
+	})
+	t.Run("disable-via-env", func(t *testing.T) {
+		t.Setenv("FUNCTION_TARGET", "HelloWorld")
+		t.Setenv("DD_TRACE_SERVERLESS_MODE_ENABLED", "false")
+		c := newConfig()
+		assert.False(t, c.serverless)
+	})
+	t.Run("overrides", func(t *testing.T) {
+		client := &http.Client{}
+		c := newConfig(WithServerlessMode(true), WithSendRetries(5), WithHTTPClient(client))
+		assert.Same(t, client, c.httpClient)
+		assert.Equal(t, 5, c.sendRetries)
+	})
+	t.Run("no-retries", func(t *testing.T) {
+		c := newConfig(WithServerlessMode(true), WithSendRetries(0))
+		assert.Zero(t, c.sendRetries)
+	})
+}
+
+func TestWithDataStreamsOptions(t *testing.T) {
+	c := newConfig()
+	assert.Empty(t, c.dataStreams)
+
+	c = newConfig(
+		WithDataStreamsBucketDuration(time.Second),
+		WithDataStreamsFlushInterval(time.Minute),
+		WithDataStreamsMaxBuckets(6),
+	)
+	assert.Len(t, c.dataStreams, 3)
+}
@@ -57,6 +57,10 @@ type payload struct {
 
 	// reader is used for reading the contents of buf.
 	reader *bytes.Reader
+
+	// prefix holds the encoded fields which precede the chunks in v0.7
+	// payloads, and is nil otherwise.
+	prefix []byte
 }
 
 var _ io.Reader = (*payload)(nil)
@@ -70,9 +74,21 @@ func newPayload() *payload {
 	return p
 }
 
+// newPayloadV07 returns a ready to use payload, encoding traces using the v0.7
+// protocol on behalf of the tracer configured by c. See encodeTracerPayloadV07
+// for a description of the format.
+func newPayloadV07(c *config) *payload {
+	p := newPayload()
+	p.prefix = encodeTracerPayloadV07(c)
+	p.updateHeader()
+	return p
+}
+
 // push pushes a new item into the stream.
 func (p *payload) push(t spanList) error {
-	if err := msgp.Encode(&p.buf, t); err != nil {
+	if p.prefix != nil {
+		p.buf.Write(encodeChunkV07(nil, t))
+	} else if err := msgp.Encode(&p.buf, t); err != nil {
 		return err
 	}
 	atomic.AddUint32(&p.count, 1)
@@ -118,6 +134,13 @@ const (
 // present in the stream.
 func (p *payload) updateHeader() {
 	n := uint64(atomic.LoadUint32(&p.count))
+	if p.prefix != nil {
+		// v0.7 payloads are a map holding the tracer fields followed by the chunks
+		p.header = append(p.header[:0], p.prefix...)
+		p.header = msgp.AppendArrayHeader(p.header, uint32(n))
+		p.off = 0
+		return
+	}
 	switch {
 	case n <= 15:
 		p.header[7] = msgpackArrayFix + byte(n)
@@ -8,11 +8,17 @@ package tracer
 import (
 	"bytes"
 	"io"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync/atomic"
 	"testing"
 
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/samplernames"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/version"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/tinylib/msgp/msgp"
 )
@@ -108,3 +114,213 @@ func benchmarkPayloadThroughput(count int) func(*testing.B) {
 		}
 	}
 }
+
+// tracerPayloadV07 mirrors the agent's TracerPayload, as defined in the
+// datadog-agent/pkg/proto/pbgo/trace package.
+type tracerPayloadV07 struct {
+	ContainerID     string
+	LanguageName    string
+	LanguageVersion string
+	TracerVersion   string
+	RuntimeID       string
+	Env             string
+	Hostname        string
+	AppVersion      string
+	Chunks          []traceChunkV07
+}
+
+// traceChunkV07 mirrors the msgpack layout of the agent's TraceChunk.
+type traceChunkV07 struct {
+	Priority     int32
+	Origin       string
+	Spans        []*span
+	Tags         map[string]string
+	DroppedTrace bool
+}
+
+// decodePayloadV07 decodes the v0.7 payload read from r.
+func decodePayloadV07(t *testing.T, r io.Reader) *tracerPayloadV07 {
+	dec := msgp.NewReader(r)
+	var tp tracerPayloadV07
+	sz, err := dec.ReadMapHeader()
+	assert.NoError(t, err)
+	for ; sz > 0; sz-- {
+		field, err := dec.ReadString()
+		assert.NoError(t, err)
+		switch field {
+		case "container_id":
+			tp.ContainerID, err = dec.ReadString()
+		case "language_name":
+			tp.LanguageName, err = dec.ReadString()
+		case "language_version":
+			tp.LanguageVersion, err = dec.ReadString()
+		case "tracer_version":
+			tp.TracerVersion, err = dec.ReadString()
+		case "runtime_id":
+			tp.RuntimeID, err = dec.ReadString()
+		case "env":
+			tp.Env, err = dec.ReadString()
+		case "hostname":
+			tp.Hostname, err = dec.ReadString()
+		case "app_version":
+			tp.AppVersion, err = dec.ReadString()
+		case "chunks":
+			var n uint32
+			n, err = dec.ReadArrayHeader()
+			assert.NoError(t, err)
+			for ; n > 0; n-- {
+				tp.Chunks = append(tp.Chunks, decodeChunkV07(t, dec))
+			}
+		default:
+			t.Fatalf("unexpected payload field %q", field)
+		}
+		assert.NoError(t, err)
+	}
+	return &tp
+}
+
+// decodeChunkV07 decodes a single trace chunk from dec.
+func decodeChunkV07(t *testing.T, dec *msgp.Reader) traceChunkV07 {
+	var c traceChunkV07
+	sz, err := dec.ReadMapHeader()
+	assert.NoError(t, err)
+	for ; sz > 0; sz-- {
+		field, err := dec.ReadString()
+		assert.NoError(t, err)
+		switch field {
+		case "priority":
+			c.Priority, err = dec.ReadInt32()
+		case "origin":
+			c.Origin, err = dec.ReadString()
+		case "spans":
+			var n uint32
+			n, err = dec.ReadArrayHeader()
+			assert.NoError(t, err)
+			for ; n > 0; n-- {
+				s := new(span)
+				assert.NoError(t, s.DecodeMsg(dec))
+				c.Spans = append(c.Spans, s)
+			}
+		case "tags":
+			var n uint32
+			n, err = dec.ReadMapHeader()
+			assert.NoError(t, err)
+			c.Tags = make(map[string]string, n)
+			for ; n > 0; n-- {
+				k, err := dec.ReadString()
+				assert.NoError(t, err)
+				c.Tags[k], err = dec.ReadString()
+				assert.NoError(t, err)
+			}
+		case "dropped_trace":
+			c.DroppedTrace, err = dec.ReadBool()
+		default:
+			t.Fatalf("unexpected chunk field %q", field)
+		}
+		assert.NoError(t, err)
+	}
+	return c
+}
+
+func TestPayloadV07(t *testing.T) {
+	c := &config{env: "prod", hostname: "host", version: "1.2.3"}
+
+	t.Run("decode", func(t *testing.T) {
+		assert := assert.New(t)
+		p := newPayloadV07(c)
+		var want []spanList
+		for i := 0; i < 20; i++ {
+			list := newSpanList(i%5 + 1)
+			list[0].Meta[keyOrigin] = "synthetics"
+			list[0].Metrics[keySamplingPriority] = 2
+			want = append(want, list)
+			assert.NoError(p.push(list))
+		}
+		assert.Equal(20, p.itemCount())
+		size := p.size()
+
+		var buf bytes.Buffer
+		_, err := io.Copy(&buf, p)
+		assert.NoError(err)
+		assert.Equal(buf.Len(), size)
+
+		got := decodePayloadV07(t, &buf)
+		assert.Equal("go", got.LanguageName)
+		assert.Equal(strings.TrimPrefix(runtime.Version(), "go"), got.LanguageVersion)
+		assert.Equal(version.Tag, got.TracerVersion)
+		assert.Equal(globalconfig.RuntimeID(), got.RuntimeID)
+		assert.Equal("prod", got.Env)
+		assert.Equal("host", got.Hostname)
+		assert.Equal("1.2.3", got.AppVersion)
+		assert.Len(got.Chunks, len(want))
+		for i, chunk := range got.Chunks {
+			assert.EqualValues(2, chunk.Priority)
+			assert.Equal("synthetics", chunk.Origin)
+			assert.Empty(chunk.Tags)
+			assert.False(chunk.DroppedTrace)
+			assert.Len(chunk.Spans, len(want[i]))
+			for j, s := range chunk.Spans {
+				w := want[i][j]
+				assert.Equal(w.Name, s.Name)
+				assert.Equal(w.Service, s.Service)
+				assert.Equal(w.Resource, s.Resource)
+				assert.Equal(w.Type, s.Type)
+				assert.Equal(w.SpanID, s.SpanID)
+				assert.Equal(w.TraceID, s.TraceID)
+				assert.Equal(w.Start, s.Start)
+				// empty maps are omitted from the encoding and decode as nil
+				assert.Len(s.Meta, len(w.Meta))
+				for k, v := range w.Meta {
+					assert.Equal(v, s.Meta[k])
+				}
+				assert.Len(s.Metrics, len(w.Metrics))
+				for k, v := range w.Metrics {
+					assert.Equal(v, s.Metrics[k])
+				}
+			}
+		}
+	})
+
+	t.Run("priority", func(t *testing.T) {
+		assert := assert.New(t)
+		p := newPayloadV07(c)
+		// no sampling decision
+		assert.NoError(p.push(newSpanList(2)))
+		// partial chunk, the root has not finished yet
+		partial := newSpanList(2)
+		for _, s := range partial {
+			s.context.trace.setSamplingPriority(ext.PriorityUserKeep, samplernames.Manual)
+		}
+		assert.NoError(p.push(partial))
+		// sampling decision set on the root span
+		root := newSpanList(1)
+		root[0].Metrics[keySamplingPriority] = ext.PriorityAutoReject
+		assert.NoError(p.push(root))
+
+		got := decodePayloadV07(t, p)
+		assert.Len(got.Chunks, 3)
+		assert.EqualValues(priorityNoneV07, got.Chunks[0].Priority)
+		assert.EqualValues(ext.PriorityUserKeep, got.Chunks[1].Priority)
+		assert.EqualValues(ext.PriorityAutoReject, got.Chunks[2].Priority)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert := assert.New(t)
+		p := newPayloadV07(c)
+		got := decodePayloadV07(t, p)
+		assert.Equal("prod", got.Env)
+		assert.Empty(got.Chunks)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		assert := assert.New(t)
+		p := newPayloadV07(c)
+		p.push(newSpanList(3))
+		first, err := io.ReadAll(p)
+		assert.NoError(err)
+		p.reset()
+		second, err := io.ReadAll(p)
+		assert.NoError(err)
+		assert.Equal(first, second)
+	})
+}
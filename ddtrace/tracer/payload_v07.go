@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"math"
+	"runtime"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/version"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// keyChunksV07 is the key holding the trace chunks in v0.7 payloads.
+const keyChunksV07 = "chunks"
+
+// encodeTracerPayloadV07 returns the encoding of all the fields of a v0.7
+// payload except its chunks, which must follow. The fields describe the tracer
+// configured by c.
+//
+// A v0.7 payload is a msgpack-encoded TracerPayload, as defined by the agent in
+// the datadog-agent/pkg/proto/pbgo/trace package:
+//
+//	{
+//	  "container_id": string,
+//	  "language_name": string,
+//	  "language_version": string,
+//	  "tracer_version": string,
+//	  "runtime_id": string,
+//	  "env": string,
+//	  "hostname": string,
+//	  "app_version": string,
+//	  "chunks": [chunk, ...],
+//	}
+//
+// Unlike v0.5 payloads, strings are encoded inline, as the agent does not accept
+// a string table on the v0.7 endpoint. See encodeChunkV07 for the encoding of
+// chunks.
+func encodeTracerPayloadV07(c *config) []byte {
+	b := msgp.AppendMapHeader(nil, 9)
+	b = msgp.AppendString(b, "container_id")
+	b = msgp.AppendString(b, internal.ContainerID())
+	b = msgp.AppendString(b, "language_name")
+	b = msgp.AppendString(b, "go")
+	b = msgp.AppendString(b, "language_version")
+	b = msgp.AppendString(b, strings.TrimPrefix(runtime.Version(), "go"))
+	b = msgp.AppendString(b, "tracer_version")
+	b = msgp.AppendString(b, version.Tag)
+	b = msgp.AppendString(b, "runtime_id")
+	b = msgp.AppendString(b, globalconfig.RuntimeID())
+	b = msgp.AppendString(b, "env")
+	b = msgp.AppendString(b, c.env)
+	b = msgp.AppendString(b, "hostname")
+	b = msgp.AppendString(b, c.hostname)
+	b = msgp.AppendString(b, "app_version")
+	b = msgp.AppendString(b, c.version)
+	return msgp.AppendString(b, keyChunksV07)
+}
+
+// encodeChunkV07 appends the v0.7 encoding of the trace chunk t to b and returns
+// the extended buffer. A chunk is a TraceChunk, holding the sampling priority
+// and origin of the trace, which are otherwise repeated on the spans. See
+// chunkPriorityV07 for how the priority is found:
+//
+//	{
+//	  "priority": int32,
+//	  "origin": string,
+//	  "spans": [span, ...],
+//	  "tags": {string: string, ...},
+//	  "dropped_trace": bool,
+//	}
+//
+// Spans hold the same keys as in the v0.4 protocol.
+func encodeChunkV07(b []byte, t spanList) []byte {
+	var origin string
+	if len(t) > 0 {
+		origin = t[0].Meta[keyOrigin]
+	}
+	b = msgp.AppendMapHeader(b, 5)
+	b = msgp.AppendString(b, "priority")
+	b = msgp.AppendInt32(b, chunkPriorityV07(t))
+	b = msgp.AppendString(b, "origin")
+	b = msgp.AppendString(b, origin)
+	b = msgp.AppendString(b, "spans")
+	b = msgp.AppendArrayHeader(b, uint32(len(t)))
+	for _, s := range t {
+		b = encodeSpanV07(b, s)
+	}
+	b = msgp.AppendString(b, "tags")
+	b = msgp.AppendMapHeader(b, 0)
+	b = msgp.AppendString(b, "dropped_trace")
+	return msgp.AppendBool(b, false)
+}
+
+// priorityNoneV07 is the chunk priority telling the agent that no sampling
+// decision was made for the trace.
+const priorityNoneV07 = math.MinInt8
+
+// chunkPriorityV07 returns the sampling priority of the trace the chunk t belongs
+// to, or priorityNoneV07 if it is unset. The priority is only set on the root
+// span when it finishes, so the trace is looked up first: partial chunks don't
+// always hold the root.
+func chunkPriorityV07(t spanList) int32 {
+	if len(t) == 0 {
+		return priorityNoneV07
+	}
+	if ctx := t[0].context; ctx != nil && ctx.trace != nil {
+		if p, ok := ctx.trace.samplingPriority(); ok {
+			return int32(p)
+		}
+	}
+	for _, s := range t {
+		if p, ok := s.Metrics[keySamplingPriority]; ok {
+			return int32(p)
+		}
+	}
+	return priorityNoneV07
+}
+
+// encodeSpanV07 appends the v0.7 encoding of s to b and returns the extended buffer.
+func encodeSpanV07(b []byte, s *span) []byte {
+	b = msgp.AppendMapHeader(b, 12)
+	b = msgp.AppendString(b, "service")
+	b = msgp.AppendString(b, s.Service)
+	b = msgp.AppendString(b, "name")
+	b = msgp.AppendString(b, s.Name)
+	b = msgp.AppendString(b, "resource")
+	b = msgp.AppendString(b, s.Resource)
+	b = msgp.AppendString(b, "trace_id")
+	b = msgp.AppendUint64(b, s.TraceID)
+	b = msgp.AppendString(b, "span_id")
+	b = msgp.AppendUint64(b, s.SpanID)
+	b = msgp.AppendString(b, "parent_id")
+	b = msgp.AppendUint64(b, s.ParentID)
+	b = msgp.AppendString(b, "start")
+	b = msgp.AppendInt64(b, s.Start)
+	b = msgp.AppendString(b, "duration")
+	b = msgp.AppendInt64(b, s.Duration)
+	b = msgp.AppendString(b, "error")
+	b = msgp.AppendInt32(b, s.Error)
+	b = msgp.AppendString(b, "type")
+	b = msgp.AppendString(b, s.Type)
+	b = msgp.AppendString(b, "meta")
+	b = msgp.AppendMapStrStr(b, s.Meta)
+	b = msgp.AppendString(b, "metrics")
+	b = msgp.AppendMapHeader(b, uint32(len(s.Metrics)))
+	for k, v := range s.Metrics {
+		b = msgp.AppendString(b, k)
+		b = msgp.AppendFloat64(b, v)
+	}
+	return b
+}
@@ -0,0 +1,229 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
+	globalinternal "gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/version"
+)
+
+// SelfCheckReport holds the result of a tracer self-check, describing how the
+// tracer is configured and whether it is able to reach the Datadog Agent.
+type SelfCheckReport struct {
+	Date               string          `json:"date"`                 // ISO 8601 date and time of the check
+	TracerVersion      string          `json:"tracer_version"`       // Tracer version
+	LangVersion        string          `json:"lang_version"`         // Go version, e.g. go1.20
+	Running            bool            `json:"running"`              // Whether a tracer is started in this process
+	Service            string          `json:"service"`              // Tracer service
+	Env                string          `json:"env"`                  // Tracer env
+	Version            string          `json:"dd_version"`           // Version of the user's application
+	AgentURL           string          `json:"agent_url"`            // The address of the agent
+	AgentReachable     bool            `json:"agent_reachable"`      // Whether the agent's trace intake could be reached
+	AgentError         string          `json:"agent_error"`          // Any error that occurred trying to connect to the agent
+	AgentVersion       string          `json:"agent_version"`        // Version of the agent, as reported by its /info endpoint
+	SampleRate         string          `json:"sample_rate"`          // The default sampling rate for the rules sampler
+	SamplingRules      []SamplingRule  `json:"sampling_rules"`       // Rules used by the rules sampler
+	SamplingRulesError string          `json:"sampling_rules_error"` // Any errors that occurred while parsing sampling rules
+	Products           map[string]bool `json:"products"`             // Products and features, and whether they are enabled
+	EnvConflicts       []string        `json:"env_conflicts"`        // Conflicting environment variables
+	Warnings           []string        `json:"warnings"`             // Other likely misconfigurations
+	Elapsed            time.Duration   `json:"elapsed_ns"`           // Time it took to run the check
+}
+
+// SelfCheck reports on the tracer configuration and its ability to reach the
+// Datadog Agent. If a tracer is started, its configuration is checked. Otherwise,
+// the configuration that Start would use with the given options is checked. The
+// options are ignored when a tracer is running. Checking the configuration has
+// no side effects on the process, and ctx bounds the time spent contacting the
+// agent.
+//
+// The returned report is meant to help diagnose misconfigurations and can be
+// serialized to JSON to be attached to support tickets.
+func SelfCheck(ctx context.Context, opts ...StartOption) *SelfCheckReport {
+	start := time.Now()
+	var c *config
+	t, running := internal.GetGlobalTracer().(*tracer)
+	if running {
+		c = t.config
+	} else {
+		c = newSelfCheckConfig(opts...)
+	}
+	r := &SelfCheckReport{
+		Date:          start.Format(time.RFC3339),
+		TracerVersion: version.Tag,
+		LangVersion:   runtime.Version(),
+		Running:       running,
+		Service:       c.serviceName,
+		Env:           c.env,
+		Version:       c.version,
+		SamplingRules: append(append([]SamplingRule(nil), c.traceRules...), c.spanRules...),
+		Products: map[string]bool{
+			"tracing":         c.enabled,
+			"appsec":          appsec.Enabled() || globalinternal.BoolEnv("DD_APPSEC_ENABLED", false),
+			"profiling":       globalinternal.BoolEnv("DD_PROFILING_ENABLED", false),
+			"data_streams":    globalinternal.BoolEnv("DD_DATA_STREAMS_ENABLED", false),
+			"runtime_metrics": c.runtimeMetrics,
+			"lambda_mode":     c.logToStdout,
+			"debug":           c.debug,
+		},
+	}
+	if c.transport != nil {
+		r.AgentURL = c.transport.endpoint()
+	}
+	if running {
+		r.SampleRate = fmt.Sprintf("%f", t.rulesSampling.traces.globalRate)
+	} else {
+		r.SampleRate = fmt.Sprintf("%f", globalSampleRate())
+	}
+	if _, _, err := samplingRulesFromEnv(); err != nil {
+		r.SamplingRulesError = err.Error()
+	}
+	r.EnvConflicts = envConflicts()
+	if !c.enabled {
+		r.Warnings = append(r.Warnings, "tracing is disabled (DD_TRACE_ENABLED=false or WithTraceEnabled(false))")
+	}
+	if c.logToStdout {
+		r.Warnings = append(r.Warnings, "lambda mode is enabled: traces are written to stdout instead of being sent to the agent")
+	} else {
+		r.checkAgent(ctx, c)
+	}
+	if rate := globalSampleRate(); !math.IsNaN(rate) && rate == 0 && len(c.traceRules) == 0 {
+		r.Warnings = append(r.Warnings, "DD_TRACE_SAMPLE_RATE is 0: no traces will be kept unless manually kept")
+	}
+	r.Elapsed = time.Since(start)
+	return r
+}
+
+// String returns the report in JSON format.
+func (r *SelfCheckReport) String() string {
+	bs, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%#v", r)
+	}
+	return string(bs)
+}
+
+// newSelfCheckConfig returns the configuration Start would use with opts. Unlike
+// newConfig, it leaves the global state of the process untouched: the logger is
+// not installed, the agent is not queried, and the global configuration that
+// newConfig and the options set is restored.
+func newSelfCheckConfig(opts ...StartOption) *config {
+	defer saveGlobalConfig()()
+	return newConfig(append(opts[:len(opts):len(opts)], withDryRun())...)
+}
+
+// saveGlobalConfig saves the global configuration set by newConfig and the start
+// options, and returns a function restoring it.
+func saveGlobalConfig() (restore func()) {
+	analyticsRate := globalconfig.AnalyticsRate()
+	serviceName := globalconfig.ServiceName()
+	dogstatsdAddr := globalconfig.DogstatsdAddr()
+	headerTags := make(map[string]string, globalconfig.HeaderTagsLen())
+	globalconfig.HeaderTagMap().Iter(func(header, tag string) {
+		headerTags[header] = tag
+	})
+	schemaVersion := namingschema.GetVersion()
+	useGlobalServiceName := namingschema.UseGlobalServiceName()
+	return func() {
+		globalconfig.SetAnalyticsRate(analyticsRate)
+		globalconfig.SetServiceName(serviceName)
+		globalconfig.SetDogstatsdAddr(dogstatsdAddr)
+		globalconfig.ClearHeaderTags()
+		for header, tag := range headerTags {
+			globalconfig.SetHeaderTag(header, tag)
+		}
+		namingschema.SetVersion(schemaVersion)
+		namingschema.SetUseGlobalServiceName(useGlobalServiceName)
+	}
+}
+
+// checkAgent checks that the agent's trace intake is reachable, and queries its
+// version and capabilities.
+func (r *SelfCheckReport) checkAgent(ctx context.Context, c *config) {
+	if err := checkEndpointContext(ctx, c.httpClient, r.AgentURL); err != nil {
+		r.AgentError = err.Error()
+		return
+	}
+	r.AgentReachable = true
+	features, err := fetchAgentFeatures(ctx, c.httpClient, c.agentURL)
+	switch {
+	case err == errAgentFeaturesUndiscoverable:
+		r.Warnings = append(r.Warnings, err.Error())
+	case err != nil:
+		r.Warnings = append(r.Warnings, fmt.Sprintf("unable to query agent info: %v", err))
+	default:
+		r.AgentVersion = features.Version
+	}
+}
+
+// checkEndpointContext is like checkEndpoint, but the request is aborted when
+// ctx is done.
+func checkEndpointContext(ctx context.Context, c *http.Client, endpoint string) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader([]byte{0x90}))
+	if err != nil {
+		return fmt.Errorf("cannot create http request: %v", err)
+	}
+	req.Header.Set(traceCountHeader, "0")
+	req.Header.Set("Content-Type", "application/msgpack")
+	res, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return nil
+}
+
+// conflictingEnv lists pairs of environment variables which, when both set,
+// are likely to result in a surprising configuration. The second variable of
+// each pair takes precedence.
+var conflictingEnv = [][2]string{
+	{"DD_AGENT_HOST", "DD_TRACE_AGENT_URL"},
+	{"DD_TRACE_AGENT_PORT", "DD_TRACE_AGENT_URL"},
+	{"DD_TRACE_SAMPLE_RATE", "DD_TRACE_SAMPLING_RULES"},
+}
+
+// taggedEnv lists environment variables which may also be set through DD_TAGS,
+// along with the name of the corresponding tag.
+var taggedEnv = map[string]string{
+	"DD_SERVICE": "service",
+	"DD_ENV":     "env",
+	"DD_VERSION": "version",
+}
+
+// envConflicts returns a description of all conflicting environment variables.
+func envConflicts() []string {
+	var conflicts []string
+	for _, pair := range conflictingEnv {
+		if os.Getenv(pair[0]) != "" && os.Getenv(pair[1]) != "" {
+			conflicts = append(conflicts, fmt.Sprintf("%s and %s are both set: %s takes precedence", pair[0], pair[1], pair[1]))
+		}
+	}
+	if v := os.Getenv("DD_TAGS"); v != "" {
+		tags := globalinternal.ParseTagString(v)
+		for env, tag := range taggedEnv {
+			if val, ok := tags[tag]; ok && os.Getenv(env) != "" && os.Getenv(env) != val {
+				conflicts = append(conflicts, fmt.Sprintf("%s=%q conflicts with tag %s:%s in DD_TAGS: %s takes precedence", env, os.Getenv(env), tag, val, env))
+			}
+		}
+	}
+	sort.Strings(conflicts)
+	return conflicts
+}
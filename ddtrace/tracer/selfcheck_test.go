@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelfCheck(t *testing.T) {
+	t.Run("reachable", func(t *testing.T) {
+		assert := assert.New(t)
+		var infoRequests int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/info" {
+				atomic.AddInt32(&infoRequests, 1)
+				w.Write([]byte(`{"version":"7.45.0","endpoints":["/v0.4/traces"]}`))
+			}
+		}))
+		defer srv.Close()
+
+		r := SelfCheck(context.Background(),
+			WithAgentAddr(strings.TrimPrefix(srv.URL, "http://")),
+			WithService("doctor"),
+			WithEnv("test"),
+			WithLogStartup(false),
+		)
+		assert.False(r.Running)
+		assert.True(r.AgentReachable)
+		assert.Empty(r.AgentError)
+		assert.Equal("7.45.0", r.AgentVersion)
+		assert.Equal("doctor", r.Service)
+		assert.Equal("test", r.Env)
+		assert.True(r.Products["tracing"])
+		assert.Contains(r.String(), `"agent_version": "7.45.0"`)
+		assert.Equal(int32(1), atomic.LoadInt32(&infoRequests))
+	})
+
+	t.Run("side-effects", func(t *testing.T) {
+		assert := assert.New(t)
+		defer globalconfig.SetServiceName(globalconfig.ServiceName())
+		globalconfig.SetServiceName("before")
+		tp := new(log.RecordLogger)
+		defer log.UseLogger(tp)()
+
+		r := SelfCheck(context.Background(),
+			WithAgentAddr("localhost:9"),
+			WithService("doctor"),
+			WithHeaderTags([]string{"X-Doctor:doctor"}),
+			WithLogger(new(log.DiscardLogger)),
+			WithLogStartup(false),
+		)
+		assert.Equal("doctor", r.Service)
+		assert.Equal("before", globalconfig.ServiceName())
+		assert.Empty(globalconfig.HeaderTag("X-Doctor"))
+		log.Warn("still recorded")
+		assert.Contains(strings.Join(tp.Logs(), "\n"), "still recorded")
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		assert := assert.New(t)
+		r := SelfCheck(context.Background(), WithAgentAddr("localhost:9"), WithLogStartup(false))
+		assert.False(r.AgentReachable)
+		assert.NotEmpty(r.AgentError)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		aborted := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// the server only notices the client going away once the body is consumed
+			io.Copy(io.Discard, r.Body)
+			<-r.Context().Done()
+			close(aborted)
+		}))
+		defer srv.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		err := checkEndpointContext(ctx, srv.Client(), srv.URL+"/v0.4/traces")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		select {
+		case <-aborted:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the request was not aborted")
+		}
+	})
+
+	t.Run("running", func(t *testing.T) {
+		_, _, _, stop := startTestTracer(t, WithService("running"))
+		defer stop()
+
+		r := SelfCheck(context.Background())
+		assert.True(t, r.Running)
+		assert.Equal(t, "running", r.Service)
+	})
+}
+
+func TestEnvConflicts(t *testing.T) {
+	t.Setenv("DD_AGENT_HOST", "agent")
+	t.Setenv("DD_TRACE_AGENT_URL", "http://agent:8126")
+	t.Setenv("DD_SERVICE", "svc")
+	t.Setenv("DD_TAGS", "service:other,env:prod")
+
+	conflicts := envConflicts()
+	assert.Len(t, conflicts, 2)
+	assert.Contains(t, conflicts[0], "DD_AGENT_HOST and DD_TRACE_AGENT_URL are both set")
+	assert.Contains(t, conflicts[1], `DD_SERVICE="svc" conflicts with tag service:other`)
+}
@@ -10,10 +10,13 @@ package tracer
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"math"
 	"os"
 	"reflect"
+	"regexp"
 	"runtime"
 	"runtime/pprof"
 	rt "runtime/trace"
@@ -22,6 +25,7 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
@@ -86,6 +90,13 @@ type span struct {
 	pprofCtxRestore context.Context `msg:"-"` // contains pprof.WithLabel labels of the parent span (if any) that need to be restored when this span finishes
 
 	taskEnd func() // ends execution tracer (runtime/trace) task, if started
+
+	spanLinks []ddtrace.SpanLink `msg:"-"` // links to spans outside of this span's parent chain
+
+	tagLimits tagLimits `msg:"-"` // limits enforced by SetTag, copied from the tracer's config when the span starts
+
+	goroutineID uint64 `msg:"-"` // goroutine the span was started on, when WithGoroutineCheck is enabled
+	propagated  uint32 `msg:"-"` // set to 1 when the span was propagated to another goroutine using Go
 }
 
 // Context yields the SpanContext for this Span. Note that the return
@@ -116,6 +127,10 @@ func (s *span) SetTag(key string, value interface{}) {
 	if s.finished {
 		return
 	}
+	limits := s.tagLimits
+	if limits.maxTags > 0 && !s.tagAllowed(key, limits.maxTags) {
+		return
+	}
 	switch key {
 	case ext.Error:
 		s.setTagError(value, errorConfig{
@@ -128,9 +143,11 @@ func (s *span) SetTag(key string, value interface{}) {
 		return
 	}
 	if v, ok := value.(string); ok {
-		if key == ext.ResourceName && s.pprofCtxActive != nil && spanResourcePIISafe(s) {
-			// If the user overrides the resource name for the span,
-			// update the endpoint label for the runtime profilers.
+		if key == ext.ResourceName && s.pprofCtxActive != nil && spanResourcePIISafe(s) && s.root() == s {
+			// If the user overrides the resource name for the local root
+			// span, update the endpoint label for the runtime profilers.
+			// The endpoint is the resource of the local root span, so
+			// renaming other spans doesn't affect it.
 			//
 			// We don't change s.pprofCtxRestore since that should
 			// stay as the original parent span context regardless
@@ -138,7 +155,7 @@ func (s *span) SetTag(key string, value interface{}) {
 			s.pprofCtxActive = pprof.WithLabels(s.pprofCtxActive, pprof.Labels(traceprof.TraceEndpoint, v))
 			pprof.SetGoroutineLabels(s.pprofCtxActive)
 		}
-		s.setMeta(key, v)
+		s.setMeta(key, limits.truncate(v))
 		return
 	}
 	if v, ok := toFloat64(value); ok {
@@ -158,11 +175,91 @@ func (s *span) SetTag(key string, value interface{}) {
 				panic(e)
 			}
 		}()
-		s.setMeta(key, v.String())
+		s.setMeta(key, limits.truncate(v.String()))
 		return
 	}
 	// not numeric, not a string, not a fmt.Stringer, not a bool, and not an error
-	s.setMeta(key, fmt.Sprint(value))
+	s.setMeta(key, limits.truncate(fmt.Sprint(value)))
+}
+
+// tagLimits holds the limits enforced on tags set using SetTag.
+type tagLimits struct {
+	// maxValueLength is the maximum length of string tag values; 0 means unlimited.
+	maxValueLength int
+
+	// maxTags is the maximum number of tags a span can hold; 0 means unlimited.
+	maxTags int
+}
+
+// truncatedTagSuffix marks tag values which were truncated.
+const truncatedTagSuffix = "..."
+
+// truncate returns v truncated to the maximum tag value length, if it exceeds it.
+func (l tagLimits) truncate(v string) string {
+	if l.maxValueLength <= 0 || len(v) <= l.maxValueLength {
+		return v
+	}
+	suffix := truncatedTagSuffix
+	n := l.maxValueLength - len(suffix)
+	if n <= 0 {
+		// no room for the suffix
+		suffix, n = "", l.maxValueLength
+	}
+	// don't cut a multi-byte character in half
+	for n > 0 && !utf8.RuneStart(v[n]) {
+		n--
+	}
+	return v[:n] + suffix
+}
+
+// tagAllowed reports whether the tag with the given key can be set on the span
+// without exceeding max tags. Tags which are already set, internal "_dd." tags,
+// the runtime ID, as well as those which map to span fields or sampling
+// decisions, are always allowed and internal tags don't count towards max. Dropped tags are counted
+// in the keyTagsDropped metric. It is not safe for concurrent use.
+func (s *span) tagAllowed(key string, max int) bool {
+	switch key {
+	case ext.SpanName, ext.ServiceName, ext.ResourceName, ext.SpanType, ext.Error,
+		ext.ManualKeep, ext.ManualDrop, ext.SamplingPriority, ext.RuntimeID:
+		return true
+	}
+	if strings.HasPrefix(key, internalTagPrefix) {
+		return true
+	}
+	if _, ok := s.Meta[key]; ok {
+		return true
+	}
+	if _, ok := s.Metrics[key]; ok {
+		return true
+	}
+	if len(s.Meta)+len(s.Metrics) < max || s.userTagCount() < max {
+		return true
+	}
+	if s.Metrics == nil {
+		s.Metrics = make(map[string]float64, 1)
+	}
+	s.Metrics[keyTagsDropped]++
+	return false
+}
+
+// internalTagPrefix prefixes the tags set by the tracer for its own use.
+const internalTagPrefix = "_dd."
+
+// userTagCount returns the number of tags set on the span, not counting the
+// internal ones. It is not safe for concurrent use.
+func (s *span) userTagCount() int {
+	n := 0
+	for k := range s.Meta {
+		if !strings.HasPrefix(k, internalTagPrefix) {
+			n++
+		}
+	}
+	for k := range s.Metrics {
+		if !strings.HasPrefix(k, internalTagPrefix) {
+			n++
+		}
+	}
+	return n
 }
 
 // setSamplingPriority locks then span, then updates the sampling priority.
@@ -292,17 +389,29 @@ func (s *span) setTagError(value interface{}, cfg errorConfig) {
 		// if anyone sets an error value as the tag, be nice here
 		// and provide all the benefits.
 		setError(true)
-		s.setMeta(ext.ErrorMsg, v.Error())
-		s.setMeta(ext.ErrorType, reflect.TypeOf(v).String())
-		if !cfg.noDebugStack {
-			s.setMeta(ext.ErrorStack, takeStacktrace(cfg.stackFrames, cfg.stackSkip))
-		}
+		msg, typ := v.Error(), reflect.TypeOf(v).String()
+		s.setMeta(ext.ErrorMsg, msg)
+		s.setMeta(ext.ErrorType, typ)
+		s.setMeta(ext.ErrorFingerprint, errorFingerprint(typ, msg))
+		var hasStack bool
 		switch v.(type) {
 		case xerrors.Formatter:
 			s.setMeta(ext.ErrorDetails, fmt.Sprintf("%+v", v))
+			hasStack = true
 		case fmt.Formatter:
 			// pkg/errors approach
 			s.setMeta(ext.ErrorDetails, fmt.Sprintf("%+v", v))
+			hasStack = true
+		}
+		stackFrames, captureStack := cfg.stackFrames, false
+		if t, ok := internal.GetGlobalTracer().(*tracer); ok {
+			if stackFrames == 0 {
+				stackFrames = t.config.errorStackFrames
+			}
+			captureStack = t.config.errorStackCapture && !hasStack
+		}
+		if !cfg.noDebugStack || captureStack {
+			s.setMeta(ext.ErrorStack, takeStacktrace(stackFrames, cfg.stackSkip))
 		}
 	case nil:
 		// no error
@@ -314,6 +423,35 @@ func (s *span) setTagError(value interface{}, cfg errorConfig) {
 	}
 }
 
+var (
+	// fingerprintUUID matches UUIDs in error messages.
+	fingerprintUUID = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+	// fingerprintHex matches hexadecimal numbers, such as addresses, in error messages.
+	fingerprintHex = regexp.MustCompile(`\b0[xX][0-9a-fA-F]+\b|\b[0-9a-fA-F]{16,}\b`)
+
+	// fingerprintNumber matches decimal numbers in error messages.
+	fingerprintNumber = regexp.MustCompile(`\d+(\.\d+)?`)
+
+	// fingerprintQuoted matches quoted strings in error messages.
+	fingerprintQuoted = regexp.MustCompile(`"[^"]*"|'[^']*'`)
+)
+
+// errorFingerprint returns a fingerprint grouping errors of the same type which
+// have the same message once variable parts, such as identifiers, numbers and
+// quoted values, are removed.
+func errorFingerprint(typ, msg string) string {
+	msg = fingerprintUUID.ReplaceAllString(msg, "?")
+	msg = fingerprintHex.ReplaceAllString(msg, "?")
+	msg = fingerprintQuoted.ReplaceAllString(msg, "?")
+	msg = fingerprintNumber.ReplaceAllString(msg, "?")
+	h := fnv.New64a()
+	h.Write([]byte(typ))
+	h.Write([]byte{0})
+	h.Write([]byte(msg))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
 // defaultStackLength specifies the default maximum size of a stack trace.
 const defaultStackLength = 32
 
@@ -460,6 +598,19 @@ func (s *span) Finish(opts ...ddtrace.FinishOption) {
 		// there's nothign we can show.
 		s.SetTag("go_execution_traced", "partial")
 	}
+	if s.goroutineID != 0 {
+		checkFinishGoroutine(s)
+	}
+	if tr, ok := internal.GetGlobalTracer().(*tracer); ok && len(tr.config.spanFinishHooks) > 0 {
+		s.RLock()
+		finished := s.finished
+		s.RUnlock()
+		if !finished {
+			for _, fn := range tr.config.spanFinishHooks {
+				fn(s)
+			}
+		}
+	}
 	s.finish(t)
 
 	if s.pprofCtxRestore != nil {
@@ -499,6 +650,9 @@ func (s *span) finish(finishTime int64) {
 	if s.Duration < 0 {
 		s.Duration = 0
 	}
+	if len(s.spanLinks) > 0 {
+		s.serializeSpanLinksInMeta()
+	}
 	s.finished = true
 
 	keep := true
@@ -530,6 +684,18 @@ func (s *span) finish(finishTime int64) {
 	s.context.finish()
 }
 
+// serializeSpanLinksInMeta encodes the span links as JSON into the span's meta,
+// so that they can be transported using the current payload format.
+// It is not safe for concurrent use.
+func (s *span) serializeSpanLinksInMeta() {
+	b, err := json.Marshal(s.spanLinks)
+	if err != nil {
+		log.Debug("Unable to marshal span links: %v", err)
+		return
+	}
+	s.setMeta(keySpanLinks, string(b))
+}
+
 // newAggregableSpan creates a new summary for the span s, within an application
 // version version.
 func newAggregableSpan(s *span, obfuscator *obfuscate.Obfuscator) *aggregableSpan {
@@ -711,6 +877,11 @@ const (
 	keyPeerServiceSource = "_dd.peer.service.source"
 	// keyPeerServiceRemappedFrom indicates the previous value for peer.service, in case remapping happened.
 	keyPeerServiceRemappedFrom = "_dd.peer.service.remapped_from"
+	// keySpanLinks holds the JSON encoded span links of a span, if any.
+	keySpanLinks = "_dd.span_links"
+	// keyTagsDropped holds the number of tags dropped from a span because it reached
+	// the maximum number of tags.
+	keyTagsDropped = "_dd.span.tags_dropped"
 )
 
 // The following set of tags is used for user monitoring and set through calls to span.SetUser().
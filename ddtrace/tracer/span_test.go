@@ -6,16 +6,20 @@
 package tracer
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
 	"runtime"
+	"runtime/pprof"
 	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/samplernames"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/traceprof"
@@ -23,6 +27,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/obfuscate"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
 )
 
 // newSpan creates a new span. This is a low-level function, required for testing and advanced usage.
@@ -341,6 +346,93 @@ func TestSpanSetTag(t *testing.T) {
 	})
 }
 
+func TestSpanSetTagLimits(t *testing.T) {
+	t.Run("value-length", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer, _, _, stop := startTestTracer(t, WithTagValueMaxLength(10))
+		defer stop()
+
+		span := tracer.newRootSpan("http.request", "mux.router", "/")
+		span.SetTag("short", "abc")
+		span.SetTag("long", strings.Repeat("a", 20))
+		span.SetTag("stringer", bytes.NewBufferString(strings.Repeat("b", 20)))
+		span.SetTag("utf8", "aaaaaa\u00e9\u00e9\u00e9")
+		assert.Equal("abc", span.Meta["short"])
+		assert.Equal("aaaaaaa...", span.Meta["long"])
+		assert.Equal("bbbbbbb...", span.Meta["stringer"])
+		assert.Equal("aaaaaa...", span.Meta["utf8"])
+
+		// the limits are those of the tracer which started the span
+		internal.SetGlobalTracer(&internal.NoopTracer{})
+		span.SetTag("after-stop", strings.Repeat("c", 20))
+		assert.Equal("ccccccc...", span.Meta["after-stop"])
+	})
+
+	t.Run("value-length-short", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer, _, _, stop := startTestTracer(t, WithTagValueMaxLength(2))
+		defer stop()
+
+		span := tracer.newRootSpan("http.request", "mux.router", "/")
+		span.SetTag("long", "abc")
+		span.SetTag("utf8", "a\u00e9")
+		assert.Equal("ab", span.Meta["long"])
+		assert.Equal("a", span.Meta["utf8"])
+	})
+
+	t.Run("max-tags", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer, _, _, stop := startTestTracer(t, WithMaxTagsPerSpan(1))
+		defer stop()
+
+		span := tracer.newRootSpan("http.request", "mux.router", "/")
+		span.SetTag("new.tag", "value")
+		span.SetTag("new.metric", 1)
+		span.SetTag(ext.ResourceName, "/other")
+		span.SetTag(ext.ManualKeep, true)
+		assert.NotContains(span.Meta, "new.tag")
+		assert.NotContains(span.Metrics, "new.metric")
+		assert.Equal("/other", span.Resource)
+		assert.Equal(float64(2), span.Metrics[keyTagsDropped])
+
+		// existing tags can still be updated
+		span.SetTag("language", "golang")
+		assert.Equal("golang", span.Meta["language"])
+
+		// internal tags are always allowed
+		span.SetTag("_dd.internal", "value")
+		assert.Equal("value", span.Meta["_dd.internal"])
+	})
+
+	t.Run("max-tags-internal", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer, _, _, stop := startTestTracer(t)
+		defer stop()
+
+		span := tracer.newRootSpan("http.request", "mux.router", "/")
+		span.Meta = map[string]string{"_dd.a": "a", "_dd.b": "b"}
+		span.Metrics = map[string]float64{"_dd.c": 1}
+		span.tagLimits.maxTags = 2
+		// internal tags don't count towards the limit
+		span.SetTag("first", "value")
+		span.SetTag("second", 2)
+		span.SetTag("third", "value")
+		assert.Equal("value", span.Meta["first"])
+		assert.Equal(float64(2), span.Metrics["second"])
+		assert.NotContains(span.Meta, "third")
+		assert.Equal(float64(1), span.Metrics[keyTagsDropped])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		tracer, _, _, stop := startTestTracer(t)
+		defer stop()
+
+		span := tracer.newRootSpan("http.request", "mux.router", "/")
+		span.SetTag("long", strings.Repeat("a", 30000))
+		assert.Len(t, span.Meta["long"], 30000)
+	})
+}
+
 func TestSpanSetTagError(t *testing.T) {
 	assert := assert.New(t)
 
@@ -409,6 +501,23 @@ func TestSpanStart(t *testing.T) {
 	assert.NotEqual(int64(0), span.Start)
 }
 
+func TestSpanLinks(t *testing.T) {
+	assert := assert.New(t)
+	tracer := newTracer(withTransport(newDefaultTransport()))
+	defer tracer.Stop()
+
+	links := []ddtrace.SpanLink{
+		{TraceID: 1, SpanID: 2},
+		{TraceID: 3, TraceIDHigh: 4, SpanID: 5, Attributes: map[string]string{"key": "val"}},
+	}
+	span := tracer.StartSpan("consume", WithSpanLinks(links)).(*span)
+	assert.NotContains(span.Meta, keySpanLinks)
+	span.Finish()
+
+	assert.Equal(`[{"trace_id":1,"span_id":2},{"trace_id":3,"trace_id_high":4,"span_id":5,"attributes":{"key":"val"}}]`,
+		span.Meta[keySpanLinks])
+}
+
 func TestSpanString(t *testing.T) {
 	assert := assert.New(t)
 	tracer := newTracer(withTransport(newDefaultTransport()))
@@ -529,6 +638,58 @@ func TestSpanError(t *testing.T) {
 	assert.Equal("", span.Meta[ext.ErrorStack])
 }
 
+func TestSpanErrorFingerprint(t *testing.T) {
+	assert := assert.New(t)
+	tracer := newTracer(withTransport(newDefaultTransport()))
+	defer tracer.Stop()
+
+	fingerprint := func(err error) string {
+		span := tracer.newRootSpan("pylons.request", "pylons", "/")
+		span.SetTag(ext.Error, err)
+		return span.Meta[ext.ErrorFingerprint]
+	}
+	a := fingerprint(fmt.Errorf("user 123 not found in table \"users\""))
+	b := fingerprint(fmt.Errorf("user 456 not found in table \"accounts\""))
+	c := fingerprint(fmt.Errorf("connection to 0x7f3a12 reset"))
+	d := fingerprint(xerrors.New("user 123 not found in table \"users\""))
+	assert.NotEmpty(a)
+	assert.Equal(a, b)
+	assert.NotEqual(a, c)
+	assert.NotEqual(a, d) // different error types
+}
+
+func TestSpanErrorStackOptions(t *testing.T) {
+	t.Run("limit", func(t *testing.T) {
+		tracer, _, _, stop := startTestTracer(t, WithErrorStackTraceLimit(1))
+		defer stop()
+		span := tracer.newRootSpan("pylons.request", "pylons", "/")
+		span.SetTag(ext.Error, errors.New("abc"))
+		assert.Equal(t, 1, strings.Count(span.Meta[ext.ErrorStack], "\n\t"))
+	})
+
+	t.Run("capture", func(t *testing.T) {
+		tracer, _, _, stop := startTestTracer(t, WithDebugStack(false), WithErrorStackCapture(true))
+		defer stop()
+		span := tracer.newRootSpan("pylons.request", "pylons", "/")
+		span.Finish(WithError(errors.New("abc")))
+		assert.NotEmpty(t, span.Meta[ext.ErrorStack])
+
+		// errors carrying a stack of their own are left untouched
+		span = tracer.newRootSpan("pylons.request", "pylons", "/")
+		span.Finish(WithError(xerrors.New("abc")))
+		assert.Empty(t, span.Meta[ext.ErrorStack])
+		assert.NotEmpty(t, span.Meta[ext.ErrorDetails])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		tracer, _, _, stop := startTestTracer(t, WithDebugStack(false))
+		defer stop()
+		span := tracer.newRootSpan("pylons.request", "pylons", "/")
+		span.Finish(WithError(errors.New("abc")))
+		assert.Empty(t, span.Meta[ext.ErrorStack])
+	})
+}
+
 func TestSpanError_Typed(t *testing.T) {
 	assert := assert.New(t)
 	tracer := newTracer(withTransport(newDefaultTransport()))
@@ -955,3 +1116,31 @@ type stringer struct{}
 func (s *stringer) String() string {
 	return "string"
 }
+
+func TestSpanProfilerEndpointLabel(t *testing.T) {
+	tracer, _, _, stop := startTestTracer(t)
+	defer stop()
+
+	endpoint := func(s ddtrace.Span) string {
+		v, _ := pprof.Label(s.(*span).pprofCtxActive, traceprof.TraceEndpoint)
+		return v
+	}
+	root := tracer.StartSpan("web.request", SpanType(ext.SpanTypeWeb), ResourceName("/a"))
+	child := tracer.StartSpan("child", ChildOf(root.Context()), ResourceName("child"))
+	assert.Equal(t, "/a", endpoint(root))
+	assert.Equal(t, "/a", endpoint(child))
+
+	// renaming a child span doesn't change the endpoint
+	child.SetTag(ext.ResourceName, "renamed")
+	assert.Equal(t, "/a", endpoint(child))
+
+	// renaming the local root span does
+	root.SetTag(ext.ResourceName, "/b")
+	assert.Equal(t, "/b", endpoint(root))
+	grandchild := tracer.StartSpan("grandchild", ChildOf(root.Context()))
+	assert.Equal(t, "/b", endpoint(grandchild))
+
+	grandchild.Finish()
+	child.Finish()
+	root.Finish()
+}
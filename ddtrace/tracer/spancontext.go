@@ -324,8 +324,9 @@ func (t *trace) setSamplingPriorityLocked(p int, sampler samplernames.SamplerNam
 	}
 	*t.priority = float64(p)
 	_, ok := t.propagatingTags[keyDecisionMaker]
-	if p > 0 && !ok && sampler != samplernames.Unknown {
-		// We have a positive priority and the sampling mechanism isn't set.
+	if p > 0 && (!ok || sampler == samplernames.Manual) && sampler != samplernames.Unknown {
+		// We have a positive priority and the sampling mechanism isn't set,
+		// or the user overrode an earlier decision manually.
 		// Send nothing when sampler is `Unknown` for RFC compliance.
 		t.setPropagatingTagLocked(keyDecisionMaker, "-"+strconv.Itoa(int(sampler)))
 	}
@@ -350,6 +351,7 @@ func (t *trace) push(sp *span) {
 		log.Error("trace buffer full (%d), dropping trace", traceMaxSize)
 		if haveTracer {
 			atomic.AddUint32(&tr.tracesDropped, 1)
+			tr.health.addTracesDropped(1)
 		}
 		return
 	}
@@ -359,6 +361,7 @@ func (t *trace) push(sp *span) {
 	t.spans = append(t.spans, sp)
 	if haveTracer {
 		atomic.AddUint32(&tr.spansStarted, 1)
+		tr.health.addSpansStarted(1)
 	}
 }
 
@@ -421,6 +424,7 @@ func (t *trace) finishedOne(s *span) {
 	}
 	// we have a tracer that can receive completed traces.
 	atomic.AddUint32(&tr.spansFinished, uint32(len(t.spans)))
+	tr.health.addSpansFinished(len(t.spans))
 	tr.pushTrace(&finishedTrace{
 		spans:    t.spans,
 		willSend: decisionKeep == samplingDecision(atomic.LoadUint32((*uint32)(&t.samplingDecision))),
@@ -70,6 +70,10 @@ type SQLCommentCarrier struct {
 	Mode          DBMPropagationMode
 	DBServiceName string
 	SpanID        uint64
+	// TraceParent holds the W3C traceparent injected in the query, in full
+	// mode. It can be used to propagate the span context to the database
+	// by other means, when the query text can't be changed.
+	TraceParent string
 }
 
 // Inject injects a span context in the carrier's Query field as a comment.
@@ -95,7 +99,8 @@ func (c *SQLCommentCarrier) Inject(spanCtx ddtrace.SpanContext) error {
 		if traceID == 0 { // check if this is a root span
 			traceID = c.SpanID
 		}
-		tags[sqlCommentTraceParent] = encodeTraceParent(traceID, c.SpanID, sampled)
+		c.TraceParent = encodeTraceParent(traceID, c.SpanID, sampled)
+		tags[sqlCommentTraceParent] = c.TraceParent
 		fallthrough
 	case DBMPropagationModeService:
 		if ctx, ok := spanCtx.(*spanContext); ok {
@@ -117,6 +117,11 @@ func TestSQLCommentCarrier(t *testing.T) {
 			require.NoError(t, err)
 			expected := strings.ReplaceAll(tc.expectedQuery, "<span_id>", fmt.Sprintf("%016s", strconv.FormatUint(carrier.SpanID, 16)))
 			assert.Equal(t, expected, carrier.Query)
+			if tc.mode == DBMPropagationModeFull {
+				assert.Contains(t, carrier.Query, "traceparent='"+carrier.TraceParent+"'")
+			} else {
+				assert.Empty(t, carrier.TraceParent)
+			}
 
 			if !tc.injectSpan {
 				traceID = carrier.SpanID
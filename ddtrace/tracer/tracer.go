@@ -20,9 +20,11 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
 	globalinternal "gopkg.in/DataDog/dd-trace-go.v1/internal"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/hostname"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/remoteconfig"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/samplernames"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/traceprof"
 
@@ -82,6 +84,10 @@ type tracer struct {
 	// partialTrace the number of partially dropped traces.
 	partialTraces uint32
 
+	// tracesDroppedQueueFull records the number of traces dropped because the
+	// trace queue was full.
+	tracesDroppedQueueFull uint32
+
 	// rulesSampling holds an instance of the rules sampler used to apply either trace sampling,
 	// or single span sampling rules on spans. These are user-defined
 	// rules for applying a sampling rate to spans that match the designated service
@@ -94,6 +100,13 @@ type tracer struct {
 
 	// statsd is used for tracking metrics associated with the runtime and the tracer.
 	statsd statsdClient
+
+	// health holds the cumulative counters returned by Stats.
+	health *healthCounters
+
+	// dataStreams processes the Data Streams Monitoring checkpoints. It is nil
+	// when Data Streams Monitoring is disabled.
+	dataStreams *datastreams.Processor
 }
 
 const (
@@ -211,11 +224,20 @@ func newUnstartedTracer(opts ...StartOption) *tracer {
 	if err != nil {
 		log.Warn("Runtime and health metrics disabled: %v", err)
 	}
+	health := new(healthCounters)
 	var writer traceWriter
-	if c.logToStdout {
-		writer = newLogTraceWriter(c, statsd)
+	if c.ciVisibilityEnabled {
+		w := newCIVisibilityTraceWriter(c, statsd)
+		w.health = health
+		writer = w
+	} else if c.logToStdout {
+		w := newLogTraceWriter(c, statsd)
+		w.health = health
+		writer = w
 	} else {
-		writer = newAgentTraceWriter(c, sampler, statsd)
+		w := newAgentTraceWriter(c, sampler, statsd)
+		w.health = health
+		writer = w
 	}
 	traces, spans, err := samplingRulesFromEnv()
 	if err != nil {
@@ -247,6 +269,10 @@ func newUnstartedTracer(opts ...StartOption) *tracer {
 			},
 		}),
 		statsd: statsd,
+		health: health,
+	}
+	if c.dataStreamsMonitoringEnabled {
+		t.dataStreams = datastreams.NewProcessor(statsd, c.env, c.serviceName, c.agentURL, c.httpClient, c.dataStreams...)
 	}
 	return t
 }
@@ -280,6 +306,10 @@ func newTracer(opts ...StartOption) *tracer {
 		t.reportHealthMetrics(statsInterval)
 	}()
 	t.stats.Start()
+	if t.dataStreams != nil {
+		t.dataStreams.Start()
+		datastreams.SetGlobalProcessor(t.dataStreams)
+	}
 	return t
 }
 
@@ -296,6 +326,9 @@ func newTracer(opts ...StartOption) *tracer {
 func Flush() {
 	if t, ok := internal.GetGlobalTracer().(*tracer); ok {
 		t.flushSync()
+		if t.dataStreams != nil {
+			t.dataStreams.Flush()
+		}
 	}
 }
 
@@ -325,9 +358,12 @@ func (t *tracer) worker(tick <-chan time.Time) {
 			t.traceWriter.flush()
 			t.statsd.Flush()
 			t.stats.flushAndSend(time.Now(), withCurrentBucket)
-			// TODO(x): In reality, the traceWriter.flush() call is not synchronous
-			// when using the agent traceWriter. However, this functionnality is used
-			// in Lambda so for that purpose this mechanism should suffice.
+			// The agent traceWriter sends the payloads asynchronously. In
+			// serverless mode, the instance may be frozen as soon as Flush
+			// returns, so the sends are awaited.
+			if w, ok := t.traceWriter.(*agentTraceWriter); ok && t.config.serverless {
+				w.wait()
+			}
 			done <- struct{}{}
 
 		case <-t.stop:
@@ -394,8 +430,45 @@ func (t *tracer) pushTrace(trace *finishedTrace) {
 	}
 	select {
 	case t.out <- trace:
+		return
 	default:
-		log.Error("payload queue full, dropping %d traces", len(trace.spans))
+	}
+	switch t.config.traceQueuePolicy {
+	case DropOldest:
+		select {
+		case old := <-t.out:
+			t.dropTrace(old)
+		default:
+		}
+		select {
+		case t.out <- trace:
+			return
+		default:
+		}
+	case BlockWithTimeout:
+		timer := time.NewTimer(t.config.traceQueueTimeout)
+		defer timer.Stop()
+		select {
+		case t.out <- trace:
+			return
+		case <-t.stop:
+			// the tracer stopped while waiting for room in the queue, so the
+			// trace will never be sent
+		case <-timer.C:
+		}
+	}
+	t.dropTrace(trace)
+}
+
+// dropTrace records that trace was dropped because the trace queue was full.
+// With the BlockWithTimeout policy, this includes the traces still waiting for
+// room in the queue when the tracer stops.
+func (t *tracer) dropTrace(trace *finishedTrace) {
+	atomic.AddUint32(&t.tracesDroppedQueueFull, 1)
+	t.health.addTracesDropped(1)
+	log.Error("payload queue full, dropping trace with %d spans", len(trace.spans))
+	if fn := t.config.traceDropCallback; fn != nil {
+		fn(len(trace.spans))
 	}
 }
 
@@ -454,6 +527,8 @@ func (t *tracer) StartSpan(operationName string, options ...ddtrace.StartSpanOpt
 		TraceID:      id,
 		Start:        startTime,
 		noDebugStack: t.config.noDebugStack,
+		spanLinks:    opts.SpanLinks,
+		tagLimits:    t.config.tagLimits,
 	}
 	if t.config.hostname != "" {
 		span.setMeta(keyHostname, t.config.hostname)
@@ -526,6 +601,12 @@ func (t *tracer) StartSpan(operationName string, options ...ddtrace.StartSpanOpt
 			span.Service = newSvc
 		}
 	}
+	if t.config.goroutineCheck {
+		span.goroutineID = currentGoroutineID()
+	}
+	for _, fn := range t.config.spanStartHooks {
+		fn(span)
+	}
 	if log.DebugEnabled() {
 		// avoid allocating the ...interface{} argument if debug logging is disabled
 		log.Debug("Started Span: %v, Operation: %s, Resource: %s, Tags: %v, %v",
@@ -588,6 +669,12 @@ func (t *tracer) Stop() {
 		t.statsd.Incr("datadog.tracer.stopped", nil, 1)
 	})
 	t.stats.Stop()
+	if t.dataStreams != nil {
+		if datastreams.GetGlobalProcessor() == t.dataStreams {
+			datastreams.SetGlobalProcessor(nil)
+		}
+		t.dataStreams.Stop()
+	}
 	t.wg.Wait()
 	t.traceWriter.stop()
 	t.statsd.Close()
@@ -613,6 +700,11 @@ func (t *tracer) sample(span *span) {
 		// sampling decision was already made
 		return
 	}
+	if t.config.ciVisibilityEnabled {
+		// test events are never sampled out
+		span.setSamplingPriority(ext.PriorityAutoKeep, samplernames.Default)
+		return
+	}
 	sampler := t.config.sampler
 	if !sampler.Sample(span) {
 		span.context.trace.drop()
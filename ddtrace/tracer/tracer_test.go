@@ -21,6 +21,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -1567,6 +1568,134 @@ func TestPushTrace(t *testing.T) {
 	assert.True(len(tp.Logs()) >= 1)
 }
 
+func TestPushTraceQueuePolicy(t *testing.T) {
+	fill := func(tracer *tracer) {
+		for i := 0; i < payloadQueueSize; i++ {
+			tracer.pushTrace(&finishedTrace{spans: make([]*span, 1)})
+		}
+	}
+
+	t.Run("drop-newest", func(t *testing.T) {
+		assert := assert.New(t)
+		var dropped []int
+		tracer := newUnstartedTracer(WithTraceDropCallback(func(n int) { dropped = append(dropped, n) }))
+		defer tracer.statsd.Close()
+		fill(tracer)
+
+		tracer.pushTrace(&finishedTrace{spans: make([]*span, 2)})
+		assert.Len(tracer.out, payloadQueueSize)
+		assert.Equal([]int{2}, dropped)
+		assert.Equal(uint32(1), atomic.LoadUint32(&tracer.tracesDroppedQueueFull))
+	})
+
+	t.Run("drop-oldest", func(t *testing.T) {
+		assert := assert.New(t)
+		var dropped []int
+		tracer := newUnstartedTracer(
+			WithTraceQueuePolicy(DropOldest),
+			WithTraceDropCallback(func(n int) { dropped = append(dropped, n) }),
+		)
+		defer tracer.statsd.Close()
+		fill(tracer)
+
+		newest := &finishedTrace{spans: make([]*span, 2)}
+		tracer.pushTrace(newest)
+		assert.Len(tracer.out, payloadQueueSize)
+		assert.Equal([]int{1}, dropped)
+		assert.Equal(uint32(1), atomic.LoadUint32(&tracer.tracesDroppedQueueFull))
+		var last *finishedTrace
+		for len(tracer.out) > 0 {
+			last = <-tracer.out
+		}
+		assert.Equal(newest, last)
+	})
+
+	t.Run("block-with-timeout", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer := newUnstartedTracer(
+			WithTraceQueuePolicy(BlockWithTimeout),
+			WithTraceQueueTimeout(time.Second),
+		)
+		defer tracer.statsd.Close()
+		fill(tracer)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			<-tracer.out
+		}()
+		tracer.pushTrace(&finishedTrace{spans: make([]*span, 2)})
+		assert.Len(tracer.out, payloadQueueSize)
+		assert.Equal(uint32(0), atomic.LoadUint32(&tracer.tracesDroppedQueueFull))
+	})
+
+	t.Run("block-timeout-expired", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer := newUnstartedTracer(
+			WithTraceQueuePolicy(BlockWithTimeout),
+			WithTraceQueueTimeout(time.Millisecond),
+		)
+		defer tracer.statsd.Close()
+		fill(tracer)
+
+		tracer.pushTrace(&finishedTrace{spans: make([]*span, 2)})
+		assert.Len(tracer.out, payloadQueueSize)
+		assert.Equal(uint32(1), atomic.LoadUint32(&tracer.tracesDroppedQueueFull))
+	})
+
+	t.Run("block-stopped", func(t *testing.T) {
+		assert := assert.New(t)
+		var dropped []int
+		tracer := newUnstartedTracer(
+			WithTraceQueuePolicy(BlockWithTimeout),
+			WithTraceQueueTimeout(time.Minute),
+			WithTraceDropCallback(func(n int) { dropped = append(dropped, n) }),
+		)
+		defer tracer.statsd.Close()
+		fill(tracer)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			close(tracer.stop)
+		}()
+		// the trace waiting for room when the tracer stops is dropped
+		tracer.pushTrace(&finishedTrace{spans: make([]*span, 2)})
+		assert.Len(tracer.out, payloadQueueSize)
+		assert.Equal([]int{2}, dropped)
+		assert.Equal(uint32(1), atomic.LoadUint32(&tracer.tracesDroppedQueueFull))
+	})
+}
+
+func TestSpanHooks(t *testing.T) {
+	assert := assert.New(t)
+	var started, finished []string
+	tracer, _, _, stop := startTestTracer(t,
+		WithSpanStartHook(func(s ddtrace.Span) {
+			s.SetTag("tenant", "acme")
+			started = append(started, s.(*span).Name)
+		}),
+		WithSpanStartHook(func(s ddtrace.Span) {
+			s.SetTag("region", "eu")
+		}),
+		WithSpanFinishHook(func(s ddtrace.Span) {
+			s.SetTag("finished.by", "hook")
+			finished = append(finished, s.(*span).Name)
+		}),
+	)
+	defer stop()
+
+	root := tracer.StartSpan("root").(*span)
+	child := tracer.StartSpan("child", ChildOf(root.Context())).(*span)
+	assert.Equal([]string{"root", "child"}, started)
+	assert.Equal("acme", child.Meta["tenant"])
+	assert.Equal("eu", child.Meta["region"])
+
+	child.Finish()
+	root.Finish()
+	root.Finish() // hooks are not called again
+	assert.Equal([]string{"child", "root"}, finished)
+	assert.Equal("hook", root.Meta["finished.by"])
+}
+
 func TestTracerFlush(t *testing.T) {
 	// https://github.com/DataDog/dd-trace-go/issues/377
 	tracer, transport, flush, stop := startTestTracer(t)
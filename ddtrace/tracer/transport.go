@@ -53,6 +53,14 @@ var defaultClient = &http.Client{
 	Timeout: defaultHTTPTimeout,
 }
 
+// serverlessClient is the HTTP client used in serverless mode. Its timeout is
+// shorter than the default one, as the traces are sent synchronously at the
+// end of every request.
+var serverlessClient = &http.Client{
+	Transport: defaultClient.Transport,
+	Timeout:   serverlessHTTPTimeout,
+}
+
 const (
 	defaultHostname    = "localhost"
 	defaultPort        = "8126"
@@ -60,6 +68,10 @@ const (
 	defaultURL         = "http://" + defaultAddress
 	defaultHTTPTimeout = 2 * time.Second         // defines the current timeout before giving up with the send process
 	traceCountHeader   = "X-Datadog-Trace-Count" // header containing the number of traces in the payload
+
+	serverlessHTTPTimeout        = 500 * time.Millisecond // timeout of the requests to the agent in serverless mode
+	defaultServerlessSendRetries = 2                      // number of retries of the payload sends in serverless mode
+	sendRetriesUnset             = -1                     // number of retries not set by WithSendRetries
 )
 
 // transport is an interface for communicating data to the agent.
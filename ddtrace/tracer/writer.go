@@ -49,21 +49,36 @@ type agentTraceWriter struct {
 
 	// statsd is used to send metrics
 	statsd statsdClient
+
+	// health holds the tracer's health counters, if any
+	health *healthCounters
 }
 
 func newAgentTraceWriter(c *config, s *prioritySampler, statsdClient statsdClient) *agentTraceWriter {
 	return &agentTraceWriter{
 		config:           c,
-		payload:          newPayload(),
+		payload:          newWriterPayload(c),
 		climit:           make(chan struct{}, concurrentConnectionLimit),
 		prioritySampling: s,
 		statsd:           statsdClient,
 	}
 }
 
+// newWriterPayload returns a new payload using the trace protocol selected by c.
+func newWriterPayload(c *config) *payload {
+	if c.canUseV07() {
+		return newPayloadV07(c)
+	}
+	return newPayload()
+}
+
 func (h *agentTraceWriter) add(trace []*span) {
-	if err := h.payload.push(trace); err != nil {
+	start := time.Now()
+	err := h.payload.push(trace)
+	h.health.addEncodeTime(time.Since(start))
+	if err != nil {
 		h.statsd.Incr("datadog.tracer.traces_dropped", []string{"reason:encoding_error"}, 1)
+		h.health.addTracesDropped(1)
 		log.Error("Error encoding msgpack: %v", err)
 	}
 	if h.payload.size() > payloadSizeLimit {
@@ -78,6 +93,11 @@ func (h *agentTraceWriter) stop() {
 	h.wg.Wait()
 }
 
+// wait blocks until the payloads being sent have been sent.
+func (h *agentTraceWriter) wait() {
+	h.wg.Wait()
+}
+
 // flush will push any currently buffered traces to the server.
 func (h *agentTraceWriter) flush() {
 	if h.payload.itemCount() == 0 {
@@ -86,7 +106,7 @@ func (h *agentTraceWriter) flush() {
 	h.wg.Add(1)
 	h.climit <- struct{}{}
 	oldp := h.payload
-	h.payload = newPayload()
+	h.payload = newWriterPayload(h.config)
 	go func(p *payload) {
 		defer func(start time.Time) {
 			// Once the payload has been used, clear the buffer for garbage
@@ -115,11 +135,13 @@ func (h *agentTraceWriter) flush() {
 				}
 				return
 			}
+			h.health.addFlushError()
 			log.Error("failure sending traces (attempt %d), will retry: %v", attempt+1, err)
 			p.reset()
 			time.Sleep(time.Millisecond)
 		}
 		h.statsd.Count("datadog.tracer.traces_dropped", int64(count), []string{"reason:send_failed"}, 1)
+		h.health.addTracesDropped(count)
 		log.Error("lost %d traces: %v", count, err)
 	}(oldp)
 }
@@ -136,6 +158,7 @@ type logTraceWriter struct {
 	hasTraces bool
 	w         io.Writer
 	statsd    statsdClient
+	health    *healthCounters
 }
 
 func newLogTraceWriter(c *config, statsdClient statsdClient) *logTraceWriter {
@@ -312,6 +335,7 @@ func (h *logTraceWriter) add(trace []*span) {
 		if err != nil {
 			log.Error("Lost a trace: %s", err.cause)
 			h.statsd.Count("datadog.tracer.traces_dropped", 1, []string{"reason:" + err.dropReason}, 1)
+			h.health.addTracesDropped(1)
 			return
 		}
 		trace = trace[n:]
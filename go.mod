@@ -3,8 +3,16 @@ module gopkg.in/DataDog/dd-trace-go.v1
 go 1.18
 
 require (
+	cloud.google.com/go/bigquery v1.50.0
 	cloud.google.com/go/pubsub v1.30.0
+	cloud.google.com/go/spanner v1.45.0
+	cloud.google.com/go/storage v1.30.1
+	connectrpc.com/connect v1.11.1
+	entgo.io/ent v0.11.10
 	github.com/99designs/gqlgen v0.16.0
+	github.com/Azure/azure-sdk-for-go/sdk/azcore v1.7.0
+	github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus v1.4.0
+	github.com/ClickHouse/clickhouse-go/v2 v2.9.1
 	github.com/DataDog/appsec-internal-go v1.0.0
 	github.com/DataDog/datadog-agent/pkg/obfuscate v0.45.0-rc.1
 	github.com/DataDog/datadog-agent/pkg/remoteconfig/state v0.46.0-rc.4
@@ -12,7 +20,12 @@ require (
 	github.com/DataDog/go-libddwaf v1.4.1
 	github.com/DataDog/gostackparse v0.5.0
 	github.com/DataDog/sketches-go v1.2.1
+	github.com/GoogleCloudPlatform/functions-framework-go v1.8.0
+	github.com/RichardKnop/machinery/v2 v2.0.11
 	github.com/Shopify/sarama v1.22.0
+	github.com/apache/cassandra-gocql-driver/v2 v2.0.0
+	github.com/apache/pulsar-client-go v0.10.0
+	github.com/aws/aws-lambda-go v1.41.0
 	github.com/aws/aws-sdk-go v1.34.28
 	github.com/aws/aws-sdk-go-v2 v1.18.0
 	github.com/aws/aws-sdk-go-v2/config v1.18.21
@@ -26,10 +39,13 @@ require (
 	github.com/aws/aws-sdk-go-v2/service/sqs v1.20.8
 	github.com/aws/smithy-go v1.13.5
 	github.com/bradfitz/gomemcache v0.0.0-20221031212613-62deef7fc822
+	github.com/cloudevents/sdk-go/v2 v2.14.0
 	github.com/confluentinc/confluent-kafka-go v1.4.0
 	github.com/confluentinc/confluent-kafka-go/v2 v2.1.1
 	github.com/denisenkom/go-mssqldb v0.11.0
 	github.com/dimfeld/httptreemux/v5 v5.5.0
+	github.com/docker/docker v24.0.9+incompatible
+	github.com/eclipse/paho.mqtt.golang v1.4.3
 	github.com/elastic/go-elasticsearch/v6 v6.8.5
 	github.com/elastic/go-elasticsearch/v7 v7.17.1
 	github.com/elastic/go-elasticsearch/v8 v8.4.0
@@ -40,6 +56,7 @@ require (
 	github.com/globalsign/mgo v0.0.0-20181015135952-eeefdecb41b8
 	github.com/go-chi/chi v1.5.0
 	github.com/go-chi/chi/v5 v5.0.0
+	github.com/go-co-op/gocron v1.18.0
 	github.com/go-pg/pg/v10 v10.11.0
 	github.com/go-redis/redis v6.15.9+incompatible
 	github.com/go-redis/redis/v7 v7.1.0
@@ -52,10 +69,13 @@ require (
 	github.com/google/pprof v0.0.0-20230509042627-b1315fad0c5a
 	github.com/google/uuid v1.3.0
 	github.com/gorilla/mux v1.8.0
+	github.com/gorilla/websocket v1.4.2
 	github.com/graph-gophers/graphql-go v1.3.0
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.18.0
 	github.com/hashicorp/consul/api v1.1.0
 	github.com/hashicorp/vault/api v1.1.0
 	github.com/hashicorp/vault/sdk v0.1.14-0.20200519221838-e0cfd64bc267
+	github.com/hibiken/asynq v0.24.1
 	github.com/jackc/pgx/v5 v5.3.1
 	github.com/jinzhu/gorm v1.9.10
 	github.com/jmoiron/sqlx v1.2.0
@@ -66,9 +86,14 @@ require (
 	github.com/mattn/go-sqlite3 v1.14.14
 	github.com/microsoft/go-mssqldb v0.21.0
 	github.com/miekg/dns v1.1.25
+	github.com/nats-io/nats.go v1.25.0
 	github.com/opentracing/opentracing-go v1.2.0
+	github.com/prometheus/client_golang v1.14.0
+	github.com/prometheus/client_model v0.3.0
+	github.com/rabbitmq/amqp091-go v1.8.1
 	github.com/redis/go-redis/v9 v9.0.0
 	github.com/richardartoul/molecule v1.0.1-0.20221107223329-32cfee06a052
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/segmentio/kafka-go v0.4.29
 	github.com/sirupsen/logrus v1.8.1
 	github.com/spaolacci/murmur3 v0.0.0-20180118202830-f09979ecbc72
@@ -77,16 +102,22 @@ require (
 	github.com/tidwall/buntdb v1.2.0
 	github.com/tinylib/msgp v1.1.8
 	github.com/twitchtv/twirp v8.1.1+incompatible
+	github.com/twmb/franz-go v1.14.4
 	github.com/urfave/negroni v1.0.0
+	github.com/valyala/fasthttp v1.45.0
+	github.com/wneessen/go-mail v0.4.1
 	github.com/vektah/gqlparser/v2 v2.2.0
 	github.com/zenazn/goji v1.0.1
 	go.mongodb.org/mongo-driver v1.7.5
 	go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp v0.42.0
 	go.opentelemetry.io/otel v1.16.0
 	go.opentelemetry.io/otel/trace v1.16.0
+	go.temporal.io/sdk v1.21.1
 	go.uber.org/atomic v1.11.0
+	golang.org/x/crypto v0.9.0
 	golang.org/x/net v0.10.0
 	golang.org/x/oauth2 v0.7.0
+	golang.org/x/sync v0.1.0
 	golang.org/x/sys v0.10.0
 	golang.org/x/time v0.3.0
 	golang.org/x/xerrors v0.0.0-20220907171357-04be3eba64a2
@@ -164,7 +195,6 @@ require (
 	github.com/googleapis/enterprise-certificate-proxy v0.2.3 // indirect
 	github.com/googleapis/gax-go/v2 v2.8.0 // indirect
 	github.com/googleapis/gnostic v0.5.5 // indirect
-	github.com/gorilla/websocket v1.4.2 // indirect
 	github.com/hailocab/go-hostpool v0.0.0-20160125115350-e80d13ce29ed // indirect
 	github.com/hashicorp/errwrap v1.1.0 // indirect
 	github.com/hashicorp/go-cleanhttp v0.5.2 // indirect
@@ -224,7 +254,6 @@ require (
 	github.com/twitchyliquid64/golang-asm v0.15.1 // indirect
 	github.com/ugorji/go/codec v1.2.11 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
-	github.com/valyala/fasthttp v1.45.0 // indirect
 	github.com/valyala/fasttemplate v1.2.1 // indirect
 	github.com/valyala/tcplisten v1.0.0 // indirect
 	github.com/vmihailenco/bufpool v0.1.11 // indirect
@@ -240,8 +269,6 @@ require (
 	go4.org/intern v0.0.0-20211027215823-ae77deb06f29 // indirect
 	go4.org/unsafe/assume-no-moving-gc v0.0.0-20220617031537-928513b29760 // indirect
 	golang.org/x/arch v0.3.0 // indirect
-	golang.org/x/crypto v0.9.0 // indirect
-	golang.org/x/sync v0.1.0 // indirect
 	golang.org/x/term v0.8.0 // indirect
 	golang.org/x/text v0.9.0 // indirect
 	google.golang.org/appengine v1.6.7 // indirect
@@ -0,0 +1,9 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package agentproto tests that the payloads sent by the tracer are decoded by
+// the Datadog Agent. It is a module of its own so that the tracer does not depend
+// on the agent's protocol definitions.
+package agentproto
@@ -0,0 +1,12 @@
+module gopkg.in/DataDog/dd-trace-go.v1/internal/agentproto
+
+go 1.25.1
+
+require (
+	github.com/DataDog/datadog-agent/pkg/proto v0.67.0
+	github.com/stretchr/testify v1.10.0
+	gopkg.in/DataDog/dd-trace-go.v1 v1.54.0
+)
+
+// use local version of dd-trace-go
+replace gopkg.in/DataDog/dd-trace-go.v1 => ../..
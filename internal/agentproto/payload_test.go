@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package agentproto
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracerPayloadV07(t *testing.T) {
+	t.Setenv("DD_TRACE_FEATURES", "v0.7")
+	payloads := make(chan *pb.TracerPayload, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/info":
+			w.Write([]byte(`{"endpoints":["/v0.4/traces","/v0.6/stats","/v0.7/traces"]}`))
+		case "/v0.7/traces":
+			if r.Header.Get("X-Datadog-Trace-Count") == "0" {
+				// connectivity check at startup
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			var tp pb.TracerPayload
+			_, err = tp.UnmarshalMsg(body)
+			assert.NoError(t, err)
+			payloads <- &tp
+		case "/v0.4/traces":
+			t.Errorf("traces sent using the v0.4 protocol")
+		}
+	}))
+	defer srv.Close()
+
+	tracer.Start(
+		tracer.WithAgentAddr(strings.TrimPrefix(srv.URL, "http://")),
+		tracer.WithService("web"),
+		tracer.WithEnv("prod"),
+		tracer.WithServiceVersion("1.2.3"),
+	)
+	defer tracer.Stop()
+	root := tracer.StartSpan("http.request", tracer.ResourceName("GET /"), tracer.Tag(ext.ManualKeep, true))
+	child := tracer.StartSpan("db.query", tracer.ChildOf(root.Context()), tracer.Tag("db.system", "postgresql"))
+	child.Finish()
+	root.Finish()
+	tracer.Flush()
+
+	var tp *pb.TracerPayload
+	select {
+	case tp = <-payloads:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the payload")
+	}
+	assert := assert.New(t)
+	assert.Equal("go", tp.LanguageName)
+	assert.Equal("prod", tp.Env)
+	assert.Equal("1.2.3", tp.AppVersion)
+	assert.NotEmpty(tp.TracerVersion)
+	assert.NotEmpty(tp.RuntimeID)
+	require.Len(t, tp.Chunks, 1)
+	chunk := tp.Chunks[0]
+	assert.EqualValues(ext.PriorityUserKeep, chunk.Priority)
+	require.Len(t, chunk.Spans, 2)
+	spans := map[string]*pb.Span{}
+	for _, s := range chunk.Spans {
+		spans[s.Name] = s
+	}
+	require.Contains(t, spans, "http.request")
+	require.Contains(t, spans, "db.query")
+	r, c := spans["http.request"], spans["db.query"]
+	assert.Equal("web", r.Service)
+	assert.Equal("GET /", r.Resource)
+	assert.Equal(r.TraceID, c.TraceID)
+	assert.Equal(r.SpanID, c.ParentID)
+	assert.Equal("postgresql", c.Meta["db.system"])
+	assert.EqualValues(ext.PriorityUserKeep, r.Metrics["_sampling_priority_v1"])
+}
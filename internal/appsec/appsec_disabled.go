@@ -35,4 +35,7 @@ func Start(...StartOption) {
 func Stop() {}
 
 // Static rule stubs when disabled.
-const staticRecommendedRules = ""
+const (
+	staticRecommendedRules = ""
+	staticRASPRules        = ""
+)
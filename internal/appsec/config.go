@@ -14,6 +14,7 @@ import (
 	"unicode"
 	"unicode/utf8"
 
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/remoteconfig"
 )
@@ -25,6 +26,7 @@ const (
 	traceRateLimitEnvVar  = "DD_APPSEC_TRACE_RATE_LIMIT"
 	obfuscatorKeyEnvVar   = "DD_APPSEC_OBFUSCATION_PARAMETER_KEY_REGEXP"
 	obfuscatorValueEnvVar = "DD_APPSEC_OBFUSCATION_PARAMETER_VALUE_REGEXP"
+	raspEnabledEnvVar     = "DD_APPSEC_RASP_ENABLED"
 )
 
 const (
@@ -48,6 +50,9 @@ type Config struct {
 	traceRateLimit uint
 	// Obfuscator configuration parameters
 	obfuscator ObfuscatorConfig
+	// raspEnabled enables the RASP (Runtime Application Self-Protection) exploit prevention, which monitors the SQL
+	// queries and outgoing HTTP requests made by the application. It is enabled by default.
+	raspEnabled bool
 	// rc is the remote configuration client used to receive product configuration updates. Nil if rc is disabled (default)
 	rc *remoteconfig.ClientConfig
 }
@@ -95,6 +100,7 @@ func newConfig() (*Config, error) {
 		wafTimeout:     readWAFTimeoutConfig(),
 		traceRateLimit: readRateLimitConfig(),
 		obfuscator:     readObfuscatorConfig(),
+		raspEnabled:    internal.BoolEnv(raspEnabledEnvVar, true),
 	}, nil
 }
 
@@ -162,12 +168,13 @@ func readObfuscatorConfigRegexp(name, defaultValue string) string {
 	return val
 }
 
+// readRulesConfig returns the content of the rules file set by DD_APPSEC_RULES, or nil when the default built-in
+// rules should be used.
 func readRulesConfig() (rules []byte, err error) {
-	rules = []byte(staticRecommendedRules)
 	filepath := os.Getenv(rulesEnvVar)
 	if filepath == "" {
 		log.Debug("appsec: using the default built-in recommended security rules")
-		return rules, nil
+		return nil, nil
 	}
 	buf, err := os.ReadFile(filepath)
 	if err != nil {
@@ -27,6 +27,7 @@ func TestConfig(t *testing.T) {
 			KeyRegex:   defaultObfuscatorKeyRegex,
 			ValueRegex: defaultObfuscatorValueRegex,
 		},
+		raspEnabled: true,
 	}
 
 	t.Run("default", func(t *testing.T) {
@@ -186,6 +187,17 @@ func TestConfig(t *testing.T) {
 		})
 	})
 
+	t.Run("rasp", func(t *testing.T) {
+		expCfg := *expectedDefaultConfig
+		expCfg.raspEnabled = false
+		restoreEnv := cleanEnv()
+		defer restoreEnv()
+		require.NoError(t, os.Setenv(raspEnabledEnvVar, "false"))
+		cfg, err := newConfig()
+		require.NoError(t, err)
+		require.Equal(t, &expCfg, cfg)
+	})
+
 	t.Run("obfuscator", func(t *testing.T) {
 		t.Run("key-regexp", func(t *testing.T) {
 			t.Run("env-var-normal", func(t *testing.T) {
@@ -258,6 +270,7 @@ func cleanEnv() func() {
 		traceRateLimitEnvVar:  os.Getenv(traceRateLimitEnvVar),
 		obfuscatorKeyEnvVar:   os.Getenv(obfuscatorKeyEnvVar),
 		obfuscatorValueEnvVar: os.Getenv(obfuscatorValueEnvVar),
+		raspEnabledEnvVar:     os.Getenv(raspEnabledEnvVar),
 	}
 	for k := range env {
 		if err := os.Unsetenv(k); err != nil {
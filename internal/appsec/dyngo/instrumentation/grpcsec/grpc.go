@@ -16,6 +16,7 @@ import (
 
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/sharedsec"
 
 	"github.com/DataDog/appsec-internal-go/netip"
 )
@@ -167,10 +168,17 @@ func StartReceiveOperation(args ReceiveOperationArgs, parent dyngo.Operation) Re
 	return op
 }
 
-// Finish the gRPC handler operation, along with the given results, and emits a
-// finish event up in the operation stack.
-func (op ReceiveOperation) Finish(res ReceiveOperationRes) {
+// Finish the gRPC receive operation, along with the given results, and emits a
+// finish event up in the operation stack. An error is returned if the received
+// message must be blocked, in which case the RPC should be aborted with the
+// status code embedded in the returned MonitoringError.
+func (op ReceiveOperation) Finish(res ReceiveOperationRes) error {
+	var err error
+	sharedsec.OnErrorData(op, func(e error) {
+		err = e
+	})
 	dyngo.FinishOperation(op, res)
+	return err
 }
 
 // gRPC receive operation's start and finish event callback function types.
@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package httpsec
+
+import (
+	"context"
+	"reflect"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/sharedsec"
+)
+
+type (
+	// RoundTripOperation type representing an outgoing HTTP request made by an
+	// HTTP client. It gets both created and destroyed in a single call to
+	// ExecuteRoundTripOperation.
+	RoundTripOperation struct {
+		dyngo.Operation
+	}
+	// RoundTripOperationArgs is the round trip operation arguments.
+	RoundTripOperationArgs struct {
+		// URL is the URL of the outgoing request.
+		URL string
+	}
+	// RoundTripOperationRes is the round trip operation results.
+	RoundTripOperationRes struct{}
+
+	// OnRoundTripOperationStart function type, called when a round trip
+	// operation starts.
+	OnRoundTripOperationStart func(*RoundTripOperation, RoundTripOperationArgs)
+)
+
+var roundTripOperationArgsType = reflect.TypeOf((*RoundTripOperationArgs)(nil)).Elem()
+
+// ExecuteRoundTripOperation starts and finishes the round trip operation by emitting a dyngo start and finish events.
+// An error is returned if the outgoing request associated to that operation must be blocked.
+func ExecuteRoundTripOperation(parent dyngo.Operation, args RoundTripOperationArgs) error {
+	var err error
+	op := &RoundTripOperation{Operation: dyngo.NewOperation(parent)}
+	sharedsec.OnErrorData(op, func(e error) {
+		err = e
+	})
+	dyngo.StartOperation(op, args)
+	dyngo.FinishOperation(op, RoundTripOperationRes{})
+	return err
+}
+
+// ListenedType returns the type a OnRoundTripOperationStart event listener
+// listens to, which is the RoundTripOperationArgs type.
+func (OnRoundTripOperationStart) ListenedType() reflect.Type { return roundTripOperationArgsType }
+
+// Call the underlying event listener function by performing the
+// type-assertion on v whose type is the one returned by ListenedType().
+func (f OnRoundTripOperationStart) Call(op dyngo.Operation, v interface{}) {
+	f(op.(*RoundTripOperation), v.(RoundTripOperationArgs))
+}
+
+// ProtectRoundTrip starts and finishes a round trip operation for the given
+// URL. A call to the WAF is made to detect server-side request forgeries and an
+// error is returned if the outgoing request should be blocked. Requests made
+// outside of a monitored request are silently ignored.
+func ProtectRoundTrip(ctx context.Context, url string) error {
+	parent, ok := ctx.Value(instrumentation.ContextKey{}).(dyngo.Operation)
+	if !ok {
+		return nil
+	}
+	return ExecuteRoundTripOperation(parent, RoundTripOperationArgs{URL: url})
+}
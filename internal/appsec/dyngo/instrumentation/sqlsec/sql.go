@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package sqlsec defines the SQL operations used by the RASP (Runtime
+// Application Self-Protection) exploit prevention of the database/sql
+// integration.
+package sqlsec
+
+import (
+	"context"
+	"reflect"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/sharedsec"
+)
+
+type (
+	// SQLOperation type representing a SQL query about to be sent to a
+	// database driver. It gets both created and destroyed in a single call to
+	// ExecuteSQLOperation.
+	SQLOperation struct {
+		dyngo.Operation
+	}
+	// SQLOperationArgs is the SQL operation arguments.
+	SQLOperationArgs struct {
+		// Query is the SQL statement about to be executed.
+		Query string
+		// DBSystem is the database system of the driver, e.g. "mysql" or "postgresql".
+		DBSystem string
+	}
+	// SQLOperationRes is the SQL operation results.
+	SQLOperationRes struct{}
+
+	// OnSQLOperationStart function type, called when a SQL operation starts.
+	OnSQLOperationStart func(operation *SQLOperation, args SQLOperationArgs)
+)
+
+var sqlOperationArgsType = reflect.TypeOf((*SQLOperationArgs)(nil)).Elem()
+
+// ExecuteSQLOperation starts and finishes the SQL operation by emitting a dyngo start and finish events.
+// An error is returned if the query associated to that operation must be blocked.
+func ExecuteSQLOperation(parent dyngo.Operation, args SQLOperationArgs) error {
+	var err error
+	op := &SQLOperation{Operation: dyngo.NewOperation(parent)}
+	sharedsec.OnErrorData(op, func(e error) {
+		err = e
+	})
+	dyngo.StartOperation(op, args)
+	dyngo.FinishOperation(op, SQLOperationRes{})
+	return err
+}
+
+// ListenedType returns the type a OnSQLOperationStart event listener
+// listens to, which is the SQLOperationArgs type.
+func (OnSQLOperationStart) ListenedType() reflect.Type { return sqlOperationArgsType }
+
+// Call the underlying event listener function by performing the type-assertion
+// on v whose type is the one returned by ListenedType().
+func (f OnSQLOperationStart) Call(op dyngo.Operation, v interface{}) {
+	f(op.(*SQLOperation), v.(SQLOperationArgs))
+}
+
+// ProtectSQLOperation starts and finishes a SQL operation for the given query.
+// A call to the WAF is made to detect SQL injections and an error is returned
+// if the query should be blocked. Queries executed outside of a monitored
+// request, such as the ones made by background jobs, are silently ignored.
+func ProtectSQLOperation(ctx context.Context, query, dbSystem string) error {
+	parent, ok := ctx.Value(instrumentation.ContextKey{}).(dyngo.Operation)
+	if !ok {
+		return nil
+	}
+	return ExecuteSQLOperation(parent, SQLOperationArgs{Query: query, DBSystem: dbSystem})
+}
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package sqlsec_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/sqlsec"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtectSQLOperation(t *testing.T) {
+	type (
+		rootArgs struct{}
+		rootRes  struct{}
+	)
+
+	t.Run("no-parent-operation", func(t *testing.T) {
+		require.NoError(t, sqlsec.ProtectSQLOperation(context.Background(), "SELECT 1", "mysql"))
+	})
+
+	t.Run("monitored", func(t *testing.T) {
+		root := dyngo.NewOperation(nil)
+		dyngo.StartOperation(root, rootArgs{})
+		defer dyngo.FinishOperation(root, rootRes{})
+
+		var got sqlsec.SQLOperationArgs
+		root.On(sqlsec.OnSQLOperationStart(func(_ *sqlsec.SQLOperation, args sqlsec.SQLOperationArgs) {
+			got = args
+		}))
+
+		ctx := context.WithValue(context.Background(), instrumentation.ContextKey{}, root)
+		require.NoError(t, sqlsec.ProtectSQLOperation(ctx, "SELECT 1", "mysql"))
+		require.Equal(t, sqlsec.SQLOperationArgs{Query: "SELECT 1", DBSystem: "mysql"}, got)
+	})
+
+	t.Run("blocked", func(t *testing.T) {
+		root := dyngo.NewOperation(nil)
+		dyngo.StartOperation(root, rootArgs{})
+		defer dyngo.FinishOperation(root, rootRes{})
+
+		blocked := errors.New("blocked")
+		root.On(sqlsec.OnSQLOperationStart(func(op *sqlsec.SQLOperation, _ sqlsec.SQLOperationArgs) {
+			op.EmitData(blocked)
+		}))
+
+		ctx := context.WithValue(context.Background(), instrumentation.ContextKey{}, root)
+		err := sqlsec.ProtectSQLOperation(ctx, "SELECT * FROM users WHERE id = '1' OR '1'='1'", "mysql")
+		require.Equal(t, blocked, err)
+	})
+}
@@ -0,0 +1,19 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+//go:build appsec
+// +build appsec
+
+package appsec
+
+import _ "embed"
+
+// Static RASP rules monitoring the server.db.statement and server.io.net.url addresses. They are merged into the
+// recommended rules by defaultRulesFragment, and kept apart from rules.json which is generated by
+// _tools/rules-updater. They only report attacks: blocking them requires a custom rules file or a remote
+// configuration override adding the block action.
+//
+//go:embed rasp_rules.json
+var staticRASPRules string
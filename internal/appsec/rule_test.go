@@ -11,6 +11,7 @@ package appsec
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	waf "github.com/DataDog/go-libddwaf"
 	"github.com/stretchr/testify/require"
@@ -29,3 +30,38 @@ func TestStaticRule(t *testing.T) {
 	require.NotNil(t, waf)
 	waf.Close()
 }
+
+func TestStaticRASPRules(t *testing.T) {
+	if supported, _ := waf.SupportsTarget(); !supported {
+		t.Skip("waf disabled")
+		return
+	}
+
+	handle, err := waf.NewHandle(defaultRulesFragment(), "", "")
+	require.NoError(t, err)
+	require.NotNil(t, handle)
+	defer handle.Close()
+	require.Subset(t, handle.Addresses(), []string{serverDBStatementAddr, serverIONetURLAddr})
+
+	for _, tc := range []struct {
+		addr  string
+		value string
+		match bool
+	}{
+		{addr: serverDBStatementAddr, value: "SELECT * FROM users WHERE name = '' OR '1'='1'", match: true},
+		{addr: serverDBStatementAddr, value: "SELECT * FROM users WHERE name = 'a'; DROP TABLE users", match: true},
+		{addr: serverDBStatementAddr, value: "SELECT * FROM users WHERE name = 'a' OR email = 'b'"},
+		{addr: serverIONetURLAddr, value: "http://169.254.169.254/latest/meta-data/", match: true},
+		{addr: serverIONetURLAddr, value: "file:///etc/passwd", match: true},
+		{addr: serverIONetURLAddr, value: "https://example.com/?next=http://169.254.169.254/"},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			wafCtx := waf.NewContext(handle)
+			require.NotNil(t, wafCtx)
+			defer wafCtx.Close()
+			matches, _, err := wafCtx.Run(map[string]interface{}{tc.addr: tc.value}, time.Second)
+			require.NoError(t, err)
+			require.Equal(t, tc.match, len(matches) > 0)
+		})
+	}
+}
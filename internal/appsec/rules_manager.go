@@ -54,12 +54,17 @@ type (
 	}
 )
 
-// defaultRulesFragment returns a rulesFragment created using the default static recommended rules
+// defaultRulesFragment returns a rulesFragment created using the default static recommended rules, along with the
+// static RASP rules
 func defaultRulesFragment() rulesFragment {
-	var f rulesFragment
+	var f, rasp rulesFragment
 	if err := json.Unmarshal([]byte(staticRecommendedRules), &f); err != nil {
 		log.Debug("appsec: error unmarshalling default rules: %v", err)
 	}
+	if err := json.Unmarshal([]byte(staticRASPRules), &rasp); err != nil {
+		log.Debug("appsec: error unmarshalling default RASP rules: %v", err)
+	}
+	f.Rules = append(f.Rules, rasp.Rules...)
 	return f
 }
 
@@ -21,6 +21,7 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/grpcsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/httpsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/sharedsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo/instrumentation/sqlsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/samplernames"
 
@@ -133,6 +134,12 @@ func newWAFEventListeners(waf *wafHandle, cfg *Config, l Limiter) (listeners []d
 
 	// Check there are supported addresses in the rule
 	httpAddresses, grpcAddresses, notSupported := supportedAddresses(ruleAddresses)
+	if !cfg.raspEnabled {
+		for _, addr := range raspAddresses {
+			delete(httpAddresses, addr)
+			delete(grpcAddresses, addr)
+		}
+	}
 	if len(httpAddresses) == 0 && len(grpcAddresses) == 0 {
 		return nil, fmt.Errorf("the addresses present in the rules are not supported: %v", notSupported)
 	}
@@ -232,6 +239,28 @@ func newHTTPWAFEventListener(handle *wafHandle, addresses map[string]struct{}, t
 			}))
 		}
 
+		if _, ok := addresses[serverDBStatementAddr]; ok {
+			op.On(sqlsec.OnSQLOperationStart(func(sqlOp *sqlsec.SQLOperation, args sqlsec.SQLOperationArgs) {
+				matches, actionIds := runWAF(wafCtx, sqlValues(args), timeout)
+				if len(matches) > 0 {
+					processHTTPSDKAction(sqlOp, handle.actions, actionIds)
+					addSecurityEvents(op, limiter, matches)
+					log.Debug("appsec: RASP detected a SQL injection attempt")
+				}
+			}))
+		}
+
+		if _, ok := addresses[serverIONetURLAddr]; ok {
+			op.On(httpsec.OnRoundTripOperationStart(func(rtOp *httpsec.RoundTripOperation, args httpsec.RoundTripOperationArgs) {
+				matches, actionIds := runWAF(wafCtx, map[string]interface{}{serverIONetURLAddr: args.URL}, timeout)
+				if len(matches) > 0 {
+					processHTTPSDKAction(rtOp, handle.actions, actionIds)
+					addSecurityEvents(op, limiter, matches)
+					log.Debug("appsec: RASP detected a server-side request forgery attempt")
+				}
+			}))
+		}
+
 		op.On(httpsec.OnHandlerOperationFinish(func(op *httpsec.Operation, res httpsec.HandlerOperationRes) {
 			defer wafCtx.Close()
 
@@ -303,17 +332,34 @@ func newGRPCWAFEventListener(handle *wafHandle, addresses map[string]struct{}, t
 			}
 			matches, actionIds := runWAF(wafCtx, values, timeout)
 			if len(matches) > 0 {
-				for _, id := range actionIds {
-					if a, ok := handle.actions[id]; ok && a.Blocking() {
-						code, err := a.GRPC()(map[string][]string{})
-						userIDOp.EmitData(grpcsec.NewMonitoringError(err.Error(), code))
-					}
-				}
+				processGRPCSDKAction(userIDOp, handle.actions, actionIds)
 				addSecurityEvents(op, limiter, matches)
 				log.Debug("appsec: WAF detected an authenticated user attack: %s", args.UserID)
 			}
 		}))
 
+		if _, ok := addresses[serverDBStatementAddr]; ok {
+			op.On(sqlsec.OnSQLOperationStart(func(sqlOp *sqlsec.SQLOperation, args sqlsec.SQLOperationArgs) {
+				matches, actionIds := runWAF(wafCtx, sqlValues(args), timeout)
+				if len(matches) > 0 {
+					processGRPCSDKAction(sqlOp, handle.actions, actionIds)
+					addSecurityEvents(op, limiter, matches)
+					log.Debug("appsec: RASP detected a SQL injection attempt")
+				}
+			}))
+		}
+
+		if _, ok := addresses[serverIONetURLAddr]; ok {
+			op.On(httpsec.OnRoundTripOperationStart(func(rtOp *httpsec.RoundTripOperation, args httpsec.RoundTripOperationArgs) {
+				matches, actionIds := runWAF(wafCtx, map[string]interface{}{serverIONetURLAddr: args.URL}, timeout)
+				if len(matches) > 0 {
+					processGRPCSDKAction(rtOp, handle.actions, actionIds)
+					addSecurityEvents(op, limiter, matches)
+					log.Debug("appsec: RASP detected a server-side request forgery attempt")
+				}
+			}))
+		}
+
 		// The same address is used for gRPC and http when it comes to client ip
 		values := map[string]interface{}{}
 		for addr := range addresses {
@@ -333,7 +379,7 @@ func newGRPCWAFEventListener(handle *wafHandle, addresses map[string]struct{}, t
 			}
 		}
 
-		op.On(grpcsec.OnReceiveOperationFinish(func(_ grpcsec.ReceiveOperation, res grpcsec.ReceiveOperationRes) {
+		op.On(grpcsec.OnReceiveOperationFinish(func(recvOp grpcsec.ReceiveOperation, res grpcsec.ReceiveOperationRes) {
 			if nbEvents.Load() == maxWAFEventsPerRequest {
 				logOnce.Do(func() {
 					log.Debug("appsec: ignoring the rpc message due to the maximum number of security events per grpc call reached")
@@ -362,9 +408,7 @@ func newGRPCWAFEventListener(handle *wafHandle, addresses map[string]struct{}, t
 			if md := handlerArgs.Metadata; len(md) > 0 {
 				values[grpcServerRequestMetadata] = md
 			}
-			// Run the WAF, ignoring the returned actions - if any - since blocking after the request handler's
-			// response is not supported at the moment.
-			event, _ := runWAF(wafCtx, values, timeout)
+			event, actionIds := runWAF(wafCtx, values, timeout)
 
 			// WAF run durations are WAF context bound. As of now we need to keep track of those externally since
 			// we use a new WAF context for each callback. When we are able to re-use the same WAF context across
@@ -378,6 +422,11 @@ func newGRPCWAFEventListener(handle *wafHandle, addresses map[string]struct{}, t
 				return
 			}
 			log.Debug("appsec: attack detected by the grpc waf")
+			// Blocking actions abort the RPC by returning an error out of the receive operation, which is the only
+			// way to interrupt streaming RPCs whose messages are received while the handler is being executed.
+			if processActions(op, handle.actions, actionIds) {
+				processGRPCSDKAction(recvOp, handle.actions, actionIds)
+			}
 			nbEvents.Inc()
 			mu.Lock()
 			events = append(events, event)
@@ -413,6 +462,15 @@ func runWAF(wafCtx *waf.Context, values map[string]interface{}, timeout time.Dur
 	return matches, actions
 }
 
+// sqlValues returns the WAF addresses of the given SQL operation arguments.
+func sqlValues(args sqlsec.SQLOperationArgs) map[string]interface{} {
+	values := map[string]interface{}{serverDBStatementAddr: args.Query}
+	if args.DBSystem != "" {
+		values[serverDBSystemAddr] = args.DBSystem
+	}
+	return values
+}
+
 // HTTP rule addresses currently supported by the WAF
 const (
 	serverRequestMethodAddr           = "server.request.method"
@@ -427,6 +485,21 @@ const (
 	userIDAddr                        = "usr.id"
 )
 
+// RASP (Runtime Application Self-Protection) rule addresses currently supported by the WAF. They are shared by the
+// HTTP and gRPC listeners, and are disabled with DD_APPSEC_RASP_ENABLED=false.
+const (
+	serverDBStatementAddr = "server.db.statement"
+	serverDBSystemAddr    = "server.db.system"
+	serverIONetURLAddr    = "server.io.net.url"
+)
+
+// List of RASP rule addresses currently supported by the WAF
+var raspAddresses = []string{
+	serverDBStatementAddr,
+	serverDBSystemAddr,
+	serverIONetURLAddr,
+}
+
 // List of HTTP rule addresses currently supported by the WAF
 var httpAddresses = []string{
 	serverRequestMethodAddr,
@@ -439,6 +512,9 @@ var httpAddresses = []string{
 	serverResponseStatusAddr,
 	httpClientIPAddr,
 	userIDAddr,
+	serverDBStatementAddr,
+	serverDBSystemAddr,
+	serverIONetURLAddr,
 }
 
 // gRPC rule addresses currently supported by the WAF
@@ -453,6 +529,9 @@ var grpcAddresses = []string{
 	grpcServerRequestMetadata,
 	httpClientIPAddr,
 	userIDAddr,
+	serverDBStatementAddr,
+	serverDBSystemAddr,
+	serverIONetURLAddr,
 }
 
 func init() {
@@ -553,3 +632,14 @@ func processHTTPSDKAction(op dyngo.Operation, actions map[string]*sharedsec.Acti
 		}
 	}
 }
+
+// processGRPCSDKAction sends a gRPC monitoring error to the current operation's data listener (created by an SDK call or
+// a RASP-protected call) when one of the actions is blocking, to signal users to interrupt their handler.
+func processGRPCSDKAction(op dyngo.Operation, actions map[string]*sharedsec.Action, actionIds []string) {
+	for _, id := range actionIds {
+		if a, ok := actions[id]; ok && a.Blocking() {
+			code, err := a.GRPC()(map[string][]string{})
+			op.EmitData(grpcsec.NewMonitoringError(err.Error(), code))
+		}
+	}
+}
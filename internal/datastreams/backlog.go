@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package datastreams
+
+import (
+	"sort"
+	"strconv"
+)
+
+// offsetType is the type of a tracked Kafka offset.
+type offsetType int
+
+const (
+	produceOffset offsetType = iota
+	commitOffset
+	highWatermarkOffset
+)
+
+// kafkaOffset is a Kafka offset waiting to be aggregated.
+type kafkaOffset struct {
+	offsetType offsetType
+	group      string // consumer group; empty for produce offsets
+	topic      string
+	partition  int32
+	offset     int64
+	timestamp  int64
+}
+
+// offsetKey identifies a tracked offset within a bucket.
+type offsetKey struct {
+	offsetType offsetType
+	group      string
+	topic      string
+	partition  int32
+}
+
+// backlog returns the backlog reporting the offset of the partition
+// identified by k.
+func (k offsetKey) backlog(offset int64) Backlog {
+	var tags []string
+	if k.group != "" {
+		tags = append(tags, "consumer_group:"+k.group)
+	}
+	tags = append(tags, "partition:"+strconv.Itoa(int(k.partition)), "topic:"+k.topic)
+	switch k.offsetType {
+	case produceOffset:
+		tags = append(tags, "type:kafka_produce")
+	case commitOffset:
+		tags = append(tags, "type:kafka_commit")
+	case highWatermarkOffset:
+		tags = append(tags, "type:kafka_high_watermark")
+	}
+	return Backlog{Tags: tags, Value: offset}
+}
+
+// exportBacklogs returns the backlogs of the given latest offsets, sorted by
+// tags so that payloads are deterministic.
+func exportBacklogs(offsets map[offsetKey]int64) []Backlog {
+	if len(offsets) == 0 {
+		return nil
+	}
+	backlogs := make([]Backlog, 0, len(offsets))
+	for k, offset := range offsets {
+		backlogs = append(backlogs, k.backlog(offset))
+	}
+	sort.Slice(backlogs, func(i, j int) bool {
+		a, b := backlogs[i].Tags, backlogs[j].Tags
+		for n := 0; n < len(a) && n < len(b); n++ {
+			if a[n] != b[n] {
+				return a[n] < b[n]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return backlogs
+}
+
+// TrackKafkaProduceOffset records the offset of a message produced to the
+// given topic partition. Along with the offsets committed by the consumer
+// groups, it is used to compute their backlogs.
+func (p *Processor) TrackKafkaProduceOffset(topic string, partition int32, offset int64) {
+	p.trackKafkaOffset(kafkaOffset{offsetType: produceOffset, topic: topic, partition: partition, offset: offset})
+}
+
+// TrackKafkaCommitOffset records the offset committed by the consumer group on
+// the given topic partition.
+func (p *Processor) TrackKafkaCommitOffset(group, topic string, partition int32, offset int64) {
+	p.trackKafkaOffset(kafkaOffset{offsetType: commitOffset, group: group, topic: topic, partition: partition, offset: offset})
+}
+
+// TrackKafkaHighWatermarkOffset records the high watermark of the given topic
+// partition, as seen by the consumer group. The difference with the committed
+// offset of the group is its backlog.
+func (p *Processor) TrackKafkaHighWatermarkOffset(group, topic string, partition int32, offset int64) {
+	p.trackKafkaOffset(kafkaOffset{offsetType: highWatermarkOffset, group: group, topic: topic, partition: partition, offset: offset})
+}
+
+func (p *Processor) trackKafkaOffset(o kafkaOffset) {
+	o.timestamp = p.timeSource().UnixNano()
+	select {
+	case p.inKafka <- o:
+	default:
+		p.statsd.Count("datadog.datastreams.processor.payloads_dropped", 1, nil, 1)
+	}
+}
+
+// addKafkaOffset aggregates the offset into its bucket, keeping the latest
+// offset of each partition.
+func (p *Processor) addKafkaOffset(o kafkaOffset) {
+	b := p.bucketFor(o.timestamp)
+	k := offsetKey{offsetType: o.offsetType, group: o.group, topic: o.topic, partition: o.partition}
+	if prev, ok := b.offsets[k]; ok && prev > o.offset {
+		return
+	}
+	b.offsets[k] = o.offset
+}
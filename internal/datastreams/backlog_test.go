@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package datastreams
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKafkaOffsets(t *testing.T) {
+	now := time.Unix(1685000000, 0)
+	p, _ := newTestProcessor(func() time.Time { return now })
+
+	p.TrackKafkaProduceOffset("orders", 1, 10)
+	p.TrackKafkaProduceOffset("orders", 1, 12)
+	p.TrackKafkaCommitOffset("workers", "orders", 1, 8)
+	// offsets received out of order don't move the latest one back
+	p.TrackKafkaCommitOffset("workers", "orders", 1, 7)
+	p.TrackKafkaHighWatermarkOffset("workers", "orders", 1, 12)
+	p.drain()
+
+	sp := p.flush(now, withCurrentBucket)
+	require.Len(t, sp.Stats, 1)
+	assert.Empty(t, sp.Stats[0].Stats)
+	assert.Equal(t, []Backlog{
+		{Tags: []string{"consumer_group:workers", "partition:1", "topic:orders", "type:kafka_commit"}, Value: 8},
+		{Tags: []string{"consumer_group:workers", "partition:1", "topic:orders", "type:kafka_high_watermark"}, Value: 12},
+		{Tags: []string{"partition:1", "topic:orders", "type:kafka_produce"}, Value: 12},
+	}, sp.Stats[0].Backlogs)
+}
+
+func TestKafkaOffsetsBuckets(t *testing.T) {
+	now := time.Unix(1685000000, 0)
+	p, _ := newTestProcessor(func() time.Time { return now })
+
+	p.TrackKafkaCommitOffset("workers", "orders", 0, 1)
+	now = now.Add(defaultBucketDuration)
+	p.TrackKafkaCommitOffset("workers", "orders", 0, 2)
+	p.drain()
+
+	sp := p.flush(now, withoutCurrentBucket)
+	require.Len(t, sp.Stats, 1)
+	require.Len(t, sp.Stats[0].Backlogs, 1)
+	assert.Equal(t, int64(1), sp.Stats[0].Backlogs[0].Value)
+
+	sp = p.flush(now, withCurrentBucket)
+	require.Len(t, sp.Stats, 1)
+	require.Len(t, sp.Stats[0].Backlogs, 1)
+	assert.Equal(t, int64(2), sp.Stats[0].Backlogs[0].Value)
+}
@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package datastreams
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"sort"
+	"time"
+)
+
+// Pathway is used to monitor how payloads are sent across different services.
+// An example Pathway would be:
+// service A -- produce to topic A --> service B -- consume from topic A --> service C
+// Every time a payload goes through a checkpoint, the hash of the pathway is
+// updated with the checkpoint's service, env and edge tags, which makes it
+// possible to identify every distinct pathway in the Data Streams map.
+type Pathway struct {
+	// hash is the hash of the current node, of the parent node,
+	// and of the edge that links the parent node to this node.
+	hash uint64
+	// pathwayStart is the start of the first node in the Pathway
+	pathwayStart time.Time
+	// edgeStart is the start of the previous node.
+	edgeStart time.Time
+}
+
+// GetHash gets the hash of a pathway.
+func (p Pathway) GetHash() uint64 {
+	return p.hash
+}
+
+// PathwayStart returns the start timestamp of the pathway
+func (p Pathway) PathwayStart() time.Time {
+	return p.pathwayStart
+}
+
+// EdgeStart returns the start timestamp of the previous checkpoint of the
+// pathway.
+func (p Pathway) EdgeStart() time.Time {
+	return p.edgeStart
+}
+
+// nodeHash returns the hash of a checkpoint, which is identified by the service
+// and env it runs in, and by its edge tags. The order of the edge tags doesn't
+// matter.
+func nodeHash(service, env string, edgeTags []string) uint64 {
+	h := fnv.New64()
+	edgeTags = append([]string(nil), edgeTags...)
+	sort.Strings(edgeTags)
+	h.Write([]byte(service))
+	h.Write([]byte(env))
+	for _, t := range edgeTags {
+		h.Write([]byte(t))
+	}
+	return h.Sum64()
+}
+
+// pathwayHash returns the hash of a pathway going through the node with the
+// given hash, coming from the pathway with the given parent hash.
+func pathwayHash(nodeHash, parentHash uint64) uint64 {
+	b := make([]byte, 16)
+	binary.LittleEndian.PutUint64(b, nodeHash)
+	binary.LittleEndian.PutUint64(b[8:], parentHash)
+	h := fnv.New64()
+	h.Write(b)
+	return h.Sum64()
+}
+
+// errInvalidPathway is returned when decoding a malformed pathway.
+var errInvalidPathway = errors.New("invalid pathway")
+
+// Encode encodes the pathway into its binary representation: the hash, as
+// an 8 bytes little endian integer, followed by the start of the pathway and
+// the start of the edge, as varint encoded milliseconds since epoch.
+func (p Pathway) Encode() []byte {
+	data := make([]byte, 8+2*binary.MaxVarintLen64)
+	binary.LittleEndian.PutUint64(data, p.hash)
+	n := 8
+	n += binary.PutVarint(data[n:], p.pathwayStart.UnixNano()/int64(time.Millisecond))
+	n += binary.PutVarint(data[n:], p.edgeStart.UnixNano()/int64(time.Millisecond))
+	return data[:n]
+}
+
+// Decode decodes a pathway encoded with Encode.
+func Decode(data []byte) (p Pathway, err error) {
+	if len(data) < 8 {
+		return p, errInvalidPathway
+	}
+	p.hash = binary.LittleEndian.Uint64(data)
+	data = data[8:]
+	pathwayStart, n := binary.Varint(data)
+	if n <= 0 {
+		return p, errInvalidPathway
+	}
+	data = data[n:]
+	edgeStart, n := binary.Varint(data)
+	if n <= 0 {
+		return p, errInvalidPathway
+	}
+	p.pathwayStart = time.Unix(0, pathwayStart*int64(time.Millisecond))
+	p.edgeStart = time.Unix(0, edgeStart*int64(time.Millisecond))
+	return p, nil
+}
+
+// EncodeBase64 encodes the pathway into a base64 string, suitable for text
+// carriers such as message headers.
+func (p Pathway) EncodeBase64() string {
+	return base64.StdEncoding.EncodeToString(p.Encode())
+}
+
+// DecodeBase64 decodes a pathway encoded with EncodeBase64.
+func DecodeBase64(s string) (Pathway, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return Pathway{}, errInvalidPathway
+	}
+	return Decode(data)
+}
@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package datastreams
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathwayHash(t *testing.T) {
+	t.Run("edge-tags-order", func(t *testing.T) {
+		h1 := nodeHash("service", "env", []string{"direction:in", "topic:orders", "type:kafka"})
+		h2 := nodeHash("service", "env", []string{"type:kafka", "direction:in", "topic:orders"})
+		assert.Equal(t, h1, h2)
+	})
+
+	t.Run("does-not-mutate-edge-tags", func(t *testing.T) {
+		tags := []string{"type:kafka", "direction:in"}
+		nodeHash("service", "env", tags)
+		assert.Equal(t, []string{"type:kafka", "direction:in"}, tags)
+	})
+
+	t.Run("parent", func(t *testing.T) {
+		n := nodeHash("service", "env", []string{"direction:in"})
+		assert.NotEqual(t, pathwayHash(n, 1), pathwayHash(n, 2))
+		assert.Equal(t, pathwayHash(n, 1), pathwayHash(n, 1))
+	})
+}
+
+func TestPathwayEncoding(t *testing.T) {
+	p := Pathway{
+		hash:         1234,
+		pathwayStart: time.Unix(1685000000, 123*int64(time.Millisecond)),
+		edgeStart:    time.Unix(1685000010, 456*int64(time.Millisecond)),
+	}
+
+	t.Run("binary", func(t *testing.T) {
+		decoded, err := Decode(p.Encode())
+		require.NoError(t, err)
+		assert.Equal(t, p.hash, decoded.hash)
+		assert.True(t, p.pathwayStart.Equal(decoded.pathwayStart))
+		assert.True(t, p.edgeStart.Equal(decoded.edgeStart))
+	})
+
+	t.Run("base64", func(t *testing.T) {
+		decoded, err := DecodeBase64(p.EncodeBase64())
+		require.NoError(t, err)
+		assert.Equal(t, p.hash, decoded.hash)
+		assert.True(t, p.edgeStart.Equal(decoded.edgeStart))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := Decode([]byte{1, 2, 3})
+		assert.Error(t, err)
+		_, err = Decode(p.Encode()[:8])
+		assert.Error(t, err)
+		_, err = DecodeBase64("not base64!")
+		assert.Error(t, err)
+	})
+}
+
+func TestPropagation(t *testing.T) {
+	p := Pathway{hash: 42, pathwayStart: time.Unix(1685000000, 0), edgeStart: time.Unix(1685000001, 0)}
+	carrier := textMapCarrier{}
+
+	InjectToBase64Carrier(context.Background(), carrier)
+	assert.Empty(t, carrier)
+
+	InjectToBase64Carrier(ContextWithPathway(context.Background(), p), carrier)
+	assert.Contains(t, carrier, PropagationKeyBase64)
+
+	extracted, ok := PathwayFromContext(ExtractFromBase64Carrier(context.Background(), carrier))
+	require.True(t, ok)
+	assert.Equal(t, uint64(42), extracted.GetHash())
+
+	_, ok = PathwayFromContext(ExtractFromBase64Carrier(context.Background(), textMapCarrier{PropagationKeyBase64: "invalid"}))
+	assert.False(t, ok)
+}
+
+type textMapCarrier map[string]string
+
+func (c textMapCarrier) Set(key, val string) { c[key] = val }
+
+func (c textMapCarrier) ForeachKey(handler func(key, val string) error) error {
+	for k, v := range c {
+		if err := handler(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+//go:generate msgp -marshal=false -o=payload_msgp.go -tests=false
+
+package datastreams
+
+// StatsPayload stores client computed stats.
+type StatsPayload struct {
+	// Env specifies the env. of the application, as defined by the user.
+	Env string
+	// Service is the service of the application
+	Service string
+	// Stats holds all stats buckets computed within this payload.
+	Stats []StatsBucket
+	// TracerVersion is the version of the tracer
+	TracerVersion string
+	// Lang is the language of the tracer
+	Lang string
+}
+
+// StatsBucket specifies a set of stats computed over a duration.
+type StatsBucket struct {
+	// Start specifies the beginning of this bucket in unix nanoseconds.
+	Start uint64
+	// Duration specifies the duration of this bucket in nanoseconds.
+	Duration uint64
+	// Stats contains a set of statistics computed for the duration of this bucket.
+	Stats []StatsPoint
+	// Schemas contains the schemas sampled at the checkpoints during this bucket.
+	Schemas []SchemaPoint
+	// Backlogs contains the latest offsets of the queues seen during this bucket.
+	Backlogs []Backlog
+}
+
+// StatsPoint contains a set of statistics grouped under various aggregation keys.
+type StatsPoint struct {
+	// These fields indicate the properties under which the stats were aggregated.
+	Service    string
+	EdgeTags   []string
+	Hash       uint64
+	ParentHash uint64
+	// These fields specify the stats for the above aggregation.
+	// those are distributions of latency in seconds, and of payload sizes in bytes.
+	PathwayLatency []byte
+	EdgeLatency    []byte
+	PayloadSize    []byte
+}
+
+// SchemaPoint holds a message schema sampled at a checkpoint.
+type SchemaPoint struct {
+	// Hash is the hash of the pathway node of the checkpoint.
+	Hash uint64
+	// EdgeTags are the edge tags of the checkpoint.
+	EdgeTags []string
+	// Type is the type of the schema, e.g. "avro", "protobuf" or "json".
+	Type string
+	// ID is the fingerprint of the schema definition.
+	ID string
+	// Definition is the schema definition.
+	Definition string
+	// Weight is the number of messages with this schema seen at the
+	// checkpoint since the previous sample.
+	Weight int64
+}
+
+// Backlog represents the latest offset of a queue partition, used to compute
+// the backlogs of the consumers.
+type Backlog struct {
+	// Tags identify the queue partition, and the type of offset, e.g.
+	// "type:kafka_commit".
+	Tags []string
+	// Value is the offset.
+	Value int64
+}
@@ -0,0 +1,555 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package datastreams
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/version"
+
+	"github.com/DataDog/sketches-go/ddsketch"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// defaultBucketDuration specifies the default span of time covered by one
+	// stats bucket.
+	defaultBucketDuration = 10 * time.Second
+	// inChannelSize is the size of the channel buffering the checkpoints
+	// waiting to be aggregated. Checkpoints are dropped when it is full.
+	inChannelSize = 10000
+	// outChannelSize is the size of the channel buffering the payloads
+	// waiting to be sent. Payloads are dropped when it is full.
+	outChannelSize = 16
+)
+
+// statsdClient is the subset of the statsd client methods used by the
+// processor to report its health metrics.
+type statsdClient interface {
+	Incr(name string, tags []string, rate float64) error
+	Count(name string, value int64, tags []string, rate float64) error
+}
+
+// statsPoint is a checkpoint waiting to be aggregated.
+type statsPoint struct {
+	edgeTags       []string
+	hash           uint64
+	parentHash     uint64
+	timestamp      int64
+	pathwayLatency int64
+	edgeLatency    int64
+	payloadSize    int64
+	nodeHash       uint64
+	schema         *Schema
+}
+
+// statsGroup holds the aggregated stats of the checkpoints of a pathway.
+type statsGroup struct {
+	edgeTags       []string
+	hash           uint64
+	parentHash     uint64
+	pathwayLatency *ddsketch.DDSketch
+	edgeLatency    *ddsketch.DDSketch
+	payloadSize    *ddsketch.DDSketch
+}
+
+// bucket holds the stats groups of a time bucket, indexed by pathway hash.
+type bucket struct {
+	points   map[uint64]statsGroup
+	schemas  []SchemaPoint
+	offsets  map[offsetKey]int64 // latest Kafka offsets
+	start    uint64
+	duration uint64
+}
+
+func newBucket(start, duration uint64) *bucket {
+	return &bucket{
+		points:   make(map[uint64]statsGroup),
+		offsets:  make(map[offsetKey]int64),
+		start:    start,
+		duration: duration,
+	}
+}
+
+// export transforms the bucket into a StatsBucket, which is the type sent to
+// the agent.
+func (b *bucket) export(service string) StatsBucket {
+	stats := make([]StatsPoint, 0, len(b.points))
+	for _, s := range b.points {
+		pathwayLatency, err := proto.Marshal(s.pathwayLatency.ToProto())
+		if err != nil {
+			log.Error("Failed to serialize pathway latency sketch: %v", err)
+			continue
+		}
+		edgeLatency, err := proto.Marshal(s.edgeLatency.ToProto())
+		if err != nil {
+			log.Error("Failed to serialize edge latency sketch: %v", err)
+			continue
+		}
+		payloadSize, err := proto.Marshal(s.payloadSize.ToProto())
+		if err != nil {
+			log.Error("Failed to serialize payload size sketch: %v", err)
+			continue
+		}
+		stats = append(stats, StatsPoint{
+			Service:        service,
+			EdgeTags:       s.edgeTags,
+			Hash:           s.hash,
+			ParentHash:     s.parentHash,
+			PathwayLatency: pathwayLatency,
+			EdgeLatency:    edgeLatency,
+			PayloadSize:    payloadSize,
+		})
+	}
+	return StatsBucket{
+		Start:    b.start,
+		Duration: b.duration,
+		Stats:    stats,
+		Schemas:  b.schemas,
+		Backlogs: exportBacklogs(b.offsets),
+	}
+}
+
+// newSketch returns the sketch used to aggregate latencies and payload sizes.
+func newSketch() *ddsketch.DDSketch {
+	// relativeAccuracy is the value accuracy we have on the percentiles. For example, we can
+	// say that p99 is 100ms +- 1ms
+	const relativeAccuracy = 0.01
+	sketch, err := ddsketch.LogUnboundedDenseDDSketch(relativeAccuracy)
+	if err != nil {
+		log.Error("Error when creating ddsketch: %v", err)
+	}
+	return sketch
+}
+
+// CheckpointParams holds the optional parameters of a checkpoint.
+type CheckpointParams struct {
+	// PayloadSize is the size of the message payload, in bytes, going through
+	// the checkpoint.
+	PayloadSize int64
+	// Schema is the schema of the message going through the checkpoint. It
+	// is sampled and reported along with the stats of the checkpoint.
+	Schema *Schema
+}
+
+// Processor aggregates the checkpoints of the Data Streams pathways in time
+// buckets, and periodically flushes them to the agent.
+type Processor struct {
+	in           chan statsPoint
+	inKafka      chan kafkaOffset
+	buckets      map[int64]*bucket
+	schemas      *schemaSampler
+	out          chan pendingPayload // payloads waiting to be sent by the sender goroutine
+	flushRequest chan chan<- struct{}
+	stop         chan struct{}
+	stopped      uint32 // stopped reports whether the processor is stopped (when non-zero)
+	wg           sync.WaitGroup
+	statsd       statsdClient
+	env          string
+	service      string
+	transport    transport
+	timeSource   func() time.Time
+
+	bucketDuration time.Duration // span of time covered by one stats bucket
+	flushInterval  time.Duration // interval at which the completed buckets are flushed
+	maxBuckets     int           // maximum number of buckets waiting to be flushed; 0 means no limit
+}
+
+// pendingPayload is a payload waiting to be sent. done, if not nil, is closed
+// once the payload has been sent.
+type pendingPayload struct {
+	payload StatsPayload
+	done    chan<- struct{}
+}
+
+// A ProcessorOption customizes a Processor.
+type ProcessorOption func(p *Processor)
+
+// WithBucketDuration sets the span of time covered by one stats bucket, which
+// is the resolution of the stats. It defaults to 10 seconds. It is ignored if
+// d isn't positive.
+func WithBucketDuration(d time.Duration) ProcessorOption {
+	return func(p *Processor) {
+		if d > 0 {
+			p.bucketDuration = d
+		}
+	}
+}
+
+// WithFlushInterval sets the interval at which the completed buckets are sent
+// to the agent. It defaults to the bucket duration. It is ignored if d isn't
+// positive.
+func WithFlushInterval(d time.Duration) ProcessorOption {
+	return func(p *Processor) {
+		if d > 0 {
+			p.flushInterval = d
+		}
+	}
+}
+
+// WithMaxBuckets sets the maximum number of buckets kept in memory while
+// waiting to be flushed. When it is reached, the oldest bucket is sent right
+// away. A value of 0, the default, disables the limit.
+func WithMaxBuckets(n int) ProcessorOption {
+	return func(p *Processor) {
+		if n >= 0 {
+			p.maxBuckets = n
+		}
+	}
+}
+
+// WithTransport makes the processor hand its payloads to send, instead of
+// sending them to the agent. It is used by the mock tracer to record them.
+func WithTransport(send func(*StatsPayload) error) ProcessorOption {
+	return func(p *Processor) {
+		if send != nil {
+			p.transport = transportFunc(send)
+		}
+	}
+}
+
+// NewProcessor returns a new processor sending the stats of the given service
+// and env to the agent at agentURL, using httpClient. The processor must be
+// started with Start.
+func NewProcessor(statsd statsdClient, env, service string, agentURL *url.URL, httpClient *http.Client, opts ...ProcessorOption) *Processor {
+	p := &Processor{
+		in:           make(chan statsPoint, inChannelSize),
+		inKafka:      make(chan kafkaOffset, inChannelSize),
+		buckets:      make(map[int64]*bucket),
+		schemas:      newSchemaSampler(),
+		out:          make(chan pendingPayload, outChannelSize),
+		flushRequest: make(chan chan<- struct{}),
+		stopped:      1,
+		statsd:       statsd,
+		env:          env,
+		service:      service,
+		transport:    newHTTPTransport(agentURL, httpClient),
+		timeSource:   time.Now,
+
+		bucketDuration: defaultBucketDuration,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.flushInterval == 0 {
+		p.flushInterval = p.bucketDuration
+	}
+	return p
+}
+
+// alignTs returns the provided timestamp truncated to the bucket size.
+// It gives us the start time of the time bucket in which such timestamp falls.
+func alignTs(ts, bucketSize int64) int64 { return ts - ts%bucketSize }
+
+// bucketFor returns the bucket in which the timestamp ts falls, creating it if
+// needed.
+func (p *Processor) bucketFor(ts int64) *bucket {
+	btime := alignTs(ts, p.bucketDuration.Nanoseconds())
+	b, ok := p.buckets[btime]
+	if !ok {
+		if p.maxBuckets > 0 && len(p.buckets) >= p.maxBuckets {
+			p.flushOldest()
+		}
+		b = newBucket(uint64(btime), uint64(p.bucketDuration.Nanoseconds()))
+		p.buckets[btime] = b
+	}
+	return b
+}
+
+func (p *Processor) add(point statsPoint) {
+	b := p.bucketFor(point.timestamp)
+	group, ok := b.points[point.hash]
+	if !ok {
+		group = statsGroup{
+			edgeTags:       point.edgeTags,
+			hash:           point.hash,
+			parentHash:     point.parentHash,
+			pathwayLatency: newSketch(),
+			edgeLatency:    newSketch(),
+			payloadSize:    newSketch(),
+		}
+		b.points[point.hash] = group
+	}
+	if err := group.pathwayLatency.Add(toSeconds(point.pathwayLatency)); err != nil {
+		log.Error("Failed to add pathway latency: %v", err)
+	}
+	if err := group.edgeLatency.Add(toSeconds(point.edgeLatency)); err != nil {
+		log.Error("Failed to add edge latency: %v", err)
+	}
+	if err := group.payloadSize.Add(float64(point.payloadSize)); err != nil {
+		log.Error("Failed to add payload size: %v", err)
+	}
+	if point.schema != nil {
+		if sp, ok := p.schemas.sample(point.nodeHash, point.edgeTags, *point.schema, point.timestamp); ok {
+			b.schemas = append(b.schemas, sp)
+		}
+	}
+}
+
+// toSeconds converts a duration in nanoseconds into seconds, ignoring negative
+// durations which can be caused by clock skews between services.
+func toSeconds(ns int64) float64 {
+	if ns < 0 {
+		return 0
+	}
+	return float64(ns) / float64(time.Second)
+}
+
+// Start starts the processor. A started processor needs to be stopped in order
+// to gracefully shut down, using Stop.
+func (p *Processor) Start() {
+	if atomic.SwapUint32(&p.stopped, 0) == 0 {
+		// already running
+		log.Warn("(*Processor).Start called more than once. This is likely a programming error.")
+		return
+	}
+	p.stop = make(chan struct{})
+	senderStop := make(chan struct{})
+	p.wg.Add(2)
+	go func() {
+		defer p.wg.Done()
+		p.runSender(senderStop)
+	}()
+	go func() {
+		defer p.wg.Done()
+		defer close(senderStop)
+		tick := time.NewTicker(p.flushInterval)
+		defer tick.Stop()
+		p.run(tick.C)
+	}()
+}
+
+// run aggregates the incoming checkpoints and flushes the buckets to the agent
+// on every tick, until the processor is stopped. The payloads are handed to
+// the sender goroutine, so that a slow agent doesn't hold up the aggregation.
+func (p *Processor) run(tick <-chan time.Time) {
+	for {
+		select {
+		case s := <-p.in:
+			p.add(s)
+		case o := <-p.inKafka:
+			p.addKafkaOffset(o)
+		case now := <-tick:
+			p.enqueue(p.flush(now, withoutCurrentBucket))
+		case done := <-p.flushRequest:
+			p.drain()
+			p.out <- pendingPayload{payload: p.flush(p.timeSource(), withCurrentBucket), done: done}
+		case <-p.stop:
+			p.drain()
+			done := make(chan struct{})
+			p.out <- pendingPayload{payload: p.flush(p.timeSource(), withCurrentBucket), done: done}
+			// the payloads are sent in order, so all of them have been
+			// sent once the last one is.
+			<-done
+			return
+		}
+	}
+}
+
+// runSender sends the payloads queued by the run loop to the agent, until stop
+// is closed.
+func (p *Processor) runSender(stop <-chan struct{}) {
+	for {
+		select {
+		case pp := <-p.out:
+			p.sendToAgent(pp.payload)
+			if pp.done != nil {
+				close(pp.done)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// enqueue queues payload to be sent by the sender goroutine. The payload is
+// dropped if too many payloads are already waiting to be sent.
+func (p *Processor) enqueue(payload StatsPayload) {
+	if len(payload.Stats) == 0 {
+		// nothing to flush
+		return
+	}
+	select {
+	case p.out <- pendingPayload{payload: payload}:
+	default:
+		p.statsd.Incr("datadog.datastreams.processor.payloads_dropped", nil, 1)
+		log.Error("Dropped a data streams stats payload: too many payloads are waiting to be sent")
+	}
+}
+
+// drain aggregates the checkpoints and offsets buffered in the in channels.
+func (p *Processor) drain() {
+	for {
+		select {
+		case s := <-p.in:
+			p.add(s)
+		case o := <-p.inKafka:
+			p.addKafkaOffset(o)
+		default:
+			return
+		}
+	}
+}
+
+// Stop stops the processor, flushing all the pending stats, and blocks until
+// the operation completes.
+func (p *Processor) Stop() {
+	if atomic.SwapUint32(&p.stopped, 1) > 0 {
+		return
+	}
+	close(p.stop)
+	p.wg.Wait()
+}
+
+// Flush sends all the pending stats, including the ones of the current bucket,
+// to the agent, and blocks until the operation completes. It is a no-op if the
+// processor is not started.
+func (p *Processor) Flush() {
+	if atomic.LoadUint32(&p.stopped) > 0 {
+		return
+	}
+	done := make(chan struct{})
+	select {
+	case p.flushRequest <- done:
+		<-done
+	case <-p.stop:
+	}
+}
+
+const (
+	withCurrentBucket    = true
+	withoutCurrentBucket = false
+)
+
+// flush exports the buckets which ended before now, or all of them if
+// includeCurrent is true, into a payload.
+func (p *Processor) flush(now time.Time, includeCurrent bool) StatsPayload {
+	nowNano := now.UnixNano()
+	sp := p.newPayload(len(p.buckets))
+	for ts, b := range p.buckets {
+		if !includeCurrent && ts > nowNano-p.bucketDuration.Nanoseconds() {
+			// do not flush the current bucket
+			continue
+		}
+		sp.Stats = append(sp.Stats, b.export(p.service))
+		delete(p.buckets, ts)
+	}
+	return sp
+}
+
+// flushOldest queues the oldest bucket to be sent to the agent, to make room
+// for a new one.
+func (p *Processor) flushOldest() {
+	var (
+		oldest int64
+		found  bool
+	)
+	for ts := range p.buckets {
+		if !found || ts < oldest {
+			oldest, found = ts, true
+		}
+	}
+	if !found {
+		return
+	}
+	sp := p.newPayload(1)
+	sp.Stats = append(sp.Stats, p.buckets[oldest].export(p.service))
+	delete(p.buckets, oldest)
+	p.enqueue(sp)
+}
+
+// newPayload returns an empty payload with room for n buckets.
+func (p *Processor) newPayload(n int) StatsPayload {
+	return StatsPayload{
+		Service:       p.service,
+		Env:           p.env,
+		Lang:          "go",
+		TracerVersion: version.Tag,
+		Stats:         make([]StatsBucket, 0, n),
+	}
+}
+
+func (p *Processor) sendToAgent(payload StatsPayload) {
+	if len(payload.Stats) == 0 {
+		// nothing to flush
+		return
+	}
+	p.statsd.Incr("datadog.datastreams.processor.flush", nil, 1)
+	if err := p.transport.sendPipelineStats(&payload); err != nil {
+		p.statsd.Incr("datadog.datastreams.processor.flush_errors", nil, 1)
+		log.Error("Error sending data streams stats payload: %v", err)
+	}
+}
+
+// SetCheckpoint sets a checkpoint on the pathway found in ctx, or on a new
+// pathway if there is none, and returns a context holding the updated
+// pathway. The edge tags identify the checkpoint, e.g. "direction:out",
+// "topic:orders" and "type:kafka".
+func (p *Processor) SetCheckpoint(ctx context.Context, edgeTags ...string) context.Context {
+	return p.SetCheckpointWithParams(ctx, CheckpointParams{}, edgeTags...)
+}
+
+// SetCheckpointWithParams is like SetCheckpoint, but also records the
+// optional parameters of the checkpoint, such as the payload size.
+func (p *Processor) SetCheckpointWithParams(ctx context.Context, params CheckpointParams, edgeTags ...string) context.Context {
+	now := p.timeSource()
+	var parentHash uint64
+	pathwayStart, edgeStart := now, now
+	if parent, ok := PathwayFromContext(ctx); ok {
+		parentHash = parent.GetHash()
+		pathwayStart = parent.PathwayStart()
+		edgeStart = parent.EdgeStart()
+	}
+	node := nodeHash(p.service, p.env, edgeTags)
+	child := Pathway{
+		hash:         pathwayHash(node, parentHash),
+		pathwayStart: pathwayStart,
+		edgeStart:    now,
+	}
+	select {
+	case p.in <- statsPoint{
+		edgeTags:       edgeTags,
+		hash:           child.hash,
+		parentHash:     parentHash,
+		timestamp:      now.UnixNano(),
+		pathwayLatency: now.Sub(pathwayStart).Nanoseconds(),
+		edgeLatency:    now.Sub(edgeStart).Nanoseconds(),
+		payloadSize:    params.PayloadSize,
+		nodeHash:       node,
+		schema:         params.Schema,
+	}:
+	default:
+		p.statsd.Count("datadog.datastreams.processor.payloads_dropped", 1, nil, 1)
+	}
+	return ContextWithPathway(ctx, child)
+}
+
+var (
+	mu              sync.RWMutex
+	globalProcessor *Processor
+)
+
+// SetGlobalProcessor sets the processor used by the integrations and by the
+// public datastreams package. A nil processor disables Data Streams Monitoring.
+func SetGlobalProcessor(p *Processor) {
+	mu.Lock()
+	defer mu.Unlock()
+	globalProcessor = p
+}
+
+// GetGlobalProcessor returns the global processor, or nil if Data Streams
+// Monitoring is disabled.
+func GetGlobalProcessor() *Processor {
+	mu.RLock()
+	defer mu.RUnlock()
+	return globalProcessor
+}
@@ -0,0 +1,250 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package datastreams
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTransport struct {
+	mu       sync.Mutex
+	payloads []StatsPayload
+}
+
+func (t *fakeTransport) sendPipelineStats(p *StatsPayload) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.payloads = append(t.payloads, *p)
+	return nil
+}
+
+func (t *fakeTransport) points() []StatsPoint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var points []StatsPoint
+	for _, p := range t.payloads {
+		for _, b := range p.Stats {
+			points = append(points, b.Stats...)
+		}
+	}
+	return points
+}
+
+func newTestProcessor(now func() time.Time) (*Processor, *fakeTransport) {
+	u, _ := url.Parse("http://localhost:8126")
+	p := NewProcessor(&statsd.NoOpClient{}, "env", "service", u, nil)
+	tr := &fakeTransport{}
+	p.transport = tr
+	p.timeSource = now
+	return p, tr
+}
+
+func TestProcessor(t *testing.T) {
+	now := time.Unix(1685000000, 0)
+	p, _ := newTestProcessor(func() time.Time { return now })
+
+	ctx := p.SetCheckpointWithParams(context.Background(), CheckpointParams{PayloadSize: 100}, "direction:out", "topic:orders", "type:kafka")
+	produced, ok := PathwayFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, pathwayHash(nodeHash("service", "env", []string{"direction:out", "topic:orders", "type:kafka"}), 0), produced.GetHash())
+
+	now = now.Add(2 * time.Second)
+	ctx = p.SetCheckpoint(ctx, "direction:in", "topic:orders", "type:kafka")
+	consumed, ok := PathwayFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, pathwayHash(nodeHash("service", "env", []string{"direction:in", "topic:orders", "type:kafka"}), produced.GetHash()), consumed.GetHash())
+	assert.True(t, consumed.PathwayStart().Equal(produced.PathwayStart()))
+	assert.True(t, consumed.EdgeStart().Equal(now))
+
+	p.drain()
+	sp := p.flush(now, withCurrentBucket)
+	require.Len(t, sp.Stats, 1)
+	points := sp.Stats[0].Stats
+	require.Len(t, points, 2)
+	byHash := map[uint64]StatsPoint{}
+	for _, s := range points {
+		byHash[s.Hash] = s
+		assert.Equal(t, "service", s.Service)
+		assert.NotEmpty(t, s.PathwayLatency)
+		assert.NotEmpty(t, s.EdgeLatency)
+		assert.NotEmpty(t, s.PayloadSize)
+	}
+	assert.Equal(t, uint64(0), byHash[produced.GetHash()].ParentHash)
+	assert.Equal(t, produced.GetHash(), byHash[consumed.GetHash()].ParentHash)
+	assert.Equal(t, []string{"direction:in", "topic:orders", "type:kafka"}, byHash[consumed.GetHash()].EdgeTags)
+}
+
+func TestProcessorFlush(t *testing.T) {
+	now := time.Unix(1685000000, 0)
+	p, tr := newTestProcessor(func() time.Time { return now })
+	p.Start()
+
+	p.SetCheckpoint(context.Background(), "direction:out", "type:kafka")
+	p.Flush()
+	assert.Len(t, tr.points(), 1)
+
+	p.SetCheckpoint(context.Background(), "direction:in", "type:kafka")
+	p.Stop()
+	assert.Len(t, tr.points(), 2)
+
+	// flushing a stopped processor is a no-op
+	p.Flush()
+}
+
+func TestProcessorFlushBuckets(t *testing.T) {
+	now := time.Unix(1685000000, 0)
+	p, _ := newTestProcessor(func() time.Time { return now })
+
+	p.add(statsPoint{hash: 1, timestamp: now.UnixNano()})
+	p.add(statsPoint{hash: 1, timestamp: now.UnixNano()})
+	p.add(statsPoint{hash: 2, timestamp: now.Add(defaultBucketDuration).UnixNano()})
+
+	// the current bucket is only flushed when requested
+	sp := p.flush(now.Add(defaultBucketDuration), withoutCurrentBucket)
+	require.Len(t, sp.Stats, 1)
+	require.Len(t, sp.Stats[0].Stats, 1)
+	assert.Equal(t, uint64(1), sp.Stats[0].Stats[0].Hash)
+	assert.Equal(t, uint64(defaultBucketDuration.Nanoseconds()), sp.Stats[0].Duration)
+	assert.Equal(t, "go", sp.Lang)
+
+	sp = p.flush(now.Add(defaultBucketDuration), withCurrentBucket)
+	require.Len(t, sp.Stats, 1)
+	assert.Equal(t, uint64(2), sp.Stats[0].Stats[0].Hash)
+	assert.Empty(t, p.buckets)
+}
+
+func TestProcessorOptions(t *testing.T) {
+	u, _ := url.Parse("http://localhost:8126")
+	t.Run("defaults", func(t *testing.T) {
+		p := NewProcessor(&statsd.NoOpClient{}, "env", "service", u, nil)
+		assert.Equal(t, defaultBucketDuration, p.bucketDuration)
+		assert.Equal(t, defaultBucketDuration, p.flushInterval)
+		assert.Equal(t, 0, p.maxBuckets)
+	})
+
+	t.Run("bucket-duration", func(t *testing.T) {
+		p := NewProcessor(&statsd.NoOpClient{}, "env", "service", u, nil, WithBucketDuration(time.Second))
+		assert.Equal(t, time.Second, p.bucketDuration)
+		// the flush interval defaults to the bucket duration
+		assert.Equal(t, time.Second, p.flushInterval)
+	})
+
+	t.Run("flush-interval", func(t *testing.T) {
+		p := NewProcessor(&statsd.NoOpClient{}, "env", "service", u, nil, WithFlushInterval(time.Minute))
+		assert.Equal(t, defaultBucketDuration, p.bucketDuration)
+		assert.Equal(t, time.Minute, p.flushInterval)
+	})
+
+	t.Run("transport", func(t *testing.T) {
+		var sent []StatsPayload
+		p := NewProcessor(&statsd.NoOpClient{}, "env", "service", u, nil, WithTransport(func(sp *StatsPayload) error {
+			sent = append(sent, *sp)
+			return nil
+		}))
+		p.Start()
+		p.SetCheckpoint(context.Background(), "direction:out", "type:kafka")
+		p.Stop()
+		require.Len(t, sent, 1)
+		assert.Equal(t, "service", sent[0].Service)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		p := NewProcessor(&statsd.NoOpClient{}, "env", "service", u, nil, WithBucketDuration(0), WithFlushInterval(-1), WithMaxBuckets(-1))
+		assert.Equal(t, defaultBucketDuration, p.bucketDuration)
+		assert.Equal(t, defaultBucketDuration, p.flushInterval)
+		assert.Equal(t, 0, p.maxBuckets)
+	})
+}
+
+func TestProcessorBucketDuration(t *testing.T) {
+	now := time.Unix(1685000000, 0)
+	p, _ := newTestProcessor(func() time.Time { return now })
+	p.bucketDuration = time.Second
+
+	p.add(statsPoint{hash: 1, timestamp: now.UnixNano()})
+	p.add(statsPoint{hash: 2, timestamp: now.Add(time.Second).UnixNano()})
+
+	sp := p.flush(now.Add(time.Second), withoutCurrentBucket)
+	require.Len(t, sp.Stats, 1)
+	assert.Equal(t, uint64(time.Second.Nanoseconds()), sp.Stats[0].Duration)
+	assert.Equal(t, uint64(1), sp.Stats[0].Stats[0].Hash)
+}
+
+func TestProcessorMaxBuckets(t *testing.T) {
+	now := time.Unix(1685000000, 0)
+	p, _ := newTestProcessor(func() time.Time { return now })
+	p.maxBuckets = 2
+
+	p.add(statsPoint{hash: 1, timestamp: now.UnixNano()})
+	p.add(statsPoint{hash: 2, timestamp: now.Add(defaultBucketDuration).UnixNano()})
+	assert.Empty(t, p.out)
+
+	// the oldest bucket is queued to make room for the new one
+	p.add(statsPoint{hash: 3, timestamp: now.Add(2 * defaultBucketDuration).UnixNano()})
+	require.Len(t, p.out, 1)
+	sp := (<-p.out).payload
+	require.Len(t, sp.Stats, 1)
+	assert.Equal(t, uint64(1), sp.Stats[0].Stats[0].Hash)
+	assert.Len(t, p.buckets, 2)
+}
+
+func TestProcessorEnqueue(t *testing.T) {
+	p, _ := newTestProcessor(time.Now)
+	payload := p.newPayload(1)
+	p.enqueue(payload)
+	assert.Empty(t, p.out, "empty payloads are not sent")
+
+	payload.Stats = append(payload.Stats, StatsBucket{Start: 1})
+	for i := 0; i < outChannelSize+1; i++ {
+		p.enqueue(payload)
+	}
+	// the payloads which don't fit in the queue are dropped
+	assert.Len(t, p.out, outChannelSize)
+}
+
+func TestProcessorSlowTransport(t *testing.T) {
+	p, _ := newTestProcessor(time.Now)
+	sending := make(chan struct{}, 1)
+	release := make(chan struct{})
+	p.transport = transportFunc(func(*StatsPayload) error {
+		select {
+		case sending <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil
+	})
+	p.Start()
+
+	p.SetCheckpoint(context.Background(), "direction:out", "type:kafka")
+	flushed := make(chan struct{})
+	go func() {
+		p.Flush()
+		close(flushed)
+	}()
+	<-sending
+
+	// the checkpoints are still aggregated while the payload is being sent
+	p.SetCheckpoint(context.Background(), "direction:in", "type:kafka")
+	assert.Eventually(t, func() bool { return len(p.in) == 0 }, time.Second, time.Millisecond)
+	select {
+	case <-flushed:
+		t.Fatal("Flush returned before the payload was sent")
+	default:
+	}
+
+	close(release)
+	<-flushed
+	p.Stop()
+}
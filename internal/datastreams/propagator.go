@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package datastreams
+
+import (
+	"context"
+)
+
+// PropagationKeyBase64 is the key used to propagate the base64 encoded pathway
+// in text carriers, such as message headers.
+const PropagationKeyBase64 = "dd-pathway-ctx-base64"
+
+// TextMapWriter allows setting key/value pairs of strings on the underlying
+// data structure. Carriers implementing TextMapWriter are compatible to be
+// used with Data Streams propagation.
+type TextMapWriter interface {
+	// Set sets the given key/value pair.
+	Set(key, val string)
+}
+
+// TextMapReader allows iterating over sets of key/value pairs. Carriers
+// implementing TextMapReader are compatible to be used with Data Streams
+// propagation.
+type TextMapReader interface {
+	// ForeachKey iterates over all keys that exist in the underlying
+	// carrier. It takes a callback function which will be called
+	// using all key/value pairs as arguments. ForeachKey will return
+	// the first error returned by the handler.
+	ForeachKey(handler func(key, val string) error) error
+}
+
+// InjectToBase64Carrier injects the pathway found in ctx, if any, into the
+// carrier.
+func InjectToBase64Carrier(ctx context.Context, carrier TextMapWriter) {
+	p, ok := PathwayFromContext(ctx)
+	if !ok {
+		return
+	}
+	carrier.Set(PropagationKeyBase64, p.EncodeBase64())
+}
+
+// ExtractFromBase64Carrier returns a copy of ctx holding the pathway found in
+// the carrier. ctx is returned unchanged if the carrier holds no valid
+// pathway.
+func ExtractFromBase64Carrier(ctx context.Context, carrier TextMapReader) context.Context {
+	var (
+		p     Pathway
+		found bool
+	)
+	carrier.ForeachKey(func(key, val string) error {
+		if key != PropagationKeyBase64 {
+			return nil
+		}
+		var err error
+		if p, err = DecodeBase64(val); err == nil {
+			found = true
+		}
+		return nil
+	})
+	if !found {
+		return ctx
+	}
+	return ContextWithPathway(ctx, p)
+}
@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package datastreams
+
+import (
+	"hash/fnv"
+	"strconv"
+	"time"
+)
+
+// schemaSampleInterval is the minimum time between two samples of the same
+// schema at the same checkpoint.
+const schemaSampleInterval = 30 * time.Second
+
+// Schema types.
+const (
+	SchemaTypeAvro     = "avro"
+	SchemaTypeProtobuf = "protobuf"
+	SchemaTypeJSON     = "json"
+)
+
+// Schema describes the schema of the messages going through a checkpoint.
+type Schema struct {
+	// Type is the type of the schema, one of the SchemaType constants.
+	Type string
+	// Definition is the schema definition, e.g. the JSON representation of an
+	// Avro schema, or the serialized file descriptor set of a Protobuf message.
+	Definition string
+}
+
+// schemaID returns the fingerprint of a schema definition.
+func schemaID(definition string) string {
+	h := fnv.New64()
+	h.Write([]byte(definition))
+	return strconv.FormatUint(h.Sum64(), 10)
+}
+
+// schemaKey identifies a schema seen at a checkpoint.
+type schemaKey struct {
+	node uint64 // hash of the pathway node of the checkpoint
+	id   string // fingerprint of the schema
+}
+
+// schemaSample tracks the sampling of a schema seen at a checkpoint.
+type schemaSample struct {
+	lastSampled int64 // unix nanoseconds of the last sample
+	weight      int64 // messages seen since the last sample
+}
+
+// schemaSampler samples the schemas seen at the checkpoints, so that each of
+// them is reported at most once per schemaSampleInterval, along with the
+// number of messages it represents. It is not safe for concurrent use.
+type schemaSampler struct {
+	samples map[schemaKey]*schemaSample
+}
+
+func newSchemaSampler() *schemaSampler {
+	return &schemaSampler{samples: make(map[schemaKey]*schemaSample)}
+}
+
+// sample records a message with the given schema going through the checkpoint
+// identified by node at ts, in unix nanoseconds. It returns the schema point
+// to report and true if the schema is sampled.
+func (s *schemaSampler) sample(node uint64, edgeTags []string, schema Schema, ts int64) (SchemaPoint, bool) {
+	k := schemaKey{node: node, id: schemaID(schema.Definition)}
+	ss, ok := s.samples[k]
+	if !ok {
+		ss = &schemaSample{}
+		s.samples[k] = ss
+	}
+	ss.weight++
+	if ok && ts-ss.lastSampled < schemaSampleInterval.Nanoseconds() {
+		return SchemaPoint{}, false
+	}
+	p := SchemaPoint{
+		Hash:       node,
+		EdgeTags:   edgeTags,
+		Type:       schema.Type,
+		ID:         k.id,
+		Definition: schema.Definition,
+		Weight:     ss.weight,
+	}
+	ss.lastSampled = ts
+	ss.weight = 0
+	return p, true
+}
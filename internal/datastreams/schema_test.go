@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package datastreams
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaSampler(t *testing.T) {
+	s := newSchemaSampler()
+	schema := Schema{Type: SchemaTypeJSON, Definition: `{"type":"object"}`}
+	now := time.Unix(1685000000, 0)
+
+	// the first occurrence is always sampled
+	sp, ok := s.sample(1, []string{"type:kafka"}, schema, now.UnixNano())
+	require.True(t, ok)
+	assert.Equal(t, uint64(1), sp.Hash)
+	assert.Equal(t, schemaID(schema.Definition), sp.ID)
+	assert.Equal(t, SchemaTypeJSON, sp.Type)
+	assert.Equal(t, int64(1), sp.Weight)
+
+	// the following ones only once per interval
+	_, ok = s.sample(1, nil, schema, now.Add(time.Second).UnixNano())
+	assert.False(t, ok)
+	sp, ok = s.sample(1, nil, schema, now.Add(schemaSampleInterval).UnixNano())
+	require.True(t, ok)
+	assert.Equal(t, int64(2), sp.Weight)
+
+	// schemas are sampled independently per checkpoint and per definition
+	_, ok = s.sample(2, nil, schema, now.Add(schemaSampleInterval).UnixNano())
+	assert.True(t, ok)
+	_, ok = s.sample(1, nil, Schema{Type: SchemaTypeJSON, Definition: `{}`}, now.Add(schemaSampleInterval).UnixNano())
+	assert.True(t, ok)
+}
+
+func TestSchemaID(t *testing.T) {
+	assert.Equal(t, schemaID("a"), schemaID("a"))
+	assert.NotEqual(t, schemaID("a"), schemaID("b"))
+}
+
+func TestProcessorSchemas(t *testing.T) {
+	now := time.Unix(1685000000, 0)
+	p, _ := newTestProcessor(func() time.Time { return now })
+	params := CheckpointParams{Schema: &Schema{Type: SchemaTypeAvro, Definition: `{"type":"record"}`}}
+
+	p.SetCheckpointWithParams(context.Background(), params, "direction:out", "topic:orders", "type:kafka")
+	p.SetCheckpointWithParams(context.Background(), params, "direction:out", "topic:orders", "type:kafka")
+	p.SetCheckpoint(context.Background(), "direction:in", "topic:orders", "type:kafka")
+	p.drain()
+	sp := p.flush(now, withCurrentBucket)
+	require.Len(t, sp.Stats, 1)
+	schemas := sp.Stats[0].Schemas
+	require.Len(t, schemas, 1)
+	assert.Equal(t, nodeHash("service", "env", []string{"direction:out", "topic:orders", "type:kafka"}), schemas[0].Hash)
+	assert.Equal(t, []string{"direction:out", "topic:orders", "type:kafka"}, schemas[0].EdgeTags)
+	assert.Equal(t, SchemaTypeAvro, schemas[0].Type)
+	assert.Equal(t, `{"type":"record"}`, schemas[0].Definition)
+}
@@ -0,0 +1,239 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package datastreams
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/version"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// transport sends the stats payloads to the agent.
+type transport interface {
+	sendPipelineStats(p *StatsPayload) error
+}
+
+// transportFunc adapts a function to the transport interface.
+type transportFunc func(p *StatsPayload) error
+
+func (f transportFunc) sendPipelineStats(p *StatsPayload) error { return f(p) }
+
+const defaultHTTPTimeout = 2 * time.Second // defines the current timeout before giving up with the send process
+
+var defaultDialer = &net.Dialer{
+	Timeout:   30 * time.Second,
+	KeepAlive: 30 * time.Second,
+	DualStack: true,
+}
+
+var defaultClient = &http.Client{
+	// We copy the transport to avoid using the default one, as it might be
+	// augmented with tracing and we don't want these calls to be recorded.
+	// See https://golang.org/pkg/net/http/#DefaultTransport .
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           defaultDialer.DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	},
+	Timeout: defaultHTTPTimeout,
+}
+
+const (
+	// maxRetries is the number of times a payload is resent to an endpoint
+	// after a retriable failure.
+	maxRetries = 2
+	// retryBackoff is the delay before the first retry. It doubles with each
+	// following retry.
+	retryBackoff = 100 * time.Millisecond
+)
+
+type httpTransport struct {
+	url     string            // the delivery URL for stats
+	client  *http.Client      // the HTTP client used in the POST
+	headers map[string]string // the Transport headers
+
+	// agentlessURL is the URL of the intake the stats are sent to when they
+	// can't be delivered to the agent. It is empty unless agentless
+	// failover is enabled.
+	agentlessURL string
+	apiKey       string
+	sleep        func(time.Duration) // sleep waits between retries; replaced in tests
+}
+
+// newHTTPTransport returns a transport sending the stats payloads to the
+// agent at agentURL, using client. The default client is used if it is nil,
+// or a client dialing the socket if agentURL is a unix domain socket URL.
+// When the DD_DATA_STREAMS_AGENTLESS_ENABLED environment variable is true and
+// DD_API_KEY is set, payloads which can't be delivered to the agent are sent
+// directly to the intake of DD_SITE instead.
+func newHTTPTransport(agentURL *url.URL, client *http.Client) *httpTransport {
+	defaultHeaders := map[string]string{
+		"Datadog-Meta-Lang":             "go",
+		"Datadog-Meta-Lang-Version":     strings.TrimPrefix(runtime.Version(), "go"),
+		"Datadog-Meta-Lang-Interpreter": runtime.Compiler + "-" + runtime.GOARCH + "-" + runtime.GOOS,
+		"Datadog-Meta-Tracer-Version":   version.Tag,
+		"Content-Type":                  "application/msgpack",
+		"Content-Encoding":              "gzip",
+	}
+	if cid := internal.ContainerID(); cid != "" {
+		defaultHeaders["Datadog-Container-ID"] = cid
+	}
+	if agentURL.Scheme == "unix" {
+		if client == nil {
+			client = udsClient(agentURL.Path)
+		}
+		agentURL = &url.URL{
+			Scheme: "http",
+			Host:   fmt.Sprintf("UDS_%s", strings.NewReplacer(":", "_", "/", "_", `\`, "_").Replace(agentURL.Path)),
+		}
+	}
+	if client == nil {
+		client = defaultClient
+	}
+	t := &httpTransport{
+		url:     fmt.Sprintf("%s/v0.1/pipeline_stats", agentURL.String()),
+		client:  client,
+		headers: defaultHeaders,
+		sleep:   time.Sleep,
+	}
+	if !internal.BoolEnv("DD_DATA_STREAMS_AGENTLESS_ENABLED", false) {
+		return t
+	}
+	if apiKey := os.Getenv("DD_API_KEY"); apiKey != "" {
+		site := os.Getenv("DD_SITE")
+		if site == "" {
+			site = "datadoghq.com"
+		}
+		t.apiKey = apiKey
+		t.agentlessURL = fmt.Sprintf("https://trace.agent.%s/api/v0.1/pipeline_stats", site)
+	}
+	return t
+}
+
+// udsClient returns a new http.Client which connects using the given UDS socket path.
+func udsClient(socketPath string) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+				return defaultDialer.DialContext(ctx, "unix", (&net.UnixAddr{
+					Name: socketPath,
+					Net:  "unix",
+				}).String())
+			},
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+		Timeout: defaultHTTPTimeout,
+	}
+}
+
+func (t *httpTransport) sendPipelineStats(p *StatsPayload) error {
+	var buf bytes.Buffer
+	gzipWriter, err := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+	if err != nil {
+		return err
+	}
+	if err := msgp.Encode(gzipWriter, p); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+	body := buf.Bytes()
+	err = t.sendWithRetry(t.url, t.client, body, nil)
+	if err == nil || t.agentlessURL == "" {
+		return err
+	}
+	log.Debug("Failed to send data streams stats to the agent (%v), sending them to %s", err, t.agentlessURL)
+	// the agent client may dial a unix domain socket, so use the default one
+	return t.sendWithRetry(t.agentlessURL, defaultClient, body, map[string]string{"DD-API-KEY": t.apiKey})
+}
+
+// sendWithRetry posts body to endpoint, retrying with an exponential backoff as
+// long as the failures are retriable.
+func (t *httpTransport) sendWithRetry(endpoint string, client *http.Client, body []byte, headers map[string]string) error {
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := t.post(endpoint, client, body, headers)
+		if err == nil || attempt >= maxRetries || !isRetriable(err) {
+			return err
+		}
+		t.sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// statusError is returned by post when the endpoint replies with an error
+// status code.
+type statusError struct {
+	code int
+	msg  string
+}
+
+func (e *statusError) Error() string {
+	txt := http.StatusText(e.code)
+	if e.msg != "" {
+		return fmt.Sprintf("%s (Status: %s)", e.msg, txt)
+	}
+	return txt
+}
+
+// isRetriable reports whether a request which failed with err may succeed if
+// sent again. Network errors, rate limiting and server errors are retriable.
+func isRetriable(err error) bool {
+	var se *statusError
+	if !errors.As(err, &se) {
+		return true
+	}
+	return se.code == http.StatusTooManyRequests || se.code >= 500
+}
+
+func (t *httpTransport) post(endpoint string, client *http.Client, body []byte, headers map[string]string) error {
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for header, value := range t.headers {
+		req.Header.Set(header, value)
+	}
+	for header, value := range headers {
+		req.Header.Set(header, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if code := resp.StatusCode; code >= 400 {
+		// error, check the body for context information and
+		// return a nice error.
+		msg := make([]byte, 1000)
+		n, _ := resp.Body.Read(msg)
+		return &statusError{code: code, msg: string(msg[:n])}
+	}
+	return nil
+}
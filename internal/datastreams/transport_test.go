@@ -0,0 +1,146 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package datastreams
+
+import (
+	"compress/gzip"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+)
+
+var testPayload = StatsPayload{
+	Env:     "env",
+	Service: "service",
+	Stats: []StatsBucket{{
+		Start:    1,
+		Duration: 2,
+		Stats:    []StatsPoint{{Service: "service", EdgeTags: []string{"type:kafka"}, Hash: 3, ParentHash: 4}},
+	}},
+}
+
+func TestHTTPTransport(t *testing.T) {
+	t.Setenv("DD_API_KEY", "")
+	var got StatsPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v0.1/pipeline_stats", r.URL.Path)
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "application/msgpack", r.Header.Get("Content-Type"))
+		gr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, msgp.Decode(gr, &got))
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	payload := testPayload
+	require.NoError(t, newHTTPTransport(u, srv.Client()).sendPipelineStats(&payload))
+	assert.Equal(t, payload.Stats[0].Stats[0].EdgeTags, got.Stats[0].Stats[0].EdgeTags)
+	assert.Equal(t, uint64(3), got.Stats[0].Stats[0].Hash)
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("bad payload"))
+	})
+	assert.Error(t, newHTTPTransport(u, srv.Client()).sendPipelineStats(&payload))
+}
+
+func TestHTTPTransportUDS(t *testing.T) {
+	t.Setenv("DD_API_KEY", "")
+	socket := filepath.Join(t.TempDir(), "apm.socket")
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	var hits int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v0.1/pipeline_stats", r.URL.Path)
+		atomic.AddInt32(&hits, 1)
+	}))
+	srv.Listener = l
+	srv.Start()
+	defer srv.Close()
+
+	payload := testPayload
+	require.NoError(t, newHTTPTransport(&url.URL{Scheme: "unix", Path: socket}, nil).sendPipelineStats(&payload))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestHTTPTransportRetry(t *testing.T) {
+	t.Setenv("DD_API_KEY", "")
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	tr := newHTTPTransport(u, srv.Client())
+	var backoffs []time.Duration
+	tr.sleep = func(d time.Duration) { backoffs = append(backoffs, d) }
+	payload := testPayload
+	require.NoError(t, tr.sendPipelineStats(&payload))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+	assert.Equal(t, []time.Duration{retryBackoff, 2 * retryBackoff}, backoffs)
+
+	t.Run("not-retriable", func(t *testing.T) {
+		atomic.StoreInt32(&hits, 0)
+		srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			w.WriteHeader(http.StatusNotFound)
+		})
+		assert.Error(t, tr.sendPipelineStats(&payload))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+	})
+}
+
+func TestHTTPTransportAgentless(t *testing.T) {
+	t.Setenv("DD_API_KEY", "abc")
+	t.Setenv("DD_SITE", "datadoghq.eu")
+	t.Setenv("DD_DATA_STREAMS_AGENTLESS_ENABLED", "true")
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer agent.Close()
+	var got StatsPayload
+	intake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "abc", r.Header.Get("DD-API-KEY"))
+		gr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, msgp.Decode(gr, &got))
+	}))
+	defer intake.Close()
+
+	u, _ := url.Parse(agent.URL)
+	tr := newHTTPTransport(u, agent.Client())
+	assert.Equal(t, "https://trace.agent.datadoghq.eu/api/v0.1/pipeline_stats", tr.agentlessURL)
+	tr.agentlessURL = intake.URL
+	payload := testPayload
+	require.NoError(t, tr.sendPipelineStats(&payload))
+	assert.Equal(t, uint64(3), got.Stats[0].Stats[0].Hash)
+}
+
+func TestHTTPTransportAgentlessDisabled(t *testing.T) {
+	t.Setenv("DD_API_KEY", "abc")
+	u, _ := url.Parse("http://localhost:8126")
+
+	// an API key alone doesn't enable the failover
+	tr := newHTTPTransport(u, nil)
+	assert.Empty(t, tr.agentlessURL)
+	assert.Empty(t, tr.apiKey)
+
+	t.Setenv("DD_DATA_STREAMS_AGENTLESS_ENABLED", "false")
+	assert.Empty(t, newHTTPTransport(u, nil).agentlessURL)
+}
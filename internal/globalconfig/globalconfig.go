@@ -28,6 +28,7 @@ type config struct {
 	serviceName   string
 	runtimeID     string
 	headersAsTags *internal.LockMap
+	dogstatsdAddr string
 }
 
 // AnalyticsRate returns the sampling rate at which events should be marked. It uses
@@ -60,6 +61,22 @@ func SetServiceName(name string) {
 	cfg.serviceName = name
 }
 
+// DogstatsdAddr returns the address of the DogStatsD server used by the tracer,
+// or an empty string if the tracer isn't started.
+func DogstatsdAddr() string {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+	return cfg.dogstatsdAddr
+}
+
+// SetDogstatsdAddr sets the address of the DogStatsD server, so that integrations
+// can send metrics to it.
+func SetDogstatsdAddr(addr string) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	cfg.dogstatsdAddr = addr
+}
+
 // RuntimeID returns this process's unique runtime id.
 func RuntimeID() string {
 	cfg.mu.RLock()
@@ -18,3 +18,10 @@ func TestHeaderTag(t *testing.T) {
 	assert.Equal(t, "tag1", cfg.headersAsTags.Get("header1"))
 	assert.Equal(t, "tag2", cfg.headersAsTags.Get("header2"))
 }
+
+func TestDogstatsdAddr(t *testing.T) {
+	defer SetDogstatsdAddr("")
+	assert.Equal(t, "", DogstatsdAddr())
+	SetDogstatsdAddr("localhost:8125")
+	assert.Equal(t, "localhost:8125", DogstatsdAddr())
+}
@@ -82,3 +82,15 @@ func NewGCPPubsubOutboundOp(opts ...Option) *Schema {
 	newOpts := append([]Option{WithOverrideV0("pubsub.publish")}, opts...)
 	return NewMessagingOutboundOp("gcp.pubsub", newOpts...)
 }
+
+// NewRabbitMQOutboundOp creates a new schema for RabbitMQ (messaging) outbound operations.
+func NewRabbitMQOutboundOp(opts ...Option) *Schema {
+	newOpts := append([]Option{WithOverrideV0("amqp.publish")}, opts...)
+	return NewMessagingOutboundOp("rabbitmq", newOpts...)
+}
+
+// NewRabbitMQInboundOp creates a new schema for RabbitMQ (messaging) inbound operations.
+func NewRabbitMQInboundOp(opts ...Option) *Schema {
+	newOpts := append([]Option{WithOverrideV0("amqp.consume")}, opts...)
+	return NewMessagingInboundOp("rabbitmq", newOpts...)
+}
@@ -54,6 +54,22 @@ func TestOpContribSchemas(t *testing.T) {
 			wantV0: "pubsub.receive",
 			wantV1: "gcp.pubsub.process",
 		},
+		{
+			name: "rabbitmq outbound",
+			newSchema: func() *namingschema.Schema {
+				return namingschema.NewRabbitMQOutboundOp()
+			},
+			wantV0: "amqp.publish",
+			wantV1: "rabbitmq.send",
+		},
+		{
+			name: "rabbitmq inbound",
+			newSchema: func() *namingschema.Schema {
+				return namingschema.NewRabbitMQInboundOp()
+			},
+			wantV0: "amqp.consume",
+			wantV1: "rabbitmq.process",
+		},
 		{
 			name: "messaging outbound override",
 			newSchema: func() *namingschema.Schema {
@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package profiler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+// captureCPUDuration is the maximum duration of the CPU profiles collected by
+// CaptureNow.
+const captureCPUDuration = 10 * time.Second
+
+// CaptureNow immediately collects the given profile types and uploads them as
+// a one-off profile, outside of the regular profiling period. It is intended to
+// be called from admin endpoints or signal handlers, e.g. while investigating an
+// incident. If no types are given, the profile types enabled on the profiler are
+// collected.
+//
+// The CPU profile is recorded for 10 seconds, or for the configured CPU duration
+// if it is shorter, and can't be collected while the regular CPU profile is
+// being recorded. Heap, mutex and block profiles are uploaded as snapshots of
+// the cumulative data since the start of the program, rather than deltas.
+// MetricsProfile and the custom profile types aren't supported.
+//
+// CaptureNow returns once the profile is uploaded. The collection and upload
+// are aborted if ctx is done. It returns an error if the profiler is not
+// started. A dormant profiler, started with WithEnabled(false), can capture
+// profiles.
+func CaptureNow(ctx context.Context, types ...ProfileType) error {
+	mu.Lock()
+	p := activeProfiler
+	mu.Unlock()
+	if p == nil {
+		return errors.New("profiler is not started")
+	}
+	return p.captureNow(ctx, types)
+}
+
+// capturable reports whether profiles of type t can be collected by CaptureNow.
+func capturable(t ProfileType) bool {
+	switch t {
+	case CPUProfile, HeapProfile, BlockProfile, MutexProfile, GoroutineProfile:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *profiler) captureNow(ctx context.Context, types []ProfileType) error {
+	if len(types) == 0 {
+		for _, t := range p.enabledProfileTypes() {
+			if capturable(t) {
+				types = append(types, t)
+			}
+		}
+	}
+	for _, t := range types {
+		if !capturable(t) {
+			return fmt.Errorf("profile type %s can't be captured on demand", t)
+		}
+	}
+	if len(types) == 0 {
+		return errors.New("no profile type to capture")
+	}
+
+	bat := batch{
+		onDemand:  true,
+		host:      p.cfg.hostname,
+		start:     now(),
+		extraTags: []string{"profile_trigger:on_demand"},
+	}
+	var errs []string
+	// The CPU profile comes first, so that the other profiles are collected
+	// at the end of its recording.
+	for _, t := range types {
+		if t != CPUProfile {
+			continue
+		}
+		data, err := p.captureCPUProfile(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", t, err))
+			continue
+		}
+		bat.addProfile(&profile{name: t.Filename(), pt: t, data: data})
+	}
+	for _, t := range types {
+		if t == CPUProfile {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := p.lookupProfile(t.String(), &buf, 0); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", t, err))
+			continue
+		}
+		bat.addProfile(&profile{name: t.Filename(), pt: t, data: buf.Bytes()})
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	bat.end = now()
+	if len(bat.profiles) > 0 {
+		if err := p.doRequestContext(ctx, bat); err != nil {
+			p.cfg.statsd.Count("datadog.profiling.go.upload_error", 1, nil, 1)
+			return fmt.Errorf("uploading profile: %v", err)
+		}
+		p.cfg.statsd.Count("datadog.profiling.go.upload_success", 1, nil, 1)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to capture profiles: %s", strings.Join(errs, "; "))
+	}
+	log.Debug("Uploaded on-demand profile")
+	return nil
+}
+
+// captureCPUProfile records a CPU profile for captureCPUDuration, or the
+// configured CPU duration if it is shorter.
+func (p *profiler) captureCPUProfile(ctx context.Context) ([]byte, error) {
+	d := captureCPUDuration
+	if p.cfg.cpuDuration < d {
+		d = p.cfg.cpuDuration
+	}
+	var buf bytes.Buffer
+	if err := p.startCPUProfile(&buf); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	case <-p.exit:
+	}
+	p.stopCPUProfile()
+	return buf.Bytes(), nil
+}
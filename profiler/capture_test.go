@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package profiler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/httpmem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureNow(t *testing.T) {
+	t.Run("not-started", func(t *testing.T) {
+		assert.Error(t, CaptureNow(context.Background()))
+	})
+
+	got := make(chan profileMeta, 1)
+	server, client := httpmem.ServerAndClient(&mockBackend{t: t, profiles: got})
+	defer server.Close()
+
+	// a dormant profiler only uploads captured profiles
+	require.NoError(t, Start(
+		WithHTTPClient(client),
+		WithProfileTypes(CPUProfile, HeapProfile),
+		CPUDuration(50*time.Millisecond),
+		WithEnabled(false),
+	))
+	defer Stop()
+
+	t.Run("enabled-types", func(t *testing.T) {
+		require.NoError(t, CaptureNow(context.Background()))
+		m := <-got
+		assert.Contains(t, m.attachments, "cpu.pprof")
+		assert.Contains(t, m.attachments, "heap.pprof")
+		assert.NotContains(t, m.attachments, "metrics.json")
+		assert.Contains(t, m.tags, "profile_trigger:on_demand")
+		for _, tag := range m.tags {
+			assert.False(t, strings.HasPrefix(tag, "profile_seq:"), tag)
+		}
+	})
+
+	t.Run("given-types", func(t *testing.T) {
+		require.NoError(t, CaptureNow(context.Background(), GoroutineProfile, MutexProfile))
+		m := <-got
+		assert.Len(t, m.attachments, 2)
+		assert.Contains(t, m.attachments, "goroutines.pprof")
+		assert.Contains(t, m.attachments, "mutex.pprof")
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		assert.Error(t, CaptureNow(context.Background(), HeapProfile, MetricsProfile))
+		assert.Error(t, CaptureNow(context.Background(), executionTrace))
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.Equal(t, context.Canceled, CaptureNow(ctx, HeapProfile))
+	})
+}
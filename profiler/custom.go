@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package profiler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// CollectFunc writes a profile in pprof format (gzip compressed protobuf data,
+// as produced by runtime/pprof) to w.
+type CollectFunc func(w io.Writer) error
+
+// customProfileType is a profile type registered with RegisterProfileType.
+type customProfileType struct {
+	name    string
+	collect CollectFunc
+}
+
+var (
+	// customProfilesMu guards customProfiles
+	customProfilesMu sync.Mutex
+	// customProfiles holds the profile types registered by the application, by name.
+	customProfiles = make(map[string]CollectFunc)
+)
+
+// RegisterProfileType registers a profile type contributed by the application,
+// e.g. to report the usage of a custom memory allocator. Once per profiling
+// period, at its end, the running profiler calls collect and uploads the
+// resulting profile along with the other profiles of the period, as
+// "<name>.pprof".
+//
+// It returns an error if collect is nil, or if name is empty or already used by
+// another profile type. RegisterProfileType may be called before or while the
+// profiler is running.
+func RegisterProfileType(name string, collect CollectFunc) error {
+	if name == "" {
+		return errors.New("profile type name must not be empty")
+	}
+	if collect == nil {
+		return fmt.Errorf("profile type %q: collect function must not be nil", name)
+	}
+	for _, t := range profileTypes {
+		if t.Name == name {
+			return fmt.Errorf("profile type %q is reserved", name)
+		}
+	}
+	customProfilesMu.Lock()
+	defer customProfilesMu.Unlock()
+	if _, ok := customProfiles[name]; ok {
+		return fmt.Errorf("profile type %q is already registered", name)
+	}
+	customProfiles[name] = collect
+	return nil
+}
+
+// UnregisterProfileType removes the profile type registered with the given name,
+// if any. The profile is no longer collected from the next profiling period on.
+func UnregisterProfileType(name string) {
+	customProfilesMu.Lock()
+	defer customProfilesMu.Unlock()
+	delete(customProfiles, name)
+}
+
+// registeredProfileTypes returns the registered custom profile types, ordered
+// by name.
+func registeredProfileTypes() []customProfileType {
+	customProfilesMu.Lock()
+	defer customProfilesMu.Unlock()
+	types := make([]customProfileType, 0, len(customProfiles))
+	for name, collect := range customProfiles {
+		types = append(types, customProfileType{name: name, collect: collect})
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].name < types[j].name })
+	return types
+}
+
+// tag returns the profile_type tag of t.
+func (t customProfileType) tag() string {
+	return fmt.Sprintf("profile_type:%s", t.name)
+}
+
+// runCustomProfile collects the custom profile type t at the end of the
+// profiling period. Panics of the collect function are returned as errors, as
+// it is application code running on the profiler's goroutine.
+func (p *profiler) runCustomProfile(t customProfileType) (prof *profile, err error) {
+	p.interruptibleSleep(p.cfg.period)
+	start := now()
+	var buf bytes.Buffer
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		err = t.collect(&buf)
+	}()
+	if err != nil {
+		return nil, err
+	}
+	tags := append(p.cfg.tags.Slice(), t.tag())
+	p.cfg.statsd.Timing("datadog.profiling.go.collect_time", now().Sub(start), tags, 1)
+	return &profile{name: t.name + ".pprof", pt: customProfile, data: buf.Bytes()}, nil
+}
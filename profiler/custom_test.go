@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package profiler
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/httpmem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterProfileType(t *testing.T) {
+	collect := func(w io.Writer) error { return nil }
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Error(t, RegisterProfileType("", collect))
+		assert.Error(t, RegisterProfileType("custom", nil))
+		assert.Error(t, RegisterProfileType("heap", collect))
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		require.NoError(t, RegisterProfileType("custom", collect))
+		defer UnregisterProfileType("custom")
+		assert.Error(t, RegisterProfileType("custom", collect))
+	})
+
+	t.Run("unregister", func(t *testing.T) {
+		require.NoError(t, RegisterProfileType("b", collect))
+		require.NoError(t, RegisterProfileType("a", collect))
+		types := registeredProfileTypes()
+		require.Len(t, types, 2)
+		assert.Equal(t, "a", types[0].name)
+		assert.Equal(t, "b", types[1].name)
+		UnregisterProfileType("a")
+		UnregisterProfileType("b")
+		assert.Empty(t, registeredProfileTypes())
+	})
+}
+
+func TestCustomProfileUpload(t *testing.T) {
+	got := make(chan profileMeta)
+	server, client := httpmem.ServerAndClient(&mockBackend{t: t, profiles: got})
+	defer server.Close()
+
+	require.NoError(t, RegisterProfileType("arena", func(w io.Writer) error {
+		_, err := w.Write([]byte("arena-data"))
+		return err
+	}))
+	defer UnregisterProfileType("arena")
+	require.NoError(t, RegisterProfileType("broken", func(w io.Writer) error {
+		return errors.New("broken")
+	}))
+	defer UnregisterProfileType("broken")
+	require.NoError(t, RegisterProfileType("panicky", func(w io.Writer) error {
+		panic("oops")
+	}))
+	defer UnregisterProfileType("panicky")
+
+	err := Start(
+		WithHTTPClient(client),
+		WithProfileTypes(),
+		WithPeriod(10*time.Millisecond),
+	)
+	require.NoError(t, err)
+	defer Stop()
+
+	m := <-got
+	assert.Equal(t, []byte("arena-data"), m.attachments["arena.pprof"])
+	assert.NotContains(t, m.attachments, "broken.pprof")
+	assert.NotContains(t, m.attachments, "panicky.pprof")
+}
@@ -106,9 +106,12 @@ type config struct {
 	blockRate            int
 	outputDir            string
 	deltaProfiles        bool
+	deltaRetention       time.Duration
 	logStartup           bool
 	traceConfig          executionTraceConfig
 	endpointCountEnabled bool
+	enabled              bool
+	remoteConfig         bool
 }
 
 // logStartup records the configuration to the configured logger in JSON format
@@ -122,6 +125,7 @@ func logStartup(c *config) {
 		LangVersion          string   `json:"lang_version"` // Go version, e.g. go1.18
 		Hostname             string   `json:"hostname"`
 		DeltaProfiles        bool     `json:"delta_profiles"`
+		DeltaRetention       string   `json:"delta_retention"`
 		Service              string   `json:"service"`
 		Env                  string   `json:"env"`
 		TargetURL            string   `json:"target_url"`
@@ -137,8 +141,11 @@ func logStartup(c *config) {
 		UploadTimeout        string   `json:"upload_timeout"`
 		TraceEnabled         bool     `json:"execution_trace_enabled"`
 		TracePeriod          string   `json:"execution_trace_period"`
+		TraceDuration        string   `json:"execution_trace_duration"`
 		TraceSizeLimit       int      `json:"execution_trace_size_limit"`
 		EndpointCountEnabled bool     `json:"endpoint_count_enabled"`
+		Enabled              bool     `json:"enabled"`
+		RemoteConfigEnabled  bool     `json:"remote_config_enabled"`
 	}{
 		Date:                 time.Now().Format(time.RFC3339),
 		OSName:               osinfo.OSName(),
@@ -148,6 +155,7 @@ func logStartup(c *config) {
 		LangVersion:          runtime.Version(),
 		Hostname:             c.hostname,
 		DeltaProfiles:        c.deltaProfiles,
+		DeltaRetention:       c.deltaRetention.String(),
 		Service:              c.service,
 		Env:                  c.env,
 		TargetURL:            c.targetURL,
@@ -162,8 +170,11 @@ func logStartup(c *config) {
 		UploadTimeout:        c.uploadTimeout.String(),
 		TraceEnabled:         c.traceConfig.Enabled,
 		TracePeriod:          c.traceConfig.Period.String(),
+		TraceDuration:        c.traceConfig.Duration.String(),
 		TraceSizeLimit:       c.traceConfig.Limit,
 		EndpointCountEnabled: c.endpointCountEnabled,
+		Enabled:              c.enabled,
+		RemoteConfigEnabled:  c.remoteConfig,
 	}
 	for t := range c.types {
 		info.EnabledProfiles = append(info.EnabledProfiles, t.String())
@@ -215,8 +226,11 @@ func defaultConfig() (*config, error) {
 		uploadTimeout:        DefaultUploadTimeout,
 		maxGoroutinesWait:    1000, // arbitrary value, should limit STW to ~30ms
 		deltaProfiles:        internal.BoolEnv("DD_PROFILING_DELTA", true),
+		deltaRetention:       internal.DurationEnv("DD_PROFILING_DELTA_RETENTION", 0),
 		logStartup:           internal.BoolEnv("DD_TRACE_STARTUP_LOGS", true),
 		endpointCountEnabled: internal.BoolEnv(traceprof.EndpointCountEnvVar, false),
+		enabled:              true,
+		remoteConfig:         internal.BoolEnv("DD_REMOTE_CONFIGURATION_ENABLED", false),
 	}
 	c.tags = c.tags.Append(fmt.Sprintf("process_id:%d", os.Getpid()))
 	for _, t := range defaultProfileTypes {
@@ -352,6 +366,21 @@ func WithDeltaProfiles(enabled bool) Option {
 	}
 }
 
+// WithDeltaRetention specifies how long the previous snapshot of a cumulative
+// profile (heap, block and mutex) is retained as the baseline of the next delta
+// profile. When the baseline is older than d, e.g. because collecting the
+// profile failed during the previous profiling periods, no delta is uploaded for
+// the current period, which only records a new baseline. This keeps delta
+// profiles from spanning several periods and hiding short-lived spikes. A
+// value of 0, the default, retains the baseline forever. Values shorter than
+// twice the profiling period are raised to twice the period. This option takes
+// precedence over the DD_PROFILING_DELTA_RETENTION environment variable.
+func WithDeltaRetention(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.deltaRetention = d
+	}
+}
+
 // WithURL specifies the HTTP URL for the Datadog Profiling API.
 func WithURL(url string) Option {
 	return func(cfg *config) {
@@ -521,6 +550,29 @@ func WithLogStartup(enabled bool) Option {
 	}
 }
 
+// WithEnabled specifies whether the profiler collects and uploads profiles once
+// started. A profiler started with WithEnabled(false) is dormant: it collects
+// nothing until it is enabled through remote configuration (see
+// WithRemoteConfig). This option is enabled by default.
+func WithEnabled(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.enabled = enabled
+	}
+}
+
+// WithRemoteConfig specifies whether the profiler can be reconfigured through
+// Datadog Remote Configuration. When enabled, the profiler polls the agent for
+// updates allowing to enable or disable it, and to change its period and
+// enabled profile types, without restarting the application. Removing the
+// remote configuration restores the options given to Start. This option takes
+// precedence over the DD_REMOTE_CONFIGURATION_ENABLED environment variable, and
+// is disabled by default.
+func WithRemoteConfig(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.remoteConfig = enabled
+	}
+}
+
 // WithHostname sets the hostname which will be added to uploaded profiles
 // through the "host:<hostname>" tag. If no hostname is given, the hostname will
 // default to the output of os.Hostname()
@@ -530,6 +582,27 @@ func WithHostname(hostname string) Option {
 	}
 }
 
+// WithExecutionTrace enables the collection of runtime execution traces, as
+// produced by the runtime/trace package, and their upload alongside the other
+// profiles. A trace is recorded at most once every period, for up to the given
+// duration, or for a full profiling period if duration is zero. Traces are also
+// stopped early once they reach DD_PROFILING_EXECUTION_TRACE_LIMIT_BYTES (5MB by
+// default). Execution traces have a noticeable overhead, so short durations and
+// long periods are recommended.
+//
+// Execution tracing can also be enabled using the DD_PROFILING_EXECUTION_TRACE_ENABLED,
+// DD_PROFILING_EXECUTION_TRACE_PERIOD and DD_PROFILING_EXECUTION_TRACE_DURATION
+// environment variables, which are ignored when this option is used.
+func WithExecutionTrace(period, duration time.Duration) Option {
+	return func(cfg *config) {
+		cfg.traceConfig.Enabled = true
+		cfg.traceConfig.Period = period
+		cfg.traceConfig.Duration = duration
+		cfg.traceConfig.fromOptions = true
+		cfg.traceConfig.Refresh()
+	}
+}
+
 // executionTraceConfig controls how often, and for how long, runtime execution
 // traces are collected.
 type executionTraceConfig struct {
@@ -537,6 +610,10 @@ type executionTraceConfig struct {
 	Enabled bool
 	// Period is the amount of time between traces.
 	Period time.Duration
+	// Duration is the maximum amount of time a single trace records. If it is
+	// zero, or longer than the profiling period, traces record for a full
+	// profiling period.
+	Duration time.Duration
 	// Limit is the desired upper bound, in bytes, of a collected trace.
 	// Traces may be slightly larger than this limit due to flushing pending
 	// buffers at the end of tracing.
@@ -550,14 +627,22 @@ type executionTraceConfig struct {
 	// warned is checked to prevent spamming a log every minute if the trace
 	// config is invalid
 	warned bool
+
+	// fromOptions reports whether execution tracing was configured using
+	// WithExecutionTrace, in which case the environment variables enabling it
+	// and setting its period and duration are ignored.
+	fromOptions bool
 }
 
 // Refresh updates the execution trace configuration to reflect any run-time
 // changes to the configuration environment variables, applying defaults as
 // needed.
 func (e *executionTraceConfig) Refresh() {
-	e.Enabled = internal.BoolEnv("DD_PROFILING_EXECUTION_TRACE_ENABLED", false)
-	e.Period = internal.DurationEnv("DD_PROFILING_EXECUTION_TRACE_PERIOD", 15*time.Minute)
+	if !e.fromOptions {
+		e.Enabled = internal.BoolEnv("DD_PROFILING_EXECUTION_TRACE_ENABLED", false)
+		e.Period = internal.DurationEnv("DD_PROFILING_EXECUTION_TRACE_PERIOD", 15*time.Minute)
+		e.Duration = internal.DurationEnv("DD_PROFILING_EXECUTION_TRACE_DURATION", 0)
+	}
 	e.Limit = internal.IntEnv("DD_PROFILING_EXECUTION_TRACE_LIMIT_BYTES", defaultExecutionTraceSizeLimit)
 
 	if e.Enabled && (e.Period == 0 || e.Limit == 0) {
@@ -424,3 +424,24 @@ func TestWith_outputDir(t *testing.T) {
 	want := map[string]string{"foo.pprof": "foo", "bar.pprof": "bar"}
 	require.Equal(t, want, fileData)
 }
+
+func TestWithExecutionTrace(t *testing.T) {
+	t.Setenv("DD_PROFILING_EXECUTION_TRACE_PERIOD", "1m")
+	cfg, err := defaultConfig()
+	require.NoError(t, err)
+	WithExecutionTrace(5*time.Minute, 10*time.Second)(cfg)
+	cfg.traceConfig.Refresh()
+	assert.True(t, cfg.traceConfig.Enabled)
+	assert.Equal(t, 5*time.Minute, cfg.traceConfig.Period)
+	assert.Equal(t, 10*time.Second, cfg.traceConfig.Duration)
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("DD_PROFILING_EXECUTION_TRACE_ENABLED", "true")
+		t.Setenv("DD_PROFILING_EXECUTION_TRACE_DURATION", "5s")
+		cfg, err := defaultConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.traceConfig.Enabled)
+		assert.Equal(t, time.Minute, cfg.traceConfig.Period)
+		assert.Equal(t, 5*time.Second, cfg.traceConfig.Duration)
+	})
+}
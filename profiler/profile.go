@@ -54,6 +54,10 @@ const (
 	// This is private, as this trace requires special explicit configuration and
 	// shouldn't just be added to WithProfileTypes
 	executionTrace
+
+	// customProfile identifies the profiles of the types registered with
+	// RegisterProfileType. It can't be enabled with WithProfileTypes.
+	customProfile
 )
 
 // profileType holds the implementation details of a ProfileType.
@@ -194,9 +198,13 @@ var profileTypes = map[ProfileType]profileType{
 			if err := trace.Start(lt); err != nil {
 				return nil, err
 			}
+			duration := p.cfg.period
+			if d := p.cfg.traceConfig.Duration; d > 0 && d < duration {
+				duration = d
+			}
 			select {
 			case <-p.exit: // Profiling was stopped
-			case <-time.After(p.cfg.period): // The profiling cycle has ended
+			case <-time.After(duration): // The profiling cycle or trace duration has ended
 			case <-lt.done: // The trace size limit was exceeded
 			}
 			trace.Stop()
@@ -260,6 +268,9 @@ func collectGenericProfile(name string, pt ProfileType) func(p *profiler) ([]byt
 		delta, err := dp.Delta(data)
 		tags := append(p.cfg.tags.Slice(), fmt.Sprintf("profile_type:%s", name))
 		p.cfg.statsd.Timing("datadog.profiling.go.delta_time", time.Since(start), tags, 1)
+		if err == errDeltaBaselineExpired {
+			return nil, err
+		}
 		if err != nil {
 			return nil, fmt.Errorf("delta profile error: %s", err)
 		}
@@ -311,10 +322,14 @@ type profile struct {
 // to what the Datadog UI calls a profile.
 type batch struct {
 	seq            uint64 // seq is the value of the profile_seq tag
+	onDemand       bool   // onDemand is set for batches collected by CaptureNow, which have no seq
 	start, end     time.Time
 	host           string
 	profiles       []*profile
 	endpointCounts map[string]uint64
+	// runtimeMetrics holds a snapshot of runtime metrics taken at the end of
+	// the profiling period
+	runtimeMetrics map[string]float64
 	// extraTags are tags which might vary depending on which profile types
 	// actually run in a given profiling cycle
 	extraTags []string
@@ -342,16 +357,31 @@ func (p *profiler) runProfile(pt ProfileType) ([]*profile, error) {
 	return []*profile{{name: filename, pt: pt, data: data}}, nil
 }
 
+// errDeltaBaselineExpired is returned by fastDeltaProfiler.Delta when the
+// previous profile, used as the baseline of the delta, was older than the
+// configured retention. The given profile becomes the new baseline and no delta
+// is produced.
+var errDeltaBaselineExpired = errors.New("delta profile baseline expired")
+
 type fastDeltaProfiler struct {
-	dc  *fastdelta.DeltaComputer
-	buf bytes.Buffer
-	gzr gzip.Reader
-	gzw *gzip.Writer
+	dc     *fastdelta.DeltaComputer
+	values []pprofutils.ValueType
+	buf    bytes.Buffer
+	gzr    gzip.Reader
+	gzw    *gzip.Writer
+
+	// retention is the maximum age of the baseline profile. Zero means the
+	// baseline never expires.
+	retention time.Duration
+	// last is the time the baseline profile was recorded.
+	last time.Time
 }
 
-func newFastDeltaProfiler(v ...pprofutils.ValueType) *fastDeltaProfiler {
+func newFastDeltaProfiler(retention time.Duration, v ...pprofutils.ValueType) *fastDeltaProfiler {
 	fd := &fastDeltaProfiler{
-		dc: fastdelta.NewDeltaComputer(v...),
+		dc:        fastdelta.NewDeltaComputer(v...),
+		values:    v,
+		retention: retention,
 	}
 	fd.gzw = gzip.NewWriter(&fd.buf)
 	return fd
@@ -375,9 +405,19 @@ func (fdp *fastDeltaProfiler) Delta(data []byte) (b []byte, err error) {
 	fdp.buf.Reset()
 	fdp.gzw.Reset(&fdp.buf)
 
+	t := now()
+	expired := fdp.retention > 0 && !fdp.last.IsZero() && t.Sub(fdp.last) > fdp.retention
+	if expired {
+		// Start over, so that data becomes the new baseline.
+		fdp.dc = fastdelta.NewDeltaComputer(fdp.values...)
+	}
 	if err = fdp.dc.Delta(data, fdp.gzw); err != nil {
 		return nil, fmt.Errorf("error computing delta: %v", err)
 	}
+	fdp.last = t
+	if expired {
+		return nil, errDeltaBaselineExpired
+	}
 	if err = fdp.gzw.Close(); err != nil {
 		return nil, fmt.Errorf("error flushing gzip writer: %v", err)
 	}
@@ -422,3 +422,36 @@ func requirePprofEqual(t *testing.T, a, b []byte) {
 	require.NoError(t, err)
 	require.Len(t, pprofDiff.Sample, 0)
 }
+
+func TestDeltaRetention(t *testing.T) {
+	values := []pprofutils.ValueType{
+		{Type: "contentions", Unit: "count"},
+		{Type: "delay", Unit: "nanoseconds"},
+	}
+	prof := func(n int) []byte {
+		return textProfile{Text: fmt.Sprintf("contentions/count delay/nanoseconds\nmain %d 1\n", n)}.Protobuf()
+	}
+
+	fdp := newFastDeltaProfiler(time.Minute, values...)
+	_, err := fdp.Delta(prof(1))
+	require.NoError(t, err)
+	delta, err := fdp.Delta(prof(3))
+	require.NoError(t, err)
+	require.Equal(t, "contentions/count delay/nanoseconds\nmain 2 0\n", protobufToText(delta))
+
+	// the baseline has expired, prof(10) becomes the new baseline
+	fdp.last = fdp.last.Add(-2 * time.Minute)
+	_, err = fdp.Delta(prof(10))
+	require.Equal(t, errDeltaBaselineExpired, err)
+	delta, err = fdp.Delta(prof(14))
+	require.NoError(t, err)
+	require.Equal(t, "contentions/count delay/nanoseconds\nmain 4 0\n", protobufToText(delta))
+
+	// without retention, the baseline never expires
+	fdp = newFastDeltaProfiler(0, values...)
+	_, err = fdp.Delta(prof(1))
+	require.NoError(t, err)
+	fdp.last = fdp.last.Add(-24 * time.Hour)
+	_, err = fdp.Delta(prof(3))
+	require.NoError(t, err)
+}
@@ -28,6 +28,10 @@ var (
 	mu             sync.Mutex
 	activeProfiler *profiler
 	containerID    = internal.ContainerID() // replaced in tests
+
+	// startOpts holds the options given to Start, on top of which remote
+	// configuration updates are applied.
+	startOpts []Option
 )
 
 // Start starts the profiler. If the profiler is already running, it will be
@@ -36,31 +40,53 @@ var (
 // It may return an error if an API key is not provided by means of the
 // WithAPIKey option, or if a hostname is not found.
 func Start(opts ...Option) error {
+	stopRemoteConfig()
 	mu.Lock()
 	defer mu.Unlock()
 
 	if activeProfiler != nil {
 		activeProfiler.stop()
+		activeProfiler = nil
 	}
 	p, err := newProfiler(opts...)
 	if err != nil {
 		return err
 	}
+	startOpts = append([]Option(nil), opts...)
+	activate(p)
+	if p.cfg.remoteConfig {
+		startRemoteConfig(p.cfg)
+	}
+	return nil
+}
+
+// activate stops the active profiler, if any, and replaces it with p, which
+// is run unless it is dormant. mu must be held.
+func activate(p *profiler) {
+	if activeProfiler != nil {
+		activeProfiler.stop()
+	}
 	activeProfiler = p
-	activeProfiler.run()
+	if !p.cfg.enabled {
+		log.Info("Profiler is dormant: profiles will not be collected until it is enabled through remote configuration")
+		traceprof.SetProfilerEnabled(false)
+		return
+	}
+	p.run()
 	traceprof.SetProfilerEnabled(true)
-	return nil
 }
 
 // Stop cancels any ongoing profiling or upload operations and returns after
 // everything has been stopped.
 func Stop() {
+	stopRemoteConfig()
 	mu.Lock()
 	if activeProfiler != nil {
 		activeProfiler.stop()
 		activeProfiler = nil
 		traceprof.SetProfilerEnabled(false)
 	}
+	startOpts = nil
 	mu.Unlock()
 }
 
@@ -74,6 +100,7 @@ type profiler struct {
 	stopOnce        sync.Once         // stopOnce ensures the profiler is stopped exactly once.
 	wg              sync.WaitGroup    // wg waits for all goroutines to exit when stopping.
 	met             *metrics          // metric collector state
+	rtm             *runtimeMetrics   // runtime metrics attached to each upload
 	deltas          map[ProfileType]*fastDeltaProfiler
 	seq             uint64         // seq is the value of the profile_seq tag
 	pendingProfiles sync.WaitGroup // signal that profile collection is done, for stopping CPU profiling
@@ -186,6 +213,12 @@ func newProfiler(opts ...Option) (*profiler, error) {
 	if cfg.cpuDuration > cfg.period {
 		cfg.cpuDuration = cfg.period
 	}
+	// A baseline younger than two periods may be discarded whenever a
+	// collection is late, so shorter retentions are raised.
+	if cfg.deltaRetention > 0 && cfg.deltaRetention < 2*cfg.period {
+		log.Warn("profiler.WithDeltaRetention: retention %s is shorter than twice the profiling period, using %s", cfg.deltaRetention, 2*cfg.period)
+		cfg.deltaRetention = 2 * cfg.period
+	}
 	if cfg.logStartup {
 		logStartup(cfg)
 	}
@@ -195,11 +228,12 @@ func newProfiler(opts ...Option) (*profiler, error) {
 		out:    make(chan batch, outChannelSize),
 		exit:   make(chan struct{}),
 		met:    newMetrics(),
+		rtm:    newRuntimeMetrics(),
 		deltas: make(map[ProfileType]*fastDeltaProfiler),
 	}
 	for pt := range cfg.types {
 		if d := profileTypes[pt].DeltaValues; len(d) > 0 {
-			p.deltas[pt] = newFastDeltaProfiler(d...)
+			p.deltas[pt] = newFastDeltaProfiler(cfg.deltaRetention, d...)
 		}
 	}
 	p.uploadFunc = p.upload
@@ -225,6 +259,7 @@ func (p *profiler) run() {
 		tick := time.NewTicker(p.cfg.period)
 		defer tick.Stop()
 		p.met.reset(now()) // collect baseline metrics at profiler start
+		p.rtm.reset()
 		p.collect(tick.C)
 	}()
 	p.wg.Add(1)
@@ -282,11 +317,13 @@ func (p *profiler) collect(ticker <-chan time.Time) {
 		if p.shouldTrace() {
 			profileTypes = append(profileTypes, executionTrace)
 		}
+		customTypes := registeredProfileTypes()
 		for _, t := range profileTypes {
 			if t != CPUProfile {
 				p.pendingProfiles.Add(1)
 			}
 		}
+		p.pendingProfiles.Add(len(customTypes))
 		for _, t := range profileTypes {
 			wg.Add(1)
 			go func(t ProfileType) {
@@ -295,7 +332,9 @@ func (p *profiler) collect(ticker <-chan time.Time) {
 					defer p.pendingProfiles.Done()
 				}
 				profs, err := p.runProfile(t)
-				if err != nil {
+				if err == errDeltaBaselineExpired {
+					log.Debug("Skipping %s profile: %v", t, err)
+				} else if err != nil {
 					log.Error("Error getting %s profile: %v; skipping.", t, err)
 					tags := append(p.cfg.tags.Slice(), t.Tag())
 					p.cfg.statsd.Count("datadog.profiling.go.collect_error", 1, tags, 1)
@@ -305,6 +344,23 @@ func (p *profiler) collect(ticker <-chan time.Time) {
 				completed = append(completed, profs...)
 			}(t)
 		}
+		for _, t := range customTypes {
+			wg.Add(1)
+			go func(t customProfileType) {
+				defer wg.Done()
+				defer p.pendingProfiles.Done()
+				prof, err := p.runCustomProfile(t)
+				if err != nil {
+					log.Error("Error getting %s profile: %v; skipping.", t.name, err)
+					tags := append(p.cfg.tags.Slice(), t.tag())
+					p.cfg.statsd.Count("datadog.profiling.go.collect_error", 1, tags, 1)
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				completed = append(completed, prof)
+			}(t)
+		}
 		wg.Wait()
 		for _, prof := range completed {
 			if prof.pt == executionTrace {
@@ -330,6 +386,11 @@ func (p *profiler) collect(ticker <-chan time.Time) {
 		// Include endpoint hits from tracer in profile `event.json`.
 		// Also reset the counters for the next profile period.
 		bat.endpointCounts = endpointCounter.GetAndReset()
+		// Attach runtime metrics, to correlate the profiles with runtime
+		// pressure, and tag the batch with the runtime configuration.
+		rtmTags, rtmValues := p.rtm.snapshot()
+		bat.runtimeMetrics = rtmValues
+		bat.extraTags = append(bat.extraTags, rtmTags...)
 		// Record the end time of the profile.
 		// This is used by the backend to upscale the endpoint counts if the cpu
 		// duration is less than the profile duration. The formula is:
@@ -117,6 +117,27 @@ func TestStart(t *testing.T) {
 		assert.Contains(t, strings.Join(rl.Logs(), " "), "profiler.WithAgentlessUpload")
 	})
 
+	t.Run("options/DeltaRetention", func(t *testing.T) {
+		rl := &log.RecordLogger{}
+		defer log.UseLogger(rl)()
+
+		p, err := newProfiler(WithPeriod(time.Minute), WithDeltaRetention(5*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Minute, p.cfg.deltaRetention)
+		assert.NotContains(t, strings.Join(rl.Logs(), " "), "profiler.WithDeltaRetention")
+
+		// retentions shorter than two periods are raised, with a warning
+		p, err = newProfiler(WithPeriod(time.Minute), WithDeltaRetention(90*time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, 2*time.Minute, p.cfg.deltaRetention)
+		assert.Contains(t, strings.Join(rl.Logs(), " "), "profiler.WithDeltaRetention")
+
+		// no retention is left unchanged
+		p, err = newProfiler(WithPeriod(time.Minute))
+		require.NoError(t, err)
+		assert.Zero(t, p.cfg.deltaRetention)
+	})
+
 	t.Run("options/BadAPIKey", func(t *testing.T) {
 		err := Start(WithAPIKey("aaaa"), WithAgentlessUpload())
 		defer Stop()
@@ -604,3 +625,29 @@ func TestExecutionTraceEnabledFlag(t *testing.T) {
 		})
 	}
 }
+
+func TestExecutionTraceOption(t *testing.T) {
+	got := make(chan profileMeta)
+	server, client := httpmem.ServerAndClient(&mockBackend{t: t, profiles: got})
+	defer server.Close()
+
+	// the option takes precedence over the environment
+	t.Setenv("DD_PROFILING_EXECUTION_TRACE_ENABLED", "false")
+	err := Start(
+		WithHTTPClient(client),
+		WithProfileTypes(),
+		WithPeriod(1*time.Second),
+		WithExecutionTrace(time.Second, 10*time.Millisecond),
+	)
+	require.NoError(t, err)
+	defer Stop()
+
+	for i := 0; i < 3; i++ {
+		m := <-got
+		if _, ok := m.attachments["go.trace"]; ok {
+			require.Contains(t, m.tags, "_dd.profiler.go_execution_trace_enabled:true")
+			return
+		}
+	}
+	t.Errorf("did not see an execution trace")
+}
@@ -0,0 +1,170 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package profiler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/remoteconfig"
+
+	rc "github.com/DataDog/datadog-agent/pkg/remoteconfig/state"
+)
+
+// rcProductProfiling is the remote configuration product holding the
+// profiler configuration.
+const rcProductProfiling = "PROFILING"
+
+// rcClient is the remote configuration client of the active profiler, or nil if
+// remote configuration is disabled. It is guarded by mu.
+var rcClient *remoteconfig.Client
+
+// remoteConfig is the profiler configuration received through remote
+// configuration. Unset fields leave the options given to Start unchanged.
+type remoteConfig struct {
+	// Enabled enables or disables (makes dormant) the profiler.
+	Enabled *bool `json:"profiling_enabled,omitempty"`
+	// PeriodSeconds is the profiling period, in seconds.
+	PeriodSeconds int `json:"profiling_period_seconds,omitempty"`
+	// ProfileTypes lists the names of the enabled profile types, e.g. "cpu" or "heap".
+	ProfileTypes []string `json:"profile_types,omitempty"`
+}
+
+// options returns the profiler options corresponding to c.
+func (c *remoteConfig) options() ([]Option, error) {
+	var opts []Option
+	if c.Enabled != nil {
+		opts = append(opts, WithEnabled(*c.Enabled))
+	}
+	if c.PeriodSeconds < 0 {
+		return nil, fmt.Errorf("invalid profiling period: %ds", c.PeriodSeconds)
+	}
+	if c.PeriodSeconds > 0 {
+		opts = append(opts, WithPeriod(time.Duration(c.PeriodSeconds)*time.Second))
+	}
+	if c.ProfileTypes != nil {
+		types := make([]ProfileType, 0, len(c.ProfileTypes))
+		for _, name := range c.ProfileTypes {
+			t, ok := profileTypeByName(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown profile type: %q", name)
+			}
+			types = append(types, t)
+		}
+		opts = append(opts, WithProfileTypes(types...))
+	}
+	return opts, nil
+}
+
+// profileTypeByName returns the profile type with the given name, as returned
+// by ProfileType.String. Only profile types which can be enabled with
+// WithProfileTypes are returned.
+func profileTypeByName(name string) (ProfileType, bool) {
+	for t, pt := range profileTypes {
+		if t != executionTrace && pt.Name == name {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// startRemoteConfig starts polling the agent for remote configuration updates
+// of the profiler configured by cfg. mu must be held.
+func startRemoteConfig(cfg *config) {
+	rcCfg := remoteconfig.DefaultClientConfig()
+	if u, err := url.Parse(cfg.agentURL); err == nil {
+		rcCfg.AgentURL = u.Scheme + "://" + u.Host
+	}
+	rcCfg.HTTP = cfg.httpClient
+	rcCfg.ServiceName = cfg.service
+	rcCfg.Env = cfg.env
+	for _, tag := range cfg.tags.Slice() {
+		if v := strings.TrimPrefix(tag, "version:"); v != tag {
+			rcCfg.AppVersion = v
+		}
+	}
+	client, err := remoteconfig.NewClient(rcCfg)
+	if err != nil {
+		log.Error("profiler: Remote config: disabled due to a client creation error: %v", err)
+		return
+	}
+	client.RegisterProduct(rcProductProfiling)
+	client.RegisterCallback(func(updates map[string]remoteconfig.ProductUpdate) map[string]rc.ApplyStatus {
+		return onRemoteConfigUpdate(client, updates)
+	})
+	client.Start()
+	rcClient = client
+}
+
+// stopRemoteConfig stops the remote configuration client, if any. mu must not
+// be held, as the client may be waiting for it to apply an update.
+func stopRemoteConfig() {
+	mu.Lock()
+	client := rcClient
+	rcClient = nil
+	mu.Unlock()
+	if client != nil {
+		client.Stop()
+	}
+}
+
+// onRemoteConfigUpdate applies the profiler configuration updates received by
+// client, by restarting the profiler with the options given to Start,
+// overridden by the received configuration. A removed configuration restores
+// the options given to Start.
+func onRemoteConfigUpdate(client *remoteconfig.Client, updates map[string]remoteconfig.ProductUpdate) map[string]rc.ApplyStatus {
+	u := updates[rcProductProfiling]
+	statuses := make(map[string]rc.ApplyStatus, len(u))
+	for path := range u {
+		statuses[path] = rc.ApplyStatus{State: rc.ApplyStateUnacknowledged}
+	}
+	if len(u) == 0 {
+		return statuses
+	}
+	if len(u) > 1 {
+		log.Error("profiler: Remote config: %d configs received for %s. Expected one at most, returning early", len(u), rcProductProfiling)
+		return statuses
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if client != rcClient {
+		// the profiler was stopped or restarted since this update was received
+		return statuses
+	}
+	for path, raw := range u {
+		var opts []Option
+		err := func() error {
+			if raw == nil {
+				log.Debug("profiler: Remote config: %s was removed, restoring the initial configuration", path)
+				return nil
+			}
+			var c remoteConfig
+			if err := json.Unmarshal(raw, &c); err != nil {
+				return err
+			}
+			var err error
+			opts, err = c.options()
+			return err
+		}()
+		if err == nil {
+			var p *profiler
+			if p, err = newProfiler(append(append([]Option(nil), startOpts...), opts...)...); err == nil {
+				activate(p)
+			}
+		}
+		if err != nil {
+			log.Error("profiler: Remote config: error while processing %s. Configuration won't be applied: %v", path, err)
+			statuses[path] = rc.ApplyStatus{State: rc.ApplyStateError, Error: err.Error()}
+			continue
+		}
+		statuses[path] = rc.ApplyStatus{State: rc.ApplyStateAcknowledged}
+	}
+	return statuses
+}
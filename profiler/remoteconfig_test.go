@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package profiler
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/httpmem"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/remoteconfig"
+
+	rc "github.com/DataDog/datadog-agent/pkg/remoteconfig/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteConfigOptions(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		opts, err := (&remoteConfig{}).options()
+		require.NoError(t, err)
+		assert.Empty(t, opts)
+	})
+
+	t.Run("full", func(t *testing.T) {
+		enabled := false
+		c := remoteConfig{Enabled: &enabled, PeriodSeconds: 30, ProfileTypes: []string{"cpu", "goroutine"}}
+		opts, err := c.options()
+		require.NoError(t, err)
+		cfg, err := defaultConfig()
+		require.NoError(t, err)
+		for _, opt := range opts {
+			opt(cfg)
+		}
+		assert.False(t, cfg.enabled)
+		assert.Equal(t, 30*time.Second, cfg.period)
+		assert.Equal(t, map[ProfileType]struct{}{
+			MetricsProfile:   {},
+			CPUProfile:       {},
+			GoroutineProfile: {},
+		}, cfg.types)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := (&remoteConfig{PeriodSeconds: -1}).options()
+		assert.Error(t, err)
+		_, err = (&remoteConfig{ProfileTypes: []string{"bogus"}}).options()
+		assert.Error(t, err)
+		_, err = (&remoteConfig{ProfileTypes: []string{"execution-trace"}}).options()
+		assert.Error(t, err)
+	})
+}
+
+func TestRemoteConfigUpdate(t *testing.T) {
+	got := make(chan profileMeta)
+	server, client := httpmem.ServerAndClient(&mockBackend{t: t, profiles: got})
+	defer server.Close()
+
+	require.NoError(t, Start(
+		WithHTTPClient(client),
+		WithProfileTypes(),
+		WithPeriod(10*time.Millisecond),
+		WithEnabled(false),
+	))
+	defer Stop()
+
+	// fake a started remote config client
+	rcc, err := remoteconfig.NewClient(remoteconfig.DefaultClientConfig())
+	require.NoError(t, err)
+	mu.Lock()
+	rcClient = rcc
+	dormant := activeProfiler
+	mu.Unlock()
+	defer func() {
+		mu.Lock()
+		rcClient = nil
+		mu.Unlock()
+	}()
+
+	update := func(raw []byte) rc.ApplyStatus {
+		statuses := onRemoteConfigUpdate(rcc, map[string]remoteconfig.ProductUpdate{
+			rcProductProfiling: {"config": raw},
+		})
+		return statuses["config"]
+	}
+
+	select {
+	case <-got:
+		t.Fatal("dormant profiler uploaded a profile")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	status := update([]byte(`{"profiling_enabled": true, "profile_types": ["heap"]}`))
+	assert.Equal(t, rc.ApplyStateAcknowledged, status.State)
+	m := <-got
+	assert.Contains(t, m.attachments, "delta-heap.pprof")
+	mu.Lock()
+	assert.NotSame(t, dormant, activeProfiler)
+	assert.True(t, activeProfiler.cfg.enabled)
+	mu.Unlock()
+
+	status = update([]byte(`{"profile_types": ["bogus"]}`))
+	assert.Equal(t, rc.ApplyStateError, status.State)
+	status = update([]byte(`not json`))
+	assert.Equal(t, rc.ApplyStateError, status.State)
+	mu.Lock()
+	assert.True(t, activeProfiler.cfg.enabled)
+	mu.Unlock()
+
+	// removing the configuration restores the initial, dormant, state
+	status = update(nil)
+	assert.Equal(t, rc.ApplyStateAcknowledged, status.State)
+	mu.Lock()
+	assert.False(t, activeProfiler.cfg.enabled)
+	mu.Unlock()
+
+	// updates received by a stale client are ignored
+	stale, err := remoteconfig.NewClient(remoteconfig.DefaultClientConfig())
+	require.NoError(t, err)
+	statuses := onRemoteConfigUpdate(stale, map[string]remoteconfig.ProductUpdate{
+		rcProductProfiling: {"config": []byte(`{"profiling_enabled": true}`)},
+	})
+	assert.Equal(t, rc.ApplyStateUnacknowledged, statuses["config"].State)
+}
@@ -0,0 +1,141 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package profiler
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	rtmetrics "runtime/metrics"
+)
+
+// Names of the runtime/metrics read by runtimeMetrics. Metrics which are not
+// supported by the running Go version are ignored.
+const (
+	rtmGCCPU        = "/cpu/classes/gc/total:cpu-seconds"
+	rtmTotalCPU     = "/cpu/classes/total:cpu-seconds"
+	rtmGOMEMLIMIT   = "/gc/gomemlimit:bytes"
+	rtmSchedLatency = "/sched/latencies:seconds"
+)
+
+// runtimeMetrics takes snapshots of the runtime metrics which are attached to
+// every upload: GOMAXPROCS and GOMEMLIMIT, which are also added as tags so that
+// profiles can be filtered on them, as well as the fraction of CPU time spent in
+// the GC and the percentiles of the scheduling latency of goroutines over the
+// profiling period.
+type runtimeMetrics struct {
+	samples []rtmetrics.Sample
+
+	// state of the cumulative metrics as of the previous snapshot
+	prevGCCPU, prevTotalCPU float64
+	prevLatencies           []uint64
+}
+
+func newRuntimeMetrics() *runtimeMetrics {
+	r := &runtimeMetrics{}
+	for _, name := range []string{rtmGCCPU, rtmTotalCPU, rtmGOMEMLIMIT, rtmSchedLatency} {
+		r.samples = append(r.samples, rtmetrics.Sample{Name: name})
+	}
+	return r
+}
+
+// reset records the current state of the cumulative metrics, which the next
+// snapshot will be computed against.
+func (r *runtimeMetrics) reset() {
+	r.snapshot()
+}
+
+// snapshot returns the tags and values of the runtime metrics. Values derived
+// from cumulative metrics cover the time elapsed since the previous snapshot.
+func (r *runtimeMetrics) snapshot() (tags []string, values map[string]float64) {
+	rtmetrics.Read(r.samples)
+	procs := runtime.GOMAXPROCS(0)
+	tags = append(tags, fmt.Sprintf("gomaxprocs:%d", procs))
+	values = map[string]float64{"go_gomaxprocs": float64(procs)}
+
+	var gcCPU, totalCPU float64
+	for _, s := range r.samples {
+		switch s.Name {
+		case rtmGCCPU:
+			if s.Value.Kind() == rtmetrics.KindFloat64 {
+				gcCPU = s.Value.Float64()
+			}
+		case rtmTotalCPU:
+			if s.Value.Kind() == rtmetrics.KindFloat64 {
+				totalCPU = s.Value.Float64()
+			}
+		case rtmGOMEMLIMIT:
+			if s.Value.Kind() != rtmetrics.KindUint64 {
+				continue
+			}
+			limit := s.Value.Uint64()
+			if limit < math.MaxInt64 {
+				// math.MaxInt64 means no limit
+				tags = append(tags, fmt.Sprintf("gomemlimit:%d", limit))
+				values["go_gomemlimit_bytes"] = float64(limit)
+			}
+		case rtmSchedLatency:
+			if s.Value.Kind() != rtmetrics.KindFloat64Histogram {
+				continue
+			}
+			// Read reuses the memory of the histogram, so it must be copied.
+			h := s.Value.Float64Histogram()
+			counts := make([]uint64, len(h.Counts))
+			copy(counts, h.Counts)
+			prev := r.prevLatencies
+			r.prevLatencies = append([]uint64(nil), counts...)
+			if len(prev) == len(counts) {
+				for i := range counts {
+					counts[i] -= prev[i]
+				}
+			}
+			for _, p := range []struct {
+				name string
+				q    float64
+			}{{"go_sched_latency_p50_seconds", 0.5}, {"go_sched_latency_p99_seconds", 0.99}} {
+				if v, ok := histogramQuantile(counts, h.Buckets, p.q); ok {
+					values[p.name] = v
+				}
+			}
+		}
+	}
+	if dt := totalCPU - r.prevTotalCPU; dt > 0 {
+		values["go_gc_cpu_fraction"] = (gcCPU - r.prevGCCPU) / dt
+	}
+	r.prevGCCPU, r.prevTotalCPU = gcCPU, totalCPU
+	for k, v := range values {
+		// NaN and infinite values can't be JSON-encoded
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			delete(values, k)
+		}
+	}
+	return tags, values
+}
+
+// histogramQuantile returns an upper bound of the q-quantile of the samples of
+// the histogram with the given counts and bucket boundaries, as returned by
+// runtime/metrics. It reports false if the histogram holds no samples.
+func histogramQuantile(counts []uint64, buckets []float64, q float64) (float64, bool) {
+	var total uint64
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 || len(buckets) != len(counts)+1 {
+		return 0, false
+	}
+	rank := uint64(math.Ceil(q * float64(total)))
+	var seen uint64
+	for i, c := range counts {
+		seen += c
+		if seen >= rank {
+			if upper := buckets[i+1]; !math.IsInf(upper, 1) {
+				return upper, true
+			}
+			return buckets[i], true
+		}
+	}
+	return buckets[len(buckets)-1], true
+}
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package profiler
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/httpmem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogramQuantile(t *testing.T) {
+	buckets := []float64{math.Inf(-1), 1, 2, 3, math.Inf(1)}
+
+	_, ok := histogramQuantile([]uint64{0, 0, 0, 0}, buckets, 0.5)
+	assert.False(t, ok)
+
+	counts := []uint64{0, 5, 4, 1}
+	v, ok := histogramQuantile(counts, buckets, 0.5)
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, v)
+	v, ok = histogramQuantile(counts, buckets, 0.99)
+	assert.True(t, ok)
+	assert.Equal(t, 3.0, v) // lower bound of the unbounded bucket
+	v, ok = histogramQuantile(counts, buckets, 0.1)
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, v)
+}
+
+func TestRuntimeMetricsSnapshot(t *testing.T) {
+	r := newRuntimeMetrics()
+	r.reset()
+	tags, values := r.snapshot()
+	procs := runtime.GOMAXPROCS(0)
+	assert.Contains(t, tags, fmt.Sprintf("gomaxprocs:%d", procs))
+	assert.Equal(t, float64(procs), values["go_gomaxprocs"])
+	for k, v := range values {
+		assert.False(t, math.IsNaN(v) || math.IsInf(v, 0), k)
+	}
+}
+
+func TestRuntimeMetricsUpload(t *testing.T) {
+	got := make(chan profileMeta)
+	server, client := httpmem.ServerAndClient(&mockBackend{t: t, profiles: got})
+	defer server.Close()
+
+	require.NoError(t, Start(
+		WithHTTPClient(client),
+		WithProfileTypes(),
+		WithPeriod(10*time.Millisecond),
+	))
+	defer Stop()
+
+	m := <-got
+	procs := runtime.GOMAXPROCS(0)
+	assert.Contains(t, m.tags, fmt.Sprintf("gomaxprocs:%d", procs))
+	assert.Equal(t, float64(procs), m.event.RuntimeMetrics["go_gomaxprocs"])
+}
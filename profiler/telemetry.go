@@ -28,6 +28,7 @@ func startTelemetry(c *config) {
 	telemetry.GlobalClient.ProductStart(telemetry.NamespaceProfilers,
 		append(configs, []telemetry.Configuration{
 			{Name: "delta_profiles", Value: c.deltaProfiles},
+			{Name: "delta_retention", Value: c.deltaRetention.String()},
 			{Name: "agentless", Value: c.agentless},
 			{Name: "profile_period", Value: c.period.String()},
 			{Name: "cpu_duration", Value: c.cpuDuration.String()},
@@ -44,6 +45,7 @@ func startTelemetry(c *config) {
 			{Name: "upload_timeout", Value: c.uploadTimeout.String()},
 			{Name: "execution_trace_enabled", Value: c.traceConfig.Enabled},
 			{Name: "execution_trace_period", Value: c.traceConfig.Period.String()},
+			{Name: "execution_trace_duration", Value: c.traceConfig.Duration.String()},
 			{Name: "execution_trace_size_limit", Value: c.traceConfig.Limit},
 			{Name: "endpoint_count_enabled", Value: c.endpointCountEnabled},
 		}...))
@@ -71,13 +71,19 @@ func (e retriableError) Error() string { return e.err.Error() }
 // doRequest makes an HTTP POST request to the Datadog Profiling API with the
 // given profile.
 func (p *profiler) doRequest(bat batch) error {
-	tags := append(p.cfg.tags.Slice(),
-		fmt.Sprintf("service:%s", p.cfg.service),
+	return p.doRequestContext(context.Background(), bat)
+}
+
+// doRequestContext is like doRequest, but the request is canceled when ctx is
+// done.
+func (p *profiler) doRequestContext(ctx context.Context, bat batch) error {
+	tags := append(p.cfg.tags.Slice(), fmt.Sprintf("service:%s", p.cfg.service))
+	if !bat.onDemand {
 		// The profile_seq tag can be used to identify the first profile
 		// uploaded by a given runtime-id, identify missing profiles, etc.. See
 		// PROF-5612 (internal) for more details.
-		fmt.Sprintf("profile_seq:%d", bat.seq),
-	)
+		tags = append(tags, fmt.Sprintf("profile_seq:%d", bat.seq))
+	}
 	tags = append(tags, bat.extraTags...)
 	// If the user did not configure an "env" in the client, we should omit
 	// the tag so that the agent has a chance to supply a default tag.
@@ -92,7 +98,7 @@ func (p *profiler) doRequest(bat batch) error {
 	funcExit := make(chan struct{})
 	defer close(funcExit)
 	// uploadTimeout is guaranteed to be >= 0, see newProfiler.
-	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.uploadTimeout)
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.uploadTimeout)
 	go func() {
 		select {
 		case <-p.exit:
@@ -133,13 +139,14 @@ func (p *profiler) doRequest(bat batch) error {
 }
 
 type uploadEvent struct {
-	Start          string            `json:"start"`
-	End            string            `json:"end"`
-	Attachments    []string          `json:"attachments"`
-	Tags           string            `json:"tags_profiler"`
-	Family         string            `json:"family"`
-	Version        string            `json:"version"`
-	EndpointCounts map[string]uint64 `json:"endpoint_counts,omitempty"`
+	Start          string             `json:"start"`
+	End            string             `json:"end"`
+	Attachments    []string           `json:"attachments"`
+	Tags           string             `json:"tags_profiler"`
+	Family         string             `json:"family"`
+	Version        string             `json:"version"`
+	EndpointCounts map[string]uint64  `json:"endpoint_counts,omitempty"`
+	RuntimeMetrics map[string]float64 `json:"runtime_metrics,omitempty"`
 }
 
 // encode encodes the profile as a multipart mime request.
@@ -160,6 +167,7 @@ func encode(bat batch, tags []string) (contentType string, body io.Reader, err e
 		End:            bat.end.Format(time.RFC3339Nano),
 		Tags:           strings.Join(tags, ","),
 		EndpointCounts: bat.endpointCounts,
+		RuntimeMetrics: bat.runtimeMetrics,
 	}
 
 	for _, p := range bat.profiles {